@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/askgitdev/askgit/pkg/actions"
+	. "github.com/askgitdev/askgit/pkg/query"
+	"github.com/spf13/cobra"
+)
+
+var actionCmd = &cobra.Command{
+	Use:   "action",
+	Short: "run the queries of a GitHub Actions step",
+	Long: `Use this command as the entrypoint of a GitHub Actions step (see action.yml).
+It runs the queries of the query input, against the repository checked out in the workspace, and writes
+their results to the job summary (if the summary input is true) and as a comment on the pull request
+that triggered the workflow (if the comment input is true). The comment of a previous run with the same title
+is updated rather than another added. A query starting with a line comment is titled by it.
+
+The step sets the following outputs:
+  results  the results of every query, as a JSON array of {columns, types, rows}
+  rows     the number of rows returned by the last query
+  value    the first column of the first row returned by the last query`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var ctx = context.Background()
+
+		var statements = Split(actions.Input("query"))
+		if len(statements) == 0 {
+			log.Fatal("the query input is required")
+		}
+
+		summary, err := actions.BoolInput("summary", true)
+		if err != nil {
+			log.Fatal(err)
+		}
+		comment, err := actions.BoolInput("comment", false)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		sections, err := runSections(ctx, statements)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		markdown, err := actions.Markdown(actions.Input("title"), sections)
+		if err != nil {
+			log.Fatalf("failed to render results: %v", err)
+		}
+
+		if summary {
+			if ok, err := actions.AppendSummary(markdown); err != nil {
+				log.Fatalf("failed to write job summary: %v", err)
+			} else if !ok {
+				log.Print("the runner doesn't support job summaries, skipping")
+			}
+		}
+
+		if comment {
+			var id = actions.Input("title")
+			if id == "" {
+				id = "action"
+			}
+			if err = commentResults(ctx, id, markdown); err != nil {
+				log.Fatalf("failed to comment on the pull request: %v", err)
+			}
+		}
+
+		var results = make([]*Result, len(sections))
+		for i, section := range sections {
+			results[i] = section.Result
+		}
+		encoded, err := json.Marshal(results)
+		if err != nil {
+			log.Fatalf("failed to encode results: %v", err)
+		}
+
+		var last = results[len(results)-1]
+		var value string
+		if len(last.Rows) > 0 && len(last.Rows[0]) > 0 && last.Rows[0][0] != nil {
+			value = fmt.Sprint(last.Rows[0][0])
+		}
+
+		for _, output := range []struct{ name, value string }{
+			{"results", string(encoded)},
+			{"rows", strconv.Itoa(len(last.Rows))},
+			{"value", value},
+		} {
+			if err = actions.SetOutput(output.name, output.value); err != nil {
+				log.Fatalf("failed to set output %s: %v", output.name, err)
+			}
+		}
+	},
+}
+
+// runSections runs every statement against a new database, in order
+func runSections(ctx context.Context, statements []string) ([]*actions.Section, error) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database connection: %v", err)
+	}
+	defer db.Close()
+
+	var sections = make([]*actions.Section, 0, len(statements))
+	for _, stmt := range statements {
+		result, err := Run(ctx, db, stmt)
+		if err != nil {
+			return nil, fmt.Errorf("query execution failed: %v\n%s", err, stmt)
+		}
+		sections = append(sections, &actions.Section{Query: stmt, Result: result})
+	}
+	return sections, nil
+}
+
+// commentResults comments markdown on the pull request that triggered the workflow, updating
+// the comment of a previous run of the step with the same id
+func commentResults(ctx context.Context, id, markdown string) error {
+	repo, number, ok, err := actions.PullRequest()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		log.Print("the workflow wasn't triggered by a pull request, skipping the comment")
+		return nil
+	}
+
+	rt, err := apiTransport()
+	if err != nil {
+		return err
+	}
+	var client = &actions.Client{HTTP: &http.Client{Transport: rt}, Token: githubToken}
+	return client.StickyComment(ctx, repo, number, id, markdown)
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/askgitdev/askgit/pkg/display"
+	"github.com/askgitdev/askgit/pkg/llm"
+	"github.com/spf13/cobra"
+)
+
+var llmEndpoint string // chat completions endpoint used to generate queries
+var llmModel string    // name of the model to use
+var askYes bool        // execute the generated query without asking for confirmation
+
+func init() {
+	askCmd.Flags().StringVar(&llmEndpoint, "endpoint", envOr("ASKGIT_LLM_ENDPOINT", "https://api.openai.com/v1/chat/completions"), "OpenAI-compatible chat completions endpoint (defaults to $ASKGIT_LLM_ENDPOINT)")
+	askCmd.Flags().StringVar(&llmModel, "model", envOr("ASKGIT_LLM_MODEL", "gpt-4o-mini"), "model used to generate the query (defaults to $ASKGIT_LLM_MODEL)")
+	askCmd.Flags().BoolVarP(&askYes, "yes", "y", false, "execute the generated query without asking for confirmation")
+	askCmd.Flags().StringVarP(&format, "format", "f", "table", "specify the output format. Options are 'csv' 'tsv' 'table' 'single' and 'json'")
+	addDisplayFlags(askCmd)
+	addLintFlag(askCmd)
+	askCmd.Flags().StringVarP(&repo, "repo", "r", ".", "specify a path to a default repo on disk. This will be used if no repo is supplied as an argument to a git table")
+}
+
+const askSystemPrompt = `You translate questions about git repositories and GitHub into a single SQLite query.
+Only use the tables and table-valued functions listed below. Arguments of table-valued functions are passed
+positionally, for instance: SELECT * FROM github_stargazers('owner/name').
+Reply with the SQL query only, without any explanation.
+
+Available tables:
+%s`
+
+var askCmd = &cobra.Command{
+	Use:   `ask "which contributors were most active last quarter?"`,
+	Short: "generate and run a query from a question in natural language",
+	Long: `Use this command to have a language model write the SQL for a question.
+The generated query is shown before it's executed, and is only run once confirmed (or if --yes is passed).
+The API key for the endpoint is read from $ASKGIT_LLM_API_KEY.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		var ctx = context.Background()
+
+		var db *sql.DB
+		if db, err = sql.Open("sqlite3", ":memory:"); err != nil {
+			log.Fatalf("failed to initialize database connection: %v", err)
+		}
+		defer db.Close()
+
+		var schema string
+		if schema, err = describeSchema(ctx, db); err != nil {
+			log.Fatalf("failed to describe tables: %v", err)
+		}
+
+		var rt http.RoundTripper
+		if rt, err = apiTransport(); err != nil {
+			log.Fatalf("failed to configure API client: %v", err)
+		}
+
+		var client = &llm.Client{
+			Endpoint:   llmEndpoint,
+			Model:      llmModel,
+			APIKey:     os.Getenv("ASKGIT_LLM_API_KEY"),
+			HTTPClient: &http.Client{Transport: rt},
+		}
+
+		var reply string
+		if reply, err = client.Complete(ctx, fmt.Sprintf(askSystemPrompt, schema), args[0]); err != nil {
+			log.Fatalf("failed to generate query: %v", err)
+		}
+
+		var query = llm.ExtractSQL(reply)
+		fmt.Fprintf(os.Stderr, "%s\n\n", query)
+		lintQuery(query)
+
+		if !askYes && !confirm("run this query?") {
+			return
+		}
+
+		var opts display.Options
+		if opts, err = tableOptions(true); err != nil {
+			log.Fatal(err)
+		}
+
+		var rows *sql.Rows
+		if rows, err = db.Query(query); err != nil {
+			log.Fatalf("query execution failed: %v", err)
+		}
+		defer rows.Close()
+
+		if err = display.WriteToWithOptions(rows, os.Stdout, format, false, opts); err != nil {
+			log.Fatalf("failed to output resultset: %v", err)
+		}
+	},
+}
+
+// confirm asks the user a yes / no question on stderr and reads the answer from stdin
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// envOr returns the value of the environment variable key, or def if it's unset or empty
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/askgitdev/askgit/pkg/audit"
+	"github.com/askgitdev/askgit/pkg/auth"
+)
+
+var auditLog string // path of the file queries are audited to
+
+// openAuditLog returns a logger appending to the audit log file at path ("-" for stderr),
+// or nil if path is empty. The returned function must be called once the logger is no longer used.
+func openAuditLog(path string) (*audit.Logger, func()) {
+	if path == "" {
+		return nil, func() {}
+	}
+
+	var w io.Writer = os.Stderr
+	var closeFn = func() {}
+	if path != "-" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			log.Fatalf("failed to open audit log: %v", err)
+		}
+		w, closeFn = f, func() { _ = f.Close() }
+	}
+
+	var logger = audit.NewLogger(w)
+	logger.Principal = func(ctx context.Context) string {
+		if key, _, ok := auth.FromContext(ctx); ok {
+			return fmt.Sprintf("%s (%s)", key.Name, key.ID)
+		}
+		return ""
+	}
+	return logger, closeFn
+}
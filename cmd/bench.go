@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/bench"
+	"github.com/spf13/cobra"
+)
+
+var benchSuite string      // suite of queries benchmarked
+var benchOrg string        // organization the github suite is run against
+var benchRepository string // repository the github suite is run against, as owner/name
+var benchRuns int          // times every query is run
+var benchLabel string      // version or variant the results are labelled with
+var benchBaseline string   // JSON report the results are compared with
+
+func init() {
+	benchCmd.Flags().StringVar(&benchSuite, "suite", "git", "suite of queries to run: "+strings.Join(bench.Names(), ", "))
+	_ = benchCmd.RegisterFlagCompletionFunc("suite", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return bench.Names(), cobra.ShellCompDirectiveNoFileComp
+	})
+	benchCmd.Flags().StringVarP(&repo, "repo", "r", ".", "path to (or URL of) the repository the git suite is run against")
+	benchCmd.Flags().StringVar(&benchOrg, "org", "", "organization the github suite is run against")
+	benchCmd.Flags().StringVar(&benchRepository, "repository", "", "repository the github suite is run against, as owner/name")
+	benchCmd.Flags().IntVar(&benchRuns, "runs", 3, "times every query is run, the median wall time being reported")
+	benchCmd.Flags().StringVar(&benchLabel, "label", "", "version or variant of askgit the results are labelled with, e.g. v0.5.0 or prefetch")
+	benchCmd.Flags().StringVar(&benchBaseline, "baseline", "", "JSON report of a previous run (see --format json) to compare the results with")
+	benchCmd.Flags().StringVarP(&format, "format", "f", "table", "output format of the report: 'table' or 'json', to be used as a --baseline later on")
+}
+
+var benchCmd = &cobra.Command{
+	Use:   "bench --suite git|github",
+	Short: "benchmark a standard set of queries",
+	Long: `Use this command to run a standard suite of queries against a repository (--suite git, with --repo)
+or a GitHub organization and repository (--suite github, with --org and --repository), reporting the median wall time,
+API calls and rows per second of every query. Reports written with --format json can be compared with later runs,
+of another version of askgit or with other flags (such as --github-prefetch), through --baseline.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var ctx = context.Background()
+
+		suite, ok := bench.Suites[benchSuite]
+		if !ok {
+			log.Fatalf("unknown suite %q, expected one of %s", benchSuite, strings.Join(bench.Names(), ", "))
+		}
+		if format != "table" && format != "json" {
+			log.Fatalf("invalid --format %q, expected table or json", format)
+		}
+
+		var values = map[string]string{"repo": repo}
+		if benchSuite == "github" {
+			values = map[string]string{"org": benchOrg, "repo": benchRepository}
+		}
+
+		var baseline *bench.Report
+		if benchBaseline != "" {
+			f, err := os.Open(benchBaseline)
+			if err != nil {
+				log.Fatalf("failed to open the baseline: %v", err)
+			}
+			baseline, err = bench.ReadReport(f)
+			f.Close()
+			if err != nil {
+				log.Fatalf("failed to read the baseline %s: %v", benchBaseline, err)
+			}
+			if baseline.Suite != benchSuite {
+				log.Fatalf("the baseline is a report of the %s suite, not %s", baseline.Suite, benchSuite)
+			}
+		}
+
+		db, err := sql.Open("sqlite3", ":memory:")
+		if err != nil {
+			log.Fatalf("failed to initialize database connection: %v", err)
+		}
+		defer db.Close()
+
+		var report = &bench.Report{Suite: benchSuite, Label: benchLabel, Params: values, Started: time.Now().UTC()}
+		if report.Results, err = suite.Run(ctx, db, values, benchRuns, githubCalls.Count); err != nil {
+			log.Fatalf("benchmark failed: %v", err)
+		}
+		if baseline != nil {
+			report.Compare(baseline)
+		}
+
+		if format == "json" {
+			err = report.WriteJSON(os.Stdout)
+		} else {
+			if baseline != nil {
+				fmt.Printf("compared with %s (%s)\n\n", benchBaseline, baseline.Label)
+			}
+			err = report.WriteTable(os.Stdout)
+		}
+		if err != nil {
+			log.Fatalf("failed to write the report: %v", err)
+		}
+	},
+}
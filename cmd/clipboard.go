@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// clipboardCommands are the commands text is put on the system clipboard with, by platform, the first available is used
+var clipboardCommands = map[string][][]string{
+	"darwin":  {{"pbcopy"}},
+	"windows": {{"clip"}},
+	"linux": {
+		{"wl-copy"},
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+	},
+}
+
+// copyToClipboard puts the text on the system clipboard
+func copyToClipboard(text []byte) error {
+	var commands = clipboardCommands[runtime.GOOS]
+	if runtime.GOOS != "darwin" && runtime.GOOS != "windows" {
+		commands = clipboardCommands["linux"]
+	}
+
+	for _, command := range commands {
+		// wl-copy only works in a Wayland session, and the others in an X one
+		if command[0] == "wl-copy" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			continue
+		}
+		path, err := exec.LookPath(command[0])
+		if err != nil {
+			continue
+		}
+
+		var cmd = exec.Command(path, command[1:]...)
+		cmd.Stdin = bytes.NewReader(text)
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+	return errors.New("no clipboard command found (pbcopy, clip, wl-copy, xclip or xsel)")
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/askgitdev/askgit/pkg/actions"
+	. "github.com/askgitdev/askgit/pkg/query"
+	"github.com/spf13/cobra"
+)
+
+var commentPR int            // number of the pull request commented on
+var commentQuery string      // file the queries are read from
+var commentRepository string // repository of the pull request, as owner/name
+var commentID string         // identifies the sticky comment updated on every run
+var commentTitle string      // heading of the comment
+
+func init() {
+	commentCmd.Flags().IntVar(&commentPR, "pr", 0, "number of the pull request to comment on")
+	commentCmd.Flags().StringVar(&commentQuery, "query", "", "file of the queries whose results are commented, separated by semicolons")
+	commentCmd.Flags().StringVar(&commentRepository, "repository", os.Getenv("GITHUB_REPOSITORY"), "repository of the pull request, as owner/name (defaults to $GITHUB_REPOSITORY)")
+	commentCmd.Flags().StringVar(&commentID, "id", "", "identifies the comment updated on every run, so that several can be kept on a pull request (defaults to the name of the query file)")
+	commentCmd.Flags().StringVar(&commentTitle, "title", "", "heading of the comment")
+	commentCmd.Flags().StringVarP(&repo, "repo", "r", ".", "specify a path to a default repo on disk. This will be used if no repo is supplied as an argument to a git table")
+	_ = commentCmd.MarkFlagRequired("pr")
+	_ = commentCmd.MarkFlagRequired("query")
+}
+
+var commentCmd = &cobra.Command{
+	Use:   "comment --pr <n> --query file.sql",
+	Short: "comment the results of queries on a pull request",
+	Long: `Use this command to render the results of the queries in a file as Markdown tables, and comment them on a pull request,
+to surface metrics (such as size, coverage delta or ownership) on every pull request. The comment is sticky:
+later runs with the same --id update it rather than adding another. A query starting with a line comment is titled by it.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var ctx = context.Background()
+
+		if !strings.Contains(commentRepository, "/") {
+			log.Fatalf("invalid repository %q, expected owner/name", commentRepository)
+		}
+
+		b, err := ioutil.ReadFile(commentQuery)
+		if err != nil {
+			log.Fatalf("failed to read queries: %v", err)
+		}
+		var statements = Split(string(b))
+		if len(statements) == 0 {
+			log.Fatalf("no queries in %s", commentQuery)
+		}
+
+		sections, err := runSections(ctx, statements)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		markdown, err := actions.Markdown(commentTitle, sections)
+		if err != nil {
+			log.Fatalf("failed to render results: %v", err)
+		}
+
+		var id = commentID
+		if id == "" {
+			id = strings.TrimSuffix(filepath.Base(commentQuery), filepath.Ext(commentQuery))
+		}
+
+		rt, err := apiTransport()
+		if err != nil {
+			log.Fatalf("failed to configure API client: %v", err)
+		}
+		var client = &actions.Client{HTTP: &http.Client{Transport: rt}, Token: githubToken}
+		if err = client.StickyComment(ctx, commentRepository, commentPR, id, markdown); err != nil {
+			log.Fatalf("failed to comment on the pull request: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "commented on %s#%d\n", commentRepository, commentPR)
+	},
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/askgitdev/askgit/pkg/materialize"
+	"github.com/askgitdev/askgit/pkg/query"
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "generate the autocompletion script of askgit for a shell",
+	Long: `Use this command to generate the script completing the commands and flags of askgit in a shell,
+along with the names of the tables in queries, of the preset queries (see --preset) and of the tables synced by askgit sync.
+
+  bash:       source <(askgit completion bash)
+              or, for every session: askgit completion bash > /etc/bash_completion.d/askgit
+  zsh:        askgit completion zsh > "${fpath[1]}/_askgit" (with compinit enabled)
+  fish:       askgit completion fish > ~/.config/fish/completions/askgit.fish
+  powershell: askgit completion powershell | Out-String | Invoke-Expression`,
+	Args:                  cobra.ExactValidArgs(1),
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	DisableFlagsInUseLine: true,
+	// generating the script needs none of the tables
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		switch args[0] {
+		case "bash":
+			err = rootCmd.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			err = rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			err = rootCmd.GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			err = rootCmd.GenPowerShellCompletionWithDesc(os.Stdout)
+		}
+		if err != nil {
+			log.Fatalf("failed to generate the completion script: %v", err)
+		}
+	},
+}
+
+// completeQuery completes the last word of a query with the names of the tables it's the start of
+func completeQuery(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var i = strings.LastIndexAny(toComplete, " \t\n,()") + 1
+	var prefix, word = toComplete[:i], strings.ToLower(toComplete[i:])
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	defer db.Close()
+
+	tables, err := listTables(context.Background(), db)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var completions []string
+	for _, table := range tables {
+		if strings.HasPrefix(table, word) {
+			completions = append(completions, prefix+table)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePresets completes the names of the preset queries
+func completePresets(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return query.Names(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeFormats completes the output formats
+func completeFormats(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return []string{"csv", "tsv", "table", "single", "json", "markdown", "xlsx"}, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePager completes the values of --pager
+func completePager(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return []string{"auto", "always", "never"}, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSyncTables completes the names of the tables of the sync configuration
+func completeSyncTables(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	config, err := materialize.LoadConfig(syncConfig)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var names []string
+	for _, t := range config.Tables {
+		if strings.HasPrefix(t.Name, toComplete) {
+			names = append(names, t.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
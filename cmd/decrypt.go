@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/askgitdev/askgit/pkg/seal"
+	"github.com/spf13/cobra"
+)
+
+var decryptCmd = &cobra.Command{
+	Use:   "decrypt [encrypted file] [output file]",
+	Short: "decrypt a database exported with --encryption-key-source",
+	Long: `Use this command to decrypt a file encrypted by askgit, such as a database exported with --encryption-key-source,
+using the key from the same --encryption-key-source.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if encryptionKey == nil {
+			log.Fatal("please supply the key the file was encrypted with, with --encryption-key-source or $ASKGIT_ENCRYPTION_KEY")
+		}
+
+		if err := seal.OpenFile(encryptionKey, args[0], args[1]); err != nil {
+			log.Fatalf("failed to decrypt file: %v", err)
+		}
+	},
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/askgitdev/askgit/pkg/display"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var displayOpts display.Options // how results are rendered as tables
+var colorMode string            // when tables are colored
+
+// addDisplayFlags adds the flags tuning how results are rendered as tables to a command printing them
+func addDisplayFlags(c *cobra.Command) {
+	c.Flags().IntVar(&displayOpts.MaxColumnWidth, "max-column-width", 0, "cap the width of table columns, wrapping longer values (or cutting them short with --truncate), 0 for no cap")
+	c.Flags().BoolVar(&displayOpts.Truncate, "truncate", false, "keep the values of table columns to their first line, cut short at --max-column-width")
+	c.Flags().StringVar(&displayOpts.NullString, "null-string", "NULL", "string shown in tables for NULL values")
+	c.Flags().BoolVar(&displayOpts.Humanize, "humanize", false, "format numbers in tables with thousands separators, and timestamps relative to now (e.g. 3 days ago), leaving the other formats raw")
+	c.Flags().StringVar(&displayOpts.Locale, "locale", display.Locale(), "locale numbers are formatted in with --humanize, e.g. de_DE (defaults to $LC_ALL, $LC_NUMERIC or $LANG)")
+	c.Flags().StringVar(&colorMode, "color", "auto", "color the header of tables (and dim NULL values): 'auto' when printing to a terminal (and $NO_COLOR isn't set), 'always' or 'never'")
+	_ = c.RegisterFlagCompletionFunc("color", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"auto", "always", "never"}, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// tableOptions returns the options results are rendered as tables with, colored as --color says
+// for output to stdout if toStdout is set, or to a file
+func tableOptions(toStdout bool) (display.Options, error) {
+	var opts = displayOpts
+	switch colorMode {
+	case "always":
+		opts.Color = true
+	case "never":
+		opts.Color = false
+	case "auto":
+		_, noColor := os.LookupEnv("NO_COLOR")
+		opts.Color = toStdout && !noColor && term.IsTerminal(int(os.Stdout.Fd()))
+	default:
+		return opts, fmt.Errorf("invalid --color %q, expected auto, always or never", colorMode)
+	}
+	return opts, nil
+}
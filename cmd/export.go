@@ -1,16 +1,31 @@
 package cmd
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
 	"path/filepath"
 
+	"github.com/askgitdev/askgit/pkg/manifest"
+	"github.com/askgitdev/askgit/pkg/materialize"
+	"github.com/askgitdev/askgit/pkg/redact"
+	"github.com/askgitdev/askgit/pkg/seal"
+	"github.com/askgitdev/askgit/pkg/transform"
+
 	"github.com/spf13/cobra"
 )
 
 var (
-	exports []string
+	exports         []string
+	redactions      []string // kinds of personal data redacted from exported tables
+	redactSalt      string   // key of the hashes redacted data is replaced with
+	transformSpecs  []string // expressions applied to the columns of exported tables
+	manifestPath    string   // path the manifest of the exported dataset is written to
+	exportResume    bool     // continue the tables partially written by an interrupted export
+	exportChunkSize int      // rows written between checkpoints
 )
 
 type export struct {
@@ -20,11 +35,23 @@ type export struct {
 
 func init() {
 	exportCmd.Flags().StringArrayVarP(&exports, "exports", "e", []string{}, "queries to export, supplied as string pairs")
+	exportCmd.Flags().BoolVar(&exportResume, "resume", false, "continue an interrupted export from its last checkpoint, skipping the tables already exported")
+	exportCmd.Flags().IntVar(&exportChunkSize, "chunk-size", 10000, "number of rows written between checkpoints")
+	exportCmd.Flags().StringArrayVar(&transformSpecs, "transform", []string{}, "set a column of an exported table to a SQL expression, evaluated on every row, e.g. commits.author_email=lower(author_email)")
+	exportCmd.Flags().StringVar(&manifestPath, "manifest", "", "write a manifest of the export (row counts, column schemas, time ranges and checksums) to this path, relative to the database's directory")
+	exportCmd.Flags().Lookup("manifest").NoOptDefVal = "manifest.json"
+	exportCmd.Flags().StringSliceVar(&redactions, "redact", []string{}, "redact personal data from exported tables, given as kind[:action] with kinds emails, names and ips, and actions hash (default) or drop")
+	exportCmd.Flags().StringVar(&redactSalt, "redact-salt", os.Getenv("ASKGIT_REDACT_SALT"), "secret key of the hashes redacted data is replaced with, so they can't be reversed by hashing known emails (defaults to $ASKGIT_REDACT_SALT)")
 }
 
 var exportCmd = &cobra.Command{
-	Use:  "export [sqlite db file]",
-	Long: `Use this command to export queries into a SQLite database file on disk`,
+	Use: "export [sqlite db file]",
+	Long: `Use this command to export queries into a SQLite database file on disk.
+With --transform, columns are set to SQL expressions (which can call the Starlark functions loaded with --udf), before any redaction.
+With --redact, emails, names and IP addresses are hashed or dropped from the exported tables, so they can be shared.
+With --encryption-key-source, the database file is encrypted, and can be read back with 'askgit decrypt'.
+Rows are written in chunks, and an interrupted export can be continued from its last checkpoint with --resume.
+With --manifest, a JSON manifest describing the exported tables and the checksum of the database file is written alongside it.`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		var err error
@@ -46,22 +73,120 @@ var exportCmd = &cobra.Command{
 			}
 		}
 
+		transforms, err := transform.ParseAll(transformSpecs)
+		if err != nil {
+			log.Fatalf("invalid transform: %v", err)
+		}
+
+		redactor, err := redact.Parse(redactions, redactSalt)
+		if err != nil {
+			log.Fatalf("invalid redaction: %v", err)
+		}
+		if redactor.Enabled() && redactSalt == "" {
+			log.Printf("warning: redacted data is hashed without --redact-salt, hashes of known emails or logins can be matched")
+		}
+
 		var fileName string
 		if fileName, err = filepath.Abs(args[0]); err != nil {
 			log.Fatalf("failed to resolve file path: %v", err)
 		}
 
-		var db *sql.DB
-		if db, err = sql.Open("sqlite3", fileName); err != nil {
-			log.Fatalf("failed to open sqlite database: %v", err)
+		var m = manifest.New()
+
+		// an encrypted database is decrypted to a temporary file next to it, and encrypted back once written,
+		// even if the export failed, so that it can be resumed and no decrypted copy is left behind
+		var dbFile = fileName
+		if encryptionKey != nil {
+			dbFile = decryptExport(fileName)
 		}
 
-		for _, pair := range pairs {
-			var query = fmt.Sprintf("CREATE TABLE %s AS %s", pair.table, pair.query)
-			if _, err = db.Exec(query); err != nil {
-				log.Fatalf("failed to execute query: %v", err)
+		err = writeExport(dbFile, pairs, transforms, redactor, m)
+
+		if encryptionKey != nil {
+			if sealErr := seal.SealFile(encryptionKey, dbFile, fileName); sealErr != nil {
+				log.Printf("failed to encrypt sqlite database, the decrypted database is left at %s: %v", dbFile, sealErr)
+			} else {
+				_ = os.Remove(dbFile)
 			}
 		}
 
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// the checksum is that of the file as written, encrypted or not
+		if manifestPath != "" {
+			var dir = filepath.Dir(fileName)
+			if !filepath.IsAbs(manifestPath) {
+				manifestPath = filepath.Join(dir, manifestPath)
+			}
+			if err = m.AddFile(dir, fileName); err != nil {
+				log.Fatalf("failed to checksum sqlite database: %v", err)
+			}
+			if err = m.Write(manifestPath); err != nil {
+				log.Fatalf("failed to write manifest: %v", err)
+			}
+		}
 	},
 }
+
+// writeExport writes the export pairs to the database at dbFile, and describes them in m if --manifest is used
+func writeExport(dbFile string, pairs []export, transforms []*transform.Transform, redactor *redact.Redactor, m *manifest.Manifest) error {
+	db, err := sql.Open("sqlite3", dbFile)
+	if err != nil {
+		return fmt.Errorf("failed to open sqlite database: %v", err)
+	}
+	defer db.Close()
+
+	// transforms and redaction are applied once all the rows of a table are written
+	var finish = func(ctx context.Context, db *sql.DB, table string) error {
+		if err := transform.Apply(ctx, db, table, transforms); err != nil {
+			return err
+		}
+		if err := redactor.Table(ctx, db, table); err != nil {
+			return fmt.Errorf("failed to redact table %s: %v", table, err)
+		}
+		return nil
+	}
+
+	var opts = materialize.ExportOptions{ChunkSize: exportChunkSize, Resume: exportResume, Finish: finish}
+	for _, pair := range pairs {
+		n, done, err := materialize.ExportTable(context.Background(), db, pair.table, pair.query, opts)
+		if err != nil {
+			return fmt.Errorf("failed to export table %s: %v", pair.table, err)
+		}
+		if done {
+			log.Printf("%s: already exported, skipped", pair.table)
+		} else if exportResume {
+			log.Printf("%s: %d rows exported", pair.table, n)
+		}
+
+		if manifestPath != "" {
+			if err = m.AddTable(context.Background(), db, pair.table); err != nil {
+				return fmt.Errorf("failed to describe table %s: %v", pair.table, err)
+			}
+		}
+	}
+
+	return db.Close()
+}
+
+// decryptExport returns the path of a temporary file holding the decrypted contents of the encrypted database
+// at fileName, so that more tables can be exported into it. The file is empty if there's no database yet.
+func decryptExport(fileName string) string {
+	f, err := ioutil.TempFile(filepath.Dir(fileName), "."+filepath.Base(fileName)+".*")
+	if err != nil {
+		log.Fatalf("failed to create temporary database: %v", err)
+	}
+	_ = f.Close()
+
+	if _, err = os.Stat(fileName); os.IsNotExist(err) {
+		return f.Name()
+	}
+
+	if err = seal.OpenFile(encryptionKey, fileName, f.Name()); err != nil {
+		_ = os.Remove(f.Name())
+		log.Fatalf("failed to decrypt sqlite database: %v", err)
+	}
+	return f.Name()
+}
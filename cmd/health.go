@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/actions"
+	"github.com/askgitdev/askgit/pkg/health"
+)
+
+var healthAddr string // address the probes of sync commands are served on
+
+// githubTokenCheck returns the check of the validity of the GitHub token, run at most once a minute
+// so as not to hammer the API, or nil if there's no token to check
+func githubTokenCheck() *health.Check {
+	if githubToken == "" {
+		return nil
+	}
+
+	rt, err := apiTransport()
+	if err != nil {
+		log.Fatalf("failed to configure API client: %v", err)
+	}
+	var client = &actions.Client{HTTP: &http.Client{Transport: rt}, Token: githubToken}
+	return &health.Check{Name: "github_token", Fn: client.CheckToken, TTL: time.Minute}
+}
+
+// serveHealth serves the probes of the checker on --health-addr in the background, if set,
+// failing the readiness probe once draining is closed
+func serveHealth(checker *health.Checker, draining <-chan struct{}) {
+	if healthAddr == "" {
+		return
+	}
+
+	var mux = http.NewServeMux()
+	checker.Register(mux)
+	go func() {
+		if err := http.ListenAndServe(healthAddr, mux); err != nil {
+			log.Fatalf("failed to serve the health probes: %v", err)
+		}
+	}()
+	go func() {
+		<-draining
+		checker.Drain()
+	}()
+}
+
+// syncChecker returns the checker of the health of sync commands: their database must be reachable to be live,
+// and writable, along with a valid GitHub token, to be ready
+func syncChecker(db *sql.DB) *health.Checker {
+	var checker = &health.Checker{
+		Live:  []*health.Check{{Name: "database", Fn: health.Ping(db)}},
+		Ready: []*health.Check{{Name: "database_writable", Fn: health.Writable(db)}},
+	}
+	if check := githubTokenCheck(); check != nil {
+		checker.Ready = append(checker.Ready, check)
+	}
+	return checker
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/askgitdev/askgit/pkg/query"
+)
+
+// listTables returns the names of the modules registered with the database connection.
+// All askgit tables are eponymous virtual tables, so every module doubles as a table.
+func listTables(ctx context.Context, db *sql.DB) ([]string, error) {
+	res, err := query.Run(ctx, db, "SELECT name FROM pragma_module_list ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+
+	var tables = make([]string, 0, len(res.Rows))
+	for _, row := range res.Rows {
+		tables = append(tables, fmt.Sprint(row[0]))
+	}
+	return tables, nil
+}
+
+// describeSchema returns a textual description of every table and its columns,
+// with the hidden columns (the arguments of table-valued functions) listed separately.
+func describeSchema(ctx context.Context, db *sql.DB) (string, error) {
+	tables, err := listTables(ctx, db)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, table := range tables {
+		res, err := query.Run(ctx, db, "SELECT name, type, hidden FROM pragma_table_xinfo(?)", table)
+		if err != nil || len(res.Rows) == 0 {
+			continue // not every module can be introspected without arguments
+		}
+
+		var columns, args []string
+		for _, row := range res.Rows {
+			column := strings.TrimSpace(fmt.Sprintf("%v %v", row[0], row[1]))
+			if fmt.Sprint(row[2]) != "0" {
+				args = append(args, column)
+			} else {
+				columns = append(columns, column)
+			}
+		}
+
+		fmt.Fprintf(&b, "%s(%s)", table, strings.Join(columns, ", "))
+		if len(args) > 0 {
+			fmt.Fprintf(&b, " arguments: %s", strings.Join(args, ", "))
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/askgitdev/askgit/pkg/auth"
+	"github.com/spf13/cobra"
+)
+
+var keyringPath string  // path to the keyring file
+var keyRole string      // role assigned to a new key
+var roleTables []string // tables a role is allowed to query
+
+func init() {
+	var defaultPath, _ = auth.DefaultPath()
+	keysCmd.PersistentFlags().StringVar(&keyringPath, "keyring", defaultPath, "path to the keyring file")
+
+	keysCreateCmd.Flags().StringVar(&keyRole, "role", "", "role assigned to the key")
+	_ = keysCreateCmd.MarkFlagRequired("role")
+	keysRoleCmd.Flags().StringSliceVar(&roleTables, "allow-table", []string{auth.AllTables}, "tables and table-valued functions the role may query ('*' for all)")
+
+	keysCmd.AddCommand(keysCreateCmd, keysListCmd, keysRevokeCmd, keysRoleCmd)
+}
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "manage the API keys and roles used by askgit serve",
+	Long: `Use these commands to manage the keyring passed to 'askgit serve --keys'.
+Every API key is assigned a role, and every role lists the tables its keys are allowed to query.`,
+}
+
+var keysCreateCmd = &cobra.Command{
+	Use:   "create [name] --role [role]",
+	Short: "create a new API key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		keyring := loadKeyring()
+
+		secret, key, err := keyring.Generate(args[0], keyRole)
+		if err != nil {
+			log.Fatalf("failed to create key: %v", err)
+		}
+		saveKeyring(keyring)
+
+		fmt.Fprintf(os.Stderr, "created key %s, the secret below won't be shown again\n", key.ID)
+		fmt.Println(secret)
+	},
+}
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list roles and API keys",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		keyring := loadKeyring()
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ROLE\tTABLES")
+		for _, name := range keyring.RoleNames() {
+			fmt.Fprintf(w, "%s\t%s\n", name, strings.Join(keyring.Roles[name].Tables, ", "))
+		}
+
+		fmt.Fprintln(w, "\nKEY\tNAME\tROLE\tCREATED")
+		for _, key := range keyring.Keys {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Role, key.Created.Format("2006-01-02 15:04:05"))
+		}
+		_ = w.Flush()
+	},
+}
+
+var keysRevokeCmd = &cobra.Command{
+	Use:   "revoke [key id]",
+	Short: "revoke an API key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		keyring := loadKeyring()
+		if err := keyring.Revoke(args[0]); err != nil {
+			log.Fatalf("failed to revoke key: %v", err)
+		}
+		saveKeyring(keyring)
+	},
+}
+
+var keysRoleCmd = &cobra.Command{
+	Use:   "role [name] --allow-table [table]",
+	Short: "create or update a role",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		keyring := loadKeyring()
+		keyring.SetRole(args[0], roleTables)
+		saveKeyring(keyring)
+	},
+}
+
+func loadKeyring() *auth.Keyring {
+	if keyringPath == "" {
+		log.Fatal("could not determine the keyring location, please supply --keyring")
+	}
+
+	keyring, err := auth.Load(keyringPath)
+	if err != nil {
+		log.Fatalf("failed to load keyring: %v", err)
+	}
+	return keyring
+}
+
+func saveKeyring(keyring *auth.Keyring) {
+	if err := keyring.Save(keyringPath); err != nil {
+		log.Fatalf("failed to save keyring: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/leader"
+)
+
+var leaderLock string // lock replicas of sync commands compete for, e.g. consul://localhost:8500/askgit/sync
+var leaderWait bool   // wait to become the leader rather than leaving the sync to the replica that is
+
+// electLeader takes the --leader-lock, if set, reporting whether this replica is the leader and should sync.
+// The returned channel is closed once draining is, or the lock is lost, for the sync to stop, and release releases the lock.
+// Locks of replicas exiting without releasing them are released by the lock service once their TTL expires.
+func electLeader(ctx context.Context, draining <-chan struct{}) (stop <-chan struct{}, release func(), ok bool) {
+	if leaderLock == "" {
+		return draining, func() {}, true
+	}
+
+	lock, err := leader.Parse(leaderLock)
+	if err != nil {
+		log.Fatalf("invalid --leader-lock: %v", err)
+	}
+
+	var lost <-chan struct{}
+	if leaderWait {
+		// replicas asked to stop while waiting have nothing to drain
+		waiting, cancel := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-draining:
+				cancel()
+			case <-waiting.Done():
+			}
+		}()
+		lost, err = leader.Acquire(waiting, lock, 5*time.Second)
+		ok = err == nil
+		if waiting.Err() != nil {
+			err = nil
+		}
+		cancel()
+	} else {
+		lost, ok, err = lock.TryAcquire(ctx)
+	}
+	if err != nil {
+		log.Fatalf("failed to acquire the leader lock: %v", err)
+	}
+	if !ok {
+		log.Printf("another replica holds the leader lock %s, leaving the sync to it", leaderLock)
+		return nil, func() {}, false
+	}
+
+	var stopping = make(chan struct{})
+	var released = make(chan struct{})
+	go func() {
+		select {
+		case <-draining:
+		case <-lost:
+			log.Printf("lost the leader lock %s, stopping", leaderLock)
+		case <-released:
+			return
+		}
+		close(stopping)
+	}()
+
+	// the lock is released once, either when the command returns or before it exits with an error
+	var once sync.Once
+	return stopping, func() {
+		once.Do(func() {
+			close(released)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := lock.Release(ctx); err != nil {
+				log.Printf("failed to release the leader lock: %v", err)
+			}
+		})
+	}, true
+}
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/library"
+	"github.com/spf13/cobra"
+)
+
+var libraryPath string            // path to the library of saved queries
+var queryDescription string       // description of a saved query
+var queryTags []string            // tags of a saved query, or the tag queries are listed by
+var queryParams map[string]string // default values of the parameters of a saved query, or the values it's run with
+var importPath string             // file of shared queries to add to the library
+var deleteQuery bool              // remove a saved query
+var listJSON bool                 // list saved queries in the JSON format they're shared in
+
+func init() {
+	var defaultPath, _ = library.DefaultPath()
+	for _, c := range []*cobra.Command{saveCmd, listCmd, runCmd, shareCmd} {
+		c.Flags().StringVar(&libraryPath, "library", envOr("ASKGIT_LIBRARY", defaultPath), "path to the library of saved queries (defaults to $ASKGIT_LIBRARY)")
+	}
+
+	saveCmd.Flags().StringVarP(&queryDescription, "description", "d", "", "description of the query")
+	saveCmd.Flags().StringSliceVar(&queryTags, "tag", nil, "tag the query, to list it by")
+	saveCmd.Flags().StringToStringVar(&queryParams, "param", nil, "default value of a named parameter of the query (:name), e.g. --param repo=askgitdev/askgit")
+	saveCmd.Flags().StringVar(&importPath, "import", "", "add the queries of this file, shared in the JSON format of askgit list --json (- for stdin)")
+	saveCmd.Flags().BoolVar(&deleteQuery, "delete", false, "remove the query from the library")
+	saveCmd.ValidArgsFunction = completeSavedQueries
+
+	listCmd.Flags().StringSliceVar(&queryTags, "tag", nil, "list the queries with this tag only")
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "print the queries in the JSON format they're shared in, to be imported with askgit save --import")
+	listCmd.ValidArgsFunction = completeSavedQueries
+
+	runCmd.Flags().StringToStringVar(&queryParams, "param", nil, "value of a named parameter of the query (:name), overriding its default, e.g. --param repo=askgitdev/askgit")
+	runCmd.Flags().StringVarP(&format, "format", "f", "table", "specify the output format. Options are 'csv' 'tsv' 'table' 'single' 'json' 'markdown' and 'xlsx'")
+	_ = runCmd.RegisterFlagCompletionFunc("format", completeFormats)
+	runCmd.Flags().StringVarP(&output, "output", "o", "", "write the results to this file rather than stdout")
+	runCmd.Flags().StringVar(&pagerMode, "pager", envOr("ASKGIT_PAGER", "never"), "pipe results through $PAGER: 'auto' when they don't fit the terminal, 'always' or 'never' (defaults to $ASKGIT_PAGER)")
+	_ = runCmd.RegisterFlagCompletionFunc("pager", completePager)
+	runCmd.Flags().BoolVar(&copyResult, "copy", false, "put the result set on the system clipboard as TSV, rather than printing it")
+	addDisplayFlags(runCmd)
+	addLintFlag(runCmd)
+	runCmd.Flags().StringVarP(&repo, "repo", "r", ".", "specify a path to a default repo on disk. This will be used if no repo is supplied as an argument to a git table")
+	runCmd.ValidArgsFunction = completeSavedQueries
+}
+
+var saveCmd = &cobra.Command{
+	Use:   `save [name] "SELECT * FROM commits WHERE author_email = :email"`,
+	Short: "save a query to the library, to be run by name",
+	Long: `Use this command to save a query to the local library of queries, along with a description, tags,
+and default values for its named parameters (:name), to be run with askgit run.
+The query is read from stdin if it isn't given, and replaces any saved query of the same name.
+Queries shared by others (see askgit list --json) are added to the library with --import.`,
+	Args: cobra.MaximumNArgs(2),
+	// saving queries needs none of the tables
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		var lib = loadLibrary()
+
+		switch {
+		case importPath != "":
+			if len(args) > 0 {
+				log.Fatal("queries are named by the file they're imported from, expected no arguments with --import")
+			}
+			var data []byte
+			var err error
+			if importPath == "-" {
+				data, err = ioutil.ReadAll(os.Stdin)
+			} else {
+				data, err = ioutil.ReadFile(importPath)
+			}
+			if err != nil {
+				log.Fatalf("failed to read the queries to import: %v", err)
+			}
+
+			queries, err := library.Parse(data)
+			if err != nil {
+				log.Fatalf("failed to parse the queries to import: %v", err)
+			}
+			for _, q := range queries {
+				if err = lib.Put(q, time.Now()); err != nil {
+					log.Fatal(err)
+				}
+			}
+			fmt.Fprintf(os.Stderr, "imported %d queries\n", len(queries))
+
+		case len(args) == 0:
+			log.Fatal("expected the name of the query")
+
+		case deleteQuery:
+			if !lib.Remove(args[0]) {
+				log.Fatalf("no saved query %s", args[0])
+			}
+
+		default:
+			var sql string
+			if len(args) > 1 {
+				sql = args[1]
+			} else {
+				stdin, err := ioutil.ReadAll(os.Stdin)
+				if err != nil {
+					log.Fatalf("failed to read from stdin: %v", err)
+				}
+				sql = string(stdin)
+			}
+
+			var q = &library.Query{Name: args[0], Description: queryDescription, Tags: queryTags, Params: queryParams, SQL: strings.TrimSpace(sql)}
+			if err := lib.Put(q, time.Now()); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		saveLibrary(lib)
+	},
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list [name...]",
+	Short: "list the saved queries",
+	Long: `Use this command to list the queries of the library, or those named, with their description, tags and parameters.
+With --json, the queries are printed in the JSON format they're shared in, which askgit save --import reads.`,
+	// listing queries needs none of the tables
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		var lib = loadLibrary()
+
+		var named = make(map[string]bool, len(args))
+		for _, name := range args {
+			if _, ok := lib.Get(name); !ok {
+				log.Fatalf("no saved query %s", name)
+			}
+			named[name] = true
+		}
+
+		var queries = make([]*library.Query, 0, len(lib.Queries))
+		for _, q := range lib.Queries {
+			if len(args) > 0 && !named[q.Name] {
+				continue
+			}
+			if !hasAnyTag(q, queryTags) {
+				continue
+			}
+			queries = append(queries, q)
+		}
+
+		if listJSON {
+			b, err := json.MarshalIndent(queries, "", "  ")
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(string(b))
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tTAGS\tPARAMS\tDESCRIPTION")
+		for _, q := range queries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", q.Name, strings.Join(q.Tags, ", "), describeParams(q), q.Description)
+		}
+		_ = w.Flush()
+	},
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run [name] --param name=value",
+	Short: "run a saved query",
+	Long: `Use this command to run a query of the library by name, with the values of its named parameters given by --param,
+or else their defaults. Results are output as by askgit itself.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var lib = loadLibrary()
+
+		q, ok := lib.Get(args[0])
+		if !ok {
+			log.Fatalf("no saved query %s, see askgit list", args[0])
+		}
+
+		values, err := q.Values(queryParams)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		runQuery(q.SQL, values)
+	},
+}
+
+// hasAnyTag reports whether the query has one of the tags, or whether there are no tags to have
+func hasAnyTag(q *library.Query, tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		if q.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// describeParams lists the named parameters of the query, with their default values if they have one
+func describeParams(q *library.Query) string {
+	var params []string
+	for _, name := range q.ParamNames() {
+		if v, ok := q.Params[name]; ok {
+			params = append(params, fmt.Sprintf("%s=%s", name, v))
+		} else {
+			params = append(params, name)
+		}
+	}
+	return strings.Join(params, ", ")
+}
+
+func loadLibrary() *library.Library {
+	if libraryPath == "" {
+		log.Fatal("could not determine the library location, please supply --library")
+	}
+
+	lib, err := library.Load(libraryPath)
+	if err != nil {
+		log.Fatalf("failed to load the library of queries: %v", err)
+	}
+	return lib
+}
+
+func saveLibrary(lib *library.Library) {
+	if err := lib.Save(libraryPath); err != nil {
+		log.Fatalf("failed to save the library of queries: %v", err)
+	}
+}
+
+// completeSavedQueries completes the names of the saved queries
+func completeSavedQueries(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if cmd != listCmd && len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	lib, err := library.Load(libraryPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return lib.Names(), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/askgitdev/askgit/pkg/lint"
+	"github.com/askgitdev/askgit/tables"
+	"github.com/spf13/cobra"
+)
+
+var lintMode string // what's done about the mistakes found in queries before they're run
+
+// addLintFlag adds the flag of the query linter to a command running queries
+func addLintFlag(c *cobra.Command) {
+	c.Flags().StringVar(&lintMode, "lint", envOr("ASKGIT_LINT", "warn"), "check queries for API tables missing their arguments or a LIMIT before running them: 'warn', 'error' to refuse to run them, or 'off' (defaults to $ASKGIT_LINT)")
+	_ = c.RegisterFlagCompletionFunc("lint", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"warn", "error", "off"}, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// lintQuery checks the query for mistakes that burn API quota, warning about them on stderr,
+// or exiting if --lint is error
+func lintQuery(sql string) {
+	switch lintMode {
+	case "off":
+		return
+	case "warn", "error":
+	default:
+		log.Fatalf("invalid --lint %q, expected warn, error or off", lintMode)
+	}
+
+	var warnings = lint.Check(sql, tables.LintTables)
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w.Message)
+	}
+	if len(warnings) > 0 && lintMode == "error" {
+		log.Fatal("not running the query, as --lint is error")
+	}
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/askgitdev/askgit/pkg/audit"
+	"github.com/askgitdev/askgit/pkg/jsonrpc"
+	"github.com/askgitdev/askgit/pkg/query"
+	"github.com/askgitdev/askgit/pkg/sandbox"
+	"github.com/spf13/cobra"
+)
+
+var mcpAllowedTables []string // tables the MCP client is allowed to query
+var mcpMaxRows int            // maximum number of rows returned per tool call
+
+func init() {
+	mcpCmd.Flags().StringSliceVar(&mcpAllowedTables, "allow-table", []string{}, "restrict queries to these tables and table-valued functions (defaults to all)")
+	mcpCmd.Flags().IntVar(&mcpMaxRows, "max-rows", 1000, "maximum number of rows returned per query")
+	mcpCmd.Flags().StringVar(&auditLog, "audit-log", "", "append a JSON record of every executed query to this file ('-' for stderr)")
+}
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "run as a Model Context Protocol server on stdin / stdout",
+	Long: `Use this command to expose askgit to LLM agents as a Model Context Protocol (MCP) server.
+The server offers tools to list the available tables, inspect their schema and run read-only queries.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		var db *sql.DB
+		if db, err = sql.Open("sqlite3", ":memory:"); err != nil {
+			log.Fatalf("failed to initialize database connection: %v", err)
+		}
+		defer db.Close()
+
+		// pin a single connection so the query_only pragma applies to all tool calls
+		db.SetMaxOpenConns(1)
+		if _, err = db.Exec("PRAGMA query_only = ON"); err != nil {
+			log.Fatalf("failed to make database connection read-only: %v", err)
+		}
+
+		logger, closeAuditLog := openAuditLog(auditLog)
+		defer closeAuditLog()
+
+		var mcp = &mcpServer{db: db, maxRows: mcpMaxRows, allowed: make(map[string]bool), audit: logger}
+		for _, table := range mcpAllowedTables {
+			mcp.allowed[strings.ToLower(table)] = true
+		}
+
+		if mcp.tables, err = listTables(context.Background(), db); err != nil {
+			log.Fatalf("failed to list tables: %v", err)
+		}
+
+		var srv = jsonrpc.NewServer()
+		srv.Handle("initialize", mcp.initialize)
+		srv.Handle("ping", func(context.Context, json.RawMessage) (interface{}, error) { return struct{}{}, nil })
+		srv.Handle("tools/list", mcp.listTools)
+		srv.Handle("tools/call", mcp.callTool)
+
+		if err = srv.Serve(context.Background(), os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("mcp server failed: %v", err)
+		}
+	},
+}
+
+type mcpServer struct {
+	db      *sql.DB
+	maxRows int
+	tables  []string
+	allowed map[string]bool
+	audit   *audit.Logger
+}
+
+type mcpTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+func (mcp *mcpServer) initialize(context.Context, json.RawMessage) (interface{}, error) {
+	return map[string]interface{}{
+		"protocolVersion": "2024-11-05",
+		"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+		"serverInfo":      map[string]interface{}{"name": "askgit", "version": "dev"},
+	}, nil
+}
+
+func (mcp *mcpServer) listTools(context.Context, json.RawMessage) (interface{}, error) {
+	var stringArg = func(name, description string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{name: map[string]interface{}{"type": "string", "description": description}},
+			"required":   []string{name},
+		}
+	}
+
+	return map[string]interface{}{"tools": []*mcpTool{
+		{
+			Name:        "list_tables",
+			Description: "List the tables and table-valued functions that can be queried",
+			InputSchema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+		},
+		{
+			Name:        "describe_table",
+			Description: "Describe the columns of a table. Hidden columns are the arguments of a table-valued function.",
+			InputSchema: stringArg("table", "name of the table to describe"),
+		},
+		{
+			Name:        "query",
+			Description: fmt.Sprintf("Run a read-only SQLite query over git and GitHub data. At most %d rows are returned.", mcp.maxRows),
+			InputSchema: stringArg("sql", "the SQL query to run"),
+		},
+	}}, nil
+}
+
+func (mcp *mcpServer) callTool(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p struct {
+		Name      string            `json:"name"`
+		Arguments map[string]string `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, jsonrpc.Errorf(jsonrpc.InvalidParams, "invalid tool call: %v", err)
+	}
+
+	var result interface{}
+	var err error
+	switch p.Name {
+	case "list_tables":
+		var tables = make([]string, 0, len(mcp.tables))
+		for _, table := range mcp.tables {
+			if mcp.isAllowed(table) {
+				tables = append(tables, table)
+			}
+		}
+		result = tables
+	case "describe_table":
+		var table = strings.ToLower(p.Arguments["table"])
+		if !mcp.isAllowed(table) {
+			err = fmt.Errorf("table %q is not available", table)
+			break
+		}
+		result, err = query.Run(ctx, mcp.db, "SELECT name, type, hidden FROM pragma_table_xinfo(?)", table)
+	case "query":
+		result, err = mcp.query(ctx, p.Arguments["sql"])
+	default:
+		return nil, jsonrpc.Errorf(jsonrpc.InvalidParams, "unknown tool: %s", p.Name)
+	}
+
+	// tool errors are reported back to the model as part of the result rather than as protocol errors
+	if err != nil {
+		return mcpToolResult(err.Error(), true), nil
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return mcpToolResult(string(encoded), false), nil
+}
+
+func (mcp *mcpServer) query(ctx context.Context, sql string) (res *query.Result, err error) {
+	ctx, entry := mcp.audit.Begin(ctx, sql)
+	defer func() {
+		var count int
+		if res != nil {
+			count = len(res.Rows)
+		}
+		_ = mcp.audit.End(entry, count, err)
+	}()
+
+	var policy = &sandbox.Policy{BannedFunctions: sandbox.DefaultBannedFunctions}
+	if err := policy.Check(sql); err != nil {
+		return nil, err
+	}
+
+	for _, ident := range query.Identifiers(sql) {
+		if mcp.isTable(ident) && !mcp.isAllowed(ident) {
+			return nil, fmt.Errorf("table %q is not available", ident)
+		}
+	}
+
+	conn, release, err := audit.Conn(ctx, mcp.db)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := conn.QueryContext(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return query.Collect(rows, mcp.maxRows)
+}
+
+func (mcp *mcpServer) isTable(name string) bool {
+	for _, table := range mcp.tables {
+		if table == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (mcp *mcpServer) isAllowed(table string) bool {
+	return len(mcp.allowed) == 0 || mcp.allowed[table]
+}
+
+func mcpToolResult(text string, isError bool) map[string]interface{} {
+	return map[string]interface{}{
+		"content": []map[string]string{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// usePager reports whether output to stdout goes through the pager, as --pager says:
+// 'always', 'never', or 'auto' for when stdout is a terminal
+func usePager(mode string) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never", "":
+		return false, nil
+	case "auto":
+		return term.IsTerminal(int(os.Stdout.Fd())), nil
+	default:
+		return false, fmt.Errorf("invalid --pager %q, expected auto, always or never", mode)
+	}
+}
+
+// fitsScreen reports whether the output fits the terminal stdout is, without wrapping nor scrolling
+func fitsScreen(output []byte) bool {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return false
+	}
+
+	var lines = bytes.Split(bytes.TrimRight(output, "\n"), []byte("\n"))
+	if len(lines) >= height {
+		return false
+	}
+	for _, line := range lines {
+		if utf8.RuneCount(line) > width {
+			return false
+		}
+	}
+	return true
+}
+
+// page writes the output to stdout through $PAGER (less by default), unless mode is 'auto' and it fits the screen.
+// Results are rendered at their full width for the pager, and less is told to chop long lines rather than wrap them
+// (as well as to quit if the output fits after all), so that the columns of tables stay aligned and can be scrolled to.
+func page(output []byte, mode string) error {
+	if mode == "auto" && fitsScreen(output) {
+		_, err := os.Stdout.Write(output)
+		return err
+	}
+
+	var pager = strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less"}
+		if runtime.GOOS == "windows" {
+			pager = []string{"more"}
+		}
+	}
+	if pager[0] == "cat" {
+		_, err := os.Stdout.Write(output)
+		return err
+	}
+
+	var cmd = exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = bytes.NewReader(output)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(cmd.Env, "LESS=FRSX")
+	}
+
+	// without a pager to run, the output is written out as it is
+	var execErr *exec.Error
+	var err = cmd.Run()
+	if errors.As(err, &execErr) {
+		_, err = os.Stdout.Write(output)
+	}
+	return err
+}
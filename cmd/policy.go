@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/askgitdev/askgit/pkg/actions"
+	"github.com/askgitdev/askgit/pkg/policy"
+	"github.com/spf13/cobra"
+)
+
+var policyRules string  // path of the policy rules
+var policyFormat string // format of the report
+var policyOutput string // file the report is written to
+var policyIssues bool   // file the violations of rules with an issue sink as issues
+
+func init() {
+	policyCmd.PersistentFlags().StringVar(&policyRules, "rules", "askgit-policy.yaml", "path of the rules, each a query returning the violations of a policy")
+
+	policyCheckCmd.Flags().StringVar(&policyFormat, "format", "text", "format of the report, 'text' or 'sarif' (to be uploaded to GitHub code scanning)")
+	policyCheckCmd.Flags().StringVarP(&policyOutput, "output", "o", "", "write the report to this file rather than stdout")
+	policyCheckCmd.Flags().BoolVar(&policyIssues, "file-issues", false, "file (or update) an issue with the violations of every rule configuring one, for scheduled checks")
+
+	policyCmd.AddCommand(policyCheckCmd)
+}
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "check repositories against policies written as queries",
+	Long: `Use these commands to check repositories, and the services around them, against a set of rules (see --rules).
+Every rule is a query returning the violations of a policy, and passes when it returns no rows.`,
+}
+
+var policyCheckCmd = &cobra.Command{
+	Use:   "check [rules...]",
+	Short: "check every rule, or the given ones",
+	Long: `Use this command to check every rule, or the given ones, and print their violations.
+It exits with a non-zero status if a rule of the error severity has violations, or if a rule can't be checked,
+so that it can gate CI pipelines.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if policyFormat != "text" && policyFormat != "sarif" {
+			log.Fatalf("unknown format %q, expected text or sarif", policyFormat)
+		}
+
+		rules, err := policy.LoadRules(policyRules)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var selected = rules.Rules
+		if len(args) > 0 {
+			selected = nil
+			for _, name := range args {
+				rule := rules.Rule(name)
+				if rule == nil {
+					log.Fatalf("no rule %s in %s", name, policyRules)
+				}
+				selected = append(selected, rule)
+			}
+		}
+
+		db, err := sql.Open("sqlite3", ":memory:")
+		if err != nil {
+			log.Fatalf("failed to initialize database connection: %v", err)
+		}
+		defer db.Close()
+
+		var out = os.Stdout
+		if policyOutput != "" {
+			if out, err = os.Create(policyOutput); err != nil {
+				log.Fatalf("failed to create output file: %v", err)
+			}
+			defer out.Close()
+		}
+
+		report := policy.Check(context.Background(), db, selected)
+		if policyFormat == "sarif" {
+			err = report.WriteSARIF(out, policyRules)
+		} else {
+			err = report.WriteText(out)
+		}
+		if err != nil {
+			log.Fatalf("failed to write report: %v", err)
+		}
+
+		if policyIssues {
+			if err = fileIssues(context.Background(), report); err != nil {
+				log.Fatalf("failed to file issues: %v", err)
+			}
+		}
+
+		if report.Failed() {
+			// exiting skips the deferred calls and the post run hook, which saves any recorded fixture bundle
+			out.Close()
+			stopRecording()
+			os.Exit(1)
+		}
+	},
+}
+
+// fileIssues files the violations of every rule with an issue sink as an issue, or updates the one filed by a previous check
+func fileIssues(ctx context.Context, report *policy.Report) error {
+	rt, err := apiTransport()
+	if err != nil {
+		return err
+	}
+	var client = &actions.Client{HTTP: &http.Client{Transport: rt}, Token: githubToken}
+
+	for _, res := range report.Results {
+		if res.Rule.Issue == nil || res.Err != nil || res.Passed() {
+			continue
+		}
+
+		body, err := res.IssueBody()
+		if err != nil {
+			return err
+		}
+		var sink = res.Rule.Issue
+		issue, err := client.FileIssue(ctx, sink.Repository, "policy:"+res.Rule.Name, sink.Title, body, sink.Labels)
+		if err != nil {
+			return fmt.Errorf("rule %s: %v", res.Rule.Name, err)
+		}
+
+		var verb = "updated"
+		if issue.Created {
+			verb = "opened"
+		}
+		log.Printf("%s: %s issue %s#%d", res.Rule.Name, verb, sink.Repository, issue.Number)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"runtime/pprof"
+)
+
+// profileFile is the open CPU profile, if --profile is used
+var profileFile *os.File
+
+// startProfile starts writing the CPU profile of the command to --profile, if set
+func startProfile() {
+	if cpuProfile == "" {
+		return
+	}
+
+	var err error
+	if profileFile, err = os.Create(cpuProfile); err != nil {
+		log.Fatalf("failed to create the CPU profile: %v", err)
+	}
+	if err = pprof.StartCPUProfile(profileFile); err != nil {
+		log.Fatalf("failed to start the CPU profile: %v", err)
+	}
+}
+
+// stopProfile stops the CPU profile started by startProfile, if any, writing it out
+func stopProfile() {
+	if profileFile == nil {
+		return
+	}
+
+	pprof.StopCPUProfile()
+	if err := profileFile.Close(); err != nil {
+		log.Fatalf("failed to write the CPU profile: %v", err)
+	}
+	profileFile = nil
+}
@@ -1,34 +1,172 @@
 package cmd
 
 import (
+	"bytes"
 	"database/sql"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"runtime"
+	"time"
 
 	"github.com/askgitdev/askgit/pkg/display"
+	"github.com/askgitdev/askgit/pkg/httpcache"
+	"github.com/askgitdev/askgit/pkg/library"
 	. "github.com/askgitdev/askgit/pkg/query"
+	"github.com/askgitdev/askgit/pkg/transport"
 	"github.com/spf13/cobra"
 )
 
 var format string                           // output format flag
+var output string                           // output file flag
 var presetQuery string                      // named / preset query flag
+var pagerMode string                        // when results are piped through the pager
+var copyResult bool                         // put the result set on the clipboard
 var repo string                             // path to repo on disk
 var githubToken = os.Getenv("GITHUB_TOKEN") // GitHub auth token for GitHub tables
+var githubTokenSource string                // secret store the GitHub token is read from
+var githubTokenTTL time.Duration            // how long a token read from the secret store is cached for
+var githubPrefetch bool                     // fetch the next page of GitHub results ahead of the query
+var githubArgs string                       // how GitHub tables missing required arguments behave
+var excludeBots bool                        // leave out the issues opened by bots
+var transportOpts transport.Options         // proxy and TLS settings of the API clients
+var breakerThreshold int                    // consecutive failures of an API host tripping the circuit breaker
+var breakerCooldown time.Duration           // how long requests to a failing API host fail fast for
+var apiBudgetFlag string                    // rate all API requests together are capped at
+var cacheResponses bool                     // store API responses on disk
+var cacheDir string                         // directory API responses are stored in
+var cacheTTL time.Duration                  // how long stored API responses are served for
+var offline bool                            // answer API-backed tables from stored responses only
+var recordBundle string                     // file API responses are recorded to
+var replayBundle string                     // file API responses are replayed from
+var pluginPaths []string                    // plugins providing third-party tables
+var udfScripts []string                     // Starlark scripts defining SQL functions
+var parallelism int                         // repositories opened concurrently in multi-repo queries
+var snapshotRef string                      // ref every repository is pinned to when first opened
+var timezone string                         // timezone every timestamp is converted to
+var signingKeyring string                   // keys commit and tag signatures are verified against
+var sandboxed bool                          // whether the command runs the queries of clients in the sandbox
+var trustedKeys string                      // keys signature_trusted accepts signatures from
+var encryptionKeySource string              // secret store the key encrypting data at rest is read from
+var cpuProfile string                       // file the CPU profile of the command is written to
 
 func init() {
 	// local (root command only) flags
-	rootCmd.Flags().StringVarP(&format, "format", "f", "table", "specify the output format. Options are 'csv' 'tsv' 'table' 'single' and 'json'")
+	rootCmd.Flags().StringVarP(&format, "format", "f", "table", "specify the output format. Options are 'csv' 'tsv' 'table' 'single' 'json' 'markdown' and 'xlsx'")
+	rootCmd.Flags().StringVarP(&output, "output", "o", "", "write the results to this file rather than stdout")
 	rootCmd.Flags().StringVarP(&presetQuery, "preset", "p", "", "used to pick a preset query")
+	_ = rootCmd.RegisterFlagCompletionFunc("preset", completePresets)
+	_ = rootCmd.RegisterFlagCompletionFunc("format", completeFormats)
+	rootCmd.Flags().StringVar(&pagerMode, "pager", envOr("ASKGIT_PAGER", "never"), "pipe results through $PAGER (less by default), with tables kept at their full width: 'auto' when they don't fit the terminal, 'always' or 'never' (defaults to $ASKGIT_PAGER)")
+	_ = rootCmd.RegisterFlagCompletionFunc("pager", completePager)
+	addDisplayFlags(rootCmd)
+	addLintFlag(rootCmd)
+	rootCmd.Flags().BoolVar(&copyResult, "copy", false, "put the result set on the system clipboard as TSV, rather than printing it")
 	rootCmd.Flags().StringVarP(&repo, "repo", "r", ".", "specify a path to a default repo on disk. This will be used if no repo is supplied as an argument to a git table")
 
+	// persistent flags, available to all sub commands
+	rootCmd.PersistentFlags().StringVar(&githubTokenSource, "github-token-source", os.Getenv("ASKGIT_GITHUB_TOKEN_SOURCE"), "read the GitHub token from a secret store, e.g. vault://secret/askgit#token, awssm://askgit/github or gcpsm://projects/p/secrets/github (defaults to $ASKGIT_GITHUB_TOKEN_SOURCE)")
+	rootCmd.PersistentFlags().DurationVar(&githubTokenTTL, "github-token-ttl", 15*time.Minute, "how long a token read from --github-token-source is cached for")
+	rootCmd.PersistentFlags().StringVar(&githubArgs, "github-args", "strict", "how GitHub tables missing a required argument (such as the login of github_starred_repos) behave: 'strict' fails the query, 'lenient' returns no rows")
+	rootCmd.PersistentFlags().BoolVar(&excludeBots, "exclude-bots", false, "leave out the issues opened by bots (see is_bot_login) from the GitHub tables")
+	rootCmd.PersistentFlags().BoolVar(&githubPrefetch, "github-prefetch", false, "fetch the next page of GitHub results in the background while the current one is read, at a lower priority than the pages queries wait on")
+	rootCmd.PersistentFlags().StringVar(&transportOpts.Proxy, "proxy", "", "send API requests through this proxy (defaults to $HTTPS_PROXY / $HTTP_PROXY)")
+	rootCmd.PersistentFlags().StringVar(&transportOpts.CABundle, "ca-bundle", os.Getenv("ASKGIT_CA_BUNDLE"), "PEM file of additional certificate authorities to trust for API requests (defaults to $ASKGIT_CA_BUNDLE)")
+	rootCmd.PersistentFlags().BoolVar(&transportOpts.InsecureSkipVerify, "insecure-skip-tls-verify", false, "do not verify the certificates of API servers (insecure, for debugging only)")
+	rootCmd.PersistentFlags().IntVar(&transportOpts.MaxIdleConnsPerHost, "http-max-idle-conns-per-host", transport.DefaultMaxIdleConnsPerHost, "connections kept open to every API host between requests, for concurrent requests to reuse")
+	rootCmd.PersistentFlags().DurationVar(&transportOpts.IdleConnTimeout, "http-idle-conn-timeout", 90*time.Second, "how long idle connections to API hosts are kept open for")
+	rootCmd.PersistentFlags().BoolVar(&transportOpts.DisableHTTP2, "http-disable-http2", false, "stick to HTTP/1.1, rather than negotiating HTTP/2 with the API servers supporting it")
+	rootCmd.PersistentFlags().BoolVar(&transportOpts.DisableCompression, "http-disable-compression", false, "do not ask API servers for gzip-compressed responses")
+	rootCmd.PersistentFlags().DurationVar(&transportOpts.DNSCacheTTL, "dns-cache-ttl", time.Minute, "how long the addresses of API hosts are cached for (0 to resolve them for every new connection)")
+	rootCmd.PersistentFlags().IntVar(&breakerThreshold, "circuit-breaker-threshold", 5, "consecutive failed requests to an API host after which requests to it fail fast (0 to disable)")
+	rootCmd.PersistentFlags().DurationVar(&breakerCooldown, "circuit-breaker-cooldown", 30*time.Second, "how long requests to a failing API host fail fast for, before it's tried again")
+	rootCmd.PersistentFlags().StringVar(&apiBudgetFlag, "api-budget", os.Getenv("ASKGIT_API_BUDGET"), "cap the rate of all API requests together, e.g. 10/s, 600/m or 5000/h, spreading them evenly (defaults to $ASKGIT_API_BUDGET, unlimited if empty)")
+
+	rootCmd.PersistentFlags().BoolVar(&cacheResponses, "cache", false, "store API responses on disk, so they can be reused with --cache-ttl or --offline")
+	rootCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", httpcache.DefaultDir(), "directory API responses are stored in")
+	rootCmd.PersistentFlags().DurationVar(&cacheTTL, "cache-ttl", 0, "serve stored API responses younger than this instead of making a request (implies --cache)")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "answer API-backed tables exclusively from stored responses, failing on anything not cached")
+	rootCmd.PersistentFlags().StringVar(&encryptionKeySource, "encryption-key-source", defaultEncryptionKeySource(), "encrypt stored API responses and exported databases with the key read from this secret store, e.g. keychain://askgit/encryption-key (defaults to $ASKGIT_ENCRYPTION_KEY_SOURCE, or env://ASKGIT_ENCRYPTION_KEY if set)")
+
+	rootCmd.PersistentFlags().StringVar(&recordBundle, "record", "", "record every API response into this fixture bundle, to be replayed with --replay")
+	rootCmd.PersistentFlags().StringVar(&replayBundle, "replay", "", "serve API responses from this fixture bundle instead of making requests")
+
+	rootCmd.PersistentFlags().StringSliceVar(&pluginPaths, "plugin", filepath.SplitList(os.Getenv("ASKGIT_PLUGINS")), "load third-party tables from this Go plugin, or directory of plugins (defaults to $ASKGIT_PLUGINS)")
+
+	rootCmd.PersistentFlags().StringSliceVar(&udfScripts, "udf", []string{}, "register the functions defined in this Starlark script as SQL functions")
+
+	rootCmd.PersistentFlags().IntVar(&parallelism, "parallelism", runtime.NumCPU(), "how many repositories found by the repos table are opened concurrently, ahead of the query (0 to disable)")
+	rootCmd.PersistentFlags().StringVar(&snapshotRef, "snapshot", "", "pin every repository to the commit this ref (HEAD if no value is given) points to when first opened, so all statements read the same snapshot")
+	rootCmd.PersistentFlags().Lookup("snapshot").NoOptDefVal = "HEAD"
+	rootCmd.PersistentFlags().StringVar(&timezone, "timezone", os.Getenv("ASKGIT_TIMEZONE"), "convert every timestamp returned by the tables to this timezone, e.g. UTC or Europe/Paris (defaults to $ASKGIT_TIMEZONE, or the timezone of each timestamp)")
+	rootCmd.PersistentFlags().StringVar(&signingKeyring, "signing-keyring", os.Getenv("ASKGIT_SIGNING_KEYRING"), "file of PGP and / or SSH public keys git_commit_signatures verifies signatures against (defaults to $ASKGIT_SIGNING_KEYRING)")
+	rootCmd.PersistentFlags().StringVar(&trustedKeys, "trusted-keys", os.Getenv("ASKGIT_TRUSTED_KEYS"), "keyring of the keys signature_trusted accepts signatures from, in the format of --signing-keyring (defaults to $ASKGIT_TRUSTED_KEYS)")
+
+	rootCmd.PersistentFlags().StringVar(&cpuProfile, "profile", "", "write the CPU profile of the command to this file, to be read with go tool pprof (e.g. to report a slow query)")
+
+	// complete the names of the tables in queries
+	rootCmd.ValidArgsFunction = completeQuery
+
 	// register the sqlite extension ahead of any command
 	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		startProfile()
+		sandboxed = (cmd == serveCmd && !serveUnsafe) || cmd == mcpCmd
 		registerExt()
 	}
 
+	// write out any recorded fixture bundle, and CPU profile, once the command completes
+	rootCmd.PersistentPostRun = func(cmd *cobra.Command, args []string) {
+		stopRecording()
+		stopProfile()
+	}
+
 	// add the export sub command
 	rootCmd.AddCommand(exportCmd)
+
+	// add the rpc sub command
+	rootCmd.AddCommand(rpcCmd)
+
+	// add the serve sub command
+	rootCmd.AddCommand(serveCmd)
+
+	// add the mcp sub command
+	rootCmd.AddCommand(mcpCmd)
+
+	// add the ask sub command
+	rootCmd.AddCommand(askCmd)
+
+	// add the keys sub command
+	rootCmd.AddCommand(keysCmd)
+
+	// add the decrypt sub command
+	rootCmd.AddCommand(decryptCmd)
+
+	// add the sync sub command
+	rootCmd.AddCommand(syncCmd)
+
+	// add the policy sub command
+	rootCmd.AddCommand(policyCmd)
+
+	// add the action sub command
+	rootCmd.AddCommand(actionCmd)
+
+	// add the comment sub command
+	rootCmd.AddCommand(commentCmd)
+
+	// add the completion sub command
+	rootCmd.AddCommand(completionCmd)
+
+	// add the save, list and run sub commands, managing saved queries
+	rootCmd.AddCommand(saveCmd, listCmd, runCmd)
+
+	// add the share sub command
+	rootCmd.AddCommand(shareCmd)
+
+	// add the bench sub command
+	rootCmd.AddCommand(benchCmd)
 }
 
 var rootCmd = &cobra.Command{
@@ -67,25 +205,103 @@ var rootCmd = &cobra.Command{
 			os.Exit(0)
 		}
 
-		var db *sql.DB
-		if db, err = sql.Open("sqlite3", ":memory:"); err != nil {
-			log.Fatalf("failed to initialize database connection: %v", err)
+		runQuery(query, nil)
+	},
+}
+
+// runQuery lints and runs the query, binding the values to its named parameters,
+// and outputs its results as the flags of the command say
+func runQuery(query string, values map[string]string) {
+	var err error
+
+	lintQuery(query)
+
+	var db *sql.DB
+	if db, err = sql.Open("sqlite3", ":memory:"); err != nil {
+		log.Fatalf("failed to initialize database connection: %v", err)
+	}
+
+	var out = os.Stdout
+	if output != "" {
+		if out, err = os.Create(output); err != nil {
+			log.Fatalf("failed to create output file: %v", err)
 		}
+		defer out.Close()
+	}
 
-		var rows *sql.Rows
-		if rows, err = db.Query(query); err != nil {
-			log.Fatalf("query execution failed: %v", err)
+	// xlsx output gets a worksheet for every statement in the query
+	if format == "xlsx" {
+		if info, err := out.Stat(); err == nil && !isPiped(info) {
+			log.Fatal("the xlsx format is binary, write it to a file with --output (or redirect stdout)")
+		}
+		if err = writeWorkbook(db, query, values, out); err != nil {
+			log.Fatalf("failed to output resultset: %v", err)
 		}
-		defer rows.Close()
+		return
+	}
+
+	var paged bool
+	if paged, err = usePager(pagerMode); err != nil {
+		log.Fatal(err)
+	}
+	var opts display.Options
+	if opts, err = tableOptions(output == ""); err != nil {
+		log.Fatal(err)
+	}
 
-		if err = display.WriteTo(rows, os.Stdout, format, false); err != nil {
+	var rows *sql.Rows
+	if rows, err = db.Query(query, library.NamedArgs(query, values)...); err != nil {
+		log.Fatalf("query execution failed: %v", err)
+	}
+	defer rows.Close()
+
+	switch {
+	case copyResult:
+		var buf bytes.Buffer
+		if err = display.WriteTo(rows, &buf, "tsv", false); err != nil {
 			log.Fatalf("failed to output resultset: %v", err)
 		}
-	},
+		if err = copyToClipboard(buf.Bytes()); err != nil {
+			log.Fatalf("failed to copy the resultset to the clipboard: %v", err)
+		}
+		fmt.Fprintln(os.Stderr, "copied the resultset to the clipboard")
+	case paged && output == "":
+		// the results are rendered in full before being paged, so that the pager scrolls them
+		var buf bytes.Buffer
+		if err = display.WriteToWithOptions(rows, &buf, format, true, opts); err != nil {
+			log.Fatalf("failed to output resultset: %v", err)
+		}
+		if err = page(buf.Bytes(), pagerMode); err != nil {
+			log.Fatalf("failed to page the resultset: %v", err)
+		}
+	default:
+		if err = display.WriteToWithOptions(rows, out, format, false, opts); err != nil {
+			log.Fatalf("failed to output resultset: %v", err)
+		}
+	}
 }
 
 func isPiped(info os.FileInfo) bool { return info.Mode()&os.ModeCharDevice == 0 }
 
+// writeWorkbook executes each statement in query (with the values of its named parameters),
+// writing every result set out as a separate worksheet
+func writeWorkbook(db *sql.DB, query string, values map[string]string, w io.Writer) error {
+	wb := display.NewWorkbook(w)
+	for i, stmt := range Split(query) {
+		rows, err := db.Query(stmt, library.NamedArgs(stmt, values)...)
+		if err != nil {
+			return fmt.Errorf("statement %d: %v", i+1, err)
+		}
+
+		err = wb.AddSheet(fmt.Sprintf("Statement %d", i+1), rows)
+		rows.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return wb.Close()
+}
+
 // Execute executes the root command
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRootXLSXOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "askgit-output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "report.xlsx")
+	rootCmd.SetArgs([]string{"SELECT 1 AS a; SELECT 'b' AS b", "--format", "xlsx", "--output", path})
+	defer func() { format, output = "table", "" }()
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("expected --output to be a workbook: %v", err)
+	}
+	defer r.Close()
+
+	var sheets = make(map[string]bool)
+	for _, f := range r.File {
+		sheets[f.Name] = true
+	}
+	for _, name := range []string{"xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"} {
+		if !sheets[name] {
+			t.Fatalf("expected a worksheet per statement, missing %s", name)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"os"
+
+	"github.com/askgitdev/askgit/pkg/audit"
+	"github.com/askgitdev/askgit/pkg/jsonrpc"
+	"github.com/askgitdev/askgit/pkg/query"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rpcCmd.Flags().StringVar(&auditLog, "audit-log", "", "append a JSON record of every executed query to this file ('-' for stderr)")
+}
+
+var rpcCmd = &cobra.Command{
+	Use:   "rpc",
+	Short: "serve queries over a JSON-RPC 2.0 interface on stdin / stdout",
+	Long: `Use this command to drive askgit from another program (a notebook for instance).
+Requests and responses are newline-delimited JSON-RPC 2.0 messages exchanged over stdin and stdout.
+
+Methods:
+  query  {"sql": "SELECT ...", "columnar": false, "arrow": false}
+         returns {"columns": [...], "types": [...], "rows": [[...], ...]}
+         or {"columns": [...], "types": [...], "data": {"column": [...], ...}} if columnar is set
+         or {"columns": [...], "types": [...], "arrow": "..."} if arrow is set, with the result set
+         as a base64 encoded Arrow IPC stream (read it with pyarrow.ipc.open_stream for instance)`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		var db *sql.DB
+		if db, err = sql.Open("sqlite3", ":memory:"); err != nil {
+			log.Fatalf("failed to initialize database connection: %v", err)
+		}
+		defer db.Close()
+
+		logger, closeAuditLog := openAuditLog(auditLog)
+		defer closeAuditLog()
+
+		var srv = jsonrpc.NewServer()
+		srv.Handle("query", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+			var p struct {
+				SQL      string `json:"sql"`
+				Columnar bool   `json:"columnar"`
+				Arrow    bool   `json:"arrow"`
+			}
+			if err := json.Unmarshal(params, &p); err != nil || p.SQL == "" {
+				return nil, jsonrpc.Errorf(jsonrpc.InvalidParams, "expected params of the form {\"sql\": \"...\"}")
+			}
+
+			ctx, entry := logger.Begin(ctx, p.SQL)
+			conn, release, err := audit.Conn(ctx, db)
+			if err != nil {
+				_ = logger.End(entry, 0, err)
+				return nil, err
+			}
+			defer release()
+
+			res, err := query.Run(ctx, conn, p.SQL)
+			if err != nil {
+				_ = logger.End(entry, 0, err)
+				return nil, err
+			}
+			_ = logger.End(entry, len(res.Rows), nil)
+
+			if p.Arrow {
+				var buf bytes.Buffer
+				if err := res.WriteArrow(&buf); err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{"columns": res.Columns, "types": res.Types, "arrow": buf.Bytes()}, nil
+			}
+			if p.Columnar {
+				return map[string]interface{}{"columns": res.Columns, "types": res.Types, "data": res.Columnar()}, nil
+			}
+			return res, nil
+		})
+
+		if err = srv.Serve(context.Background(), os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("rpc server failed: %v", err)
+		}
+	},
+}
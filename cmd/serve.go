@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/auth"
+	"github.com/askgitdev/askgit/pkg/health"
+	"github.com/askgitdev/askgit/pkg/sandbox"
+	"github.com/askgitdev/askgit/pkg/server"
+	"github.com/spf13/cobra"
+)
+
+var listenAddr string // address the server listens on
+var grpcAddr string   // address the gRPC service listens on, if any
+var serveToken string // bearer token clients must present
+var serveKeys string  // path to the keyring holding API keys and roles
+
+var serveUnsafe bool              // disable the query sandbox
+var serveMaxRows int              // maximum number of rows returned per query
+var serveTimeout time.Duration    // maximum duration of a query
+var serveBannedFunctions []string // functions clients may not call
+var serveDebug bool               // expose the profiles and runtime metrics of the server
+
+func init() {
+	serveCmd.Flags().StringVar(&listenAddr, "addr", ":8080", "address to listen on")
+	serveCmd.Flags().StringVar(&grpcAddr, "grpc", "", "if set, also serve queries over gRPC on this address (e.g. :9090)")
+	serveCmd.Flags().StringVar(&serveToken, "token", os.Getenv("ASKGIT_SERVE_TOKEN"), "if set, clients must present this bearer token (defaults to $ASKGIT_SERVE_TOKEN)")
+	serveCmd.Flags().StringVar(&serveKeys, "keys", "", "authenticate clients with the API keys in this keyring, see 'askgit keys'")
+	serveCmd.Flags().StringVar(&auditLog, "audit-log", "", "append a JSON record of every executed query to this file ('-' for stderr)")
+	serveCmd.Flags().BoolVar(&serveUnsafe, "unsafe", false, "disable the sandbox, allowing clients to run any statement without limits")
+	serveCmd.Flags().IntVar(&serveMaxRows, "max-rows", 10000, "maximum number of rows returned per query (0 for unlimited)")
+	serveCmd.Flags().DurationVar(&serveTimeout, "timeout", 30*time.Second, "maximum duration of a query (0 for unlimited)")
+	serveCmd.Flags().StringSliceVar(&serveBannedFunctions, "ban-function", sandbox.DefaultBannedFunctions, "functions and table-valued functions clients are not allowed to call")
+	serveCmd.Flags().BoolVar(&serveDebug, "debug", false, "expose the profiles of the server under /debug/pprof/ and the metrics of the Go runtime under /debug/runtime, behind the same authentication as queries")
+	serveCmd.Flags().DurationVar(&shutdownTimeout, "shutdown-timeout", 25*time.Second, "how long queries in flight are drained for on SIGTERM (or SIGINT) before being interrupted, within the grace period of Kubernetes")
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "serve queries over HTTP",
+	Long: `Use this command to run askgit as a long-running service.
+Queries are submitted with a POST request to /v1/query with a body of {"sql": "...", "batch_size": 100}
+and results are streamed back as newline-delimited JSON: a header with the column names and types,
+followed by batches of rows and a trailer reporting the row count or any error.
+
+With --grpc, the same queries can be run with the ExecuteQuery call of the askgit.v1.Query service
+(see pkg/server/pb/query.proto), which streams back the same messages, behind the same authentication.
+
+Unless --unsafe is passed, queries run in a sandbox: only a single SELECT statement is allowed,
+on a read-only connection, with a limit on the number of rows returned and the query duration.
+
+With --debug, the profiles of the server are served under /debug/pprof/ (see go tool pprof),
+and the metrics of the Go runtime (memory, goroutines, GC) as JSON under /debug/runtime.
+
+The liveness and readiness probes of the server are served, without authentication, under /healthz and /readyz:
+the server is live while its database is reachable, and ready while its GitHub token is valid (if it has one).
+
+On SIGTERM (or SIGINT), the server stops accepting queries, and waits for those in flight to complete
+for up to --shutdown-timeout (or until a second signal) before interrupting them and exiting.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		var dsn = sandbox.DSN
+		if serveUnsafe {
+			dsn = ":memory:"
+		}
+
+		var db *sql.DB
+		if db, err = sql.Open("sqlite3", dsn); err != nil {
+			log.Fatalf("failed to initialize database connection: %v", err)
+		}
+		defer db.Close()
+
+		logger, closeAuditLog := openAuditLog(auditLog)
+		defer closeAuditLog()
+
+		var checker = &health.Checker{Live: []*health.Check{{Name: "database", Fn: health.Ping(db)}}}
+		if check := githubTokenCheck(); check != nil {
+			checker.Ready = append(checker.Ready, check)
+		}
+
+		var srv = &server.Server{DB: db, Audit: logger, Debug: serveDebug, Health: checker}
+		switch {
+		case serveKeys != "" && serveToken != "":
+			log.Fatal("only one of --keys and --token can be used")
+		case serveKeys != "":
+			var keyring *auth.Keyring
+			if keyring, err = auth.Load(serveKeys); err != nil {
+				log.Fatalf("failed to load keyring: %v", err)
+			}
+
+			var tables []string
+			if tables, err = listTables(context.Background(), db); err != nil {
+				log.Fatalf("failed to list tables: %v", err)
+			}
+
+			srv.Interceptors = append(srv.Interceptors, keyring.Interceptor())
+			srv.StreamInterceptors = append(srv.StreamInterceptors, keyring.StreamInterceptor())
+			srv.Authorize = auth.Authorizer(tables)
+		case serveToken != "":
+			srv.Interceptors = append(srv.Interceptors, server.BearerTokenAuth(serveToken))
+			srv.StreamInterceptors = append(srv.StreamInterceptors, server.BearerTokenStreamAuth(serveToken))
+		}
+
+		if !serveUnsafe {
+			srv.Sandbox = &sandbox.Policy{MaxRows: serveMaxRows, Timeout: serveTimeout, BannedFunctions: serveBannedFunctions}
+		}
+
+		var httpServer = &http.Server{Addr: listenAddr, Handler: srv.Handler()}
+		draining, ctx, stop := onShutdown()
+		defer stop()
+
+		var grpcServer = srv.GRPC()
+		if grpcAddr != "" {
+			var lis net.Listener
+			if lis, err = net.Listen("tcp", grpcAddr); err != nil {
+				log.Fatalf("failed to listen on %s: %v", grpcAddr, err)
+			}
+			go func() {
+				log.Printf("serving gRPC on %s", grpcAddr)
+				if err := grpcServer.Serve(lis); err != nil {
+					log.Fatalf("gRPC server failed: %v", err)
+				}
+			}()
+		}
+
+		// once asked to stop, the servers accept no more queries, and wait for those in flight until ctx is done.
+		// Both are drained at once, so that neither keeps accepting queries while the other drains.
+		var shutdown = make(chan error, 1)
+		go func() {
+			<-draining
+			checker.Drain()
+			var stopped = make(chan struct{})
+			go func() {
+				grpcServer.GracefulStop()
+				close(stopped)
+			}()
+
+			var err = httpServer.Shutdown(ctx)
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				grpcServer.Stop()
+				err = ctx.Err()
+			}
+			shutdown <- err
+		}()
+
+		log.Printf("listening on %s", listenAddr)
+		if err = httpServer.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatalf("server failed: %v", err)
+		}
+		if err = <-shutdown; err != nil {
+			// the queries still running are interrupted, as their connections are closed
+			_ = httpServer.Close()
+			log.Print("in-flight queries were interrupted")
+		}
+		log.Print("server stopped")
+	},
+}
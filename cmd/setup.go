@@ -1,23 +1,234 @@
 package cmd
 
 import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/audit"
+	"github.com/askgitdev/askgit/pkg/httpcache"
 	"github.com/askgitdev/askgit/pkg/locator"
+	"github.com/askgitdev/askgit/pkg/plugins"
+	"github.com/askgitdev/askgit/pkg/replay"
+	"github.com/askgitdev/askgit/pkg/seal"
+	"github.com/askgitdev/askgit/pkg/secrets"
+	"github.com/askgitdev/askgit/pkg/transport"
+	"github.com/askgitdev/askgit/pkg/udf"
 	"github.com/askgitdev/askgit/tables"
+	"github.com/dnaeon/go-vcr/v2/recorder"
 	"go.riyazali.net/sqlite"
+	"golang.org/x/time/rate"
 
 	// bring in sqlite 🙌
 	_ "github.com/askgitdev/askgit/pkg/sqlite"
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// apiBudget is the budget every API request of the process draws from, unlimited unless --api-budget
+// (or the budget of the sync configuration) is set
+var apiBudget = rate.NewLimiter(rate.Inf, 1)
+
+// setAPIBudget caps the rate of the API requests at the budget s, e.g. 5000/h
+func setAPIBudget(s string) error {
+	limit, err := transport.ParseBudget(s)
+	if err != nil {
+		return err
+	}
+	apiBudget.SetLimit(limit)
+	return nil
+}
+
+// apiTransport returns the base transport of the API clients, honouring the proxy and TLS flags,
+// within the API budget, behind a circuit breaker failing requests to hosts that keep failing fast
+// (without drawing from the budget)
+func apiTransport() (http.RoundTripper, error) {
+	t, err := transport.New(&transportOpts)
+	if err != nil {
+		return nil, err
+	}
+	var budget = &transport.Budget{Base: t, Limiter: apiBudget}
+	return &transport.Breaker{Base: budget, Threshold: breakerThreshold, Cooldown: breakerCooldown}, nil
+}
+
+// githubTransport is the transport of the requests to the GitHub API made outside of tables,
+// through the response cache and with the token of --github-token-source, as those of the tables
+var githubTransport http.RoundTripper
+
+// githubCalls counts the requests made to the GitHub API, so they can be attributed to queries in the audit log
+var githubCalls = &audit.CountingTransport{}
+
+// bundle records or replays API responses, if --record or --replay is used
+var bundle *recorder.Recorder
+
+// stopRecording writes out the recorded fixture bundle, if any
+func stopRecording() {
+	if bundle != nil {
+		if err := bundle.Stop(); err != nil {
+			log.Fatalf("failed to save fixture bundle: %v", err)
+		}
+	}
+}
+
+// encryptionKey is the key data is encrypted at rest with, if --encryption-key-source is used
+var encryptionKey []byte
+
+// defaultEncryptionKeySource returns $ASKGIT_ENCRYPTION_KEY_SOURCE, or $ASKGIT_ENCRYPTION_KEY if only the key itself is set
+func defaultEncryptionKeySource() string {
+	if source := os.Getenv("ASKGIT_ENCRYPTION_KEY_SOURCE"); source != "" {
+		return source
+	}
+	if os.Getenv("ASKGIT_ENCRYPTION_KEY") != "" {
+		return "env://ASKGIT_ENCRYPTION_KEY"
+	}
+	return ""
+}
+
+// loadEncryptionKey reads the key data is encrypted at rest with from --encryption-key-source
+func loadEncryptionKey() {
+	if encryptionKeySource == "" {
+		return
+	}
+
+	fetcher, err := secrets.Parse(encryptionKeySource)
+	if err != nil {
+		log.Fatalf("invalid encryption key source: %v", err)
+	}
+
+	secret, err := fetcher.Fetch(context.Background())
+	if err != nil {
+		log.Fatalf("failed to read encryption key: %v", err)
+	}
+
+	if encryptionKey, err = seal.ParseKey(secret); err != nil {
+		log.Fatalf("invalid encryption key: %v", err)
+	}
+}
+
 func registerExt() {
-	sqlite.Register(
-		tables.RegisterFn(
-			tables.WithExtraFunctions(),
-			tables.WithRepoLocator(locator.CachedLocator(locator.MultiLocator())),
-			tables.WithContextValue("defaultRepoPath", repo),
-			tables.WithGitHub(),
-			tables.WithContextValue("githubToken", githubToken),
-		),
-	)
+	var err error
+	loadEncryptionKey()
+
+	// without a token, the one the GitHub CLI was authenticated with is reused, if it was
+	if githubToken == "" && githubTokenSource == "" {
+		if token, err := (&secrets.GHCLI{Host: os.Getenv("GH_HOST")}).Fetch(context.Background()); err == nil {
+			githubToken = token
+		}
+	}
+
+	if githubArgs != "strict" && githubArgs != "lenient" {
+		log.Fatalf("invalid --github-args %q, expected strict or lenient", githubArgs)
+	}
+
+	if apiBudgetFlag != "" {
+		if err = setAPIBudget(apiBudgetFlag); err != nil {
+			log.Fatalf("invalid --api-budget: %v", err)
+		}
+	}
+
+	if githubCalls.Base, err = apiTransport(); err != nil {
+		log.Fatalf("failed to configure API client: %v", err)
+	}
+	if transportOpts.InsecureSkipVerify {
+		log.Printf("warning: TLS certificate verification of API servers is disabled")
+	}
+
+	// record to or replay from a fixture bundle, in place of the network
+	switch {
+	case recordBundle != "" && replayBundle != "":
+		log.Fatal("only one of --record and --replay can be used")
+	case recordBundle != "":
+		if bundle, err = replay.Open(recordBundle, replay.Record, githubCalls.Base); err != nil {
+			log.Fatalf("failed to record fixture bundle: %v", err)
+		}
+		githubCalls.Base = bundle
+	case replayBundle != "":
+		if bundle, err = replay.Open(replayBundle, replay.Replay, nil); err != nil {
+			log.Fatalf("failed to open fixture bundle: %v", err)
+		}
+		githubCalls.Base = bundle
+	}
+
+	// requests go through the response cache, if enabled, before being counted and sent out
+	var rt http.RoundTripper = githubCalls
+	if cacheResponses || cacheTTL > 0 || offline {
+		rt = &httpcache.Transport{Dir: cacheDir, TTL: cacheTTL, Offline: offline, Base: rt, Key: encryptionKey}
+	}
+
+	var locatorOpts = []locator.CacheOption{locator.WithPrefetchWorkers(parallelism)}
+	if snapshotRef != "" {
+		locatorOpts = append(locatorOpts, locator.WithSnapshot(snapshotRef))
+	}
+
+	// the tables of services other than GitHub share the same transport, without the GitHub credentials
+	var opts = []tables.OptionFn{
+		tables.WithTransport(rt),
+		tables.WithExtraFunctions(),
+		tables.WithRepoLocator(locator.CachedLocator(locator.MultiLocator(), locatorOpts...)),
+		tables.WithContextValue("defaultRepoPath", repo),
+		tables.WithContextValue("signingKeyring", signingKeyring),
+		tables.WithContextValue("sandboxed", strconv.FormatBool(sandboxed)),
+		tables.WithContextValue("trustedKeys", trustedKeys),
+		tables.WithGitHub(),
+		tables.WithContextValue("githubToken", githubToken),
+		tables.WithContextValue("githubArgs", githubArgs),
+		tables.WithContextValue("githubExcludeBots", strconv.FormatBool(excludeBots)),
+		tables.WithContextValue("githubPrefetch", strconv.FormatBool(githubPrefetch)),
+		tables.WithContextValue("gerritUser", os.Getenv("GERRIT_USER")),
+		tables.WithContextValue("gerritPassword", os.Getenv("GERRIT_PASSWORD")),
+		tables.WithContextValue("phabricatorToken", os.Getenv("PHABRICATOR_TOKEN")),
+		tables.WithContextValue("azureDevOpsToken", os.Getenv("AZURE_DEVOPS_EXT_PAT")),
+		tables.WithContextValue("jenkinsUser", os.Getenv("JENKINS_USER")),
+		tables.WithContextValue("jenkinsToken", os.Getenv("JENKINS_TOKEN")),
+		tables.WithContextValue("pagerdutyToken", os.Getenv("PAGERDUTY_TOKEN")),
+		tables.WithContextValue("opsgenieKey", os.Getenv("OPSGENIE_API_KEY")),
+		tables.WithContextValue("opsgenieURL", os.Getenv("OPSGENIE_API_URL")),
+		tables.WithContextValue("sentryToken", os.Getenv("SENTRY_AUTH_TOKEN")),
+		tables.WithContextValue("sentryURL", os.Getenv("SENTRY_URL")),
+		tables.WithContextValue("codecovToken", os.Getenv("CODECOV_API_TOKEN")),
+		tables.WithContextValue("sonarToken", os.Getenv("SONAR_TOKEN")),
+		tables.WithContextValue("stackExchangeKey", os.Getenv("STACKEXCHANGE_KEY")),
+	}
+
+	// read the token from a secret store, re-reading it whenever GitHub rejects it
+	if githubTokenSource != "" {
+		fetcher, err := secrets.Parse(githubTokenSource)
+		if err != nil {
+			log.Fatalf("invalid GitHub token source: %v", err)
+		}
+
+		var source = secrets.NewCached(fetcher, githubTokenTTL)
+		rt = &secrets.Transport{Source: source, Base: rt}
+		opts = append(opts, tables.WithGitHubTokenSource(source))
+	}
+
+	opts = append(opts, tables.WithGitHubTransport(rt))
+	githubTransport = rt
+
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			log.Fatalf("invalid --timezone: %v", err)
+		}
+		opts = append(opts, tables.WithTimezone(loc))
+	}
+
+	if len(pluginPaths) > 0 {
+		providers, err := plugins.Load(pluginPaths...)
+		if err != nil {
+			log.Fatalf("failed to load plugins: %v", err)
+		}
+		opts = append(opts, tables.WithProviders(providers...))
+	}
+
+	for _, script := range udfScripts {
+		provider, err := udf.Load(script)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, tables.WithProviders(provider))
+	}
+	sqlite.Register(tables.RegisterFn(opts...))
 }
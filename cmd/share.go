@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/askgitdev/askgit/pkg/actions"
+	"github.com/askgitdev/askgit/pkg/display"
+	"github.com/askgitdev/askgit/pkg/library"
+	"github.com/spf13/cobra"
+)
+
+var shareResults bool       // attach the results of the query to the gist
+var sharePublic bool        // create a public gist rather than a secret one
+var shareDescription string // description of the gist
+
+func init() {
+	shareCmd.Flags().BoolVar(&shareResults, "results", false, "run the query and attach its results to the gist, as CSV")
+	shareCmd.Flags().StringToStringVar(&queryParams, "param", nil, "value of a named parameter of the query (:name) the results are attached for, overriding its default")
+	shareCmd.Flags().BoolVar(&sharePublic, "public", false, "create a public gist, rather than a secret one (reachable by its URL only)")
+	shareCmd.Flags().StringVar(&shareDescription, "description", "", "description of the gist (defaults to that of the query)")
+	shareCmd.Flags().StringVarP(&repo, "repo", "r", ".", "specify a path to a default repo on disk. This will be used if no repo is supplied as an argument to a git table")
+	shareCmd.ValidArgsFunction = completeSavedQueries
+}
+
+var shareCmd = &cobra.Command{
+	Use:   "share [saved query] --results",
+	Short: "share a saved query as a gist",
+	Long: `Use this command to upload a saved query as a gist, printing its URL to circulate it.
+The gist holds the SQL of the query, the query in the JSON format of the library (to be added to another one
+with askgit save --import), and with --results the results of the query as CSV.
+Gists are created with the GitHub token, which needs the gist scope, and are secret unless --public is passed.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var ctx = context.Background()
+
+		q, ok := loadLibrary().Get(args[0])
+		if !ok {
+			log.Fatalf("no saved query %s, see askgit list", args[0])
+		}
+		if githubToken == "" {
+			log.Fatal("sharing a query needs a GitHub token with the gist scope, see GITHUB_TOKEN")
+		}
+
+		shared, err := json.MarshalIndent([]*library.Query{q}, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		var files = map[string]string{
+			q.Name + ".sql":  q.SQL + "\n",
+			q.Name + ".json": string(shared) + "\n",
+		}
+
+		if shareResults {
+			values, err := q.Values(queryParams)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if files[q.Name+".csv"], err = resultsCSV(q.SQL, values); err != nil {
+				log.Fatalf("failed to run the query: %v", err)
+			}
+		}
+
+		var description = shareDescription
+		if description == "" {
+			description = q.Description
+		}
+		if description == "" {
+			description = fmt.Sprintf("askgit query %s", q.Name)
+		}
+
+		rt, err := apiTransport()
+		if err != nil {
+			log.Fatalf("failed to configure API client: %v", err)
+		}
+		var client = &actions.Client{HTTP: &http.Client{Transport: rt}, Token: githubToken}
+		gist, err := client.CreateGist(ctx, description, sharePublic, files)
+		if err != nil {
+			log.Fatalf("failed to create the gist: %v", err)
+		}
+		fmt.Println(gist.URL)
+	},
+}
+
+// resultsCSV runs the query, with the values of its named parameters, returning its results as CSV
+func resultsCSV(query string, values map[string]string) (string, error) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(query, library.NamedArgs(query, values)...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var buf bytes.Buffer
+	if err = display.WriteTo(rows, &buf, "csv", false); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+var shutdownTimeout time.Duration // how long in-flight work is drained for once asked to stop
+
+// onShutdown returns a channel closed once the process is asked to stop (by SIGTERM or SIGINT), so that it starts
+// no more work, and a context cancelled --shutdown-timeout later, or on a second signal, interrupting the work
+// still in flight. stop releases the signals once the work is done.
+func onShutdown() (draining <-chan struct{}, ctx context.Context, stop func()) {
+	var signals = make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+
+	var drain = make(chan struct{})
+	var done = make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		select {
+		case sig := <-signals:
+			log.Printf("received %s, draining in-flight work for up to %s", sig, shutdownTimeout)
+			close(drain)
+		case <-done:
+			return
+		}
+
+		var deadline = time.NewTimer(shutdownTimeout)
+		defer deadline.Stop()
+		select {
+		case <-deadline.C:
+			log.Print("in-flight work didn't complete in time, interrupting it")
+		case sig := <-signals:
+			log.Printf("received %s again, interrupting in-flight work", sig)
+		case <-done:
+			return
+		}
+		cancel()
+	}()
+
+	return drain, ctx, func() {
+		signal.Stop(signals)
+		close(done)
+		cancel()
+	}
+}
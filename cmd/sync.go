@@ -0,0 +1,483 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/actions"
+	"github.com/askgitdev/askgit/pkg/display"
+	"github.com/askgitdev/askgit/pkg/events"
+	"github.com/askgitdev/askgit/pkg/materialize"
+	"github.com/askgitdev/askgit/pkg/redact"
+	"github.com/spf13/cobra"
+)
+
+var syncConfig string // path of the sync configuration
+var syncDryRun bool   // only print the migrations sync migrate would apply
+var syncWorkers int   // tables synced concurrently
+
+var backfillSince string  // start of the backfilled history
+var backfillUntil string  // end of the backfilled history
+var backfillWindow string // length of the windows history is backfilled in
+var backfillRestart bool  // backfill from --since, even if a previous backfill went further
+
+var syncAsOf string // time versioned tables are queried as of
+var syncVacuum bool // vacuum the database after pruning, whatever the configuration says
+
+var diffTable string  // versioned table whose snapshots are diffed
+var diffBefore string // sync run (or time) diffed from
+var diffAfter string  // sync run (or time) diffed to
+var diffFormat string // format of the diff
+
+func init() {
+	syncCmd.PersistentFlags().StringVarP(&syncConfig, "config", "c", "askgit-sync.yaml", "path of the sync configuration, listing the tables to sync and their queries")
+	syncCmd.PersistentFlags().StringVar(&redactSalt, "redact-salt", os.Getenv("ASKGIT_REDACT_SALT"), "secret key of the hashes redacted data is replaced with (defaults to $ASKGIT_REDACT_SALT)")
+	syncCmd.PersistentFlags().DurationVar(&shutdownTimeout, "shutdown-timeout", 25*time.Second, "how long the tables (or backfill windows) being synced are drained for on SIGTERM (or SIGINT) before being interrupted, within the grace period of Kubernetes")
+	syncCmd.PersistentFlags().StringVar(&leaderLock, "leader-lock", envOr("ASKGIT_LEADER_LOCK", ""), "lock replicas of sync run and backfill compete for, only the leader syncing: file:///path, consul://host:port/key or etcd://host:port/key (defaults to $ASKGIT_LEADER_LOCK)")
+	syncCmd.PersistentFlags().BoolVar(&leaderWait, "leader-wait", false, "wait to become the leader, rather than exiting if another replica is")
+	syncCmd.PersistentFlags().StringVar(&healthAddr, "health-addr", "", "serve the liveness (/healthz) and readiness (/readyz) probes of sync run and backfill on this address, e.g. :8081")
+
+	syncRunCmd.Flags().IntVar(&syncWorkers, "workers", 0, "number of tables synced concurrently, in the order of their dependencies (defaults to the workers of the configuration, or 4)")
+
+	syncMigrateCmd.Flags().BoolVar(&syncDryRun, "dry-run", false, "print the migrations without applying them")
+
+	syncBackfillCmd.Flags().StringVar(&backfillSince, "since", "", "date (2006-01-02) or time (RFC 3339) to backfill from")
+	_ = syncBackfillCmd.MarkFlagRequired("since")
+	syncBackfillCmd.Flags().StringVar(&backfillUntil, "until", "", "date (2006-01-02) or time (RFC 3339) to backfill up to (defaults to now)")
+	syncBackfillCmd.Flags().StringVar(&backfillWindow, "window", "30d", "length of the windows history is synced in, e.g. 30d, 2w or 12h")
+	syncBackfillCmd.Flags().BoolVar(&backfillRestart, "restart", false, "backfill from --since, rather than from where the last backfill or sync stopped")
+
+	syncQueryCmd.Flags().StringVar(&syncAsOf, "as-of", "", "date (2006-01-02) or time (RFC 3339) to query versioned tables as of")
+	syncQueryCmd.Flags().StringVarP(&format, "format", "f", "table", "specify the output format. Options are 'csv' 'tsv' 'table' 'single' 'json' and 'markdown'")
+	addDisplayFlags(syncQueryCmd)
+
+	syncDiffCmd.Flags().StringVar(&diffTable, "table", "", "versioned table to diff")
+	_ = syncDiffCmd.RegisterFlagCompletionFunc("table", completeSyncTables)
+	_ = syncDiffCmd.MarkFlagRequired("table")
+	syncDiffCmd.Flags().StringVar(&diffBefore, "before", "", "sync run (an id of the askgit_sync_runs table), date (2006-01-02) or time (RFC 3339) to diff from")
+	_ = syncDiffCmd.MarkFlagRequired("before")
+	syncDiffCmd.Flags().StringVar(&diffAfter, "after", "", "sync run, date or time to diff to (defaults to now)")
+	syncDiffCmd.Flags().StringVar(&diffFormat, "format", "text", "format of the diff, 'text' or 'json'")
+
+	syncPruneCmd.Flags().BoolVar(&syncVacuum, "vacuum", false, "vacuum the database once rows are pruned, even if the configuration doesn't")
+
+	// the commands taking tables complete the names of those of the configuration
+	for _, c := range []*cobra.Command{syncRunCmd, syncMigrateCmd, syncBackfillCmd, syncPruneCmd} {
+		c.ValidArgsFunction = completeSyncTables
+	}
+
+	syncCmd.AddCommand(syncRunCmd, syncMigrateCmd, syncBackfillCmd, syncPruneCmd, syncQueryCmd, syncDiffCmd)
+}
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "materialize the results of queries as tables of a SQLite database",
+	Long: `Use these commands to keep the results of queries materialized as tables of a SQLite database,
+described by a configuration file (see --config), so that they can be queried without hitting the APIs again.`,
+}
+
+var syncRunCmd = &cobra.Command{
+	Use:   "run [tables...]",
+	Short: "sync every table, or the given ones",
+	Long: `Use this command to sync every table of the configuration, or the given ones.
+Independent tables are synced concurrently (see --workers), and tables after those they depend on (see depends_on),
+all drawing from the API budget of the configuration (unless --api-budget is set), which is shared between
+the organizations of the tables (see orgs), and tables with a low priority deferred once the quota of the token is under its reserve.
+Tables whose columns changed since they were last synced are migrated first, and tables with a retention are pruned afterwards.
+It exits with a non-zero status if a table fails to sync, or fails its checks, whose sync is rolled back.
+The tables depending on a table that failed aren't synced, the others still are.
+On SIGTERM (or SIGINT), the tables being synced are finished, for up to --shutdown-timeout, and the others left for the next run.
+With --leader-lock, replicas compete for a lock and only the one holding it syncs, the others exiting (or waiting, with --leader-wait).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config, runner := openSync()
+		defer runner.DB.Close()
+
+		if config.Budget != "" && apiBudgetFlag == "" {
+			if err := setAPIBudget(config.Budget); err != nil {
+				log.Fatalf("invalid budget in %s: %v", syncConfig, err)
+			}
+		}
+
+		var workers = syncWorkers
+		if workers <= 0 {
+			workers = config.Workers
+		}
+		if workers <= 0 {
+			workers = 4
+		}
+
+		// once asked to stop, the tables being synced are finished, and the others left for the next run
+		draining, ctx, stop := onShutdown()
+		defer stop()
+		serveHealth(syncChecker(runner.DB), draining)
+
+		// with --leader-lock, only the leader syncs, and stops if it's no longer the leader
+		stopping, release, leading := electLeader(ctx, draining)
+		if !leading {
+			return
+		}
+		defer release()
+		runner.Stop = stopping
+
+		// the API quota is shared between the organizations of the tables, those with a low priority
+		// being deferred once it's scarce
+		runner.Scheduler = &materialize.Scheduler{Weights: config.Weights(), Calls: githubCalls.Count}
+		if config.Reserve > 0 && githubToken != "" {
+			rt, err := apiTransport()
+			if err != nil {
+				log.Fatalf("failed to configure API client: %v", err)
+			}
+			runner.Scheduler.Quota = githubQuota{&actions.Client{HTTP: &http.Client{Transport: rt}, Token: githubToken}}
+			runner.Scheduler.Reserve = config.Reserve
+		}
+
+		// tables failing to sync are rolled back, and reported once the others are synced
+		var failed bool
+		var pruned int64
+		err := runner.SyncAll(ctx, syncTables(config, args), workers, func(t *materialize.Table, n int64, err error) {
+			if err == materialize.ErrDeferred {
+				log.Printf("%s: %v", t.Name, err)
+				return
+			}
+			if err == materialize.ErrStopped {
+				err = fmt.Errorf("%s: not synced, %v", t.Name, err)
+			}
+			if err != nil {
+				log.Print(err)
+				failed = true
+				return
+			}
+			log.Printf("%s: %d rows written", t.Name, n)
+
+			if n, err = pruneTable(runner, t); err != nil {
+				log.Print(err)
+				failed = true
+				return
+			}
+			pruned += n
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if config.Vacuum && pruned > 0 {
+			if err := runner.Vacuum(context.Background()); err != nil {
+				log.Fatalf("failed to vacuum the database: %v", err)
+			}
+		}
+
+		if failed {
+			release()
+			runner.DB.Close()
+			stopRecording()
+			stopProfile()
+			os.Exit(1)
+		}
+	},
+}
+
+var syncMigrateCmd = &cobra.Command{
+	Use:   "migrate [tables...]",
+	Short: "migrate tables to the columns their queries return",
+	Long: `Use this command to migrate the tables of the configuration, or the given ones, to the columns their queries return,
+for instance after upgrading askgit. New columns are added and backfilled, and columns that are no longer returned are kept.
+Tables synced incrementally (windowed, or following events) are backfilled by syncing their history again, in full,
+up to where their last sync stopped, which the next sync carries on from. A sync migrating them itself only fills
+the new columns of the rows it syncs, so migrate them ahead of it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config, runner := openSync()
+		defer runner.DB.Close()
+
+		for _, t := range syncTables(config, args) {
+			var plan *materialize.Plan
+			var err error
+			if syncDryRun {
+				plan, err = runner.Plan(context.Background(), t)
+			} else {
+				plan, err = runner.Migrate(context.Background(), t)
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(plan)
+		}
+	},
+}
+
+var syncBackfillCmd = &cobra.Command{
+	Use:   "backfill --since [date] [tables...]",
+	Short: "sync the history of windowed tables, one window at a time",
+	Long: `Use this command to load the history of the windowed tables of the configuration (those whose query references :since and :until),
+or of the given ones, one window at a time, so that multi-year loads don't exhaust API rate limits.
+An interrupted backfill resumes from the last window synced.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		since, err := parseTime(backfillSince)
+		if err != nil {
+			log.Fatalf("invalid --since: %v", err)
+		}
+
+		var until = time.Now().UTC()
+		if backfillUntil != "" {
+			if until, err = parseTime(backfillUntil); err != nil {
+				log.Fatalf("invalid --until: %v", err)
+			}
+		}
+
+		window, err := materialize.ParseWindow(backfillWindow)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		config, runner := openSync()
+		defer runner.DB.Close()
+
+		// once asked to stop, the window being synced is finished, and the backfill resumes from there when run again
+		draining, ctx, stop := onShutdown()
+		defer stop()
+		serveHealth(syncChecker(runner.DB), draining)
+
+		stopping, release, leading := electLeader(ctx, draining)
+		if !leading {
+			return
+		}
+		defer release()
+		runner.Stop = stopping
+
+		var tables = syncTables(config, args)
+		for _, t := range tables {
+			if !t.Windowed() {
+				if len(args) > 0 {
+					log.Fatalf("table %s can't be backfilled, its query doesn't reference :since or :until", t.Name)
+				}
+				continue
+			}
+
+			n, err := runner.Backfill(ctx, t, since, until, window, backfillRestart)
+			if err == materialize.ErrStopped {
+				log.Printf("%s: %d rows backfilled before stopping, run the backfill again to resume", t.Name, n)
+				release()
+				runner.DB.Close()
+				stopRecording()
+				stopProfile()
+				os.Exit(1)
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+			log.Printf("%s: %d rows backfilled", t.Name, n)
+		}
+	},
+}
+
+var syncPruneCmd = &cobra.Command{
+	Use:   "prune [tables...]",
+	Short: "prune the rows of every table older than its retention, or of the given ones",
+	Long: `Use this command to delete the rows of the tables of the configuration (or of the given ones) older than their retention keeps,
+as askgit sync run does after syncing them. The database is vacuumed afterwards if the configuration (or --vacuum) says so.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config, runner := openSync()
+		defer runner.DB.Close()
+
+		var pruned int64
+		for _, t := range syncTables(config, args) {
+			n, err := pruneTable(runner, t)
+			if err != nil {
+				log.Fatal(err)
+			}
+			pruned += n
+		}
+
+		if (config.Vacuum || syncVacuum) && pruned > 0 {
+			if err := runner.Vacuum(context.Background()); err != nil {
+				log.Fatalf("failed to vacuum the database: %v", err)
+			}
+		}
+	},
+}
+
+// pruneTable prunes the rows of t older than its retention, returning how many were deleted
+func pruneTable(runner *materialize.Runner, t *materialize.Table) (int64, error) {
+	n, err := runner.Prune(context.Background(), t)
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		log.Printf("%s: %d rows pruned", t.Name, n)
+	}
+	return n, nil
+}
+
+var syncQueryCmd = &cobra.Command{
+	Use:   `query [--as-of date] "SELECT * FROM issues"`,
+	Short: "query the synced tables, optionally as they were at a point in time",
+	Long: `Use this command to query the database tables are synced to.
+With --as-of, versioned tables (those with versioned: true) return their rows as they were synced at that time,
+read from their history, so that questions such as "how many issues were open on March 1" can be answered after the fact.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var ctx = context.Background()
+
+		opts, err := tableOptions(true)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		config, runner := openSync()
+		defer runner.DB.Close()
+
+		// the tables are shadowed by temporary views, which only exist on the connection they're created on
+		conn, err := runner.DB.Conn(ctx)
+		if err != nil {
+			log.Fatalf("failed to open sqlite database: %v", err)
+		}
+		defer conn.Close()
+
+		if syncAsOf != "" {
+			at, err := parseTime(syncAsOf)
+			if err != nil {
+				log.Fatalf("invalid --as-of: %v", err)
+			}
+			if err = materialize.AsOf(ctx, conn, config.Tables, at); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		rows, err := conn.QueryContext(ctx, args[0])
+		if err != nil {
+			log.Fatalf("query execution failed: %v", err)
+		}
+		defer rows.Close()
+
+		if err = display.WriteToWithOptions(rows, os.Stdout, format, false, opts); err != nil {
+			log.Fatalf("failed to output resultset: %v", err)
+		}
+	},
+}
+
+var syncDiffCmd = &cobra.Command{
+	Use:   "diff --table issues --before [run] --after [run]",
+	Short: "diff the snapshots of a versioned table between two sync runs",
+	Long: `Use this command to list the rows of a versioned table (one with versioned: true) added, removed and changed,
+column by column, between two sync runs, so that weekly reports can highlight what changed.
+Runs are the ids of the askgit_sync_runs table, or points in time.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var ctx = context.Background()
+		if diffFormat != "text" && diffFormat != "json" {
+			log.Fatalf("unknown format %q, expected text or json", diffFormat)
+		}
+
+		config, runner := openSync()
+		defer runner.DB.Close()
+
+		var t = config.Table(diffTable)
+		if t == nil {
+			log.Fatalf("no table %s in %s", diffTable, syncConfig)
+		}
+
+		before, err := syncRunTime(ctx, runner.DB, t, diffBefore)
+		if err != nil {
+			log.Fatalf("invalid --before: %v", err)
+		}
+		var after = time.Now().UTC()
+		if diffAfter != "" {
+			if after, err = syncRunTime(ctx, runner.DB, t, diffAfter); err != nil {
+				log.Fatalf("invalid --after: %v", err)
+			}
+		}
+
+		diff, err := materialize.DiffSnapshots(ctx, runner.DB, t, before, after)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if diffFormat == "json" {
+			var enc = json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(diff)
+		} else {
+			err = diff.WriteText(os.Stdout)
+		}
+		if err != nil {
+			log.Fatalf("failed to write diff: %v", err)
+		}
+	},
+}
+
+// syncRunTime returns the time of a sync run of t, given by its id, or a point in time
+func syncRunTime(ctx context.Context, db *sql.DB, t *materialize.Table, s string) (time.Time, error) {
+	if id, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return materialize.RunTime(ctx, db, t, id)
+	}
+	return parseTime(s)
+}
+
+// parseTime parses a date (2006-01-02) or an RFC 3339 time
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// openSync loads the sync configuration and opens its database
+func openSync() (*materialize.Config, *materialize.Runner) {
+	config, err := materialize.LoadConfig(syncConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	redactor, err := redact.Parse(config.Redact, redactSalt)
+	if err != nil {
+		log.Fatalf("invalid redaction: %v", err)
+	}
+
+	db, err := sql.Open("sqlite3", config.Database)
+	if err != nil {
+		log.Fatalf("failed to open sqlite database: %v", err)
+	}
+
+	// tables following events learn what changed from the GitHub API
+	var feed = &events.Client{REST: &actions.Client{HTTP: &http.Client{Transport: githubTransport}, Token: githubToken}}
+
+	return config, &materialize.Runner{DB: db, Redactor: redactor, Logf: log.Printf, Events: feed}
+}
+
+// githubQuota is the quota of the GitHub token, that of the GraphQL API the GitHub tables call
+type githubQuota struct{ client *actions.Client }
+
+func (q githubQuota) Remaining(ctx context.Context) (int64, error) {
+	limits, err := q.client.RateLimits(ctx)
+	if err != nil {
+		return 0, err
+	}
+	graphql, ok := limits["graphql"]
+	if !ok {
+		return 0, fmt.Errorf("no rate limit of the GraphQL API")
+	}
+	return graphql.Remaining, nil
+}
+
+// syncTables returns the tables of the configuration called names, or all of them if names is empty
+func syncTables(config *materialize.Config, names []string) []*materialize.Table {
+	if len(names) == 0 {
+		return config.Tables
+	}
+
+	var tables []*materialize.Table
+	for _, name := range names {
+		t := config.Table(name)
+		if t == nil {
+			log.Fatalf("no table %s in %s", name, syncConfig)
+		}
+		tables = append(tables, t)
+	}
+	return tables
+}
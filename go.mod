@@ -6,7 +6,7 @@ require (
 	github.com/BurntSushi/toml v0.3.1
 	github.com/DATA-DOG/go-sqlmock v1.5.0
 	github.com/Microsoft/go-winio v0.5.0 // indirect
-	github.com/ProtonMail/go-crypto v0.0.0-20210707164159-52430bf6b52c // indirect
+	github.com/ProtonMail/go-crypto v0.0.0-20210707164159-52430bf6b52c
 	github.com/asaskevich/govalidator v0.0.0-20210307081110-f21760c49a8d // indirect
 	github.com/augmentable-dev/vtab v0.0.0-20210717200339-0c8dcfe2033b
 	github.com/clbanning/mxj/v2 v2.5.5
@@ -18,6 +18,7 @@ require (
 	github.com/go-openapi/errors v0.20.0 // indirect
 	github.com/go-openapi/strfmt v0.20.1 // indirect
 	github.com/jedib0t/go-pretty v4.3.0+incompatible
+	github.com/jmespath/go-jmespath v0.4.0
 	github.com/kevinburke/ssh_config v1.1.0 // indirect
 	github.com/libgit2/git2go/v31 v31.4.14
 	github.com/mattn/go-runewidth v0.0.13 // indirect
@@ -29,10 +30,13 @@ require (
 	github.com/spf13/cobra v1.2.1
 	go.mongodb.org/mongo-driver v1.6.0 // indirect
 	go.riyazali.net/sqlite v0.0.0-20210707161919-414349b4032a
-	golang.org/x/crypto v0.0.0-20210711020723-a769d52b0f97 // indirect
+	go.starlark.net v0.0.0-20200306205701-8dd3e2ee1dd5
+	golang.org/x/crypto v0.0.0-20210711020723-a769d52b0f97
 	golang.org/x/net v0.0.0-20210716203947-853a461950ff // indirect
 	golang.org/x/oauth2 v0.0.0-20210628180205-a41e5a781914
 	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c // indirect
 	golang.org/x/term v0.0.0-20210615171337-6886f2dfbf5b
 	golang.org/x/time v0.0.0-20210723032227-1f47c861a9ac
+	google.golang.org/grpc v1.43.0
+	google.golang.org/protobuf v1.27.1
 )
@@ -0,0 +1,371 @@
+// Package actions implements the GitHub Actions side of `askgit action`: reading the inputs of the step,
+// setting its outputs, writing the job summary and commenting on the pull request that triggered the workflow.
+package actions
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/askgitdev/askgit/pkg/display"
+	"github.com/askgitdev/askgit/pkg/query"
+)
+
+// Input returns the value of the input of the step called name, as passed by the runner in $INPUT_<NAME>
+func Input(name string) string {
+	return strings.TrimSpace(os.Getenv("INPUT_" + strings.ToUpper(strings.Replace(name, " ", "_", -1))))
+}
+
+// BoolInput returns the value of the boolean input called name, or def if it isn't set
+func BoolInput(name string, def bool) (bool, error) {
+	var value = Input(name)
+	if value == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("input %s must be true or false, got %q", name, value)
+	}
+	return b, nil
+}
+
+// SetOutput sets the output of the step called name to value, for the steps that follow
+func SetOutput(name, value string) error {
+	var path = os.Getenv("GITHUB_OUTPUT")
+	if path == "" {
+		// runners older than the output file read outputs from workflow commands
+		_, err := fmt.Printf("::set-output name=%s::%s\n", name, escapeCommand(value))
+		return err
+	}
+
+	// values can span several lines, and are framed by a delimiter they can't contain
+	var b = make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	var delimiter = "askgit_" + hex.EncodeToString(b)
+	return appendFile(path, fmt.Sprintf("%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter))
+}
+
+// escapeCommand escapes the value of a workflow command
+func escapeCommand(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// AppendSummary appends markdown to the summary of the job, it reports false if the runner doesn't support summaries
+func AppendSummary(markdown string) (bool, error) {
+	var path = os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return false, nil
+	}
+	return true, appendFile(path, markdown+"\n")
+}
+
+func appendFile(path, s string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.WriteString(s); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// PullRequest returns the repository (as owner/name) and number of the pull request that triggered the workflow,
+// reading the event in $GITHUB_EVENT_PATH. It reports false if the workflow wasn't triggered by a pull request
+// (or a comment on one).
+func PullRequest() (repo string, number int, ok bool, err error) {
+	var path = os.Getenv("GITHUB_EVENT_PATH")
+	if path == "" {
+		return "", 0, false, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", 0, false, err
+	}
+
+	var event struct {
+		PullRequest *struct {
+			Number int `json:"number"`
+		} `json:"pull_request"`
+		Issue *struct {
+			Number      int              `json:"number"`
+			PullRequest *json.RawMessage `json:"pull_request"`
+		} `json:"issue"`
+	}
+	if err = json.Unmarshal(b, &event); err != nil {
+		return "", 0, false, fmt.Errorf("failed to parse event %s: %v", path, err)
+	}
+
+	repo = os.Getenv("GITHUB_REPOSITORY")
+	switch {
+	case event.PullRequest != nil:
+		return repo, event.PullRequest.Number, true, nil
+	case event.Issue != nil && event.Issue.PullRequest != nil:
+		return repo, event.Issue.Number, true, nil
+	}
+	return "", 0, false, nil
+}
+
+// Client is a client of the REST API of GitHub, to comment on pull requests, file issues, create gists and check tokens
+type Client struct {
+	HTTP  *http.Client
+	Token string
+
+	// BaseURL is the URL of the API, $GITHUB_API_URL or https://api.github.com by default
+	BaseURL string
+}
+
+func (c *Client) baseURL() string {
+	if c.BaseURL != "" {
+		return strings.TrimSuffix(c.BaseURL, "/")
+	}
+	if url := os.Getenv("GITHUB_API_URL"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return "https://api.github.com"
+}
+
+// do sends a request to the API, decoding the response into out if not nil
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, c.baseURL()+path, &body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "token "+c.Token)
+	}
+
+	var client = c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("%s %s: %s: %s", method, path, res.Status, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		return json.NewDecoder(res.Body).Decode(out)
+	}
+	return nil
+}
+
+// Get sends a GET request for path to the API, decoding the response into out
+func (c *Client) Get(ctx context.Context, path string, out interface{}) error {
+	return c.do(ctx, http.MethodGet, path, nil, out)
+}
+
+// Viewer returns the login of the user the token authenticates
+func (c *Client) Viewer(ctx context.Context) (string, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/user", nil, &user); err != nil {
+		return "", err
+	}
+	return user.Login, nil
+}
+
+// CheckToken checks the token is valid, by asking for its rate limit, which doesn't count against it
+func (c *Client) CheckToken(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/rate_limit", nil, nil)
+}
+
+// RateLimit is the rate limit of the token for one of the APIs
+type RateLimit struct {
+	Limit     int64 `json:"limit"`
+	Remaining int64 `json:"remaining"`
+	Reset     int64 `json:"reset"` // unix time the limit is reset at
+}
+
+// RateLimits returns the rate limits of the token, by API (core, graphql, search...), which doesn't count against them
+func (c *Client) RateLimits(ctx context.Context) (map[string]*RateLimit, error) {
+	var limits struct {
+		Resources map[string]*RateLimit `json:"resources"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/rate_limit", nil, &limits); err != nil {
+		return nil, err
+	}
+	return limits.Resources, nil
+}
+
+// Comment comments body on the pull request (or issue) number of repo, given as owner/name
+func (c *Client) Comment(ctx context.Context, repo string, number int, body string) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", repo, number), map[string]string{"body": body}, nil)
+}
+
+// commentsPerPage is the size of the pages comments (and issues) are listed in, the maximum allowed by the API
+const commentsPerPage = 100
+
+// marker returns the hidden marker identifying the comments and issues with the given id
+func marker(id string) string {
+	return fmt.Sprintf("<!-- askgit:%s -->", id)
+}
+
+// StickyComment comments body on the pull request (or issue) number of repo, editing the comment left by a previous
+// call with the same id rather than adding another, so that every run updates a single comment. The comment is
+// found by a hidden marker appended to body.
+func (c *Client) StickyComment(ctx context.Context, repo string, number int, id, body string) error {
+	var mark = marker(id)
+	body = body + "\n" + mark
+
+	for page := 1; ; page++ {
+		var comments []struct {
+			ID   int64  `json:"id"`
+			Body string `json:"body"`
+		}
+		var path = fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=%d&page=%d", repo, number, commentsPerPage, page)
+		if err := c.do(ctx, http.MethodGet, path, nil, &comments); err != nil {
+			return err
+		}
+
+		for _, comment := range comments {
+			if strings.Contains(comment.Body, mark) {
+				path = fmt.Sprintf("/repos/%s/issues/comments/%d", repo, comment.ID)
+				return c.do(ctx, http.MethodPatch, path, map[string]string{"body": body}, nil)
+			}
+		}
+
+		if len(comments) < commentsPerPage {
+			return c.Comment(ctx, repo, number, body)
+		}
+	}
+}
+
+// Section is a query run by the action, and its result
+type Section struct {
+	Query  string
+	Result *query.Result
+}
+
+// Title returns the annotation of a query, the text of the line comment it starts with, if any:
+//
+//	-- Commits by author
+//	SELECT author_name, count(*) FROM commits GROUP BY author_name
+func Title(stmt string) string {
+	var line = strings.TrimSpace(stmt)
+	if !strings.HasPrefix(line, "--") {
+		return ""
+	}
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	return strings.TrimSpace(strings.TrimPrefix(line, "--"))
+}
+
+// Markdown renders the sections as Markdown, a table for every query under its title (or the query itself)
+func Markdown(title string, sections []*Section) (string, error) {
+	var b strings.Builder
+	if title != "" {
+		fmt.Fprintf(&b, "### %s\n\n", title)
+	}
+
+	for _, section := range sections {
+		if heading := Title(section.Query); heading != "" {
+			fmt.Fprintf(&b, "**%s**\n\n", heading)
+		} else {
+			fmt.Fprintf(&b, "```sql\n%s\n```\n\n", strings.TrimSpace(section.Query))
+		}
+
+		if len(section.Result.Rows) == 0 {
+			b.WriteString("_no rows_\n\n")
+			continue
+		}
+		if err := display.WriteMarkdown(&b, section.Result.Columns, section.Result.Rows); err != nil {
+			return "", err
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// Issue is an issue filed by FileIssue
+type Issue struct {
+	Number  int    `json:"number"`
+	URL     string `json:"html_url"`
+	Created bool   `json:"-"`
+}
+
+// FileIssue opens an issue titled title in repo, with the given body and labels. If an issue filed by a previous
+// call with the same id is still open, it's updated instead, so that a recurring report keeps to a single issue.
+// The issue is found by a hidden marker appended to body.
+func (c *Client) FileIssue(ctx context.Context, repo, id, title, body string, labels []string) (*Issue, error) {
+	var mark = marker(id)
+	body = body + "\n" + mark
+
+	for page := 1; ; page++ {
+		var issues []struct {
+			Issue
+			Body        string           `json:"body"`
+			PullRequest *json.RawMessage `json:"pull_request"`
+		}
+		var path = fmt.Sprintf("/repos/%s/issues?state=open&per_page=%d&page=%d", repo, commentsPerPage, page)
+		if err := c.do(ctx, http.MethodGet, path, nil, &issues); err != nil {
+			return nil, err
+		}
+
+		for _, issue := range issues {
+			if issue.PullRequest == nil && strings.Contains(issue.Body, mark) {
+				path = fmt.Sprintf("/repos/%s/issues/%d", repo, issue.Number)
+				var updated Issue
+				return &updated, c.do(ctx, http.MethodPatch, path, map[string]string{"title": title, "body": body}, &updated)
+			}
+		}
+
+		if len(issues) < commentsPerPage {
+			break
+		}
+	}
+
+	if labels == nil {
+		labels = []string{}
+	}
+	var created = Issue{Created: true}
+	var in = map[string]interface{}{"title": title, "body": body, "labels": labels}
+	return &created, c.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/issues", repo), in, &created)
+}
+
+// Gist is a gist created by CreateGist
+type Gist struct {
+	ID  string `json:"id"`
+	URL string `json:"html_url"`
+}
+
+// CreateGist creates a gist of the files (their contents by name), secret unless public is set
+func (c *Client) CreateGist(ctx context.Context, description string, public bool, files map[string]string) (*Gist, error) {
+	var contents = make(map[string]map[string]string, len(files))
+	for name, content := range files {
+		contents[name] = map[string]string{"content": content}
+	}
+
+	var gist Gist
+	var in = map[string]interface{}{"description": description, "public": public, "files": contents}
+	return &gist, c.do(ctx, http.MethodPost, "/gists", in, &gist)
+}
@@ -0,0 +1,285 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/askgitdev/askgit/pkg/query"
+)
+
+// setenv sets the environment variables in env, returning a function restoring their previous values
+func setenv(env map[string]string) func() {
+	var previous = make(map[string]*string, len(env))
+	for key, value := range env {
+		if v, ok := os.LookupEnv(key); ok {
+			previous[key] = &v
+		} else {
+			previous[key] = nil
+		}
+		os.Setenv(key, value)
+	}
+	return func() {
+		for key, value := range previous {
+			if value == nil {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, *value)
+			}
+		}
+	}
+}
+
+func TestOutputsAndSummary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "askgit-actions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var output, summary = filepath.Join(dir, "output"), filepath.Join(dir, "summary")
+	defer setenv(map[string]string{
+		"GITHUB_OUTPUT":       output,
+		"GITHUB_STEP_SUMMARY": summary,
+		"INPUT_COMMENT":       "yes please",
+		"INPUT_SUMMARY":       " false ",
+	})()
+
+	if _, err = BoolInput("comment", false); err == nil {
+		t.Fatal("expected an invalid boolean input to fail")
+	}
+	if b, err := BoolInput("summary", true); err != nil || b {
+		t.Fatalf("expected summary to be false, got: %v (%v)", b, err)
+	}
+	if b, err := BoolInput("missing", true); err != nil || !b {
+		t.Fatalf("expected a missing input to default, got: %v (%v)", b, err)
+	}
+
+	if err = SetOutput("value", "first\nsecond"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "value<<") || lines[3] != strings.TrimPrefix(lines[0], "value<<") || lines[1] != "first" {
+		t.Fatalf("unexpected output file:\n%s", b)
+	}
+
+	for _, markdown := range []string{"one", "two"} {
+		if ok, err := AppendSummary(markdown); err != nil || !ok {
+			t.Fatalf("failed to append to the summary: %v", err)
+		}
+	}
+	if b, err = ioutil.ReadFile(summary); err != nil || string(b) != "one\ntwo\n" {
+		t.Fatalf("unexpected summary: %q (%v)", b, err)
+	}
+}
+
+func TestPullRequest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "askgit-actions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var event = filepath.Join(dir, "event.json")
+	defer setenv(map[string]string{"GITHUB_EVENT_PATH": event, "GITHUB_REPOSITORY": "askgitdev/askgit"})()
+
+	for _, test := range []struct {
+		event  string
+		number int
+		ok     bool
+	}{
+		{`{"pull_request": {"number": 42}}`, 42, true},
+		{`{"issue": {"number": 7, "pull_request": {"url": "..."}}}`, 7, true},
+		{`{"issue": {"number": 7}}`, 0, false},
+		{`{"ref": "refs/heads/main"}`, 0, false},
+	} {
+		if err = ioutil.WriteFile(event, []byte(test.event), 0600); err != nil {
+			t.Fatal(err)
+		}
+		repo, number, ok, err := PullRequest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if number != test.number || ok != test.ok || ok && repo != "askgitdev/askgit" {
+			t.Fatalf("%s: expected %d (%v), got %s#%d (%v)", test.event, test.number, test.ok, repo, number, ok)
+		}
+	}
+}
+
+func TestComment(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/askgitdev/askgit/issues/42/comments" || r.Header.Get("Authorization") != "token secret" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		var comment struct{ Body string }
+		json.NewDecoder(r.Body).Decode(&comment)
+		body = comment.Body
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	var client = &Client{Token: "secret", BaseURL: srv.URL}
+	if err := client.Comment(context.Background(), "askgitdev/askgit", 42, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if body != "hello" {
+		t.Fatalf("expected the comment to be posted, got: %q", body)
+	}
+
+	if err := client.Comment(context.Background(), "askgitdev/askgit", 7, "hello"); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Fatalf("expected the request to fail, got: %v", err)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	markdown, err := Markdown("Report", []*Section{
+		{Query: "-- Top authors\nSELECT author_name, count(*) FROM commits", Result: &query.Result{Columns: []string{"author_name", "count(*)"}, Rows: [][]interface{}{{"ada", int64(3)}}}},
+		{Query: "SELECT * FROM empty", Result: &query.Result{Columns: []string{"id"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = "### Report\n\n**Top authors**\n\n| author_name | count(*) |\n| --- | --- |\n| ada | 3 |\n\n```sql\nSELECT * FROM empty\n```\n\n_no rows_\n\n"
+	if markdown != expected {
+		t.Fatalf("unexpected markdown:\n%s", markdown)
+	}
+}
+
+func TestStickyComment(t *testing.T) {
+	var comments = []map[string]interface{}{{"id": 1, "body": "looks good"}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var comment struct{ Body string }
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/askgitdev/askgit/issues/42/comments":
+			json.NewEncoder(w).Encode(comments)
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/askgitdev/askgit/issues/42/comments":
+			json.NewDecoder(r.Body).Decode(&comment)
+			comments = append(comments, map[string]interface{}{"id": len(comments) + 1, "body": comment.Body})
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPatch && r.URL.Path == "/repos/askgitdev/askgit/issues/comments/2":
+			json.NewDecoder(r.Body).Decode(&comment)
+			comments[1]["body"] = comment.Body
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	var client = &Client{BaseURL: srv.URL}
+	for _, body := range []string{"size: 10", "size: 12"} {
+		if err := client.StickyComment(context.Background(), "askgitdev/askgit", 42, "size", body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(comments) != 2 {
+		t.Fatalf("expected a single comment to be added, got: %v", comments)
+	}
+	if body := comments[1]["body"].(string); body != "size: 12\n<!-- askgit:size -->" {
+		t.Fatalf("expected the comment to be updated, got: %q", body)
+	}
+}
+
+func TestFileIssue(t *testing.T) {
+	var issues = []map[string]interface{}{
+		{"number": 1, "body": "<!-- askgit:stale -->", "pull_request": map[string]string{"url": "..."}},
+		{"number": 2, "body": "unrelated"},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var issue map[string]interface{}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/askgitdev/askgit/issues" && r.URL.Query().Get("state") == "open":
+			json.NewEncoder(w).Encode(issues)
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/askgitdev/askgit/issues":
+			json.NewDecoder(r.Body).Decode(&issue)
+			issue["number"] = len(issues) + 1
+			issues = append(issues, issue)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(issue)
+		case r.Method == http.MethodPatch && r.URL.Path == "/repos/askgitdev/askgit/issues/3":
+			json.NewDecoder(r.Body).Decode(&issue)
+			issues[2]["body"] = issue["body"]
+			json.NewEncoder(w).Encode(issues[2])
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	var client = &Client{BaseURL: srv.URL}
+	opened, err := client.FileIssue(context.Background(), "askgitdev/askgit", "stale", "Stale branches", "main", []string{"chore"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opened.Created || opened.Number != 3 || issues[2]["labels"].([]interface{})[0] != "chore" {
+		t.Fatalf("expected an issue to be opened, ignoring the pull request with the marker, got: %+v", opened)
+	}
+
+	updated, err := client.FileIssue(context.Background(), "askgitdev/askgit", "stale", "Stale branches", "main, dev", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Created || updated.Number != 3 || issues[2]["body"] != "main, dev\n<!-- askgit:stale -->" {
+		t.Fatalf("expected the issue to be updated, got: %+v", issues[2])
+	}
+}
+
+func TestCreateGist(t *testing.T) {
+	var gist struct {
+		Description string
+		Public      bool
+		Files       map[string]struct{ Content string }
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/gists" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&gist)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"id": "abc", "html_url": "https://gist.github.com/abc"})
+	}))
+	defer srv.Close()
+
+	var client = &Client{BaseURL: srv.URL}
+	created, err := client.CreateGist(context.Background(), "authors", false, map[string]string{"authors.sql": "SELECT 1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.URL != "https://gist.github.com/abc" || gist.Description != "authors" || gist.Public || gist.Files["authors.sql"].Content != "SELECT 1" {
+		t.Fatalf("unexpected gist: %+v (%+v)", gist, created)
+	}
+}
+
+func TestRateLimits(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/rate_limit" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"resources": {"core": {"limit": 5000, "remaining": 4990, "reset": 1600000000}, "graphql": {"limit": 5000, "remaining": 120, "reset": 1600000000}}}`))
+	}))
+	defer srv.Close()
+
+	var client = &Client{BaseURL: srv.URL}
+	limits, err := client.RateLimits(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if graphql := limits["graphql"]; graphql == nil || graphql.Remaining != 120 || graphql.Limit != 5000 {
+		t.Fatalf("unexpected rate limits: %+v", limits)
+	}
+}
@@ -0,0 +1,102 @@
+// Package audit records the queries executed by askgit's server modes as newline-delimited JSON,
+// so that operators can tell who ran what, how long it took and how much of the API quota it consumed.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Entry is a single record of the audit log
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Principal string    `json:"principal,omitempty"`
+	SQL       string    `json:"sql"`
+	Duration  float64   `json:"duration_ms"`
+	Rows      int       `json:"rows"`
+	APICalls  int64     `json:"api_calls"`
+	Error     string    `json:"error,omitempty"`
+
+	start time.Time
+	calls *int64
+}
+
+// Logger writes audit entries to an underlying writer. It's safe for concurrent use.
+type Logger struct {
+	// Principal, if set, returns the identity of the client that issued the query running under ctx
+	Principal func(ctx context.Context) string
+
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewLogger returns a logger writing entries to w, one JSON object per line
+func NewLogger(w io.Writer) *Logger {
+	return &Logger{enc: json.NewEncoder(w)}
+}
+
+// Begin starts the audit entry of a query, and returns the context the query should run under, which carries
+// the counter of the API calls it makes (see WithCounter and Conn). It returns ctx and a nil entry if l is nil,
+// so callers don't need to check whether auditing is enabled.
+func (l *Logger) Begin(ctx context.Context, sql string) (context.Context, *Entry) {
+	if l == nil {
+		return ctx, nil
+	}
+
+	ctx = WithCounter(ctx)
+	var entry = &Entry{SQL: sql, start: time.Now(), calls: ctx.Value(callsKey{}).(*int64)}
+	entry.Time = entry.start.UTC()
+	if l.Principal != nil {
+		entry.Principal = l.Principal(ctx)
+	}
+	return ctx, entry
+}
+
+// End completes entry with the number of rows returned and the error it failed with, and writes it out
+func (l *Logger) End(entry *Entry, rows int, err error) error {
+	if l == nil || entry == nil {
+		return nil
+	}
+
+	entry.Duration = float64(time.Since(entry.start)) / float64(time.Millisecond)
+	entry.Rows = rows
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	entry.APICalls = atomic.LoadInt64(entry.calls)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.enc.Encode(entry)
+}
+
+// CountingTransport is an http.RoundTripper that counts the requests going through it,
+// in total as well as on the counter carried by the context of each request, if any
+type CountingTransport struct {
+	// Base is the transport used to make requests, http.DefaultTransport if nil
+	Base http.RoundTripper
+
+	count int64
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *CountingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt64(&t.count, 1)
+	if calls, ok := req.Context().Value(callsKey{}).(*int64); ok {
+		atomic.AddInt64(calls, 1)
+	}
+
+	var base = t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// Count returns the number of requests made so far
+func (t *CountingTransport) Count() int64 { return atomic.LoadInt64(&t.count) }
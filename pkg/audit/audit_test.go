@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	var logger = NewLogger(&buf)
+	logger.Principal = func(context.Context) string { return "alice" }
+
+	// only the requests made under the context of the query are attributed to it
+	var client = &http.Client{Transport: &CountingTransport{}}
+	ctx, entry := logger.Begin(context.Background(), "SELECT * FROM github_stargazers('askgitdev/askgit')")
+	for _, ctx := range []context.Context{ctx, ctx, ctx, context.Background()} {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	if err := logger.End(entry, 42, errors.New("rate limited")); err != nil {
+		t.Fatal(err)
+	}
+
+	var got Entry
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Principal != "alice" || got.Rows != 42 || got.APICalls != 3 || got.Error != "rate limited" {
+		t.Fatalf("unexpected entry: %+v", got)
+	}
+
+	// a nil logger is a no-op
+	var disabled *Logger
+	if _, entry := disabled.Begin(context.Background(), "SELECT 1"); entry != nil || disabled.End(entry, 1, nil) != nil {
+		t.Fatal("expected a disabled logger to be a no-op")
+	}
+}
+
+func TestCountingTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	var transport = &CountingTransport{}
+	var client = &http.Client{Transport: transport}
+	for i := 0; i < 2; i++ {
+		res, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	if transport.Count() != 2 {
+		t.Fatalf("expected 2 requests, got: %d", transport.Count())
+	}
+}
+
+func TestScope(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// the tables make their requests with a background context, attributed to the query the scope is bound to
+	var scope = &Scope{}
+	var client = &http.Client{Transport: scope.Transport(&CountingTransport{})}
+	var get = func() {
+		res, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	var ctx = WithCounter(context.Background())
+	get()
+	scope.Bind(ctx)
+	get()
+	get()
+	scope.Bind(nil)
+	get()
+
+	if Calls(ctx) != 2 {
+		t.Fatalf("expected 2 requests made while bound, got: %d", Calls(ctx))
+	}
+}
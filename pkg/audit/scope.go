@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// BindFunction is the name of the SQL function the tables register on every connection, which binds the scope
+// of the connection to the query registered under the id it's passed (or unbinds it, if passed NULL)
+const BindFunction = "askgit_bind_query"
+
+// callsKey is the key of the counter of API calls carried by the context of a query
+type callsKey struct{}
+
+// WithCounter returns a copy of ctx carrying a new counter of API calls,
+// incremented by the CountingTransport for every request made under it
+func WithCounter(ctx context.Context) context.Context {
+	return context.WithValue(ctx, callsKey{}, new(int64))
+}
+
+// Calls returns the number of API calls counted on ctx so far, 0 if it carries no counter
+func Calls(ctx context.Context) int64 {
+	if calls, ok := ctx.Value(callsKey{}).(*int64); ok {
+		return atomic.LoadInt64(calls)
+	}
+	return 0
+}
+
+// Scope is the query running on a database connection. The tables make their requests with a background context,
+// as sqlite doesn't pass the context of a query down to virtual tables, so the requests made through the transport
+// of a connection's scope are attributed to the query it's bound to instead, on which their calls are counted.
+type Scope struct {
+	mu  sync.Mutex
+	ctx context.Context
+}
+
+// Bind attributes the requests made through the scope to the query running under ctx, or to none if ctx is nil
+func (s *Scope) Bind(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ctx = ctx
+}
+
+// Transport returns an http.RoundTripper making its requests through base (http.DefaultTransport if nil),
+// under a context carrying the counter of the query the scope is bound to
+func (s *Scope) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &scopeTransport{scope: s, base: base}
+}
+
+type scopeTransport struct {
+	scope *Scope
+	base  http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *scopeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.scope.mu.Lock()
+	var ctx = t.scope.ctx
+	t.scope.mu.Unlock()
+
+	// only the counter is carried over, the request keeps its own deadline
+	if ctx != nil {
+		if calls, ok := ctx.Value(callsKey{}).(*int64); ok {
+			req = req.WithContext(context.WithValue(req.Context(), callsKey{}, calls))
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// the contexts of the queries running on a bound connection, by id
+var queries = struct {
+	sync.Mutex
+	last int64
+	ctx  map[int64]context.Context
+}{ctx: make(map[int64]context.Context)}
+
+// Lookup returns the context of the query registered under id, nil if there's none
+func Lookup(id int64) context.Context {
+	queries.Lock()
+	defer queries.Unlock()
+	return queries.ctx[id]
+}
+
+// Conn returns a connection of db whose scope is bound to the query running under ctx, so that the API calls made
+// by the tables it queries are counted on ctx. The returned function unbinds the connection, and returns it to the pool,
+// once the rows of the query are closed. If the tables aren't registered on db, the calls simply aren't counted.
+func Conn(ctx context.Context, db *sql.DB) (*sql.Conn, func(), error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	queries.Lock()
+	queries.last++
+	var id = queries.last
+	queries.ctx[id] = ctx
+	queries.Unlock()
+
+	var release = func() {
+		queries.Lock()
+		delete(queries.ctx, id)
+		queries.Unlock()
+		_ = conn.Close()
+	}
+
+	if _, err = conn.ExecContext(ctx, "SELECT "+BindFunction+"(?)", id); err != nil {
+		return conn, release, nil
+	}
+	return conn, func() {
+		_, _ = conn.ExecContext(context.Background(), "SELECT "+BindFunction+"(NULL)")
+		release()
+	}, nil
+}
@@ -0,0 +1,183 @@
+// Package auth implements API key authentication and role-based access control for askgit serve.
+//
+// API keys and roles are stored in a keyring file. Each key is assigned a role, and each role
+// lists the tables (and table-valued functions) its keys are allowed to query. Only a hash of
+// every key is persisted, so the secret itself is shown once, when the key is created.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AllTables is the table allowlist entry granting access to every table
+const AllTables = "*"
+
+// Role is a named set of permissions
+type Role struct {
+	// Tables lists the tables and table-valued functions the role may query
+	Tables []string `json:"tables"`
+}
+
+// Allows reports whether the role grants access to table
+func (r *Role) Allows(table string) bool {
+	for _, t := range r.Tables {
+		if t == AllTables || strings.EqualFold(t, table) {
+			return true
+		}
+	}
+	return false
+}
+
+// Key is an API key assigned to a role
+type Key struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Role    string    `json:"role"`
+	Hash    string    `json:"hash"`
+	Created time.Time `json:"created"`
+}
+
+// Keyring holds the roles and API keys known to a server
+type Keyring struct {
+	Roles map[string]*Role `json:"roles"`
+	Keys  []*Key           `json:"keys"`
+}
+
+// DefaultPath returns the location of the keyring in the user's configuration directory
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "askgit", "keys.json"), nil
+}
+
+// Load reads the keyring stored at path. A missing file yields an empty keyring.
+func Load(path string) (*Keyring, error) {
+	var kr = &Keyring{Roles: make(map[string]*Role)}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return kr, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(b, kr); err != nil {
+		return nil, fmt.Errorf("failed to parse keyring %s: %v", path, err)
+	}
+
+	if kr.Roles == nil {
+		kr.Roles = make(map[string]*Role)
+	}
+	return kr, nil
+}
+
+// Save writes the keyring to path, readable by the current user only
+func (kr *Keyring) Save(path string) error {
+	b, err := json.MarshalIndent(kr, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0600)
+}
+
+// SetRole creates or replaces the role called name
+func (kr *Keyring) SetRole(name string, tables []string) {
+	kr.Roles[name] = &Role{Tables: tables}
+}
+
+// RoleNames returns the names of all roles, sorted
+func (kr *Keyring) RoleNames() []string {
+	var names = make([]string, 0, len(kr.Roles))
+	for name := range kr.Roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Generate creates a new API key for role and returns its secret, which is not stored in the keyring
+func (kr *Keyring) Generate(name, role string) (string, *Key, error) {
+	if _, ok := kr.Roles[role]; !ok {
+		return "", nil, fmt.Errorf("unknown role: %s", role)
+	}
+
+	var buf = make([]byte, 28)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+
+	var key = &Key{ID: hex.EncodeToString(buf[:4]), Name: name, Role: role, Created: time.Now().UTC()}
+	var secret = "askgit_" + key.ID + "_" + hex.EncodeToString(buf[4:])
+	key.Hash = hash(secret)
+
+	kr.Keys = append(kr.Keys, key)
+	return secret, key, nil
+}
+
+// Revoke removes the key with the given id
+func (kr *Keyring) Revoke(id string) error {
+	for i, key := range kr.Keys {
+		if key.ID == id {
+			kr.Keys = append(kr.Keys[:i], kr.Keys[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown key: %s", id)
+}
+
+// Authenticate returns the key matching secret and the role it's assigned to
+func (kr *Keyring) Authenticate(secret string) (*Key, *Role, bool) {
+	var h = []byte(hash(secret))
+	for _, key := range kr.Keys {
+		if subtle.ConstantTimeCompare(h, []byte(key.Hash)) == 1 {
+			if role, ok := kr.Roles[key.Role]; ok {
+				return key, role, true
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+type contextKey struct{}
+
+type principal struct {
+	key  *Key
+	role *Role
+}
+
+// NewContext returns a copy of ctx carrying the authenticated key and its role
+func NewContext(ctx context.Context, key *Key, role *Role) context.Context {
+	return context.WithValue(ctx, contextKey{}, &principal{key, role})
+}
+
+// FromContext returns the authenticated key and its role carried by ctx, if any
+func FromContext(ctx context.Context) (*Key, *Role, bool) {
+	p, ok := ctx.Value(contextKey{}).(*principal)
+	if !ok {
+		return nil, nil, false
+	}
+	return p.key, p.role, true
+}
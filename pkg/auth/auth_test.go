@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestKeyring(t *testing.T) {
+	dir, err := ioutil.TempDir("", "askgit-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "keys.json")
+	kr, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kr.SetRole("analyst", []string{"commits", "github_stargazers"})
+	secret, key, err := kr.Generate("alice", "analyst")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err = kr.Generate("bob", "admin"); err == nil {
+		t.Fatal("expected an error generating a key for an unknown role")
+	}
+
+	if err = kr.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if kr, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, ok := kr.Authenticate("askgit_bogus"); ok {
+		t.Fatal("expected an unknown secret to be rejected")
+	}
+
+	got, role, ok := kr.Authenticate(secret)
+	if !ok || got.ID != key.ID {
+		t.Fatalf("expected secret to authenticate key %s", key.ID)
+	}
+
+	var authorize = Authorizer([]string{"commits", "github_stargazers", "github_org_audit_log"})
+	var ctx = NewContext(context.Background(), got, role)
+	if err = authorize(ctx, "SELECT count(*) FROM commits"); err != nil {
+		t.Fatalf("expected query to be authorized, got: %v", err)
+	}
+	if err = authorize(ctx, "SELECT * FROM github_org_audit_log('askgitdev')"); err == nil {
+		t.Fatal("expected query of a table outside the role to be rejected")
+	}
+
+	if err = kr.Revoke(key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok = kr.Authenticate(secret); ok {
+		t.Fatal("expected revoked key to be rejected")
+	}
+}
+
+// serverStream is a grpc.ServerStream of a call whose context is all that's read
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context { return s.ctx }
+
+func TestStreamInterceptor(t *testing.T) {
+	var kr = &Keyring{Roles: map[string]*Role{}}
+	kr.SetRole("analyst", []string{"commits"})
+	secret, key, err := kr.Generate("alice", "analyst")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var interceptor = kr.StreamInterceptor()
+	var call = func(authorization string) (*Key, error) {
+		var ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", authorization))
+		var authenticated *Key
+		err := interceptor(nil, &serverStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+			authenticated, _, _ = FromContext(ss.Context())
+			return nil
+		})
+		return authenticated, err
+	}
+
+	if _, err = call("Bearer askgit_bogus"); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected an unknown key to be rejected, got: %v", err)
+	}
+	got, err := call("Bearer " + secret)
+	if err != nil || got == nil || got.ID != key.ID {
+		t.Fatalf("expected the call to be authenticated as key %s, got: %v (%v)", key.ID, got, err)
+	}
+}
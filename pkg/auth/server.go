@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/askgitdev/askgit/pkg/query"
+	"github.com/askgitdev/askgit/pkg/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Interceptor returns a server interceptor that authenticates requests with the bearer keys in kr
+func (kr *Keyring) Interceptor() server.Interceptor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var secret = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			key, role, ok := kr.Authenticate(secret)
+			if !ok {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "missing or invalid API key"})
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), key, role)))
+		})
+	}
+}
+
+// StreamInterceptor returns a gRPC interceptor that authenticates calls with the bearer keys in kr, as Interceptor does requests
+func (kr *Keyring) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		var secret = strings.TrimPrefix(server.Authorization(ss.Context()), "Bearer ")
+		key, role, ok := kr.Authenticate(secret)
+		if !ok {
+			return status.Error(codes.Unauthenticated, "missing or invalid API key")
+		}
+		return handler(srv, server.WithContext(ss, NewContext(ss.Context(), key, role)))
+	}
+}
+
+// Authorizer returns a function checking that the role of the authenticated key is allowed
+// to query every one of tables referenced in a statement
+func Authorizer(tables []string) func(ctx context.Context, sql string) error {
+	var known = make(map[string]bool, len(tables))
+	for _, table := range tables {
+		known[strings.ToLower(table)] = true
+	}
+
+	return func(ctx context.Context, sql string) error {
+		key, role, ok := FromContext(ctx)
+		if !ok {
+			return fmt.Errorf("request is not authenticated")
+		}
+
+		for _, ident := range query.Identifiers(sql) {
+			if known[ident] && !role.Allows(ident) {
+				return fmt.Errorf("role %s of key %s is not allowed to query %s", key.Role, key.ID, ident)
+			}
+		}
+		return nil
+	}
+}
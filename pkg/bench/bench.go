@@ -0,0 +1,212 @@
+// Package bench runs suites of standard queries, measuring their wall time, the API calls they make
+// and the rows they return, so that the performance of versions (or redesigns) of askgit can be compared.
+package bench
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/library"
+)
+
+// Query is a query of a suite
+type Query struct {
+	Name string
+	SQL  string
+}
+
+// Suite is a standard set of queries, run against the repository or organization given by its parameters
+type Suite struct {
+	// Params are the named parameters of the queries, e.g. :repo
+	Params []string
+
+	Queries []Query
+}
+
+// Suites are the standard suites, by name
+var Suites = map[string]*Suite{
+	"git": {
+		Params: []string{"repo"},
+		Queries: []Query{
+			{"commits", "SELECT * FROM commits(:repo)"},
+			{"commits-per-author", "SELECT author_email, count(*) FROM commits(:repo) GROUP BY author_email ORDER BY count(*) DESC"},
+			{"refs", "SELECT * FROM refs(:repo)"},
+			{"files", "SELECT path, size FROM files(:repo)"},
+			{"stats", "SELECT c.hash, s.file_path, s.additions, s.deletions FROM (SELECT hash FROM commits(:repo) LIMIT 100) c, stats(:repo, c.hash) s"},
+		},
+	},
+	"github": {
+		Params: []string{"org", "repo"},
+		Queries: []Query{
+			{"org-repos", "SELECT * FROM github_org_repos(:org)"},
+			{"repos-batched", "SELECT name_with_owner, stargazer_count FROM github_repos((SELECT json_group_array(name_with_owner) FROM github_org_repos(:org)))"},
+			{"stargazers", "SELECT * FROM github_stargazers(:repo)"},
+			{"issues", "SELECT * FROM github_repo_issues(:repo)"},
+			{"issues-limited", "SELECT * FROM github_repo_issues(:repo) LIMIT 10"},
+		},
+	},
+}
+
+// Names returns the names of the suites, sorted
+func Names() []string {
+	var names = make([]string, 0, len(Suites))
+	for name := range Suites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Result is the measure of a query of a suite, over all of its runs
+type Result struct {
+	Query      string        `json:"query"`
+	Runs       int           `json:"runs"`
+	Wall       time.Duration `json:"wall_ns"`   // median wall time of a run
+	Rows       int64         `json:"rows"`      // rows returned by a run
+	APICalls   float64       `json:"api_calls"` // mean API calls made by a run
+	RowsPerSec float64       `json:"rows_per_sec"`
+
+	// Baseline is the result of the same query in the baseline the results are compared with, if any
+	Baseline *Result `json:"baseline,omitempty"`
+}
+
+// Report is the result of a run of a suite
+type Report struct {
+	Suite   string            `json:"suite"`
+	Label   string            `json:"label,omitempty"` // version or variant the suite was run with
+	Params  map[string]string `json:"params"`
+	Started time.Time         `json:"started"`
+	Results []*Result         `json:"results"`
+}
+
+// Run runs every query of the suite the given number of times, with the values of its parameters.
+// calls returns the number of API calls made so far, to count those of every run.
+func (s *Suite) Run(ctx context.Context, db *sql.DB, values map[string]string, runs int, calls func() int64) ([]*Result, error) {
+	for _, param := range s.Params {
+		if values[param] == "" {
+			return nil, fmt.Errorf("the suite needs a value for its parameter %s", param)
+		}
+	}
+	if runs < 1 {
+		runs = 1
+	}
+
+	var results = make([]*Result, 0, len(s.Queries))
+	for _, q := range s.Queries {
+		var result = &Result{Query: q.Name, Runs: runs}
+		var walls = make([]time.Duration, 0, runs)
+		var before = calls()
+
+		for i := 0; i < runs; i++ {
+			var start = time.Now()
+			rows, err := count(ctx, db, q.SQL, library.NamedArgs(q.SQL, values))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", q.Name, err)
+			}
+			walls = append(walls, time.Since(start))
+			result.Rows = rows
+		}
+
+		result.APICalls = float64(calls()-before) / float64(runs)
+		result.Wall = median(walls)
+		if result.Wall > 0 {
+			result.RowsPerSec = float64(result.Rows) / result.Wall.Seconds()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// count runs the query, reading all of its rows, and returns how many there were
+func count(ctx context.Context, db *sql.DB, query string, args []interface{}) (int64, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var n int64
+	for rows.Next() {
+		n++
+	}
+	return n, rows.Err()
+}
+
+// median returns the median of the durations, which it sorts
+func median(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	var mid = len(durations) / 2
+	if len(durations)%2 == 0 {
+		return (durations[mid-1] + durations[mid]) / 2
+	}
+	return durations[mid]
+}
+
+// Compare sets the baseline of every result of the report to the result of the same query in baseline
+func (r *Report) Compare(baseline *Report) {
+	var previous = make(map[string]*Result, len(baseline.Results))
+	for _, result := range baseline.Results {
+		previous[result.Query] = result
+	}
+	for _, result := range r.Results {
+		if b, ok := previous[result.Query]; ok {
+			var copied = *b
+			copied.Baseline = nil
+			result.Baseline = &copied
+		}
+	}
+}
+
+// ReadReport reads a report written by WriteJSON
+func ReadReport(r io.Reader) (*Report, error) {
+	var report Report
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// WriteJSON writes the report as JSON, to be compared with later on
+func (r *Report) WriteJSON(w io.Writer) error {
+	var enc = json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteTable writes the report as a table, with the change from the baseline of every measure if there's one
+func (r *Report) WriteTable(w io.Writer) error {
+	var tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "QUERY\tWALL\tAPI CALLS\tROWS\tROWS/SEC\t")
+	for _, result := range r.Results {
+		var wall = result.Wall.Round(time.Millisecond).String()
+		var calls = fmt.Sprintf("%.1f", result.APICalls)
+		var rate = fmt.Sprintf("%.0f", result.RowsPerSec)
+		if b := result.Baseline; b != nil {
+			wall += " " + change(float64(b.Wall), float64(result.Wall))
+			calls += " " + change(b.APICalls, result.APICalls)
+			rate += " " + change(b.RowsPerSec, result.RowsPerSec)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t\n", result.Query, wall, calls, result.Rows, rate)
+	}
+	return tw.Flush()
+}
+
+// change formats the relative change from before to after, e.g. (-12%)
+func change(before, after float64) string {
+	if before == 0 {
+		if after == 0 {
+			return "(=)"
+		}
+		return "(new)"
+	}
+	return fmt.Sprintf("(%+.0f%%)", (after-before)/before*100)
+}
@@ -0,0 +1,65 @@
+package bench
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/query"
+)
+
+func TestSuiteParams(t *testing.T) {
+	for name, suite := range Suites {
+		var declared = make(map[string]bool)
+		for _, param := range suite.Params {
+			declared[param] = true
+		}
+		for _, q := range suite.Queries {
+			for _, param := range query.Parameters(q.SQL) {
+				if !declared[param] {
+					t.Fatalf("%s: query %s uses the undeclared parameter %s", name, q.Name, param)
+				}
+			}
+		}
+	}
+}
+
+func TestMedian(t *testing.T) {
+	if m := median([]time.Duration{3, 1, 2}); m != 2 {
+		t.Fatalf("expected 2, got %d", m)
+	}
+	if m := median([]time.Duration{4, 1, 3, 2}); m != 2 {
+		t.Fatalf("expected 2 (2.5 truncated), got %d", m)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	var baseline = &Report{Results: []*Result{{Query: "stargazers", Wall: 2 * time.Second, APICalls: 10, Rows: 1000, RowsPerSec: 500}}}
+	var report = &Report{Results: []*Result{
+		{Query: "stargazers", Wall: time.Second, APICalls: 10, Rows: 1000, RowsPerSec: 1000},
+		{Query: "issues", Wall: time.Second, Rows: 10, RowsPerSec: 10},
+	}}
+	report.Compare(baseline)
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadReport(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.Results[0].Baseline == nil || read.Results[0].Baseline.Wall != 2*time.Second || read.Results[1].Baseline != nil {
+		t.Fatalf("expected stargazers only to have a baseline, got %+v", read.Results)
+	}
+
+	buf.Reset()
+	if err = read.WriteTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var lines = strings.Split(buf.String(), "\n")
+	if !strings.Contains(lines[1], "1s (-50%)") || !strings.Contains(lines[1], "10.0 (+0%)") || !strings.Contains(lines[1], "1000 (+100%)") {
+		t.Fatalf("expected the changes from the baseline, got:\n%s", buf.String())
+	}
+}
@@ -5,12 +5,45 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/jedib0t/go-pretty/table"
+	"github.com/jedib0t/go-pretty/text"
 	"golang.org/x/term"
 )
 
+// Options tune how results are rendered as a table
+type Options struct {
+	// MaxColumnWidth caps the width of every column, in characters, 0 for no cap.
+	// Longer values are wrapped onto several lines, unless Truncate is set.
+	MaxColumnWidth int
+
+	// Truncate keeps values to their first line (so that, say, issue bodies don't take up the screen),
+	// and cuts them short at MaxColumnWidth, with an ellipsis marking values that were cut
+	Truncate bool
+
+	// NullString is shown for NULL values, NULL if empty
+	NullString string
+
+	// Color colors the header of tables, and dims NULL values
+	Color bool
+
+	// Humanize formats numbers with the thousands separators of Locale, and timestamps relative to now (e.g. 3 days ago),
+	// for people to read. Only tables are humanized, the other formats are for programs and stay raw.
+	Humanize bool
+
+	// Locale is the locale numbers are formatted in (e.g. de_DE), see Locale
+	Locale string
+}
+
+// WriteTo writes the rows out in the format, with the default Options
 func WriteTo(rows *sql.Rows, w io.Writer, format string, interactive bool) error {
+	return WriteToWithOptions(rows, w, format, interactive, Options{})
+}
+
+// WriteToWithOptions writes the rows out in the format, tables being rendered as the options say
+func WriteToWithOptions(rows *sql.Rows, w io.Writer, format string, interactive bool, opts Options) error {
 	switch format {
 	case "single":
 		err := single(rows, w)
@@ -32,9 +65,22 @@ func WriteTo(rows *sql.Rows, w io.Writer, format string, interactive bool) error
 		if err != nil {
 			return err
 		}
+	case "markdown":
+		err := markdownDisplay(rows, w)
+		if err != nil {
+			return err
+		}
+	case "xlsx":
+		wb := NewWorkbook(w)
+		if err := wb.AddSheet("", rows); err != nil {
+			return err
+		}
+		if err := wb.Close(); err != nil {
+			return err
+		}
 	//TODO: switch between table and csv dependent on num columns(suggested num for table 5<=
 	default:
-		err := tableDisplay(rows, w, interactive)
+		err := tableDisplay(rows, w, interactive, opts)
 		if err != nil {
 			return err
 		}
@@ -147,7 +193,24 @@ func jsonDisplay(rows *sql.Rows, write io.Writer) error {
 
 	return nil
 }
-func tableDisplay(rows *sql.Rows, write io.Writer, overflow bool) error {
+
+// truncateCell cuts s short to its first line, and to width characters if width isn't 0, marking the cut with an ellipsis
+func truncateCell(s string, width int) string {
+	var cut bool
+	if i := strings.IndexAny(s, "\r\n"); i >= 0 {
+		s, cut = s[:i], true
+	}
+	var r = []rune(s)
+	if width > 0 && (len(r) > width || (cut && len(r) >= width)) {
+		r, cut = r[:width-1], true
+	}
+	if cut {
+		return string(r) + "…"
+	}
+	return s
+}
+
+func tableDisplay(rows *sql.Rows, write io.Writer, overflow bool, opts Options) error {
 	columns, err := rows.Columns()
 	if err != nil {
 		return err
@@ -176,6 +239,33 @@ func tableDisplay(rows *sql.Rows, write io.Writer, overflow bool) error {
 	t.AppendHeader(cols)
 	t.SetOutputMirror(write)
 
+	// wrapping is left to the table, unless values are truncated
+	if opts.MaxColumnWidth > 0 && !opts.Truncate {
+		var configs = make([]table.ColumnConfig, len(columns))
+		for i := range configs {
+			configs[i] = table.ColumnConfig{Number: i + 1, WidthMax: opts.MaxColumnWidth}
+		}
+		t.SetColumnConfigs(configs)
+	}
+
+	var h *humanizer
+	if opts.Humanize {
+		h = newHumanizer(opts.Locale, time.Now())
+	}
+
+	var null = "NULL"
+	if opts.NullString != "" {
+		null = opts.NullString
+	}
+	if opts.Color {
+		var colors = make([]text.Colors, len(columns))
+		for i := range colors {
+			colors[i] = text.Colors{text.Bold, text.FgCyan}
+		}
+		t.SetColorsHeader(colors)
+		null = text.Faint.Sprint(null)
+	}
+
 	for rows.Next() {
 		err := rows.Scan(pointers...)
 		if err != nil {
@@ -184,11 +274,19 @@ func tableDisplay(rows *sql.Rows, write io.Writer, overflow bool) error {
 
 		r := make([]interface{}, len(columns))
 		for i, c := range container {
-			if c.Valid {
-				r[i] = c.String
-			} else {
-				r[i] = "NULL"
+			if !c.Valid {
+				r[i] = null
+				continue
+			}
+
+			var value = c.String
+			if h != nil {
+				value = h.format(columns[i], value)
+			}
+			if opts.Truncate {
+				value = truncateCell(value, opts.MaxColumnWidth)
 			}
+			r[i] = value
 		}
 
 		t.AppendRow(r)
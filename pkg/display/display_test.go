@@ -1,9 +1,11 @@
 package display
 
 import (
+	"archive/zip"
 	"bufio"
 	"bytes"
 	"encoding/csv"
+	"io/ioutil"
 	"strings"
 	"testing"
 
@@ -40,6 +42,32 @@ func TestDisplayTable(t *testing.T) {
 
 }
 
+func TestDisplayTableOptions(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+
+	mockRows := sqlmock.NewRows([]string{"number", "body"}).
+		AddRow("1", "a long first line\nand a second one").
+		AddRow("2", nil)
+
+	mock.ExpectQuery("select").WillReturnRows(mockRows)
+
+	rows, _ := db.Query("select")
+
+	var b bytes.Buffer
+	err := WriteToWithOptions(rows, &b, "table", false, Options{MaxColumnWidth: 8, Truncate: true, NullString: "-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out = b.String()
+	if !strings.Contains(out, "a long …") || strings.Contains(out, "second") {
+		t.Fatalf("expected the body to be truncated to its first 8 characters, got:\n%s", out)
+	}
+	if strings.Contains(out, "NULL") || !strings.Contains(out, " - ") {
+		t.Fatalf("expected NULL values to be shown as -, got:\n%s", out)
+	}
+}
+
 func TestDisplayCSV(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 
@@ -163,3 +191,86 @@ func TestDisplaySingle(t *testing.T) {
 		t.Fatalf("expected output to be the only the first column of the first row: %s, got: %s", "1", b.String())
 	}
 }
+
+func TestDisplayXLSX(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+
+	mockRows := sqlmock.NewRows([]string{"id", "name", "value"}).
+		AddRow(int64(1), "name 1", 1.5).
+		AddRow(int64(2), "name <2>", nil).
+		AddRow(int64(3), "name 3", 3.5)
+
+	mock.ExpectQuery("select").WillReturnRows(mockRows)
+
+	rows, _ := db.Query("select")
+
+	var b bytes.Buffer
+	err := WriteTo(rows, &b, "xlsx", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sheet string
+	for _, f := range r.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents, err := ioutil.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rc.Close()
+		sheet = string(contents)
+	}
+
+	if sheet == "" {
+		t.Fatalf("expected workbook to contain a worksheet")
+	}
+
+	if !strings.Contains(sheet, `state="frozen"`) {
+		t.Fatalf("expected header row to be frozen")
+	}
+
+	if rowCount := strings.Count(sheet, "<row "); rowCount != 4 {
+		t.Fatalf("expected 4 rows of output, got: %d", rowCount)
+	}
+
+	if !strings.Contains(sheet, `<c r="A2"><v>1</v></c>`) {
+		t.Fatalf("expected numeric cells to be written as numbers")
+	}
+
+	if !strings.Contains(sheet, "name &lt;2&gt;") {
+		t.Fatalf("expected text cells to be escaped")
+	}
+}
+
+func TestDisplayMarkdown(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+
+	mockRows := sqlmock.NewRows([]string{"id", "name"}).
+		AddRow("1", "a | b").
+		AddRow("2", nil)
+
+	mock.ExpectQuery("select").WillReturnRows(mockRows)
+
+	rows, _ := db.Query("select")
+
+	var b bytes.Buffer
+	if err := WriteTo(rows, &b, "markdown", false); err != nil {
+		t.Fatal(err)
+	}
+
+	var expected = "| id | name |\n| --- | --- |\n| 1 | a \\| b |\n| 2 | NULL |\n"
+	if b.String() != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
@@ -0,0 +1,145 @@
+package display
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// separators are the thousands and decimal separators of a locale
+type separators struct {
+	thousands, decimal string
+}
+
+// localeSeparators are the separators of numbers by language (or language and region), English's by default
+var localeSeparators = map[string]separators{
+	"en": {",", "."}, "ja": {",", "."}, "ko": {",", "."}, "zh": {",", "."}, "he": {",", "."},
+	"de": {".", ","}, "es": {".", ","}, "it": {".", ","}, "nl": {".", ","}, "pt": {".", ","},
+	"da": {".", ","}, "id": {".", ","}, "tr": {".", ","}, "el": {".", ","},
+	"fr": {"\u202f", ","}, "ru": {"\u00a0", ","}, "uk": {"\u00a0", ","}, "pl": {"\u00a0", ","}, "cs": {"\u00a0", ","},
+	"sv": {"\u00a0", ","}, "nb": {"\u00a0", ","}, "fi": {"\u00a0", ","},
+	"de_CH": {"\u2019", "."}, "pt_BR": {".", ","}, "en_IN": {",", "."},
+}
+
+// Locale returns the locale of the environment, as $LC_ALL, $LC_NUMERIC or $LANG say (e.g. de_DE.UTF-8), or en
+func Locale() string {
+	for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if v := os.Getenv(name); v != "" && v != "C" && v != "POSIX" {
+			return v
+		}
+	}
+	return "en"
+}
+
+// separatorsOf returns the separators of the locale (e.g. de_DE.UTF-8, de-DE or de)
+func separatorsOf(locale string) separators {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	locale = strings.Replace(locale, "-", "_", 1)
+	if s, ok := localeSeparators[locale]; ok {
+		return s
+	}
+	if i := strings.Index(locale, "_"); i >= 0 {
+		locale = locale[:i]
+	}
+	if s, ok := localeSeparators[strings.ToLower(locale)]; ok {
+		return s
+	}
+	return localeSeparators["en"]
+}
+
+// humanizer formats values for people, rather than programs: numbers with the separators of a locale,
+// and timestamps relative to now (in English), e.g. 3 days ago
+type humanizer struct {
+	separators
+	now time.Time
+}
+
+func newHumanizer(locale string, now time.Time) *humanizer {
+	return &humanizer{separators: separatorsOf(locale), now: now}
+}
+
+var numberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?$`)
+
+// timestampLayouts are the layouts of the timestamps formatted relative to now, as the tables return them
+var timestampLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05"}
+
+// identifier reports whether the column holds identifiers (like ids or issue numbers), whose digits aren't grouped
+func identifier(column string) bool {
+	column = strings.ToLower(column)
+	return column == "id" || column == "number" || column == "year" || strings.HasSuffix(column, "_id") || strings.HasSuffix(column, "_number")
+}
+
+// format formats the value of the column, returning it as it is if it's neither a number nor a timestamp
+func (h *humanizer) format(column, value string) string {
+	if numberPattern.MatchString(value) {
+		if identifier(column) {
+			return value
+		}
+		return h.number(value)
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return h.relative(t)
+		}
+	}
+	return value
+}
+
+// number groups the digits of the integer part of a number by thousands
+func (h *humanizer) number(value string) string {
+	var sign, integer, fraction string
+	if strings.HasPrefix(value, "-") {
+		sign, value = "-", value[1:]
+	}
+	integer = value
+	if i := strings.Index(value, "."); i >= 0 {
+		integer, fraction = value[:i], h.decimal+value[i+1:]
+	}
+
+	var b strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(h.thousands)
+		}
+		b.WriteRune(digit)
+	}
+	return sign + b.String() + fraction
+}
+
+// relative formats the time relative to now, e.g. 3 days ago or in 2 hours
+func (h *humanizer) relative(t time.Time) string {
+	var d = h.now.Sub(t)
+	var future = d < 0
+	if future {
+		d = -d
+	}
+
+	var n int
+	var unit string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		n, unit = int(d/time.Minute), "minute"
+	case d < 24*time.Hour:
+		n, unit = int(d/time.Hour), "hour"
+	case d < 30*24*time.Hour:
+		n, unit = int(d/(24*time.Hour)), "day"
+	case d < 365*24*time.Hour:
+		n, unit = int(d/(30*24*time.Hour)), "month"
+	default:
+		n, unit = int(d/(365*24*time.Hour)), "year"
+	}
+	if n > 1 {
+		unit += "s"
+	}
+
+	if future {
+		return fmt.Sprintf("in %d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %s ago", n, unit)
+}
@@ -0,0 +1,33 @@
+package display
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHumanize(t *testing.T) {
+	var now = time.Date(2021, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		locale, column, value, want string
+	}{
+		{"en_US.UTF-8", "stargazer_count", "1234567", "1,234,567"},
+		{"en_US.UTF-8", "additions", "-1234.5", "-1,234.5"},
+		{"de_DE.UTF-8", "stargazer_count", "1234567", "1.234.567"},
+		{"de-DE", "ratio", "1234.25", "1.234,25"},
+		{"fr_FR", "stargazer_count", "12345", "12\u202f345"},
+		{"xx", "stargazer_count", "999", "999"},
+		{"en", "database_id", "1234567", "1234567"},
+		{"en", "issue_number", "1234", "1234"},
+		{"en", "zip", "01234", "01234"},
+		{"en", "created_at", "2021-06-12T12:00:00Z", "3 days ago"},
+		{"en", "created_at", "2021-06-15 11:59:30", "just now"},
+		{"en", "due_at", "2021-06-15T14:30:00Z", "in 2 hours"},
+		{"en", "pushed_at", "2019-01-01T00:00:00+02:00", "2 years ago"},
+		{"en", "title", "Fix 1234", "Fix 1234"},
+	} {
+		if got := newHumanizer(tc.locale, now).format(tc.column, tc.value); got != tc.want {
+			t.Errorf("%s %s = %s: expected %q, got %q", tc.locale, tc.column, tc.value, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package display
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// markdownCell escapes a value so that it stays in its cell of a Markdown table
+var markdownCell = strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>")
+
+// WriteMarkdown writes columns and rows as a GitHub flavored Markdown table, as found in comments and job summaries
+func WriteMarkdown(w io.Writer, columns []string, rows [][]interface{}) error {
+	var b strings.Builder
+	var line = func(cells []string) {
+		b.WriteString("|")
+		for _, cell := range cells {
+			b.WriteString(" " + markdownCell.Replace(cell) + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	line(columns)
+	var separators = make([]string, len(columns))
+	for i := range separators {
+		separators[i] = "---"
+	}
+	line(separators)
+
+	for _, row := range rows {
+		var cells = make([]string, len(row))
+		for i, v := range row {
+			if v == nil {
+				cells[i] = "NULL"
+			} else {
+				cells[i] = fmt.Sprint(v)
+			}
+		}
+		line(cells)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownDisplay(rows *sql.Rows, write io.Writer) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	pointers := make([]interface{}, len(columns))
+	container := make([]sql.NullString, len(columns))
+	for i := range pointers {
+		pointers[i] = &container[i]
+	}
+
+	var values [][]interface{}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+
+		r := make([]interface{}, len(columns))
+		for i, c := range container {
+			if c.Valid {
+				r[i] = c.String
+			}
+		}
+		values = append(values, r)
+	}
+
+	return WriteMarkdown(write, columns, values)
+}
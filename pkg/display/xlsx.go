@@ -0,0 +1,226 @@
+package display
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Workbook writes result sets out as worksheets of an Office Open XML (.xlsx) workbook.
+// Every call to AddSheet streams a single result set into its own worksheet, and Close
+// writes out the workbook index, so a Workbook must always be closed to produce a valid file.
+type Workbook struct {
+	zip    *zip.Writer
+	sheets []string
+}
+
+// NewWorkbook returns a new Workbook that writes the generated .xlsx archive to w
+func NewWorkbook(w io.Writer) *Workbook {
+	return &Workbook{zip: zip.NewWriter(w)}
+}
+
+// AddSheet consumes rows and writes them out as a new worksheet. The first row of the sheet
+// holds the column names and is frozen, so it stays visible while scrolling through the results.
+func (wb *Workbook) AddSheet(name string, rows *sql.Rows) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	name = wb.sheetName(name)
+	wb.sheets = append(wb.sheets, name)
+
+	w, err := wb.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(wb.sheets)))
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	buf.WriteString(`<sheetViews><sheetView workbookViewId="0">`)
+	buf.WriteString(`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`)
+	buf.WriteString(`</sheetView></sheetViews><sheetData>`)
+
+	buf.WriteString(`<row r="1">`)
+	for i, column := range columns {
+		writeCell(&buf, cellRef(i, 1), column, 1)
+	}
+	buf.WriteString(`</row>`)
+
+	values := make([]interface{}, len(columns))
+	for i := range values {
+		values[i] = new(interface{})
+	}
+
+	for r := 2; rows.Next(); r++ {
+		if err = rows.Scan(values...); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&buf, `<row r="%d">`, r)
+		for i := range columns {
+			writeCell(&buf, cellRef(i, r), *(values[i].(*interface{})), 0)
+		}
+		buf.WriteString(`</row>`)
+
+		// flush what we have so far to keep memory usage flat for large result sets
+		if buf.Len() > 1<<16 {
+			if _, err = buf.WriteTo(w); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	buf.WriteString(`</sheetData></worksheet>`)
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// Close writes out the workbook metadata and finalizes the archive.
+// It does not close the underlying writer.
+func (wb *Workbook) Close() error {
+	var sheets, rels, overrides strings.Builder
+	for i, name := range wb.sheets {
+		var escaped bytes.Buffer
+		_ = xml.EscapeText(&escaped, []byte(name))
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escaped.String(), i+1, i+1)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+		fmt.Fprintf(&overrides, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	styles := len(wb.sheets) + 1
+
+	var files = []struct{ name, body string }{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			overrides.String() + `</Types>`},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + sheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels.String() +
+			fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, styles) +
+			`</Relationships>`},
+		// style 0 is the default, style 1 is the bold font used for the header row
+		{"xl/styles.xml", `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+			`<fills count="1"><fill><patternFill patternType="none"/></fill></fills>` +
+			`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+			`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+			`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+			`</styleSheet>`},
+	}
+
+	for _, file := range files {
+		w, err := wb.zip.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(w, xml.Header+file.body); err != nil {
+			return err
+		}
+	}
+
+	return wb.zip.Close()
+}
+
+// sheetName returns a valid and unique worksheet name derived from name.
+// Excel limits names to 31 characters and disallows a handful of special characters.
+func (wb *Workbook) sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+
+	if name == "" {
+		name = fmt.Sprintf("Sheet%d", len(wb.sheets)+1)
+	}
+
+	candidate := truncate(name, 31)
+	for n := 2; wb.hasSheet(candidate); n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		candidate = truncate(name, 31-len(suffix)) + suffix
+	}
+	return candidate
+}
+
+func (wb *Workbook) hasSheet(name string) bool {
+	for _, sheet := range wb.sheets {
+		if strings.EqualFold(sheet, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+// cellRef returns the A1-style reference of the cell at the given 0-based column and 1-based row
+func cellRef(col, row int) string {
+	var name []byte
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = append([]byte{byte('A' + (col-1)%26)}, name...)
+	}
+	return string(name) + strconv.Itoa(row)
+}
+
+// writeCell writes out a single typed cell. Numbers and booleans are written as native spreadsheet
+// values so they can be used in formulas, everything else is written out as an inline string.
+func writeCell(buf *bytes.Buffer, ref string, value interface{}, style int) {
+	var s string
+	if style != 0 {
+		s = fmt.Sprintf(` s="%d"`, style)
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return
+	case int64:
+		fmt.Fprintf(buf, `<c r="%s"%s><v>%d</v></c>`, ref, s, v)
+		return
+	case float64:
+		fmt.Fprintf(buf, `<c r="%s"%s><v>%s</v></c>`, ref, s, strconv.FormatFloat(v, 'g', -1, 64))
+		return
+	case bool:
+		fmt.Fprintf(buf, `<c r="%s"%s t="b"><v>%d</v></c>`, ref, s, t1f0(v))
+		return
+	case time.Time:
+		value = v.Format(time.RFC3339)
+	case []byte:
+		value = string(v)
+	}
+
+	fmt.Fprintf(buf, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">`, ref, s)
+	_ = xml.EscapeText(buf, []byte(fmt.Sprint(value)))
+	buf.WriteString(`</t></is></c>`)
+}
+
+// t1f0 converts a bool to an int
+func t1f0(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
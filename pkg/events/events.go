@@ -0,0 +1,155 @@
+// Package events reads the events of GitHub organizations and repositories, to tell which repositories
+// and issues changed since a point in time, so that `askgit sync` only refreshes those (see materialize.Table).
+package events
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/actions"
+)
+
+// Client reads events from the GitHub REST API
+type Client struct {
+	// REST sends the requests to the API
+	REST *actions.Client
+
+	once   sync.Once
+	viewer string // the login of the user the token authenticates, if any
+}
+
+// login returns the login of the user the token authenticates, or an empty string if there's no token,
+// or if it doesn't authenticate a user (as the tokens of GitHub Apps don't)
+func (c *Client) login(ctx context.Context) string {
+	c.once.Do(func() {
+		if c.REST.Token != "" {
+			c.viewer, _ = c.REST.Viewer(ctx)
+		}
+	})
+	return c.viewer
+}
+
+// Issue is an issue (or pull request) of a repository
+type Issue struct {
+	Repo   string `json:"repo"`
+	Number int    `json:"number"`
+}
+
+// Changes lists the repositories and issues that had events since a point in time
+type Changes struct {
+	// Repos lists the repositories, as owner/name, sorted
+	Repos []string
+
+	// Issues lists the issues and pull requests, sorted by repository and number
+	Issues []Issue
+
+	// Complete reports whether the events read reach back to the point in time. The API only keeps
+	// the last 300 events (of the last 90 days) of every organization and repository, so the changes of
+	// a busy one, or since long ago, are incomplete, and everything must be refreshed instead.
+	// So are those of organizations read without a user token, whose private events can't be read.
+	Complete bool
+}
+
+// the API returns events in pages of up to 100, and up to 3 pages of them
+const (
+	perPage  = 100
+	maxPages = 3
+)
+
+// horizon is how long events are kept by the API
+const horizon = 90 * 24 * time.Hour
+
+// event is the part of an event telling what changed
+type event struct {
+	Type string `json:"type"`
+	Repo struct {
+		Name string `json:"name"`
+	} `json:"repo"`
+	Payload struct {
+		Number int `json:"number"`
+		Issue  *struct {
+			Number int `json:"number"`
+		} `json:"issue"`
+		PullRequest *struct {
+			Number int `json:"number"`
+		} `json:"pull_request"`
+	} `json:"payload"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// issue returns the number of the issue or pull request the event is about, or 0
+func (e *event) issue() int {
+	switch {
+	case e.Payload.Issue != nil:
+		return e.Payload.Issue.Number
+	case e.Payload.PullRequest != nil:
+		return e.Payload.PullRequest.Number
+	}
+	return e.Payload.Number
+}
+
+// Changes returns the repositories and issues with events since the given time, in the sources:
+// organizations (askgitdev) and repositories (askgitdev/askgit). The events of organizations, private ones included,
+// are read from the dashboard of the user the token authenticates, so only organizations the user is a member of are.
+func (c *Client) Changes(ctx context.Context, sources []string, since time.Time) (*Changes, error) {
+	var repos = make(map[string]bool)
+	var issues = make(map[Issue]bool)
+	var complete = true
+
+	for _, source := range sources {
+		var path string
+		var public bool // only the public events of the source are read
+		switch {
+		case strings.Contains(source, "/"):
+			path = fmt.Sprintf("/repos/%s/events", source)
+		case c.login(ctx) != "":
+			path = fmt.Sprintf("/users/%s/events/orgs/%s", c.login(ctx), source)
+		default:
+			path, public = fmt.Sprintf("/orgs/%s/events", source), true
+		}
+
+		var reached bool
+		for page := 1; page <= maxPages && !reached; page++ {
+			var events []*event
+			if err := c.REST.Get(ctx, fmt.Sprintf("%s?per_page=%d&page=%d", path, perPage, page), &events); err != nil {
+				return nil, fmt.Errorf("failed to read the events of %s: %v", source, err)
+			}
+
+			for _, e := range events {
+				if e.CreatedAt.Before(since) {
+					reached = true
+					break
+				}
+				repos[e.Repo.Name] = true
+				if n := e.issue(); n > 0 {
+					issues[Issue{Repo: e.Repo.Name, Number: n}] = true
+				}
+			}
+
+			// a short page is the last, there's no event before those listed but the expired ones
+			if len(events) < perPage {
+				reached = reached || time.Since(since) < horizon
+				break
+			}
+		}
+		complete = complete && reached && !public
+	}
+
+	var changes = &Changes{Repos: make([]string, 0, len(repos)), Issues: make([]Issue, 0, len(issues)), Complete: complete}
+	for repo := range repos {
+		changes.Repos = append(changes.Repos, repo)
+	}
+	sort.Strings(changes.Repos)
+	for issue := range issues {
+		changes.Issues = append(changes.Issues, issue)
+	}
+	sort.Slice(changes.Issues, func(i, j int) bool {
+		a, b := changes.Issues[i], changes.Issues[j]
+		return a.Repo < b.Repo || (a.Repo == b.Repo && a.Number < b.Number)
+	})
+	return changes, nil
+}
@@ -0,0 +1,95 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/actions"
+)
+
+func TestChanges(t *testing.T) {
+	var now = time.Now().UTC().Truncate(time.Second)
+	var since = now.Add(-time.Hour)
+
+	var orgEvents = []map[string]interface{}{
+		{"type": "IssuesEvent", "repo": map[string]string{"name": "askgitdev/askgit"}, "payload": map[string]interface{}{"issue": map[string]int{"number": 12}}, "created_at": now.Add(-10 * time.Minute)},
+		{"type": "PullRequestEvent", "repo": map[string]string{"name": "askgitdev/askgit"}, "payload": map[string]interface{}{"number": 14, "pull_request": map[string]int{"number": 14}}, "created_at": now.Add(-20 * time.Minute)},
+		{"type": "PushEvent", "repo": map[string]string{"name": "askgitdev/site"}, "payload": map[string]interface{}{}, "created_at": now.Add(-30 * time.Minute)},
+		{"type": "IssueCommentEvent", "repo": map[string]string{"name": "askgitdev/old"}, "payload": map[string]interface{}{"issue": map[string]int{"number": 1}}, "created_at": now.Add(-2 * time.Hour)},
+	}
+
+	var busy = make([]map[string]interface{}, perPage)
+	for i := range busy {
+		busy[i] = map[string]interface{}{"type": "WatchEvent", "repo": map[string]string{"name": "busy/repo"}, "created_at": now}
+	}
+
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		if r.URL.Path == "/orgs/askgitdev/events" {
+			// the public events of the organization, read without a token
+			_ = json.NewEncoder(w).Encode(orgEvents)
+			return
+		}
+		if r.Header.Get("Authorization") != "token secret" {
+			t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/user":
+			_, _ = w.Write([]byte(`{"login": "alice"}`))
+		case "/users/alice/events/orgs/askgitdev":
+			_ = json.NewEncoder(w).Encode(orgEvents)
+		case "/repos/busy/repo/events":
+			_ = json.NewEncoder(w).Encode(busy)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var client = &Client{REST: &actions.Client{BaseURL: srv.URL, Token: "secret"}}
+	changes, err := client.Changes(context.Background(), []string{"askgitdev"}, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changes.Complete {
+		t.Fatal("expected the events to reach back to the last sync")
+	}
+	if !reflect.DeepEqual(changes.Repos, []string{"askgitdev/askgit", "askgitdev/site"}) {
+		t.Fatalf("unexpected repositories: %v", changes.Repos)
+	}
+	if !reflect.DeepEqual(changes.Issues, []Issue{{"askgitdev/askgit", 12}, {"askgitdev/askgit", 14}}) {
+		t.Fatalf("unexpected issues: %v", changes.Issues)
+	}
+	if len(requests) != 2 || requests[1] != fmt.Sprintf("/users/alice/events/orgs/askgitdev?per_page=%d&page=1", perPage) {
+		t.Fatalf("expected a single page of the dashboard of the user to be read, got: %v", requests)
+	}
+
+	// without a token, the private events of the organization can't be read
+	anonymous := &Client{REST: &actions.Client{BaseURL: srv.URL}}
+	if changes, err = anonymous.Changes(context.Background(), []string{"askgitdev"}, since); err != nil {
+		t.Fatal(err)
+	}
+	if changes.Complete || len(changes.Repos) != 2 {
+		t.Fatalf("expected the public events of the organization to be incomplete, got: %+v", changes)
+	}
+
+	// the events of a busy repository don't reach back to the last sync, which can't be told apart
+	requests = nil
+	if changes, err = client.Changes(context.Background(), []string{"busy/repo"}, since); err != nil {
+		t.Fatal(err)
+	}
+	if changes.Complete || len(requests) != maxPages {
+		t.Fatalf("expected %d pages of incomplete changes, got %d pages: %+v", maxPages, len(requests), changes)
+	}
+
+	if _, err = client.Changes(context.Background(), []string{"missing"}, since); err == nil {
+		t.Fatal("expected the events of a missing organization to fail")
+	}
+}
@@ -0,0 +1,155 @@
+// Package health serves the liveness (/healthz) and readiness (/readyz) probes of long-running commands,
+// so that orchestrators such as Kubernetes restart instances that are wedged, and route no work to those
+// that can't do it, e.g. because their GitHub token was revoked or their database can't be written to.
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Check is a check of the health of a service
+type Check struct {
+	Name string
+	Fn   func(ctx context.Context) error
+
+	// TTL, if set, is how long the outcome of the check is reused for, for checks too costly to run on every probe
+	TTL time.Duration
+
+	mu      sync.Mutex
+	checked time.Time
+	err     error
+}
+
+func (c *Check) run(ctx context.Context, now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.TTL > 0 && !c.checked.IsZero() && now.Sub(c.checked) < c.TTL {
+		return c.err
+	}
+	c.err, c.checked = c.Fn(ctx), now
+	return c.err
+}
+
+// Checker runs the checks of the probes of a service
+type Checker struct {
+	// Live are the checks of the liveness probe, failing if the service is wedged and should be restarted
+	Live []*Check
+
+	// Ready are the checks of the readiness probe, failing if the service can't do its work for now
+	Ready []*Check
+
+	// Timeout is how long the checks of a probe may take, 5 seconds if zero
+	Timeout time.Duration
+
+	mu       sync.Mutex
+	draining bool
+}
+
+// Drain marks the service as shutting down, failing its readiness probe so that it's given no more work
+func (c *Checker) Drain() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.draining = true
+}
+
+func (c *Checker) isDraining() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.draining
+}
+
+// Report is the outcome of a probe
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// probe runs the checks, reporting whether they all passed
+func (c *Checker) probe(ctx context.Context, checks []*Check, ready bool) (*Report, bool) {
+	var timeout = c.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var report = &Report{Status: "ok", Checks: make(map[string]string, len(checks))}
+	var ok = true
+	if ready && c.isDraining() {
+		report.Checks["shutdown"] = "draining"
+		ok = false
+	}
+
+	var now = time.Now()
+	for _, check := range checks {
+		if err := check.run(ctx, now); err != nil {
+			report.Checks[check.Name] = "failed: " + err.Error()
+			ok = false
+		} else {
+			report.Checks[check.Name] = "ok"
+		}
+	}
+	if !ok {
+		report.Status = "unavailable"
+	}
+	return report, ok
+}
+
+// Register serves the liveness probe under /healthz, and the readiness probe under /readyz, on mux.
+// Probes respond with a 200 status if their checks pass, and a 503 otherwise, along with a report of every check.
+func (c *Checker) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", c.handler(func() []*Check { return c.Live }, false))
+	mux.HandleFunc("/readyz", c.handler(func() []*Check {
+		var checks = make([]*Check, 0, len(c.Live)+len(c.Ready))
+		return append(append(checks, c.Live...), c.Ready...)
+	}, true))
+}
+
+func (c *Checker) handler(checks func() []*Check, ready bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report, ok := c.probe(r.Context(), checks(), ready)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if !ok {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	}
+}
+
+// Ping checks the database is reachable
+func Ping(db *sql.DB) func(ctx context.Context) error {
+	return db.PingContext
+}
+
+// Writable checks the database can be written to: that its write lock can be taken, and its journal written,
+// by creating a table in a transaction that's rolled back, leaving the database as it was
+func Writable(db *sql.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		c, err := db.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		if _, err = c.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+			return fmt.Errorf("failed to take the write lock: %v", err)
+		}
+		_, err = c.ExecContext(ctx, "CREATE TABLE askgit_health_probe (id INTEGER)")
+		if _, rbErr := c.ExecContext(context.Background(), "ROLLBACK"); err == nil && rbErr != nil {
+			err = rbErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write: %v", err)
+		}
+		return nil
+	}
+}
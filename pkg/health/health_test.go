@@ -0,0 +1,88 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestChecker(t *testing.T) {
+	var tokenChecks int
+	var tokenErr error
+	var checker = &Checker{
+		Live: []*Check{{Name: "database", Fn: func(ctx context.Context) error { return nil }}},
+		Ready: []*Check{{Name: "github_token", TTL: time.Minute, Fn: func(ctx context.Context) error {
+			tokenChecks++
+			return tokenErr
+		}}},
+	}
+	tokenErr = errors.New("401 Bad credentials")
+
+	var mux = http.NewServeMux()
+	checker.Register(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var probe = func(path string) (int, *Report) {
+		res, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		var report Report
+		if err = json.NewDecoder(res.Body).Decode(&report); err != nil {
+			t.Fatal(err)
+		}
+		return res.StatusCode, &report
+	}
+
+	if status, report := probe("/healthz"); status != http.StatusOK || report.Checks["database"] != "ok" || report.Checks["github_token"] != "" {
+		t.Fatalf("expected the service to be live, got %d: %+v", status, report)
+	}
+	if status, report := probe("/readyz"); status != http.StatusServiceUnavailable || report.Checks["github_token"] != "failed: 401 Bad credentials" {
+		t.Fatalf("expected the service not to be ready, got %d: %+v", status, report)
+	}
+
+	// the outcome of the token check is reused until it expires
+	tokenErr = nil
+	if status, _ := probe("/readyz"); status != http.StatusServiceUnavailable || tokenChecks != 1 {
+		t.Fatalf("expected the failed token check to be reused, got %d after %d checks", status, tokenChecks)
+	}
+	checker.Ready[0].checked = time.Now().Add(-time.Hour)
+	if status, _ := probe("/readyz"); status != http.StatusOK || tokenChecks != 2 {
+		t.Fatalf("expected the service to be ready once the token check expired, got %d after %d checks", status, tokenChecks)
+	}
+
+	checker.Drain()
+	if status, report := probe("/readyz"); status != http.StatusServiceUnavailable || report.Checks["shutdown"] != "draining" {
+		t.Fatalf("expected a draining service not to be ready, got %d: %+v", status, report)
+	}
+	if status, _ := probe("/healthz"); status != http.StatusOK {
+		t.Fatalf("expected a draining service to stay live, got %d", status)
+	}
+}
+
+func TestWritable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(`BEGIN IMMEDIATE`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE askgit_health_probe`).WillReturnError(errors.New("attempt to write a readonly database"))
+	mock.ExpectExec(`ROLLBACK`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err = Writable(db)(context.Background()); err == nil {
+		t.Fatal("expected a read-only database to fail the check")
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
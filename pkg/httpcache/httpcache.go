@@ -0,0 +1,135 @@
+// Package httpcache implements an on-disk cache of API responses, used to avoid repeating
+// requests across askgit invocations and to answer queries while offline.
+//
+// Entries are keyed by the request method, URL and body, which is what identifies a GraphQL query.
+// Credentials are not part of the key, so a cache directory should not be shared between users.
+// Responses can hold sensitive data, and are encrypted at rest when the transport is given a Key.
+package httpcache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/seal"
+)
+
+// Transport is an http.RoundTripper that stores successful responses on disk and serves them back
+type Transport struct {
+	// Dir is the directory entries are stored in
+	Dir string
+
+	// TTL is how long a stored response is served for before a request is made again.
+	// With a TTL of 0 requests are always made, and responses are only stored for later offline use.
+	TTL time.Duration
+
+	// Offline serves every request from the cache, regardless of its age, and never makes requests.
+	// Requests without a stored response fail with a *MissError.
+	Offline bool
+
+	// Base is the transport used to make requests, http.DefaultTransport if nil
+	Base http.RoundTripper
+
+	// Key, if set, encrypts the stored responses (see package seal).
+	// Entries stored without encryption, or with another key, are treated as missing.
+	Key []byte
+}
+
+// MissError is returned in offline mode for requests without a stored response
+type MissError struct {
+	Method, URL string
+}
+
+func (e *MissError) Error() string {
+	return fmt.Sprintf("offline: no cached response for %s %s, run the query once without --offline to cache it", e.Method, e.URL)
+}
+
+// DefaultDir returns the default cache directory, in the user's cache directory
+func DefaultDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "askgit", "http")
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	var path = filepath.Join(t.Dir, Key(req.Method, req.URL.String(), body))
+	if info, err := os.Stat(path); err == nil && (t.Offline || time.Since(info.ModTime()) < t.TTL) {
+		if res, err := t.load(path, req); err == nil {
+			return res, nil
+		}
+	}
+
+	if t.Offline {
+		return nil, &MissError{Method: req.Method, URL: req.URL.String()}
+	}
+
+	var base = t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	res, err := base.RoundTrip(req)
+	if err != nil || res.StatusCode != http.StatusOK {
+		return res, err
+	}
+
+	dump, err := httputil.DumpResponse(res, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Key != nil {
+		if dump, err = seal.Seal(t.Key, dump); err != nil {
+			return nil, err
+		}
+	}
+
+	// a failure to write to the cache shouldn't fail the request
+	if err = os.MkdirAll(t.Dir, 0700); err == nil {
+		_ = ioutil.WriteFile(path, dump, 0600)
+	}
+	return res, nil
+}
+
+// Key returns the cache key of a request
+func Key(method, url string, body []byte) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s %s\n", method, url)
+	_, _ = h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (t *Transport) load(path string, req *http.Request) (*http.Response, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Key != nil {
+		if b, err = seal.Open(t.Key, b); err != nil {
+			return nil, err
+		}
+	}
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(b)), req)
+}
@@ -0,0 +1,107 @@
+package httpcache
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransport(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		b, _ := ioutil.ReadAll(r.Body)
+		_, _ = w.Write(b)
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "askgit-httpcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var post = func(tr *Transport, body string) (string, error) {
+		res, err := (&http.Client{Transport: tr}).Post(srv.URL, "application/json", strings.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		b, err := ioutil.ReadAll(res.Body)
+		return string(b), err
+	}
+
+	// with a TTL, the second request is answered from the cache
+	var online = &Transport{Dir: dir, TTL: time.Hour}
+	for i := 0; i < 2; i++ {
+		if got, err := post(online, `{"query": "a"}`); err != nil || got != `{"query": "a"}` {
+			t.Fatalf("unexpected response %q: %v", got, err)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("expected 1 request, got: %d", requests)
+	}
+
+	var offline = &Transport{Dir: dir, Offline: true}
+	if got, err := post(offline, `{"query": "a"}`); err != nil || got != `{"query": "a"}` {
+		t.Fatalf("unexpected offline response %q: %v", got, err)
+	}
+
+	if _, err = post(offline, `{"query": "b"}`); err == nil || !strings.Contains(err.Error(), "offline") {
+		t.Fatalf("expected an offline cache miss, got: %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("expected no request to be made offline, got: %d", requests)
+	}
+}
+
+func TestTransportEncrypted(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("confidential issue body"))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "askgit-httpcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var get = func(tr *Transport) (string, error) {
+		res, err := (&http.Client{Transport: tr}).Get(srv.URL)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		b, err := ioutil.ReadAll(res.Body)
+		return string(b), err
+	}
+
+	var key = []byte(strings.Repeat("k", 32))
+	if _, err = get(&Transport{Dir: dir, Key: key}); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := ioutil.ReadFile(filepath.Join(dir, Key(http.MethodGet, srv.URL, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(entry), "confidential") {
+		t.Fatal("expected the stored response to be encrypted")
+	}
+
+	if got, err := get(&Transport{Dir: dir, Offline: true, Key: key}); err != nil || got != "confidential issue body" {
+		t.Fatalf("unexpected offline response %q: %v", got, err)
+	}
+
+	// without the right key, the entry can't be read and counts as missing
+	var other = []byte(strings.Repeat("o", 32))
+	if _, err = get(&Transport{Dir: dir, Offline: true, Key: other}); err == nil || !strings.Contains(err.Error(), "offline") {
+		t.Fatalf("expected an offline cache miss, got: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+// Package jsonrpc provides a minimal JSON-RPC 2.0 [https://www.jsonrpc.org/specification] server
+// that exchanges newline-delimited messages over a pair of streams (usually stdin and stdout).
+// It is used by the askgit sub-commands that allow other programs to drive askgit as a subprocess.
+package jsonrpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Standard error codes defined by the JSON-RPC 2.0 specification
+const (
+	ParseError     = -32700
+	InvalidRequest = -32600
+	MethodNotFound = -32601
+	InvalidParams  = -32602
+	InternalError  = -32603
+)
+
+// Request is a JSON-RPC request or notification (if ID is empty)
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is a JSON-RPC response, holding either a Result or an Error
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error object. Handlers can return an *Error
+// to control the code reported back to the client.
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Errorf returns an *Error with the given code and formatted message
+func Errorf(code int, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// HandlerFunc handles a single method call. The returned value is encoded as the call's result.
+type HandlerFunc func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+// Server dispatches incoming requests to the registered method handlers
+type Server struct {
+	handlers map[string]HandlerFunc
+}
+
+// NewServer returns a new server with no registered methods
+func NewServer() *Server {
+	return &Server{handlers: make(map[string]HandlerFunc)}
+}
+
+// Handle registers the handler for the given method, replacing any existing one
+func (s *Server) Handle(method string, fn HandlerFunc) { s.handlers[method] = fn }
+
+// Serve reads requests from r, one per line, and writes responses to w until r is exhausted
+// or ctx is cancelled. Requests are handled sequentially in the order they are received.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	reply := func(res *Response) error {
+		mu.Lock()
+		defer mu.Unlock()
+		res.JSONRPC = "2.0"
+		return enc.Encode(res)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var req Request
+		if err := json.Unmarshal(line, &req); err != nil {
+			if err := reply(&Response{ID: json.RawMessage("null"), Error: Errorf(ParseError, "parse error: %v", err)}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		res := s.call(ctx, &req)
+		if len(req.ID) == 0 {
+			continue // notifications never get a response
+		}
+
+		res.ID = req.ID
+		if err := reply(res); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (s *Server) call(ctx context.Context, req *Request) *Response {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return &Response{Error: Errorf(InvalidRequest, "invalid request")}
+	}
+
+	fn, ok := s.handlers[req.Method]
+	if !ok {
+		return &Response{Error: Errorf(MethodNotFound, "method not found: %s", req.Method)}
+	}
+
+	result, err := fn(ctx, req.Params)
+	if err != nil {
+		if rpcErr, ok := err.(*Error); ok {
+			return &Response{Error: rpcErr}
+		}
+		return &Response{Error: &Error{Code: InternalError, Message: err.Error()}}
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return &Response{Error: Errorf(InternalError, "failed to encode result: %v", err)}
+	}
+
+	return &Response{Result: encoded}
+}
@@ -0,0 +1,67 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestServe(t *testing.T) {
+	srv := NewServer()
+	srv.Handle("echo", func(_ context.Context, params json.RawMessage) (interface{}, error) {
+		var p map[string]interface{}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, Errorf(InvalidParams, "invalid params")
+		}
+		return p, nil
+	})
+	srv.Handle("fail", func(context.Context, json.RawMessage) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+
+	var in = strings.Join([]string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "echo", "params": {"hello": "world"}}`,
+		`{"jsonrpc": "2.0", "method": "echo", "params": {}}`,
+		`{"jsonrpc": "2.0", "id": "two", "method": "fail"}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "missing"}`,
+		`not json`,
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := srv.Serve(context.Background(), strings.NewReader(in), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	var responses []Response
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var res Response
+		if err := dec.Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, res)
+	}
+
+	if len(responses) != 4 {
+		t.Fatalf("expected 4 responses (notifications get none), got: %d", len(responses))
+	}
+
+	if string(responses[0].ID) != "1" || string(responses[0].Result) != `{"hello":"world"}` {
+		t.Fatalf("unexpected echo response: %s %s", responses[0].ID, responses[0].Result)
+	}
+
+	if string(responses[1].ID) != `"two"` || responses[1].Error == nil || responses[1].Error.Code != InternalError {
+		t.Fatalf("expected an internal error response, got: %+v", responses[1])
+	}
+
+	if responses[2].Error == nil || responses[2].Error.Code != MethodNotFound {
+		t.Fatalf("expected a method not found response, got: %+v", responses[2])
+	}
+
+	if responses[3].Error == nil || responses[3].Error.Code != ParseError {
+		t.Fatalf("expected a parse error response, got: %+v", responses[3])
+	}
+}
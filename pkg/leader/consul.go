@@ -0,0 +1,91 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Consul is a lock of a key of Consul, held by a session the replica holding it renews.
+// The lock is released if the session expires, when the replica stops renewing it.
+type Consul struct {
+	Address string // address of the HTTP API of the agent, e.g. http://127.0.0.1:8500
+	Key     string
+	Token   string        // ACL token, if any
+	TTL     time.Duration // time to live of the session, 15s if zero
+	HTTP    *http.Client
+
+	mu      sync.Mutex
+	session string
+	stop    chan struct{}
+}
+
+func (c *Consul) ttl() time.Duration {
+	if c.TTL > 0 {
+		return c.TTL
+	}
+	return defaultTTL
+}
+
+func (c *Consul) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var header = make(http.Header)
+	if c.Token != "" {
+		header.Set("X-Consul-Token", c.Token)
+	}
+	return do(ctx, c.HTTP, method, strings.TrimSuffix(c.Address, "/")+path, header, in, out)
+}
+
+// TryAcquire implements Lock
+func (c *Consul) TryAcquire(ctx context.Context) (<-chan struct{}, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.session != "" {
+		return nil, false, fmt.Errorf("the lock of %s is already held", c.Key)
+	}
+
+	var session struct{ ID string }
+	var in = map[string]string{"Name": "askgit-leader", "TTL": c.ttl().String(), "Behavior": "release"}
+	if err := c.do(ctx, http.MethodPut, "/v1/session/create", in, &session); err != nil {
+		return nil, false, fmt.Errorf("failed to create a consul session: %v", err)
+	}
+
+	var acquired bool
+	var path = fmt.Sprintf("/v1/kv/%s?acquire=%s", c.Key, url.QueryEscape(session.ID))
+	if err := c.do(ctx, http.MethodPut, path, identity(), &acquired); err != nil || !acquired {
+		_ = c.do(ctx, http.MethodPut, "/v1/session/destroy/"+session.ID, nil, nil)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to acquire the lock of %s: %v", c.Key, err)
+		}
+		return nil, false, nil
+	}
+
+	var lost = make(chan struct{})
+	c.session, c.stop = session.ID, make(chan struct{})
+	go renew(c.ttl(), c.stop, lost, func(ctx context.Context) error {
+		return c.do(ctx, http.MethodPut, "/v1/session/renew/"+session.ID, nil, nil)
+	})
+	return lost, true, nil
+}
+
+// Release implements Lock
+func (c *Consul) Release(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.session == "" {
+		return nil
+	}
+
+	close(c.stop)
+	var session = c.session
+	c.session = ""
+
+	var err = c.do(ctx, http.MethodPut, fmt.Sprintf("/v1/kv/%s?release=%s", c.Key, url.QueryEscape(session)), nil, nil)
+	if destroyErr := c.do(ctx, http.MethodPut, "/v1/session/destroy/"+session, nil, nil); err == nil {
+		err = destroyErr
+	}
+	return err
+}
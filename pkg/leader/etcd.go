@@ -0,0 +1,101 @@
+package leader
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Etcd is a lock of a key of etcd, created with a lease the replica holding it keeps alive, through the JSON
+// gateway of the v3 API. The key is deleted, releasing the lock, if the lease expires when the replica stops renewing it.
+type Etcd struct {
+	Address string // address of the API, e.g. http://127.0.0.1:2379
+	Key     string
+	TTL     time.Duration // time to live of the lease, 15s if zero
+	HTTP    *http.Client
+
+	mu    sync.Mutex
+	lease string
+	stop  chan struct{}
+}
+
+func (e *Etcd) ttl() time.Duration {
+	if e.TTL > 0 {
+		return e.TTL
+	}
+	return defaultTTL
+}
+
+func (e *Etcd) do(ctx context.Context, path string, in, out interface{}) error {
+	return do(ctx, e.HTTP, http.MethodPost, strings.TrimSuffix(e.Address, "/")+path, nil, in, out)
+}
+
+// TryAcquire implements Lock
+func (e *Etcd) TryAcquire(ctx context.Context) (<-chan struct{}, bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.lease != "" {
+		return nil, false, fmt.Errorf("the lock of %s is already held", e.Key)
+	}
+
+	var lease struct{ ID string }
+	var seconds = int64(e.ttl() / time.Second)
+	if err := e.do(ctx, "/v3/lease/grant", map[string]string{"TTL": strconv.FormatInt(seconds, 10)}, &lease); err != nil {
+		return nil, false, fmt.Errorf("failed to grant an etcd lease: %v", err)
+	}
+
+	// the key is put with the lease only if it doesn't exist, that is if no other replica holds the lock
+	var key = base64.StdEncoding.EncodeToString([]byte(e.Key))
+	var txn = map[string]interface{}{
+		"compare": []map[string]string{{"key": key, "target": "CREATE", "result": "EQUAL", "create_revision": "0"}},
+		"success": []map[string]interface{}{{"request_put": map[string]string{
+			"key":   key,
+			"value": base64.StdEncoding.EncodeToString([]byte(identity())),
+			"lease": lease.ID,
+		}}},
+	}
+	var result struct{ Succeeded bool }
+	if err := e.do(ctx, "/v3/kv/txn", txn, &result); err != nil || !result.Succeeded {
+		_ = e.do(ctx, "/v3/lease/revoke", map[string]string{"ID": lease.ID}, nil)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to acquire the lock of %s: %v", e.Key, err)
+		}
+		return nil, false, nil
+	}
+
+	var lost = make(chan struct{})
+	e.lease, e.stop = lease.ID, make(chan struct{})
+	go renew(e.ttl(), e.stop, lost, func(ctx context.Context) error {
+		var alive struct {
+			Result struct{ TTL string }
+		}
+		if err := e.do(ctx, "/v3/lease/keepalive", map[string]string{"ID": lease.ID}, &alive); err != nil {
+			return err
+		}
+		// the lease of the lock expired, for good, if it has no time to live left
+		if ttl, _ := strconv.ParseInt(alive.Result.TTL, 10, 64); ttl <= 0 {
+			return fmt.Errorf("the lease of %s expired", e.Key)
+		}
+		return nil
+	})
+	return lost, true, nil
+}
+
+// Release implements Lock
+func (e *Etcd) Release(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.lease == "" {
+		return nil
+	}
+
+	close(e.stop)
+	var lease = e.lease
+	e.lease = ""
+	return e.do(ctx, "/v3/lease/revoke", map[string]string{"ID": lease}, nil)
+}
@@ -0,0 +1,68 @@
+// +build !windows
+
+package leader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// FileLock is an exclusive lock (flock) of a file, on a filesystem shared by the replicas.
+// It's held for as long as the process holding it runs, and so can't be lost.
+type FileLock struct {
+	Path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// TryAcquire implements Lock
+func (l *FileLock) TryAcquire(ctx context.Context) (<-chan struct{}, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		return nil, true, nil
+	}
+
+	f, err := os.OpenFile(l.Path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, false, err
+	}
+	if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err == syscall.EWOULDBLOCK {
+		f.Close()
+		return nil, false, nil
+	} else if err != nil {
+		f.Close()
+		return nil, false, fmt.Errorf("failed to lock %s: %v", l.Path, err)
+	}
+
+	// the file tells which replica holds the lock, for operators
+	if err = f.Truncate(0); err == nil {
+		_, err = f.WriteAt([]byte(identity()+"\n"), 0)
+	}
+	if err != nil {
+		f.Close()
+		return nil, false, err
+	}
+
+	l.file = f
+	return nil, true, nil
+}
+
+// Release implements Lock
+func (l *FileLock) Release(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+
+	_ = l.file.Truncate(0)
+	_ = syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	var err = l.file.Close()
+	l.file = nil
+	return err
+}
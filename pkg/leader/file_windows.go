@@ -0,0 +1,19 @@
+package leader
+
+import (
+	"context"
+	"errors"
+)
+
+// FileLock is an exclusive lock of a file, which isn't supported on Windows
+type FileLock struct {
+	Path string
+}
+
+// TryAcquire implements Lock
+func (l *FileLock) TryAcquire(ctx context.Context) (<-chan struct{}, bool, error) {
+	return nil, false, errors.New("file locks aren't supported on Windows, use a lock of consul or etcd")
+}
+
+// Release implements Lock
+func (l *FileLock) Release(ctx context.Context) error { return nil }
@@ -0,0 +1,146 @@
+// Package leader elects a single leader among the replicas of a deployment, so that only one of them
+// syncs at a time, rather than every replica syncing the same tables and spending API quota twice.
+// Replicas compete for a lock: a file lock on a shared filesystem, or a lock held by a session
+// of Consul or a lease of etcd, which is lost if the replica holding it stops renewing it.
+package leader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Lock is a lock replicas compete for, the replica holding it being the leader
+type Lock interface {
+	// TryAcquire tries to take the lock, without waiting for it, reporting whether it was taken.
+	// Once taken, lost is closed if the lock is lost (a nil channel for locks that can't be).
+	TryAcquire(ctx context.Context) (lost <-chan struct{}, ok bool, err error)
+
+	// Release releases the lock, if it's held
+	Release(ctx context.Context) error
+}
+
+// Parse returns the lock described by uri: file:///path/to/lock, consul://host:port/key or etcd://host:port/key.
+// Consul is authenticated with $CONSUL_HTTP_TOKEN, if set.
+func Parse(uri string) (Lock, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lock uri %q: %v", uri, err)
+	}
+
+	var key = strings.Trim(u.Path, "/")
+	switch u.Scheme {
+	case "file", "":
+		if u.Path == "" {
+			return nil, fmt.Errorf("expected a lock uri of the form file:///path/to/lock, got %q", uri)
+		}
+		return &FileLock{Path: u.Path}, nil
+	case "consul":
+		if u.Host == "" || key == "" {
+			return nil, fmt.Errorf("expected a lock uri of the form consul://host:port/key, got %q", uri)
+		}
+		return &Consul{Address: "http://" + u.Host, Key: key, Token: os.Getenv("CONSUL_HTTP_TOKEN")}, nil
+	case "etcd":
+		if u.Host == "" || key == "" {
+			return nil, fmt.Errorf("expected a lock uri of the form etcd://host:port/key, got %q", uri)
+		}
+		return &Etcd{Address: "http://" + u.Host, Key: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported lock uri scheme %q", u.Scheme)
+	}
+}
+
+// Acquire waits for the lock to be taken, trying again every interval, until ctx is done
+func Acquire(ctx context.Context, l Lock, interval time.Duration) (<-chan struct{}, error) {
+	for {
+		lost, ok, err := l.TryAcquire(ctx)
+		if err != nil || ok {
+			return lost, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// identity identifies the replica holding a lock, in the value of the lock
+func identity() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s (pid %d)", host, os.Getpid())
+}
+
+// defaultTTL is how long the locks of Consul and etcd outlive the last renewal of the replica holding them
+const defaultTTL = 15 * time.Second
+
+// renew calls fn every ttl/3 until stop is closed. Renewals can fail once, but lost is closed if the lock
+// wasn't renewed for 2/3 of its ttl, so that the replica stops before its lock expires and another one takes it.
+func renew(ttl time.Duration, stop <-chan struct{}, lost chan<- struct{}, fn func(ctx context.Context) error) {
+	var ticker = time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	var renewed = time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+			err := fn(ctx)
+			cancel()
+			if err == nil {
+				renewed = time.Now()
+			} else if time.Since(renewed) >= ttl*2/3 {
+				close(lost)
+				return
+			}
+		}
+	}
+}
+
+// do sends a request with a JSON body (unless in is nil) to the HTTP API of a lock service,
+// decoding the JSON response into out if not nil
+func do(ctx context.Context, client *http.Client, method, url string, header http.Header, in, out interface{}) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, url, &body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("%s %s: %s: %s", method, req.URL.Path, res.Status, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		return json.NewDecoder(res.Body).Decode(out)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package leader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for uri, expected := range map[string]Lock{
+		"file:///var/lib/askgit/sync.lock":    &FileLock{Path: "/var/lib/askgit/sync.lock"},
+		"consul://127.0.0.1:8500/askgit/sync": &Consul{Address: "http://127.0.0.1:8500", Key: "askgit/sync", Token: os.Getenv("CONSUL_HTTP_TOKEN")},
+		"etcd://127.0.0.1:2379/askgit/sync":   &Etcd{Address: "http://127.0.0.1:2379", Key: "askgit/sync"},
+	} {
+		lock, err := Parse(uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := lock, expected; !equalLocks(got, want) {
+			t.Fatalf("%s: expected %+v, got %+v", uri, want, got)
+		}
+	}
+
+	for _, uri := range []string{"consul://127.0.0.1:8500", "zookeeper://host/key"} {
+		if _, err := Parse(uri); err == nil {
+			t.Fatalf("expected %s to be rejected", uri)
+		}
+	}
+}
+
+func equalLocks(a, b Lock) bool {
+	switch a := a.(type) {
+	case *FileLock:
+		b, ok := b.(*FileLock)
+		return ok && a.Path == b.Path
+	case *Consul:
+		b, ok := b.(*Consul)
+		return ok && a.Address == b.Address && a.Key == b.Key && a.Token == b.Token
+	case *Etcd:
+		b, ok := b.(*Etcd)
+		return ok && a.Address == b.Address && a.Key == b.Key
+	}
+	return false
+}
+
+func TestFileLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "askgit-leader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "sync.lock")
+	var first, second = &FileLock{Path: path}, &FileLock{Path: path}
+	if _, ok, err := first.TryAcquire(context.Background()); err != nil || !ok {
+		t.Fatalf("expected the first replica to be elected, got: %v (%v)", ok, err)
+	}
+	if _, ok, err := second.TryAcquire(context.Background()); err != nil || ok {
+		t.Fatalf("expected the second replica not to be elected, got: %v (%v)", ok, err)
+	}
+
+	if err = first.Release(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := second.TryAcquire(context.Background()); err != nil || !ok {
+		t.Fatalf("expected the second replica to be elected once the first released the lock, got: %v (%v)", ok, err)
+	}
+	_ = second.Release(context.Background())
+}
+
+func TestConsul(t *testing.T) {
+	var mu sync.Mutex
+	var holder string
+	var sessions int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/session/create":
+			sessions++
+			json.NewEncoder(w).Encode(map[string]string{"ID": "session-" + string(rune('0'+sessions))})
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/kv/askgit/sync" && r.URL.Query().Get("acquire") != "":
+			var acquired = holder == ""
+			if acquired {
+				holder = r.URL.Query().Get("acquire")
+			}
+			json.NewEncoder(w).Encode(acquired)
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/kv/askgit/sync" && r.URL.Query().Get("release") == holder:
+			holder = ""
+			json.NewEncoder(w).Encode(true)
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v1/session/"):
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	var first, second = &Consul{Address: srv.URL, Key: "askgit/sync"}, &Consul{Address: srv.URL, Key: "askgit/sync"}
+	if _, ok, err := first.TryAcquire(context.Background()); err != nil || !ok {
+		t.Fatalf("expected the first replica to be elected, got: %v (%v)", ok, err)
+	}
+	if _, ok, err := second.TryAcquire(context.Background()); err != nil || ok {
+		t.Fatalf("expected the second replica not to be elected, got: %v (%v)", ok, err)
+	}
+	if err := first.Release(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := second.TryAcquire(context.Background()); err != nil || !ok || holder != "session-3" {
+		t.Fatalf("expected the second replica to be elected once the first released the lock, got: %v (%v)", ok, err)
+	}
+	_ = second.Release(context.Background())
+}
+
+func TestEtcd(t *testing.T) {
+	var mu sync.Mutex
+	var leases = map[string]bool{}
+	var holder string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/v3/lease/grant":
+			var id = string(rune('0' + len(leases) + 1))
+			leases[id] = true
+			json.NewEncoder(w).Encode(map[string]string{"ID": id, "TTL": body["TTL"].(string)})
+		case "/v3/kv/txn":
+			var put = body["success"].([]interface{})[0].(map[string]interface{})["request_put"].(map[string]interface{})
+			var succeeded = holder == ""
+			if succeeded {
+				holder = put["lease"].(string)
+			}
+			json.NewEncoder(w).Encode(map[string]bool{"succeeded": succeeded})
+		case "/v3/lease/revoke":
+			if holder == body["ID"] {
+				holder = ""
+			}
+			delete(leases, body["ID"].(string))
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	var first, second = &Etcd{Address: srv.URL, Key: "askgit/sync"}, &Etcd{Address: srv.URL, Key: "askgit/sync"}
+	if _, ok, err := first.TryAcquire(context.Background()); err != nil || !ok {
+		t.Fatalf("expected the first replica to be elected, got: %v (%v)", ok, err)
+	}
+	if _, ok, err := second.TryAcquire(context.Background()); err != nil || ok || len(leases) != 1 {
+		t.Fatalf("expected the second replica not to be elected, and its lease revoked, got: %v (%v)", ok, err)
+	}
+	if err := first.Release(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := second.TryAcquire(context.Background()); err != nil || !ok {
+		t.Fatalf("expected the second replica to be elected once the first released the lock, got: %v (%v)", ok, err)
+	}
+	_ = second.Release(context.Background())
+}
+
+func TestRenewLost(t *testing.T) {
+	var stop, lost = make(chan struct{}), make(chan struct{})
+	defer close(stop)
+
+	go renew(30*time.Millisecond, stop, lost, func(ctx context.Context) error { return errors.New("session not found") })
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatal("expected the lock to be lost once it couldn't be renewed")
+	}
+}
@@ -0,0 +1,219 @@
+// Package library manages a local library of saved queries, with their descriptions, tags and the default values
+// of their parameters. Libraries are stored as a JSON array of queries, the format queries are shared in as well.
+package library
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/query"
+)
+
+// Query is a saved query
+type Query struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Params      map[string]string `json:"params,omitempty"` // default values of the named parameters of the query
+	SQL         string            `json:"sql"`
+	Created     time.Time         `json:"created"`
+	Updated     time.Time         `json:"updated"`
+}
+
+// HasTag reports whether the query is tagged with tag
+func (q *Query) HasTag(tag string) bool {
+	for _, t := range q.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// ParamNames returns the names of the named parameters of the query, in the order they appear
+func (q *Query) ParamNames() []string {
+	return query.Parameters(q.SQL)
+}
+
+// Values returns the values of the named parameters of the query: those given, or else their defaults.
+// Every parameter of the query needs a value, and only those of the query can be given one.
+func (q *Query) Values(given map[string]string) (map[string]string, error) {
+	var params = q.ParamNames()
+	var known = make(map[string]bool, len(params))
+	for _, name := range params {
+		known[name] = true
+	}
+	for name := range given {
+		if !known[name] {
+			return nil, fmt.Errorf("query %s has no parameter %s", q.Name, name)
+		}
+	}
+
+	var values = make(map[string]string, len(params))
+	for _, name := range params {
+		if v, ok := given[name]; ok {
+			values[name] = v
+		} else if v, ok := q.Params[name]; ok {
+			values[name] = v
+		} else {
+			return nil, fmt.Errorf("query %s is missing a value for its parameter %s, e.g. --param %s=...", q.Name, name, name)
+		}
+	}
+	return values, nil
+}
+
+// NamedArgs returns the arguments binding the values to the named parameters of the statement
+func NamedArgs(stmt string, values map[string]string) []interface{} {
+	var args []interface{}
+	for _, name := range query.Parameters(stmt) {
+		if v, ok := values[name]; ok {
+			args = append(args, sql.Named(name, v))
+		}
+	}
+	return args
+}
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Validate checks the query has a valid name and some SQL
+func (q *Query) Validate() error {
+	if !namePattern.MatchString(q.Name) {
+		return fmt.Errorf("invalid query name %q, expected letters, digits, '_', '.' and '-'", q.Name)
+	}
+	if strings.TrimSpace(q.SQL) == "" {
+		return fmt.Errorf("query %s has no SQL", q.Name)
+	}
+	return nil
+}
+
+// Library is a collection of saved queries, by name
+type Library struct {
+	Queries []*Query
+}
+
+// DefaultPath returns the location of the library in the user's configuration directory
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "askgit", "queries.json"), nil
+}
+
+// Parse reads queries shared in the JSON format of libraries: an array of queries, or a single one
+func Parse(data []byte) ([]*Query, error) {
+	var queries []*Query
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "{") {
+		var q Query
+		if err := json.Unmarshal(data, &q); err != nil {
+			return nil, err
+		}
+		queries = []*Query{&q}
+	} else if err := json.Unmarshal(data, &queries); err != nil {
+		return nil, err
+	}
+
+	for _, q := range queries {
+		if err := q.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return queries, nil
+}
+
+// Load reads the library stored at path. A missing file yields an empty library.
+func Load(path string) (*Library, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Library{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	queries, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the query library %s: %v", path, err)
+	}
+	return &Library{Queries: queries}, nil
+}
+
+// Save writes the library to path
+func (l *Library) Save(path string) error {
+	b, err := json.MarshalIndent(l.Queries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0600)
+}
+
+// Get returns the query called name
+func (l *Library) Get(name string) (*Query, bool) {
+	for _, q := range l.Queries {
+		if q.Name == name {
+			return q, true
+		}
+	}
+	return nil, false
+}
+
+// Put adds the query to the library, replacing the one of the same name (whose creation time it keeps).
+// Queries are kept sorted by name.
+func (l *Library) Put(q *Query, now time.Time) error {
+	if err := q.Validate(); err != nil {
+		return err
+	}
+
+	if q.Created.IsZero() {
+		q.Created = now
+	}
+	if q.Updated.IsZero() {
+		q.Updated = now
+	}
+
+	for i, existing := range l.Queries {
+		if existing.Name == q.Name {
+			if !existing.Created.IsZero() {
+				q.Created = existing.Created
+			}
+			q.Updated = now
+			l.Queries[i] = q
+			return nil
+		}
+	}
+
+	l.Queries = append(l.Queries, q)
+	sort.Slice(l.Queries, func(i, j int) bool { return l.Queries[i].Name < l.Queries[j].Name })
+	return nil
+}
+
+// Remove removes the query called name, reporting whether there was one
+func (l *Library) Remove(name string) bool {
+	for i, q := range l.Queries {
+		if q.Name == name {
+			l.Queries = append(l.Queries[:i], l.Queries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns the names of the queries of the library
+func (l *Library) Names() []string {
+	var names = make([]string, 0, len(l.Queries))
+	for _, q := range l.Queries {
+		names = append(names, q.Name)
+	}
+	return names
+}
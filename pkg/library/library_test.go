@@ -0,0 +1,95 @@
+package library
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLibrary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "askgit-library")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "queries.json")
+	l, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var created = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	var stars = &Query{
+		Name:        "stars",
+		Description: "latest stargazers of a repository",
+		Tags:        []string{"github"},
+		Params:      map[string]string{"limit": "10"},
+		SQL:         "SELECT login FROM github_stargazers(:repo) ORDER BY starred_at DESC LIMIT :limit",
+	}
+	if err = l.Put(stars, created); err != nil {
+		t.Fatal(err)
+	}
+	if err = l.Put(&Query{Name: "authors", SQL: "SELECT DISTINCT author_email FROM commits"}, created); err != nil {
+		t.Fatal(err)
+	}
+	if err = l.Put(&Query{Name: "bad name", SQL: "SELECT 1"}, created); err == nil {
+		t.Fatal("expected an error saving a query with an invalid name")
+	}
+
+	if err = l.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if l, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if names := l.Names(); !reflect.DeepEqual(names, []string{"authors", "stars"}) {
+		t.Fatalf("expected the queries to be sorted by name, got %v", names)
+	}
+
+	var updated = created.Add(time.Hour)
+	if err = l.Put(&Query{Name: "stars", SQL: stars.SQL + " -- updated", Params: stars.Params}, updated); err != nil {
+		t.Fatal(err)
+	}
+	q, ok := l.Get("stars")
+	if !ok || !q.Created.Equal(created) || !q.Updated.Equal(updated) {
+		t.Fatalf("expected the replaced query to keep its creation time, got %+v", q)
+	}
+
+	if _, err = q.Values(nil); err == nil {
+		t.Fatal("expected an error for the missing value of repo")
+	}
+	if _, err = q.Values(map[string]string{"repo": "askgitdev/askgit", "bogus": "1"}); err == nil {
+		t.Fatal("expected an error for an unknown parameter")
+	}
+	values, err := q.Values(map[string]string{"repo": "askgitdev/askgit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want = []interface{}{sql.Named("repo", "askgitdev/askgit"), sql.Named("limit", "10")}
+	if args := NamedArgs(q.SQL, values); !reflect.DeepEqual(args, want) {
+		t.Fatalf("expected args %v, got %v", want, args)
+	}
+
+	if !l.Remove("authors") || l.Remove("authors") {
+		t.Fatal("expected authors to be removed once")
+	}
+}
+
+func TestParse(t *testing.T) {
+	queries, err := Parse([]byte(`{"name": "one", "sql": "SELECT 1", "tags": ["misc"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 1 || !queries[0].HasTag("misc") {
+		t.Fatalf("expected a single query tagged misc, got %+v", queries)
+	}
+
+	if _, err = Parse([]byte(`[{"name": "empty", "sql": " "}]`)); err == nil {
+		t.Fatal("expected an error for a query without SQL")
+	}
+}
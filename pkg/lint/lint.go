@@ -0,0 +1,187 @@
+// Package lint checks queries before they're run for mistakes that burn API quota,
+// such as tables backed by an API referenced without the arguments they require, or without a LIMIT.
+package lint
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/askgitdev/askgit/pkg/query"
+)
+
+// Table describes what a query of a table backed by an API should give it
+type Table struct {
+	// Args are the arguments (hidden columns) the table requires, in the order they're passed in
+	Args []string
+
+	// Paged reports whether the table pages through its results, a request at a time,
+	// so that reading all of them without a LIMIT (or max_pages) can take many requests
+	Paged bool
+
+	// Example is a call of the table with its arguments, to be suggested
+	Example string
+}
+
+// Warning is a mistake found in a query
+type Warning struct {
+	Table   string
+	Message string
+}
+
+// Check checks every statement of sql for references to the tables without their required arguments,
+// and to paged tables without a LIMIT. Arguments count as given if they're passed in a call of the table,
+// or if their column is referenced elsewhere in the statement (as in WHERE login = 'askgitdev').
+func Check(sql string, tables map[string]Table) []Warning {
+	var warnings []Warning
+	for _, stmt := range query.Split(sql) {
+		warnings = append(warnings, checkStatement(stmt, tables)...)
+	}
+	return warnings
+}
+
+func checkStatement(stmt string, tables map[string]Table) []Warning {
+	var tokens = tokenize(stmt)
+	var idents = make(map[string]int)
+	for _, tok := range tokens {
+		if tok.ident {
+			idents[tok.text]++
+		}
+	}
+
+	var warnings []Warning
+	var warned = make(map[string]bool)
+	for i, tok := range tokens {
+		t, ok := tables[tok.text]
+		if !tok.ident || !ok || warned[tok.text] {
+			continue
+		}
+		// only references to the table count, not columns that happen to share its name
+		if i == 0 || (tokens[i-1].text != "from" && tokens[i-1].text != "join" && tokens[i-1].text != ",") {
+			continue
+		}
+
+		var args int
+		if i+1 < len(tokens) && tokens[i+1].text == "(" {
+			args = countArgs(tokens[i+1:])
+		}
+
+		var missing []string
+		for _, arg := range t.Args[min(args, len(t.Args)):] {
+			if idents[arg] == 0 {
+				missing = append(missing, arg)
+			}
+		}
+
+		if len(missing) > 0 {
+			warned[tok.text] = true
+			var msg = fmt.Sprintf("%s is missing its %s argument, the query will fail", tok.text, strings.Join(missing, ", "))
+			if t.Example != "" {
+				msg += ", e.g. " + t.Example
+			}
+			warnings = append(warnings, Warning{Table: tok.text, Message: msg})
+			continue
+		}
+
+		if t.Paged && idents["limit"] == 0 && idents["max_pages"] == 0 {
+			warned[tok.text] = true
+			warnings = append(warnings, Warning{Table: tok.text, Message: fmt.Sprintf("%s is read without a LIMIT (or max_pages), every page of it is fetched, at a request each", tok.text)})
+		}
+	}
+	return warnings
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// countArgs counts the arguments of a call, whose tokens start at its opening parenthesis
+func countArgs(tokens []token) int {
+	var depth, args int
+	for i, tok := range tokens {
+		switch tok.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				if i == 1 {
+					return 0 // ()
+				}
+				return args + 1
+			}
+		case ",":
+			if depth == 1 {
+				args++
+			}
+		}
+	}
+	return args + 1
+}
+
+// token is a token of a statement: an identifier or keyword (lower-cased), a literal, or a punctuation character
+type token struct {
+	text  string
+	ident bool
+}
+
+// tokenize splits the statement into tokens, skipping comments
+func tokenize(stmt string) []token {
+	var tokens []token
+	for i := 0; i < len(stmt); i++ {
+		switch c := stmt[i]; {
+		case c == '\'':
+			end := skipUntil(stmt, i+1, '\'')
+			tokens = append(tokens, token{text: stmt[i:end]})
+			i = end - 1
+		case c == '"' || c == '`' || c == '[':
+			var term = c
+			if c == '[' {
+				term = ']'
+			}
+			end := skipUntil(stmt, i+1, term)
+			tokens = append(tokens, token{text: strings.ToLower(strings.Trim(stmt[i:end], "\"`[]")), ident: true})
+			i = end - 1
+		case strings.HasPrefix(stmt[i:], "--"):
+			if end := strings.IndexByte(stmt[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(stmt)
+			}
+		case strings.HasPrefix(stmt[i:], "/*"):
+			if end := strings.Index(stmt[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(stmt)
+			}
+		case c == '_' || c > 127 || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)):
+			start := i
+			for i+1 < len(stmt) && (stmt[i+1] == '_' || stmt[i+1] == '$' || stmt[i+1] > 127 || unicode.IsLetter(rune(stmt[i+1])) || unicode.IsDigit(rune(stmt[i+1]))) {
+				i++
+			}
+			var text = stmt[start : i+1]
+			tokens = append(tokens, token{text: strings.ToLower(text), ident: !unicode.IsDigit(rune(c))})
+		case !unicode.IsSpace(rune(c)):
+			tokens = append(tokens, token{text: string(c)})
+		}
+	}
+	return tokens
+}
+
+// skipUntil returns the index following the terminating character of a literal or quoted identifier starting at i,
+// a doubled terminating character being an escaped one
+func skipUntil(s string, i int, term byte) int {
+	for ; i < len(s); i++ {
+		if s[i] == term {
+			if i+1 < len(s) && s[i+1] == term && term != ']' {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
@@ -0,0 +1,39 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	var tables = map[string]Table{
+		"github_stargazers": {Args: []string{"owner"}, Paged: true, Example: "github_stargazers('askgitdev/askgit')"},
+		"github_org_repos":  {Args: []string{"login"}, Paged: true},
+		"github_repos":      {Args: []string{"repos"}},
+	}
+
+	for sql, want := range map[string][]string{
+		"SELECT * FROM github_stargazers('askgitdev/askgit') LIMIT 10":                              nil,
+		"SELECT * FROM github_stargazers WHERE owner = 'askgitdev' AND reponame = 'askgit' LIMIT 5": nil,
+		"SELECT count(*) FROM github_stargazers('askgitdev/askgit') WHERE max_pages = 2":            nil,
+		"SELECT * FROM commits LIMIT 1":                                                             nil,
+		"SELECT github_stargazers FROM t":                                                           nil,
+		"SELECT * FROM github_repos('askgitdev/askgit')":                                            nil,
+		"SELECT * FROM github_org_repos('askgitdev') r JOIN github_stargazers(r.name) s LIMIT 1":    nil,
+		"SELECT * FROM github_stargazers LIMIT 10":                                                  {"github_stargazers is missing its owner argument, the query will fail, e.g. github_stargazers('askgitdev/askgit')"},
+		"SELECT * FROM github_repos()":                                                              {"github_repos is missing its repos argument, the query will fail"},
+		"select * from GITHUB_ORG_REPOS('askgitdev') -- limit 10":                                   {"github_org_repos is read without a LIMIT (or max_pages), every page of it is fetched, at a request each"},
+		"SELECT * FROM github_repos; SELECT 'github_org_repos'; SELECT * FROM \"github_org_repos\"": {
+			"github_repos is missing its repos argument, the query will fail",
+			"github_org_repos is missing its login argument, the query will fail",
+		},
+	} {
+		var got []string
+		for _, w := range Check(sql, tables) {
+			got = append(got, w.Message)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %q, got %q", sql, want, got)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package llm provides a minimal client for chat completion APIs compatible with the OpenAI
+// chat completions endpoint, which most hosted and self-hosted model servers implement.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Client sends chat completion requests to Endpoint
+type Client struct {
+	// Endpoint is the full url of the chat completions endpoint,
+	// for instance https://api.openai.com/v1/chat/completions
+	Endpoint string
+
+	// Model is the name of the model to use
+	Model string
+
+	// APIKey is sent as a bearer token, if set
+	APIKey string
+
+	// HTTPClient is used to make requests, http.DefaultClient is used if nil
+	HTTPClient *http.Client
+}
+
+// Message is a single message in a chat
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Complete sends the system prompt and user message to the model and returns its reply
+func (c *Client) Complete(ctx context.Context, system, user string) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"model":       c.Model,
+		"temperature": 0,
+		"messages":    []Message{{Role: "system", Content: system}, {Role: "user", Content: user}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	var client = c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	contents, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("completion request failed with status %d: %s", res.StatusCode, strings.TrimSpace(string(contents)))
+	}
+
+	var completion struct {
+		Choices []struct {
+			Message Message `json:"message"`
+		} `json:"choices"`
+	}
+	if err = json.Unmarshal(contents, &completion); err != nil {
+		return "", fmt.Errorf("failed to decode completion response: %v", err)
+	}
+
+	if len(completion.Choices) == 0 {
+		return "", fmt.Errorf("completion response contained no choices")
+	}
+
+	return completion.Choices[0].Message.Content, nil
+}
+
+var fenced = regexp.MustCompile("(?s)```(?:sql|sqlite)?\\s*(.*?)```")
+
+// ExtractSQL returns the SQL statement in a model's reply, stripping any markdown code fences around it
+func ExtractSQL(reply string) string {
+	if m := fenced.FindStringSubmatch(reply); m != nil {
+		return strings.TrimSpace(m[1])
+	}
+	return strings.TrimSpace(reply)
+}
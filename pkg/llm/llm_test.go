@@ -0,0 +1,48 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			t.Fatalf("expected api key to be sent as a bearer token")
+		}
+
+		var req struct {
+			Model    string    `json:"model"`
+			Messages []Message `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+
+		if req.Model != "some-model" || len(req.Messages) != 2 {
+			t.Fatalf("unexpected request: %+v", req)
+		}
+
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "` + "```sql\\nSELECT 1\\n```" + `"}}]}`))
+	}))
+	defer srv.Close()
+
+	client := &Client{Endpoint: srv.URL, Model: "some-model", APIKey: "key"}
+	reply, err := client.Complete(context.Background(), "system", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sql := ExtractSQL(reply); sql != "SELECT 1" {
+		t.Fatalf("expected: %q, got: %q", "SELECT 1", sql)
+	}
+}
+
+func TestExtractSQL(t *testing.T) {
+	if sql := ExtractSQL("  SELECT * FROM commits  "); sql != "SELECT * FROM commits" {
+		t.Fatalf("unexpected sql: %q", sql)
+	}
+}
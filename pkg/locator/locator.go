@@ -12,12 +12,15 @@ import (
 	"io/ioutil"
 	"net/url"
 	"os"
+	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/askgitdev/askgit/tables"
 	"github.com/askgitdev/askgit/tables/services"
 	"github.com/go-git/go-billy/v5/osfs"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/cache"
 	"github.com/go-git/go-git/v5/storage/filesystem"
 	"github.com/pkg/errors"
@@ -33,22 +36,138 @@ func DiskLocator() services.RepoLocator {
 // CachedLocator is decorator function that takes a RepoLocator instance
 // and returns another one that caches output from the underlying locator
 // using path as the key.
-func CachedLocator(rl services.RepoLocator) services.RepoLocator {
-	var cache = make(map[string]*git.Repository)
+//
+// The returned locator is safe for concurrent use, and is a services.RepoPrefetcher:
+// repositories can be opened in the background by a bounded pool of workers (see WithPrefetchWorkers).
+func CachedLocator(rl services.RepoLocator, opts ...CacheOption) services.RepoLocator {
+	var c = &cachedLocator{locator: rl, workers: runtime.NumCPU(), cache: make(map[string]*openedRepo)}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.sem = make(chan struct{}, c.workers)
+	return c
+}
 
-	return tables.RepoLocatorFn(func(ctx context.Context, path string) (*git.Repository, error) {
-		if cached, ok := cache[path]; ok {
-			return cached, nil
-		}
+// CacheOption configures the locator returned by CachedLocator
+type CacheOption func(*cachedLocator)
+
+// WithPrefetchWorkers sets how many repositories can be opened concurrently in the background, NumCPU by default.
+// Zero disables prefetching.
+func WithPrefetchWorkers(n int) CacheOption {
+	return func(c *cachedLocator) { c.workers = n }
+}
+
+// WithSnapshot pins every repository to the commit ref (HEAD if empty) resolves to when the repository is first opened,
+// so that the tables reading HEAD by default keep reading that commit, even if the repository changes in the meantime.
+// The returned locator is then a services.SnapshotLocator.
+func WithSnapshot(ref string) CacheOption {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	return func(c *cachedLocator) { c.snapshotRef = ref }
+}
+
+// openedRepo is a repository in the cache, done is closed once it's opened (or failed to)
+type openedRepo struct {
+	done     chan struct{}
+	repo     *git.Repository
+	err      error
+	snapshot plumbing.Hash
+}
+
+type cachedLocator struct {
+	locator     services.RepoLocator
+	workers     int
+	sem         chan struct{} // bounds the prefetches in flight
+	snapshotRef string
+
+	mu    sync.Mutex
+	cache map[string]*openedRepo
+}
+
+// lookup returns the cache entry of path, and whether it was just created (and so must be opened by the caller)
+func (c *cachedLocator) lookup(path string) (*openedRepo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.cache[path]; ok {
+		return entry, false
+	}
+	var entry = &openedRepo{done: make(chan struct{})}
+	c.cache[path] = entry
+	return entry, true
+}
+
+// open opens the repository of a new cache entry, failures are evicted so that they can be retried
+func (c *cachedLocator) open(ctx context.Context, path string, entry *openedRepo) {
+	entry.repo, entry.err = c.locator.Open(ctx, path)
+	if entry.err == nil {
+		// also load HEAD, so that the packfile indexes are read in the background too
+		_, _ = entry.repo.Head()
 
-		repo, err := rl.Open(ctx, path)
-		if err != nil {
-			return nil, err
+		// repositories without any commit yet aren't pinned
+		if c.snapshotRef != "" {
+			if hash, err := entry.repo.ResolveRevision(plumbing.Revision(c.snapshotRef)); err == nil {
+				entry.snapshot = *hash
+			}
 		}
+	} else {
+		c.mu.Lock()
+		delete(c.cache, path)
+		c.mu.Unlock()
+	}
+	close(entry.done)
+}
 
-		cache[path] = repo
-		return repo, nil
-	})
+func (c *cachedLocator) Open(ctx context.Context, path string) (*git.Repository, error) {
+	entry, created := c.lookup(path)
+	if created {
+		c.open(ctx, path, entry)
+	}
+
+	select {
+	case <-entry.done:
+		return entry.repo, entry.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *cachedLocator) Snapshot(path string) (string, bool) {
+	if c.snapshotRef == "" {
+		return "", false
+	}
+
+	c.mu.Lock()
+	entry, ok := c.cache[path]
+	c.mu.Unlock()
+	if !ok {
+		return "", false
+	}
+
+	select {
+	case <-entry.done:
+		return entry.snapshot.String(), entry.err == nil && !entry.snapshot.IsZero()
+	default:
+		return "", false // still being opened
+	}
+}
+
+func (c *cachedLocator) Prefetch(ctx context.Context, path string) {
+	if c.workers <= 0 {
+		return
+	}
+
+	entry, created := c.lookup(path)
+	if !created {
+		return
+	}
+
+	go func() {
+		c.sem <- struct{}{}
+		defer func() { <-c.sem }()
+		c.open(ctx, path, entry)
+	}()
 }
 
 // HttpLocator returns a repo locator capable of cloning remote
@@ -0,0 +1,115 @@
+package locator
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/askgitdev/askgit/tables"
+	"github.com/askgitdev/askgit/tables/services"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+func TestCachedLocatorPrefetch(t *testing.T) {
+	var opened, inFlight, maxInFlight int32
+	var rl = tables.RepoLocatorFn(func(ctx context.Context, path string) (*git.Repository, error) {
+		atomic.AddInt32(&opened, 1)
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		if path == "missing" {
+			return nil, errors.New("not found")
+		}
+		return git.Init(memory.NewStorage(), nil)
+	})
+
+	var loc = CachedLocator(rl, WithPrefetchWorkers(2))
+	var paths = []string{"a", "b", "c", "d", "e"}
+	for _, path := range paths {
+		loc.(services.RepoPrefetcher).Prefetch(context.Background(), path)
+	}
+
+	// concurrent opens of prefetched repositories wait for them, rather than opening them again
+	var wg sync.WaitGroup
+	for _, path := range append(paths, paths...) {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			if _, err := loc.Open(context.Background(), path); err != nil {
+				t.Errorf("failed to open %s: %v", path, err)
+			}
+		}(path)
+	}
+	wg.Wait()
+
+	if opened != int32(len(paths)) {
+		t.Fatalf("expected %d repositories to be opened once, got: %d opens", len(paths), opened)
+	}
+	if maxInFlight > 2 {
+		t.Fatalf("expected at most 2 prefetches at once, got: %d", maxInFlight)
+	}
+
+	// failures aren't cached
+	for i := 0; i < 2; i++ {
+		if _, err := loc.Open(context.Background(), "missing"); err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	if opened != int32(len(paths))+2 {
+		t.Fatalf("expected failed opens to be retried, got: %d opens", opened)
+	}
+}
+
+func TestCachedLocatorSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := func(msg string) plumbing.Hash {
+		wt, err := repo.Worktree()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash, err := wt.Commit(msg, &git.CommitOptions{Author: &object.Signature{Name: "a", Email: "a@example.com", When: time.Now()}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+
+	var first = commit("first")
+	var loc = CachedLocator(DiskLocator(), WithSnapshot(""))
+	if _, ok := loc.(services.SnapshotLocator).Snapshot(dir); ok {
+		t.Fatal("expected no snapshot before the repository is opened")
+	}
+	if _, err = loc.Open(context.Background(), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// commits made after the repository was opened don't move the snapshot
+	commit("second")
+	if hash, ok := loc.(services.SnapshotLocator).Snapshot(dir); !ok || hash != first.String() {
+		t.Fatalf("expected the snapshot to be pinned to %s, got: %s", first, hash)
+	}
+}
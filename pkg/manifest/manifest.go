@@ -0,0 +1,134 @@
+// Package manifest describes exported datasets (their tables, row counts, column schemas, time ranges
+// and file checksums) so that downstream pipelines can check that a scheduled dump is complete.
+package manifest
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// Manifest describes a dataset
+type Manifest struct {
+	CreatedAt time.Time `json:"created_at"`
+	Tables    []*Table  `json:"tables"`
+	Files     []*File   `json:"files"`
+}
+
+// Table describes a table of a dataset
+type Table struct {
+	Name    string    `json:"name"`
+	Rows    int64     `json:"rows"`
+	Columns []*Column `json:"columns"`
+}
+
+// Column describes a column of a table. Timestamp columns also have the range of their values.
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+
+	Min interface{} `json:"min,omitempty"`
+	Max interface{} `json:"max,omitempty"`
+}
+
+// File is a file of a dataset, with its checksum
+type File struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// timestampColumn matches the names of the columns holding timestamps, such as created_at or author_when
+var timestampColumn = regexp.MustCompile(`(?i)(^|_)(at|when|date|time|timestamp)$`)
+
+// New returns an empty manifest
+func New() *Manifest {
+	return &Manifest{CreatedAt: time.Now().UTC()}
+}
+
+// AddTable describes the table called name of db in the manifest
+func (m *Manifest) AddTable(ctx context.Context, db *sql.DB, name string) error {
+	var table = &Table{Name: name}
+	if err := db.QueryRowContext(ctx, fmt.Sprintf("SELECT count(*) FROM %q", name)).Scan(&table.Rows); err != nil {
+		return err
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT name, type FROM pragma_table_info(?) ORDER BY cid", name)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c Column
+		if err = rows.Scan(&c.Name, &c.Type); err != nil {
+			return err
+		}
+		table.Columns = append(table.Columns, &c)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	_ = rows.Close()
+
+	for _, c := range table.Columns {
+		if !timestampColumn.MatchString(c.Name) {
+			continue
+		}
+		var min, max interface{}
+		if err = db.QueryRowContext(ctx, fmt.Sprintf("SELECT min(%q), max(%q) FROM %q", c.Name, c.Name, name)).Scan(&min, &max); err != nil {
+			return err
+		}
+		c.Min, c.Max = text(min), text(max)
+	}
+
+	m.Tables = append(m.Tables, table)
+	return nil
+}
+
+// text returns the text of values scanned as bytes, so that they're encoded as strings rather than base64
+func text(v interface{}) interface{} {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return v
+}
+
+// AddFile adds the checksum of the file at path to the manifest. Paths are recorded relative to dir.
+func (m *Manifest) AddFile(dir, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+
+	if rel, err := filepath.Rel(dir, path); err == nil {
+		path = rel
+	}
+	m.Files = append(m.Files, &File{Path: filepath.ToSlash(path), Size: size, SHA256: hex.EncodeToString(h.Sum(nil))})
+	return nil
+}
+
+// Write writes the manifest as JSON to path
+func (m *Manifest) Write(path string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
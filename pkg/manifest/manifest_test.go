@@ -0,0 +1,67 @@
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestManifest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "commits"`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery(`pragma_table_info`).WithArgs("commits").WillReturnRows(sqlmock.NewRows([]string{"name", "type"}).
+		AddRow("hash", "TEXT").AddRow("author_when", "TEXT").AddRow("additions", "INT"))
+	mock.ExpectQuery(`SELECT min\("author_when"\), max\("author_when"\) FROM "commits"`).
+		WillReturnRows(sqlmock.NewRows([]string{"min", "max"}).AddRow([]byte("2020-01-01T00:00:00Z"), []byte("2021-06-01T00:00:00Z")))
+
+	var m = New()
+	if err = m.AddTable(context.Background(), db, "commits"); err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "askgit-manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var dataset = filepath.Join(dir, "export.db")
+	if err = ioutil.WriteFile(dataset, []byte("abc"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.AddFile(dir, dataset); err != nil {
+		t.Fatal(err)
+	}
+
+	var path = filepath.Join(dir, "manifest.json")
+	if err = m.Write(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var got Manifest
+	b, _ := ioutil.ReadFile(path)
+	if err = json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	var commits = got.Tables[0]
+	if commits.Rows != 2 || len(commits.Columns) != 3 || commits.Columns[1].Min != "2020-01-01T00:00:00Z" || commits.Columns[0].Min != nil {
+		t.Fatalf("unexpected table: %s", b)
+	}
+	if f := got.Files[0]; f.Path != "export.db" || f.Size != 3 || f.SHA256 != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Fatalf("unexpected file: %+v", f)
+	}
+}
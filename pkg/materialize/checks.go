@@ -0,0 +1,100 @@
+package materialize
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Checks are assertions on the rows of a table, evaluated on every sync before the rows are committed,
+// so that the rows of a faulty sync (a silent pagination bug, for instance) are rolled back rather than
+// corrupting what's downstream:
+//
+//	checks:
+//	  not_null: [node_id, title]
+//	  unique: [node_id]
+//	  monotonic: [updated_at]
+type Checks struct {
+	// NotNull lists the columns that can't be NULL
+	NotNull []string `json:"not_null,omitempty"`
+
+	// Unique lists the columns whose (non-NULL) values must be unique
+	Unique []string `json:"unique,omitempty"`
+
+	// Monotonic lists the columns whose maximum can't decrease from a sync to the next
+	Monotonic []string `json:"monotonic,omitempty"`
+}
+
+// CheckError is returned by the sync of a table failing its checks, the rows synced are rolled back
+type CheckError struct {
+	Table    string
+	Failures []string
+}
+
+func (e *CheckError) Error() string {
+	return fmt.Sprintf("table %s failed its checks, the sync was rolled back: %s", e.Table, strings.Join(e.Failures, "; "))
+}
+
+const checksTable = "askgit_sync_checks"
+
+// check evaluates the checks of t against the rows written in tx, and records the maximum of its monotonic columns
+func (r *Runner) check(ctx context.Context, tx *sql.Tx, t *Table) error {
+	if t.Checks == nil {
+		return nil
+	}
+
+	var failures []string
+	var count = func(query string, args ...interface{}) (int64, error) {
+		var n int64
+		err := tx.QueryRowContext(ctx, query, args...).Scan(&n)
+		return n, err
+	}
+
+	for _, column := range t.Checks.NotNull {
+		n, err := count(fmt.Sprintf("SELECT count(*) FROM %s WHERE %s IS NULL", quote(t.Name), quote(column)))
+		if err != nil {
+			return fmt.Errorf("failed to check table %s: %v", t.Name, err)
+		}
+		if n > 0 {
+			failures = append(failures, fmt.Sprintf("%d rows with a NULL %s", n, column))
+		}
+	}
+
+	for _, column := range t.Checks.Unique {
+		n, err := count(fmt.Sprintf("SELECT count(*) FROM (SELECT %s FROM %s WHERE %s IS NOT NULL GROUP BY %s HAVING count(*) > 1)", quote(column), quote(t.Name), quote(column), quote(column)))
+		if err != nil {
+			return fmt.Errorf("failed to check table %s: %v", t.Name, err)
+		}
+		if n > 0 {
+			failures = append(failures, fmt.Sprintf("%d duplicated values of %s", n, column))
+		}
+	}
+
+	if len(t.Checks.Monotonic) > 0 {
+		// the maximum is kept as it is, without a type affinity, to be compared with the next one
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (table_name TEXT NOT NULL, column_name TEXT NOT NULL, max_value, PRIMARY KEY (table_name, column_name))", checksTable)); err != nil {
+			return err
+		}
+	}
+	for _, column := range t.Checks.Monotonic {
+		n, err := count(fmt.Sprintf("SELECT count(*) FROM %s WHERE table_name = ? AND column_name = ? AND (SELECT max(%s) FROM %s) < max_value", checksTable, quote(column), quote(t.Name)), t.Name, column)
+		if err != nil {
+			return fmt.Errorf("failed to check table %s: %v", t.Name, err)
+		}
+		if n > 0 {
+			failures = append(failures, fmt.Sprintf("the maximum of %s decreased since the last sync", column))
+			continue
+		}
+
+		var record = fmt.Sprintf("INSERT INTO %s (table_name, column_name, max_value) SELECT ?, ?, max(%s) FROM %s WHERE true ON CONFLICT (table_name, column_name) DO UPDATE SET max_value = excluded.max_value", checksTable, quote(column), quote(t.Name))
+		if _, err = tx.ExecContext(ctx, record, t.Name, column); err != nil {
+			return err
+		}
+	}
+
+	if len(failures) > 0 {
+		return &CheckError{Table: t.Name, Failures: failures}
+	}
+	return nil
+}
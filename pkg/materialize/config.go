@@ -0,0 +1,273 @@
+// Package materialize implements `askgit sync`, which keeps the results of queries materialized as tables
+// of a SQLite database, so that they can be queried (and joined) without hitting the APIs again,
+// and the resumable writes of `askgit export`.
+//
+// Queries can be windowed in time, with the :since and :until parameters, so that tables are synced
+// incrementally and that years of history can be backfilled one window at a time (see Window).
+//
+// Every table is synced by running its query into a staging table, applying any transforms and redaction
+// to it, and writing its rows into the table: replacing them all, or updating them by key if the table has one.
+// When the columns of a query change (between askgit versions, or as the query is edited) the table is
+// migrated, rather than failing or losing columns: new columns are added and backfilled, and columns that
+// are no longer returned are kept, with NULL in new rows.
+package materialize
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/askgitdev/askgit/pkg/transport"
+	"github.com/ghodss/yaml"
+)
+
+// Config describes the tables to sync, it's usually read from a YAML file:
+//
+//	database: askgit.db
+//	redact: [emails]
+//	vacuum: true
+//	workers: 4
+//	budget: 5000/h
+//	reserve: 500
+//	orgs:
+//	  - name: askgitdev
+//	    weight: 2
+//	  - name: example
+//	tables:
+//	  - name: issues
+//	    query: SELECT * FROM github_repo_issues('askgitdev/askgit')
+//	    key: [issue_number]
+//	    versioned: true
+//	    transforms:
+//	      author_login: lower(author_login)
+//	  - name: repos
+//	    query: SELECT name FROM github_org_repos('askgitdev')
+//	    key: [name]
+//	    org: askgitdev
+//	    priority: high
+//	  - name: org_issues
+//	    query: SELECT repos.name AS repo, i.* FROM repos, github_repo_issues('askgitdev', repos.name) AS i
+//	    key: [repo, issue_number]
+//	    depends_on: [repos]
+//	    org: askgitdev
+//	  - name: example_stars
+//	    query: SELECT * FROM github_stargazers('example/example')
+//	    org: example
+//	    priority: low
+//	  - name: builds
+//	    query: SELECT * FROM jenkins_builds('https://ci.example.com/job/platform/')
+//	    key: [job, number]
+//	    retention:
+//	      column: started_at
+//	      keep: 180d
+type Config struct {
+	// Database is the path of the SQLite database the tables are synced to
+	Database string `json:"database"`
+
+	// Redact lists the kinds of personal data redacted from every table, see package redact
+	Redact []string `json:"redact,omitempty"`
+
+	// Vacuum rebuilds the database once rows were pruned by the retention of its tables, to return their space
+	Vacuum bool `json:"vacuum,omitempty"`
+
+	// Workers is the number of tables synced concurrently, in the order of their dependencies (see Runner.SyncAll)
+	Workers int `json:"workers,omitempty"`
+
+	// Budget, if set, caps the rate of the API requests of all the tables together, e.g. 5000/h (see transport.ParseBudget)
+	Budget string `json:"budget,omitempty"`
+
+	// Orgs lists the organizations tables are synced for (see Table.Org), with their share of the API quota
+	Orgs []*Org `json:"orgs,omitempty"`
+
+	// Reserve, if set, is the remaining API quota under which it's scarce, and the tables with a low priority
+	// are deferred to the next run, leaving the quota to the others (see Scheduler)
+	Reserve int64 `json:"reserve,omitempty"`
+
+	Tables []*Table `json:"tables"`
+}
+
+// Table is a table kept in sync with the results of a query
+type Table struct {
+	// Name is the name of the table in the database
+	Name string `json:"name"`
+
+	// Query is the query the rows of the table are read from, it can be windowed (see Window)
+	Query string `json:"query"`
+
+	// Key lists the columns identifying a row. Tables with a key have their rows updated in place,
+	// while the rows of tables without one are all replaced on every sync.
+	Key []string `json:"key,omitempty"`
+
+	// Transforms maps columns to the SQL expressions they're set to, evaluated on every row (see package transform)
+	Transforms map[string]string `json:"transforms,omitempty"`
+
+	// Versioned keeps every version of the rows of the table in its history table, so that it can be queried
+	// as of a point in time (see AsOf). Versioned tables need a key.
+	Versioned bool `json:"versioned,omitempty"`
+
+	// Checks, if set, are evaluated on every sync, whose rows are rolled back if they fail
+	Checks *Checks `json:"checks,omitempty"`
+
+	// Retention, if set, prunes the rows of the table older than it keeps (see Runner.Prune)
+	Retention *Retention `json:"retention,omitempty"`
+
+	// DependsOn lists the tables the query of the table reads, which are synced before it
+	DependsOn []string `json:"depends_on,omitempty"`
+
+	// Events lists the organizations (askgitdev) and repositories (askgitdev/askgit) whose events tell what changed
+	// since the last sync, so that only the rows of what changed are refreshed. The query reads them from the
+	// :changed_repos and :changed_issues parameters, which are NULL on the first sync, or if the events don't reach
+	// back to the last one, when every row is synced. Tables following events need a key.
+	Events []string `json:"events,omitempty"`
+
+	// Org is the organization the table is synced for, which its API calls are charged to (see Config.Orgs)
+	Org string `json:"org,omitempty"`
+
+	// Priority is high, normal (the default) or low. Tables with a higher priority are synced first,
+	// and those with a low priority are deferred to the next run while the API quota is scarce.
+	Priority string `json:"priority,omitempty"`
+}
+
+// Org is an organization tables are synced for
+type Org struct {
+	Name string `json:"name"`
+
+	// Weight is the share of the API quota of the organization, relative to the others, 1 if not set
+	Weight int `json:"weight,omitempty"`
+}
+
+var validName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// LoadConfig reads the configuration in the YAML (or JSON) file at path
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err = yaml.Unmarshal(b, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse sync configuration %s: %v", path, err)
+	}
+
+	if err = config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid sync configuration %s: %v", path, err)
+	}
+	return &config, nil
+}
+
+// Validate checks that the configuration is complete, that table names are unique, and that tables don't depend on each other
+func (c *Config) Validate() error {
+	if c.Database == "" {
+		return fmt.Errorf("no database")
+	}
+	if c.Budget != "" {
+		if _, err := transport.ParseBudget(c.Budget); err != nil {
+			return err
+		}
+	}
+
+	var orgs = make(map[string]bool, len(c.Orgs))
+	for i, org := range c.Orgs {
+		if org.Name == "" {
+			return fmt.Errorf("organization %d has no name", i)
+		}
+		if orgs[org.Name] {
+			return fmt.Errorf("organization %s is listed twice", org.Name)
+		}
+		if org.Weight < 0 {
+			return fmt.Errorf("organization %s has a negative weight", org.Name)
+		}
+		orgs[org.Name] = true
+	}
+	if c.Reserve < 0 {
+		return fmt.Errorf("negative reserve")
+	}
+
+	var seen = make(map[string]bool)
+	for i, t := range c.Tables {
+		if !validName.MatchString(t.Name) {
+			return fmt.Errorf("table %d: invalid name %q", i, t.Name)
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("table %s is defined twice", t.Name)
+		}
+		seen[t.Name] = true
+
+		if t.Query == "" {
+			return fmt.Errorf("table %s has no query", t.Name)
+		}
+		if t.Org != "" && len(c.Orgs) > 0 && !orgs[t.Org] {
+			return fmt.Errorf("table %s is synced for organization %s, which isn't listed in orgs", t.Name, t.Org)
+		}
+		if _, ok := priorities[t.Priority]; !ok {
+			return fmt.Errorf("table %s: invalid priority %q, expected high, normal or low", t.Name, t.Priority)
+		}
+
+		// the rows of a window replace all rows of tables without a key
+		if t.Windowed() && len(t.Key) == 0 {
+			return fmt.Errorf("table %s has a windowed query, and so needs a key", t.Name)
+		}
+
+		// only the rows that changed are written, the others must be left as they are
+		if len(t.Events) > 0 {
+			if len(t.Key) == 0 {
+				return fmt.Errorf("table %s follows events, and so needs a key", t.Name)
+			}
+			if !strings.Contains(t.Query, ":changed_repos") && !strings.Contains(t.Query, ":changed_issues") {
+				return fmt.Errorf("table %s follows events, but its query doesn't reference :changed_repos or :changed_issues", t.Name)
+			}
+			for _, source := range t.Events {
+				if source == "" || strings.Count(source, "/") > 1 {
+					return fmt.Errorf("table %s: invalid events source %q, expected an organization or a repository (owner/name)", t.Name, source)
+				}
+			}
+		}
+
+		// versions of a row are told apart by its key
+		if t.Versioned && len(t.Key) == 0 {
+			return fmt.Errorf("table %s is versioned, and so needs a key", t.Name)
+		}
+
+		if t.Retention != nil {
+			if t.Retention.Column == "" {
+				return fmt.Errorf("table %s: retention has no column", t.Name)
+			}
+			if _, err := ParseWindow(t.Retention.Keep); err != nil {
+				return fmt.Errorf("table %s: invalid retention: %v", t.Name, err)
+			}
+		}
+	}
+
+	for _, t := range c.Tables {
+		if t.Versioned && seen[historyTable(t)] {
+			return fmt.Errorf("table %s is the history of versioned table %s, and can't be synced", historyTable(t), t.Name)
+		}
+		for _, d := range t.DependsOn {
+			if !seen[d] {
+				return fmt.Errorf("table %s depends on table %s, which isn't in the configuration", t.Name, d)
+			}
+		}
+	}
+	return dependencyCycle(c.Tables)
+}
+
+// Weights returns the weights of the organizations of the configuration, by name
+func (c *Config) Weights() map[string]int {
+	var weights = make(map[string]int, len(c.Orgs))
+	for _, org := range c.Orgs {
+		weights[org.Name] = org.Weight
+	}
+	return weights
+}
+
+// Table returns the table called name, or nil
+func (c *Config) Table(name string) *Table {
+	for _, t := range c.Tables {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
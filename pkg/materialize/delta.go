@@ -0,0 +1,58 @@
+package materialize
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/events"
+)
+
+// ChangeFeed tells which repositories and issues had events since a point in time, it's implemented by *events.Client
+type ChangeFeed interface {
+	Changes(ctx context.Context, sources []string, since time.Time) (*events.Changes, error)
+}
+
+// eventsLag is how late events can show up in the API, the events since that long before the last sync are read
+const eventsLag = 6 * time.Hour
+
+// changes returns what changed since the last sync of t, or nil if the events don't reach back to it,
+// and every row must be synced
+func (r *Runner) changes(ctx context.Context, t *Table, since time.Time) (*events.Changes, error) {
+	if r.Events == nil {
+		return nil, fmt.Errorf("table %s follows events, but they can't be read", t.Name)
+	}
+
+	changes, err := r.Events.Changes(ctx, t.Events, since.Add(-eventsLag))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the events of table %s: %v", t.Name, err)
+	}
+	if !changes.Complete {
+		r.logf("%s: the events don't reach back to the last sync, syncing every row", t.Name)
+		return nil, nil
+	}
+	return changes, nil
+}
+
+// changeParams returns the :changed_repos and :changed_issues parameters of the query of t: JSON arrays of
+// the repositories (owner/name) and issues ({"repo": "owner/name", "number": 1}) that changed, or NULL if changes is nil
+func changeParams(t *Table, changes *events.Changes) []interface{} {
+	var repos, issues interface{}
+	if changes != nil {
+		r, _ := json.Marshal(changes.Repos)
+		i, _ := json.Marshal(changes.Issues)
+		repos, issues = string(r), string(i)
+	}
+
+	var params []interface{}
+	if strings.Contains(t.Query, ":changed_repos") {
+		params = append(params, sql.Named("changed_repos", repos))
+	}
+	if strings.Contains(t.Query, ":changed_issues") {
+		params = append(params, sql.Named("changed_issues", issues))
+	}
+	return params
+}
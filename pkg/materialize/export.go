@@ -0,0 +1,187 @@
+package materialize
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+const checkpointsTable = "askgit_export_checkpoints"
+
+// ExportOptions configures ExportTable
+type ExportOptions struct {
+	// ChunkSize is the number of rows written per transaction, and so between checkpoints
+	ChunkSize int
+
+	// Resume continues tables partially written by a previous export, rather than failing on them
+	Resume bool
+
+	// Finish, if set, is called once all the rows of a table are written, before it's marked as complete.
+	// It's called again if the export is interrupted and resumed in between.
+	Finish func(ctx context.Context, db *sql.DB, table string) error
+}
+
+// ExportTable writes the rows returned by query to a new table of db, committing them in chunks,
+// and recording the number of rows written in a checkpoint table. If the export is interrupted,
+// it can be resumed, with Resume set, from the last checkpoint: the query is run again, skipping
+// the rows already written. Queries must therefore return rows in a stable order.
+// It returns the number of rows written, and whether the table was already complete.
+func ExportTable(ctx context.Context, db *sql.DB, table, query string, opts ExportOptions) (int64, bool, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 10000
+	}
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (table_name TEXT PRIMARY KEY, query TEXT NOT NULL, rows INTEGER NOT NULL, completed INTEGER NOT NULL DEFAULT 0)", checkpointsTable)); err != nil {
+		return 0, false, err
+	}
+
+	var checkpointQuery string
+	var written int64
+	var completed bool
+	switch err := db.QueryRowContext(ctx, fmt.Sprintf("SELECT query, rows, completed FROM %s WHERE table_name = ?", checkpointsTable), table).Scan(&checkpointQuery, &written, &completed); {
+	case err == sql.ErrNoRows:
+		if err = createExportTable(ctx, db, table, query); err != nil {
+			return 0, false, err
+		}
+	case err != nil:
+		return 0, false, err
+	case !opts.Resume && completed:
+		return 0, false, fmt.Errorf("table %s already exists", table)
+	case !opts.Resume:
+		return 0, false, fmt.Errorf("table %s was partially exported (%d rows), use --resume to continue", table, written)
+	case checkpointQuery != query:
+		return 0, false, fmt.Errorf("table %s was exported from another query, it can't be resumed", table)
+	case completed:
+		return written, true, nil
+	}
+
+	n, err := writeChunks(ctx, db, table, query, written, opts.ChunkSize)
+	if err != nil {
+		return written + n, false, err
+	}
+
+	if opts.Finish != nil {
+		if err = opts.Finish(ctx, db, table); err != nil {
+			return written + n, false, err
+		}
+	}
+
+	_, err = db.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET completed = 1 WHERE table_name = ?", checkpointsTable), table)
+	return written + n, false, err
+}
+
+// createExportTable creates the table with the columns of query, and its checkpoint
+func createExportTable(ctx context.Context, db *sql.DB, table, query string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = tx.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s AS SELECT * FROM (%s) LIMIT 0", quote(table), query)); err != nil {
+		return fmt.Errorf("failed to execute query: %v", err)
+	}
+	if _, err = tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (table_name, query, rows) VALUES (?, ?, 0)", checkpointsTable), table, query); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// writeChunks copies the rows of query, past the first offset ones, to table, one chunk per transaction.
+// It returns the number of rows written.
+func writeChunks(ctx context.Context, db *sql.DB, table, query string, offset int64, chunkSize int) (int64, error) {
+	columns, err := tableColumns(ctx, db, table)
+	if err != nil {
+		return 0, err
+	}
+
+	var names, selected, params []string
+	for _, c := range columns {
+		names = append(names, quote(c.Name))
+		// the unary + leaves values as they are, but drops the declared type of the column,
+		// which the driver would otherwise use to convert DATETIME columns to time.Time
+		selected = append(selected, "+"+quote(c.Name))
+		params = append(params, "?")
+	}
+
+	// rows are read and written on a single connection, as SQLite lets a connection write while it reads,
+	// but a writer would wait forever on the lock held by the reading connection
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM (%s) LIMIT -1 OFFSET ?", strings.Join(selected, ", "), query), offset)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer rows.Close()
+
+	insert, err := conn.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quote(table), strings.Join(names, ", "), strings.Join(params, ", ")))
+	if err != nil {
+		return 0, err
+	}
+	defer insert.Close()
+
+	var written, pending int64
+	var commit = func() error {
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET rows = rows + ? WHERE table_name = ?", checkpointsTable), pending, table); err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+			return err
+		}
+		written, pending = written+pending, 0
+		return nil
+	}
+
+	var values = make([]interface{}, len(columns))
+	var dest = make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	var inTx bool
+	defer func() {
+		if inTx {
+			_, _ = conn.ExecContext(context.Background(), "ROLLBACK")
+		}
+	}()
+
+	for rows.Next() {
+		if !inTx {
+			if _, err = conn.ExecContext(ctx, "BEGIN"); err != nil {
+				return written, err
+			}
+			inTx = true
+		}
+
+		if err = rows.Scan(dest...); err != nil {
+			return written, err
+		}
+		if _, err = insert.ExecContext(ctx, values...); err != nil {
+			return written, err
+		}
+
+		if pending++; pending == int64(chunkSize) {
+			if err = commit(); err != nil {
+				return written, err
+			}
+			inTx = false
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return written, fmt.Errorf("failed to execute query: %v", err)
+	}
+
+	if inTx {
+		if err = commit(); err != nil {
+			return written, err
+		}
+		inTx = false
+	}
+	return written, nil
+}
@@ -0,0 +1,126 @@
+package materialize
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// columns of the history tables of versioned tables, bounding the time every version of a row was current
+const (
+	validFrom = "valid_from"
+	validTo   = "valid_to"
+)
+
+// historyTable returns the name of the table the versions of the rows of t are kept in.
+//
+// Every sync of a versioned table closes the current version of the rows whose values changed (or that are
+// no longer returned by a query that isn't windowed, nor limited to what changed), setting its valid_to to the time of the sync, and adds
+// a version of the new and changed rows, valid from that time, whose valid_to is NULL until it changes again.
+// Every sync is recorded in the askgit_sync_runs table, to be diffed with others (see DiffSnapshots). Versions are RFC 3339 timestamps in UTC, and so history
+// is as precise as syncs are frequent, and starts with the first sync of the table.
+func historyTable(t *Table) string { return t.Name + "_history" }
+
+// migrateHistory migrates the history table of t to the given columns of the table, and the validity columns
+func (r *Runner) migrateHistory(ctx context.Context, t *Table, columns []Column) error {
+	var validity = []Column{{validFrom, "TEXT"}, {validTo, "TEXT"}}
+
+	existing, err := tableColumns(ctx, r.db(), historyTable(t))
+	if err != nil {
+		return err
+	}
+
+	var plan = Diff(historyTable(t), existing, append(columns[:len(columns):len(columns)], validity...))
+	if plan.Create {
+		// the history is created from the staging table, which lacks the validity columns
+		plan.Added = validity
+	}
+	if plan.Drifted() && !plan.Create {
+		r.logf("migrating %s", plan)
+	}
+	return plan.apply(ctx, r.db(), stagingTable(t))
+}
+
+// version records the rows of the staging table of t, which has the given columns, as new versions
+// valid from now, closing the versions they replace. With partial set, the staging table only has some of the rows.
+func (r *Runner) version(ctx context.Context, tx *sql.Tx, t *Table, columns []string, partial bool, now time.Time) error {
+	var history, staging = historyTable(t), stagingTable(t)
+	var at = now.UTC().Format(time.RFC3339)
+
+	// the history table is named rather than aliased in the update, as older versions of SQLite can't alias it
+	var sameKey, sameValues = make([]string, len(t.Key)), make([]string, len(columns))
+	for i, k := range t.Key {
+		sameKey[i] = fmt.Sprintf("s.%s = %s.%s", quote(k), quote(history), quote(k))
+	}
+	for i, c := range columns {
+		sameValues[i] = fmt.Sprintf("s.%s IS %s.%s", quote(c), quote(history), quote(c))
+	}
+
+	var closeChanged = fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s IS NULL AND EXISTS (SELECT 1 FROM %s AS s WHERE %s AND NOT (%s))",
+		quote(history), validTo, validTo, quote(staging), strings.Join(sameKey, " AND "), strings.Join(sameValues, " AND "))
+	if _, err := tx.ExecContext(ctx, closeChanged, at); err != nil {
+		return fmt.Errorf("failed to close the versions of table %s: %v", t.Name, err)
+	}
+
+	// the rows of a window (or that changed) are only some of the rows, those of other syncs no longer returned are gone
+	if !partial {
+		var closeRemoved = fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s IS NULL AND NOT EXISTS (SELECT 1 FROM %s AS s WHERE %s)",
+			quote(history), validTo, validTo, quote(staging), strings.Join(sameKey, " AND "))
+		if _, err := tx.ExecContext(ctx, closeRemoved, at); err != nil {
+			return fmt.Errorf("failed to close the versions of table %s: %v", t.Name, err)
+		}
+	}
+
+	var openCurrent = fmt.Sprintf("INSERT INTO %s (%s, %s) SELECT %s, ? FROM %s AS s WHERE NOT EXISTS (SELECT 1 FROM %s WHERE %s AND %s IS NULL)",
+		quote(history), quoteAll(columns), validFrom, quoteAll(columns), quote(staging), quote(history), strings.Join(sameKey, " AND "), validTo)
+	if _, err := tx.ExecContext(ctx, openCurrent, at); err != nil {
+		return fmt.Errorf("failed to version table %s: %v", t.Name, err)
+	}
+	return recordRun(ctx, tx, t, at)
+}
+
+// AsOf shadows every versioned table of tables, for the queries run on conn, with a temporary view of its history
+// returning the rows of the table as they were synced at the given time:
+//
+//	conn, _ := db.Conn(ctx)
+//	_ = materialize.AsOf(ctx, conn, config.Tables, time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC))
+//	conn.QueryRowContext(ctx, "SELECT count(*) FROM issues WHERE state = 'OPEN'")
+//
+// Tables have no rows before their first sync.
+func AsOf(ctx context.Context, conn *sql.Conn, tables []*Table, at time.Time) error {
+	var ts = at.UTC().Format(time.RFC3339)
+	for _, t := range tables {
+		if !t.Versioned {
+			continue
+		}
+
+		columns, err := tableColumns(ctx, conn, historyTable(t))
+		if err != nil {
+			return err
+		}
+		if columns == nil {
+			return fmt.Errorf("table %s has no history, it's yet to be synced", t.Name)
+		}
+
+		var names []string
+		for _, c := range columns {
+			if !strings.EqualFold(c.Name, validFrom) && !strings.EqualFold(c.Name, validTo) {
+				names = append(names, c.Name)
+			}
+		}
+
+		// temporary objects take precedence over those of the main schema, the view stands in for the table
+		for _, statement := range []string{
+			fmt.Sprintf("DROP VIEW IF EXISTS temp.%s", quote(t.Name)),
+			fmt.Sprintf("CREATE TEMP VIEW %s AS SELECT %s FROM main.%s WHERE %s <= '%s' AND (%s IS NULL OR %s > '%s')",
+				quote(t.Name), quoteAll(names), quote(historyTable(t)), validFrom, ts, validTo, validTo, ts),
+		} {
+			if _, err = conn.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("failed to query table %s as of %s: %v", t.Name, ts, err)
+			}
+		}
+	}
+	return nil
+}
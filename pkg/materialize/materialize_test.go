@@ -0,0 +1,767 @@
+package materialize
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/askgitdev/askgit/pkg/events"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "askgit-sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "sync.yaml")
+	if err = ioutil.WriteFile(path, []byte(`
+database: askgit.db
+tables:
+  - name: issues
+    query: SELECT * FROM github_repo_issues('askgitdev/askgit')
+    key: [number]
+    transforms:
+      author_login: lower(author_login)
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issues := config.Table("issues"); issues == nil || issues.Key[0] != "number" || issues.Transforms["author_login"] != "lower(author_login)" {
+		t.Fatalf("unexpected configuration: %+v", config.Tables[0])
+	}
+
+	var invalid = &Config{Database: "askgit.db", Tables: []*Table{{Name: "issues; DROP TABLE x", Query: "SELECT 1"}}}
+	if err = invalid.Validate(); err == nil {
+		t.Fatal("expected an invalid table name to be rejected")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	if plan := Diff("issues", nil, []Column{{"number", "INT"}}); !plan.Create {
+		t.Fatalf("expected a missing table to be created: %+v", plan)
+	}
+
+	var existing = []Column{{"number", "INT"}, {"title", "TEXT"}, {"author", "TEXT"}}
+	var wanted = []Column{{"Number", "INT"}, {"title", "NUM"}, {"author_login", "TEXT"}}
+
+	plan := Diff("issues", existing, wanted)
+	if len(plan.Added) != 1 || plan.Added[0].Name != "author_login" {
+		t.Fatalf("expected author_login to be added: %+v", plan)
+	}
+	if len(plan.Removed) != 1 || plan.Removed[0].Name != "author" {
+		t.Fatalf("expected author to be removed: %+v", plan)
+	}
+	if len(plan.Retyped) != 1 || plan.Retyped[0].Name != "title" {
+		t.Fatalf("expected title to be retyped: %+v", plan)
+	}
+
+	if plan = Diff("issues", existing, existing); plan.Drifted() || plan.String() != "issues: up to date" {
+		t.Fatalf("expected no drift: %s", plan)
+	}
+}
+
+func TestSyncMigrates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var exact = func(sql string) string { return "^" + regexp.QuoteMeta(sql) + "$" }
+	var columns = func(names ...string) *sqlmock.Rows {
+		var rows = sqlmock.NewRows([]string{"name", "type"})
+		for _, name := range names {
+			rows.AddRow(name, "")
+		}
+		return rows
+	}
+
+	mock.ExpectExec(exact(`DROP TABLE IF EXISTS "askgit_staging_issues"`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(exact(`CREATE TEMP TABLE "askgit_staging_issues" AS SELECT * FROM (SELECT * FROM github_repo_issues('askgitdev/askgit'))`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`pragma_table_info`).WithArgs("askgit_staging_issues").WillReturnRows(columns("number", "title", "state"))
+	mock.ExpectQuery(`pragma_table_info`).WithArgs("issues").WillReturnRows(columns("number", "title"))
+
+	// the new column is added, and recorded as a migration
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS askgit_sync_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(exact(`ALTER TABLE "issues" ADD COLUMN "state"`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO askgit_sync_migrations`).WithArgs("issues", `ALTER TABLE "issues" ADD COLUMN "state"`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// existing rows are updated by key, which backfills the new column
+	mock.ExpectBegin()
+	mock.ExpectExec(exact(`CREATE UNIQUE INDEX IF NOT EXISTS "issues_key" ON "issues" ("number")`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(exact(`INSERT INTO "issues" ("number", "title", "state") SELECT "number", "title", "state" FROM "askgit_staging_issues" WHERE true ON CONFLICT ("number") DO UPDATE SET "title" = excluded."title", "state" = excluded."state"`)).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+	mock.ExpectExec(exact(`DROP TABLE IF EXISTS "askgit_staging_issues"`)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	var migrations []string
+	var runner = &Runner{DB: db, Logf: func(format string, args ...interface{}) { migrations = append(migrations, format) }}
+	n, err := runner.Sync(context.Background(), &Table{Name: "issues", Query: "SELECT * FROM github_repo_issues('askgitdev/askgit');", Key: []string{"number"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 || len(migrations) != 1 {
+		t.Fatalf("expected 3 rows written after a migration, got %d rows and %d migrations", n, len(migrations))
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateIncremental(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var exact = func(sql string) string { return "^" + regexp.QuoteMeta(sql) + "$" }
+	var columns = func(names ...string) *sqlmock.Rows {
+		var rows = sqlmock.NewRows([]string{"name", "type"})
+		for _, name := range names {
+			rows.AddRow(name, "")
+		}
+		return rows
+	}
+
+	// the plan is made without any row
+	var never = "0001-01-01T00:00:00Z"
+	mock.ExpectExec(`DROP TABLE IF EXISTS "askgit_staging_commits"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TEMP TABLE "askgit_staging_commits" .* LIMIT 0`).WithArgs(sql.Named("since", never), sql.Named("until", never)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`pragma_table_info`).WithArgs("askgit_staging_commits").WillReturnRows(columns("hash", "author_email"))
+	mock.ExpectQuery(`pragma_table_info`).WithArgs("commits").WillReturnRows(columns("hash"))
+	mock.ExpectExec(`DROP TABLE IF EXISTS "askgit_staging_commits"`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// the whole history is synced again, up to the last sync
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS askgit_sync_state`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT synced_until FROM askgit_sync_state`).WithArgs("commits").WillReturnRows(sqlmock.NewRows([]string{"synced_until"}).AddRow("2020-02-01T00:00:00Z"))
+	mock.ExpectExec(`DROP TABLE IF EXISTS "askgit_staging_commits"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TEMP TABLE "askgit_staging_commits"`).WithArgs(sql.Named("since", never), sql.Named("until", "2020-02-01T00:00:00Z")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`pragma_table_info`).WithArgs("askgit_staging_commits").WillReturnRows(columns("hash", "author_email"))
+	mock.ExpectQuery(`pragma_table_info`).WithArgs("commits").WillReturnRows(columns("hash"))
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS askgit_sync_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(exact(`ALTER TABLE "commits" ADD COLUMN "author_email"`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO askgit_sync_migrations`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE UNIQUE INDEX`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO "commits"`).WillReturnResult(sqlmock.NewResult(0, 20))
+	mock.ExpectCommit()
+	mock.ExpectExec(`DROP TABLE IF EXISTS "askgit_staging_commits"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	// and the point the next sync starts from isn't moved
+
+	var table = &Table{Name: "commits", Query: "SELECT * FROM commits WHERE author_when >= :since AND author_when < :until", Key: []string{"hash"}}
+	plan, err := (&Runner{DB: db}).Migrate(context.Background(), table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Added) != 1 || plan.Added[0].Name != "author_email" {
+		t.Fatalf("expected author_email to be added: %+v", plan)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	// identifiers are quoted as SQL identifiers, rather than as Go strings
+	if got := quote(`a"b\c`); got != `"a""b\c"` {
+		t.Fatalf("unexpected quoted identifier: %s", got)
+	}
+}
+
+func TestExportTableResume(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var exact = func(sql string) string { return "^" + regexp.QuoteMeta(sql) + "$" }
+
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS askgit_export_checkpoints`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT query, rows, completed FROM askgit_export_checkpoints`).WithArgs("commits").
+		WillReturnRows(sqlmock.NewRows([]string{"query", "rows", "completed"}).AddRow("SELECT * FROM commits", 2, 0))
+	mock.ExpectQuery(`pragma_table_info`).WithArgs("commits").WillReturnRows(sqlmock.NewRows([]string{"name", "type"}).AddRow("hash", "TEXT").AddRow("author_when", "DATETIME"))
+
+	// the rows already written are skipped, and the rest is written in chunks of 2
+	mock.ExpectQuery(exact(`SELECT +"hash", +"author_when" FROM (SELECT * FROM commits) LIMIT -1 OFFSET ?`)).WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"hash", "author_when"}).AddRow("c", "2021").AddRow("d", "2021").AddRow("e", "2021"))
+	var insert = mock.ExpectPrepare(exact(`INSERT INTO "commits" ("hash", "author_when") VALUES (?, ?)`))
+	mock.ExpectExec("BEGIN").WillReturnResult(sqlmock.NewResult(0, 0))
+	insert.ExpectExec().WithArgs("c", "2021").WillReturnResult(sqlmock.NewResult(3, 1))
+	insert.ExpectExec().WithArgs("d", "2021").WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectExec(`UPDATE askgit_export_checkpoints SET rows = rows \+ \?`).WithArgs(2, "commits").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("COMMIT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("BEGIN").WillReturnResult(sqlmock.NewResult(0, 0))
+	insert.ExpectExec().WithArgs("e", "2021").WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectExec(`UPDATE askgit_export_checkpoints SET rows = rows \+ \?`).WithArgs(1, "commits").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("COMMIT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE askgit_export_checkpoints SET completed = 1`).WithArgs("commits").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var finished bool
+	var opts = ExportOptions{ChunkSize: 2, Resume: true, Finish: func(context.Context, *sql.DB, string) error { finished = true; return nil }}
+	n, done, err := ExportTable(context.Background(), db, "commits", "SELECT * FROM commits;", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 || done || !finished {
+		t.Fatalf("expected 5 rows written in total, and the table to be finished, got %d rows", n)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportTableWithoutResume(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS askgit_export_checkpoints`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT query, rows, completed FROM askgit_export_checkpoints`).WithArgs("commits").
+		WillReturnRows(sqlmock.NewRows([]string{"query", "rows", "completed"}).AddRow("SELECT * FROM commits", 2, 0))
+
+	if _, _, err = ExportTable(context.Background(), db, "commits", "SELECT * FROM commits", ExportOptions{}); err == nil || !regexp.MustCompile(`--resume`).MatchString(err.Error()) {
+		t.Fatalf("expected a partially exported table to require --resume, got: %v", err)
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	for s, want := range map[string]time.Duration{"30d": 30 * 24 * time.Hour, "2w": 14 * 24 * time.Hour, "12h": 12 * time.Hour} {
+		if got, err := ParseWindow(s); err != nil || got != want {
+			t.Fatalf("unexpected window for %s: %v (%v)", s, got, err)
+		}
+	}
+	for _, s := range []string{"0d", "-1h", "month"} {
+		if _, err := ParseWindow(s); err == nil {
+			t.Fatalf("expected %q to be rejected", s)
+		}
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var table = &Table{Name: "commits", Query: "SELECT * FROM commits WHERE author_when >= :since AND author_when < :until", Key: []string{"hash"}}
+	if err = (&Config{Database: "askgit.db", Tables: []*Table{{Name: table.Name, Query: table.Query}}}).Validate(); err == nil {
+		t.Fatal("expected a windowed table without a key to be rejected")
+	}
+
+	// a previous backfill stopped on 2020-01-15, it resumes from there
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS askgit_sync_state`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT synced_until FROM askgit_sync_state`).WithArgs("commits").WillReturnRows(sqlmock.NewRows([]string{"synced_until"}).AddRow("2020-01-15T00:00:00Z"))
+
+	for _, w := range [][2]string{{"2020-01-15T00:00:00Z", "2020-01-25T00:00:00Z"}, {"2020-01-25T00:00:00Z", "2020-02-01T00:00:00Z"}} {
+		mock.ExpectExec(`DROP TABLE IF EXISTS "askgit_staging_commits"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`CREATE TEMP TABLE "askgit_staging_commits"`).WithArgs(sql.Named("since", w[0]), sql.Named("until", w[1])).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`pragma_table_info`).WithArgs("askgit_staging_commits").WillReturnRows(sqlmock.NewRows([]string{"name", "type"}).AddRow("hash", "TEXT"))
+		mock.ExpectQuery(`pragma_table_info`).WithArgs("commits").WillReturnRows(sqlmock.NewRows([]string{"name", "type"}).AddRow("hash", "TEXT"))
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE UNIQUE INDEX`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO "commits"`).WillReturnResult(sqlmock.NewResult(0, 5))
+		mock.ExpectCommit()
+		mock.ExpectExec(`DROP TABLE IF EXISTS "askgit_staging_commits"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO askgit_sync_state`).WithArgs("commits", w[1]).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	var since = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var until = time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+	n, err := (&Runner{DB: db}).Backfill(context.Background(), table, since, until, 10*24*time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 10 {
+		t.Fatalf("expected 10 rows backfilled, got: %d", n)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// feed is a ChangeFeed returning the same changes, recording the time they're asked since
+type feed struct {
+	changes *events.Changes
+	since   time.Time
+}
+
+func (f *feed) Changes(ctx context.Context, sources []string, since time.Time) (*events.Changes, error) {
+	f.since = since
+	return f.changes, nil
+}
+
+func TestSyncEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var table = &Table{
+		Name:   "org_issues",
+		Query:  "SELECT * FROM repos, github_repo_issues('askgitdev', repos.name) WHERE :changed_repos IS NULL OR 'askgitdev/' || repos.name IN (SELECT value FROM json_each(:changed_repos))",
+		Key:    []string{"repo", "issue_number"},
+		Events: []string{"askgitdev"},
+	}
+	if err = (&Config{Database: "askgit.db", Tables: []*Table{{Name: table.Name, Query: table.Query, Events: table.Events}}}).Validate(); err == nil {
+		t.Fatal("expected a table following events without a key to be rejected")
+	}
+
+	var lastSync = func() {
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS askgit_sync_state`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT synced_until FROM askgit_sync_state`).WithArgs("org_issues").WillReturnRows(sqlmock.NewRows([]string{"synced_until"}).AddRow("2021-03-01T00:00:00Z"))
+	}
+	var sync = func(changed interface{}) {
+		mock.ExpectExec(`DROP TABLE IF EXISTS "askgit_staging_org_issues"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`CREATE TEMP TABLE "askgit_staging_org_issues"`).WithArgs(sql.Named("changed_repos", changed)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`pragma_table_info`).WithArgs("askgit_staging_org_issues").WillReturnRows(sqlmock.NewRows([]string{"name", "type"}).AddRow("repo", "TEXT").AddRow("issue_number", "INT"))
+		mock.ExpectQuery(`pragma_table_info`).WithArgs("org_issues").WillReturnRows(sqlmock.NewRows([]string{"name", "type"}).AddRow("repo", "TEXT").AddRow("issue_number", "INT"))
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE UNIQUE INDEX`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO "org_issues"`).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+		mock.ExpectExec(`DROP TABLE IF EXISTS "askgit_staging_org_issues"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO askgit_sync_state`).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	// the repositories with events since the last sync are refreshed
+	var changes = &feed{changes: &events.Changes{Repos: []string{"askgitdev/askgit"}, Complete: true}}
+	var runner = &Runner{DB: db, Events: changes}
+	lastSync()
+	sync(`["askgitdev/askgit"]`)
+	if _, err = runner.Sync(context.Background(), table); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC).Add(-eventsLag); !changes.since.Equal(want) {
+		t.Fatalf("expected the events since %s to be read, got %s", want, changes.since)
+	}
+
+	// without events, there's nothing to sync
+	changes.changes = &events.Changes{Complete: true}
+	lastSync()
+	mock.ExpectExec(`INSERT INTO askgit_sync_state`).WillReturnResult(sqlmock.NewResult(0, 1))
+	if _, err = runner.Sync(context.Background(), table); err != nil {
+		t.Fatal(err)
+	}
+
+	// events that don't reach back to the last sync can't tell what changed, every row is synced
+	changes.changes = &events.Changes{Repos: []string{"askgitdev/askgit"}}
+	lastSync()
+	sync(nil)
+	if _, err = runner.Sync(context.Background(), table); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncVersions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var table = &Table{Name: "issues", Query: "SELECT number, state FROM github_repo_issues('askgitdev/askgit')", Key: []string{"number"}, Versioned: true}
+	if err = (&Config{Database: "askgit.db", Tables: []*Table{{Name: table.Name, Query: table.Query, Versioned: true}}}).Validate(); err == nil {
+		t.Fatal("expected a versioned table without a key to be rejected")
+	}
+	if err = (&Config{Database: "askgit.db", Tables: []*Table{table, {Name: "issues_history", Query: "SELECT 1"}}}).Validate(); err == nil {
+		t.Fatal("expected a table named after the history of a versioned table to be rejected")
+	}
+
+	var exact = func(sql string) string { return "^" + regexp.QuoteMeta(sql) + "$" }
+	var columns = sqlmock.NewRows([]string{"name", "type"}).AddRow("number", "INT").AddRow("state", "TEXT")
+
+	mock.ExpectExec(`DROP TABLE IF EXISTS "askgit_staging_issues"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TEMP TABLE "askgit_staging_issues"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`pragma_table_info`).WithArgs("askgit_staging_issues").WillReturnRows(columns)
+	mock.ExpectQuery(`pragma_table_info`).WithArgs("issues").WillReturnRows(sqlmock.NewRows([]string{"name", "type"}).AddRow("number", "INT").AddRow("state", "TEXT"))
+
+	// the history is created on the first sync, with the validity columns
+	mock.ExpectQuery(`pragma_table_info`).WithArgs("issues_history").WillReturnRows(sqlmock.NewRows([]string{"name", "type"}))
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS askgit_sync_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, statement := range []string{
+		`CREATE TABLE "issues_history" AS SELECT * FROM "askgit_staging_issues" WHERE 0`,
+		`ALTER TABLE "issues_history" ADD COLUMN "valid_from" TEXT`,
+		`ALTER TABLE "issues_history" ADD COLUMN "valid_to" TEXT`,
+	} {
+		mock.ExpectExec(exact(statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO askgit_sync_migrations`).WithArgs("issues_history", statement).WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectCommit()
+
+	// the versions of changed and removed rows are closed, new versions opened and the run recorded, before the rows are written
+	mock.ExpectBegin()
+	mock.ExpectExec(exact(`UPDATE "issues_history" SET valid_to = ? WHERE valid_to IS NULL AND EXISTS (SELECT 1 FROM "askgit_staging_issues" AS s WHERE s."number" = "issues_history"."number" AND NOT (s."number" IS "issues_history"."number" AND s."state" IS "issues_history"."state"))`)).
+		WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(exact(`UPDATE "issues_history" SET valid_to = ? WHERE valid_to IS NULL AND NOT EXISTS (SELECT 1 FROM "askgit_staging_issues" AS s WHERE s."number" = "issues_history"."number")`)).
+		WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(exact(`INSERT INTO "issues_history" ("number", "state", valid_from) SELECT "number", "state", ? FROM "askgit_staging_issues" AS s WHERE NOT EXISTS (SELECT 1 FROM "issues_history" WHERE s."number" = "issues_history"."number" AND valid_to IS NULL)`)).
+		WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS askgit_sync_runs`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO askgit_sync_runs`).WithArgs("issues", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`CREATE UNIQUE INDEX`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO "issues"`).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	mock.ExpectExec(`DROP TABLE IF EXISTS "askgit_staging_issues"`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if _, err = (&Runner{DB: db}).Sync(context.Background(), table); err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAsOf(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	mock.ExpectQuery(`pragma_table_info`).WithArgs("issues_history").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "type"}).AddRow("number", "INT").AddRow("state", "TEXT").AddRow("valid_from", "TEXT").AddRow("valid_to", "TEXT"))
+	mock.ExpectExec(regexp.QuoteMeta(`DROP VIEW IF EXISTS temp."issues"`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE TEMP VIEW "issues" AS SELECT "number", "state" FROM main."issues_history" WHERE valid_from <= '2021-03-01T00:00:00Z' AND (valid_to IS NULL OR valid_to > '2021-03-01T00:00:00Z')`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	var tables = []*Table{{Name: "issues", Key: []string{"number"}, Versioned: true}, {Name: "commits"}}
+	if err = AsOf(context.Background(), conn, tables, time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`pragma_table_info`).WithArgs("issues_history").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "type"}).AddRow("number", "INT").AddRow("state", "TEXT").AddRow("title", "TEXT").AddRow("valid_from", "TEXT").AddRow("valid_to", "TEXT"))
+
+	var snapshot = regexp.QuoteMeta(`SELECT "number", "state", "title" FROM "issues_history" WHERE valid_from <= ? AND (valid_to IS NULL OR valid_to > ?) ORDER BY "number"`)
+	var columns = []string{"number", "state", "title"}
+	mock.ExpectQuery(snapshot).WithArgs("2021-03-01T00:00:00Z", "2021-03-01T00:00:00Z").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "OPEN", "crash").AddRow(2, "OPEN", "typo").AddRow(3, "OPEN", "docs"))
+	mock.ExpectQuery(snapshot).WithArgs("2021-03-08T00:00:00Z", "2021-03-08T00:00:00Z").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "CLOSED", "crash").AddRow(3, "OPEN", "docs").AddRow(4, "OPEN", "slow"))
+
+	var table = &Table{Name: "issues", Key: []string{"number"}, Versioned: true}
+	var before, after = time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 3, 8, 0, 0, 0, 0, time.UTC)
+	diff, err := DiffSnapshots(context.Background(), db, table, before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diff.Added) != 1 || diff.Added[0][2] != "slow" {
+		t.Fatalf("expected issue 4 to be added, got: %v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0][2] != "typo" {
+		t.Fatalf("expected issue 2 to be removed, got: %v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || len(diff.Changed[0].Changes) != 1 || diff.Changed[0].Changes[0] != (ColumnChange{"state", "OPEN", "CLOSED"}) {
+		t.Fatalf("expected the state of issue 1 to change, got: %+v", diff.Changed)
+	}
+
+	var out bytes.Buffer
+	if err = diff.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"+ number=4 state=OPEN title=slow", "- number=2 state=OPEN title=typo", "~ number=1: state OPEN -> CLOSED", "issues: 1 added, 1 removed, 1 changed"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("expected the diff to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestSyncChecks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var exact = func(sql string) string { return "^" + regexp.QuoteMeta(sql) + "$" }
+	var count = func(n int) *sqlmock.Rows { return sqlmock.NewRows([]string{"count(*)"}).AddRow(n) }
+
+	mock.ExpectExec(`DROP TABLE IF EXISTS "askgit_staging_issues"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TEMP TABLE "askgit_staging_issues"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`pragma_table_info`).WithArgs("askgit_staging_issues").WillReturnRows(sqlmock.NewRows([]string{"name", "type"}).AddRow("node_id", "TEXT").AddRow("updated_at", "TEXT"))
+	mock.ExpectQuery(`pragma_table_info`).WithArgs("issues").WillReturnRows(sqlmock.NewRows([]string{"name", "type"}).AddRow("node_id", "TEXT").AddRow("updated_at", "TEXT"))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "issues"`).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO "issues"`).WillReturnResult(sqlmock.NewResult(0, 3))
+
+	// the checks run on the rows written, which are rolled back as some fail
+	mock.ExpectQuery(exact(`SELECT count(*) FROM "issues" WHERE "node_id" IS NULL`)).WillReturnRows(count(2))
+	mock.ExpectQuery(exact(`SELECT count(*) FROM (SELECT "node_id" FROM "issues" WHERE "node_id" IS NOT NULL GROUP BY "node_id" HAVING count(*) > 1)`)).WillReturnRows(count(0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS askgit_sync_checks`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(exact(`SELECT count(*) FROM askgit_sync_checks WHERE table_name = ? AND column_name = ? AND (SELECT max("updated_at") FROM "issues") < max_value`)).
+		WithArgs("issues", "updated_at").WillReturnRows(count(1))
+	mock.ExpectRollback()
+	mock.ExpectExec(`DROP TABLE IF EXISTS "askgit_staging_issues"`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	var table = &Table{Name: "issues", Query: "SELECT node_id, updated_at FROM github_repo_issues('askgitdev/askgit')", Checks: &Checks{
+		NotNull:   []string{"node_id"},
+		Unique:    []string{"node_id"},
+		Monotonic: []string{"updated_at"},
+	}}
+	_, err = (&Runner{DB: db}).Sync(context.Background(), table)
+	if checkErr, ok := err.(*CheckError); !ok || len(checkErr.Failures) != 2 {
+		t.Fatalf("expected 2 failed checks, got: %v", err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var table = &Table{Name: "builds", Query: "SELECT * FROM builds", Key: []string{"number"}, Versioned: true, Retention: &Retention{Column: "started_at", Keep: "180d"}}
+	if err = (&Config{Database: "askgit.db", Tables: []*Table{{Name: "builds", Query: "SELECT 1", Retention: &Retention{Column: "started_at", Keep: "6 months"}}}}).Validate(); err == nil {
+		t.Fatal("expected an invalid retention to be rejected")
+	}
+
+	var exact = func(sql string) string { return "^" + regexp.QuoteMeta(sql) + "$" }
+	var cutoff = time.Now().Add(-180 * 24 * time.Hour).UTC().Format("2006-01-02")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(exact(`DELETE FROM "builds" WHERE datetime("started_at") < datetime(?)`)).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(exact(`DELETE FROM "builds_history" WHERE valid_to < ?`)).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(exact(`DELETE FROM askgit_sync_runs WHERE table_name = ? AND synced_at < ?`)).WithArgs("builds", cutoffArg(cutoff)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	n, err := (&Runner{DB: db}).Prune(context.Background(), table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 6 {
+		t.Fatalf("expected 6 rows pruned, got: %d", n)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncAll(t *testing.T) {
+	var unknown = &Config{Database: "askgit.db", Tables: []*Table{{Name: "issues", Query: "SELECT 1", DependsOn: []string{"repos"}}}}
+	if err := unknown.Validate(); err == nil {
+		t.Fatal("expected a dependency on a missing table to be rejected")
+	}
+	var cycle = &Config{Database: "askgit.db", Tables: []*Table{
+		{Name: "repos", Query: "SELECT 1", DependsOn: []string{"issues"}},
+		{Name: "issues", Query: "SELECT 1", DependsOn: []string{"repos"}},
+	}}
+	if err := cycle.Validate(); err == nil || !strings.Contains(err.Error(), "repos -> issues -> repos") {
+		t.Fatalf("expected a dependency cycle to be rejected, got: %v", err)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var tables = []*Table{
+		{Name: "org_issues", Query: "SELECT * FROM repos, github_repo_issues('askgitdev', repos.name)", Key: []string{"repo", "issue_number"}, DependsOn: []string{"repos"}},
+		{Name: "repos", Query: "SELECT name FROM github_org_repos('askgitdev')"},
+		{Name: "stars", Query: "SELECT login FROM github_stargazers('askgitdev/askgit')"},
+	}
+
+	// the query of repos fails, and so org_issues, which reads it, isn't synced, while stars is
+	mock.ExpectExec(`DROP TABLE IF EXISTS "askgit_staging_repos"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TEMP TABLE "askgit_staging_repos"`).WillReturnError(errors.New("rate limited"))
+	mock.ExpectExec(`DROP TABLE IF EXISTS "askgit_staging_repos"`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(`DROP TABLE IF EXISTS "askgit_staging_stars"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TEMP TABLE "askgit_staging_stars"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`pragma_table_info`).WithArgs("askgit_staging_stars").WillReturnRows(sqlmock.NewRows([]string{"name", "type"}).AddRow("login", "TEXT"))
+	mock.ExpectQuery(`pragma_table_info`).WithArgs("stars").WillReturnRows(sqlmock.NewRows([]string{"name", "type"}).AddRow("login", "TEXT"))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "stars"`).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO "stars"`).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	mock.ExpectExec(`DROP TABLE IF EXISTS "askgit_staging_stars"`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	var outcomes = make(map[string]error)
+	err = (&Runner{DB: db}).SyncAll(context.Background(), tables, 1, func(t *Table, n int64, err error) {
+		outcomes[t.Name] = err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(outcomes) != 3 || outcomes["repos"] == nil || outcomes["stars"] != nil {
+		t.Fatalf("unexpected outcomes: %v", outcomes)
+	}
+	if depErr, ok := outcomes["org_issues"].(*DependencyError); !ok || depErr.Dependency != "repos" {
+		t.Fatalf("expected org_issues to be skipped, got: %v", outcomes["org_issues"])
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStop(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var stop = make(chan struct{})
+	close(stop)
+	var runner = &Runner{DB: db, Stop: stop}
+
+	// once stopped, no table is synced, and those depending on them are skipped as stopped too
+	var tables = []*Table{
+		{Name: "repos", Query: "SELECT name FROM github_org_repos('askgitdev')"},
+		{Name: "org_issues", Query: "SELECT * FROM repos, github_repo_issues('askgitdev', repos.name)", Key: []string{"repo", "issue_number"}, DependsOn: []string{"repos"}},
+	}
+	var outcomes = make(map[string]error)
+	if err = runner.SyncAll(context.Background(), tables, 1, func(t *Table, n int64, err error) { outcomes[t.Name] = err }); err != nil {
+		t.Fatal(err)
+	}
+	if outcomes["repos"] != ErrStopped || outcomes["org_issues"] != ErrStopped {
+		t.Fatalf("expected both tables to be stopped, got: %v", outcomes)
+	}
+
+	// backfills stop before their next window, leaving their progress as it is
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS askgit_sync_state`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT synced_until FROM askgit_sync_state`).WithArgs("commits").WillReturnRows(sqlmock.NewRows([]string{"synced_until"}))
+
+	var table = &Table{Name: "commits", Query: "SELECT * FROM commits WHERE author_when >= :since AND author_when < :until", Key: []string{"hash"}}
+	var since = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err = runner.Backfill(context.Background(), table, since, since.AddDate(0, 1, 0), 10*24*time.Hour, false); err != ErrStopped {
+		t.Fatalf("expected the backfill to be stopped, got: %v", err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// quota is a Quota with a fixed number of calls remaining
+type quota int64
+
+func (q quota) Remaining(ctx context.Context) (int64, error) { return int64(q), nil }
+
+func TestScheduler(t *testing.T) {
+	var unlisted = &Config{Database: "askgit.db", Orgs: []*Org{{Name: "askgitdev"}}, Tables: []*Table{{Name: "stars", Query: "SELECT 1", Org: "example"}}}
+	if err := unlisted.Validate(); err == nil {
+		t.Fatal("expected a table of an organization that isn't listed to be rejected")
+	}
+	var urgent = &Config{Database: "askgit.db", Tables: []*Table{{Name: "stars", Query: "SELECT 1", Priority: "urgent"}}}
+	if err := urgent.Validate(); err == nil {
+		t.Fatal("expected an invalid priority to be rejected")
+	}
+
+	// tables of a higher priority go first, then askgitdev, which has twice the share of example,
+	// gets two tables started for every one of example
+	var ready = []*Table{
+		{Name: "a1", Org: "askgitdev"},
+		{Name: "a2", Org: "askgitdev"},
+		{Name: "a3", Org: "askgitdev"},
+		{Name: "a4", Org: "askgitdev"},
+		{Name: "e1", Org: "example"},
+		{Name: "e2", Org: "example"},
+		{Name: "backlog", Org: "askgitdev", Priority: "low"},
+		{Name: "releases", Org: "example", Priority: "high"},
+	}
+	var s = newSchedule(&Scheduler{Weights: map[string]int{"askgitdev": 2}})
+	var order []string
+	for len(ready) > 0 {
+		i, deferred, err := s.next(context.Background(), ready)
+		if err != nil || deferred {
+			t.Fatalf("expected %s to be started, got: %v (%v)", ready[i].Name, deferred, err)
+		}
+		s.charge(ready[i])()
+		order = append(order, ready[i].Name)
+		ready = append(ready[:i:i], ready[i+1:]...)
+	}
+	if got, want := strings.Join(order, " "), "releases a1 a2 a3 e1 a4 e2 backlog"; got != want {
+		t.Fatalf("expected the tables to be started in the order %s, got %s", want, got)
+	}
+
+	// while the quota is scarce, tables with a low priority are deferred, along with those depending on them
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var tables = []*Table{
+		{Name: "stars", Query: "SELECT login FROM github_stargazers('askgitdev/askgit')", Priority: "low"},
+		{Name: "star_counts", Query: "SELECT count(*) FROM stars", DependsOn: []string{"stars"}},
+	}
+	var outcomes = make(map[string]error)
+	var runner = &Runner{DB: db, Scheduler: &Scheduler{Quota: quota(100), Reserve: 500}}
+	if err = runner.SyncAll(context.Background(), tables, 1, func(t *Table, n int64, err error) { outcomes[t.Name] = err }); err != nil {
+		t.Fatal(err)
+	}
+	if outcomes["stars"] != ErrDeferred || outcomes["star_counts"] != ErrDeferred {
+		t.Fatalf("expected both tables to be deferred, got: %v", outcomes)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	// with enough quota, they're not
+	runner.Scheduler.Quota = quota(1000)
+	if _, deferred, err := newSchedule(runner.Scheduler).next(context.Background(), tables[:1]); err != nil || deferred {
+		t.Fatal("expected a table not to be deferred while the quota isn't scarce")
+	}
+}
+
+// cutoffArg matches an RFC 3339 timestamp on the given date
+type cutoffArg string
+
+func (c cutoffArg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	return ok && strings.HasPrefix(s, string(c)+"T")
+}
@@ -0,0 +1,201 @@
+package materialize
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DependencyError is reported, in place of syncing it, for a table one of whose dependencies failed to sync
+type DependencyError struct {
+	Table      string
+	Dependency string
+}
+
+func (e *DependencyError) Error() string {
+	return fmt.Sprintf("table %s wasn't synced, as table %s it depends on failed to", e.Table, e.Dependency)
+}
+
+// dependencyCycle returns an error if the dependencies of tables form a cycle
+func dependencyCycle(tables []*Table) error {
+	var byName = make(map[string]*Table, len(tables))
+	for _, t := range tables {
+		byName[t.Name] = t
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	var state = make(map[string]int, len(tables))
+
+	var visit func(t *Table, path []string) error
+	visit = func(t *Table, path []string) error {
+		switch state[t.Name] {
+		case visiting:
+			return fmt.Errorf("tables depend on each other: %s", strings.Join(append(path, t.Name), " -> "))
+		case visited:
+			return nil
+		}
+
+		state[t.Name] = visiting
+		for _, d := range t.DependsOn {
+			if dep, ok := byName[d]; ok {
+				if err := visit(dep, append(path, t.Name)); err != nil {
+					return err
+				}
+			}
+		}
+		state[t.Name] = visited
+		return nil
+	}
+
+	for _, t := range tables {
+		if err := visit(t, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SyncAll syncs the tables, up to workers of them at a time, in the order of their dependencies: a table
+// is synced once the tables it depends on are, and is skipped, with a DependencyError, if one of them failed.
+// Dependencies that aren't among the tables are taken as synced. fn is called with the outcome of every table,
+// from a single goroutine, as they complete. Once Stop is closed, the tables yet to start fail with ErrStopped.
+// Tables ready to sync are started in the order of their priority, and as picked by the Scheduler if set.
+//
+// With more than a worker, the database is switched to write-ahead logging, so that the queries of tables
+// reading the others don't block their writes.
+func (r *Runner) SyncAll(ctx context.Context, tables []*Table, workers int, fn func(t *Table, n int64, err error)) error {
+	if err := dependencyCycle(tables); err != nil {
+		return err
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > 1 {
+		if _, err := r.DB.ExecContext(ctx, "PRAGMA journal_mode=WAL"); err != nil {
+			return fmt.Errorf("failed to enable write-ahead logging: %v", err)
+		}
+	}
+
+	var included = make(map[string]bool, len(tables))
+	for _, t := range tables {
+		included[t.Name] = true
+	}
+
+	// pending counts the dependencies of every table yet to be synced
+	var pending = make(map[string]int, len(tables))
+	var dependents = make(map[string][]*Table, len(tables))
+	for _, t := range tables {
+		for _, d := range t.DependsOn {
+			if included[d] {
+				pending[t.Name]++
+				dependents[d] = append(dependents[d], t)
+			}
+		}
+	}
+
+	type outcome struct {
+		t   *Table
+		n   int64
+		err error
+	}
+
+	// start is only sent to once a worker is idle, so that the next table is picked as late as possible
+	var start = make(chan *Table)
+	var done = make(chan outcome)
+	defer close(start)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for t := range start {
+				if r.stopped() {
+					done <- outcome{t, 0, ErrStopped}
+					continue
+				}
+				n, err := r.Sync(ctx, t)
+				done <- outcome{t, n, err}
+			}
+		}()
+	}
+
+	var ready []*Table
+	for _, t := range tables {
+		if pending[t.Name] == 0 {
+			ready = append(ready, t)
+		}
+	}
+
+	var remaining = len(tables)
+	var skipped = make(map[string]bool)
+
+	// settle releases the dependents of the table called name once it's synced, or skips them if it failed
+	// (or wasn't synced, as syncing was stopped or it was deferred)
+	var settle func(name string, err error)
+	settle = func(name string, err error) {
+		for _, d := range dependents[name] {
+			if skipped[d.Name] {
+				continue
+			}
+			if err != nil {
+				skipped[d.Name] = true
+				remaining--
+				var skip = err
+				if err != ErrStopped && err != ErrDeferred {
+					skip = &DependencyError{Table: d.Name, Dependency: name}
+				}
+				if fn != nil {
+					fn(d, 0, skip)
+				}
+				settle(d.Name, skip)
+				continue
+			}
+			if pending[d.Name]--; pending[d.Name] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+
+	// dispatch starts the tables picked by the scheduler, as long as workers are idle
+	var schedule = newSchedule(r.Scheduler)
+	var charged = make(map[string]func(), workers)
+	var idle = workers
+	var dispatch = func() {
+		for idle > 0 && len(ready) > 0 {
+			i, deferred, err := schedule.next(ctx, ready)
+			if err != nil {
+				r.logf("failed to check the API quota: %v", err)
+			}
+			var t = ready[i]
+			ready = append(ready[:i:i], ready[i+1:]...)
+
+			if deferred {
+				remaining--
+				if fn != nil {
+					fn(t, 0, ErrDeferred)
+				}
+				settle(t.Name, ErrDeferred)
+				continue
+			}
+
+			idle--
+			charged[t.Name] = schedule.charge(t)
+			start <- t
+		}
+	}
+
+	dispatch()
+	for remaining > 0 {
+		o := <-done
+		idle++
+		remaining--
+		charged[o.t.Name]()
+		if fn != nil {
+			fn(o.t, o.n, o.err)
+		}
+		settle(o.t.Name, o.err)
+		dispatch()
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package materialize
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Retention bounds how long the rows of a table are kept, so that long-running sync databases don't grow unbounded
+type Retention struct {
+	// Column is the timestamp the age of a row is measured from, rows where it's NULL are kept
+	Column string `json:"column"`
+
+	// Keep is how long rows are kept, e.g. 180d, 26w or 72h (see ParseWindow)
+	Keep string `json:"keep"`
+}
+
+// Prune deletes the rows of t older than its retention keeps, along with the versions of its history (and the runs)
+// that ended before then. It returns the number of rows deleted, which is 0 for tables without a retention.
+func (r *Runner) Prune(ctx context.Context, t *Table) (int64, error) {
+	if t.Retention == nil {
+		return 0, nil
+	}
+
+	keep, err := ParseWindow(t.Retention.Keep)
+	if err != nil {
+		return 0, err
+	}
+	var cutoff = time.Now().Add(-keep).UTC().Format(time.RFC3339)
+
+	type statement struct {
+		query string
+		args  []interface{}
+	}
+
+	// datetime() normalizes timestamps to UTC, whatever their offset, so that they compare with the cutoff
+	var statements = []statement{
+		{fmt.Sprintf("DELETE FROM %s WHERE datetime(%s) < datetime(?)", quote(t.Name), quote(t.Retention.Column)), []interface{}{cutoff}},
+	}
+	if t.Versioned {
+		statements = append(statements,
+			statement{fmt.Sprintf("DELETE FROM %s WHERE %s < ?", quote(historyTable(t)), validTo), []interface{}{cutoff}},
+			statement{fmt.Sprintf("DELETE FROM %s WHERE table_name = ? AND synced_at < ?", runsTable), []interface{}{t.Name, cutoff}},
+		)
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var pruned int64
+	for _, stmt := range statements {
+		res, err := tx.ExecContext(ctx, stmt.query, stmt.args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to prune table %s: %v", t.Name, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		pruned += n
+	}
+	return pruned, tx.Commit()
+}
+
+// Vacuum rebuilds the database, returning the space of the rows pruned to the file system
+func (r *Runner) Vacuum(ctx context.Context) error {
+	_, err := r.DB.ExecContext(ctx, "VACUUM")
+	return err
+}
@@ -0,0 +1,353 @@
+package materialize
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/events"
+	"github.com/askgitdev/askgit/pkg/redact"
+	"github.com/askgitdev/askgit/pkg/transform"
+)
+
+// Runner syncs tables into a database
+type Runner struct {
+	// DB is the database tables are synced to. It must be opened with the askgit extension,
+	// as queries are run in it, so that their results are written without leaving SQLite.
+	DB *sql.DB
+
+	// Redactor, if set, redacts the rows of every table before they're written
+	Redactor *redact.Redactor
+
+	// Logf, if set, is called with a description of the migrations applied to tables
+	Logf func(format string, args ...interface{})
+
+	// Events tells what changed since the last sync of the tables following events, which fail to sync if it's nil
+	Events ChangeFeed
+
+	// Stop, if set, is closed to stop syncing gracefully: the tables being synced are finished (unless their context
+	// is cancelled), but no other is started, and backfills stop after the window being synced, which they resume from
+	Stop <-chan struct{}
+
+	// Scheduler, if set, shares the API quota between the organizations of the tables SyncAll syncs,
+	// and defers those with a low priority when it's scarce
+	Scheduler *Scheduler
+
+	// bound, if set, is the connection of DB the statements of a sync run on (see withConn)
+	bound *sql.Conn
+}
+
+// conn is implemented by *sql.DB and *sql.Conn
+type conn interface {
+	querier
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// db returns the connection statements run on, DB unless r is bound to one of its connections
+func (r *Runner) db() conn {
+	if r.bound != nil {
+		return r.bound
+	}
+	return r.DB
+}
+
+// withConn calls fn with a copy of r bound to a single connection of DB. Staging tables are temporary,
+// and so only exist on the connection they're created on, which also keeps syncs running concurrently
+// from holding the lock of the database while their queries run.
+func (r *Runner) withConn(ctx context.Context, fn func(r *Runner) error) error {
+	if r.bound != nil {
+		return fn(r)
+	}
+
+	c, err := r.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	var bound = *r
+	bound.bound = c
+	return fn(&bound)
+}
+
+// ErrStopped is reported for the tables that weren't synced, and the backfills that didn't complete, as Stop was closed
+var ErrStopped = errors.New("syncing was stopped")
+
+// stopped reports whether Stop was closed
+func (r *Runner) stopped() bool {
+	select {
+	case <-r.Stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *Runner) logf(format string, args ...interface{}) {
+	if r.Logf != nil {
+		r.Logf(format, args...)
+	}
+}
+
+// stagingTable returns the name of the table the rows of t are staged in before being written
+func stagingTable(t *Table) string { return "askgit_staging_" + t.Name }
+
+// stage runs the query of t into its staging table, for the window w if it's windowed, and applies
+// the transforms and redaction to it. The query of a table following events is bound to the changes, or to NULL
+// if everything is synced. r must be bound to a connection (see withConn). With empty set, the staging table only has the columns of the query,
+// and no row. It returns the columns of the staging table, which must be dropped once no longer used.
+func (r *Runner) stage(ctx context.Context, t *Table, w Window, changes *events.Changes, empty bool) ([]Column, error) {
+	var staging = stagingTable(t)
+	if _, err := r.db().ExecContext(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s", quote(staging))); err != nil {
+		return nil, err
+	}
+
+	var query = fmt.Sprintf("CREATE TEMP TABLE %s AS SELECT * FROM (%s)", quote(staging), strings.TrimRight(strings.TrimSpace(t.Query), ";"))
+	if empty {
+		query += " LIMIT 0"
+	}
+	if _, err := r.db().ExecContext(ctx, query, t.params(w, changes)...); err != nil {
+		return nil, fmt.Errorf("failed to run the query of table %s: %v", t.Name, err)
+	}
+
+	var columns = make([]string, 0, len(t.Transforms))
+	for column := range t.Transforms {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	var transforms []*transform.Transform
+	for _, column := range columns {
+		transforms = append(transforms, &transform.Transform{Table: staging, Column: column, Expr: t.Transforms[column]})
+	}
+	if err := transform.Apply(ctx, r.db(), staging, transforms); err != nil {
+		return nil, err
+	}
+
+	if !empty {
+		if err := r.Redactor.Table(ctx, r.db(), staging); err != nil {
+			return nil, fmt.Errorf("failed to redact table %s: %v", t.Name, err)
+		}
+	}
+
+	return tableColumns(ctx, r.db(), staging)
+}
+
+// dropStaging drops the staging table of t
+func (r *Runner) dropStaging(ctx context.Context, t *Table) {
+	_, _ = r.db().ExecContext(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s", quote(stagingTable(t))))
+}
+
+// Plan returns how the table t differs from the columns its query returns, without changing it.
+// The query is run with LIMIT 0, so it's cheap if its tables honour the limit.
+func (r *Runner) Plan(ctx context.Context, t *Table) (*Plan, error) {
+	var plan *Plan
+	err := r.withConn(ctx, func(r *Runner) error {
+		defer r.dropStaging(ctx, t)
+
+		columns, err := r.stage(ctx, t, Window{}, nil, true)
+		if err != nil {
+			return err
+		}
+
+		existing, err := tableColumns(ctx, r.db(), t.Name)
+		if err != nil {
+			return err
+		}
+		plan = Diff(t.Name, existing, columns)
+		return nil
+	})
+	return plan, err
+}
+
+// Migrate migrates the table t to the columns its query returns. Tables are created or given new
+// columns by syncing them, which backfills the new columns of existing rows. Tables synced incrementally
+// are synced again from the start of their history, up to where the last sync stopped (see resync).
+func (r *Runner) Migrate(ctx context.Context, t *Table) (*Plan, error) {
+	plan, err := r.Plan(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case plan.Create || (len(plan.Added) > 0 && !t.Incremental()):
+		_, err = r.Sync(ctx, t)
+	case len(plan.Added) > 0:
+		err = r.resync(ctx, t)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// resync syncs the incremental table t again, in full: its whole history (in a single window, if it's windowed)
+// up to where the last sync stopped, whatever changed. The point the next sync starts from isn't moved,
+// as only the rows up to it are synced again.
+func (r *Runner) resync(ctx context.Context, t *Table) error {
+	return r.withConn(ctx, func(r *Runner) error {
+		until, err := r.syncedUntil(ctx, t)
+		if err != nil {
+			return err
+		}
+		if until.IsZero() {
+			// the table was never synced, so it's synced as it would be on its first sync
+			_, err = r.Sync(ctx, t)
+			return err
+		}
+
+		n, err := r.sync(ctx, t, Window{Until: until}, nil)
+		if err != nil {
+			return err
+		}
+		r.logf("%s: %d rows synced again up to %s, to backfill its new columns", t.Name, n, until.Format(time.RFC3339))
+		return nil
+	})
+}
+
+// Sync writes the rows returned by the query of t to the table, migrating it first if its columns changed.
+// Windowed tables are synced from where the last sync or backfill stopped, up to now, and tables following
+// events only refresh what changed since the last sync (see Table.Events). It returns the number of rows written.
+func (r *Runner) Sync(ctx context.Context, t *Table) (int64, error) {
+	var n int64
+	err := r.withConn(ctx, func(r *Runner) error {
+		var err error
+		if !t.Incremental() {
+			n, err = r.sync(ctx, t, Window{}, nil)
+			return err
+		}
+
+		since, err := r.syncedUntil(ctx, t)
+		if err != nil {
+			return err
+		}
+		var w = Window{Since: since, Until: time.Now().UTC()}
+
+		var changes *events.Changes
+		if len(t.Events) > 0 && !since.IsZero() {
+			if changes, err = r.changes(ctx, t, since); err != nil {
+				return err
+			}
+			if changes != nil && len(changes.Repos) == 0 && !t.Windowed() {
+				r.logf("%s: no events since the last sync", t.Name)
+				return r.setSyncedUntil(ctx, t, w.Until)
+			}
+		}
+
+		if n, err = r.sync(ctx, t, w, changes); err != nil {
+			return err
+		}
+		return r.setSyncedUntil(ctx, t, w.Until)
+	})
+	return n, err
+}
+
+// sync writes the rows returned by the query of t, for the window w and the changes (nil unless the table follows
+// events, and only some of its rows are synced), to the table. r must be bound to a connection.
+func (r *Runner) sync(ctx context.Context, t *Table, w Window, changes *events.Changes) (int64, error) {
+	defer r.dropStaging(ctx, t)
+
+	columns, err := r.stage(ctx, t, w, changes, false)
+	if err != nil {
+		return 0, err
+	}
+
+	existing, err := tableColumns(ctx, r.db(), t.Name)
+	if err != nil {
+		return 0, err
+	}
+
+	var plan = Diff(t.Name, existing, columns)
+	if plan.Drifted() && !plan.Create {
+		r.logf("migrating %s", plan)
+	}
+	if err = plan.apply(ctx, r.db(), stagingTable(t)); err != nil {
+		return 0, err
+	}
+	if t.Versioned {
+		if err = r.migrateHistory(ctx, t, columns); err != nil {
+			return 0, err
+		}
+	}
+
+	return r.write(ctx, t, columns, t.Windowed() || changes != nil)
+}
+
+// write writes the rows of the staging table of t to the table, which must have the given columns.
+// With partial set, the staging table only has some of the rows of the table (those of a window, or that changed).
+func (r *Runner) write(ctx context.Context, t *Table, columns []Column, partial bool) (int64, error) {
+	var names = make([]string, len(columns))
+	var byName = make(map[string]bool, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+		byName[strings.ToLower(c.Name)] = true
+	}
+
+	var key = make(map[string]bool, len(t.Key))
+	for _, k := range t.Key {
+		if !byName[strings.ToLower(k)] {
+			return 0, fmt.Errorf("key column %s of table %s isn't returned by its query", k, t.Name)
+		}
+		key[strings.ToLower(k)] = true
+	}
+
+	tx, err := r.db().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// the staged rows are recorded as new versions of those that changed
+	if t.Versioned {
+		if err = r.version(ctx, tx, t, names, partial, time.Now()); err != nil {
+			return 0, err
+		}
+	}
+
+	var insert = fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", quote(t.Name), quoteAll(names), quoteAll(names), quote(stagingTable(t)))
+	if len(t.Key) == 0 {
+		if _, err = tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", quote(t.Name))); err != nil {
+			return 0, err
+		}
+	} else {
+		// the key may have been added to the configuration after the table was created
+		if _, err = tx.ExecContext(ctx, fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (%s)", quote(t.Name+"_key"), quote(t.Name), quoteAll(t.Key))); err != nil {
+			return 0, fmt.Errorf("failed to index table %s by its key: %v", t.Name, err)
+		}
+
+		var set []string
+		for _, name := range names {
+			if !key[strings.ToLower(name)] {
+				set = append(set, fmt.Sprintf("%s = excluded.%s", quote(name), quote(name)))
+			}
+		}
+
+		// WHERE true disambiguates the upsert clause from a join constraint, as documented by SQLite
+		insert += fmt.Sprintf(" WHERE true ON CONFLICT (%s) DO ", quoteAll(t.Key))
+		if len(set) == 0 {
+			insert += "NOTHING"
+		} else {
+			insert += "UPDATE SET " + strings.Join(set, ", ")
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, insert)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write table %s: %v", t.Name, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if err = r.check(ctx, tx, t); err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
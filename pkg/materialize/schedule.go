@@ -0,0 +1,103 @@
+package materialize
+
+import (
+	"context"
+	"errors"
+)
+
+// priorities ranks the priorities of tables, the tables of the lowest rank being synced first
+var priorities = map[string]int{"high": 0, "": 1, "normal": 1, "low": 2}
+
+// Quota reports the API quota remaining, such as the rate limit of the GitHub token
+type Quota interface {
+	Remaining(ctx context.Context) (int64, error)
+}
+
+// ErrDeferred is reported for the tables with a low priority that weren't synced, and for those depending on them,
+// as the API quota was scarce
+var ErrDeferred = errors.New("deferred to the next run, as the API quota is scarce")
+
+// Scheduler picks the table SyncAll syncs next among those ready to: the table with the highest priority,
+// then that of the organization which was charged the fewest API calls relative to its weight, so that
+// the organizations synced with a single token share its quota in proportion to their weights,
+// rather than the first ones configured using it all up. Tables with a low priority are deferred
+// once the remaining quota is under the reserve.
+type Scheduler struct {
+	// Weights are the shares of the API quota of organizations, by name. Organizations that aren't listed,
+	// or whose weight isn't set, have a weight of 1, as do the tables that aren't synced for any organization.
+	Weights map[string]int
+
+	// Calls, if set, returns the number of API calls made so far, the organization of a table being charged
+	// for those made while it's synced (approximately, when other tables are synced concurrently).
+	// Otherwise, organizations are charged a call per table.
+	Calls func() int64
+
+	// Quota, if set, is checked before starting a table with a low priority, which is deferred
+	// if less than Reserve is remaining
+	Quota   Quota
+	Reserve int64
+}
+
+// schedule is the state of a Scheduler during a run of SyncAll
+type schedule struct {
+	*Scheduler
+	charged map[string]float64 // API calls charged to organizations, divided by their weight
+}
+
+// newSchedule starts a run of s, or of a Scheduler with neither weights nor quota if nil
+func newSchedule(s *Scheduler) *schedule {
+	if s == nil {
+		s = &Scheduler{}
+	}
+	return &schedule{Scheduler: s, charged: make(map[string]float64)}
+}
+
+func (s *schedule) weight(org string) float64 {
+	if w := s.Weights[org]; w > 0 {
+		return float64(w)
+	}
+	return 1
+}
+
+// next returns the index of the table of ready to sync next, and whether it's deferred instead,
+// along with the error of checking the quota, which doesn't defer it
+func (s *schedule) next(ctx context.Context, ready []*Table) (int, bool, error) {
+	var next = 0
+	for i, t := range ready[1:] {
+		var best = ready[next]
+		if p, q := priorities[t.Priority], priorities[best.Priority]; p != q {
+			if p < q {
+				next = i + 1
+			}
+			continue
+		}
+		if s.charged[t.Org] < s.charged[best.Org] {
+			next = i + 1
+		}
+	}
+
+	if priorities[ready[next].Priority] < priorities["low"] || s.Quota == nil || s.Reserve <= 0 {
+		return next, false, nil
+	}
+	remaining, err := s.Quota.Remaining(ctx)
+	if err != nil {
+		return next, false, err
+	}
+	return next, remaining < s.Reserve, nil
+}
+
+// charge charges the organization of t for a call as it starts syncing, returning the function charging it
+// for the calls made while it was, once it's synced
+func (s *schedule) charge(t *Table) func() {
+	s.charged[t.Org] += 1 / s.weight(t.Org)
+	if s.Calls == nil {
+		return func() {}
+	}
+
+	var before = s.Calls()
+	return func() {
+		if calls := s.Calls() - before; calls > 1 {
+			s.charged[t.Org] += float64(calls-1) / s.weight(t.Org)
+		}
+	}
+}
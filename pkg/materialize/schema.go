@@ -0,0 +1,164 @@
+package materialize
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Column is a column of a table, with its declared type
+type Column struct {
+	Name, Type string
+}
+
+// Plan describes how a synced table differs from the results of its query
+type Plan struct {
+	Table string
+
+	// Create is set if the table doesn't exist yet
+	Create bool
+
+	// Added lists the columns returned by the query that the table lacks. They're added, and backfilled by the sync
+	// of tables synced in full, but only by Runner.Migrate for tables synced incrementally.
+	Added []Column
+
+	// Removed lists the columns of the table the query no longer returns, they're kept, with NULL in new rows
+	Removed []Column
+
+	// Retyped lists the columns whose declared type changed. SQLite doesn't enforce declared types,
+	// so they're left as they are, new values are stored with the new type.
+	Retyped []Column
+}
+
+// Drifted reports whether the table differs from its query
+func (p *Plan) Drifted() bool {
+	return p.Create || len(p.Added) > 0 || len(p.Removed) > 0 || len(p.Retyped) > 0
+}
+
+// String describes the plan, one change per line
+func (p *Plan) String() string {
+	var lines []string
+	if p.Create {
+		lines = append(lines, fmt.Sprintf("%s: create table", p.Table))
+	}
+	for _, c := range p.Added {
+		lines = append(lines, fmt.Sprintf("%s: add column %s %s", p.Table, c.Name, c.Type))
+	}
+	for _, c := range p.Removed {
+		lines = append(lines, fmt.Sprintf("%s: keep column %s, no longer returned by the query", p.Table, c.Name))
+	}
+	for _, c := range p.Retyped {
+		lines = append(lines, fmt.Sprintf("%s: column %s is now %s", p.Table, c.Name, c.Type))
+	}
+	if len(lines) == 0 {
+		return fmt.Sprintf("%s: up to date", p.Table)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Diff returns the plan migrating a table with the existing columns to the wanted ones.
+// Columns are matched by name, case insensitively, as in SQLite.
+func Diff(table string, existing, wanted []Column) *Plan {
+	var plan = &Plan{Table: table, Create: existing == nil}
+	if plan.Create {
+		return plan
+	}
+
+	var byName = make(map[string]Column, len(existing))
+	for _, c := range existing {
+		byName[strings.ToLower(c.Name)] = c
+	}
+
+	var returned = make(map[string]bool, len(wanted))
+	for _, c := range wanted {
+		returned[strings.ToLower(c.Name)] = true
+		if e, ok := byName[strings.ToLower(c.Name)]; !ok {
+			plan.Added = append(plan.Added, c)
+		} else if c.Type != "" && !strings.EqualFold(e.Type, c.Type) {
+			plan.Retyped = append(plan.Retyped, c)
+		}
+	}
+
+	for _, c := range existing {
+		if !returned[strings.ToLower(c.Name)] {
+			plan.Removed = append(plan.Removed, c)
+		}
+	}
+	return plan
+}
+
+// querier is implemented by *sql.DB, *sql.Conn and *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// tableColumns returns the columns of table, or nil if it doesn't exist
+func tableColumns(ctx context.Context, db querier, table string) ([]Column, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name, type FROM pragma_table_info(?) ORDER BY cid", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []Column
+	for rows.Next() {
+		var c Column
+		if err = rows.Scan(&c.Name, &c.Type); err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+const migrationsTable = "askgit_sync_migrations"
+
+// apply runs the statements migrating the table to the plan, based on the staging table, and records them in the migrations table
+func (p *Plan) apply(ctx context.Context, db conn, staging string) error {
+	var statements []string
+	if p.Create {
+		statements = append(statements, fmt.Sprintf("CREATE TABLE %s AS SELECT * FROM %s WHERE 0", quote(p.Table), quote(staging)))
+	}
+	for _, c := range p.Added {
+		statements = append(statements, strings.TrimSpace(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", quote(p.Table), quote(c.Name), c.Type)))
+	}
+
+	if len(statements) == 0 {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = tx.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (table_name TEXT NOT NULL, statement TEXT NOT NULL, applied_at TEXT NOT NULL DEFAULT (strftime('%%Y-%%m-%%dT%%H:%%M:%%fZ', 'now')))", migrationsTable)); err != nil {
+		return err
+	}
+
+	for _, statement := range statements {
+		if _, err = tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to migrate table %s: %v", p.Table, err)
+		}
+		if _, err = tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (table_name, statement) VALUES (?, ?)", migrationsTable), p.Table, statement); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// quote quotes name as an SQL identifier, doubling the double quotes it contains
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteAll returns the quoted names, separated by commas
+func quoteAll(names []string) string {
+	var quoted = make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quote(name)
+	}
+	return strings.Join(quoted, ", ")
+}
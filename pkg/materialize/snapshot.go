@@ -0,0 +1,190 @@
+package materialize
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/query"
+)
+
+const runsTable = "askgit_sync_runs"
+
+// recordRun records a sync of the versioned table t at the given time, so that it can be diffed with others
+func recordRun(ctx context.Context, tx *sql.Tx, t *Table, at string) error {
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id INTEGER PRIMARY KEY, table_name TEXT NOT NULL, synced_at TEXT NOT NULL)", runsTable)); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (table_name, synced_at) VALUES (?, ?)", runsTable), t.Name, at)
+	return err
+}
+
+// RunTime returns the time of the sync run id of the versioned table t, as listed in the askgit_sync_runs table
+func RunTime(ctx context.Context, db *sql.DB, t *Table, id int64) (time.Time, error) {
+	var at string
+	switch err := db.QueryRowContext(ctx, fmt.Sprintf("SELECT synced_at FROM %s WHERE id = ? AND table_name = ?", runsTable), id, t.Name).Scan(&at); {
+	case err == sql.ErrNoRows:
+		return time.Time{}, fmt.Errorf("no sync run %d of table %s", id, t.Name)
+	case err != nil:
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, at)
+}
+
+// ColumnChange is the change of a column of a row between two snapshots
+type ColumnChange struct {
+	Column string      `json:"column"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// ChangedRow is a row whose values changed between two snapshots, identified by its key
+type ChangedRow struct {
+	Key     []interface{}  `json:"key"`
+	Changes []ColumnChange `json:"changes"`
+}
+
+// SnapshotDiff is the difference between a versioned table as it was synced at two points in time
+type SnapshotDiff struct {
+	Table   string          `json:"table"`
+	Key     []string        `json:"key"`
+	Columns []string        `json:"columns"`
+	Added   [][]interface{} `json:"added"`
+	Removed [][]interface{} `json:"removed"`
+	Changed []*ChangedRow   `json:"changed"`
+}
+
+// DiffSnapshots compares the versioned table t as it was synced at before and after, returning the rows
+// added, removed and changed (column by column) in between. Rows are matched by the key of the table.
+func DiffSnapshots(ctx context.Context, db *sql.DB, t *Table, before, after time.Time) (*SnapshotDiff, error) {
+	if !t.Versioned {
+		return nil, fmt.Errorf("table %s isn't versioned, its snapshots can't be diffed", t.Name)
+	}
+
+	columns, err := tableColumns(ctx, db, historyTable(t))
+	if err != nil {
+		return nil, err
+	}
+	if columns == nil {
+		return nil, fmt.Errorf("table %s has no history, it's yet to be synced", t.Name)
+	}
+
+	var names []string
+	for _, c := range columns {
+		if !strings.EqualFold(c.Name, validFrom) && !strings.EqualFold(c.Name, validTo) {
+			names = append(names, c.Name)
+		}
+	}
+
+	var keyIndexes = make([]int, len(t.Key))
+	for i, k := range t.Key {
+		keyIndexes[i] = -1
+		for c, name := range names {
+			if strings.EqualFold(name, k) {
+				keyIndexes[i] = c
+			}
+		}
+		if keyIndexes[i] < 0 {
+			return nil, fmt.Errorf("key column %s isn't in the history of table %s", k, t.Name)
+		}
+	}
+
+	var snapshot = func(at time.Time) (*query.Result, error) {
+		var ts = at.UTC().Format(time.RFC3339)
+		return query.Run(ctx, db, fmt.Sprintf("SELECT %s FROM %s WHERE %s <= ? AND (%s IS NULL OR %s > ?) ORDER BY %s",
+			quoteAll(names), quote(historyTable(t)), validFrom, validTo, validTo, quoteAll(t.Key)), ts, ts)
+	}
+
+	old, err := snapshot(before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table %s as of %s: %v", t.Name, before.Format(time.RFC3339), err)
+	}
+	current, err := snapshot(after)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table %s as of %s: %v", t.Name, after.Format(time.RFC3339), err)
+	}
+
+	var keyOf = func(row []interface{}) (string, []interface{}) {
+		var values = make([]interface{}, len(keyIndexes))
+		var parts = make([]string, len(keyIndexes))
+		for i, c := range keyIndexes {
+			values[i] = row[c]
+			parts[i] = fmt.Sprintf("%#v", row[c])
+		}
+		return strings.Join(parts, "\x00"), values
+	}
+
+	var diff = &SnapshotDiff{Table: t.Name, Key: t.Key, Columns: names, Added: make([][]interface{}, 0), Removed: make([][]interface{}, 0), Changed: make([]*ChangedRow, 0)}
+
+	var previous = make(map[string][]interface{}, len(old.Rows))
+	for _, row := range old.Rows {
+		key, _ := keyOf(row)
+		previous[key] = row
+	}
+
+	var seen = make(map[string]bool, len(current.Rows))
+	for _, row := range current.Rows {
+		key, values := keyOf(row)
+		seen[key] = true
+
+		was, ok := previous[key]
+		if !ok {
+			diff.Added = append(diff.Added, row)
+			continue
+		}
+
+		var changed = &ChangedRow{Key: values}
+		for c, name := range names {
+			if was[c] != row[c] {
+				changed.Changes = append(changed.Changes, ColumnChange{Column: name, Before: was[c], After: row[c]})
+			}
+		}
+		if len(changed.Changes) > 0 {
+			diff.Changed = append(diff.Changed, changed)
+		}
+	}
+
+	for _, row := range old.Rows {
+		if key, _ := keyOf(row); !seen[key] {
+			diff.Removed = append(diff.Removed, row)
+		}
+	}
+	return diff, nil
+}
+
+// WriteText writes the diff for people: a line for every row added (+) or removed (-), and for every change of a column (~)
+func (d *SnapshotDiff) WriteText(w io.Writer) error {
+	var value = func(v interface{}) interface{} {
+		if v == nil {
+			return "NULL"
+		}
+		return v
+	}
+	var row = func(values []interface{}, names []string) string {
+		var fields = make([]string, len(values))
+		for i, v := range values {
+			fields[i] = fmt.Sprintf("%s=%v", names[i], value(v))
+		}
+		return strings.Join(fields, " ")
+	}
+
+	var b strings.Builder
+	for _, added := range d.Added {
+		fmt.Fprintf(&b, "+ %s\n", row(added, d.Columns))
+	}
+	for _, removed := range d.Removed {
+		fmt.Fprintf(&b, "- %s\n", row(removed, d.Columns))
+	}
+	for _, changed := range d.Changed {
+		for _, change := range changed.Changes {
+			fmt.Fprintf(&b, "~ %s: %s %v -> %v\n", row(changed.Key, d.Key), change.Column, value(change.Before), value(change.After))
+		}
+	}
+	fmt.Fprintf(&b, "%s: %d added, %d removed, %d changed\n", d.Table, len(d.Added), len(d.Removed), len(d.Changed))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
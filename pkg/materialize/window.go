@@ -0,0 +1,132 @@
+package materialize
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/events"
+)
+
+// Window is a range of time, from Since (included) to Until (excluded), that the query of a windowed table
+// returns the rows of. Windowed queries reference it with the :since and :until parameters, bound to RFC 3339
+// timestamps in UTC, e.g. SELECT * FROM commits WHERE author_when >= :since AND author_when < :until
+type Window struct {
+	Since, Until time.Time
+}
+
+const stateTable = "askgit_sync_state"
+
+// Windowed reports whether the query of the table references the :since or :until parameters
+func (t *Table) Windowed() bool {
+	return strings.Contains(t.Query, ":since") || strings.Contains(t.Query, ":until")
+}
+
+// Incremental reports whether the table is synced from where its last sync stopped: windowed tables, and those following events
+func (t *Table) Incremental() bool {
+	return t.Windowed() || len(t.Events) > 0
+}
+
+// params returns the parameters of the query of the table for window w, and the changes since the last sync
+func (t *Table) params(w Window, changes *events.Changes) []interface{} {
+	var params = changeParams(t, changes)
+	if strings.Contains(t.Query, ":since") {
+		params = append(params, sql.Named("since", w.Since.UTC().Format(time.RFC3339)))
+	}
+	if strings.Contains(t.Query, ":until") {
+		params = append(params, sql.Named("until", w.Until.UTC().Format(time.RFC3339)))
+	}
+	return params
+}
+
+// ParseWindow parses the length of a window, as a Go duration (e.g. 12h) or a number of days (30d) or weeks (2w)
+func ParseWindow(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, err := strconv.Atoi(strings.TrimSuffix(s, suffix)); err == nil && strings.HasSuffix(s, suffix) {
+			if n <= 0 {
+				return 0, fmt.Errorf("invalid window %q, expected a positive length", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid window %q, expected a length such as 30d, 2w or 12h", s)
+	}
+	return d, nil
+}
+
+// syncedUntil returns the end of the last window synced for the table, or the zero time
+func (r *Runner) syncedUntil(ctx context.Context, t *Table) (time.Time, error) {
+	if _, err := r.db().ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (table_name TEXT PRIMARY KEY, synced_until TEXT NOT NULL)", stateTable)); err != nil {
+		return time.Time{}, err
+	}
+
+	var until string
+	switch err := r.db().QueryRowContext(ctx, fmt.Sprintf("SELECT synced_until FROM %s WHERE table_name = ?", stateTable), t.Name).Scan(&until); {
+	case err == sql.ErrNoRows:
+		return time.Time{}, nil
+	case err != nil:
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, until)
+}
+
+// setSyncedUntil records that the table is synced up to until
+func (r *Runner) setSyncedUntil(ctx context.Context, t *Table, until time.Time) error {
+	_, err := r.db().ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (table_name, synced_until) VALUES (?, ?) ON CONFLICT (table_name) DO UPDATE SET synced_until = excluded.synced_until", stateTable),
+		t.Name, until.UTC().Format(time.RFC3339))
+	return err
+}
+
+// Backfill syncs the windowed table t from since to until, one window of the given length at a time,
+// so that loading years of history doesn't take a single query exhausting the API rate limits.
+// Progress is recorded after every window: an interrupted backfill resumes where it stopped,
+// unless restart is set. It returns the number of rows written, and ErrStopped if Stop was closed before the last window.
+func (r *Runner) Backfill(ctx context.Context, t *Table, since, until time.Time, length time.Duration, restart bool) (int64, error) {
+	if !t.Windowed() {
+		return 0, fmt.Errorf("table %s can't be backfilled, its query doesn't reference :since or :until", t.Name)
+	}
+
+	var total int64
+	err := r.withConn(ctx, func(r *Runner) error {
+		if !restart {
+			synced, err := r.syncedUntil(ctx, t)
+			if err != nil {
+				return err
+			}
+			if synced.After(since) {
+				since = synced
+			}
+		}
+
+		for start := since; start.Before(until); start = start.Add(length) {
+			if r.stopped() {
+				r.logf("%s: backfill stopped at %s", t.Name, start.Format(time.RFC3339))
+				return ErrStopped
+			}
+
+			var w = Window{Since: start, Until: start.Add(length)}
+			if w.Until.After(until) {
+				w.Until = until
+			}
+
+			n, err := r.sync(ctx, t, w, nil)
+			if err != nil {
+				return fmt.Errorf("failed to backfill %s from %s, run the backfill again to resume: %v", t.Name, w.Since.Format(time.RFC3339), err)
+			}
+			if err = r.setSyncedUntil(ctx, t, w.Until); err != nil {
+				return err
+			}
+
+			total += n
+			r.logf("%s: %d rows from %s to %s", t.Name, n, w.Since.Format(time.RFC3339), w.Until.Format(time.RFC3339))
+		}
+		return nil
+	})
+	return total, err
+}
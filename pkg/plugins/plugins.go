@@ -0,0 +1,94 @@
+// Package plugins loads third-party table providers from Go plugins [https://golang.org/pkg/plugin/].
+//
+// A plugin is a main package built with -buildmode=plugin, against the same version of askgit and its
+// dependencies as the binary loading it, that exports a variable named Provider implementing tables.Provider:
+//
+//	package main
+//
+//	var Provider tables.Provider = &gerrit.Provider{}
+//
+// Go plugins are only supported on Linux, FreeBSD and macOS.
+package plugins
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"plugin"
+	"sort"
+
+	"github.com/askgitdev/askgit/tables"
+)
+
+// SymbolName is the name of the variable a plugin exports its provider as
+const SymbolName = "Provider"
+
+// Open loads the plugin at path and returns the provider it exports
+func Open(path string) (tables.Provider, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plugin %s: %v", path, err)
+	}
+
+	sym, err := p.Lookup(SymbolName)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s doesn't export a %s: %v", path, SymbolName, err)
+	}
+
+	// looking up a variable yields a pointer to it, which is either a pointer to a tables.Provider
+	// or a pointer to a concrete type implementing it
+	switch provider := sym.(type) {
+	case *tables.Provider:
+		return *provider, nil
+	case tables.Provider:
+		return provider, nil
+	default:
+		return nil, fmt.Errorf("%s exported by plugin %s is a %T, not a tables.Provider", SymbolName, path, sym)
+	}
+}
+
+// Load loads the plugins at the given paths. Directories are searched (non recursively) for *.so files.
+func Load(paths ...string) ([]tables.Provider, error) {
+	var providers []tables.Provider
+	for _, path := range paths {
+		files, err := pluginFiles(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			provider, err := Open(file)
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, provider)
+		}
+	}
+	return providers, nil
+}
+
+func pluginFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".so" {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
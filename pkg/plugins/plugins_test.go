@@ -0,0 +1,39 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "askgit-plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// an empty directory yields no providers
+	providers, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(providers) != 0 {
+		t.Fatalf("expected no providers, got: %d", len(providers))
+	}
+
+	// files without the .so extension are ignored, and invalid plugins are reported
+	for _, name := range []string{"README.md", "broken.so"} {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte("not a plugin"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = Load(dir); err == nil {
+		t.Fatal("expected an error loading an invalid plugin")
+	}
+
+	if _, err = Load(filepath.Join(dir, "missing.so")); err == nil {
+		t.Fatal("expected an error loading a missing plugin")
+	}
+}
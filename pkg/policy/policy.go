@@ -0,0 +1,269 @@
+// Package policy implements `askgit policy check`, which checks repositories (and the services around them)
+// against a set of rules, each a SQL query listing the violations of the rule: a rule passes when its query
+// returns no rows.
+package policy
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/askgitdev/askgit/pkg/display"
+	"github.com/askgitdev/askgit/pkg/query"
+	"github.com/ghodss/yaml"
+)
+
+// severities of rules
+const (
+	SeverityError   = "error"   // violations fail the check
+	SeverityWarning = "warning" // violations are reported, but don't fail the check
+)
+
+// Rules is a set of rules, usually read from a YAML file:
+//
+//	rules:
+//	  - name: signed-commits
+//	    description: commits on main are signed with an allowed key
+//	    query: SELECT hash, author_email FROM commits('', 'main') WHERE NOT signature_trusted(hash)
+//	  - name: stale-branches
+//	    severity: warning
+//	    query: SELECT name FROM refs WHERE type = 'branch' AND (SELECT committer_when FROM commits('', refs.hash) LIMIT 1) < date('now', '-6 months')
+//	    issue:
+//	      repository: askgitdev/askgit
+//	      labels: [chore]
+//	  - name: stale-issues
+//	    severity: warning
+//	    query: SELECT issue_number FROM github_repo_issues('askgitdev/askgit') WHERE state = 'OPEN' AND updated_at < date('now', '-1 year')
+type Rules struct {
+	Rules []*Rule `json:"rules"`
+}
+
+// Rule is a query returning the violations of a policy
+type Rule struct {
+	// Name identifies the rule in reports
+	Name string `json:"name"`
+
+	// Description explains the rule to those who broke it
+	Description string `json:"description,omitempty"`
+
+	// Severity is SeverityError (the default) or SeverityWarning
+	Severity string `json:"severity,omitempty"`
+
+	// Query returns one row for every violation of the rule, the rule passes when it returns none
+	Query string `json:"query"`
+
+	// Issue, if set, files the violations of the rule as an issue (see askgit policy check --file-issues)
+	Issue *IssueSink `json:"issue,omitempty"`
+}
+
+// IssueSink describes the issue the violations of a rule are filed as. The issue is updated on every check
+// while it's open, so that a recurring report (of stale branches or unowned files, for instance) keeps to a single issue.
+type IssueSink struct {
+	// Repository the issue is filed in, as owner/name
+	Repository string `json:"repository"`
+
+	// Title of the issue, the description (or name) of the rule by default
+	Title string `json:"title,omitempty"`
+
+	// Labels of the issue, when it's opened
+	Labels []string `json:"labels,omitempty"`
+}
+
+// LoadRules reads the rules in the YAML (or JSON) file at path
+func LoadRules(path string) (*Rules, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules Rules
+	if err = yaml.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse rules %s: %v", path, err)
+	}
+
+	if err = rules.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid rules %s: %v", path, err)
+	}
+	return &rules, nil
+}
+
+// Validate checks that every rule has a unique name and a query, and defaults their severity
+func (r *Rules) Validate() error {
+	if len(r.Rules) == 0 {
+		return fmt.Errorf("no rules")
+	}
+
+	var seen = make(map[string]bool)
+	for i, rule := range r.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("rule %d has no name", i)
+		}
+		if seen[rule.Name] {
+			return fmt.Errorf("rule %s is defined twice", rule.Name)
+		}
+		seen[rule.Name] = true
+
+		if rule.Query == "" {
+			return fmt.Errorf("rule %s has no query", rule.Name)
+		}
+
+		switch rule.Severity {
+		case "":
+			rule.Severity = SeverityError
+		case SeverityError, SeverityWarning:
+		default:
+			return fmt.Errorf("rule %s: invalid severity %q, expected %s or %s", rule.Name, rule.Severity, SeverityError, SeverityWarning)
+		}
+
+		if rule.Issue != nil {
+			if parts := strings.Split(rule.Issue.Repository, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				return fmt.Errorf("rule %s: invalid issue repository %q, expected owner/name", rule.Name, rule.Issue.Repository)
+			}
+			if rule.Issue.Title == "" {
+				rule.Issue.Title = rule.Name
+				if rule.Description != "" {
+					rule.Issue.Title = rule.Description
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Rule returns the rule called name, or nil
+func (r *Rules) Rule(name string) *Rule {
+	for _, rule := range r.Rules {
+		if rule.Name == name {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Result is the outcome of checking a rule
+type Result struct {
+	Rule *Rule
+
+	// Violations are the rows returned by the query of the rule, nil if it failed
+	Violations *query.Result
+
+	// Err is the error the query of the rule failed with. Rules that can't be checked fail, whatever their severity.
+	Err error
+}
+
+// Passed reports whether the rule was checked, and has no violations
+func (r *Result) Passed() bool {
+	return r.Err == nil && len(r.Violations.Rows) == 0
+}
+
+// Failed reports whether the rule fails the check: it couldn't be checked, or has violations and is an error
+func (r *Result) Failed() bool {
+	return r.Err != nil || !r.Passed() && r.Rule.Severity == SeverityError
+}
+
+// Report is the outcome of checking a set of rules
+type Report struct {
+	Results []*Result
+}
+
+// Check checks every rule against db, the results are in the order of the rules
+func Check(ctx context.Context, db *sql.DB, rules []*Rule) *Report {
+	var report = &Report{Results: make([]*Result, 0, len(rules))}
+	for _, rule := range rules {
+		violations, err := query.Run(ctx, db, rule.Query)
+		report.Results = append(report.Results, &Result{Rule: rule, Violations: violations, Err: err})
+	}
+	return report
+}
+
+// Failed reports whether any rule failed the check
+func (r *Report) Failed() bool {
+	for _, res := range r.Results {
+		if res.Failed() {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteText writes the report for people: the outcome of every rule, followed by its violations
+func (r *Report) WriteText(w io.Writer) error {
+	var failed, warned int
+	for _, res := range r.Results {
+		var status string
+		switch {
+		case res.Err != nil:
+			status = "ERROR"
+		case res.Passed():
+			status = "PASS"
+		case res.Failed():
+			status = "FAIL"
+		default:
+			status = "WARN"
+		}
+		if res.Failed() {
+			failed++
+		} else if !res.Passed() {
+			warned++
+		}
+
+		var line = fmt.Sprintf("%-5s %s", status, res.Rule.Name)
+		if res.Rule.Description != "" {
+			line += ": " + res.Rule.Description
+		}
+		switch {
+		case res.Err != nil:
+			line += fmt.Sprintf(" (%v)", res.Err)
+		case !res.Passed():
+			line += fmt.Sprintf(" (%d violations)", len(res.Violations.Rows))
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+
+		if res.Err == nil && !res.Passed() {
+			if err := writeViolations(w, res.Violations); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d rules checked, %d failed, %d with warnings\n", len(r.Results), failed, warned)
+	return err
+}
+
+// IssueBody renders the violations of the rule as the body of the issue they're filed as
+func (r *Result) IssueBody() (string, error) {
+	var b strings.Builder
+	if r.Rule.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", r.Rule.Description)
+	}
+	fmt.Fprintf(&b, "`askgit policy check` found %d violations of the rule `%s`:\n\n", len(r.Violations.Rows), r.Rule.Name)
+	if err := display.WriteMarkdown(&b, r.Violations.Columns, r.Violations.Rows); err != nil {
+		return "", err
+	}
+	fmt.Fprintf(&b, "\n<details><summary>Query</summary>\n\n```sql\n%s\n```\n</details>\n", strings.TrimSpace(r.Rule.Query))
+	return b.String(), nil
+}
+
+// writeViolations writes the violations of a rule as an indented table
+func writeViolations(w io.Writer, violations *query.Result) error {
+	var tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "      %s\n", strings.Join(violations.Columns, "\t"))
+	for _, row := range violations.Rows {
+		var values = make([]string, len(row))
+		for i, v := range row {
+			if v == nil {
+				values[i] = "NULL"
+			} else {
+				values[i] = fmt.Sprint(v)
+			}
+		}
+		fmt.Fprintf(tw, "      %s\n", strings.Join(values, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,168 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/askgitdev/askgit/pkg/query"
+)
+
+func TestLoadRules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "askgit-policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "rules.yaml")
+	if err = ioutil.WriteFile(path, []byte(`
+rules:
+  - name: signed-commits
+    description: commits on main are signed with an allowed key
+    query: SELECT hash FROM commits('', 'main') WHERE NOT signature_trusted(hash)
+  - name: stale-issues
+    severity: warning
+    query: SELECT number FROM github_repo_issues('askgitdev/askgit') WHERE state = 'OPEN'
+    issue:
+      repository: askgitdev/askgit
+      labels: [chore]
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := LoadRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed := rules.Rule("signed-commits"); signed == nil || signed.Severity != SeverityError {
+		t.Fatalf("expected signed-commits to default to the error severity, got: %+v", signed)
+	}
+	stale := rules.Rule("stale-issues")
+	if stale == nil || stale.Severity != SeverityWarning {
+		t.Fatalf("expected stale-issues to be a warning, got: %+v", stale)
+	}
+	if stale.Issue == nil || stale.Issue.Title != "stale-issues" || stale.Issue.Labels[0] != "chore" {
+		t.Fatalf("expected stale-issues to be filed as an issue titled by its name, got: %+v", stale.Issue)
+	}
+
+	for _, invalid := range []*Rules{
+		{},
+		{Rules: []*Rule{{Query: "SELECT 1"}}},
+		{Rules: []*Rule{{Name: "a", Query: "SELECT 1"}, {Name: "a", Query: "SELECT 2"}}},
+		{Rules: []*Rule{{Name: "a"}}},
+		{Rules: []*Rule{{Name: "a", Query: "SELECT 1", Severity: "fatal"}}},
+		{Rules: []*Rule{{Name: "a", Query: "SELECT 1", Issue: &IssueSink{Repository: "askgit"}}}},
+	} {
+		if err = invalid.Validate(); err == nil {
+			t.Fatalf("expected %+v to be invalid", invalid)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var rules = &Rules{Rules: []*Rule{
+		{Name: "signed-commits", Query: "SELECT hash FROM unsigned"},
+		{Name: "no-binaries", Query: "SELECT path FROM binaries"},
+		{Name: "stale-issues", Severity: SeverityWarning, Query: "SELECT number FROM stale"},
+		{Name: "broken", Query: "SELECT * FROM missing"},
+	}}
+	if err = rules.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery("SELECT hash FROM unsigned").WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow("abc123").AddRow("def456"))
+	mock.ExpectQuery("SELECT path FROM binaries").WillReturnRows(sqlmock.NewRows([]string{"path"}))
+	mock.ExpectQuery("SELECT number FROM stale").WillReturnRows(sqlmock.NewRows([]string{"number"}).AddRow(42))
+	mock.ExpectQuery(`SELECT \* FROM missing`).WillReturnError(errors.New("no such table: missing"))
+
+	report := Check(context.Background(), db, rules.Rules)
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, expected := range []struct{ passed, failed bool }{{false, true}, {true, false}, {false, false}, {false, true}} {
+		if res := report.Results[i]; res.Passed() != expected.passed || res.Failed() != expected.failed {
+			t.Fatalf("rule %s: expected passed=%v failed=%v, got passed=%v failed=%v", res.Rule.Name, expected.passed, expected.failed, res.Passed(), res.Failed())
+		}
+	}
+	if !report.Failed() {
+		t.Fatal("expected the check to fail")
+	}
+
+	var out bytes.Buffer
+	if err = report.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	body, err := report.Results[0].IssueBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body, "| hash |\n| --- |\n| abc123 |\n| def456 |\n") {
+		t.Fatalf("expected the issue to list the violations, got:\n%s", body)
+	}
+
+	for _, expected := range []string{"FAIL  signed-commits (2 violations)", "abc123", "PASS  no-binaries", "WARN  stale-issues (1 violations)", "ERROR broken (no such table: missing)", "4 rules checked, 2 failed, 1 with warnings"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("expected the report to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var report = &Report{Results: []*Result{
+		{
+			Rule:       &Rule{Name: "no-binaries", Description: "binaries aren't committed", Severity: SeverityError},
+			Violations: &query.Result{Columns: []string{"path", "size"}, Rows: [][]interface{}{{"dist/app.exe", int64(1024)}}},
+		},
+		{
+			Rule:       &Rule{Name: "stale-issues", Severity: SeverityWarning},
+			Violations: &query.Result{Columns: []string{"number"}, Rows: [][]interface{}{{int64(42)}}},
+		},
+		{Rule: &Rule{Name: "broken", Severity: SeverityError}, Err: errors.New("no such table: missing")},
+	}}
+
+	var out bytes.Buffer
+	if err := report.WriteSARIF(&out, "policy/rules.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	var run = log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 3 || len(run.Results) != 2 {
+		t.Fatalf("expected 3 rules and 2 results, got: %d and %d", len(run.Tool.Driver.Rules), len(run.Results))
+	}
+
+	binary := run.Results[0]
+	if binary.Level != "error" || binary.Locations[0].PhysicalLocation.ArtifactLocation.URI != "dist/app.exe" {
+		t.Fatalf("expected an error located in dist/app.exe, got: %+v", binary)
+	}
+	if binary.Message.Text != "binaries aren't committed: path=dist/app.exe, size=1024" {
+		t.Fatalf("unexpected message: %s", binary.Message.Text)
+	}
+
+	stale := run.Results[1]
+	if stale.Level != "warning" || stale.RuleIndex != 1 || stale.Locations[0].PhysicalLocation.ArtifactLocation.URI != "policy/rules.yaml" {
+		t.Fatalf("expected a warning located in the rules, got: %+v", stale)
+	}
+
+	if run.Invocations[0].ExecutionSuccessful || run.Invocations[0].ToolExecutionNotifications[0].Descriptor.ID != "broken" {
+		t.Fatalf("expected the broken rule to fail the execution, got: %+v", run.Invocations[0])
+	}
+}
@@ -0,0 +1,182 @@
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// the subset of SARIF 2.1.0 (https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html) code scanning reads
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Descriptor sarifDescriptor `json:"descriptor"`
+}
+
+type sarifDescriptor struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// WriteSARIF writes the report as a SARIF log, to be uploaded to GitHub code scanning.
+//
+// Every violation is a result of its rule, located in the file named by its path (or file) column,
+// at the line of its line column, if the query of the rule returns them. Other violations are located
+// in rulesPath, the file the rules were read from. Rules that can't be checked are reported as
+// notifications of a failed execution.
+func (r *Report) WriteSARIF(w io.Writer, rulesPath string) error {
+	var run = sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "askgit",
+			InformationURI: "https://github.com/askgitdev/askgit",
+			Rules:          make([]sarifRule, 0, len(r.Results)),
+		}},
+		Results: make([]sarifResult, 0),
+	}
+
+	var invocation = sarifInvocation{ExecutionSuccessful: true}
+	for i, res := range r.Results {
+		var level = sarifLevel(res.Rule.Severity)
+		var description = res.Rule.Description
+		if description == "" {
+			description = res.Rule.Name
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   res.Rule.Name,
+			ShortDescription:     sarifMessage{description},
+			DefaultConfiguration: sarifConfiguration{level},
+		})
+
+		if res.Err != nil {
+			invocation.ExecutionSuccessful = false
+			invocation.ToolExecutionNotifications = append(invocation.ToolExecutionNotifications, sarifNotification{
+				Level:      "error",
+				Message:    sarifMessage{res.Err.Error()},
+				Descriptor: sarifDescriptor{res.Rule.Name},
+			})
+			continue
+		}
+
+		for _, row := range res.Violations.Rows {
+			var location = sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{filepath.ToSlash(rulesPath)}}
+			var fields = make([]string, len(row))
+			for c, column := range res.Violations.Columns {
+				var value = "NULL"
+				if row[c] != nil {
+					value = fmt.Sprint(row[c])
+				}
+				fields[c] = column + "=" + value
+
+				switch strings.ToLower(column) {
+				case "path", "file":
+					if row[c] != nil {
+						location.ArtifactLocation.URI = value
+					}
+				case "line":
+					if line, err := strconv.Atoi(value); err == nil && line > 0 {
+						location.Region = &sarifRegion{StartLine: line}
+					}
+				}
+			}
+
+			// the fingerprint identifies the violation across runs, so code scanning doesn't report it as new every time
+			var sum = sha256.Sum256([]byte(res.Rule.Name + "\x00" + strings.Join(fields, "\x00")))
+			run.Results = append(run.Results, sarifResult{
+				RuleID:              res.Rule.Name,
+				RuleIndex:           i,
+				Level:               level,
+				Message:             sarifMessage{description + ": " + strings.Join(fields, ", ")},
+				Locations:           []sarifLocation{{location}},
+				PartialFingerprints: map[string]string{"askgitViolation/v1": hex.EncodeToString(sum[:])},
+			})
+		}
+	}
+	run.Invocations = []sarifInvocation{invocation}
+
+	var enc = json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// sarifLevel returns the SARIF level of results of the given severity
+func sarifLevel(severity string) string {
+	if severity == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
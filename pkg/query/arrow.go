@@ -0,0 +1,220 @@
+package query
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// the ids of the Arrow types result sets are written out as, in the Type union of Schema.fbs
+const (
+	arrowInt   = 2
+	arrowFloat = 3
+	arrowUtf8  = 5
+)
+
+// the version of the Arrow metadata written out (V5), and the ids of the MessageHeader union of Message.fbs
+const (
+	arrowMetadataVersion = 4
+	arrowSchemaMessage   = 1
+	arrowRecordBatch     = 3
+)
+
+// WriteArrow writes the result set out to w as an Arrow IPC stream (see https://arrow.apache.org/docs/format/Columnar.html),
+// made of its schema and a single record batch, so that it can be read as a table by pyarrow.ipc.open_stream for instance.
+// Columns holding only integers are written out as 64 bit integers, those holding only numbers as doubles,
+// and any other column as UTF-8 strings. NULLs are written out as nulls, whatever the type of the column.
+func (r *Result) WriteArrow(w io.Writer) error {
+	var types = make([]byte, len(r.Columns))
+	for c := range r.Columns {
+		types[c] = r.arrowType(c)
+	}
+
+	var fields = make([]func(b *fbBuilder) int, len(r.Columns))
+	for c, column := range r.Columns {
+		var name, typ = column, types[c]
+		fields[c] = func(b *fbBuilder) int {
+			return b.table(
+				fbRef(func(b *fbBuilder) int { return b.string(name) }),
+				fbBool(true),
+				fbUint8(typ),
+				fbRef(func(b *fbBuilder) int { return arrowTypeTable(b, typ) }),
+				fbField{},
+				// readers expect the children of a field, even if it has none
+				fbRef(func(b *fbBuilder) int { return b.tables() }),
+			)
+		}
+	}
+
+	var schema = fbFinish(func(b *fbBuilder) int {
+		return b.table(
+			fbInt16(arrowMetadataVersion),
+			fbUint8(arrowSchemaMessage),
+			fbRef(func(b *fbBuilder) int {
+				return b.table(fbField{}, fbRef(func(b *fbBuilder) int { return b.tables(fields...) }))
+			}),
+			fbInt64(0),
+		)
+	})
+	if err := writeArrowMessage(w, schema, nil); err != nil {
+		return err
+	}
+
+	var body arrowBody
+	var nodes = make([][2]int64, len(r.Columns))
+	for c := range r.Columns {
+		nodes[c] = [2]int64{int64(len(r.Rows)), int64(r.writeArrowColumn(&body, c, types[c]))}
+	}
+
+	var batch = fbFinish(func(b *fbBuilder) int {
+		return b.table(
+			fbInt16(arrowMetadataVersion),
+			fbUint8(arrowRecordBatch),
+			fbRef(func(b *fbBuilder) int {
+				return b.table(
+					fbInt64(int64(len(r.Rows))),
+					fbRef(func(b *fbBuilder) int { return b.longs(nodes) }),
+					fbRef(func(b *fbBuilder) int { return b.longs(body.buffers) }),
+				)
+			}),
+			fbInt64(int64(len(body.bytes))),
+		)
+	})
+	if err := writeArrowMessage(w, batch, body.bytes); err != nil {
+		return err
+	}
+
+	// the end of the stream
+	_, err := w.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0})
+	return err
+}
+
+// arrowType returns the Arrow type the values of column c are written out as
+func (r *Result) arrowType(c int) byte {
+	var typ byte = arrowInt
+	for _, row := range r.Rows {
+		switch row[c].(type) {
+		case nil, int64, bool:
+		case float64:
+			typ = arrowFloat
+		default:
+			return arrowUtf8
+		}
+	}
+	return typ
+}
+
+// arrowTypeTable writes the table describing an Arrow type out to b
+func arrowTypeTable(b *fbBuilder, typ byte) int {
+	switch typ {
+	case arrowInt:
+		return b.table(fbInt32(64), fbBool(true))
+	case arrowFloat:
+		return b.table(fbInt16(2)) // double precision
+	default:
+		return b.table()
+	}
+}
+
+// arrowBody is the body of a record batch, and the offset and length of each of the buffers it's made of
+type arrowBody struct {
+	bytes   []byte
+	buffers [][2]int64
+}
+
+// add appends a buffer to the body, padded to a multiple of 8 bytes
+func (body *arrowBody) add(buf []byte) {
+	body.buffers = append(body.buffers, [2]int64{int64(len(body.bytes)), int64(len(buf))})
+	body.bytes = append(body.bytes, buf...)
+	for len(body.bytes)%8 != 0 {
+		body.bytes = append(body.bytes, 0)
+	}
+}
+
+// writeArrowColumn adds the buffers of column c to body: its validity bitmap, followed by its values
+// (or the offsets of its strings, and their bytes). It returns the number of NULLs in the column.
+func (r *Result) writeArrowColumn(body *arrowBody, c int, typ byte) int {
+	var validity, nulls = make([]byte, (len(r.Rows)+7)/8), 0
+	for i, row := range r.Rows {
+		if row[c] == nil {
+			nulls++
+		} else {
+			validity[i/8] |= 1 << (i % 8)
+		}
+	}
+	if nulls == 0 {
+		validity = nil // every value is valid
+	}
+	body.add(validity)
+
+	switch typ {
+	case arrowInt, arrowFloat:
+		var values = make([]byte, 8*len(r.Rows))
+		for i, row := range r.Rows {
+			var integer, number = arrowNumber(row[c])
+			var bits = uint64(integer)
+			if typ == arrowFloat {
+				bits = math.Float64bits(number)
+			}
+			binary.LittleEndian.PutUint64(values[8*i:], bits)
+		}
+		body.add(values)
+	default:
+		var offsets, data = make([]byte, 4*(len(r.Rows)+1)), make([]byte, 0)
+		for i, row := range r.Rows {
+			if row[c] != nil {
+				data = append(data, arrowText(row[c])...)
+			}
+			binary.LittleEndian.PutUint32(offsets[4*(i+1):], uint32(len(data)))
+		}
+		body.add(offsets)
+		body.add(data)
+	}
+	return nulls
+}
+
+// arrowNumber returns a value of a column of numbers as an integer and as a double, 0 if it's NULL
+func arrowNumber(v interface{}) (int64, float64) {
+	switch v := v.(type) {
+	case int64:
+		return v, float64(v)
+	case float64:
+		return int64(v), v
+	case bool:
+		if v {
+			return 1, 1
+		}
+	}
+	return 0, 0
+}
+
+// arrowText returns the text a value is written out as in a column of strings
+func arrowText(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// writeArrowMessage writes an encapsulated message out to w: a continuation marker and the length of
+// its metadata, followed by the metadata padded to a multiple of 8 bytes, and its body
+func writeArrowMessage(w io.Writer, metadata, body []byte) error {
+	var padding = (8 - len(metadata)%8) % 8
+	var prefix = []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(prefix[4:], uint32(len(metadata)+padding))
+
+	for _, b := range [][]byte{prefix, metadata, make([]byte, padding), body} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package query
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// readArrowMessages splits an Arrow IPC stream into the metadata and body of each of its messages
+func readArrowMessages(t *testing.T, stream []byte) (metadata, bodies [][]byte) {
+	for {
+		if len(stream) < 8 || binary.LittleEndian.Uint32(stream) != 0xffffffff {
+			t.Fatalf("expected a continuation marker, got: %v", stream)
+		}
+		var length = int(binary.LittleEndian.Uint32(stream[4:]))
+		if length == 0 {
+			if len(stream) != 8 {
+				t.Fatalf("expected the stream to end after its end marker, got %d more bytes", len(stream)-8)
+			}
+			return metadata, bodies
+		}
+		if length%8 != 0 {
+			t.Fatalf("expected the metadata to be padded to 8 bytes, got: %d", length)
+		}
+
+		var meta = stream[8 : 8+length]
+		var size = int(binary.LittleEndian.Uint64(fbFieldBytes(meta, fbRoot(meta), 3)))
+		metadata, bodies = append(metadata, meta), append(bodies, stream[8+length:8+length+size])
+		stream = stream[8+length+size:]
+	}
+}
+
+// fbRoot returns the position of the root table of a FlatBuffer
+func fbRoot(buf []byte) int { return int(binary.LittleEndian.Uint32(buf)) }
+
+// fbFieldBytes returns the bytes of buf starting at field i of the table at position table, nil if the field is absent
+func fbFieldBytes(buf []byte, table, i int) []byte {
+	var vtable = table - int(int32(binary.LittleEndian.Uint32(buf[table:])))
+	if 4+2*i >= int(binary.LittleEndian.Uint16(buf[vtable:])) {
+		return nil
+	}
+	if offset := int(binary.LittleEndian.Uint16(buf[vtable+4+2*i:])); offset != 0 {
+		return buf[table+offset:]
+	}
+	return nil
+}
+
+// fbDeref returns the position of the object referenced by field i of the table at position table
+func fbDeref(buf []byte, table, i int) int {
+	var field = len(buf) - len(fbFieldBytes(buf, table, i))
+	return field + int(binary.LittleEndian.Uint32(buf[field:]))
+}
+
+func TestWriteArrow(t *testing.T) {
+	var res = &Result{Columns: []string{"id", "name", "score"}, Rows: [][]interface{}{
+		{int64(1), "a", 1.5},
+		{int64(2), nil, int64(3)},
+	}}
+
+	var buf bytes.Buffer
+	if err := res.WriteArrow(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, bodies := readArrowMessages(t, buf.Bytes())
+	if len(metadata) != 2 {
+		t.Fatalf("expected a schema and a record batch, got %d messages", len(metadata))
+	}
+
+	// the fields of the schema, with their names and types
+	var schema = metadata[0]
+	if typ := fbFieldBytes(schema, fbRoot(schema), 1)[0]; typ != arrowSchemaMessage {
+		t.Fatalf("expected a schema message first, got: %d", typ)
+	}
+	var fields = fbDeref(schema, fbDeref(schema, fbRoot(schema), 2), 1)
+	if n := binary.LittleEndian.Uint32(schema[fields:]); n != 3 {
+		t.Fatalf("expected 3 fields, got: %d", n)
+	}
+	for i, want := range []struct {
+		name string
+		typ  byte
+	}{{"id", arrowInt}, {"name", arrowUtf8}, {"score", arrowFloat}} {
+		var offset = fields + 4 + 4*i
+		var field = offset + int(binary.LittleEndian.Uint32(schema[offset:]))
+		var name = fbDeref(schema, field, 0)
+		var length = int(binary.LittleEndian.Uint32(schema[name:]))
+		if got := string(schema[name+4 : name+4+length]); got != want.name {
+			t.Fatalf("expected field %d to be named %q, got: %q", i, want.name, got)
+		}
+		if typ := fbFieldBytes(schema, field, 2)[0]; typ != want.typ {
+			t.Fatalf("expected field %q to be of type %d, got: %d", want.name, want.typ, typ)
+		}
+	}
+
+	// the buffers of the record batch: the validity and values of id, the validity, offsets and data of name,
+	// and the validity and values of score
+	var batch, body = metadata[1], bodies[1]
+	var header = fbDeref(batch, fbRoot(batch), 2)
+	if length := binary.LittleEndian.Uint64(fbFieldBytes(batch, header, 0)); length != 2 {
+		t.Fatalf("expected a batch of 2 rows, got: %d", length)
+	}
+	var buffers = fbDeref(batch, header, 2)
+	var buffer = func(i int) []byte {
+		var offset = binary.LittleEndian.Uint64(batch[buffers+4+16*i:])
+		var length = binary.LittleEndian.Uint64(batch[buffers+4+16*i+8:])
+		return body[offset : offset+length]
+	}
+	if n := binary.LittleEndian.Uint32(batch[buffers:]); n != 7 {
+		t.Fatalf("expected 7 buffers, got: %d", n)
+	}
+
+	if ids := buffer(1); binary.LittleEndian.Uint64(ids) != 1 || binary.LittleEndian.Uint64(ids[8:]) != 2 {
+		t.Fatalf("unexpected values of id: %v", ids)
+	}
+	if validity := buffer(2); len(validity) != 1 || validity[0] != 1 {
+		t.Fatalf("expected the second name to be null, got: %v", validity)
+	}
+	if names := buffer(4); string(names) != "a" {
+		t.Fatalf("unexpected data of name: %q", names)
+	}
+	if scores := buffer(6); math.Float64frombits(binary.LittleEndian.Uint64(scores[8:])) != 3 {
+		t.Fatalf("expected the integer score to be written out as a double, got: %v", scores)
+	}
+}
@@ -0,0 +1,154 @@
+package query
+
+import "encoding/binary"
+
+// fbBuilder lays out a FlatBuffer front to back: the objects a table references are written after it,
+// so that every offset points forward, as FlatBuffers requires. That's all the messages of Arrow need,
+// whose schema is small enough that the generated code of flatc isn't worth depending on.
+type fbBuilder struct {
+	buf []byte
+}
+
+// fbField is a field of a table, either an inline scalar or a reference to the object written by ref.
+// The zero value is an absent field.
+type fbField struct {
+	scalar []byte
+	ref    func(b *fbBuilder) int
+}
+
+func fbBool(v bool) fbField {
+	if v {
+		return fbField{scalar: []byte{1}}
+	}
+	return fbField{scalar: []byte{0}}
+}
+
+func fbUint8(v uint8) fbField { return fbField{scalar: []byte{v}} }
+
+func fbInt16(v int16) fbField {
+	var b = make([]byte, 2)
+	binary.LittleEndian.PutUint16(b, uint16(v))
+	return fbField{scalar: b}
+}
+
+func fbInt32(v int32) fbField {
+	var b = make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, uint32(v))
+	return fbField{scalar: b}
+}
+
+func fbInt64(v int64) fbField {
+	var b = make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, uint64(v))
+	return fbField{scalar: b}
+}
+
+func fbRef(ref func(b *fbBuilder) int) fbField { return fbField{ref: ref} }
+
+// fbFinish returns the FlatBuffer whose root table is written by root
+func fbFinish(root func(b *fbBuilder) int) []byte {
+	var b = &fbBuilder{buf: make([]byte, 4)}
+	b.patch(0, root(b))
+	return b.buf
+}
+
+// pad pads the buffer with zeros up to a multiple of n
+func (b *fbBuilder) pad(n int) {
+	for len(b.buf)%n != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+func (b *fbBuilder) uint16(v uint16) {
+	b.buf = append(b.buf, byte(v), byte(v>>8))
+}
+
+func (b *fbBuilder) uint32(v uint32) {
+	b.buf = append(b.buf, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(b.buf[len(b.buf)-4:], v)
+}
+
+// patch sets the offset at position at to point to the object at position target
+func (b *fbBuilder) patch(at, target int) {
+	binary.LittleEndian.PutUint32(b.buf[at:], uint32(target-at))
+}
+
+// table writes a table of fields, in the order of their ids, preceded by its vtable, and returns its position.
+// The table is aligned on 8 bytes and its fields on their size, so that none of them is misaligned.
+func (b *fbBuilder) table(fields ...fbField) int {
+	var offsets = make([]int, len(fields))
+	var size = 4 // the offset to the vtable
+	for i, f := range fields {
+		var n = len(f.scalar)
+		if f.ref != nil {
+			n = 4
+		}
+		if n == 0 {
+			continue
+		}
+		for size%n != 0 {
+			size++
+		}
+		offsets[i] = size
+		size += n
+	}
+
+	b.pad(2)
+	var vtable = len(b.buf)
+	b.uint16(uint16(4 + 2*len(fields)))
+	b.uint16(uint16(size))
+	for _, offset := range offsets {
+		b.uint16(uint16(offset))
+	}
+
+	b.pad(8)
+	var table = len(b.buf)
+	b.uint32(uint32(table - vtable))
+	b.buf = append(b.buf, make([]byte, size-4)...)
+	for i, f := range fields {
+		copy(b.buf[table+offsets[i]:], f.scalar)
+	}
+	for i, f := range fields {
+		if f.ref != nil {
+			b.patch(table+offsets[i], f.ref(b))
+		}
+	}
+	return table
+}
+
+// string writes s as a null-terminated string, and returns its position
+func (b *fbBuilder) string(s string) int {
+	b.pad(4)
+	var pos = len(b.buf)
+	b.uint32(uint32(len(s)))
+	b.buf = append(b.buf, s...)
+	b.buf = append(b.buf, 0)
+	return pos
+}
+
+// tables writes a vector of the tables written by refs, and returns its position
+func (b *fbBuilder) tables(refs ...func(b *fbBuilder) int) int {
+	b.pad(4)
+	var pos = len(b.buf)
+	b.uint32(uint32(len(refs)))
+	b.buf = append(b.buf, make([]byte, 4*len(refs))...)
+	for i, ref := range refs {
+		b.patch(pos+4+4*i, ref(b))
+	}
+	return pos
+}
+
+// longs writes a vector of structs made of two longs (as the FieldNode and Buffer of Arrow are),
+// with the structs aligned on 8 bytes, and returns its position
+func (b *fbBuilder) longs(structs [][2]int64) int {
+	for (len(b.buf)+4)%8 != 0 {
+		b.buf = append(b.buf, 0)
+	}
+	var pos = len(b.buf)
+	b.uint32(uint32(len(structs)))
+	for _, s := range structs {
+		b.buf = append(b.buf, fbInt64(s[0]).scalar...)
+		b.buf = append(b.buf, fbInt64(s[1]).scalar...)
+	}
+	return pos
+}
@@ -0,0 +1,70 @@
+package query
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Identifiers returns the (lower-cased) bare and quoted identifiers referenced in sql, in order of appearance.
+// String literals and comments are skipped. Keywords are included as well, since telling them apart
+// requires a full parser, so callers should match the output against a set of names they are interested in.
+func Identifiers(sql string) []string {
+	var idents []string
+	scan(sql, func(ident string, _ bool) { idents = append(idents, ident) })
+	return idents
+}
+
+// FunctionCalls returns the (lower-cased) names of the scalar and table-valued functions called in sql,
+// that is, every identifier immediately followed by an opening parenthesis.
+func FunctionCalls(sql string) []string {
+	var calls []string
+	scan(sql, func(ident string, call bool) {
+		if call {
+			calls = append(calls, ident)
+		}
+	})
+	return calls
+}
+
+// scan tokenizes sql and invokes fn for every identifier found, along with whether it's followed by a '('
+func scan(sql string, fn func(ident string, call bool)) {
+	var emit = func(ident string, end int) {
+		rest := strings.TrimLeftFunc(sql[end+1:], unicode.IsSpace)
+		fn(strings.ToLower(ident), strings.HasPrefix(rest, "("))
+	}
+
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '\'':
+			i = skipUntil(sql, i+1, "'")
+		case c == '"' || c == '`' || c == '[':
+			term := string(c)
+			if c == '[' {
+				term = "]"
+			}
+			end := skipUntil(sql, i+1, term)
+			if end > i {
+				emit(sql[i+1:end], end)
+			}
+			i = end
+		case strings.HasPrefix(sql[i:], "--"):
+			i = skipUntil(sql, i+2, "\n")
+		case strings.HasPrefix(sql[i:], "/*"):
+			i = skipUntil(sql, i+2, "*/")
+		case isIdentStart(rune(c)):
+			start := i
+			for i+1 < len(sql) && isIdentPart(rune(sql[i+1])) {
+				i++
+			}
+			emit(sql[start:i+1], i)
+		case unicode.IsDigit(rune(c)):
+			// skip over numeric literals so that something like 1e10 isn't read as an identifier
+			for i+1 < len(sql) && isIdentPart(rune(sql[i+1])) {
+				i++
+			}
+		}
+	}
+}
+
+func isIdentStart(r rune) bool { return r == '_' || r > 127 || unicode.IsLetter(r) }
+func isIdentPart(r rune) bool  { return isIdentStart(r) || unicode.IsDigit(r) || r == '$' }
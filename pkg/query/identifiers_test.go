@@ -0,0 +1,31 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIdentifiers(t *testing.T) {
+	var cases = []struct {
+		sql  string
+		want []string
+	}{
+		{"SELECT * FROM commits", []string{"select", "from", "commits"}},
+		{"SELECT 'github_stargazers', 1e10 FROM \"Refs\" -- blame", []string{"select", "from", "refs"}},
+		{"select * from github_stargazers('askgitdev/askgit') /* stats */", []string{"select", "from", "github_stargazers"}},
+	}
+
+	for _, c := range cases {
+		if got := Identifiers(c.sql); !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("Identifiers(%q): expected %q, got %q", c.sql, c.want, got)
+		}
+	}
+}
+
+func TestFunctionCalls(t *testing.T) {
+	var sql = "SELECT count(*), lower (name), 'load_extension(x)' FROM github_stargazers('askgitdev/askgit') AS s, commits"
+	var want = []string{"count", "lower", "github_stargazers"}
+	if got := FunctionCalls(sql); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,47 @@
+package query
+
+import "strings"
+
+// Parameters returns the names of the named parameters (:name, @name or $name) of sql, without their prefix,
+// in the order they first appear. Parameters inside of string literals, quoted identifiers and comments are ignored.
+func Parameters(sql string) []string {
+	var names []string
+	var seen = make(map[string]bool)
+
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; c {
+		case '\'', '"', '`':
+			i = skipUntil(sql, i+1, string(c))
+		case '[':
+			i = skipUntil(sql, i+1, "]")
+		case '-':
+			if strings.HasPrefix(sql[i:], "--") {
+				i = skipUntil(sql, i+2, "\n")
+			}
+		case '/':
+			if strings.HasPrefix(sql[i:], "/*") {
+				i = skipUntil(sql, i+2, "*/")
+			}
+		case ':', '@', '$':
+			// a parameter starts a word
+			if i > 0 && isWordByte(sql[i-1]) {
+				continue
+			}
+			var end = i + 1
+			for end < len(sql) && isWordByte(sql[end]) {
+				end++
+			}
+			if name := sql[i+1 : end]; name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+			i = end - 1
+		}
+	}
+
+	return names
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
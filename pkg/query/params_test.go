@@ -0,0 +1,25 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParameters(t *testing.T) {
+	var cases = []struct {
+		sql  string
+		want []string
+	}{
+		{"SELECT 1", nil},
+		{"SELECT * FROM github_stargazers(:repo) LIMIT :limit", []string{"repo", "limit"}},
+		{"SELECT @a, $b, :a", []string{"a", "b"}},
+		{"SELECT ':no', \"@no\" FROM [$no] -- :no\n WHERE x = :yes /* @no */", []string{"yes"}},
+		{"SELECT b:c FROM t", nil},
+	}
+
+	for _, c := range cases {
+		if got := Parameters(c.sql); !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("Parameters(%q): expected %q, got %q", c.sql, c.want, got)
+		}
+	}
+}
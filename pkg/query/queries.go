@@ -1,5 +1,7 @@
 package query
 
+import "sort"
+
 var queries = map[string]string{
 	// show all commit information from repository, in the current directory
 	"commit-info": "SELECT * FROM commits",
@@ -33,3 +35,13 @@ var queries = map[string]string{
 
 // Find finds and return the named query
 func Find(name string) (string, bool) { q, ok := queries[name]; return q, ok }
+
+// Names returns the names of the preset queries, sorted
+func Names() []string {
+	var names = make([]string, 0, len(queries))
+	for name := range queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
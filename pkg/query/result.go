@@ -0,0 +1,95 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Result is a fully materialized result set, suitable for encoding (as JSON for instance)
+type Result struct {
+	Columns []string        `json:"columns"`
+	Types   []string        `json:"types"`
+	Rows    [][]interface{} `json:"rows"`
+
+	// Truncated is set if there were more rows available than were collected
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Columnar returns the result set as a map of column name to column values
+func (r *Result) Columnar() map[string][]interface{} {
+	var data = make(map[string][]interface{}, len(r.Columns))
+	for c, column := range r.Columns {
+		values := make([]interface{}, len(r.Rows))
+		for i, row := range r.Rows {
+			values[i] = row[c]
+		}
+		data[column] = values
+	}
+	return data
+}
+
+// Querier is a database, or one of its connections, queries can be run against
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Run executes query against db and collects the result set
+func Run(ctx context.Context, db Querier, query string, args ...interface{}) (*Result, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return Collect(rows, -1)
+}
+
+// Collect reads at most limit rows into a Result. A negative limit collects all rows.
+func Collect(rows *sql.Rows, limit int) (*Result, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	var res = &Result{Columns: columns, Types: make([]string, len(types)), Rows: make([][]interface{}, 0)}
+	for i, t := range types {
+		res.Types[i] = t.DatabaseTypeName()
+	}
+
+	pointers := make([]interface{}, len(columns))
+	for rows.Next() {
+		if limit >= 0 && len(res.Rows) == limit {
+			res.Truncated = true
+			break
+		}
+
+		values := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err = rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		res.Rows = append(res.Rows, Normalize(values))
+	}
+
+	return res, rows.Err()
+}
+
+// Normalize converts the scanned values of a row in place into types that encode nicely.
+// Blobs are returned as []byte by the driver which would otherwise get base64 encoded.
+func Normalize(values []interface{}) []interface{} {
+	for i, v := range values {
+		if b, ok := v.([]byte); ok {
+			values[i] = string(b)
+		}
+	}
+	return values
+}
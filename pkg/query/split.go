@@ -0,0 +1,63 @@
+package query
+
+import "strings"
+
+// Split splits sql into the individual statements it is made up of.
+// Semicolons inside of string literals, quoted identifiers and comments are not treated as separators.
+// Statements are returned without the trailing semicolon, and empty statements are dropped.
+func Split(sql string) []string {
+	var statements []string
+	var start int
+
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; c {
+		case '\'', '"', '`':
+			i = skipUntil(sql, i+1, string(c))
+		case '[':
+			i = skipUntil(sql, i+1, "]")
+		case '-':
+			if strings.HasPrefix(sql[i:], "--") {
+				i = skipUntil(sql, i+2, "\n")
+			}
+		case '/':
+			if strings.HasPrefix(sql[i:], "/*") {
+				i = skipUntil(sql, i+2, "*/")
+			}
+		case ';':
+			if stmt := strings.TrimSpace(sql[start:i]); stmt != "" && !onlyComments(stmt) {
+				statements = append(statements, stmt)
+			}
+			start = i + 1
+		}
+	}
+
+	if stmt := strings.TrimSpace(sql[start:]); stmt != "" && !onlyComments(stmt) {
+		statements = append(statements, stmt)
+	}
+
+	return statements
+}
+
+// skipUntil returns the index of the last byte of the first occurrence of term in s at or after from,
+// or the index of the last byte in s if term is never found.
+func skipUntil(s string, from int, term string) int {
+	if idx := strings.Index(s[from:], term); idx >= 0 {
+		return from + idx + len(term) - 1
+	}
+	return len(s) - 1
+}
+
+// onlyComments reports whether the statement is made up entirely of comments
+func onlyComments(stmt string) bool {
+	for stmt = strings.TrimSpace(stmt); stmt != ""; stmt = strings.TrimSpace(stmt) {
+		switch {
+		case strings.HasPrefix(stmt, "--"):
+			stmt = stmt[skipUntil(stmt, 2, "\n")+1:]
+		case strings.HasPrefix(stmt, "/*"):
+			stmt = stmt[skipUntil(stmt, 2, "*/")+1:]
+		default:
+			return false
+		}
+	}
+	return true
+}
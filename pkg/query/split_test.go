@@ -0,0 +1,26 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	var cases = []struct {
+		sql  string
+		want []string
+	}{
+		{"SELECT 1", []string{"SELECT 1"}},
+		{"SELECT 1; SELECT 2;", []string{"SELECT 1", "SELECT 2"}},
+		{"SELECT ';'; SELECT \"a;b\" FROM [c;d]", []string{"SELECT ';'", "SELECT \"a;b\" FROM [c;d]"}},
+		{"SELECT 1; -- trailing; comment", []string{"SELECT 1"}},
+		{"/* a; b */ SELECT 1;;", []string{"/* a; b */ SELECT 1"}},
+		{"  ;  ", nil},
+	}
+
+	for _, c := range cases {
+		if got := Split(c.sql); !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("Split(%q): expected %q, got %q", c.sql, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+// Package redact scrubs personal data (emails, names of people, IP addresses) from exported tables,
+// so that datasets can be shared without leaking contributor details.
+//
+// Values are either dropped or replaced by a keyed hash. Hashes are stable for a given salt, so rows
+// by the same contributor can still be grouped and joined across tables, but can't be reversed by
+// hashing a list of known emails without the salt.
+package redact
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kind is a kind of personal data
+type Kind string
+
+const (
+	Emails Kind = "emails" // email addresses, in email columns and within any text
+	Names  Kind = "names"  // names and logins of people, in author, committer and similar columns
+	IPs    Kind = "ips"    // IPv4 and IPv6 addresses, within any text
+)
+
+// Action is what's done with redacted values
+type Action string
+
+const (
+	Hash Action = "hash" // replace values with a keyed hash
+	Drop Action = "drop" // replace values with NULL, or with "[redacted]" within text
+)
+
+// Dropped replaces the data dropped from within text
+const Dropped = "[redacted]"
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	ipPattern    = regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])\.){3}(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])\b|\b(?:[0-9A-Fa-f]{1,4}:){7}[0-9A-Fa-f]{1,4}\b|\b(?:[0-9A-Fa-f]{1,4}:){1,6}:(?:[0-9A-Fa-f]{1,4}:){0,5}[0-9A-Fa-f]{1,4}\b`)
+
+	emailColumn  = regexp.MustCompile(`(?i)e-?mail`)
+	personColumn = regexp.MustCompile(`(?i)(^|_)(author|committer|tagger|user|assignee|reviewer|creator|owner|actor|sender|login)(_name|_login)?$`)
+)
+
+// Redactor applies a set of redaction rules
+type Redactor struct {
+	actions map[Kind]Action
+	salt    []byte
+}
+
+// Parse returns a redactor applying rules given as kind[:action] (e.g. "emails", "names:drop"),
+// hashing values with salt. The action defaults to hash.
+func Parse(rules []string, salt string) (*Redactor, error) {
+	var r = &Redactor{actions: make(map[Kind]Action), salt: []byte(salt)}
+	for _, rule := range rules {
+		var kind, action = rule, string(Hash)
+		if i := strings.Index(rule, ":"); i >= 0 {
+			kind, action = rule[:i], rule[i+1:]
+		}
+
+		switch Kind(kind) {
+		case Emails, Names, IPs:
+		default:
+			return nil, fmt.Errorf("unknown kind of data to redact %q, expected one of emails, names or ips", kind)
+		}
+
+		switch Action(action) {
+		case Hash, Drop:
+		default:
+			return nil, fmt.Errorf("unknown redaction %q for %s, expected hash or drop", action, kind)
+		}
+
+		r.actions[Kind(kind)] = Action(action)
+	}
+	return r, nil
+}
+
+// Enabled reports whether any data is redacted
+func (r *Redactor) Enabled() bool { return r != nil && len(r.actions) > 0 }
+
+// hash returns the keyed hash of s
+func (r *Redactor) hash(s string) string {
+	var mac = hmac.New(sha256.New, r.salt)
+	_, _ = mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// apply returns the redacted form of a whole value of kind, NULL if it's dropped
+func (r *Redactor) apply(kind Kind, s string) interface{} {
+	switch r.actions[kind] {
+	case Hash:
+		return r.hash(strings.ToLower(strings.TrimSpace(s)))
+	case Drop:
+		return nil
+	}
+	return s
+}
+
+// replace redacts the matches of pattern within s
+func (r *Redactor) replace(kind Kind, pattern *regexp.Regexp, s string) string {
+	switch r.actions[kind] {
+	case Hash:
+		return pattern.ReplaceAllStringFunc(s, func(m string) string { return r.hash(strings.ToLower(m)) })
+	case Drop:
+		return pattern.ReplaceAllLiteralString(s, Dropped)
+	}
+	return s
+}
+
+// Value returns the redacted form of the value v of column, and whether it was changed.
+// Only text values are redacted.
+func (r *Redactor) Value(column string, v interface{}) (interface{}, bool) {
+	var s string
+	switch t := v.(type) {
+	case string:
+		s = t
+	case []byte:
+		s = string(t)
+	default:
+		return v, false
+	}
+
+	if s == "" {
+		return v, false
+	}
+
+	// whole columns holding personal data
+	if _, ok := r.actions[Emails]; ok && emailColumn.MatchString(column) {
+		return r.apply(Emails, s), true
+	}
+	if _, ok := r.actions[Names]; ok && personColumn.MatchString(column) {
+		return r.apply(Names, s), true
+	}
+
+	// personal data within free text, like commit messages
+	var redacted = r.replace(Emails, emailPattern, s)
+	redacted = r.replace(IPs, ipPattern, redacted)
+	if redacted == s {
+		return v, false
+	}
+	return redacted, true
+}
+
+// txBeginner is implemented by *sql.DB and *sql.Conn
+type txBeginner interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// Table redacts the rows of table in db in place
+func (r *Redactor) Table(ctx context.Context, db txBeginner, table string) error {
+	if !r.Enabled() {
+		return nil
+	}
+
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT rowid, * FROM %q", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	columns = columns[1:]
+
+	type update struct {
+		rowid  int64
+		values []interface{}
+	}
+
+	// updates are collected before being applied, as the table can't be written while it's being read
+	var updates []update
+	for rows.Next() {
+		var rowid int64
+		var values = make([]interface{}, len(columns))
+		var dest = []interface{}{&rowid}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return err
+		}
+
+		var changed bool
+		for i, v := range values {
+			if redacted, ok := r.Value(columns[i], v); ok {
+				values[i], changed = redacted, true
+			}
+		}
+		if changed {
+			updates = append(updates, update{rowid, values})
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	_ = rows.Close()
+
+	var set []string
+	for _, column := range columns {
+		set = append(set, fmt.Sprintf("%q = ?", column))
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("UPDATE %q SET %s WHERE rowid = ?", table, strings.Join(set, ", ")))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, u := range updates {
+		if _, err = stmt.ExecContext(ctx, append(u.values, u.rowid)...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
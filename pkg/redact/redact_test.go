@@ -0,0 +1,79 @@
+package redact
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestValue(t *testing.T) {
+	r, err := Parse([]string{"emails", "names:drop", "ips:drop"}, "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	email, ok := r.Value("author_email", "Jane@example.com")
+	if !ok || email == "Jane@example.com" || len(email.(string)) != 16 {
+		t.Fatalf("expected the email to be hashed, got: %v", email)
+	}
+	if again, _ := r.Value("committer_email", "jane@example.com"); again != email {
+		t.Fatalf("expected the same email to hash the same, got %v and %v", email, again)
+	}
+
+	if name, ok := r.Value("author_name", "Jane Doe"); !ok || name != nil {
+		t.Fatalf("expected the name to be dropped, got: %v", name)
+	}
+	if repo, ok := r.Value("name", "askgit"); ok {
+		t.Fatalf("expected a non-person name column to be kept, got: %v", repo)
+	}
+
+	msg, ok := r.Value("message", []byte("Signed-off-by: Jane <jane@example.com>\nfrom 10.0.0.1"))
+	if !ok || strings.Contains(msg.(string), "jane@") || !strings.Contains(msg.(string), "from [redacted]") {
+		t.Fatalf("unexpected redacted message: %q", msg)
+	}
+	if !strings.Contains(msg.(string), email.(string)) {
+		t.Fatalf("expected the email within text to be hashed like the email column, got: %q", msg)
+	}
+
+	if n, ok := r.Value("additions", int64(3)); ok || n != int64(3) {
+		t.Fatalf("expected numbers to be kept, got: %v", n)
+	}
+}
+
+func TestParse(t *testing.T) {
+	if _, err := Parse([]string{"phones"}, ""); err == nil {
+		t.Fatal("expected an unknown kind to be rejected")
+	}
+	if _, err := Parse([]string{"emails:mask"}, ""); err == nil {
+		t.Fatal("expected an unknown action to be rejected")
+	}
+	if r, _ := Parse(nil, ""); r.Enabled() {
+		t.Fatal("expected a redactor without rules to be disabled")
+	}
+}
+
+func TestTable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT rowid, \* FROM "commits"`).WillReturnRows(sqlmock.NewRows([]string{"rowid", "hash", "author_email"}).
+		AddRow(1, "abc", "jane@example.com").
+		AddRow(2, "def", nil))
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`UPDATE "commits" SET "hash" = \?, "author_email" = \? WHERE rowid = \?`).
+		ExpectExec().WithArgs("abc", sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	r, _ := Parse([]string{"emails"}, "salt")
+	if err = r.Table(context.Background(), db, "commits"); err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
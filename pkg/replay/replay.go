@@ -0,0 +1,130 @@
+// Package replay records the API responses of a query into a fixture bundle and serves them back,
+// so that demos, CI tests and bug reports can be reproduced without API tokens or network access.
+// Bundles are go-vcr [https://github.com/dnaeon/go-vcr] cassettes, the same format as the fixtures of askgit's own tests.
+package replay
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dnaeon/go-vcr/v2/cassette"
+	"github.com/dnaeon/go-vcr/v2/recorder"
+)
+
+// Mode is the mode a bundle is opened in
+type Mode = recorder.Mode
+
+const (
+	// Record makes requests with the underlying transport and captures them into the bundle
+	Record = recorder.ModeRecording
+
+	// Replay serves requests from the bundle, and fails those that were not recorded
+	Replay = recorder.ModeReplaying
+)
+
+// Open returns a transport recording to, or replaying from, the bundle at path.
+// In record mode the bundle is written out when Stop is called on the returned recorder.
+// Credentials, in the Authorization header or in the key, access_token, token and api.token parameters
+// of the query or of a form body (as Phabricator's Conduit API takes its token), are never written to the bundle.
+func Open(path string, mode Mode, base http.RoundTripper) (*recorder.Recorder, error) {
+	r, err := recorder.NewAsMode(strings.TrimSuffix(path, ".yaml"), mode, base)
+	if err != nil {
+		return nil, err
+	}
+
+	r.SkipRequestLatency = true
+	r.SetMatcher(MatchBody)
+	r.AddSaveFilter(func(i *cassette.Interaction) error {
+		delete(i.Request.Headers, "Authorization")
+		i.Request.URL = scrubURL(i.Request.URL)
+		for _, param := range credentialParams {
+			delete(i.Request.Form, param)
+		}
+		if isForm(i.Request.Headers) {
+			i.Request.Body = scrubForm(i.Request.Body)
+		}
+		return nil
+	})
+
+	return r, nil
+}
+
+// credentialParams are the query (or form) parameters APIs take credentials in, rather than the Authorization header
+var credentialParams = []string{"key", "access_token", "token", "api.token"}
+
+// scrubURL returns rawurl without its credentialParams. URLs without any are returned as they are,
+// rather than with their query re-encoded.
+func scrubURL(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return rawurl
+	}
+
+	if !hasCredentials(u.RawQuery) {
+		return rawurl
+	}
+
+	u.RawQuery = scrubQuery(u.RawQuery)
+	return u.String()
+}
+
+// scrubForm returns the url-encoded form body without its credentialParams, as scrubURL does a query
+func scrubForm(body string) string {
+	if !hasCredentials(body) {
+		return body
+	}
+	return scrubQuery(body)
+}
+
+// scrubQuery returns the url-encoded query re-encoded without its credentialParams (and any malformed pair)
+func scrubQuery(raw string) string {
+	query, _ := url.ParseQuery(raw)
+	for _, param := range credentialParams {
+		query.Del(param)
+	}
+	return query.Encode()
+}
+
+// hasCredentials reports whether the url-encoded query has any of the credentialParams
+func hasCredentials(raw string) bool {
+	query, _ := url.ParseQuery(raw)
+	for _, param := range credentialParams {
+		if _, ok := query[param]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isForm reports whether the body of a request with the given headers is a url-encoded form
+func isForm(headers http.Header) bool {
+	return strings.HasPrefix(headers.Get("Content-Type"), "application/x-www-form-urlencoded")
+}
+
+// MatchBody matches requests on their method, URL and body, but for their credentialParams.
+// All GraphQL queries are sent to the same endpoint, so the body is what tells them apart.
+func MatchBody(r *http.Request, i cassette.Request) bool {
+	if r.Method != i.Method || scrubURL(r.URL.String()) != scrubURL(i.URL) {
+		return false
+	}
+
+	if r.Body == nil {
+		return i.Body == ""
+	}
+
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return false
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+	// the bodies of forms are recorded without their credentials, and compared re-encoded,
+	// so that they match whether or not they had any
+	if isForm(r.Header) {
+		return scrubQuery(string(b)) == scrubQuery(i.Body)
+	}
+	return string(b) == i.Body
+}
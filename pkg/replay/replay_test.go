@@ -0,0 +1,145 @@
+package replay
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dnaeon/go-vcr/v2/cassette"
+)
+
+func TestRecordReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
+			_, _ = w.Write([]byte("echo: " + r.PostFormValue("params[ids][0]")))
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		_, _ = w.Write([]byte("echo: " + string(b)))
+	}))
+
+	dir, err := ioutil.TempDir("", "askgit-replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var bundle = filepath.Join(dir, "bundle.yaml")
+	// requests are authenticated with a header and with a query parameter, as some APIs expect
+	var do = func(rt http.RoundTripper, req *http.Request) (string, error) {
+		res, err := (&http.Client{Transport: rt}).Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		b, err := ioutil.ReadAll(res.Body)
+		return string(b), err
+	}
+	var post = func(rt http.RoundTripper, body, token string) (string, error) {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/?page=2&access_token="+token, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		return do(rt, req)
+	}
+	// or with a form parameter, as Phabricator's Conduit API expects
+	var postForm = func(rt http.RoundTripper, method, token string) (string, error) {
+		var form = url.Values{"api.token": {token}, "params[ids][0]": {"1"}}
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/"+method, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return do(rt, req)
+	}
+
+	r, err := Open(bundle, Record, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, body := range []string{"a", "b"} {
+		if _, err = post(r, body, "secret"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = postForm(r, "differential.revision.search", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the bundle is replayed with the server gone
+	srv.Close()
+
+	contents, err := ioutil.ReadFile(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(contents), "secret") {
+		t.Fatal("expected credentials to be stripped from the bundle")
+	}
+
+	if r, err = Open(bundle, Replay, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer r.Stop()
+
+	// the requests of the bundle are matched whatever credentials they're made with
+	if got, err := post(r, "b", "other"); err != nil || got != "echo: b" {
+		t.Fatalf("unexpected replayed response %q: %v", got, err)
+	}
+	if _, err = post(r, "c", "other"); err == nil {
+		t.Fatal("expected a request missing from the bundle to fail")
+	}
+	if got, err := postForm(r, "differential.revision.search", "other"); err != nil || got != "echo: 1" {
+		t.Fatalf("unexpected replayed response %q: %v", got, err)
+	}
+}
+
+func TestScrubURL(t *testing.T) {
+	var cases = []struct{ url, want string }{
+		{"https://api.example.com/v1/items?page=2", "https://api.example.com/v1/items?page=2"},
+		{"https://api.example.com/v1/items?z=1&a=2", "https://api.example.com/v1/items?z=1&a=2"},
+		{"https://api.example.com/v1/items?key=secret&page=2", "https://api.example.com/v1/items?page=2"},
+		{"https://api.example.com/v1/items?access_token=secret&token=secret", "https://api.example.com/v1/items"},
+	}
+	for _, c := range cases {
+		if got := scrubURL(c.url); got != c.want {
+			t.Fatalf("scrubURL(%q): expected %q, got %q", c.url, c.want, got)
+		}
+	}
+}
+
+func TestMatchBody(t *testing.T) {
+	var recorded = cassette.Request{Method: http.MethodGet, URL: "https://api.example.com/v1/items?page=2"}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/v1/items?page=2&key=secret", nil)
+	if !MatchBody(req, recorded) {
+		t.Fatal("expected a request to match regardless of its credentials")
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "https://api.example.com/v1/items?page=3&key=secret", nil)
+	if MatchBody(req, recorded) {
+		t.Fatal("expected a request for another page not to match")
+	}
+
+	// forms are matched on their body, but for their credentials
+	recorded = cassette.Request{Method: http.MethodPost, URL: "https://phabricator.example.com/api/user.whoami", Body: "params=%7B%7D"}
+	for body, want := range map[string]bool{"api.token=secret&params=%7B%7D": true, "params=%7B%7D": true, "api.token=secret&params=%7B%22a%22%7D": false} {
+		req, _ = http.NewRequest(http.MethodPost, recorded.URL, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if MatchBody(req, recorded) != want {
+			t.Fatalf("expected the form %q to match: %v", body, want)
+		}
+	}
+}
+
+func TestScrubForm(t *testing.T) {
+	if got := scrubForm("api.token=secret&params=%7B%7D"); got != "params=%7B%7D" {
+		t.Fatalf("expected the token to be scrubbed, got: %q", got)
+	}
+	if got := scrubForm("z=1&a=2"); got != "z=1&a=2" {
+		t.Fatalf("expected a form without credentials to be left as it is, got: %q", got)
+	}
+}
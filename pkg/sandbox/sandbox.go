@@ -0,0 +1,89 @@
+// Package sandbox restricts the queries askgit executes on behalf of semi-trusted clients,
+// such as the ones connecting to the serve endpoints.
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/query"
+)
+
+// DSN is the data source name of a read-only in-memory database.
+// The query_only pragma is applied to every connection opened by the database/sql pool.
+const DSN = ":memory:?_query_only=on"
+
+// DefaultBannedFunctions are functions that provide access to the host or the sqlite runtime,
+// the tables reading local files or fetching arbitrary URLs, and the function binding a connection to the query
+// its API calls are counted for (see audit.Conn)
+var DefaultBannedFunctions = []string{
+	"load_extension", "readfile", "writefile", "edit", "fts3_tokenizer", "askgit_bind_query",
+	"coverage_report", "read_csv", "read_json", "http_json", "fs_walk", "zip_entries", "tar_entries", "mbox_patches",
+	"phabricator_revisions", "phabricator_reviewers", "feed", "repos",
+}
+
+// statements that are not prevented by the query_only pragma, or that have no place in a read-only session
+var bannedKeywords = map[string]bool{
+	"insert": true, "update": true, "delete": true, "create": true, "drop": true, "alter": true,
+	"attach": true, "detach": true, "pragma": true, "vacuum": true, "reindex": true, "analyze": true,
+}
+
+// Policy describes the restrictions applied to queries
+type Policy struct {
+	// MaxRows is the maximum number of rows a query may return, 0 means unlimited
+	MaxRows int
+
+	// Timeout is the maximum duration of a query, 0 means unlimited
+	Timeout time.Duration
+
+	// BannedFunctions lists the scalar and table-valued functions that cannot be called, nor referenced at all
+	BannedFunctions []string
+}
+
+// Check returns an error if sql is not allowed by the policy.
+// Only a single SELECT (or VALUES) statement, optionally preceded by a WITH clause, is allowed.
+func (p *Policy) Check(sql string) error {
+	statements := query.Split(sql)
+	if len(statements) != 1 {
+		return fmt.Errorf("expected exactly one statement, got %d", len(statements))
+	}
+
+	idents := query.Identifiers(statements[0])
+	if len(idents) == 0 {
+		return fmt.Errorf("only SELECT statements are allowed")
+	}
+
+	switch idents[0] {
+	case "select", "with", "values":
+	default:
+		return fmt.Errorf("only SELECT statements are allowed, got %s", strings.ToUpper(idents[0]))
+	}
+
+	for _, ident := range idents {
+		if bannedKeywords[ident] {
+			return fmt.Errorf("only SELECT statements are allowed, found %s", strings.ToUpper(ident))
+		}
+	}
+
+	// every identifier is matched, not only those called with parentheses, as table-valued functions
+	// can also be called with their arguments as constraints on hidden columns (FROM fn WHERE arg = ...)
+	for _, ident := range idents {
+		for _, banned := range p.BannedFunctions {
+			if strings.EqualFold(ident, banned) {
+				return fmt.Errorf("function %s is not allowed", ident)
+			}
+		}
+	}
+
+	return nil
+}
+
+// WithTimeout returns a copy of ctx bounded by the policy's timeout
+func (p *Policy) WithTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, p.Timeout)
+}
@@ -0,0 +1,38 @@
+package sandbox
+
+import "testing"
+
+func TestCheck(t *testing.T) {
+	var policy = &Policy{BannedFunctions: DefaultBannedFunctions}
+
+	var allowed = []string{
+		"SELECT * FROM commits",
+		"  -- a comment\nWITH c AS (SELECT * FROM commits) SELECT count(*) FROM c;",
+		"SELECT replace(message, 'update', 'delete') FROM commits",
+		"VALUES (1)",
+	}
+	for _, sql := range allowed {
+		if err := policy.Check(sql); err != nil {
+			t.Fatalf("expected %q to be allowed, got: %v", sql, err)
+		}
+	}
+
+	var rejected = []string{
+		"",
+		"SELECT 1; SELECT 2",
+		"DELETE FROM t",
+		"ATTACH DATABASE 'x.db' AS x",
+		"WITH c AS (SELECT 1) INSERT INTO t SELECT * FROM c",
+		"SELECT load_extension('evil.so')",
+		"SELECT * FROM fts3_tokenizer WHERE name = 'x'",
+		"SELECT * FROM \"FTS3_TOKENIZER\"",
+		"PRAGMA query_only = off",
+		"SELECT askgit_bind_query(1)",
+		"SELECT * FROM feed('http://169.254.169.254/latest/meta-data')",
+	}
+	for _, sql := range rejected {
+		if err := policy.Check(sql); err == nil {
+			t.Fatalf("expected %q to be rejected", sql)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Package seal encrypts files at rest, such as the cached API responses and exported databases,
+// which may hold sensitive data like issue bodies. Data is sealed with AES-256-GCM, under a key
+// supplied by the user, and is prefixed with a header identifying it as sealed.
+package seal
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// KeySize is the size of a key, in bytes
+const KeySize = 32
+
+// header prefixes every sealed file, so that sealed and plain files can be told apart
+var header = []byte("askgit-sealed-v1\n")
+
+// ErrNotSealed is returned when opening data without the sealed header
+var ErrNotSealed = errors.New("data is not sealed")
+
+// ParseKey decodes a key given as 64 hex characters or as base64, which is what `openssl rand -hex 32`
+// and `openssl rand -base64 32` generate. Passphrases aren't accepted, as they're easily guessed.
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if key, err := hex.DecodeString(s); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(s); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("expected a %d byte key, encoded as hex or base64 (generate one with `openssl rand -hex %d`)", KeySize, KeySize)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal encrypts plaintext under key, with a random nonce
+func Seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var out = make([]byte, len(header)+gcm.NonceSize(), len(header)+gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	copy(out, header)
+	var nonce = out[len(header):]
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	// the header is authenticated along with the data
+	return gcm.Seal(out, nonce, plaintext, header), nil
+}
+
+// Open decrypts data sealed under key. It fails with ErrNotSealed if data isn't sealed,
+// and with an error if data was sealed under another key or was tampered with.
+func Open(key, data []byte) ([]byte, error) {
+	if !IsSealed(data) {
+		return nil, ErrNotSealed
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data = data[len(header):]
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("sealed data is truncated")
+	}
+
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], header)
+	if err != nil {
+		return nil, errors.New("failed to decrypt sealed data, it was sealed with another key or was modified")
+	}
+	return plaintext, nil
+}
+
+// IsSealed reports whether data starts with the sealed header
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, header)
+}
+
+// SealFile encrypts the file at src under key, and writes it to dst with owner-only permissions.
+// dst is replaced atomically, so it's never left half written.
+func SealFile(key []byte, src, dst string) error {
+	b, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	if b, err = Seal(key, b); err != nil {
+		return err
+	}
+	return writeFile(dst, b)
+}
+
+// OpenFile decrypts the sealed file at src, and writes it to dst with owner-only permissions
+func OpenFile(key []byte, src, dst string) error {
+	b, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	if b, err = Open(key, b); err != nil {
+		return fmt.Errorf("%s: %v", src, err)
+	}
+	return writeFile(dst, b)
+}
+
+// writeFile writes b to a temporary file next to path, and renames it over path
+func writeFile(path string, b []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(b); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
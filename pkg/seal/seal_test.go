@@ -0,0 +1,77 @@
+package seal
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSeal(t *testing.T) {
+	key, err := ParseKey(strings.Repeat("ab", KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := Seal(key, []byte("issue body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealed(sealed) || bytes.Contains(sealed, []byte("issue body")) {
+		t.Fatalf("expected sealed data to be encrypted: %q", sealed)
+	}
+
+	if plain, err := Open(key, sealed); err != nil || string(plain) != "issue body" {
+		t.Fatalf("unexpected opened data %q: %v", plain, err)
+	}
+
+	other, _ := ParseKey(strings.Repeat("cd", KeySize))
+	if _, err = Open(other, sealed); err == nil {
+		t.Fatal("expected opening with another key to fail")
+	}
+
+	sealed[len(sealed)-1] ^= 1
+	if _, err = Open(key, sealed); err == nil {
+		t.Fatal("expected opening modified data to fail")
+	}
+
+	if _, err = Open(key, []byte("plain")); err != ErrNotSealed {
+		t.Fatalf("expected ErrNotSealed, got: %v", err)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	if _, err := ParseKey("q83vEjRWeJCrze8SNFZ4kKvN7xI0VniQq83vEjRWeJA="); err != nil {
+		t.Fatalf("expected a base64 key to be accepted: %v", err)
+	}
+	if _, err := ParseKey("correct horse battery staple"); err == nil {
+		t.Fatal("expected a passphrase to be rejected")
+	}
+}
+
+func TestSealFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "askgit-seal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var key = bytes.Repeat([]byte{1}, KeySize)
+	var plain, sealed, opened = filepath.Join(dir, "plain"), filepath.Join(dir, "sealed"), filepath.Join(dir, "opened")
+	if err = ioutil.WriteFile(plain, []byte("audit log"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = SealFile(key, plain, sealed); err != nil {
+		t.Fatal(err)
+	}
+	if err = OpenFile(key, sealed, opened); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, err := ioutil.ReadFile(opened); err != nil || string(b) != "audit log" {
+		t.Fatalf("unexpected opened file %q: %v", b, err)
+	}
+}
@@ -0,0 +1,138 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSSecretsManager reads a secret from AWS Secrets Manager. Credentials are read from the standard
+// $AWS_ACCESS_KEY_ID, $AWS_SECRET_ACCESS_KEY and $AWS_SESSION_TOKEN environment variables.
+type AWSSecretsManager struct {
+	SecretID string // name or ARN of the secret
+	Key      string // if set, the secret is a JSON object and the token is the value of this key
+	Region   string // region of the secret, e.g. us-east-1
+
+	// Endpoint overrides the regional service endpoint
+	Endpoint string
+
+	Client *http.Client
+}
+
+// Fetch implements Fetcher
+func (a *AWSSecretsManager) Fetch(ctx context.Context) (string, error) {
+	if a.Region == "" {
+		return "", fmt.Errorf("aws region is not set")
+	}
+
+	var endpoint = a.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", a.Region)
+	}
+
+	body, err := json.Marshal(map[string]string{"SecretId": a.SecretID})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	var creds = awsCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return "", fmt.Errorf("aws credentials are not set")
+	}
+	creds.sign(req, body, a.Region, "secretsmanager", time.Now().UTC())
+
+	var res struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err = doJSON(a.Client, req, &res); err != nil {
+		return "", fmt.Errorf("failed to read aws secret %s: %v", a.SecretID, err)
+	}
+	return jsonKey(res.SecretString, a.Key)
+}
+
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// sign adds an AWS Signature Version 4 [https://docs.aws.amazon.com/general/latest/gr/sigv4_signing.html]
+// Authorization header to req
+func (c *awsCredentials) sign(req *http.Request, body []byte, region, service string, now time.Time) {
+	var amzDate, day = now.Format("20060102T150405Z"), now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+
+	// canonical headers always include the host, along with every header set above
+	var headers = map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(req.Header.Get(name))
+	}
+
+	var names = make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	var signedHeaders = strings.Join(names, ";")
+
+	var path = req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	var canonicalRequest = strings.Join([]string{
+		req.Method, path, req.URL.RawQuery, canonicalHeaders.String(), signedHeaders, sha256Hex(body),
+	}, "\n")
+
+	var scope = strings.Join([]string{day, region, service, "aws4_request"}, "/")
+	var stringToSign = strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	var key = hmacSHA256([]byte("AWS4"+c.SecretAccessKey), day)
+	for _, part := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	var signature = hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,86 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+const (
+	gcpSecretManagerEndpoint = "https://secretmanager.googleapis.com"
+	gcpMetadataTokenURL      = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// GCPSecretManager reads a secret version from GCP Secret Manager. Requests are authenticated with
+// $GOOGLE_OAUTH_ACCESS_TOKEN if set, or else with the service account of the instance, read from the metadata server.
+type GCPSecretManager struct {
+	Name string // resource name of the secret version, e.g. projects/p/secrets/s/versions/latest
+	Key  string // if set, the secret is a JSON object and the token is the value of this key
+
+	// Endpoint and MetadataURL override the service endpoint and the metadata server token URL
+	Endpoint    string
+	MetadataURL string
+
+	Client *http.Client
+}
+
+// Fetch implements Fetcher
+func (g *GCPSecretManager) Fetch(ctx context.Context) (string, error) {
+	accessToken, err := g.accessToken(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to authenticate with gcp: %v", err)
+	}
+
+	var endpoint = g.Endpoint
+	if endpoint == "" {
+		endpoint = gcpSecretManagerEndpoint
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/%s:access", endpoint, g.Name), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	var res struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err = doJSON(g.Client, req, &res); err != nil {
+		return "", fmt.Errorf("failed to read gcp secret %s: %v", g.Name, err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(res.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode gcp secret %s: %v", g.Name, err)
+	}
+	return jsonKey(string(data), g.Key)
+}
+
+func (g *GCPSecretManager) accessToken(ctx context.Context) (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	var url = g.MetadataURL
+	if url == "" {
+		url = gcpMetadataTokenURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	var res struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err = doJSON(g.Client, req, &res); err != nil {
+		return "", err
+	}
+	return res.AccessToken, nil
+}
@@ -0,0 +1,90 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// GHCLI reads the token the GitHub CLI (gh) was authenticated with by `gh auth login`: from its hosts.yml,
+// where older versions of gh (and those told not to use the keyring) store it, or else from `gh auth token`,
+// which reads it from the keyring it's stored in by default.
+type GHCLI struct {
+	Host      string // host the token is for, github.com if empty
+	ConfigDir string // configuration directory of gh, found as gh does if empty
+}
+
+// ghConfigDir returns the configuration directory of gh: $GH_CONFIG_DIR, $XDG_CONFIG_HOME/gh, %AppData%/GitHub CLI
+// on Windows, or ~/.config/gh
+func ghConfigDir() string {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh")
+	}
+	if dir := os.Getenv("AppData"); runtime.GOOS == "windows" && dir != "" {
+		return filepath.Join(dir, "GitHub CLI")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "gh")
+}
+
+// ghHost is the authentication of gh to a host, in its hosts.yml
+type ghHost struct {
+	User       string `json:"user"`
+	OAuthToken string `json:"oauth_token"`
+}
+
+// Fetch implements Fetcher
+func (g *GHCLI) Fetch(ctx context.Context) (string, error) {
+	var host = g.Host
+	if host == "" {
+		host = "github.com"
+	}
+	var dir = g.ConfigDir
+	if dir == "" {
+		dir = ghConfigDir()
+	}
+
+	if data, err := ioutil.ReadFile(filepath.Join(dir, "hosts.yml")); err == nil {
+		var hosts map[string]*ghHost
+		if err := yaml.Unmarshal(data, &hosts); err != nil {
+			return "", fmt.Errorf("failed to read the gh hosts in %s: %v", dir, err)
+		}
+		if h := hosts[host]; h != nil && h.OAuthToken != "" {
+			return h.OAuthToken, nil
+		} else if h == nil {
+			return "", fmt.Errorf("gh isn't authenticated to %s, see gh auth login", host)
+		}
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	var stderr bytes.Buffer
+	var cmd = exec.CommandContext(ctx, "gh", "auth", "token", "--hostname", host)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var execErr *exec.Error
+	switch {
+	case errors.As(err, &execErr):
+		return "", fmt.Errorf("gh isn't installed, or authenticated to %s", host)
+	case err != nil:
+		return "", fmt.Errorf("failed to read the gh token of %s: %v: %s", host, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var token = strings.TrimSpace(string(out))
+	if token == "" {
+		return "", fmt.Errorf("gh isn't authenticated to %s, see gh auth login", host)
+	}
+	return token, nil
+}
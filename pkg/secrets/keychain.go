@@ -0,0 +1,40 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Keychain reads a secret from the operating system's keychain: the macOS login keychain through `security`,
+// or the Secret Service (GNOME Keyring, KWallet) through `secret-tool` elsewhere.
+type Keychain struct {
+	Service string // service the secret is stored under
+	Account string // account the secret is stored under
+}
+
+// Fetch implements Fetcher
+func (k *Keychain) Fetch(ctx context.Context) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.CommandContext(ctx, "security", "find-generic-password", "-s", k.Service, "-a", k.Account, "-w")
+	} else {
+		cmd = exec.CommandContext(ctx, "secret-tool", "lookup", "service", k.Service, "account", k.Account)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s/%s from the keychain: %v: %s", k.Service, k.Account, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var secret = strings.TrimRight(string(out), "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("no secret %s/%s in the keychain", k.Service, k.Account)
+	}
+	return secret, nil
+}
@@ -0,0 +1,207 @@
+// Package secrets provides GitHub token sources backed by secret stores, so that tokens don't need to
+// live in environment variables in production. The same sources supply other secrets, such as encryption keys.
+// Sources are described by URIs:
+//
+//	vault://<mount>/<path>#<field>                  HashiCorp Vault KV v2 ($VAULT_ADDR, $VAULT_TOKEN)
+//	awssm://<secret id>[#<json key>]                AWS Secrets Manager ($AWS_REGION, $AWS_ACCESS_KEY_ID, ...)
+//	gcpsm://projects/<p>/secrets/<s>[#<json key>]   GCP Secret Manager (latest version unless /versions/<v> is given)
+//	keychain://<service>/<account>                  the macOS keychain, or the Secret Service on Linux
+//	gh://[<host>]                                   the token of the GitHub CLI (see GHCLI)
+//	env://<variable>                                an environment variable
+//
+// Tokens are cached for a configurable duration, and re-read from the store whenever the API
+// rejects them with a 401, so that rotated tokens are picked up without a restart.
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// Fetcher reads a secret from a store
+type Fetcher interface {
+	Fetch(ctx context.Context) (string, error)
+}
+
+// FetcherFunc is an adapter type that adapts any function with compatible signature to a Fetcher
+type FetcherFunc func(ctx context.Context) (string, error)
+
+func (fn FetcherFunc) Fetch(ctx context.Context) (string, error) { return fn(ctx) }
+
+// Parse returns the Fetcher described by uri
+func Parse(uri string) (Fetcher, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret uri %q: %v", uri, err)
+	}
+
+	// the host is the first segment of the path in all supported schemes
+	var path = strings.Trim(u.Host+u.Path, "/")
+	switch u.Scheme {
+	case "vault":
+		i := strings.Index(path, "/")
+		if i < 0 || u.Fragment == "" {
+			return nil, fmt.Errorf("expected a vault uri of the form vault://<mount>/<path>#<field>, got %q", uri)
+		}
+		return &Vault{Address: os.Getenv("VAULT_ADDR"), Token: os.Getenv("VAULT_TOKEN"), Mount: path[:i], Path: path[i+1:], Field: u.Fragment}, nil
+	case "awssm":
+		return &AWSSecretsManager{SecretID: path, Key: u.Fragment, Region: os.Getenv("AWS_REGION")}, nil
+	case "gcpsm":
+		if !strings.Contains(path, "/versions/") {
+			path += "/versions/latest"
+		}
+		return &GCPSecretManager{Name: path, Key: u.Fragment}, nil
+	case "keychain":
+		i := strings.Index(path, "/")
+		if i < 0 {
+			return nil, fmt.Errorf("expected a keychain uri of the form keychain://<service>/<account>, got %q", uri)
+		}
+		return &Keychain{Service: path[:i], Account: path[i+1:]}, nil
+	case "gh":
+		return &GHCLI{Host: path}, nil
+	case "env":
+		return FetcherFunc(func(context.Context) (string, error) {
+			if v := os.Getenv(path); v != "" {
+				return v, nil
+			}
+			return "", fmt.Errorf("environment variable %s is not set", path)
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported secret uri scheme %q", u.Scheme)
+	}
+}
+
+// Cached is an oauth2.TokenSource that caches the secret read by a Fetcher for TTL
+type Cached struct {
+	Fetcher Fetcher
+
+	// TTL is how long a secret is cached for, 0 means until it's invalidated
+	TTL time.Duration
+
+	mu      sync.Mutex
+	token   string
+	fetched time.Time
+}
+
+// NewCached returns a token source caching the secrets read by f for ttl
+func NewCached(f Fetcher, ttl time.Duration) *Cached {
+	return &Cached{Fetcher: f, TTL: ttl}
+}
+
+// Token implements oauth2.TokenSource
+func (c *Cached) Token() (*oauth2.Token, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token == "" || (c.TTL > 0 && time.Since(c.fetched) > c.TTL) {
+		token, err := c.Fetcher.Fetch(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token: %v", err)
+		}
+		c.token, c.fetched = token, time.Now()
+	}
+
+	return &oauth2.Token{AccessToken: c.token, TokenType: "Bearer"}, nil
+}
+
+// Invalidate drops the cached secret, so that it's read again on next use
+func (c *Cached) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = ""
+}
+
+// Transport is an http.RoundTripper that retries requests rejected with a 401 once,
+// with a freshly read token. It's meant to be used as the base transport of an oauth2 client.
+type Transport struct {
+	Source *Cached
+
+	// Base is the transport used to make requests, http.DefaultTransport if nil
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var base = t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	// buffer the body so that the request can be replayed
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	res, err := base.RoundTrip(req)
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+
+	t.Source.Invalidate()
+	token, err := t.Source.Token()
+	if err != nil {
+		return res, nil // report the original 401 rather than the failure to refresh
+	}
+	_ = res.Body.Close()
+
+	retry := req.Clone(req.Context())
+	if body != nil {
+		retry.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	token.SetAuthHeader(retry)
+	return base.RoundTrip(retry)
+}
+
+// jsonKey returns the value of key in the JSON object secret, or secret itself if key is empty
+func jsonKey(secret, key string) (string, error) {
+	if key == "" {
+		return secret, nil
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &values); err != nil {
+		return "", fmt.Errorf("expected secret to be a JSON object: %v", err)
+	}
+
+	v, ok := values[key].(string)
+	if !ok {
+		return "", fmt.Errorf("secret has no string field %q", key)
+	}
+	return v, nil
+}
+
+// doJSON sends req and decodes the JSON response into v
+func doJSON(client *http.Client, req *http.Request, v interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		b, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("unexpected status %s: %s", res.Status, strings.TrimSpace(string(b)))
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
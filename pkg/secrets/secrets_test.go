@@ -0,0 +1,136 @@
+package secrets
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestParse(t *testing.T) {
+	f, err := Parse("vault://secret/askgit/github#token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := f.(*Vault); v.Mount != "secret" || v.Path != "askgit/github" || v.Field != "token" {
+		t.Fatalf("unexpected vault source: %+v", v)
+	}
+
+	if f, err = Parse("gcpsm://projects/p/secrets/github"); err != nil {
+		t.Fatal(err)
+	}
+	if g := f.(*GCPSecretManager); g.Name != "projects/p/secrets/github/versions/latest" {
+		t.Fatalf("unexpected gcp secret name: %s", g.Name)
+	}
+
+	if f, err = Parse("keychain://askgit/encryption-key"); err != nil {
+		t.Fatal(err)
+	}
+	if k := f.(*Keychain); k.Service != "askgit" || k.Account != "encryption-key" {
+		t.Fatalf("unexpected keychain source: %+v", k)
+	}
+
+	if f, err = Parse("gh://github.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if g := f.(*GHCLI); g.Host != "github.example.com" {
+		t.Fatalf("unexpected gh source: %+v", g)
+	}
+
+	if _, err = Parse("ftp://secret"); err == nil {
+		t.Fatal("expected an error for an unsupported scheme")
+	}
+}
+
+func TestVault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/askgit" || r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"data": {"token": "ghp_secret"}}}`))
+	}))
+	defer srv.Close()
+
+	var v = &Vault{Address: srv.URL, Token: "root", Mount: "secret", Path: "askgit", Field: "token"}
+	token, err := v.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "ghp_secret" {
+		t.Fatalf("expected ghp_secret, got: %s", token)
+	}
+}
+
+func TestGHCLI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "askgit-gh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var hosts = "github.com:\n    user: octocat\n    oauth_token: gho_secret\n    git_protocol: https\n"
+	if err = ioutil.WriteFile(filepath.Join(dir, "hosts.yml"), []byte(hosts), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := (&GHCLI{ConfigDir: dir}).Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "gho_secret" {
+		t.Fatalf("expected gho_secret, got: %s", token)
+	}
+
+	if _, err = (&GHCLI{Host: "github.example.com", ConfigDir: dir}).Fetch(context.Background()); err == nil {
+		t.Fatal("expected an error for a host gh isn't authenticated to")
+	}
+}
+
+func TestTransportRetriesOn401(t *testing.T) {
+	// the first token handed out is stale, the second one is valid
+	var tokens = []string{"stale", "fresh"}
+	var source = NewCached(FetcherFunc(func(context.Context) (string, error) {
+		token := tokens[0]
+		tokens = tokens[1:]
+		return token, nil
+	}), time.Hour)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	var client = &http.Client{Transport: &oauth2.Transport{Source: source, Base: &Transport{Source: source}}}
+	res, err := client.Post(srv.URL, "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected request to succeed after re-reading the token, got: %s", res.Status)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// the get-vanilla case of the AWS Signature Version 4 test suite
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	var creds = &awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	creds.sign(req, nil, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	var want = "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("expected %s, got: %s", want, got)
+	}
+}
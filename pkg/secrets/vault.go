@@ -0,0 +1,48 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Vault reads a field of a secret stored in a HashiCorp Vault KV (version 2) secrets engine
+type Vault struct {
+	Address string // address of the Vault server, e.g. https://vault.example.com:8200
+	Token   string // Vault token used to authenticate
+	Mount   string // mount path of the secrets engine, e.g. secret
+	Path    string // path of the secret within the engine
+	Field   string // field of the secret holding the token
+
+	Client *http.Client
+}
+
+// Fetch implements Fetcher
+func (v *Vault) Fetch(ctx context.Context) (string, error) {
+	if v.Address == "" {
+		return "", fmt.Errorf("vault address is not set")
+	}
+
+	var url = fmt.Sprintf("%s/v1/%s/data/%s", strings.TrimRight(v.Address, "/"), v.Mount, v.Path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+
+	var res struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err = doJSON(v.Client, req, &res); err != nil {
+		return "", fmt.Errorf("failed to read vault secret %s/%s: %v", v.Mount, v.Path, err)
+	}
+
+	value, ok := res.Data.Data[v.Field].(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s/%s has no string field %q", v.Mount, v.Path, v.Field)
+	}
+	return value, nil
+}
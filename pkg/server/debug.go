@@ -0,0 +1,68 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+)
+
+// started is when the process started, to report its uptime
+var started = time.Now()
+
+// RuntimeMetrics are the metrics of the Go runtime served under /debug/runtime
+type RuntimeMetrics struct {
+	GoVersion     string  `json:"go_version"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+	Goroutines    int     `json:"goroutines"`
+	CPUs          int     `json:"cpus"`
+
+	HeapAlloc    uint64 `json:"heap_alloc_bytes"`
+	HeapInuse    uint64 `json:"heap_inuse_bytes"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	TotalAlloc   uint64 `json:"total_alloc_bytes"`
+	Sys          uint64 `json:"sys_bytes"`
+	Mallocs      uint64 `json:"mallocs"`
+	Frees        uint64 `json:"frees"`
+	NumGC        uint32 `json:"gc_count"`
+	PauseTotalNs uint64 `json:"gc_pause_total_ns"`
+	LastGC       int64  `json:"gc_last_unix_ns"`
+}
+
+// ReadRuntimeMetrics reads the current metrics of the Go runtime
+func ReadRuntimeMetrics() *RuntimeMetrics {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return &RuntimeMetrics{
+		GoVersion:     runtime.Version(),
+		UptimeSeconds: time.Since(started).Seconds(),
+		Goroutines:    runtime.NumGoroutine(),
+		CPUs:          runtime.NumCPU(),
+		HeapAlloc:     mem.HeapAlloc,
+		HeapInuse:     mem.HeapInuse,
+		HeapObjects:   mem.HeapObjects,
+		TotalAlloc:    mem.TotalAlloc,
+		Sys:           mem.Sys,
+		Mallocs:       mem.Mallocs,
+		Frees:         mem.Frees,
+		NumGC:         mem.NumGC,
+		PauseTotalNs:  mem.PauseTotalNs,
+		LastGC:        int64(mem.LastGC),
+	}
+}
+
+// handleDebug registers the profiles of net/http/pprof under /debug/pprof/, and the runtime metrics under /debug/runtime
+func handleDebug(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	mux.HandleFunc("/debug/runtime", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(ReadRuntimeMetrics())
+	})
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/askgitdev/askgit/pkg/server/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GRPC returns a gRPC server serving the Query service of pb, which runs queries as the query endpoint does,
+// behind the StreamInterceptors. The probes and debug endpoints are only served over HTTP.
+func (s *Server) GRPC(opts ...grpc.ServerOption) *grpc.Server {
+	var g = grpc.NewServer(append(opts, grpc.ChainStreamInterceptor(s.StreamInterceptors...))...)
+	pb.RegisterQueryServer(g, &queryService{s: s})
+	return g
+}
+
+type queryService struct {
+	pb.UnimplementedQueryServer
+	s *Server
+}
+
+func (q *queryService) ExecuteQuery(req *pb.QueryRequest, stream pb.Query_ExecuteQueryServer) error {
+	if req.Sql == "" {
+		return status.Error(codes.InvalidArgument, "sql is required")
+	}
+
+	var send = func(msg interface{}) error { return stream.Send(response(msg)) }
+	switch code, err := q.s.run(stream.Context(), &QueryRequest{SQL: req.Sql, BatchSize: int(req.BatchSize)}, send); code {
+	case 0:
+		return err
+	case http.StatusForbidden:
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+}
+
+// response returns the message of the Query service of a *Header, *Batch or *Trailer
+func response(msg interface{}) *pb.QueryResponse {
+	switch msg := msg.(type) {
+	case *Header:
+		return &pb.QueryResponse{Message: &pb.QueryResponse_Header{Header: &pb.Header{Columns: msg.Columns, Types: msg.Types}}}
+	case *Batch:
+		var rows = make([]*pb.Row, len(msg.Rows))
+		for i, row := range msg.Rows {
+			rows[i] = &pb.Row{Values: make([]*pb.Value, len(row))}
+			for j, v := range row {
+				rows[i].Values[j] = value(v)
+			}
+		}
+		return &pb.QueryResponse{Message: &pb.QueryResponse_Batch{Batch: &pb.Batch{Rows: rows}}}
+	case *Trailer:
+		var trailer = &pb.Trailer{Done: msg.Done, RowCount: int64(msg.RowCount), Truncated: msg.Truncated, Error: msg.Error}
+		return &pb.QueryResponse{Message: &pb.QueryResponse_Trailer{Trailer: trailer}}
+	}
+	panic(fmt.Sprintf("unexpected message %T", msg))
+}
+
+// value returns the Value of pb of a value scanned from a row, by its storage class
+func value(v interface{}) *pb.Value {
+	switch v := v.(type) {
+	case nil:
+		return &pb.Value{}
+	case int64:
+		return &pb.Value{Kind: &pb.Value_Integer{Integer: v}}
+	case bool:
+		var i int64
+		if v {
+			i = 1
+		}
+		return &pb.Value{Kind: &pb.Value_Integer{Integer: i}}
+	case float64:
+		return &pb.Value{Kind: &pb.Value_Real{Real: v}}
+	case string:
+		return &pb.Value{Kind: &pb.Value_Text{Text: v}}
+	case []byte:
+		return &pb.Value{Kind: &pb.Value_Blob{Blob: v}}
+	case time.Time:
+		return &pb.Value{Kind: &pb.Value_Text{Text: v.Format(time.RFC3339Nano)}}
+	default:
+		return &pb.Value{Kind: &pb.Value_Text{Text: fmt.Sprint(v)}}
+	}
+}
+
+// Authorization returns the authorization metadata of the gRPC call of ctx, such as "Bearer <token>"
+func Authorization(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// contextStream is a grpc.ServerStream whose context was replaced
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
+
+// WithContext returns ss with ctx as its context, as for interceptors passing what they authenticated on to the call
+func WithContext(ss grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return &contextStream{ServerStream: ss, ctx: ctx}
+}
+
+// BearerTokenStreamAuth returns a gRPC interceptor that rejects any call not carrying the given bearer token,
+// the counterpart of BearerTokenAuth
+func BearerTokenStreamAuth(token string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if subtle.ConstantTimeCompare([]byte(Authorization(ss.Context())), []byte("Bearer "+token)) != 1 {
+			return status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+		}
+		return handler(srv, ss)
+	}
+}
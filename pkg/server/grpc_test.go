@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/askgitdev/askgit/pkg/sandbox"
+	"github.com/askgitdev/askgit/pkg/server/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dialGRPC serves the gRPC service of s in memory, returning a client of it and the function stopping it
+func dialGRPC(t *testing.T, s *Server) (pb.QueryClient, func()) {
+	var lis = bufconn.Listen(1 << 20)
+	var g = s.GRPC()
+	go func() { _ = g.Serve(lis) }()
+
+	var dial = func(context.Context, string) (net.Conn, error) { return lis.Dial() }
+	conn, err := grpc.DialContext(context.Background(), "bufnet", grpc.WithContextDialer(dial), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pb.NewQueryClient(conn), func() { conn.Close(); g.Stop() }
+}
+
+// receive reads the messages of a streamed result set until its end, returning the error that ended it, if any
+func receive(stream pb.Query_ExecuteQueryClient) ([]*pb.QueryResponse, error) {
+	var messages []*pb.QueryResponse
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return messages, nil
+		}
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, msg)
+	}
+}
+
+func TestGRPCQuery(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+
+	mockRows := sqlmock.NewRows([]string{"id", "name"}).
+		AddRow(int64(1), "name 1").
+		AddRow(int64(2), nil).
+		AddRow(int64(3), []byte{0xff})
+
+	mock.ExpectQuery("select").WillReturnRows(mockRows)
+
+	client, stop := dialGRPC(t, &Server{DB: db})
+	defer stop()
+
+	stream, err := client.ExecuteQuery(context.Background(), &pb.QueryRequest{Sql: "select", BatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages, err := receive(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// header, two batches and the trailer
+	if len(messages) != 4 {
+		t.Fatalf("expected 4 messages, got: %d", len(messages))
+	}
+	if header := messages[0].GetHeader(); len(header.GetColumns()) != 2 {
+		t.Fatalf("expected a header of 2 columns, got: %v", messages[0])
+	}
+
+	var first, second = messages[1].GetBatch(), messages[2].GetBatch()
+	if len(first.GetRows()) != 2 || len(second.GetRows()) != 1 {
+		t.Fatalf("expected batches of 2 and 1 rows, got: %v %v", first, second)
+	}
+	if id, name := first.Rows[0].Values[0], first.Rows[0].Values[1]; id.GetInteger() != 1 || name.GetText() != "name 1" {
+		t.Fatalf("expected an integer and a text value, got: %v %v", id, name)
+	}
+	if null := first.Rows[1].Values[1]; null.GetKind() != nil {
+		t.Fatalf("expected a NULL value, got: %v", null)
+	}
+	if blob := second.Rows[0].Values[1]; len(blob.GetBlob()) != 1 {
+		t.Fatalf("expected a blob value, got: %v", blob)
+	}
+
+	if trailer := messages[3].GetTrailer(); !trailer.GetDone() || trailer.GetRowCount() != 3 || trailer.GetError() != "" {
+		t.Fatalf("unexpected trailer: %v", trailer)
+	}
+}
+
+func TestGRPCAuthAndSandbox(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	mock.ExpectQuery("SELECT id FROM t").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+
+	var s = &Server{DB: db, Sandbox: &sandbox.Policy{}, StreamInterceptors: []grpc.StreamServerInterceptor{BearerTokenStreamAuth("secret")}}
+	client, stop := dialGRPC(t, s)
+	defer stop()
+
+	var call = func(ctx context.Context, sql string) error {
+		stream, err := client.ExecuteQuery(ctx, &pb.QueryRequest{Sql: sql})
+		if err != nil {
+			return err
+		}
+		_, err = receive(stream)
+		return err
+	}
+
+	if err := call(context.Background(), "SELECT id FROM t"); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected a call without the token to be rejected, got: %v", err)
+	}
+
+	var ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	if err := call(ctx, "DELETE FROM t"); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected the sandbox to reject the statement, got: %v", err)
+	}
+	if err := call(ctx, "SELECT id FROM t"); err != nil {
+		t.Fatalf("expected the query to run, got: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: query.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// QueryClient is the client API for Query service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type QueryClient interface {
+	// ExecuteQuery runs a statement, streaming back a header, batches of rows and a trailer.
+	// Statements rejected before running fail the call, with PERMISSION_DENIED or INVALID_ARGUMENT,
+	// while the errors interrupting a result set are reported in its trailer.
+	ExecuteQuery(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (Query_ExecuteQueryClient, error)
+}
+
+type queryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewQueryClient(cc grpc.ClientConnInterface) QueryClient {
+	return &queryClient{cc}
+}
+
+func (c *queryClient) ExecuteQuery(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (Query_ExecuteQueryClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Query_ServiceDesc.Streams[0], "/askgit.v1.Query/ExecuteQuery", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &queryExecuteQueryClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Query_ExecuteQueryClient interface {
+	Recv() (*QueryResponse, error)
+	grpc.ClientStream
+}
+
+type queryExecuteQueryClient struct {
+	grpc.ClientStream
+}
+
+func (x *queryExecuteQueryClient) Recv() (*QueryResponse, error) {
+	m := new(QueryResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// QueryServer is the server API for Query service.
+// All implementations must embed UnimplementedQueryServer
+// for forward compatibility
+type QueryServer interface {
+	// ExecuteQuery runs a statement, streaming back a header, batches of rows and a trailer.
+	// Statements rejected before running fail the call, with PERMISSION_DENIED or INVALID_ARGUMENT,
+	// while the errors interrupting a result set are reported in its trailer.
+	ExecuteQuery(*QueryRequest, Query_ExecuteQueryServer) error
+	mustEmbedUnimplementedQueryServer()
+}
+
+// UnimplementedQueryServer must be embedded to have forward compatible implementations.
+type UnimplementedQueryServer struct {
+}
+
+func (UnimplementedQueryServer) ExecuteQuery(*QueryRequest, Query_ExecuteQueryServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExecuteQuery not implemented")
+}
+func (UnimplementedQueryServer) mustEmbedUnimplementedQueryServer() {}
+
+// UnsafeQueryServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to QueryServer will
+// result in compilation errors.
+type UnsafeQueryServer interface {
+	mustEmbedUnimplementedQueryServer()
+}
+
+func RegisterQueryServer(s grpc.ServiceRegistrar, srv QueryServer) {
+	s.RegisterService(&Query_ServiceDesc, srv)
+}
+
+func _Query_ExecuteQuery_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(QueryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(QueryServer).ExecuteQuery(m, &queryExecuteQueryServer{stream})
+}
+
+type Query_ExecuteQueryServer interface {
+	Send(*QueryResponse) error
+	grpc.ServerStream
+}
+
+type queryExecuteQueryServer struct {
+	grpc.ServerStream
+}
+
+func (x *queryExecuteQueryServer) Send(m *QueryResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Query_ServiceDesc is the grpc.ServiceDesc for Query service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Query_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "askgit.v1.Query",
+	HandlerType: (*QueryServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExecuteQuery",
+			Handler:       _Query_ExecuteQuery_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "query.proto",
+}
@@ -0,0 +1,291 @@
+// Package server exposes askgit query execution over HTTP and gRPC, so that other services can run
+// queries against a long-running askgit instance rather than shelling out to the CLI.
+//
+// Results are streamed back as newline-delimited JSON messages, or as the messages of the Query service
+// of pb over gRPC. The first message holds the column metadata, followed by any number of row batches,
+// and finally a trailer with either the total row count or the error that interrupted the stream.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/askgitdev/askgit/pkg/audit"
+	"github.com/askgitdev/askgit/pkg/health"
+	"github.com/askgitdev/askgit/pkg/query"
+	"github.com/askgitdev/askgit/pkg/sandbox"
+	"google.golang.org/grpc"
+)
+
+// DefaultBatchSize is the number of rows sent per message if the client doesn't ask for a specific size
+const DefaultBatchSize = 100
+
+// Interceptor wraps the handling of every request made to the server.
+// Interceptors can be used to implement cross-cutting concerns such as authentication or logging.
+type Interceptor func(next http.Handler) http.Handler
+
+// Server executes queries received over HTTP against DB
+type Server struct {
+	DB *sql.DB
+
+	// Interceptors are applied to every request, in order, with the first one being the outermost
+	Interceptors []Interceptor
+
+	// StreamInterceptors are applied to every call of the gRPC service, as Interceptors are to requests
+	StreamInterceptors []grpc.StreamServerInterceptor
+
+	// Sandbox, if set, restricts the queries clients are allowed to run
+	Sandbox *sandbox.Policy
+
+	// Authorize, if set, is called with the request context and statement of every query before
+	// it's executed. Returning an error rejects the query with a 403 status.
+	Authorize func(ctx context.Context, sql string) error
+
+	// Audit, if set, records every query received by the server
+	Audit *audit.Logger
+
+	// Health, if set, serves the liveness and readiness probes of the server under /healthz and /readyz,
+	// outside of the interceptors, as probes aren't authenticated
+	Health *health.Checker
+
+	// Debug, if set, exposes the profiles of net/http/pprof under /debug/pprof/,
+	// and the metrics of the Go runtime as JSON under /debug/runtime (behind the interceptors, as the API)
+	Debug bool
+}
+
+// QueryRequest is the body expected by the query endpoint
+type QueryRequest struct {
+	SQL       string `json:"sql"`
+	BatchSize int    `json:"batch_size,omitempty"`
+}
+
+// Header is the first message of a streamed result set
+type Header struct {
+	Columns []string `json:"columns"`
+	Types   []string `json:"types"`
+}
+
+// Batch holds a chunk of rows of a streamed result set
+type Batch struct {
+	Rows [][]interface{} `json:"rows"`
+}
+
+// Trailer is the last message of a streamed result set
+type Trailer struct {
+	Done      bool   `json:"done"`
+	RowCount  int    `json:"row_count"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Handler returns the http.Handler serving the query API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/query", s.handleQuery)
+	if s.Debug {
+		handleDebug(mux)
+	}
+
+	var h http.Handler = mux
+	for i := len(s.Interceptors) - 1; i >= 0; i-- {
+		h = s.Interceptors[i](h)
+	}
+
+	if s.Health != nil {
+		var probes = http.NewServeMux()
+		s.Health.Register(probes)
+		probes.Handle("/", h)
+		return probes
+	}
+	return h
+}
+
+// BearerTokenAuth returns an interceptor that rejects any request not carrying the given bearer token
+func BearerTokenAuth(token string) Interceptor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// compared in constant time, so that the token can't be guessed from how long it takes to be rejected
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+				writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "only POST requests are supported")
+		return
+	}
+
+	var req QueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.SQL == "" {
+		writeError(w, http.StatusBadRequest, "expected a body of the form {\"sql\": \"...\"}")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	if code, err := s.run(r.Context(), &req, ndjson(w)); code != 0 {
+		writeError(w, code, err.Error())
+	}
+}
+
+// run authorizes the query, and runs it in the sandbox, streaming its results out through send.
+// Queries rejected before anything is sent fail with the HTTP status they're rejected with, otherwise
+// the status is 0 and the error is that of sending the results. Either way, the query is audited.
+func (s *Server) run(ctx context.Context, req *QueryRequest, send func(msg interface{}) error) (int, error) {
+	if req.BatchSize <= 0 {
+		req.BatchSize = DefaultBatchSize
+	}
+
+	var limit = 0
+	ctx, entry := s.Audit.Begin(ctx, req.SQL)
+	if s.Authorize != nil {
+		if err := s.Authorize(ctx, req.SQL); err != nil {
+			_ = s.Audit.End(entry, 0, err)
+			return http.StatusForbidden, err
+		}
+	}
+
+	if s.Sandbox != nil {
+		if err := s.Sandbox.Check(req.SQL); err != nil {
+			_ = s.Audit.End(entry, 0, err)
+			return http.StatusForbidden, err
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = s.Sandbox.WithTimeout(ctx)
+		defer cancel()
+		limit = s.Sandbox.MaxRows
+	}
+
+	// the query runs on a connection of its own, so that the API calls of its tables are counted on ctx
+	conn, release, err := audit.Conn(ctx, s.DB)
+	if err != nil {
+		_ = s.Audit.End(entry, 0, err)
+		return http.StatusServiceUnavailable, err
+	}
+	defer release()
+
+	rows, err := conn.QueryContext(ctx, req.SQL)
+	if err != nil {
+		_ = s.Audit.End(entry, 0, err)
+		return http.StatusBadRequest, err
+	}
+	defer rows.Close()
+
+	trailer, err := stream(rows, req.BatchSize, limit, send)
+	var failed = err
+	if err == nil && trailer.Error != "" {
+		failed = errors.New(trailer.Error)
+	}
+	_ = s.Audit.End(entry, trailer.RowCount, failed)
+	return 0, err
+}
+
+// ndjson returns a function sending messages to w as newline-delimited JSON, flushing every one of them
+func ndjson(w http.ResponseWriter) func(msg interface{}) error {
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	return func(msg interface{}) error {
+		// blobs are sent as text, rather than as the base64 encoding/json turns them into
+		if batch, ok := msg.(*Batch); ok {
+			for _, row := range batch.Rows {
+				query.Normalize(row)
+			}
+		}
+		if err := enc.Encode(msg); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+}
+
+// Stream writes rows out to w as a header, followed by batches of at most batchSize rows and a trailer.
+// If limit is greater than zero, at most limit rows are sent and the trailer is marked as truncated if there were more.
+// Errors encountered after the header has been written are reported in the trailer, which is returned
+// along with any error writing to w.
+func Stream(rows *sql.Rows, batchSize, limit int, w http.ResponseWriter) (*Trailer, error) {
+	return stream(rows, batchSize, limit, ndjson(w))
+}
+
+// stream sends rows as Stream does, through send, which is passed a *Header, *Batch or *Trailer.
+// The rows of a batch are only valid until send returns.
+func stream(rows *sql.Rows, batchSize, limit int, send func(msg interface{}) error) (*Trailer, error) {
+	var trailer = &Trailer{Done: true}
+	columns, err := rows.Columns()
+	if err != nil {
+		trailer.Error = err.Error()
+		return trailer, send(trailer)
+	}
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		trailer.Error = err.Error()
+		return trailer, send(trailer)
+	}
+
+	var header = &Header{Columns: columns, Types: make([]string, len(types))}
+	for i, t := range types {
+		header.Types[i] = t.DatabaseTypeName()
+	}
+
+	if err = send(header); err != nil {
+		return trailer, err
+	}
+
+	var batch = &Batch{Rows: make([][]interface{}, 0, batchSize)}
+	pointers := make([]interface{}, len(columns))
+	for rows.Next() {
+		if limit > 0 && trailer.RowCount == limit {
+			trailer.Truncated = true
+			break
+		}
+
+		values := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err = rows.Scan(pointers...); err != nil {
+			trailer.Error = err.Error()
+			return trailer, send(trailer)
+		}
+
+		batch.Rows = append(batch.Rows, values)
+		trailer.RowCount++
+
+		if len(batch.Rows) == batchSize {
+			if err = send(batch); err != nil {
+				return trailer, err
+			}
+			batch.Rows = batch.Rows[:0]
+		}
+	}
+
+	if len(batch.Rows) > 0 {
+		if err = send(batch); err != nil {
+			return trailer, err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		trailer.Error = err.Error()
+	}
+	return trailer, send(trailer)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
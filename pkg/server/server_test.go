@@ -0,0 +1,219 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/askgitdev/askgit/pkg/health"
+	"github.com/askgitdev/askgit/pkg/sandbox"
+)
+
+func TestQuery(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+
+	mockRows := sqlmock.NewRows([]string{"id", "name"}).
+		AddRow("1", "name 1").
+		AddRow("2", "name 2").
+		AddRow("3", "name 3")
+
+	mock.ExpectQuery("select").WillReturnRows(mockRows)
+
+	srv := httptest.NewServer((&Server{DB: db}).Handler())
+	defer srv.Close()
+
+	res, err := http.Post(srv.URL+"/v1/query", "application/json", strings.NewReader(`{"sql": "select", "batch_size": 2}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	// header, two batches and the trailer
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 messages, got: %d", len(lines))
+	}
+
+	var header Header
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil {
+		t.Fatal(err)
+	}
+	if len(header.Columns) != 2 {
+		t.Fatalf("expected 2 columns, got: %d", len(header.Columns))
+	}
+
+	var batch Batch
+	if err := json.Unmarshal([]byte(lines[1]), &batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(batch.Rows) != 2 {
+		t.Fatalf("expected a batch of 2 rows, got: %d", len(batch.Rows))
+	}
+
+	var trailer Trailer
+	if err := json.Unmarshal([]byte(lines[3]), &trailer); err != nil {
+		t.Fatal(err)
+	}
+	if !trailer.Done || trailer.RowCount != 3 || trailer.Error != "" {
+		t.Fatalf("unexpected trailer: %+v", trailer)
+	}
+}
+
+func TestBearerTokenAuth(t *testing.T) {
+	db, _, _ := sqlmock.New()
+
+	srv := httptest.NewServer((&Server{DB: db, Interceptors: []Interceptor{BearerTokenAuth("secret")}}).Handler())
+	defer srv.Close()
+
+	res, err := http.Post(srv.URL+"/v1/query", "application/json", strings.NewReader(`{"sql": "select"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected status %d, got: %d", http.StatusUnauthorized, res.StatusCode)
+	}
+}
+
+func TestHealthBypassesInterceptors(t *testing.T) {
+	db, _, _ := sqlmock.New()
+
+	var checker = &health.Checker{Live: []*health.Check{{Name: "database", Fn: func(ctx context.Context) error { return nil }}}}
+	srv := httptest.NewServer((&Server{DB: db, Health: checker, Interceptors: []Interceptor{BearerTokenAuth("secret")}}).Handler())
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected the probe to be served without a token, got: %d", res.StatusCode)
+	}
+
+	if res, err = http.Get(srv.URL + "/v1/query"); err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected queries to still be authenticated, got: %d", res.StatusCode)
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+
+	mockRows := sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2").AddRow("3")
+	mock.ExpectQuery("SELECT id FROM t").WillReturnRows(mockRows)
+
+	srv := httptest.NewServer((&Server{DB: db, Sandbox: &sandbox.Policy{MaxRows: 2}}).Handler())
+	defer srv.Close()
+
+	res, err := http.Post(srv.URL+"/v1/query", "application/json", strings.NewReader(`{"sql": "DELETE FROM t"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status %d, got: %d", http.StatusForbidden, res.StatusCode)
+	}
+
+	if res, err = http.Post(srv.URL+"/v1/query", "application/json", strings.NewReader(`{"sql": "SELECT id FROM t"}`)); err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	var trailer Trailer
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &trailer); err != nil {
+		t.Fatal(err)
+	}
+	if trailer.RowCount != 2 || !trailer.Truncated {
+		t.Fatalf("expected a truncated result of 2 rows, got: %+v", trailer)
+	}
+}
+
+func TestSandboxBannedTables(t *testing.T) {
+	db, _, _ := sqlmock.New()
+
+	srv := httptest.NewServer((&Server{DB: db, Sandbox: &sandbox.Policy{BannedFunctions: sandbox.DefaultBannedFunctions}}).Handler())
+	defer srv.Close()
+
+	// the tables reading local files or fetching arbitrary URLs, with and without parentheses
+	var queries = []string{
+		"SELECT * FROM coverage_report('/etc/passwd')",
+		"SELECT * FROM coverage_report WHERE path = '/etc/passwd'",
+		"SELECT * FROM read_csv('/etc/passwd')",
+		"SELECT * FROM read_json WHERE path = 'http://169.254.169.254/latest/meta-data'",
+		"SELECT * FROM http_json('http://169.254.169.254/latest/meta-data')",
+		"SELECT * FROM fs_walk WHERE root = '/'",
+		"SELECT * FROM zip_entries('/tmp/x.zip')",
+		"SELECT * FROM tar_entries WHERE source = '/tmp/x.tar'",
+		"SELECT * FROM mbox_patches('/var/mail/root')",
+		"SELECT * FROM phabricator_revisions('/etc/passwd')",
+		"SELECT * FROM phabricator_reviewers WHERE source = 'http://169.254.169.254'",
+		"SELECT * FROM feed('file:///etc/passwd')",
+		"SELECT * FROM repos WHERE root = '/'",
+	}
+	for _, sql := range queries {
+		body, _ := json.Marshal(&QueryRequest{SQL: sql})
+		res, err := http.Post(srv.URL+"/v1/query", "application/json", strings.NewReader(string(body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusForbidden {
+			t.Fatalf("expected %q to be rejected with status %d, got: %d", sql, http.StatusForbidden, res.StatusCode)
+		}
+	}
+}
+
+func TestDebug(t *testing.T) {
+	db, _, _ := sqlmock.New()
+
+	for _, debug := range []bool{false, true} {
+		srv := httptest.NewServer((&Server{DB: db, Debug: debug}).Handler())
+
+		res, err := http.Get(srv.URL + "/debug/runtime")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var metrics RuntimeMetrics
+		err = json.NewDecoder(res.Body).Decode(&metrics)
+		res.Body.Close()
+
+		if !debug && res.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected the debug endpoints to be disabled, got status %d", res.StatusCode)
+		}
+		if debug && (err != nil || metrics.Goroutines == 0 || metrics.GoVersion == "") {
+			t.Fatalf("expected runtime metrics, got %+v (%v)", metrics, err)
+		}
+
+		if res, err = http.Get(srv.URL + "/debug/pprof/goroutine?debug=1"); err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if debug != (res.StatusCode == http.StatusOK) {
+			t.Fatalf("expected pprof to be served only in debug mode, got status %d", res.StatusCode)
+		}
+		srv.Close()
+	}
+}
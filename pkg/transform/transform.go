@@ -0,0 +1,107 @@
+// Package transform applies user supplied SQL expressions to the rows of exported tables, such as
+// lowercasing logins or mapping logins to team names, so that exports don't need post-processing.
+// Expressions can call any SQL function, including the Starlark functions loaded with --udf.
+package transform
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Transform sets a column of a table to the value of an expression, evaluated on every row
+type Transform struct {
+	Table, Column, Expr string
+}
+
+// Parse parses a transform given as table.column=expression, e.g. commits.author_email=lower(author_email)
+func Parse(s string) (*Transform, error) {
+	var eq = strings.Index(s, "=")
+	var dot = strings.Index(s, ".")
+	if eq < 0 || dot < 0 || dot > eq {
+		return nil, fmt.Errorf("expected a transform of the form table.column=expression, got %q", s)
+	}
+
+	var t = &Transform{Table: strings.TrimSpace(s[:dot]), Column: strings.TrimSpace(s[dot+1 : eq]), Expr: strings.TrimSpace(s[eq+1:])}
+	if t.Table == "" || t.Column == "" || t.Expr == "" {
+		return nil, fmt.Errorf("expected a transform of the form table.column=expression, got %q", s)
+	}
+	return t, nil
+}
+
+// ParseAll parses every transform in specs
+func ParseAll(specs []string) ([]*Transform, error) {
+	var transforms []*Transform
+	for _, spec := range specs {
+		t, err := Parse(spec)
+		if err != nil {
+			return nil, err
+		}
+		transforms = append(transforms, t)
+	}
+	return transforms, nil
+}
+
+// execer is implemented by *sql.DB, *sql.Conn and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Apply applies the transforms of table to its rows in db. Columns that don't exist are added,
+// so that expressions can derive new columns. As in any UPDATE, every expression sees the original row.
+func Apply(ctx context.Context, db execer, table string, transforms []*Transform) error {
+	var set []string
+	var columns map[string]bool
+	for _, t := range transforms {
+		if !strings.EqualFold(t.Table, table) {
+			continue
+		}
+
+		if columns == nil {
+			var err error
+			if columns, err = tableColumns(ctx, db, table); err != nil {
+				return err
+			}
+		}
+
+		if !columns[strings.ToLower(t.Column)] {
+			if _, err := db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %q ADD COLUMN %q", table, t.Column)); err != nil {
+				return fmt.Errorf("failed to add column %s: %v", t.Column, err)
+			}
+			columns[strings.ToLower(t.Column)] = true
+		}
+
+		set = append(set, fmt.Sprintf("%q = (%s)", t.Column, t.Expr))
+	}
+
+	if len(set) == 0 {
+		return nil
+	}
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("UPDATE %q SET %s", table, strings.Join(set, ", "))); err != nil {
+		return fmt.Errorf("failed to transform table %s: %v", table, err)
+	}
+	return nil
+}
+
+// tableColumns returns the lowercased names of the columns of table
+func tableColumns(ctx context.Context, db execer, table string) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %q LIMIT 0", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var columns = make(map[string]bool, len(names))
+	for _, name := range names {
+		columns[strings.ToLower(name)] = true
+	}
+	return columns, nil
+}
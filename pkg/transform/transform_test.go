@@ -0,0 +1,53 @@
+package transform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestParse(t *testing.T) {
+	tr, err := Parse("commits.team=CASE WHEN author_email LIKE '%@example.com' THEN 'core' END")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.Table != "commits" || tr.Column != "team" || tr.Expr != "CASE WHEN author_email LIKE '%@example.com' THEN 'core' END" {
+		t.Fatalf("unexpected transform: %+v", tr)
+	}
+
+	for _, invalid := range []string{"commits=lower(x)", "author_email=lower(author_email)", "commits.author_email=", ".x=1"} {
+		if _, err = Parse(invalid); err == nil {
+			t.Fatalf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	transforms, err := ParseAll([]string{"commits.author_email=lower(author_email)", "commits.team=team_of(author_email)", "files.path=upper(path)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM "commits" LIMIT 0`).WillReturnRows(sqlmock.NewRows([]string{"hash", "author_email"}))
+	mock.ExpectExec(`ALTER TABLE "commits" ADD COLUMN "team"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE "commits" SET "author_email" = \(lower\(author_email\)\), "team" = \(team_of\(author_email\)\)`).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	if err = Apply(context.Background(), db, "commits", transforms); err != nil {
+		t.Fatal(err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	// tables without transforms are left alone
+	if err = Apply(context.Background(), db, "refs", transforms); err != nil {
+		t.Fatal(err)
+	}
+}
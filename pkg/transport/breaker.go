@@ -0,0 +1,124 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Breaker is a circuit breaker: once requests to a host fail Threshold times in a row, it trips,
+// and requests to that host fail fast, without being sent, for Cooldown. A single request is then let through,
+// closing the circuit again if it succeeds. This keeps every table from retrying an API that's down for minutes.
+//
+// A request fails if it can't be sent, or if the server answers with a 5xx status.
+type Breaker struct {
+	// Base is the transport requests are sent with, http.DefaultTransport if nil
+	Base http.RoundTripper
+
+	// Threshold is the number of consecutive failures tripping the breaker, 0 disables it
+	Threshold int
+
+	// Cooldown is how long requests fail fast for once the breaker trips
+	Cooldown time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*circuit
+}
+
+// circuit is the state of the breaker for a host
+type circuit struct {
+	failures  int       // consecutive failures
+	openUntil time.Time // requests fail fast until then, once tripped
+	probing   bool      // whether the request testing the host after the cooldown is in flight
+	last      string    // the last failure
+}
+
+// OpenError is returned, in place of sending the request, while the circuit of a host is open
+type OpenError struct {
+	Host     string
+	Failures int
+	Last     string
+	Until    time.Time
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s is failing (%d consecutive failures, last: %s), not sending requests until %s",
+		e.Host, e.Failures, e.Last, e.Until.Format(time.RFC3339))
+}
+
+func (b *Breaker) RoundTrip(req *http.Request) (*http.Response, error) {
+	var base = b.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if b.Threshold <= 0 {
+		return base.RoundTrip(req)
+	}
+
+	var host = req.URL.Host
+	if err := b.allow(host); err != nil {
+		return nil, err
+	}
+
+	res, err := base.RoundTrip(req)
+	switch {
+	case err != nil:
+		b.record(host, err.Error())
+	case res.StatusCode >= 500:
+		b.record(host, res.Status)
+	default:
+		b.record(host, "")
+	}
+	return res, err
+}
+
+// allow returns an OpenError if the circuit of host is open
+func (b *Breaker) allow(host string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var c = b.circuit(host)
+	if c.failures < b.Threshold {
+		return nil
+	}
+
+	// after the cooldown, a single request probes the host, while the others keep failing fast
+	if time.Now().Before(c.openUntil) || c.probing {
+		return &OpenError{Host: host, Failures: c.failures, Last: c.last, Until: c.openUntil}
+	}
+	c.probing = true
+	return nil
+}
+
+// record records the outcome of a request to host, failure being empty if it succeeded
+func (b *Breaker) record(host, failure string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var c = b.circuit(host)
+	c.probing = false
+	if failure == "" {
+		c.failures = 0
+		return
+	}
+
+	c.failures++
+	c.last = failure
+	if c.failures >= b.Threshold {
+		c.openUntil = time.Now().Add(b.Cooldown)
+	}
+}
+
+// circuit returns the circuit of host, the lock must be held
+func (b *Breaker) circuit(host string) *circuit {
+	if b.hosts == nil {
+		b.hosts = make(map[string]*circuit)
+	}
+	var c, ok = b.hosts[host]
+	if !ok {
+		c = &circuit{}
+		b.hosts[host] = c
+	}
+	return c
+}
@@ -0,0 +1,83 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	var status = http.StatusBadGateway
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	var b = &Breaker{Threshold: 3, Cooldown: 50 * time.Millisecond}
+	var client = &http.Client{Transport: b}
+
+	get := func() error {
+		res, err := client.Get(srv.URL)
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := get(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the breaker tripped, requests fail without reaching the server
+	err := get()
+	if err == nil {
+		t.Fatal("expected the request to fail fast")
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 requests to reach the server, got %d", calls)
+	}
+
+	// after the cooldown, a successful request closes the circuit
+	time.Sleep(60 * time.Millisecond)
+	status = http.StatusOK
+	for i := 0; i < 2; i++ {
+		if err = get(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 5 {
+		t.Fatalf("expected 5 requests to reach the server, got %d", calls)
+	}
+}
+
+func TestBreakerProbeFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	var b = &Breaker{Threshold: 1, Cooldown: 20 * time.Millisecond}
+	var client = &http.Client{Transport: b}
+
+	res, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	time.Sleep(30 * time.Millisecond)
+	if res, err = client.Get(srv.URL); err != nil {
+		t.Fatalf("expected the probe to be sent, got %v", err)
+	}
+	res.Body.Close()
+
+	// the probe failed, the circuit is open again
+	if _, err = client.Get(srv.URL); err == nil {
+		t.Fatal("expected the request to fail fast")
+	}
+}
@@ -0,0 +1,64 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Budget caps the rate of the requests sent through it, whatever the API or the table they're made for.
+// Its limiter is usually shared by every transport of a process, so that concurrent queries (or the tables
+// of askgit sync) draw from a single budget, rather than each exhausting the rate limits of the APIs.
+// Requests wait for the budget, until their context is done.
+type Budget struct {
+	// Base is the transport requests are sent with, http.DefaultTransport if nil
+	Base http.RoundTripper
+
+	// Limiter is the budget, requests aren't limited if nil
+	Limiter *rate.Limiter
+}
+
+func (b *Budget) RoundTrip(req *http.Request) (*http.Response, error) {
+	var base = b.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if b.Limiter != nil {
+		if err := b.Limiter.Wait(req.Context()); err != nil {
+			return nil, fmt.Errorf("out of API budget: %v", err)
+		}
+	}
+	return base.RoundTrip(req)
+}
+
+// ParseBudget parses a number of requests per second, minute or hour, e.g. 10/s, 600/m or 5000/h,
+// into the rate of a limiter. Requests are spread evenly, the burst of the limiter being a single request.
+func ParseBudget(s string) (rate.Limit, error) {
+	var parts = strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid budget %q, expected a number of requests per second, minute or hour, such as 5000/h", s)
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid budget %q, expected a number of requests per second, minute or hour, such as 5000/h", s)
+	}
+
+	var per time.Duration
+	switch strings.TrimSpace(parts[1]) {
+	case "s":
+		per = time.Second
+	case "m":
+		per = time.Minute
+	case "h":
+		per = time.Hour
+	default:
+		return 0, fmt.Errorf("invalid budget %q, expected a number of requests per second, minute or hour, such as 5000/h", s)
+	}
+	return rate.Limit(n / per.Seconds()), nil
+}
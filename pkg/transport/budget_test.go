@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestParseBudget(t *testing.T) {
+	for s, want := range map[string]rate.Limit{"10/s": 10, "600/m": 10, "3600/h": 1} {
+		got, err := ParseBudget(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("%s: expected %v requests per second, got %v", s, want, got)
+		}
+	}
+
+	for _, s := range []string{"", "10", "0/s", "ten/s", "10/d"} {
+		if _, err := ParseBudget(s); err == nil {
+			t.Fatalf("expected budget %q to be invalid", s)
+		}
+	}
+}
+
+func TestBudget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// both clients draw from the same budget, of a request every 20ms
+	var limiter = rate.NewLimiter(rate.Every(20*time.Millisecond), 1)
+	var a = &http.Client{Transport: &Budget{Limiter: limiter}}
+	var b = &http.Client{Transport: &Budget{Limiter: limiter}}
+
+	var start = time.Now()
+	for _, client := range []*http.Client{a, b, a, b} {
+		res, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Fatalf("expected 4 requests to take at least 60ms, took %s", elapsed)
+	}
+}
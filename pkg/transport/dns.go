@@ -0,0 +1,86 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// dnsCache resolves the hosts connections are dialed to, keeping the addresses of every host for a while,
+// as thousands of small requests otherwise spend a fair share of their time waiting on the resolver
+type dnsCache struct {
+	ttl    time.Duration
+	dialer *net.Dialer
+
+	// lookup resolves a host, net.DefaultResolver.LookupIPAddr if nil
+	lookup func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+	mu    sync.Mutex
+	hosts map[string]*resolved
+}
+
+// resolved are the addresses of a host, until they expire
+type resolved struct {
+	addrs   []net.IPAddr
+	expires time.Time
+}
+
+// resolve returns the addresses of host, from the cache if they're yet to expire
+func (c *dnsCache) resolve(ctx context.Context, host string) ([]net.IPAddr, error) {
+	c.mu.Lock()
+	var r, ok = c.hosts[host]
+	c.mu.Unlock()
+	if ok && time.Now().Before(r.expires) {
+		return r.addrs, nil
+	}
+
+	var lookup = c.lookup
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupIPAddr
+	}
+	addrs, err := lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.hosts == nil {
+		c.hosts = make(map[string]*resolved)
+	}
+	c.hosts[host] = &resolved{addrs: addrs, expires: time.Now().Add(c.ttl)}
+	return addrs, nil
+}
+
+// DialContext dials addr, trying the addresses of its host in turn. IP addresses are dialed as they are.
+func (c *dnsCache) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return c.dialer.DialContext(ctx, network, addr)
+	}
+
+	addrs, err := c.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var conn net.Conn
+	for _, ip := range addrs {
+		if conn, err = c.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port)); err == nil {
+			return conn, nil
+		}
+	}
+	if err == nil {
+		err = &net.DNSError{Err: "no addresses", Name: host}
+	}
+
+	// the host may have moved, it's resolved again on the next dial
+	c.mu.Lock()
+	delete(c.hosts, host)
+	c.mu.Unlock()
+	return nil, err
+}
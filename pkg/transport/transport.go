@@ -0,0 +1,107 @@
+// Package transport builds the http.RoundTripper used by askgit's API clients,
+// so that users behind corporate proxies and TLS intercepting appliances can reach the APIs,
+// and that the thousands of small requests of a query reuse their connections.
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Options configures the transport returned by New
+type Options struct {
+	// Proxy is the URL of the proxy requests are sent through.
+	// If empty, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are honoured.
+	Proxy string
+
+	// CABundle is the path to a PEM file of additional certificate authorities to trust,
+	// on top of the system's certificate pool
+	CABundle string
+
+	// InsecureSkipVerify disables the verification of server certificates. Only ever use this for debugging.
+	InsecureSkipVerify bool
+
+	// MaxIdleConnsPerHost is the number of connections kept open to every host, between requests, DefaultMaxIdleConnsPerHost if 0.
+	// Go only keeps 2 by default, and so the concurrent requests of a query keep opening (and tearing down) new ones.
+	MaxIdleConnsPerHost int
+
+	// IdleConnTimeout is how long idle connections are kept open for, 90s if 0
+	IdleConnTimeout time.Duration
+
+	// DisableHTTP2 sticks to HTTP/1.1, HTTP/2 being negotiated with the servers that support it otherwise
+	DisableHTTP2 bool
+
+	// DisableCompression doesn't ask for gzip-compressed responses, which are transparently decompressed otherwise
+	DisableCompression bool
+
+	// DNSCacheTTL is how long the addresses of hosts are kept for, 0 resolving them for every new connection
+	DNSCacheTTL time.Duration
+}
+
+// DefaultMaxIdleConnsPerHost is the default of Options.MaxIdleConnsPerHost
+const DefaultMaxIdleConnsPerHost = 16
+
+// New returns a transport configured with opts, based on http.DefaultTransport
+func New(opts *Options) (*http.Transport, error) {
+	var t = http.DefaultTransport.(*http.Transport).Clone()
+
+	t.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	if opts.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if t.MaxIdleConns < t.MaxIdleConnsPerHost {
+		t.MaxIdleConns = t.MaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	t.DisableCompression = opts.DisableCompression
+
+	// a non-nil, empty map of protocols disables HTTP/2
+	if opts.DisableHTTP2 {
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+
+	if opts.DNSCacheTTL > 0 {
+		var cache = &dnsCache{ttl: opts.DNSCacheTTL, dialer: &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}}
+		t.DialContext = cache.DialContext
+	}
+
+	t.Proxy = http.ProxyFromEnvironment
+	if opts.Proxy != "" {
+		u, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url %q: %v", opts.Proxy, err)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+
+	if opts.CABundle != "" || opts.InsecureSkipVerify {
+		t.TLSClientConfig = &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}
+	}
+
+	if opts.CABundle != "" {
+		pem, err := ioutil.ReadFile(opts.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca bundle: %v", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ca bundle %s", opts.CABundle)
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+
+	return t, nil
+}
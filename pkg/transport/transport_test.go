@@ -0,0 +1,128 @@
+package transport
+
+import (
+	"context"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	f, err := ioutil.TempFile("", "askgit-ca-*.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	if err = pem.Encode(f, &pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	// without the bundle, the test server's certificate isn't trusted
+	tr, err := New(&Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = (&http.Client{Transport: tr}).Get(srv.URL); err == nil {
+		t.Fatal("expected an untrusted certificate error")
+	}
+
+	if tr, err = New(&Options{CABundle: f.Name()}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := (&http.Client{Transport: tr}).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+}
+
+func TestProxy(t *testing.T) {
+	var proxied bool
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { proxied = true }))
+	defer proxy.Close()
+
+	tr, err := New(&Options{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := (&http.Client{Transport: tr}).Get("http://api.github.invalid/graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if !proxied {
+		t.Fatal("expected the request to go through the proxy")
+	}
+}
+
+func TestTuning(t *testing.T) {
+	tr, err := New(&Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost || !tr.ForceAttemptHTTP2 || tr.DisableCompression {
+		t.Fatalf("unexpected default transport: %d idle connections per host, HTTP/2 %v, compression disabled %v", tr.MaxIdleConnsPerHost, tr.ForceAttemptHTTP2, tr.DisableCompression)
+	}
+
+	if tr, err = New(&Options{MaxIdleConnsPerHost: 200, IdleConnTimeout: time.Minute, DisableHTTP2: true, DisableCompression: true}); err != nil {
+		t.Fatal(err)
+	}
+	if tr.MaxIdleConnsPerHost != 200 || tr.MaxIdleConns < 200 || tr.IdleConnTimeout != time.Minute {
+		t.Fatalf("unexpected connection pool: %d per host, %d in all, idle for %s", tr.MaxIdleConnsPerHost, tr.MaxIdleConns, tr.IdleConnTimeout)
+	}
+	if tr.ForceAttemptHTTP2 || tr.TLSNextProto == nil || !tr.DisableCompression {
+		t.Fatal("expected HTTP/2 and compression to be disabled")
+	}
+}
+
+func TestDNSCache(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, _ := net.SplitHostPort(u.Host)
+
+	var lookups int
+	var cache = &dnsCache{ttl: time.Minute, dialer: &net.Dialer{}, lookup: func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		lookups++
+		return []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}, nil
+	}}
+
+	for i := 0; i < 3; i++ {
+		conn, err := cache.DialContext(context.Background(), "tcp", net.JoinHostPort("api.example.invalid", port))
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+	}
+	if lookups != 1 {
+		t.Fatalf("expected the host to be resolved once, got %d lookups", lookups)
+	}
+
+	// once it expires, the host is resolved again
+	cache.hosts["api.example.invalid"].expires = time.Now()
+	conn, err := cache.DialContext(context.Background(), "tcp", net.JoinHostPort("api.example.invalid", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if lookups != 2 {
+		t.Fatalf("expected the host to be resolved again, got %d lookups", lookups)
+	}
+}
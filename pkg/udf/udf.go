@@ -0,0 +1,209 @@
+// Package udf loads user-defined scalar SQL functions from Starlark [https://github.com/google/starlark-go] scripts,
+// for bespoke parsing logic that doesn't warrant a contribution to askgit itself.
+//
+// Every global function of a script whose name doesn't start with an underscore is registered as a SQL function
+// of the same name, taking as many arguments as it has parameters (or any number, if it has *args):
+//
+//	def ticket(message):
+//	    for word in message.split():
+//	        if word.startswith("JIRA-"):
+//	            return word
+//	    return None
+//
+// SQL values are passed to Starlark as None, int, float or string (blobs are passed as strings).
+// None, int, float, string and bool results are returned as is, while lists, tuples and dicts are returned as JSON text.
+package udf
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/askgitdev/askgit/tables"
+	"go.riyazali.net/sqlite"
+	"go.starlark.net/starlark"
+)
+
+// Provider registers the functions defined by a script
+type Provider struct {
+	path      string
+	functions map[string]*starlark.Function
+}
+
+// Load executes the script at path and collects the functions it defines
+func Load(path string) (*Provider, error) {
+	var thread = &starlark.Thread{Name: path, Print: printToStderr}
+	globals, err := starlark.ExecFile(thread, path, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load udf script %s: %v", path, err)
+	}
+	globals.Freeze()
+
+	var p = &Provider{path: path, functions: make(map[string]*starlark.Function)}
+	for name, value := range globals {
+		if fn, ok := value.(*starlark.Function); ok && !strings.HasPrefix(name, "_") {
+			p.functions[name] = fn
+		}
+	}
+
+	if len(p.functions) == 0 {
+		return nil, fmt.Errorf("udf script %s doesn't define any function", path)
+	}
+	return p, nil
+}
+
+// Name implements tables.Provider
+func (p *Provider) Name() string { return p.path }
+
+// Functions returns the names of the functions defined by the script, sorted
+func (p *Provider) Functions() []string {
+	var names = make([]string, 0, len(p.functions))
+	for name := range p.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Register implements tables.Provider
+func (p *Provider) Register(ext *sqlite.ExtensionApi, _ *tables.Options) error {
+	for _, name := range p.Functions() {
+		if err := ext.CreateFunction(name, &function{fn: p.functions[name]}); err != nil {
+			return fmt.Errorf("failed to register function %q: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// function adapts a Starlark function to a scalar SQL function
+type function struct{ fn *starlark.Function }
+
+func (f *function) Args() int {
+	if f.fn.HasVarargs() || f.fn.HasKwargs() {
+		return -1
+	}
+	return f.fn.NumParams()
+}
+
+// Starlark programs can't observe time, randomness or the outside world, so functions are deterministic
+func (f *function) Deterministic() bool { return true }
+
+func (f *function) Apply(ctx *sqlite.Context, values ...sqlite.Value) {
+	var args = make(starlark.Tuple, len(values))
+	for i, v := range values {
+		args[i] = toStarlark(v)
+	}
+
+	res, err := f.call(args)
+	if err != nil {
+		ctx.ResultError(err)
+		return
+	}
+
+	switch res := res.(type) {
+	case nil:
+		ctx.ResultNull()
+	case int64:
+		ctx.ResultInt64(res)
+	case float64:
+		ctx.ResultFloat(res)
+	case string:
+		ctx.ResultText(res)
+	}
+}
+
+// call invokes the function and converts its result to nil, int64, float64 or string
+func (f *function) call(args starlark.Tuple) (interface{}, error) {
+	var thread = &starlark.Thread{Name: f.fn.Name(), Print: printToStderr}
+	res, err := starlark.Call(thread, f.fn, args, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := toGo(res)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", f.fn.Name(), err)
+	}
+
+	switch v := v.(type) {
+	case nil, int64, float64, string:
+		return v, nil
+	case bool:
+		if v {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f.fn.Name(), err)
+		}
+		return string(b), nil
+	}
+}
+
+func toStarlark(v sqlite.Value) starlark.Value {
+	switch v.Type() {
+	case sqlite.SQLITE_NULL:
+		return starlark.None
+	case sqlite.SQLITE_INTEGER:
+		return starlark.MakeInt64(v.Int64())
+	case sqlite.SQLITE_FLOAT:
+		return starlark.Float(v.Float())
+	case sqlite.SQLITE_BLOB:
+		return starlark.String(v.Blob())
+	default:
+		return starlark.String(v.Text())
+	}
+}
+
+// toGo converts a Starlark value to its Go equivalent, suitable for encoding as JSON
+func toGo(v starlark.Value) (interface{}, error) {
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(v), nil
+	case starlark.Int:
+		if i, ok := v.Int64(); ok {
+			return i, nil
+		}
+		return nil, fmt.Errorf("integer %s overflows 64 bits", v)
+	case starlark.Float:
+		return float64(v), nil
+	case starlark.String:
+		return string(v), nil
+	case starlark.Indexable: // lists and tuples
+		var items = make([]interface{}, v.Len())
+		for i := range items {
+			item, err := toGo(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	case *starlark.Dict:
+		var obj = make(map[string]interface{}, v.Len())
+		for _, item := range v.Items() {
+			key, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("dict keys must be strings, got %s", item[0].Type())
+			}
+			value, err := toGo(item[1])
+			if err != nil {
+				return nil, err
+			}
+			obj[string(key)] = value
+		}
+		return obj, nil
+	default:
+		return nil, fmt.Errorf("unsupported result type %s", v.Type())
+	}
+}
+
+func printToStderr(thread *starlark.Thread, msg string) {
+	fmt.Fprintf(os.Stderr, "%s: %s\n", thread.Name, msg)
+}
@@ -0,0 +1,71 @@
+package udf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go.starlark.net/starlark"
+)
+
+const script = `
+def ticket(message):
+    for word in message.split():
+        if word.startswith("JIRA-"):
+            return word
+    return None
+
+def labels(*names):
+    return {"count": len(names), "names": sorted(names)}
+
+def _helper():
+    pass
+`
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "askgit-udf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "funcs.star")
+	if err = ioutil.WriteFile(path, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if names := p.Functions(); !reflect.DeepEqual(names, []string{"labels", "ticket"}) {
+		t.Fatalf("unexpected functions: %v", names)
+	}
+
+	var ticket = &function{fn: p.functions["ticket"]}
+	if ticket.Args() != 1 {
+		t.Fatalf("expected ticket to take 1 argument, got: %d", ticket.Args())
+	}
+
+	res, err := ticket.call(starlark.Tuple{starlark.String("fix JIRA-123 crash")})
+	if err != nil || res != "JIRA-123" {
+		t.Fatalf("unexpected result %v: %v", res, err)
+	}
+
+	if res, err = ticket.call(starlark.Tuple{starlark.String("no ticket")}); err != nil || res != nil {
+		t.Fatalf("expected a NULL result, got %v: %v", res, err)
+	}
+
+	var labels = &function{fn: p.functions["labels"]}
+	if labels.Args() != -1 {
+		t.Fatalf("expected labels to be variadic, got: %d", labels.Args())
+	}
+
+	res, err = labels.call(starlark.Tuple{starlark.String("bug"), starlark.String("api")})
+	if err != nil || res != `{"count":2,"names":["api","bug"]}` {
+		t.Fatalf("unexpected result %v: %v", res, err)
+	}
+}
@@ -0,0 +1,90 @@
+// Package azuredevops implements tables over the repositories, pull requests and pipeline runs of Azure DevOps
+package azuredevops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+)
+
+// Options configures the Azure DevOps tables
+type Options struct {
+	// Client is used to make requests to the Azure DevOps REST API
+	Client *rest.Client
+
+	// Context holds the azureDevOpsToken personal access token
+	Context services.Context
+}
+
+// apiVersion is the version of the REST API the tables are written against
+const apiVersion = "6.0"
+
+// perPage is the number of items fetched per request
+const perPage = 100
+
+// continuationHeader holds the token of the next page of results, for the APIs paginating that way
+const continuationHeader = "X-Ms-Continuationtoken"
+
+type identity struct {
+	DisplayName string `json:"displayName"`
+	UniqueName  string `json:"uniqueName"`
+}
+
+// list is the envelope of the collections returned by the API
+type list struct {
+	Count int             `json:"count"`
+	Value json.RawMessage `json:"value"`
+}
+
+// baseURL returns the base URL of organization, which is either the name of an organization
+// on dev.azure.com or the full URL of an Azure DevOps Server collection
+func baseURL(organization string) string {
+	if strings.Contains(organization, "://") {
+		return strings.TrimSuffix(organization, "/")
+	}
+	return "https://dev.azure.com/" + url.PathEscape(organization)
+}
+
+// client returns the API client, authenticating with the personal access token if one is set
+func client(opts *Options) *rest.Client {
+	var token = opts.Context["azureDevOpsToken"]
+	if token == "" {
+		return opts.Client
+	}
+	// personal access tokens are sent as the password of basic auth, with an empty username
+	return opts.Client.With(func(req *http.Request) { req.SetBasicAuth("", token) })
+}
+
+// fetchList fetches a page of the collection at endpoint into v, returning the continuation token of the next page, if any
+func fetchList(ctx context.Context, client *rest.Client, endpoint string, params url.Values, v interface{}) (int, string, error) {
+	params.Set("api-version", apiVersion)
+
+	body, header, err := client.FetchWithHeader(ctx, endpoint+"?"+params.Encode())
+	if err != nil {
+		return 0, "", err
+	}
+
+	var res list
+	if err = json.Unmarshal(body, &res); err != nil {
+		return 0, "", fmt.Errorf("failed to decode Azure DevOps response: %v", err)
+	}
+	if err = json.Unmarshal(res.Value, v); err != nil {
+		return 0, "", fmt.Errorf("failed to decode Azure DevOps response: %v", err)
+	}
+	return res.Count, header.Get(continuationHeader), nil
+}
+
+// timeOrZero dereferences t, for the timestamps that may be missing
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
@@ -0,0 +1,55 @@
+package azuredevops
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+)
+
+func TestBaseURL(t *testing.T) {
+	if u := baseURL("contoso"); u != "https://dev.azure.com/contoso" {
+		t.Fatalf("unexpected URL: %s", u)
+	}
+	if u := baseURL("https://ado.example.com/tfs/DefaultCollection/"); u != "https://ado.example.com/tfs/DefaultCollection" {
+		t.Fatalf("unexpected URL: %s", u)
+	}
+}
+
+func TestFetchList(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pass, _ := r.BasicAuth(); pass != "pat" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("api-version") != apiVersion {
+			t.Errorf("expected the api-version to be set, got: %s", r.URL.RawQuery)
+		}
+
+		w.Header().Set(continuationHeader, "next")
+		_, _ = w.Write([]byte(`{"count": 1, "value": [{"id": 42, "buildNumber": "20210301.1", "result": "succeeded", "queueTime": "2021-03-01T10:00:00Z"}]}`))
+	}))
+	defer srv.Close()
+
+	var opts = &Options{Client: &rest.Client{}, Context: services.Context{"azureDevOpsToken": "pat"}}
+
+	var builds []*build
+	count, continuation, err := fetchList(context.Background(), client(opts), srv.URL, map[string][]string{}, &builds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 1 || len(builds) != 1 || builds[0].ID != 42 || builds[0].QueueTime == nil {
+		t.Fatalf("unexpected builds: %+v", builds)
+	}
+	if continuation != "next" {
+		t.Fatalf("expected the continuation token to be returned, got: %q", continuation)
+	}
+
+	if _, _, err = fetchList(context.Background(), &rest.Client{}, srv.URL, map[string][]string{}, &builds); err == nil {
+		t.Fatal("expected unauthenticated requests to fail")
+	}
+}
@@ -0,0 +1,108 @@
+package azuredevops
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+type build struct {
+	ID            int        `json:"id"`
+	BuildNumber   string     `json:"buildNumber"`
+	Status        string     `json:"status"`
+	Result        string     `json:"result"`
+	Reason        string     `json:"reason"`
+	QueueTime     *time.Time `json:"queueTime"`
+	StartTime     *time.Time `json:"startTime"`
+	FinishTime    *time.Time `json:"finishTime"`
+	SourceBranch  string     `json:"sourceBranch"`
+	SourceVersion string     `json:"sourceVersion"`
+	Definition    struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"definition"`
+	Repository struct {
+		Name string `json:"name"`
+	} `json:"repository"`
+	RequestedFor identity `json:"requestedFor"`
+	Links        struct {
+		Web struct {
+			Href string `json:"href"`
+		} `json:"web"`
+	} `json:"_links"`
+}
+
+var pipelineRunsCols = []vtab.Column{
+	{Name: "organization", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "project", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "pipeline_id", Type: sqlite.SQLITE_INTEGER, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: sqlite.SQLITE_INTEGER},
+	{Name: "build_number", Type: sqlite.SQLITE_TEXT},
+	{Name: "pipeline", Type: sqlite.SQLITE_TEXT},
+	{Name: "repository", Type: sqlite.SQLITE_TEXT},
+	{Name: "status", Type: sqlite.SQLITE_TEXT},
+	{Name: "result", Type: sqlite.SQLITE_TEXT},
+	{Name: "reason", Type: sqlite.SQLITE_TEXT},
+	{Name: "source_branch", Type: sqlite.SQLITE_TEXT},
+	{Name: "source_version", Type: sqlite.SQLITE_TEXT},
+	{Name: "requested_for", Type: sqlite.SQLITE_TEXT},
+	{Name: "queued_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "started_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "finished_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "url", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewPipelineRunsModule returns the implementation of a table-valued-function listing the runs of the pipelines
+// of an Azure DevOps project, optionally restricted to a single pipeline
+func NewPipelineRunsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("azure_pipeline_runs", pipelineRunsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var organization, project, pipeline string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					organization = constraint.Value.Text()
+				case 1:
+					project = constraint.Value.Text()
+				case 2:
+					pipeline = constraint.Value.Text()
+				}
+			}
+		}
+
+		// runs are read from the builds API, which covers every pipeline of the project and pages with continuation tokens
+		var endpoint = fmt.Sprintf("%s/%s/_apis/build/builds", baseURL(organization), url.PathEscape(project))
+
+		var continuation string
+		return rest.NewIterator(func(ctx context.Context) ([][]interface{}, bool, error) {
+			var params = url.Values{"$top": {fmt.Sprint(perPage)}}
+			if pipeline != "" {
+				params.Set("definitions", pipeline)
+			}
+			if continuation != "" {
+				params.Set("continuationToken", continuation)
+			}
+
+			var builds []*build
+			var err error
+			if _, continuation, err = fetchList(ctx, client(opts), endpoint, params, &builds); err != nil {
+				return nil, false, err
+			}
+
+			var rows [][]interface{}
+			for _, b := range builds {
+				rows = append(rows, []interface{}{
+					organization, project, b.Definition.ID, b.ID, b.BuildNumber, b.Definition.Name, b.Repository.Name, b.Status, b.Result, b.Reason,
+					b.SourceBranch, b.SourceVersion, b.RequestedFor.DisplayName,
+					timeOrZero(b.QueueTime), timeOrZero(b.StartTime), timeOrZero(b.FinishTime), b.Links.Web.Href,
+				})
+			}
+			return rows, continuation != "", nil
+		}), nil
+	})
+}
@@ -0,0 +1,107 @@
+package azuredevops
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+type pullRequest struct {
+	PullRequestID int        `json:"pullRequestId"`
+	Status        string     `json:"status"`
+	CreatedBy     identity   `json:"createdBy"`
+	CreationDate  *time.Time `json:"creationDate"`
+	ClosedDate    *time.Time `json:"closedDate"`
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	SourceRefName string     `json:"sourceRefName"`
+	TargetRefName string     `json:"targetRefName"`
+	MergeStatus   string     `json:"mergeStatus"`
+	IsDraft       bool       `json:"isDraft"`
+	Reviewers     []*struct {
+		identity
+		Vote int `json:"vote"`
+	} `json:"reviewers"`
+	LastMergeCommit struct {
+		CommitID string `json:"commitId"`
+	} `json:"lastMergeCommit"`
+}
+
+var pullRequestsCols = []vtab.Column{
+	{Name: "organization", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "project", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "repository", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "id", Type: sqlite.SQLITE_INTEGER},
+	{Name: "title", Type: sqlite.SQLITE_TEXT},
+	{Name: "description", Type: sqlite.SQLITE_TEXT},
+	{Name: "status", Type: sqlite.SQLITE_TEXT},
+	{Name: "is_draft", Type: sqlite.SQLITE_INTEGER},
+	{Name: "merge_status", Type: sqlite.SQLITE_TEXT},
+	{Name: "author_name", Type: sqlite.SQLITE_TEXT},
+	{Name: "author_unique_name", Type: sqlite.SQLITE_TEXT},
+	{Name: "source_ref", Type: sqlite.SQLITE_TEXT},
+	{Name: "target_ref", Type: sqlite.SQLITE_TEXT},
+	{Name: "merge_commit", Type: sqlite.SQLITE_TEXT},
+	{Name: "reviewer_count", Type: sqlite.SQLITE_INTEGER},
+	{Name: "approval_count", Type: sqlite.SQLITE_INTEGER},
+	{Name: "created_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "closed_at", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewPullRequestsModule returns the implementation of a table-valued-function listing the pull requests
+// (in any state) of an Azure DevOps git repository
+func NewPullRequestsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("azure_pull_requests", pullRequestsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var organization, project, repository string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					organization = constraint.Value.Text()
+				case 1:
+					project = constraint.Value.Text()
+				case 2:
+					repository = constraint.Value.Text()
+				}
+			}
+		}
+
+		var endpoint = fmt.Sprintf("%s/%s/_apis/git/repositories/%s/pullrequests", baseURL(organization), url.PathEscape(project), url.PathEscape(repository))
+
+		var skip int
+		return rest.NewIterator(func(ctx context.Context) ([][]interface{}, bool, error) {
+			var params = url.Values{"searchCriteria.status": {"all"}, "$top": {fmt.Sprint(perPage)}, "$skip": {fmt.Sprint(skip)}}
+
+			var prs []*pullRequest
+			if _, _, err := fetchList(ctx, client(opts), endpoint, params, &prs); err != nil {
+				return nil, false, err
+			}
+			skip += len(prs)
+
+			var rows [][]interface{}
+			for _, pr := range prs {
+				// a vote of 10 is an approval, 5 an approval with suggestions
+				var approvals int
+				for _, reviewer := range pr.Reviewers {
+					if reviewer.Vote >= 5 {
+						approvals++
+					}
+				}
+
+				rows = append(rows, []interface{}{
+					organization, project, repository, pr.PullRequestID, pr.Title, pr.Description, pr.Status, pr.IsDraft, pr.MergeStatus,
+					pr.CreatedBy.DisplayName, pr.CreatedBy.UniqueName, pr.SourceRefName, pr.TargetRefName, pr.LastMergeCommit.CommitID,
+					len(pr.Reviewers), approvals, timeOrZero(pr.CreationDate), timeOrZero(pr.ClosedDate),
+				})
+			}
+
+			// this API doesn't say whether there are more results, only a short page tells it's the last one
+			return rows, len(prs) == perPage, nil
+		}), nil
+	})
+}
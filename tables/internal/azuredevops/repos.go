@@ -0,0 +1,78 @@
+package azuredevops
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+type repo struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	DefaultBranch string `json:"defaultBranch"`
+	Size          int64  `json:"size"`
+	RemoteURL     string `json:"remoteUrl"`
+	SSHURL        string `json:"sshUrl"`
+	WebURL        string `json:"webUrl"`
+	IsDisabled    bool   `json:"isDisabled"`
+	Project       struct {
+		Name string `json:"name"`
+	} `json:"project"`
+}
+
+var reposCols = []vtab.Column{
+	{Name: "organization", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "project", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: sqlite.SQLITE_TEXT},
+	{Name: "name", Type: sqlite.SQLITE_TEXT},
+	{Name: "project_name", Type: sqlite.SQLITE_TEXT},
+	{Name: "default_branch", Type: sqlite.SQLITE_TEXT},
+	{Name: "size", Type: sqlite.SQLITE_INTEGER},
+	{Name: "remote_url", Type: sqlite.SQLITE_TEXT},
+	{Name: "ssh_url", Type: sqlite.SQLITE_TEXT},
+	{Name: "web_url", Type: sqlite.SQLITE_TEXT},
+	{Name: "is_disabled", Type: sqlite.SQLITE_INTEGER},
+}
+
+// NewReposModule returns the implementation of a table-valued-function listing the git repositories
+// of an Azure DevOps organization, or of one of its projects
+func NewReposModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("azure_repos", reposCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var organization, project string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					organization = constraint.Value.Text()
+				case 1:
+					project = constraint.Value.Text()
+				}
+			}
+		}
+
+		var endpoint = baseURL(organization)
+		if project != "" {
+			endpoint += "/" + url.PathEscape(project)
+		}
+		endpoint += "/_apis/git/repositories"
+
+		// the repositories API returns every repository in a single response
+		return rest.NewIterator(func(ctx context.Context) ([][]interface{}, bool, error) {
+			var repos []*repo
+			if _, _, err := fetchList(ctx, client(opts), endpoint, url.Values{}, &repos); err != nil {
+				return nil, false, err
+			}
+
+			var rows [][]interface{}
+			for _, r := range repos {
+				rows = append(rows, []interface{}{
+					organization, project, r.ID, r.Name, r.Project.Name, r.DefaultBranch, r.Size, r.RemoteURL, r.SSHURL, r.WebURL, r.IsDisabled,
+				})
+			}
+			return rows, false, nil
+		}), nil
+	})
+}
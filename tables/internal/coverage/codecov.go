@@ -0,0 +1,102 @@
+package coverage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+// Options configures the Codecov table
+type Options struct {
+	// Client is used to make requests to the Codecov API
+	Client *rest.Client
+
+	// Context holds the codecovToken API token, required for private repositories
+	Context services.Context
+}
+
+// codecovURL is the base URL of the Codecov API, for repositories hosted on GitHub
+const codecovURL = "https://api.codecov.io/api/v2/github"
+
+type codecovReport struct {
+	Files []*struct {
+		Name   string `json:"name"`
+		Totals struct {
+			Lines    int     `json:"lines"`
+			Hits     int     `json:"hits"`
+			Coverage float64 `json:"coverage"`
+		} `json:"totals"`
+	} `json:"files"`
+}
+
+func fetchCodecovReport(ctx context.Context, client *rest.Client, baseURL, owner, name, branch string) ([]*fileCoverage, error) {
+	var endpoint = fmt.Sprintf("%s/%s/repos/%s/report/", baseURL, url.PathEscape(owner), url.PathEscape(name))
+	if branch != "" {
+		endpoint += "?" + url.Values{"branch": {branch}}.Encode()
+	}
+
+	var report codecovReport
+	if err := client.GetJSON(ctx, endpoint, &report); err != nil {
+		return nil, err
+	}
+
+	var files []*fileCoverage
+	for _, f := range report.Files {
+		files = append(files, &fileCoverage{Path: f.Name, Lines: f.Totals.Lines, Covered: f.Totals.Hits})
+	}
+	return sorted(files), nil
+}
+
+var codecovCols = []vtab.Column{
+	{Name: "owner", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "name", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "branch", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "file_path", Type: sqlite.SQLITE_TEXT},
+	{Name: "lines", Type: sqlite.SQLITE_INTEGER},
+	{Name: "covered_lines", Type: sqlite.SQLITE_INTEGER},
+	{Name: "coverage", Type: sqlite.SQLITE_FLOAT},
+}
+
+// NewCodecovModule returns the implementation of a table-valued-function listing the per-file coverage
+// of the latest Codecov report of a GitHub repository, on its default branch or the given one
+func NewCodecovModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("codecov_coverage", codecovCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var owner, name, branch string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					owner = constraint.Value.Text()
+				case 1:
+					name = constraint.Value.Text()
+				case 2:
+					branch = constraint.Value.Text()
+				}
+			}
+		}
+
+		var client = opts.Client
+		if token := opts.Context["codecovToken"]; token != "" {
+			client = client.With(func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) })
+		}
+
+		return rest.NewIterator(func(ctx context.Context) ([][]interface{}, bool, error) {
+			files, err := fetchCodecovReport(ctx, client, codecovURL, owner, name, branch)
+			if err != nil {
+				return nil, false, err
+			}
+
+			var rows [][]interface{}
+			for _, f := range files {
+				rows = append(rows, []interface{}{owner, name, branch, f.Path, f.Lines, f.Covered, f.percent()})
+			}
+			return rows, false, nil
+		}), nil
+	})
+}
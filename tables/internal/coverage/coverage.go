@@ -0,0 +1,199 @@
+// Package coverage implements tables over per-file code coverage, read from local coverage reports or from Codecov
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fileCoverage is the coverage of a single file
+type fileCoverage struct {
+	Path    string
+	Lines   int
+	Covered int
+}
+
+// percent returns the share of covered lines, or nil for files without any measured line
+func (f *fileCoverage) percent() interface{} {
+	if f.Lines == 0 {
+		return nil
+	}
+	return 100 * float64(f.Covered) / float64(f.Lines)
+}
+
+// parseReport parses a coverage report, detecting whether it's a Go cover profile, an LCOV tracefile or a Cobertura XML report
+func parseReport(b []byte) ([]*fileCoverage, error) {
+	var trimmed = bytes.TrimSpace(b)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("mode:")):
+		return parseGoProfile(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return parseCobertura(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("TN:")), bytes.HasPrefix(trimmed, []byte("SF:")):
+		return parseLCOV(trimmed)
+	default:
+		return nil, fmt.Errorf("unrecognized coverage report format, expected a Go cover profile, LCOV or Cobertura XML")
+	}
+}
+
+// parseGoProfile parses the output of go test -coverprofile, in which coverage is measured in statements rather than lines
+func parseGoProfile(b []byte) ([]*fileCoverage, error) {
+	type block struct {
+		statements int
+		count      int
+	}
+	var blocks = make(map[string]map[string]*block)
+
+	var scanner = bufio.NewScanner(bytes.NewReader(b))
+	scanner.Scan() // skip the mode line
+	for n := 2; scanner.Scan(); n++ {
+		var line = strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		// each line reads file.go:startLine.startCol,endLine.endCol statements count
+		var colon = strings.LastIndex(line, ":")
+		var fields = strings.Fields(line[colon+1:])
+		if colon < 0 || len(fields) != 3 {
+			return nil, fmt.Errorf("invalid cover profile line %d: %q", n, line)
+		}
+		statements, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cover profile line %d: %q", n, line)
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cover profile line %d: %q", n, line)
+		}
+
+		var file = line[:colon]
+		if blocks[file] == nil {
+			blocks[file] = make(map[string]*block)
+		}
+		// the same block appears multiple times in merged profiles
+		if existing, ok := blocks[file][fields[0]]; ok {
+			if count > existing.count {
+				existing.count = count
+			}
+			continue
+		}
+		blocks[file][fields[0]] = &block{statements: statements, count: count}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var files []*fileCoverage
+	for path, fileBlocks := range blocks {
+		var f = &fileCoverage{Path: path}
+		for _, b := range fileBlocks {
+			f.Lines += b.statements
+			if b.count > 0 {
+				f.Covered += b.statements
+			}
+		}
+		files = append(files, f)
+	}
+	return sorted(files), nil
+}
+
+// parseLCOV parses an LCOV tracefile, as produced by lcov, Istanbul / nyc, c8, grcov...
+func parseLCOV(b []byte) ([]*fileCoverage, error) {
+	var files []*fileCoverage
+	var current *fileCoverage
+	var hits map[int]bool
+
+	var scanner = bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		var line = strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "SF:"):
+			current, hits = &fileCoverage{Path: strings.TrimPrefix(line, "SF:")}, make(map[int]bool)
+		case strings.HasPrefix(line, "DA:") && current != nil:
+			var parts = strings.Split(strings.TrimPrefix(line, "DA:"), ",")
+			if len(parts) < 2 {
+				continue
+			}
+			number, err := strconv.Atoi(parts[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid LCOV line: %q", line)
+			}
+			count, _ := strconv.ParseFloat(parts[1], 64)
+			hits[number] = hits[number] || count > 0
+		case line == "end_of_record" && current != nil:
+			for _, hit := range hits {
+				current.Lines++
+				if hit {
+					current.Covered++
+				}
+			}
+			files, current = append(files, current), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sorted(files), nil
+}
+
+// parseCobertura parses a Cobertura XML report, as produced by coverage.py, JaCoCo converters, gcovr...
+func parseCobertura(b []byte) ([]*fileCoverage, error) {
+	var report struct {
+		Sources []string `xml:"sources>source"`
+		Classes []struct {
+			Filename string `xml:"filename,attr"`
+			Lines    []struct {
+				Number int `xml:"number,attr"`
+				Hits   int `xml:"hits,attr"`
+			} `xml:"lines>line"`
+		} `xml:"packages>package>classes>class"`
+	}
+	if err := xml.Unmarshal(b, &report); err != nil {
+		return nil, fmt.Errorf("invalid Cobertura report: %v", err)
+	}
+
+	// a file may be split across multiple classes
+	var hits = make(map[string]map[int]bool)
+	for _, class := range report.Classes {
+		if hits[class.Filename] == nil {
+			hits[class.Filename] = make(map[int]bool)
+		}
+		for _, line := range class.Lines {
+			hits[class.Filename][line.Number] = hits[class.Filename][line.Number] || line.Hits > 0
+		}
+	}
+
+	var files []*fileCoverage
+	for path, lines := range hits {
+		var f = &fileCoverage{Path: path}
+		for _, hit := range lines {
+			f.Lines++
+			if hit {
+				f.Covered++
+			}
+		}
+		files = append(files, f)
+	}
+	return sorted(files), nil
+}
+
+func sorted(files []*fileCoverage) []*fileCoverage {
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
+
+// readReport reads and parses the coverage report at path
+func readReport(path string) ([]*fileCoverage, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseReport(b)
+}
@@ -0,0 +1,98 @@
+package coverage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+)
+
+func assertFiles(t *testing.T, files []*fileCoverage, expected ...fileCoverage) {
+	t.Helper()
+	if len(files) != len(expected) {
+		t.Fatalf("expected %d files, got: %d", len(expected), len(files))
+	}
+	for i, f := range files {
+		if *f != expected[i] {
+			t.Fatalf("expected %+v, got: %+v", expected[i], *f)
+		}
+	}
+}
+
+func TestGoProfile(t *testing.T) {
+	var profile = `mode: set
+github.com/askgitdev/askgit/pkg/a.go:3.10,5.2 2 1
+github.com/askgitdev/askgit/pkg/a.go:7.10,9.2 3 0
+github.com/askgitdev/askgit/pkg/a.go:7.10,9.2 3 1
+github.com/askgitdev/askgit/pkg/b.go:1.1,2.2 4 0
+`
+	files, err := parseReport([]byte(profile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFiles(t, files,
+		fileCoverage{Path: "github.com/askgitdev/askgit/pkg/a.go", Lines: 5, Covered: 5},
+		fileCoverage{Path: "github.com/askgitdev/askgit/pkg/b.go", Lines: 4, Covered: 0},
+	)
+}
+
+func TestLCOV(t *testing.T) {
+	var tracefile = `TN:
+SF:src/index.js
+DA:1,1
+DA:2,0
+DA:3,4
+end_of_record
+SF:src/util.js
+DA:1,0
+end_of_record
+`
+	files, err := parseReport([]byte(tracefile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFiles(t, files, fileCoverage{Path: "src/index.js", Lines: 3, Covered: 2}, fileCoverage{Path: "src/util.js", Lines: 1})
+
+	if p := files[0].percent().(float64); int(p) != 66 {
+		t.Fatalf("unexpected coverage: %v", p)
+	}
+}
+
+func TestCobertura(t *testing.T) {
+	var report = `<?xml version="1.0" ?>
+<coverage line-rate="0.5">
+  <packages><package name="app"><classes>
+    <class name="a" filename="app/a.py"><lines><line number="1" hits="1"/><line number="2" hits="0"/></lines></class>
+    <class name="b" filename="app/a.py"><lines><line number="2" hits="3"/><line number="3" hits="0"/></lines></class>
+  </classes></package></packages>
+</coverage>`
+	files, err := parseReport([]byte(report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFiles(t, files, fileCoverage{Path: "app/a.py", Lines: 3, Covered: 2})
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if _, err := parseReport([]byte("{}")); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
+
+func TestCodecov(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/askgitdev/repos/askgit/report/" || r.URL.Query().Get("branch") != "main" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		_, _ = w.Write([]byte(`{"totals": {}, "files": [{"name": "cmd/root.go", "totals": {"lines": 10, "hits": 7, "coverage": 70.0}}]}`))
+	}))
+	defer srv.Close()
+
+	files, err := fetchCodecovReport(context.Background(), &rest.Client{}, srv.URL, "askgitdev", "askgit", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFiles(t, files, fileCoverage{Path: "cmd/root.go", Lines: 10, Covered: 7})
+}
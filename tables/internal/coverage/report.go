@@ -0,0 +1,43 @@
+package coverage
+
+import (
+	"context"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+var reportCols = []vtab.Column{
+	{Name: "path", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "file_path", Type: sqlite.SQLITE_TEXT},
+	{Name: "lines", Type: sqlite.SQLITE_INTEGER},
+	{Name: "covered_lines", Type: sqlite.SQLITE_INTEGER},
+	{Name: "coverage", Type: sqlite.SQLITE_FLOAT},
+}
+
+// NewReportModule returns the implementation of a table-valued-function listing the per-file coverage
+// of a local coverage report (Go cover profile, LCOV or Cobertura XML)
+func NewReportModule() sqlite.Module {
+	return vtab.NewTableFunc("coverage_report", reportCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var path string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && constraint.ColIndex == 0 {
+				path = constraint.Value.Text()
+			}
+		}
+
+		return rest.NewIterator(func(context.Context) ([][]interface{}, bool, error) {
+			files, err := readReport(path)
+			if err != nil {
+				return nil, false, err
+			}
+
+			var rows [][]interface{}
+			for _, f := range files {
+				rows = append(rows, []interface{}{path, f.Path, f.Lines, f.Covered, f.percent()})
+			}
+			return rows, false, nil
+		}), nil
+	})
+}
@@ -0,0 +1,115 @@
+package dataset
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+// parseCSV parses a CSV document whose first record is a header, returning every following record
+// as a JSON object keyed by the header's fields
+func parseCSV(b []byte, delimiter rune) ([]string, error) {
+	// strip the byte order mark spreadsheet applications like to add
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+
+	var r = csv.NewReader(bytes.NewReader(b))
+	r.Comma = delimiter
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var records []string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		var obj = make(map[string]string, len(header))
+		for i, field := range record {
+			if i < len(header) {
+				obj[header[i]] = field
+			} else {
+				// fields beyond the header are kept under their position
+				obj[fmt.Sprint(i+1)] = field
+			}
+		}
+
+		encoded, err := json.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, string(encoded))
+	}
+	return records, nil
+}
+
+var csvCols = []vtab.Column{
+	{Name: "path", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "delimiter", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "line", Type: sqlite.SQLITE_INTEGER},
+	{Name: "data", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewCSVModule returns the implementation of a table-valued-function reading a CSV file with a header,
+// from a local path or a URL. Each record is returned as a JSON object, e.g. SELECT json_extract(data, '$.email') FROM read_csv('team.csv')
+func NewCSVModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("read_csv", csvCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var source, delimiter string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					source = constraint.Value.Text()
+				case 1:
+					delimiter = constraint.Value.Text()
+				}
+			}
+		}
+
+		var comma = ','
+		if delimiter != "" {
+			if delimiter == `\t` {
+				delimiter = "\t"
+			}
+			if utf8.RuneCountInString(delimiter) != 1 {
+				return nil, fmt.Errorf("invalid delimiter %q: must be a single character", delimiter)
+			}
+			comma, _ = utf8.DecodeRuneInString(delimiter)
+		}
+
+		return rest.NewIterator(func(ctx context.Context) ([][]interface{}, bool, error) {
+			b, err := read(ctx, opts.Client, source, "text/csv, */*;q=0.8")
+			if err != nil {
+				return nil, false, err
+			}
+
+			records, err := parseCSV(b, comma)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to parse %s: %v", source, err)
+			}
+
+			var rows = make([][]interface{}, 0, len(records))
+			for i, record := range records {
+				rows = append(rows, []interface{}{source, delimiter, i + 1, record})
+			}
+			return rows, false, nil
+		}), nil
+	})
+}
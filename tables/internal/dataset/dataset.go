@@ -0,0 +1,26 @@
+// Package dataset implements table-valued functions reading local or remote CSV and JSON files,
+// so datasets such as team rosters can be joined with repository data without being imported first
+package dataset
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+)
+
+// Options configures the dataset tables
+type Options struct {
+	// Client is used to fetch remote files
+	Client *rest.Client
+}
+
+// read returns the contents of source, a URL or the path to a local file, asking for the given media type when fetching URLs
+func read(ctx context.Context, client *rest.Client, source, accept string) ([]byte, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return client.With(func(req *http.Request) { req.Header.Set("Accept", accept) }).Fetch(ctx, source)
+	}
+	return ioutil.ReadFile(source)
+}
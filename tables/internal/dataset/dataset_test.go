@@ -0,0 +1,102 @@
+package dataset
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+)
+
+func TestParseCSV(t *testing.T) {
+	var doc = "\xef\xbb\xbfname;team\nada;platform\nbob;web;extra\n"
+	records, err := parseCSV([]byte(doc), ';')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected = []string{`{"name":"ada","team":"platform"}`, `{"3":"extra","name":"bob","team":"web"}`}
+	if !reflect.DeepEqual(records, expected) {
+		t.Fatalf("expected %v, got: %v", expected, records)
+	}
+}
+
+func TestSelectRows(t *testing.T) {
+	doc, err := parseJSON([]byte(`{"data": {"items": [{"id": 1, "tags": ["a"]}, {"id": 2, "tags": ["b", "c"]}], "a.b": true}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cases = map[string][]string{
+		"":                        {`{"data":{"a.b":true,"items":[{"id":1,"tags":["a"]},{"id":2,"tags":["b","c"]}]}}`},
+		"$.data.items":            {`{"id":1,"tags":["a"]}`, `{"id":2,"tags":["b","c"]}`},
+		"$.data.items[*].id":      {`1`, `2`},
+		"$.data.items[-1].tags":   {`"b"`, `"c"`},
+		"$.data.items[*].tags[0]": {`"a"`, `"b"`},
+		"$.data['a.b']":           {`true`},
+		"$.missing":               {},
+	}
+
+	for path, expected := range cases {
+		rows, err := selectRows(doc, path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if !reflect.DeepEqual(rows, expected) {
+			t.Fatalf("%s: expected %v, got: %v", path, expected, rows)
+		}
+	}
+
+	if _, err := selectRows(doc, "data.items"); err == nil {
+		t.Fatal("expected an error for a path not starting with $")
+	}
+}
+
+func TestParseJSONLines(t *testing.T) {
+	doc, err := parseJSON([]byte("{\"id\": 1}\n{\"id\": 2}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := selectRows(doc, "$[*].id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rows, []string{"1", "2"}) {
+		t.Fatalf("unexpected rows: %v", rows)
+	}
+}
+
+func TestHTTPJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"services": [{"name": "api", "replicas": 3}, {"name": "web", "replicas": 1}]}`))
+	}))
+	defer srv.Close()
+
+	doc, err := fetchJSON(context.Background(), &rest.Client{}, srv.URL, `{"X-Api-Key": "secret"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := search(doc, "services[?replicas > `1`].name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rows, []string{`"api"`}) {
+		t.Fatalf("unexpected rows: %v", rows)
+	}
+
+	if rows, err = search(doc, "length(services)"); err != nil || !reflect.DeepEqual(rows, []string{"2"}) {
+		t.Fatalf("unexpected rows: %v (%v)", rows, err)
+	}
+
+	if _, err = fetchJSON(context.Background(), &rest.Client{}, "file:///etc/passwd", ""); err == nil {
+		t.Fatal("expected non-HTTP URLs to be rejected")
+	}
+}
@@ -0,0 +1,111 @@
+package dataset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"github.com/jmespath/go-jmespath"
+	"go.riyazali.net/sqlite"
+)
+
+// fetchJSON fetches the JSON document at url, sending the extra headers given as a JSON object
+func fetchJSON(ctx context.Context, client *rest.Client, url, headersJSON string) (interface{}, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("invalid URL %q: only http and https are supported", url)
+	}
+
+	var headers map[string]string
+	if headersJSON != "" {
+		if err := json.Unmarshal([]byte(headersJSON), &headers); err != nil {
+			return nil, fmt.Errorf("invalid headers, expected a JSON object of strings: %v", err)
+		}
+	}
+
+	var doc interface{}
+	var err = client.With(func(req *http.Request) {
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+	}).GetJSON(ctx, url, &doc)
+	return doc, err
+}
+
+// search applies a JMESPath expression to doc, spreading the elements of a resulting array over rows,
+// and returns every row as JSON
+func search(doc interface{}, expression string) ([]string, error) {
+	var result = doc
+	if expression != "" {
+		var err error
+		if result, err = jmespath.Search(expression, doc); err != nil {
+			return nil, fmt.Errorf("invalid JMESPath expression %q: %v", expression, err)
+		}
+	}
+
+	var elements = []interface{}{result}
+	if arr, ok := result.([]interface{}); ok {
+		elements = arr
+	} else if result == nil {
+		elements = nil
+	}
+
+	var rows = make([]string, 0, len(elements))
+	for _, e := range elements {
+		encoded, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, string(encoded))
+	}
+	return rows, nil
+}
+
+var httpCols = []vtab.Column{
+	{Name: "url", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "expression", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "headers", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "idx", Type: sqlite.SQLITE_INTEGER},
+	{Name: "value", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewHTTPModule returns the implementation of a table-valued-function fetching a JSON API and returning a row
+// per element of the result of a JMESPath expression, as an escape hatch for APIs without a dedicated table
+func NewHTTPModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("http_json", httpCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var url, expression, headers string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					url = constraint.Value.Text()
+				case 1:
+					expression = constraint.Value.Text()
+				case 2:
+					headers = constraint.Value.Text()
+				}
+			}
+		}
+
+		return rest.NewIterator(func(ctx context.Context) ([][]interface{}, bool, error) {
+			doc, err := fetchJSON(ctx, opts.Client, url, headers)
+			if err != nil {
+				return nil, false, err
+			}
+
+			values, err := search(doc, expression)
+			if err != nil {
+				return nil, false, err
+			}
+
+			var rows = make([][]interface{}, 0, len(values))
+			for i, value := range values {
+				rows = append(rows, []interface{}{url, expression, headers, i, value})
+			}
+			return rows, false, nil
+		}), nil
+	})
+}
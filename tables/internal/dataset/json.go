@@ -0,0 +1,106 @@
+package dataset
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+// parseJSON decodes a JSON document. Files made of several values, such as JSON lines, are decoded as an array of them.
+func parseJSON(b []byte) (interface{}, error) {
+	var values []interface{}
+	var dec = json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	for {
+		var v interface{}
+		if err := dec.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+
+	if len(values) == 1 {
+		return values[0], nil
+	}
+	return values, nil
+}
+
+// selectRows applies path to doc, spreading the elements of a single matched array over rows,
+// and returns every row as JSON
+func selectRows(doc interface{}, path string) ([]string, error) {
+	matches, err := selectPath(doc, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 1 {
+		if arr, ok := matches[0].([]interface{}); ok {
+			matches = arr
+		}
+	}
+
+	var rows = make([]string, 0, len(matches))
+	for _, m := range matches {
+		encoded, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, string(encoded))
+	}
+	return rows, nil
+}
+
+var jsonCols = []vtab.Column{
+	{Name: "path", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "json_path", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "idx", Type: sqlite.SQLITE_INTEGER},
+	{Name: "value", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewJSONModule returns the implementation of a table-valued-function reading a JSON (or JSON lines) file,
+// from a local path or a URL, returning a row per element selected by a JSONPath expression
+func NewJSONModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("read_json", jsonCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var source, path string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					source = constraint.Value.Text()
+				case 1:
+					path = constraint.Value.Text()
+				}
+			}
+		}
+
+		return rest.NewIterator(func(ctx context.Context) ([][]interface{}, bool, error) {
+			b, err := read(ctx, opts.Client, source, "application/json")
+			if err != nil {
+				return nil, false, err
+			}
+
+			doc, err := parseJSON(b)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to parse %s: %v", source, err)
+			}
+
+			values, err := selectRows(doc, path)
+			if err != nil {
+				return nil, false, err
+			}
+
+			var rows = make([][]interface{}, 0, len(values))
+			for i, value := range values {
+				rows = append(rows, []interface{}{source, path, i, value})
+			}
+			return rows, false, nil
+		}), nil
+	})
+}
@@ -0,0 +1,123 @@
+package dataset
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// selectPath evaluates a JSONPath expression against a decoded JSON document. A subset of JSONPath is supported:
+// the root ($), member access (.name or ['name']), array indices ([0], negative ones counting from the end) and wildcards (.* or [*]).
+// The matches are returned in document order.
+func selectPath(doc interface{}, path string) ([]interface{}, error) {
+	path = strings.TrimSpace(path)
+	if path == "" || path == "$" {
+		return []interface{}{doc}, nil
+	}
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid JSON path %q: must start with $", path)
+	}
+
+	var nodes = []interface{}{doc}
+	for rest := path[1:]; rest != ""; {
+		var step string
+		var err error
+		switch {
+		case strings.HasPrefix(rest, "."):
+			var end = strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			step, rest = rest[1:end+1], rest[end+1:]
+		case strings.HasPrefix(rest, "["):
+			var end = strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: unterminated [", path)
+			}
+			step, rest = rest[1:end], rest[end+1:]
+			if unquoted, uerr := unquote(step); uerr == nil {
+				// a quoted member name, which may contain dots or brackets
+				nodes = apply(nodes, func(n interface{}) []interface{} { return member(n, unquoted) })
+				continue
+			}
+		default:
+			return nil, fmt.Errorf("invalid JSON path %q: unexpected %q", path, rest)
+		}
+
+		if step == "" {
+			return nil, fmt.Errorf("invalid JSON path %q: empty step", path)
+		}
+
+		if step == "*" {
+			nodes = apply(nodes, children)
+			continue
+		}
+
+		var index int
+		if index, err = strconv.Atoi(step); err == nil {
+			nodes = apply(nodes, func(n interface{}) []interface{} { return element(n, index) })
+			continue
+		}
+
+		var name = step
+		nodes = apply(nodes, func(n interface{}) []interface{} { return member(n, name) })
+	}
+	return nodes, nil
+}
+
+func unquote(s string) (string, error) {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1], nil
+	}
+	return "", fmt.Errorf("not quoted")
+}
+
+func apply(nodes []interface{}, fn func(interface{}) []interface{}) []interface{} {
+	var out []interface{}
+	for _, n := range nodes {
+		out = append(out, fn(n)...)
+	}
+	return out
+}
+
+func member(n interface{}, name string) []interface{} {
+	if obj, ok := n.(map[string]interface{}); ok {
+		if v, ok := obj[name]; ok {
+			return []interface{}{v}
+		}
+	}
+	return nil
+}
+
+func element(n interface{}, index int) []interface{} {
+	if arr, ok := n.([]interface{}); ok {
+		if index < 0 {
+			index += len(arr)
+		}
+		if index >= 0 && index < len(arr) {
+			return []interface{}{arr[index]}
+		}
+	}
+	return nil
+}
+
+// children returns the elements of an array, or the values of an object ordered by key
+func children(n interface{}) []interface{} {
+	switch v := n.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		var keys = make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var out = make([]interface{}, 0, len(keys))
+		for _, k := range keys {
+			out = append(out, v[k])
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+// Package feed implements a table parsing RSS and Atom feeds, such as release feeds and blogs
+package feed
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+// Options configures the feed table
+type Options struct {
+	// Client is used to fetch feeds
+	Client *rest.Client
+}
+
+type entry struct {
+	Title     string
+	Link      string
+	Published time.Time
+	Author    string
+	ID        string
+}
+
+// item is an RSS item
+type item struct {
+	Title   string `xml:"title"`
+	Link    string `xml:"link"`
+	GUID    string `xml:"guid"`
+	PubDate string `xml:"pubDate"`
+	Author  string `xml:"author"`
+	Creator string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Date    string `xml:"http://purl.org/dc/elements/1.1/ date"`
+}
+
+// document holds the elements of both RSS and Atom documents, of which only one set is populated
+type document struct {
+	XMLName xml.Name
+	// RSS, whose items are children of the channel in RSS 2.0 and of the root element in RSS 1.0
+	Items    []*item `xml:"channel>item"`
+	RDFItems []*item `xml:"item"`
+	// Atom
+	Entries []*struct {
+		Title string `xml:"title"`
+		ID    string `xml:"id"`
+		Links []*struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		Authors   []*struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+	} `xml:"entry"`
+}
+
+// dateLayouts are the formats dates are found in, feeds in the wild not always sticking to their specification
+var dateLayouts = []string{time.RFC1123Z, time.RFC1123, time.RFC3339, time.RFC3339Nano, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST", "2006-01-02"}
+
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+// parse parses an RSS 2.0 or Atom document
+func parse(b []byte) ([]*entry, error) {
+	var doc document
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("invalid feed: %v", err)
+	}
+
+	var entries []*entry
+	switch doc.XMLName.Local {
+	case "rss", "RDF":
+		for _, item := range append(doc.Items, doc.RDFItems...) {
+			var e = &entry{Title: strings.TrimSpace(item.Title), Link: strings.TrimSpace(item.Link), ID: item.GUID, Author: item.Author}
+			if e.Author == "" {
+				e.Author = item.Creator
+			}
+			if e.Published = parseDate(item.PubDate); e.Published.IsZero() {
+				e.Published = parseDate(item.Date)
+			}
+			entries = append(entries, e)
+		}
+	case "feed":
+		for _, item := range doc.Entries {
+			var e = &entry{Title: strings.TrimSpace(item.Title), ID: item.ID}
+			for _, link := range item.Links {
+				if link.Rel == "" || link.Rel == "alternate" {
+					e.Link = link.Href
+					break
+				}
+			}
+			var authors []string
+			for _, author := range item.Authors {
+				authors = append(authors, author.Name)
+			}
+			e.Author = strings.Join(authors, ", ")
+			if e.Published = parseDate(item.Published); e.Published.IsZero() {
+				e.Published = parseDate(item.Updated)
+			}
+			entries = append(entries, e)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported feed format: <%s>, expected RSS or Atom", doc.XMLName.Local)
+	}
+	return entries, nil
+}
+
+// fetch reads the feed at the URL source
+func fetch(ctx context.Context, client *rest.Client, source string) ([]*entry, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return nil, fmt.Errorf("expected the http(s) URL of a feed, got: %q", source)
+	}
+
+	b, err := client.Fetch(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	return parse(b)
+}
+
+var feedCols = []vtab.Column{
+	{Name: "url", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "title", Type: sqlite.SQLITE_TEXT},
+	{Name: "link", Type: sqlite.SQLITE_TEXT},
+	{Name: "published", Type: sqlite.SQLITE_TEXT},
+	{Name: "author", Type: sqlite.SQLITE_TEXT},
+	{Name: "id", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewFeedModule returns the implementation of a table-valued-function listing the entries of an RSS or Atom feed
+func NewFeedModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("feed", feedCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var source string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && constraint.ColIndex == 0 {
+				source = constraint.Value.Text()
+			}
+		}
+
+		return rest.NewIterator(func(ctx context.Context) ([][]interface{}, bool, error) {
+			entries, err := fetch(ctx, opts.Client.With(acceptFeeds), source)
+			if err != nil {
+				return nil, false, err
+			}
+
+			var rows [][]interface{}
+			for _, e := range entries {
+				rows = append(rows, []interface{}{source, e.Title, e.Link, e.Published, e.Author, e.ID})
+			}
+			return rows, false, nil
+		}), nil
+	})
+}
+
+// acceptFeeds asks for feeds rather than JSON
+func acceptFeeds(req *http.Request) {
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, */*;q=0.8")
+}
@@ -0,0 +1,72 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+)
+
+const rss = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel>
+    <title>Blog</title>
+    <item><title> Announcing v1.0 </title><link>https://example.com/v1</link><pubDate>Mon, 01 Mar 2021 10:00:00 +0000</pubDate><dc:creator>Ada</dc:creator></item>
+  </channel>
+</rss>`
+
+const atom = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <id>tag:github.com,2008:Repository/1/v1.0.0</id>
+    <title>v1.0.0</title>
+    <link rel="alternate" type="text/html" href="https://github.com/askgitdev/askgit/releases/tag/v1.0.0"/>
+    <updated>2021-03-01T10:00:00Z</updated>
+    <author><name>ada</name></author>
+  </entry>
+</feed>`
+
+func TestParse(t *testing.T) {
+	entries, err := parse([]byte(rss))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Title != "Announcing v1.0" || entries[0].Author != "Ada" || entries[0].Published.IsZero() {
+		t.Fatalf("unexpected RSS entries: %+v", entries[0])
+	}
+
+	entries, err = parse([]byte(atom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Link != "https://github.com/askgitdev/askgit/releases/tag/v1.0.0" || entries[0].Published.IsZero() {
+		t.Fatalf("unexpected Atom entries: %+v", entries[0])
+	}
+
+	if _, err = parse([]byte(`<html></html>`)); err == nil {
+		t.Fatal("expected an error for a document that isn't a feed")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/atom+xml")
+		_, _ = w.Write([]byte(atom))
+	}))
+	defer srv.Close()
+
+	entries, err := fetch(context.Background(), &rest.Client{}, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got: %d", len(entries))
+	}
+
+	// feeds are only fetched, never read from the local filesystem
+	if _, err = fetch(context.Background(), &rest.Client{}, "/etc/passwd"); err == nil {
+		t.Fatal("expected a local path to be rejected")
+	}
+}
@@ -0,0 +1,257 @@
+package filesystem
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/internal/tz"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+// Options configures the archive tables
+type Options struct {
+	// Client is used to download remote archives
+	Client *rest.Client
+}
+
+// entry is a file in an archive
+type entry struct {
+	name           string
+	size           int64
+	compressedSize interface{} // nil in formats that don't compress files individually
+	mode           os.FileMode
+	mtime          time.Time
+
+	// open returns a reader over the contents of the entry, only called if the content column is queried
+	open     func() (io.Reader, error)
+	content  []byte
+	hasBytes bool
+}
+
+// entryIter is an iterator over the entries of an archive
+type entryIter struct {
+	next   func() (*entry, error)
+	closer io.Closer
+	cur    *entry
+}
+
+// Next implements vtab.Iterator
+func (i *entryIter) Next() (vtab.Row, error) {
+	var err error
+	if i.cur, err = i.next(); err != nil {
+		_ = i.Close()
+		return nil, err
+	}
+	return i, nil
+}
+
+// Close releases the archive file, if any
+func (i *entryIter) Close() error {
+	if i.closer == nil {
+		return nil
+	}
+	var err = i.closer.Close()
+	i.closer = nil
+	return err
+}
+
+// Column implements vtab.Row
+func (i *entryIter) Column(ctx *sqlite.Context, c int) error {
+	var e = i.cur
+	switch archiveCols[c].Name {
+	case "name":
+		ctx.ResultText(e.name)
+	case "size":
+		ctx.ResultInt64(e.size)
+	case "compressed_size":
+		if size, ok := e.compressedSize.(int64); ok {
+			ctx.ResultInt64(size)
+		} else {
+			ctx.ResultNull()
+		}
+	case "mode":
+		ctx.ResultText(e.mode.String())
+	case "mtime":
+		if e.mtime.IsZero() {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(tz.Format(e.mtime, time.RFC3339Nano))
+		}
+	case "is_dir":
+		if e.mode.IsDir() {
+			ctx.ResultInt(1)
+		} else {
+			ctx.ResultInt(0)
+		}
+	case "content":
+		if !e.mode.IsRegular() {
+			ctx.ResultNull()
+			return nil
+		}
+		if !e.hasBytes {
+			r, err := e.open()
+			if err != nil {
+				return err
+			}
+			if e.content, err = ioutil.ReadAll(r); err != nil {
+				return err
+			}
+			if closer, ok := r.(io.Closer); ok {
+				closer.Close()
+			}
+			e.hasBytes = true
+		}
+		ctx.ResultBlob(e.content)
+	}
+	return nil
+}
+
+// archive is an archive opened for reading, either a local file or one downloaded in memory
+type archive struct {
+	r      io.ReaderAt
+	size   int64
+	closer io.Closer
+}
+
+// openArchive opens source, a URL or the path to a local file
+func openArchive(ctx context.Context, client *rest.Client, source string) (*archive, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		body, err := client.Fetch(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		return &archive{r: bytes.NewReader(body), size: int64(len(body))}, nil
+	}
+
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &archive{r: f, size: info.Size(), closer: f}, nil
+}
+
+// zipEntries returns an iterator over the files of a zip archive
+func zipEntries(a *archive) (*entryIter, error) {
+	z, err := zip.NewReader(a.r, a.size)
+	if err != nil {
+		return nil, err
+	}
+
+	var files = z.File
+	return &entryIter{closer: a.closer, next: func() (*entry, error) {
+		if len(files) == 0 {
+			return nil, io.EOF
+		}
+		var f = files[0]
+		files = files[1:]
+
+		return &entry{
+			name:           f.Name,
+			size:           int64(f.UncompressedSize64),
+			compressedSize: int64(f.CompressedSize64),
+			mode:           f.Mode(),
+			mtime:          f.Modified,
+			open:           func() (io.Reader, error) { return f.Open() },
+		}, nil
+	}}, nil
+}
+
+// tarEntries returns an iterator over the files of a tar archive, compressed with gzip or bzip2 or not at all
+func tarEntries(a *archive) (*entryIter, error) {
+	var r = bufio.NewReader(io.NewSectionReader(a.r, 0, a.size))
+	magic, _ := r.Peek(3)
+
+	var tr *tar.Reader
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		tr = tar.NewReader(gz)
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		tr = tar.NewReader(bzip2.NewReader(r))
+	default:
+		tr = tar.NewReader(r)
+	}
+
+	return &entryIter{closer: a.closer, next: func() (*entry, error) {
+		hdr, err := tr.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		// the contents of an entry can only be read until moving on to the next one,
+		// which SQLite doesn't do before it's done with the columns of the current row
+		return &entry{
+			name:  hdr.Name,
+			size:  hdr.Size,
+			mode:  hdr.FileInfo().Mode(),
+			mtime: hdr.ModTime,
+			open:  func() (io.Reader, error) { return tr, nil },
+		}, nil
+	}}, nil
+}
+
+var archiveCols = []vtab.Column{
+	{Name: "source", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "name", Type: sqlite.SQLITE_TEXT},
+	{Name: "size", Type: sqlite.SQLITE_INTEGER},
+	{Name: "compressed_size", Type: sqlite.SQLITE_INTEGER},
+	{Name: "mode", Type: sqlite.SQLITE_TEXT},
+	{Name: "mtime", Type: sqlite.SQLITE_TEXT},
+	{Name: "is_dir", Type: sqlite.SQLITE_INTEGER},
+	{Name: "content", Type: sqlite.SQLITE_BLOB},
+}
+
+func newArchiveModule(name string, opts *Options, entries func(*archive) (*entryIter, error)) sqlite.Module {
+	return vtab.NewTableFunc(name, archiveCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var source string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && constraint.ColIndex == 0 {
+				source = constraint.Value.Text()
+			}
+		}
+
+		a, err := openArchive(context.Background(), opts.Client, source)
+		if err != nil {
+			return nil, err
+		}
+
+		iter, err := entries(a)
+		if err != nil {
+			if a.closer != nil {
+				a.closer.Close()
+			}
+			return nil, err
+		}
+		return iter, nil
+	})
+}
+
+// NewZipModule returns the implementation of a table-valued-function listing the files of a zip archive
+func NewZipModule(opts *Options) sqlite.Module {
+	return newArchiveModule("zip_entries", opts, zipEntries)
+}
+
+// NewTarModule returns the implementation of a table-valued-function listing the files of a (compressed) tar archive
+func NewTarModule(opts *Options) sqlite.Module {
+	return newArchiveModule("tar_entries", opts, tarEntries)
+}
@@ -0,0 +1,102 @@
+package filesystem
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+var archiveFiles = []struct{ name, contents string }{
+	{"bin/askgit", "\x7fELF"},
+	{"README.md", "# askgit"},
+}
+
+// readEntries returns the names and contents of the files of an archive
+func readEntries(t *testing.T, iter *entryIter) map[string]string {
+	var files = make(map[string]string)
+	for {
+		e, err := iter.next()
+		if err == io.EOF {
+			return files
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := e.open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int64(len(b)) != e.size {
+			t.Fatalf("expected %s to be %d bytes, got: %d", e.name, e.size, len(b))
+		}
+		files[e.name] = string(b)
+	}
+}
+
+func checkEntries(t *testing.T, files map[string]string) {
+	if len(files) != len(archiveFiles) {
+		t.Fatalf("expected %d files, got: %v", len(archiveFiles), files)
+	}
+	for _, f := range archiveFiles {
+		if files[f.name] != f.contents {
+			t.Fatalf("unexpected contents of %s: %q", f.name, files[f.name])
+		}
+	}
+}
+
+func TestZipEntries(t *testing.T) {
+	var buf bytes.Buffer
+	var w = zip.NewWriter(&buf)
+	for _, f := range archiveFiles {
+		fw, err := w.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = fw.Write([]byte(f.contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	iter, err := zipEntries(&archive{r: bytes.NewReader(buf.Bytes()), size: int64(buf.Len())})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEntries(t, readEntries(t, iter))
+}
+
+func TestTarEntries(t *testing.T) {
+	var buf bytes.Buffer
+	var gz = gzip.NewWriter(&buf)
+	var w = tar.NewWriter(gz)
+	for _, f := range archiveFiles {
+		if err := w.WriteHeader(&tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.contents))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(f.contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	iter, err := tarEntries(&archive{r: bytes.NewReader(buf.Bytes()), size: int64(buf.Len())})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEntries(t, readEntries(t, iter))
+}
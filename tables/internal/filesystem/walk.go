@@ -0,0 +1,125 @@
+// Package filesystem implements tables over files outside of git repositories, such as directories on disk and
+// archives, so that build outputs and release artifacts can be analyzed with the same SQL as repositories
+package filesystem
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+// sniffLen is the number of bytes read to detect the type of files without a well-known extension
+const sniffLen = 512
+
+// mimeType returns the media type of a file, from its extension or, failing that, from its first bytes
+func mimeType(path string, info os.FileInfo) string {
+	if info.IsDir() || !info.Mode().IsRegular() {
+		return ""
+	}
+	if t := mime.TypeByExtension(filepath.Ext(path)); t != "" {
+		return t
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	var buf = make([]byte, sniffLen)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return ""
+	}
+	return http.DetectContentType(buf[:n])
+}
+
+// readDir returns the entries of dir, sorted by name
+func readDir(dir string) ([]os.FileInfo, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries, err := f.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// walk returns a function listing the entries under root a directory at a time, so that large trees
+// are only read as far as the query needs. Symbolic links are listed, but not followed.
+// The subdirectories that can't be read for lack of permission are listed, but not their entries.
+func walk(root string) rest.PageFunc {
+	var dirs = []string{root}
+	return func(context.Context) ([][]interface{}, bool, error) {
+		var dir = dirs[len(dirs)-1]
+		dirs = dirs[:len(dirs)-1]
+
+		entries, err := readDir(dir)
+		if err != nil && (dir == root || !os.IsPermission(err)) {
+			return nil, false, err
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+		var rows = make([][]interface{}, 0, len(entries))
+		var subdirs []string
+		for _, info := range entries {
+			var path = filepath.Join(dir, info.Name())
+			if info.IsDir() {
+				subdirs = append(subdirs, path)
+			}
+
+			rows = append(rows, []interface{}{
+				root, path, info.Name(), info.Size(), info.Mode().String(), info.ModTime(),
+				info.IsDir(), info.Mode()&os.ModeSymlink != 0, mimeType(path, info),
+			})
+		}
+
+		// subdirectories are pushed in reverse, so they're visited in order
+		for i := len(subdirs) - 1; i >= 0; i-- {
+			dirs = append(dirs, subdirs[i])
+		}
+		return rows, len(dirs) > 0, nil
+	}
+}
+
+var walkCols = []vtab.Column{
+	{Name: "root", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "path", Type: sqlite.SQLITE_TEXT},
+	{Name: "name", Type: sqlite.SQLITE_TEXT},
+	{Name: "size", Type: sqlite.SQLITE_INTEGER},
+	{Name: "mode", Type: sqlite.SQLITE_TEXT},
+	{Name: "mtime", Type: sqlite.SQLITE_TEXT},
+	{Name: "is_dir", Type: sqlite.SQLITE_INTEGER},
+	{Name: "is_symlink", Type: sqlite.SQLITE_INTEGER},
+	{Name: "mime_type", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewWalkModule returns the implementation of a table-valued-function listing every file and directory under a path
+func NewWalkModule() sqlite.Module {
+	return vtab.NewTableFunc("fs_walk", walkCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var root string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && constraint.ColIndex == 0 {
+				root = constraint.Value.Text()
+			}
+		}
+
+		if root == "" {
+			root = "."
+		}
+		return rest.NewIterator(walk(root)), nil
+	})
+}
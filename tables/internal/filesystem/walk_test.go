@@ -0,0 +1,102 @@
+package filesystem
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs_walk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var files = map[string]string{
+		"README.md":         "# hello",
+		"build/app":         "\x7fELF\x02\x01\x01",
+		"build/static/a.js": "console.log(1)",
+		"notes":             "plain text",
+	}
+	for name, contents := range files {
+		var path = filepath.Join(dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var next = walk(dir)
+	var paths []string
+	var types = make(map[string]string)
+	for more := true; more; {
+		var rows [][]interface{}
+		if rows, more, err = next(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		for _, row := range rows {
+			rel, _ := filepath.Rel(dir, row[1].(string))
+			paths = append(paths, filepath.ToSlash(rel))
+			types[filepath.ToSlash(rel)] = row[8].(string)
+		}
+	}
+
+	var expected = []string{"README.md", "build", "notes", "build/app", "build/static", "build/static/a.js"}
+	if len(paths) != len(expected) {
+		t.Fatalf("expected %v, got: %v", expected, paths)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Fatalf("expected %v, got: %v", expected, paths)
+		}
+	}
+
+	if types["notes"] != "text/plain; charset=utf-8" || types["build"] != "" {
+		t.Fatalf("unexpected mime types: %v", types)
+	}
+}
+
+func TestWalkPermissionDenied(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("directory permissions aren't enforced")
+	}
+
+	dir, err := ioutil.TempDir("", "fs_walk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"private/secret", "public"} {
+		if err = os.MkdirAll(filepath.Join(dir, filepath.FromSlash(name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = os.Chmod(filepath.Join(dir, "private"), 0000); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(filepath.Join(dir, "private"), 0755)
+
+	var next = walk(dir)
+	var paths []string
+	for more := true; more; {
+		var rows [][]interface{}
+		if rows, more, err = next(context.Background()); err != nil {
+			t.Fatalf("expected the unreadable directory to be skipped, got: %v", err)
+		}
+		for _, row := range rows {
+			rel, _ := filepath.Rel(dir, row[1].(string))
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+	}
+
+	if len(paths) != 2 || paths[0] != "private" || paths[1] != "public" {
+		t.Fatalf("expected the unreadable directory to be listed, but not its entries, got: %v", paths)
+	}
+}
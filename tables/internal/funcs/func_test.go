@@ -31,6 +31,8 @@ func init() {
 			"enry_is_image":         &EnryIsImage{},
 			"enry_is_test":          &EnryIsTest{},
 			"enry_is_vendor":        &EnryIsVendor{},
+			"is_bot_login":          &IsBotLoginFn{},
+			"to_tz":                 &ToTz{},
 		}
 
 		// alias yaml_to_json => yml_to_json
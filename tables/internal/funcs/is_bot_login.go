@@ -0,0 +1,68 @@
+package funcs
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"go.riyazali.net/sqlite"
+)
+
+// knownBots are the logins of popular bots that don't follow any naming convention
+var knownBots = map[string]bool{
+	"dependabot":      true,
+	"renovate":        true,
+	"greenkeeper":     true,
+	"github-actions":  true,
+	"codecov":         true,
+	"coveralls":       true,
+	"mergify":         true,
+	"imgbot":          true,
+	"allcontributors": true,
+	"netlify":         true,
+	"vercel":          true,
+	"sonarcloud":      true,
+	"k8s-ci-robot":    true,
+}
+
+// botLogin matches the logins of GitHub Apps (e.g. dependabot[bot]) and accounts named as bots (e.g. ci-bot, bot_deploy)
+var botLogin = regexp.MustCompile(`(?i)(\[bot\]|[-_.]bot|[-_.]robot)$|^(ro)?bot[-_.]`)
+
+// IsBotLogin reports whether login looks like the login of a bot.
+// typename is the GraphQL __typename of the account if known ("Bot" for GitHub Apps), or empty.
+func IsBotLogin(login, typename string) bool {
+	if typename == "Bot" {
+		return true
+	}
+	return botLogin.MatchString(login) || knownBots[strings.ToLower(login)]
+}
+
+var errIsBotLoginArgs = errors.New("is_bot_login takes a login, and optionally its GraphQL __typename")
+
+// IsBotLoginFn implements the is_bot_login scalar sql function.
+// The function signature of the equivalent sql function is:
+//
+//	is_bot_login(login, [typename]) int
+type IsBotLoginFn struct{}
+
+func (f *IsBotLoginFn) Args() int           { return -1 }
+func (f *IsBotLoginFn) Deterministic() bool { return true }
+func (f *IsBotLoginFn) Apply(context *sqlite.Context, value ...sqlite.Value) {
+	var login, typename string
+	switch len(value) {
+	case 2:
+		typename = value[1].Text()
+		fallthrough
+	case 1:
+		login = value[0].Text()
+	default:
+		context.ResultError(errIsBotLoginArgs)
+		return
+	}
+
+	if IsBotLogin(login, typename) {
+		context.ResultInt(1)
+	} else {
+		context.ResultInt(0)
+	}
+}
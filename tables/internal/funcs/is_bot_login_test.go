@@ -0,0 +1,45 @@
+package funcs
+
+import (
+	"testing"
+
+	"github.com/askgitdev/askgit/tables/internal/tools"
+)
+
+func TestIsBotLogin(t *testing.T) {
+	for login, want := range map[string]bool{
+		"dependabot[bot]": true,
+		"renovate":        true,
+		"k8s-ci-robot":    true,
+		"deploy-bot":      true,
+		"bot_release":     true,
+		"abbot":           false,
+		"talbot":          false,
+		"patrickdevivo":   false,
+		"":                false,
+	} {
+		if got := IsBotLogin(login, ""); got != want {
+			t.Errorf("IsBotLogin(%q): expected %v, got %v", login, want, got)
+		}
+	}
+
+	if !IsBotLogin("some-app", "Bot") {
+		t.Errorf("expected accounts of type Bot to be bots")
+	}
+}
+
+func TestIsBotLoginFn(t *testing.T) {
+	rows, err := FixtureDatabase.Query("SELECT is_bot_login('dependabot[bot]'), is_bot_login('octocat'), is_bot_login('octo-app', 'Bot')")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rowNum, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("err %d at row %d", err, rowNum)
+	}
+
+	if contents[0][0] != "1" || contents[0][1] != "0" || contents[0][2] != "1" {
+		t.Fatalf("expected 1, 0 and 1, got %v", contents[0])
+	}
+}
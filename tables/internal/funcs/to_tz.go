@@ -0,0 +1,28 @@
+package funcs
+
+import (
+	"github.com/askgitdev/askgit/tables/internal/tz"
+	"go.riyazali.net/sqlite"
+)
+
+// ToTz implements the to_tz scalar sql function, converting an RFC 3339 timestamp to a timezone.
+// The function signature of the equivalent sql function is:
+//
+//	to_tz(timestamp, zone) string
+type ToTz struct{}
+
+func (f *ToTz) Args() int           { return 2 }
+func (f *ToTz) Deterministic() bool { return true }
+func (f *ToTz) Apply(context *sqlite.Context, value ...sqlite.Value) {
+	if value[0].IsNil() {
+		context.ResultNull()
+		return
+	}
+
+	ts, err := tz.Convert(value[0].Text(), value[1].Text())
+	if err != nil {
+		context.ResultError(err)
+		return
+	}
+	context.ResultText(ts)
+}
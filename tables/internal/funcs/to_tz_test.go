@@ -0,0 +1,26 @@
+package funcs
+
+import (
+	"testing"
+
+	"github.com/askgitdev/askgit/tables/internal/tools"
+)
+
+func TestToTz(t *testing.T) {
+	rows, err := FixtureDatabase.Query("SELECT to_tz('2021-03-01T23:30:00-05:00', 'UTC'), to_tz(NULL, 'UTC')")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rowNum, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("err %d at row %d", err, rowNum)
+	}
+
+	if contents[0][0] != "2021-03-02T04:30:00Z" {
+		t.Fatalf("expected string: %s, got %s", "2021-03-02T04:30:00Z", contents[0][0])
+	}
+	if contents[0][1] != "NULL" {
+		t.Fatalf("expected: %s, got %s", "NULL", contents[0][1])
+	}
+}
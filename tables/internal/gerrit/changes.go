@@ -0,0 +1,54 @@
+package gerrit
+
+import (
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+var changesCols = []vtab.Column{
+	{Name: "url", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "query", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "number", Type: sqlite.SQLITE_INTEGER},
+	{Name: "change_id", Type: sqlite.SQLITE_TEXT},
+	{Name: "project", Type: sqlite.SQLITE_TEXT},
+	{Name: "branch", Type: sqlite.SQLITE_TEXT},
+	{Name: "topic", Type: sqlite.SQLITE_TEXT},
+	{Name: "subject", Type: sqlite.SQLITE_TEXT},
+	{Name: "status", Type: sqlite.SQLITE_TEXT},
+	{Name: "owner_name", Type: sqlite.SQLITE_TEXT},
+	{Name: "owner_email", Type: sqlite.SQLITE_TEXT},
+	{Name: "owner_username", Type: sqlite.SQLITE_TEXT},
+	{Name: "created", Type: sqlite.SQLITE_TEXT},
+	{Name: "updated", Type: sqlite.SQLITE_TEXT},
+	{Name: "submitted", Type: sqlite.SQLITE_TEXT},
+	{Name: "insertions", Type: sqlite.SQLITE_INTEGER},
+	{Name: "deletions", Type: sqlite.SQLITE_INTEGER},
+	{Name: "current_revision", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewChangesModule returns the implementation of a table-valued-function listing the changes
+// of a Gerrit server matching a search query, e.g. gerrit_changes('https://review.example.com', 'status:open')
+func NewChangesModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("gerrit_changes", changesCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var baseURL, query string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					baseURL = constraint.Value.Text()
+				case 1:
+					query = constraint.Value.Text()
+				}
+			}
+		}
+
+		return rest.NewIterator(changePages(opts, baseURL, query, []string{"DETAILED_ACCOUNTS"}, func(c *change) [][]interface{} {
+			return [][]interface{}{{
+				baseURL, query, c.Number, c.ChangeID, c.Project, c.Branch, c.Topic, c.Subject, c.Status,
+				c.Owner.Name, c.Owner.Email, c.Owner.Username, c.Created.Time, c.Updated.Time, c.Submitted.Time,
+				c.Insertions, c.Deletions, c.CurrentRevision,
+			}}
+		})), nil
+	})
+}
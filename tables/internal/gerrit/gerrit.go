@@ -0,0 +1,177 @@
+// Package gerrit implements tables over the changes, patchsets and review labels of a Gerrit code review server
+package gerrit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+)
+
+// Options configures the Gerrit tables
+type Options struct {
+	// Client is used to make requests to the Gerrit REST API
+	Client *rest.Client
+
+	// Context holds the gerritUser and gerritPassword credentials, if any
+	Context services.Context
+}
+
+// perPage is the number of changes fetched per request
+const perPage = 100
+
+// xssiPrefix is prepended by Gerrit to every JSON response, to prevent cross-site script inclusion
+var xssiPrefix = []byte(")]}'")
+
+// timeLayout is the format of the timestamps returned by Gerrit, always in UTC
+const timeLayout = "2006-01-02 15:04:05.000000000"
+
+// timestamp is a Gerrit timestamp
+type timestamp struct{ time.Time }
+
+func (t *timestamp) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		return nil
+	}
+
+	parsed, err := time.ParseInLocation(timeLayout, s, time.UTC)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+type account struct {
+	AccountID int    `json:"_account_id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+}
+
+type approval struct {
+	account
+	Value *int      `json:"value"`
+	Date  timestamp `json:"date"`
+}
+
+type label struct {
+	All []*approval `json:"all"`
+}
+
+type revision struct {
+	Kind     string    `json:"kind"`
+	Number   int       `json:"_number"`
+	Created  timestamp `json:"created"`
+	Uploader account   `json:"uploader"`
+	Ref      string    `json:"ref"`
+}
+
+type change struct {
+	ID              string               `json:"id"`
+	Number          int                  `json:"_number"`
+	ChangeID        string               `json:"change_id"`
+	Project         string               `json:"project"`
+	Branch          string               `json:"branch"`
+	Topic           string               `json:"topic"`
+	Subject         string               `json:"subject"`
+	Status          string               `json:"status"`
+	Owner           account              `json:"owner"`
+	Created         timestamp            `json:"created"`
+	Updated         timestamp            `json:"updated"`
+	Submitted       timestamp            `json:"submitted"`
+	Insertions      int                  `json:"insertions"`
+	Deletions       int                  `json:"deletions"`
+	CurrentRevision string               `json:"current_revision"`
+	Revisions       map[string]*revision `json:"revisions"`
+	Labels          map[string]*label    `json:"labels"`
+	MoreChanges     bool                 `json:"_more_changes"`
+}
+
+type fetchChangesOptions struct {
+	Client   *rest.Client
+	URL      string
+	Query    string
+	Options  []string
+	Start    int
+	PerPage  int
+	Username string
+}
+
+type fetchChangesResults struct {
+	Changes     []*change
+	HasNextPage bool
+}
+
+func fetchChanges(ctx context.Context, input *fetchChangesOptions) (*fetchChangesResults, error) {
+	var endpoint = strings.TrimSuffix(input.URL, "/")
+	// authenticated requests are made to the /a/ prefixed endpoints
+	if input.Username != "" {
+		endpoint += "/a"
+	}
+
+	var params = url.Values{}
+	if input.Query != "" {
+		params.Set("q", input.Query)
+	}
+	for _, o := range input.Options {
+		params.Add("o", o)
+	}
+	params.Set("n", fmt.Sprint(input.PerPage))
+	params.Set("S", fmt.Sprint(input.Start))
+
+	body, err := input.Client.Fetch(ctx, endpoint+"/changes/?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []*change
+	if err = json.Unmarshal(bytes.TrimPrefix(body, xssiPrefix), &changes); err != nil {
+		return nil, fmt.Errorf("failed to decode Gerrit changes: %v", err)
+	}
+
+	var results = &fetchChangesResults{Changes: changes}
+	// only the last change of a page tells whether there are more to come
+	if len(changes) > 0 {
+		results.HasNextPage = changes[len(changes)-1].MoreChanges
+	}
+	return results, nil
+}
+
+// changePages returns a function fetching the changes matching query one page at a time, turning them into rows with toRows
+func changePages(opts *Options, baseURL, query string, options []string, toRows func(*change) [][]interface{}) rest.PageFunc {
+	var username, password = opts.Context["gerritUser"], opts.Context["gerritPassword"]
+
+	var client = opts.Client
+	if username != "" {
+		client = opts.Client.With(func(req *http.Request) { req.SetBasicAuth(username, password) })
+	}
+
+	var start int
+	return func(ctx context.Context) ([][]interface{}, bool, error) {
+		results, err := fetchChanges(ctx, &fetchChangesOptions{
+			Client: client, URL: baseURL, Query: query, Options: options, Start: start, PerPage: perPage, Username: username,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		start += len(results.Changes)
+
+		var rows [][]interface{}
+		for _, c := range results.Changes {
+			rows = append(rows, toRows(c)...)
+		}
+		return rows, results.HasNextPage, nil
+	}
+}
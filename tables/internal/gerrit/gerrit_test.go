@@ -0,0 +1,70 @@
+package gerrit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+)
+
+const page1 = `)]}'
+[{"_number": 1, "change_id": "I1", "status": "NEW", "created": "2021-03-01 09:59:32.126000000",
+  "labels": {"Code-Review": {"all": [{"name": "Ada", "value": 2, "date": "2021-03-02 10:00:00.000000000"}, {"name": "Bob"}]}},
+  "_more_changes": true}]`
+
+const page2 = `)]}'
+[{"_number": 2, "change_id": "I2", "status": "MERGED", "labels": {}}]`
+
+func TestChangePages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/a/changes/" {
+			t.Errorf("expected an authenticated request, got: %s", r.URL.Path)
+		}
+		if user, pass, _ := r.BasicAuth(); user != "ada" || pass != "secret" {
+			t.Errorf("unexpected credentials: %s:%s", user, pass)
+		}
+		if r.URL.Query().Get("q") != "status:open" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+
+		switch r.URL.Query().Get("S") {
+		case "0":
+			_, _ = w.Write([]byte(page1))
+		case "1":
+			_, _ = w.Write([]byte(page2))
+		default:
+			t.Errorf("unexpected page start: %s", r.URL.Query().Get("S"))
+		}
+	}))
+	defer srv.Close()
+
+	var opts = &Options{Client: &rest.Client{}, Context: services.Context{"gerritUser": "ada", "gerritPassword": "secret"}}
+
+	var changes []*change
+	var next = changePages(opts, srv.URL+"/", "status:open", nil, func(c *change) [][]interface{} {
+		changes = append(changes, c)
+		return nil
+	})
+
+	for more := true; more; {
+		var err error
+		if _, more, err = next(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got: %d", len(changes))
+	}
+
+	var c = changes[0]
+	if c.Created.Format("2006-01-02T15:04:05") != "2021-03-01T09:59:32" {
+		t.Fatalf("unexpected created timestamp: %v", c.Created)
+	}
+	if votes := c.Labels["Code-Review"].All; len(votes) != 2 || *votes[0].Value != 2 || votes[1].Value != nil {
+		t.Fatalf("unexpected Code-Review votes: %v", votes)
+	}
+}
@@ -0,0 +1,62 @@
+package gerrit
+
+import (
+	"sort"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+var labelsCols = []vtab.Column{
+	{Name: "url", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "query", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "change_number", Type: sqlite.SQLITE_INTEGER},
+	{Name: "change_id", Type: sqlite.SQLITE_TEXT},
+	{Name: "label", Type: sqlite.SQLITE_TEXT},
+	{Name: "reviewer_name", Type: sqlite.SQLITE_TEXT},
+	{Name: "reviewer_email", Type: sqlite.SQLITE_TEXT},
+	{Name: "reviewer_username", Type: sqlite.SQLITE_TEXT},
+	{Name: "value", Type: sqlite.SQLITE_INTEGER},
+	{Name: "date", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewLabelsModule returns the implementation of a table-valued-function listing the review votes (e.g. Code-Review +2)
+// cast on the changes of a Gerrit server matching a search query
+func NewLabelsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("gerrit_labels", labelsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var baseURL, query string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					baseURL = constraint.Value.Text()
+				case 1:
+					query = constraint.Value.Text()
+				}
+			}
+		}
+
+		return rest.NewIterator(changePages(opts, baseURL, query, []string{"DETAILED_LABELS", "DETAILED_ACCOUNTS"}, func(c *change) [][]interface{} {
+			var names = make([]string, 0, len(c.Labels))
+			for name := range c.Labels {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			var rows [][]interface{}
+			for _, name := range names {
+				for _, vote := range c.Labels[name].All {
+					// reviewers who can vote on a label but haven't are listed without a value
+					if vote.Value == nil {
+						continue
+					}
+					rows = append(rows, []interface{}{
+						baseURL, query, c.Number, c.ChangeID, name, vote.Name, vote.Email, vote.Username, *vote.Value, vote.Date.Time,
+					})
+				}
+			}
+			return rows
+		})), nil
+	})
+}
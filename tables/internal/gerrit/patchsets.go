@@ -0,0 +1,57 @@
+package gerrit
+
+import (
+	"sort"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+var patchsetsCols = []vtab.Column{
+	{Name: "url", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "query", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "change_number", Type: sqlite.SQLITE_INTEGER},
+	{Name: "change_id", Type: sqlite.SQLITE_TEXT},
+	{Name: "number", Type: sqlite.SQLITE_INTEGER},
+	{Name: "revision", Type: sqlite.SQLITE_TEXT},
+	{Name: "kind", Type: sqlite.SQLITE_TEXT},
+	{Name: "ref", Type: sqlite.SQLITE_TEXT},
+	{Name: "uploader_name", Type: sqlite.SQLITE_TEXT},
+	{Name: "uploader_email", Type: sqlite.SQLITE_TEXT},
+	{Name: "uploader_username", Type: sqlite.SQLITE_TEXT},
+	{Name: "created", Type: sqlite.SQLITE_TEXT},
+	{Name: "is_current", Type: sqlite.SQLITE_INTEGER},
+}
+
+// NewPatchsetsModule returns the implementation of a table-valued-function listing every patchset
+// of the changes of a Gerrit server matching a search query
+func NewPatchsetsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("gerrit_patchsets", patchsetsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var baseURL, query string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					baseURL = constraint.Value.Text()
+				case 1:
+					query = constraint.Value.Text()
+				}
+			}
+		}
+
+		return rest.NewIterator(changePages(opts, baseURL, query, []string{"ALL_REVISIONS", "DETAILED_ACCOUNTS"}, func(c *change) [][]interface{} {
+			var rows [][]interface{}
+			for sha, rev := range c.Revisions {
+				rows = append(rows, []interface{}{
+					baseURL, query, c.Number, c.ChangeID, rev.Number, sha, rev.Kind, rev.Ref,
+					rev.Uploader.Name, rev.Uploader.Email, rev.Uploader.Username, rev.Created.Time, sha == c.CurrentRevision,
+				})
+			}
+
+			// revisions come in a map, list them in the order they were uploaded
+			sort.Slice(rows, func(i, j int) bool { return rows[i][4].(int) < rows[j][4].(int) })
+			return rows
+		})), nil
+	})
+}
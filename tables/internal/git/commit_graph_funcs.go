@@ -0,0 +1,98 @@
+package git
+
+import (
+	"github.com/askgitdev/askgit/tables/services"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+// commitArgs looks up the commits named by the first n values (hashes or revisions), in the repository
+// given by the optional value that follows them, or the default repository
+func commitArgs(locator services.RepoLocator, ctx services.Context, n int, values []sqlite.Value) ([]*object.Commit, error) {
+	var repoPath string
+	if len(values) > n {
+		repoPath = values[n].Text()
+	}
+
+	repo, repoPath, err := openRepo(locator, ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits = make([]*object.Commit, n)
+	for i := range commits {
+		hash, err := repo.ResolveRevision(plumbing.Revision(PinnedRev(locator, repoPath, values[i].Text())))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve %q", values[i].Text())
+		}
+		if commits[i], err = repo.CommitObject(*hash); err != nil {
+			return nil, err
+		}
+	}
+	return commits, nil
+}
+
+// IsMergeFn implements the IS_MERGE(hash [, repository]) sql function,
+// returning whether the commit has more than one parent
+type IsMergeFn struct {
+	Locator services.RepoLocator
+	Context services.Context
+}
+
+func (*IsMergeFn) Deterministic() bool { return true }
+func (*IsMergeFn) Args() int           { return -1 }
+func (fn *IsMergeFn) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	if len(values) < 1 || len(values) > 2 {
+		c.ResultError(errors.New("is_merge takes a commit, and optionally a repository"))
+		return
+	}
+
+	commits, err := commitArgs(fn.Locator, fn.Context, 1, values)
+	if err != nil {
+		c.ResultError(err)
+		return
+	}
+
+	if commits[0].NumParents() > 1 {
+		c.ResultInt(1)
+	} else {
+		c.ResultInt(0)
+	}
+}
+
+// MergeBaseFn implements the MERGE_BASE(a, b [, repository]) sql function,
+// returning the hash of the best common ancestor of two commits, or NULL if they have none
+type MergeBaseFn struct {
+	Locator services.RepoLocator
+	Context services.Context
+}
+
+func (*MergeBaseFn) Deterministic() bool { return true }
+func (*MergeBaseFn) Args() int           { return -1 }
+func (fn *MergeBaseFn) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	if len(values) < 2 || len(values) > 3 {
+		c.ResultError(errors.New("merge_base takes two commits, and optionally a repository"))
+		return
+	}
+
+	commits, err := commitArgs(fn.Locator, fn.Context, 2, values)
+	if err != nil {
+		c.ResultError(err)
+		return
+	}
+
+	bases, err := commits[0].MergeBase(commits[1])
+	if err != nil {
+		c.ResultError(err)
+		return
+	}
+
+	// criss-cross merges can have several best common ancestors, like git merge-base the first one is returned
+	if len(bases) == 0 {
+		c.ResultNull()
+	} else {
+		c.ResultText(bases[0].Hash.String())
+	}
+}
@@ -0,0 +1,112 @@
+package git
+
+import (
+	"github.com/askgitdev/askgit/tables/services"
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"go.riyazali.net/sqlite"
+)
+
+var commitParentsCols = []vtab.Column{
+	{Name: "commit_hash", Type: sqlite.SQLITE_TEXT},
+	{Name: "parent_hash", Type: sqlite.SQLITE_TEXT},
+	{Name: "parent_index", Type: sqlite.SQLITE_INTEGER},
+
+	{Name: "repository", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ref", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// NewCommitParentsModule returns the implementation of a table-valued-function listing the edges of the commit graph,
+// one row per parent of every commit reachable from ref (HEAD by default), so it can be traversed with recursive CTEs
+func NewCommitParentsModule(locator services.RepoLocator, ctx services.Context) sqlite.Module {
+	return vtab.NewTableFunc("git_commit_parents", commitParentsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 3:
+					repoPath = constraint.Value.Text()
+				case 4:
+					ref = constraint.Value.Text()
+				}
+			}
+		}
+
+		repo, repoPath, err := openRepo(locator, ctx, repoPath)
+		if err != nil {
+			return nil, err
+		}
+
+		from, err := resolveCommit(repo, PinnedRev(locator, repoPath, ref))
+		if err != nil {
+			return nil, err
+		}
+
+		commits, err := repo.Log(&git.LogOptions{From: from})
+		if err != nil {
+			return nil, err
+		}
+		return &commitParentsIter{repoPath: repoPath, ref: ref, commits: commits}, nil
+	})
+}
+
+// resolveCommit returns the hash of the commit rev resolves to, HEAD if rev is empty
+func resolveCommit(repo *git.Repository, rev string) (plumbing.Hash, error) {
+	if rev == "" {
+		head, err := repo.Head()
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		return head.Hash(), nil
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return *hash, nil
+}
+
+type commitParentsIter struct {
+	repoPath, ref string
+	commits       object.CommitIter
+
+	commit *object.Commit
+	index  int
+}
+
+func (i *commitParentsIter) Next() (vtab.Row, error) {
+	i.index++
+
+	// move on to the next commit with parents left to emit, skipping root commits
+	for i.commit == nil || i.index >= i.commit.NumParents() {
+		commit, err := i.commits.Next()
+		if err != nil {
+			if eof(err) {
+				i.commits.Close()
+			}
+			return nil, err
+		}
+		i.commit, i.index = commit, 0
+	}
+
+	return i, nil
+}
+
+func (i *commitParentsIter) Column(ctx *sqlite.Context, c int) error {
+	switch c {
+	case 0:
+		ctx.ResultText(i.commit.Hash.String())
+	case 1:
+		ctx.ResultText(i.commit.ParentHashes[i.index].String())
+	case 2:
+		ctx.ResultInt(i.index)
+	case 3:
+		ctx.ResultText(i.repoPath)
+	case 4:
+		ctx.ResultText(i.ref)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package git_test
+
+import (
+	"testing"
+)
+
+func TestCommitParents(t *testing.T) {
+	db := Connect(t, Memory)
+	repo := "https://github.com/askgitdev/askgit"
+
+	// every parent edge of the log is in the graph, and merges are the commits with several edges
+	var edges, parents, merges, isMerge int
+	if err := db.QueryRow("SELECT count(*) FROM git_commit_parents(?)", repo).Scan(&edges); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if err := db.QueryRow("SELECT sum(parents), count(*) FILTER (WHERE parents > 1) FROM commits(?)", repo).Scan(&parents, &merges); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if edges != parents {
+		t.Fatalf("expected %d edges, got: %d", parents, edges)
+	}
+
+	if err := db.QueryRow("SELECT count(*) FROM commits(?) WHERE is_merge(hash, ?)", repo, repo).Scan(&isMerge); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if isMerge != merges {
+		t.Fatalf("expected %d merges, got: %d", merges, isMerge)
+	}
+}
+
+func TestMergeBase(t *testing.T) {
+	db := Connect(t, Memory)
+	repo := "https://github.com/askgitdev/askgit"
+
+	// the merge base of a merge's parents is an ancestor of both of them
+	var base, first string
+	err := db.QueryRow(`
+		SELECT merge_base(a.parent_hash, b.parent_hash, ?), a.parent_hash
+		FROM git_commit_parents(?) a JOIN git_commit_parents(?) b ON a.commit_hash = b.commit_hash
+		WHERE a.parent_index = 0 AND b.parent_index = 1 LIMIT 1`, repo, repo, repo).Scan(&base, &first)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+
+	var same string
+	if err = db.QueryRow("SELECT merge_base(?, ?, ?)", base, first, repo).Scan(&same); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if same != base {
+		t.Fatalf("expected %s to be an ancestor of %s", base, first)
+	}
+}
@@ -0,0 +1,132 @@
+package git
+
+import (
+	"context"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// followIter filters a commit iterator down to the commits changing a file, following the file across renames
+// like git log --follow does: once the commit adding the file under its current name is found, the history
+// carries on under the name it was renamed from, if any.
+type followIter struct {
+	object.CommitIter
+	path string
+}
+
+// entryHash returns the hash of the blob at path in the tree of commit, if there's one
+func entryHash(commit *object.Commit, path string) (plumbing.Hash, bool, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, false, err
+	}
+
+	entry, err := tree.FindEntry(path)
+	switch err {
+	case nil:
+		return entry.Hash, entry.Mode.IsFile(), nil
+	case object.ErrEntryNotFound, object.ErrDirectoryNotFound:
+		return plumbing.ZeroHash, false, nil
+	default:
+		return plumbing.ZeroHash, false, err
+	}
+}
+
+// touches returns whether commit changes the file at i.path, and the path the file had in its first parent if the commit renamed it
+func (i *followIter) touches(commit *object.Commit) (bool, string, error) {
+	hash, exists, err := entryHash(commit, i.path)
+	if err != nil {
+		return false, "", err
+	}
+
+	var parents []*object.Commit
+	err = commit.Parents().ForEach(func(parent *object.Commit) error {
+		parents = append(parents, parent)
+		return nil
+	})
+	if err != nil {
+		return false, "", err
+	}
+
+	if len(parents) == 0 {
+		return exists, "", nil
+	}
+
+	// like git log, a commit leaving the file as it was in any of its parents (e.g. a merge not touching it) is left out
+	for _, parent := range parents {
+		parentHash, parentExists, err := entryHash(parent, i.path)
+		if err != nil {
+			return false, "", err
+		}
+		if parentExists == exists && parentHash == hash {
+			return false, "", nil
+		}
+	}
+
+	// the file was added (or renamed) by this commit, look for the name it had before in the first parent
+	if _, inParent, err := entryHash(parents[0], i.path); err != nil || inParent || !exists {
+		return true, "", err
+	}
+
+	from, err := parents[0].Tree()
+	if err != nil {
+		return false, "", err
+	}
+	to, err := commit.Tree()
+	if err != nil {
+		return false, "", err
+	}
+
+	changes, err := object.DiffTreeWithOptions(context.Background(), from, to, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return false, "", err
+	}
+	for _, change := range changes {
+		if change.To.Name == i.path && change.From.Name != "" && change.From.Name != i.path {
+			return true, change.From.Name, nil
+		}
+	}
+	return true, "", nil
+}
+
+// Next returns the next commit changing the file
+func (i *followIter) Next() (*object.Commit, error) {
+	for {
+		commit, err := i.CommitIter.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		touches, renamedFrom, err := i.touches(commit)
+		if err != nil {
+			return nil, err
+		}
+		if renamedFrom != "" {
+			i.path = renamedFrom
+		}
+		if touches {
+			return commit, nil
+		}
+	}
+}
+
+// ForEach calls cb for every commit changing the file
+func (i *followIter) ForEach(cb func(*object.Commit) error) error {
+	defer i.Close()
+	for {
+		commit, err := i.Next()
+		if eof(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err = cb(commit); err == storer.ErrStop {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// MaxLFSPointerSize is the size above which a blob can't be a Git LFS pointer, as set by the LFS specification
+const MaxLFSPointerSize = 1024
+
+// ParseLFSPointer parses the contents of a blob as a Git LFS pointer file, returning the oid
+// (with its hash method, i.e. sha256:...) and size of the object it stands for.
+// See https://github.com/git-lfs/git-lfs/blob/main/docs/spec.md
+func ParseLFSPointer(contents []byte) (oid string, size int64, ok bool) {
+	if len(contents) >= MaxLFSPointerSize {
+		return "", 0, false
+	}
+
+	var lines = bufio.NewScanner(bytes.NewReader(contents))
+	for i := 0; lines.Scan(); i++ {
+		var line = lines.Text()
+		var key, value = line, ""
+		if sp := strings.IndexByte(line, ' '); sp >= 0 {
+			key, value = line[:sp], line[sp+1:]
+		}
+
+		switch {
+		case i == 0:
+			// the version must come first, pointers written by early versions of LFS use the hawser one
+			if key != "version" || (value != "https://git-lfs.github.com/spec/v1" && value != "https://hawser.github.com/spec/v1") {
+				return "", 0, false
+			}
+		case key == "oid":
+			oid = value
+		case key == "size":
+			var err error
+			if size, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return "", 0, false
+			}
+		}
+	}
+
+	return oid, size, oid != "" && strings.Contains(oid, ":")
+}
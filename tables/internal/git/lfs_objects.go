@@ -0,0 +1,129 @@
+package git
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/askgitdev/askgit/tables/services"
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"go.riyazali.net/sqlite"
+)
+
+var lfsObjectsCols = []vtab.Column{
+	{Name: "oid", Type: sqlite.SQLITE_TEXT},
+	{Name: "size", Type: sqlite.SQLITE_INTEGER},
+	{Name: "path", Type: sqlite.SQLITE_TEXT},
+
+	{Name: "repository", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "rev", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// NewLFSObjectsModule returns the implementation of a table-valued-function listing the files of a commit (HEAD by default)
+// that are Git LFS pointers, with the oid and size of the objects they stand for
+func NewLFSObjectsModule(locator services.RepoLocator, ctx services.Context) sqlite.Module {
+	return vtab.NewTableFunc("git_lfs_objects", lfsObjectsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, rev string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 3:
+					repoPath = constraint.Value.Text()
+				case 4:
+					rev = constraint.Value.Text()
+				}
+			}
+		}
+
+		repo, repoPath, err := openRepo(locator, ctx, repoPath)
+		if err != nil {
+			return nil, err
+		}
+
+		hash, err := resolveCommit(repo, PinnedRev(locator, repoPath, rev))
+		if err != nil {
+			return nil, err
+		}
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return nil, err
+		}
+		tree, err := commit.Tree()
+		if err != nil {
+			return nil, err
+		}
+
+		return &lfsObjectsIter{repoPath: repoPath, rev: rev, files: tree.Files()}, nil
+	})
+}
+
+type lfsObject struct {
+	oid  string
+	size int64
+	path string
+}
+
+type lfsObjectsIter struct {
+	repoPath, rev string
+	files         *object.FileIter
+	current       *lfsObject
+}
+
+// pointer returns the LFS object the file stands for, or nil if it isn't a pointer
+func pointer(file *object.File) (*lfsObject, error) {
+	// only small files can be pointers, there's no need to read the others
+	if file.Size >= MaxLFSPointerSize || !file.Mode.IsFile() {
+		return nil, nil
+	}
+
+	r, err := file.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	contents, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if oid, size, ok := ParseLFSPointer(contents); ok {
+		return &lfsObject{oid: oid, size: size, path: file.Name}, nil
+	}
+	return nil, nil
+}
+
+func (i *lfsObjectsIter) Next() (vtab.Row, error) {
+	for {
+		file, err := i.files.Next()
+		if err != nil {
+			if err == io.EOF {
+				i.files.Close()
+			}
+			return nil, err
+		}
+
+		if i.current, err = pointer(file); err != nil {
+			return nil, err
+		}
+		if i.current != nil {
+			return i, nil
+		}
+	}
+}
+
+func (i *lfsObjectsIter) Column(ctx *sqlite.Context, c int) error {
+	switch c {
+	case 0:
+		ctx.ResultText(i.current.oid)
+	case 1:
+		ctx.ResultInt64(i.current.size)
+	case 2:
+		ctx.ResultText(i.current.path)
+	case 3:
+		ctx.ResultText(i.repoPath)
+	case 4:
+		ctx.ResultText(i.rev)
+	}
+	return nil
+}
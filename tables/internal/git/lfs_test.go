@@ -0,0 +1,29 @@
+package git
+
+import (
+	"testing"
+)
+
+func TestParseLFSPointer(t *testing.T) {
+	var tests = []struct {
+		contents string
+		oid      string
+		size     int64
+		ok       bool
+	}{
+		{"version https://git-lfs.github.com/spec/v1\noid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 12345\n",
+			"sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", 12345, true},
+		{"version https://hawser.github.com/spec/v1\noid sha256:abc\nsize 1\n", "sha256:abc", 1, true},
+		{"oid sha256:abc\nversion https://git-lfs.github.com/spec/v1\nsize 1\n", "", 0, false},
+		{"version https://git-lfs.github.com/spec/v1\nsize 1\n", "", 0, false},
+		{"version https://git-lfs.github.com/spec/v1\noid sha256:abc\nsize many\n", "", 0, false},
+		{"package main\n", "", 0, false},
+	}
+
+	for _, test := range tests {
+		oid, size, ok := ParseLFSPointer([]byte(test.contents))
+		if ok != test.ok || (ok && (oid != test.oid || size != test.size)) {
+			t.Fatalf("unexpected parse of %q: %q %d %v", test.contents, oid, size, ok)
+		}
+	}
+}
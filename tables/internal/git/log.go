@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/askgitdev/askgit/tables/internal/tz"
 	"github.com/askgitdev/askgit/tables/services"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
@@ -33,6 +34,10 @@ func (mod *LogModule) Connect(_ *sqlite.Conn, _ []string, declare func(string) e
 
 			repository 	HIDDEN,
 			ref 		HIDDEN,
+			path 		HIDDEN,
+
+			author_tz_offset 	INT,
+			committer_tz_offset INT,
 			PRIMARY KEY ( hash )
 		) WITHOUT ROWID`
 
@@ -75,7 +80,7 @@ func (tab *gitLogTable) Open() (sqlite.VirtualCursor, error) {
 //   and op code is an integer constant for the operation.
 //
 //   A potential issue with such framing is the small count of columns we can map,
-//   which comes to about 2^4 = 16 .. we have already got 11 columns in current implementation.
+//   which comes to about 2^4 = 16 .. we have already got 14 columns in current implementation.
 //   And so, this contract must be revisited if we exceed the count of columns.
 func (tab *gitLogTable) BestIndex(input *sqlite.IndexInfoInput) (*sqlite.IndexInfoOutput, error) {
 	var argv = 0
@@ -109,8 +114,8 @@ func (tab *gitLogTable) BestIndex(input *sqlite.IndexInfoInput) (*sqlite.IndexIn
 				out.IdxFlags |= sqlite.INDEX_SCAN_UNIQUE // we only visit at most one row or commit
 			}
 
-		// user has specified which repository and / or reference to use
-		case (idx == 9 || idx == 10) && constraint.Op == sqlite.INDEX_CONSTRAINT_EQ:
+		// user has specified which repository, reference and / or file to use
+		case (idx == 9 || idx == 10 || idx == 11) && constraint.Op == sqlite.INDEX_CONSTRAINT_EQ:
 			{
 				set(1, idx)
 				out.ConstraintUsage[i] = &sqlite.ConstraintUsage{ArgvIndex: argv, Omit: true}
@@ -158,7 +163,7 @@ type gitLogCursor struct {
 
 func (cur *gitLogCursor) Filter(_ int, s string, values ...sqlite.Value) (err error) {
 	// values extracted from constraints
-	var hash, path, refName string
+	var hash, path, refName, filePath string
 	var start, end string
 
 	var bitmap, _ = dec(s)
@@ -170,6 +175,8 @@ func (cur *gitLogCursor) Filter(_ int, s string, values ...sqlite.Value) (err er
 			path = val.Text()
 		case 0b00011010:
 			refName = val.Text()
+		case 0b00011011:
+			filePath = val.Text()
 		case 0b0100111:
 			end = val.Text()
 		case 0b0110111:
@@ -192,6 +199,8 @@ func (cur *gitLogCursor) Filter(_ int, s string, values ...sqlite.Value) (err er
 		cur.repo = repo
 	}
 
+	refName = PinnedRev(cur.locator, path, refName)
+
 	if hash != "" {
 		// we only need to get a single commit
 		cur.commits = object.NewCommitIter(repo.Storer, storer.NewEncodedObjectLookupIter(
@@ -235,6 +244,11 @@ func (cur *gitLogCursor) Filter(_ int, s string, values ...sqlite.Value) (err er
 		return errors.Wrap(err, "failed to create iterator")
 	}
 
+	// only list the commits changing the file, following it across renames
+	if filePath != "" {
+		cur.commits = &followIter{CommitIter: cur.commits, path: filePath}
+	}
+
 	return cur.Next()
 }
 
@@ -251,20 +265,31 @@ func (cur *gitLogCursor) Column(c *sqlite.Context, col int) error {
 	case 3:
 		c.ResultText(commit.Author.Email)
 	case 4:
-		c.ResultText(commit.Author.When.Format(time.RFC3339))
+		c.ResultText(tz.Format(commit.Author.When, time.RFC3339))
 	case 5:
 		c.ResultText(commit.Committer.Name)
 	case 6:
 		c.ResultText(commit.Committer.Email)
 	case 7:
-		c.ResultText(commit.Committer.When.Format(time.RFC3339))
+		c.ResultText(tz.Format(commit.Committer.When, time.RFC3339))
 	case 8:
 		c.ResultInt(commit.NumParents())
+	case 12:
+		c.ResultInt(tzOffset(commit.Author.When))
+	case 13:
+		c.ResultInt(tzOffset(commit.Committer.When))
 	}
 
 	return nil
 }
 
+// tzOffset returns the offset of the timezone of t, in minutes east of UTC. It's kept as recorded in the commit,
+// even when timestamps are converted to another timezone (see tz.SetLocation).
+func tzOffset(t time.Time) int {
+	_, offset := t.Zone()
+	return offset / 60
+}
+
 func (cur *gitLogCursor) Next() (err error) {
 	if cur.commit, err = cur.commits.Next(); err != nil {
 		// check for ErrObjectNotFound to ensure we don't crash
@@ -21,8 +21,8 @@ func TestSelectAllCommits(t *testing.T) {
 		var hash, message string
 		var authorName, authorEmail, authorWhen string
 		var committerName, committerEmail, committerWhen string
-		var parents int
-		err = rows.Scan(&hash, &message, &authorName, &authorEmail, &authorWhen, &committerName, &committerEmail, &committerWhen, &parents)
+		var parents, authorOffset, committerOffset int
+		err = rows.Scan(&hash, &message, &authorName, &authorEmail, &authorWhen, &committerName, &committerEmail, &committerWhen, &parents, &authorOffset, &committerOffset)
 		if err != nil {
 			t.Fatalf("failed to scan resultset: %v", err)
 		}
@@ -110,3 +110,32 @@ func TestDefaultCases(t *testing.T) {
 		}
 	})
 }
+
+func TestFollowFileHistory(t *testing.T) {
+	db := Connect(t, Memory)
+	repo, ref := "https://github.com/askgitdev/askgit", "HEAD"
+
+	var all, touching int
+	if err := db.QueryRow("SELECT count(*) FROM commits(?, ?)", repo, ref).Scan(&all); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if err := db.QueryRow("SELECT count(*) FROM commits(?, ?, 'go.mod')", repo, ref).Scan(&touching); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+
+	if touching == 0 || touching >= all {
+		t.Fatalf("expected some, but not all, of the %d commits to change go.mod, got: %d", all, touching)
+	}
+
+	// every non-merge commit listed changes the file
+	var unchanged int
+	err := db.QueryRow(`
+		SELECT count(*) FROM commits(?, ?, 'go.mod') c
+		WHERE c.parents < 2 AND NOT EXISTS (SELECT 1 FROM stats(?, c.hash) WHERE file_path = 'go.mod')`, repo, ref, repo).Scan(&unchanged)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if unchanged != 0 {
+		t.Fatalf("expected every commit to change go.mod, %d didn't", unchanged)
+	}
+}
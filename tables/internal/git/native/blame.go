@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 
 	"github.com/askgitdev/askgit/tables/internal/git"
 	"github.com/askgitdev/askgit/tables/services"
@@ -22,7 +23,7 @@ var blameCols = []vtab.Column{
 
 	{Name: "repository", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
 	{Name: "rev", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}, OrderBy: vtab.NONE},
-	{Name: "file_path", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "file_path", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: append([]*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, pathFilters...), OrderBy: vtab.NONE},
 }
 
 // NewBlameModule returns the implementation of a table-valued-function for accessing git blame
@@ -42,8 +43,10 @@ func NewBlameModule(locator services.RepoLocator, ctx services.Context) sqlite.M
 			}
 		}
 
-		if filePath == "" {
-			return nil, fmt.Errorf("blame table requires a file path")
+		// a pattern over file_path blames every matching file, instead of a single one
+		var filter = newPathFilter(constraints, 4)
+		if filePath == "" && len(filter) == 0 {
+			return nil, fmt.Errorf("blame table requires a file path, or a LIKE or GLOB pattern over file_path")
 		}
 
 		if repoPath == "" {
@@ -54,15 +57,14 @@ func NewBlameModule(locator services.RepoLocator, ctx services.Context) sqlite.M
 			}
 		}
 
-		return newBlameIter(locator, repoPath, rev, filePath)
+		return newBlameIter(locator, repoPath, rev, filePath, filter)
 	})
 }
 
-func newBlameIter(locator services.RepoLocator, repoPath, rev, filePath string) (*blameIter, error) {
+func newBlameIter(locator services.RepoLocator, repoPath, rev, filePath string, filter pathFilter) (*blameIter, error) {
 	iter := &blameIter{
 		repoPath: repoPath,
 		rev:      rev,
-		filePath: filePath,
 		index:    -1,
 	}
 
@@ -78,6 +80,7 @@ func newBlameIter(locator services.RepoLocator, repoPath, rev, filePath string)
 	if err != nil {
 		return nil, err
 	}
+	rev = git.PinnedRev(locator, repoPath, rev)
 
 	fsStorer, ok := r.Storer.(*filesystem.Storage)
 	if !ok {
@@ -88,38 +91,80 @@ func newBlameIter(locator services.RepoLocator, repoPath, rev, filePath string)
 	if err != nil {
 		return nil, err
 	}
-	defer repo.Free()
+	iter.repo = repo
 
-	var commitID *libgit2.Oid
 	// if no rev is supplied, use HEAD
 	if rev == "" {
 		head, err := repo.Head()
 		if err != nil {
+			repo.Free()
 			return nil, err
 		}
-		commitID = head.Target()
+		iter.commitID = head.Target()
 	} else {
 		obj, err := repo.RevparseSingle(rev)
 		if err != nil {
+			repo.Free()
 			return nil, err
 		}
 		defer obj.Free()
 
 		if obj.Type() != libgit2.ObjectCommit {
+			repo.Free()
 			return nil, fmt.Errorf("invalid rev, could not resolve to a commit")
 		}
 
-		commitID = obj.Id()
+		iter.commitID = obj.Id()
 	}
 
+	if filePath != "" {
+		iter.files = []string{filePath}
+		return iter, nil
+	}
+
+	// list the files matching the patterns, skipping the directories that can't contain any
+	commit, err := repo.LookupCommit(iter.commitID)
+	if err != nil {
+		repo.Free()
+		return nil, err
+	}
+	defer commit.Free()
+
+	tree, err := commit.Tree()
+	if err != nil {
+		repo.Free()
+		return nil, err
+	}
+	defer tree.Free()
+
+	err = tree.Walk(func(p string, treeEntry *libgit2.TreeEntry) int {
+		var entryPath = path.Join(p, treeEntry.Name)
+		switch {
+		case treeEntry.Type == libgit2.ObjectTree && !filter.Descend(entryPath):
+			return 1 // skip the subtree
+		case treeEntry.Type == libgit2.ObjectBlob && filter.Match(entryPath):
+			iter.files = append(iter.files, entryPath)
+		}
+		return 0
+	})
+	if err != nil {
+		repo.Free()
+		return nil, err
+	}
+
+	return iter, nil
+}
+
+// blameFile returns the blamed lines of the file at filePath
+func (i *blameIter) blameFile(filePath string) ([]*blamedLine, error) {
 	opts, err := libgit2.DefaultBlameOptions()
 	if err != nil {
 		return nil, err
 	}
 
-	opts.NewestCommit = commitID
+	opts.NewestCommit = i.commitID
 
-	blame, err := repo.BlameFile(filePath, &opts)
+	blame, err := i.repo.BlameFile(filePath, &opts)
 	if err != nil {
 		return nil, err
 	}
@@ -130,7 +175,7 @@ func newBlameIter(locator services.RepoLocator, repoPath, rev, filePath string)
 		}
 	}()
 
-	iter.lines = make([]*blamedLine, 0)
+	lines := make([]*blamedLine, 0)
 	fileLine := 1
 	for {
 		hunk, err := blame.HunkByLine(fileLine)
@@ -140,14 +185,14 @@ func newBlameIter(locator services.RepoLocator, repoPath, rev, filePath string)
 			}
 			return nil, err
 		}
-		iter.lines = append(iter.lines, &blamedLine{
+		lines = append(lines, &blamedLine{
 			hunk:   &hunk,
 			lineNo: fileLine,
 		})
 		fileLine++
 	}
 
-	return iter, nil
+	return lines, nil
 }
 
 type blamedLine struct {
@@ -158,9 +203,16 @@ type blamedLine struct {
 type blameIter struct {
 	repoPath string
 	rev      string
+	repo     *libgit2.Repository
+	commitID *libgit2.Oid
+
+	// files are the paths of the files to blame, one after the other
+	files    []string
+	file     int
 	filePath string
-	lines    []*blamedLine
-	index    int
+
+	lines []*blamedLine
+	index int
 }
 
 func (i *blameIter) Column(ctx *sqlite.Context, c int) error {
@@ -170,14 +222,35 @@ func (i *blameIter) Column(ctx *sqlite.Context, c int) error {
 		ctx.ResultInt(currentLine.lineNo)
 	case 1:
 		ctx.ResultText(currentLine.hunk.OrigCommitId.String())
+	case 2:
+		ctx.ResultText(i.repoPath)
+	case 3:
+		ctx.ResultText(i.rev)
+	case 4:
+		ctx.ResultText(i.filePath)
 	}
 	return nil
 }
 
 func (i *blameIter) Next() (vtab.Row, error) {
 	i.index++
-	if i.index >= len(i.lines) {
-		return nil, io.EOF
+
+	// blame the next file once done with the lines of the current one (skipping empty files)
+	for i.index >= len(i.lines) {
+		if i.file >= len(i.files) {
+			if i.repo != nil {
+				i.repo.Free()
+				i.repo = nil
+			}
+			return nil, io.EOF
+		}
+
+		lines, err := i.blameFile(i.files[i.file])
+		if err != nil {
+			return nil, err
+		}
+		i.filePath, i.lines, i.index = i.files[i.file], lines, 0
+		i.file++
 	}
 	return i, nil
 }
@@ -0,0 +1,24 @@
+package native
+
+import (
+	"github.com/askgitdev/askgit/tables/internal/git"
+	libgit2 "github.com/libgit2/git2go/v31"
+	"go.riyazali.net/sqlite"
+)
+
+// isLFSPointer returns whether the blob is a Git LFS pointer, standing for a file stored outside of the repository
+func isLFSPointer(blob *libgit2.Blob) bool {
+	if blob.Size() >= git.MaxLFSPointerSize {
+		return false
+	}
+	_, _, ok := git.ParseLFSPointer(blob.Contents())
+	return ok
+}
+
+func resultBool(ctx *sqlite.Context, b bool) {
+	if b {
+		ctx.ResultInt(1)
+	} else {
+		ctx.ResultInt(0)
+	}
+}
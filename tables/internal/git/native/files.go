@@ -16,9 +16,12 @@ import (
 )
 
 var filesCols = []vtab.Column{
-	{Name: "path", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "path", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: pathFilters, OrderBy: vtab.NONE},
 	{Name: "executable", Type: sqlite.SQLITE_INTEGER, NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
 	{Name: "contents", Type: sqlite.SQLITE_BLOB, NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "size", Type: sqlite.SQLITE_INTEGER, NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "is_binary", Type: sqlite.SQLITE_INTEGER, NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "is_lfs_pointer", Type: sqlite.SQLITE_INTEGER, NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
 
 	{Name: "repository", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
 	{Name: "rev", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}, OrderBy: vtab.NONE},
@@ -31,9 +34,9 @@ func NewFilesModule(locator services.RepoLocator, ctx services.Context) sqlite.M
 		for _, constraint := range constraints {
 			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
 				switch constraint.ColIndex {
-				case 3:
+				case 6:
 					repoPath = constraint.Value.Text()
-				case 4:
+				case 7:
 					rev = constraint.Value.Text()
 				}
 			}
@@ -47,11 +50,11 @@ func NewFilesModule(locator services.RepoLocator, ctx services.Context) sqlite.M
 			}
 		}
 
-		return newFilesIter(locator, repoPath, rev)
+		return newFilesIter(locator, repoPath, rev, newPathFilter(constraints, 0))
 	})
 }
 
-func newFilesIter(locator services.RepoLocator, repoPath, rev string) (*filesIter, error) {
+func newFilesIter(locator services.RepoLocator, repoPath, rev string, filter pathFilter) (*filesIter, error) {
 	iter := &filesIter{
 		repoPath: repoPath,
 		rev:      rev,
@@ -70,6 +73,7 @@ func newFilesIter(locator services.RepoLocator, repoPath, rev string) (*filesIte
 	if err != nil {
 		return nil, err
 	}
+	rev = git.PinnedRev(locator, repoPath, rev)
 
 	fsStorer, ok := r.Storer.(*filesystem.Storage)
 	if !ok {
@@ -117,12 +121,16 @@ func newFilesIter(locator services.RepoLocator, repoPath, rev string) (*filesIte
 
 	iter.files = make([]*file, 0, tree.EntryCount())
 	err = tree.Walk(func(p string, treeEntry *libgit2.TreeEntry) int {
-		if treeEntry.Type != libgit2.ObjectBlob {
+		var filePath = path.Join(p, treeEntry.Name)
+		switch {
+		case treeEntry.Type == libgit2.ObjectTree && !filter.Descend(filePath):
+			return 1 // skip the subtree
+		case treeEntry.Type != libgit2.ObjectBlob || !filter.Match(filePath):
 			return 0
 		}
 		iter.files = append(iter.files, &file{
 			id:         treeEntry.Id,
-			path:       path.Join(p, treeEntry.Name),
+			path:       filePath,
 			executable: treeEntry.Filemode == libgit2.FilemodeBlobExecutable,
 		})
 		return 0
@@ -166,6 +174,21 @@ func (i *filesIter) Column(ctx *sqlite.Context, c int) error {
 		}
 		defer blob.Free()
 		ctx.ResultText(string(blob.Contents()))
+	case 3, 4, 5:
+		blob, err := i.repo.LookupBlob(currentFile.id)
+		if err != nil {
+			return err
+		}
+		defer blob.Free()
+
+		switch c {
+		case 3:
+			ctx.ResultInt64(blob.Size())
+		case 4:
+			resultBool(ctx, blob.IsBinary())
+		case 5:
+			resultBool(ctx, isLFSPointer(blob))
+		}
 	}
 	return nil
 }
@@ -63,3 +63,24 @@ func TestSelectKnownContents(t *testing.T) {
 		t.Fatalf("failed to fetch results: %v", err.Error())
 	}
 }
+
+func TestSelectFilesPattern(t *testing.T) {
+	db := Connect(t, Memory)
+	repo := "https://github.com/askgitdev/askgit"
+
+	// LIKE and GLOB are pushed down into the tree walk, substr isn't, both must return the same files
+	var like, glob, all int
+	if err := db.QueryRow("SELECT count(*) FROM files(?) WHERE path LIKE 'tables/%'", repo).Scan(&like); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if err := db.QueryRow("SELECT count(*) FROM files(?) WHERE path GLOB 'tables/*'", repo).Scan(&glob); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if err := db.QueryRow("SELECT count(*) FROM files(?) WHERE substr(path, 1, 7) = 'tables/'", repo).Scan(&all); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+
+	if like == 0 || like != all || glob != all {
+		t.Fatalf("expected %d files, got: %d with LIKE and %d with GLOB", all, like, glob)
+	}
+}
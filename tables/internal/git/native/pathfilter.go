@@ -0,0 +1,180 @@
+package native
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+// pathFilters are the LIKE and GLOB constraints on the path column of the file-based tables.
+// They're pushed down so tree walks and diffs can skip the directories and files that can't match,
+// but SQLite still checks them (they aren't omitted), so filtering here only needs to be conservative.
+var pathFilters = []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_LIKE}, {Op: sqlite.INDEX_CONSTRAINT_GLOB}}
+
+// pattern is a LIKE or GLOB pattern over paths
+type pattern struct {
+	re *regexp.Regexp
+
+	// prefix is the literal beginning of the pattern, lower cased for (case-insensitive) LIKE patterns
+	prefix     string
+	ignoreCase bool
+}
+
+// pathFilter is the conjunction of the patterns constraining a path column
+type pathFilter []*pattern
+
+// newPathFilter returns the filter made of the LIKE and GLOB constraints on the column at index col
+func newPathFilter(constraints []*vtab.Constraint, col int) pathFilter {
+	var filter pathFilter
+	for _, constraint := range constraints {
+		if constraint.ColIndex != col {
+			continue
+		}
+		switch constraint.Op {
+		case sqlite.INDEX_CONSTRAINT_LIKE:
+			filter = append(filter, likePattern(constraint.Value.Text()))
+		case sqlite.INDEX_CONSTRAINT_GLOB:
+			filter = append(filter, globPattern(constraint.Value.Text()))
+		}
+	}
+	return filter
+}
+
+// likePattern compiles a LIKE pattern, where % matches any sequence of characters and _ any single character
+func likePattern(like string) *pattern {
+	var re strings.Builder
+	var prefix = -1
+	for i, r := range like {
+		switch r {
+		case '%':
+			re.WriteString(".*")
+		case '_':
+			re.WriteString(".")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+			continue
+		}
+		if prefix < 0 {
+			prefix = i
+		}
+	}
+	if prefix < 0 {
+		prefix = len(like)
+	}
+
+	return &pattern{
+		re:         regexp.MustCompile("(?is)^" + re.String() + "$"),
+		prefix:     strings.ToLower(like[:prefix]),
+		ignoreCase: true,
+	}
+}
+
+// globPattern compiles a GLOB pattern, where * matches any sequence of characters (including /),
+// ? any single character, and [...] a character in a set, or not in it with [^...]
+func globPattern(glob string) *pattern {
+	var re strings.Builder
+	var prefix = -1
+	for i := 0; i < len(glob); i++ {
+		var wildcard = true
+		switch c := glob[i]; c {
+		case '*':
+			re.WriteString(".*")
+		case '?':
+			re.WriteString(".")
+		case '[':
+			// a ] right after the opening bracket (or its negation) is part of the set
+			var end = i + 1
+			if end < len(glob) && glob[end] == '^' {
+				end++
+			}
+			if end < len(glob) && glob[end] == ']' {
+				end++
+			}
+			for end < len(glob) && glob[end] != ']' {
+				end++
+			}
+			if end >= len(glob) {
+				re.WriteString(regexp.QuoteMeta(glob[i:])) // an unterminated set is matched literally
+				i = len(glob)
+				wildcard = false
+				break
+			}
+
+			var set = glob[i+1 : end]
+			var negate = strings.HasPrefix(set, "^")
+			set = strings.TrimPrefix(set, "^")
+			set = strings.ReplaceAll(strings.ReplaceAll(set, `\`, `\\`), "[", `\[`)
+			if strings.HasPrefix(set, "]") {
+				set = `\` + set
+			}
+			if negate {
+				re.WriteString("[^" + set + "]")
+			} else {
+				re.WriteString("[" + set + "]")
+			}
+			i = end
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+			wildcard = false
+		}
+		if wildcard && prefix < 0 {
+			prefix = strings.IndexAny(glob, "*?[")
+		}
+	}
+	if prefix < 0 {
+		prefix = len(glob)
+	}
+
+	var expr = "(?s)^" + re.String() + "$"
+	compiled, err := regexp.Compile(expr)
+	if err != nil {
+		// anything we can't translate (such as an invalid range) is left for SQLite to evaluate
+		compiled = regexp.MustCompile("(?s).*")
+	}
+	return &pattern{re: compiled, prefix: glob[:prefix]}
+}
+
+// Match returns whether path satisfies every pattern of the filter
+func (f pathFilter) Match(path string) bool {
+	for _, p := range f {
+		if !p.re.MatchString(path) {
+			return false
+		}
+	}
+	return true
+}
+
+// Descend returns whether the directory at dir may contain paths satisfying the filter
+func (f pathFilter) Descend(dir string) bool {
+	dir = strings.TrimSuffix(dir, "/") + "/"
+	for _, p := range f {
+		var d = dir
+		if p.ignoreCase {
+			d = strings.ToLower(d)
+		}
+		if !strings.HasPrefix(p.prefix, d) && !strings.HasPrefix(d, p.prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// Pathspec returns the directories paths satisfying the case-sensitive patterns of the filter lie in,
+// to limit diffs to, or nil if the filter doesn't narrow diffs down to a directory
+func (f pathFilter) Pathspec() []string {
+	var longest string
+	for _, p := range f {
+		if p.ignoreCase {
+			continue
+		}
+		if i := strings.LastIndex(p.prefix, "/"); i > len(longest) {
+			longest = p.prefix[:i]
+		}
+	}
+	if longest == "" {
+		return nil
+	}
+	return []string{longest}
+}
@@ -0,0 +1,47 @@
+package native
+
+import (
+	"testing"
+)
+
+func TestPathPatterns(t *testing.T) {
+	var tests = []struct {
+		pattern *pattern
+		path    string
+		match   bool
+	}{
+		{likePattern("src/%"), "src/main.go", true},
+		{likePattern("src/%"), "SRC/main.go", true},
+		{likePattern("src/%"), "lib/src/main.go", false},
+		{likePattern("%.g_"), "pkg/a/b.go", true},
+		{likePattern("a.b"), "axb", false},
+		{globPattern("src/*.go"), "src/a/b.go", true},
+		{globPattern("src/*.go"), "SRC/a.go", false},
+		{globPattern("*_test.go"), "pkg/x_test.go", true},
+		{globPattern("?.md"), "a.md", true},
+		{globPattern("[a-c]*"), "docs", false},
+		{globPattern("[^a-c]*"), "docs", true},
+		{globPattern("[]x]"), "]", true},
+		{globPattern("a[b"), "a[b", true},
+	}
+
+	for _, test := range tests {
+		if match := test.pattern.re.MatchString(test.path); match != test.match {
+			t.Fatalf("expected %s to match %q: %v, got: %v", test.pattern.re, test.path, test.match, match)
+		}
+	}
+}
+
+func TestPathFilterDescend(t *testing.T) {
+	var filter = pathFilter{globPattern("tables/internal/*"), likePattern("TABLES/%")}
+
+	for dir, descend := range map[string]bool{"tables": true, "tables/internal": true, "tables/internal/git": true, "cmd": false, "tables/services": false} {
+		if filter.Descend(dir) != descend {
+			t.Fatalf("expected descending into %s to be %v", dir, descend)
+		}
+	}
+
+	if spec := filter.Pathspec(); len(spec) != 1 || spec[0] != "tables/internal" {
+		t.Fatalf("unexpected pathspec: %v", spec)
+	}
+}
@@ -14,28 +14,65 @@ import (
 )
 
 var statsCols = []vtab.Column{
-	{Name: "file_path", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "file_path", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: pathFilters, OrderBy: vtab.NONE},
 	{Name: "additions", Type: sqlite.SQLITE_INTEGER, NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
 	{Name: "deletions", Type: sqlite.SQLITE_INTEGER, NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "old_path", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "new_path", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "status", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "similarity", Type: sqlite.SQLITE_INTEGER, NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "is_binary", Type: sqlite.SQLITE_INTEGER, NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "is_lfs_pointer", Type: sqlite.SQLITE_INTEGER, NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
 
 	{Name: "repository", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
 	{Name: "rev", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}, OrderBy: vtab.NONE},
 	{Name: "to_rev", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "renames", Type: sqlite.SQLITE_INTEGER, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "rename_threshold", Type: sqlite.SQLITE_INTEGER, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
 }
 
+// renameOptions configures the detection of renamed and copied files in stats
+type renameOptions struct {
+	// mode is renamesByConfig (as git diff does, following diff.renames), renamesOff or renamesOn
+	mode      int
+	threshold uint16
+}
+
+const (
+	renamesByConfig = iota
+	renamesOff
+	renamesOn
+)
+
+// defaultRenameThreshold is the similarity (in percent) above which files are considered renamed, like git's default
+const defaultRenameThreshold = 50
+
 // NewStatsModule returns the implementation of a table-valued-function for git stats
 func NewStatsModule(locator services.RepoLocator, ctx services.Context) sqlite.Module {
 	return vtab.NewTableFunc("stats", statsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
 		var repoPath, rev, toRev string
+		var renames = renameOptions{mode: renamesByConfig, threshold: defaultRenameThreshold}
 		for _, constraint := range constraints {
 			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
 				switch constraint.ColIndex {
-				case 3:
+				case 9:
 					repoPath = constraint.Value.Text()
-				case 4:
+				case 10:
 					rev = constraint.Value.Text()
-				case 5:
+				case 11:
 					toRev = constraint.Value.Text()
+				case 12:
+					if constraint.Value.Int() != 0 {
+						renames.mode = renamesOn
+					} else {
+						renames.mode = renamesOff
+					}
+				case 13:
+					var threshold = constraint.Value.Int()
+					if threshold < 0 || threshold > 100 {
+						return nil, fmt.Errorf("rename_threshold must be a percentage, between 0 and 100")
+					}
+					renames.threshold = uint16(threshold)
 				}
 			}
 		}
@@ -48,11 +85,11 @@ func NewStatsModule(locator services.RepoLocator, ctx services.Context) sqlite.M
 			}
 		}
 
-		return newStatsIter(locator, repoPath, rev, toRev)
+		return newStatsIter(locator, repoPath, rev, toRev, newPathFilter(constraints, 0), renames)
 	})
 }
 
-func newStatsIter(locator services.RepoLocator, repoPath, rev, toRev string) (*statsIter, error) {
+func newStatsIter(locator services.RepoLocator, repoPath, rev, toRev string, filter pathFilter, renames renameOptions) (*statsIter, error) {
 	iter := &statsIter{
 		repoPath: repoPath,
 		index:    -1,
@@ -62,6 +99,7 @@ func newStatsIter(locator services.RepoLocator, repoPath, rev, toRev string) (*s
 	if err != nil {
 		return nil, err
 	}
+	rev = git.PinnedRev(locator, repoPath, rev)
 
 	fsStorer, ok := r.Storer.(*filesystem.Storage)
 	if !ok {
@@ -140,6 +178,7 @@ func newStatsIter(locator services.RepoLocator, repoPath, rev, toRev string) (*s
 	if err != nil {
 		return nil, err
 	}
+	diffOpts.Pathspec = filter.Pathspec()
 
 	diff, err := repo.DiffTreeToTree(toTree, tree, &diffOpts)
 	if err != nil {
@@ -153,19 +192,46 @@ func newStatsIter(locator services.RepoLocator, repoPath, rev, toRev string) (*s
 		}
 	}()
 
-	diffFindOpts, err := libgit2.DefaultDiffFindOptions()
-	if err != nil {
-		return nil, err
-	}
+	if renames.mode != renamesOff {
+		diffFindOpts, err := libgit2.DefaultDiffFindOptions()
+		if err != nil {
+			return nil, err
+		}
 
-	err = diff.FindSimilar(&diffFindOpts)
-	if err != nil {
-		return nil, err
+		if renames.mode == renamesOn {
+			diffFindOpts.Flags = libgit2.DiffFindRenames | libgit2.DiffFindCopies
+			diffFindOpts.RenameThreshold = renames.threshold
+			diffFindOpts.CopyThreshold = renames.threshold
+		}
+
+		err = diff.FindSimilar(&diffFindOpts)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	iter.stats = make([]*stat, 0)
 	err = diff.ForEach(func(delta libgit2.DiffDelta, progress float64) (libgit2.DiffForEachHunkCallback, error) {
-		stat := &stat{filePath: delta.NewFile.Path}
+		if !filter.Match(delta.NewFile.Path) {
+			return nil, nil
+		}
+		stat := &stat{filePath: delta.NewFile.Path, status: delta.Status, similarity: delta.Similarity}
+		if delta.Status != libgit2.DeltaAdded {
+			stat.oldPath = delta.OldFile.Path
+		}
+		if delta.Status != libgit2.DeltaDeleted {
+			stat.newPath = delta.NewFile.Path
+		}
+
+		// the kind of file is that of its new version, or the old one for deleted files
+		var id = delta.NewFile.Oid
+		if delta.Status == libgit2.DeltaDeleted {
+			id = delta.OldFile.Oid
+		}
+		if blob, err := repo.LookupBlob(id); err == nil {
+			stat.binary, stat.lfsPointer = blob.IsBinary(), isLFSPointer(blob)
+			blob.Free()
+		}
 		iter.stats = append(iter.stats, stat)
 		return func(hunk libgit2.DiffHunk) (libgit2.DiffForEachLineCallback, error) {
 			return func(line libgit2.DiffLine) error {
@@ -187,9 +253,34 @@ func newStatsIter(locator services.RepoLocator, repoPath, rev, toRev string) (*s
 }
 
 type stat struct {
-	filePath  string
-	additions int
-	deletions int
+	filePath   string
+	additions  int
+	deletions  int
+	oldPath    string
+	newPath    string
+	status     libgit2.Delta
+	similarity uint16
+	binary     bool
+	lfsPointer bool
+}
+
+// statuses are the names of the kinds of changes to a file reported in stats
+var statuses = map[libgit2.Delta]string{
+	libgit2.DeltaAdded:      "added",
+	libgit2.DeltaDeleted:    "deleted",
+	libgit2.DeltaModified:   "modified",
+	libgit2.DeltaRenamed:    "renamed",
+	libgit2.DeltaCopied:     "copied",
+	libgit2.DeltaTypeChange: "typechange",
+}
+
+// resultText sets the result to s, or NULL if it's empty
+func resultText(ctx *sqlite.Context, s string) {
+	if s == "" {
+		ctx.ResultNull()
+	} else {
+		ctx.ResultText(s)
+	}
 }
 
 type statsIter struct {
@@ -207,6 +298,22 @@ func (i *statsIter) Column(ctx *sqlite.Context, c int) error {
 		ctx.ResultInt(currentStat.additions)
 	case 2:
 		ctx.ResultInt(currentStat.deletions)
+	case 3:
+		resultText(ctx, currentStat.oldPath)
+	case 4:
+		resultText(ctx, currentStat.newPath)
+	case 5:
+		resultText(ctx, statuses[currentStat.status])
+	case 6:
+		if currentStat.status == libgit2.DeltaRenamed || currentStat.status == libgit2.DeltaCopied {
+			ctx.ResultInt(int(currentStat.similarity))
+		} else {
+			ctx.ResultNull()
+		}
+	case 7:
+		resultBool(ctx, currentStat.binary)
+	case 8:
+		resultBool(ctx, currentStat.lfsPointer)
 	}
 	return nil
 }
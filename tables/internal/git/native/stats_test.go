@@ -84,3 +84,41 @@ func TestFromAndToStats(t *testing.T) {
 		t.Fatalf("expected %d deletions, got %d", expectedDeletions, deletions)
 	}
 }
+
+func TestRenameDetectionStats(t *testing.T) {
+	db := Connect(t, Memory)
+	repo := "https://github.com/askgitdev/askgit"
+
+	// with detection on, renames have both paths and are at least as similar as the threshold
+	rows, err := db.Query(`
+		SELECT old_path, new_path, similarity FROM commits($1), stats($1, commits.hash, '', 1, 60)
+		WHERE status = 'renamed'`, repo)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var oldPath, newPath string
+		var similarity int
+		if err = rows.Scan(&oldPath, &newPath, &similarity); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+		if oldPath == newPath || similarity < 60 {
+			t.Fatalf("unexpected rename: %s => %s (%d%%)", oldPath, newPath, similarity)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err.Error())
+	}
+
+	// with detection off, renames are reported as a deletion and an addition
+	var renames int
+	err = db.QueryRow("SELECT count(*) FROM commits($1), stats($1, commits.hash, '', 0) WHERE status = 'renamed'", repo).Scan(&renames)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if renames != 0 {
+		t.Fatalf("expected no renames without rename detection, got: %d", renames)
+	}
+}
@@ -0,0 +1,205 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/tz"
+	"github.com/askgitdev/askgit/tables/services"
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5"
+	"go.riyazali.net/sqlite"
+)
+
+var reposCols = []vtab.Column{
+	{Name: "path", Type: sqlite.SQLITE_TEXT},
+	{Name: "branch", Type: sqlite.SQLITE_TEXT},
+	{Name: "head", Type: sqlite.SQLITE_TEXT},
+	{Name: "remotes", Type: sqlite.SQLITE_TEXT},
+	{Name: "last_commit_at", Type: sqlite.SQLITE_TEXT},
+
+	{Name: "root", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// NewReposModule returns the implementation of a table-valued-function listing the git repositories under a directory
+// (the current one by default), so that every one of them can be queried by joining with the other tables
+func NewReposModule(locator services.RepoLocator) sqlite.Module {
+	return vtab.NewTableFunc("repos", reposCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var root = "."
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && constraint.ColIndex == 5 && constraint.Value.Text() != "" {
+				root = constraint.Value.Text()
+			}
+		}
+
+		root, err := filepath.Abs(root)
+		if err != nil {
+			return nil, err
+		}
+		return &reposIter{locator: locator, root: root, dirs: []string{root}}, nil
+	})
+}
+
+// isRepo returns whether dir is a git repository: a working tree (whose .git may be a file, for worktrees and submodules)
+// or a bare repository
+func isRepo(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		return true
+	}
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+type discoveredRepo struct {
+	path         string
+	branch, head string
+	remotes      map[string]string
+	lastCommitAt string
+}
+
+// prefetchWindow is how many repositories are discovered (and opened in the background, if the locator supports it)
+// ahead of the one the query is at, so that they're processed concurrently while bounding how many are held open
+const prefetchWindow = 8
+
+type reposIter struct {
+	locator services.RepoLocator
+	root    string
+
+	// dirs are the directories left to look into, depth-first
+	dirs []string
+	// found are the repositories discovered ahead of the current one
+	found   []string
+	current *discoveredRepo
+}
+
+// describe opens the repository at path and reads its current branch, remotes and last commit
+func (i *reposIter) describe(path string) (*discoveredRepo, error) {
+	repo, err := i.locator.Open(context.Background(), path)
+	if err != nil {
+		return nil, err
+	}
+
+	var r = &discoveredRepo{path: path, remotes: make(map[string]string)}
+	remotes, err := repo.Remotes()
+	if err != nil {
+		return nil, err
+	}
+	for _, remote := range remotes {
+		if urls := remote.Config().URLs; len(urls) > 0 {
+			r.remotes[remote.Config().Name] = urls[0]
+		}
+	}
+
+	// repositories without any commit yet have no HEAD to resolve
+	head, err := repo.Head()
+	if err != nil {
+		return r, nil
+	}
+	r.head = head.Hash().String()
+	if head.Name().IsBranch() {
+		r.branch = head.Name().Short()
+	}
+	if commit, err := repo.CommitObject(head.Hash()); err == nil {
+		r.lastCommitAt = tz.Format(commit.Committer.When, time.RFC3339)
+	}
+	return r, nil
+}
+
+// discover walks the directory tree until prefetchWindow repositories are found ahead, or there's nothing left to look into
+func (i *reposIter) discover() error {
+	for len(i.dirs) > 0 && len(i.found) < prefetchWindow {
+		var dir = i.dirs[len(i.dirs)-1]
+		i.dirs = i.dirs[:len(i.dirs)-1]
+
+		// repositories aren't looked into, nested ones (such as submodules) can be listed from their parent
+		if isRepo(dir) {
+			i.found = append(i.found, dir)
+			if prefetcher, ok := i.locator.(services.RepoPrefetcher); ok {
+				prefetcher.Prefetch(context.Background(), dir)
+			}
+			continue
+		}
+
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			if dir == i.root {
+				return err
+			}
+			continue // skip the directories that can't be read
+		}
+
+		// subdirectories are pushed in reverse, so they're visited in order
+		sort.Slice(entries, func(a, b int) bool { return entries[a].Name() > entries[b].Name() })
+		for _, entry := range entries {
+			if entry.IsDir() {
+				i.dirs = append(i.dirs, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	return nil
+}
+
+func (i *reposIter) Next() (vtab.Row, error) {
+	for {
+		if err := i.discover(); err != nil {
+			return nil, err
+		}
+		if len(i.found) == 0 {
+			return nil, io.EOF
+		}
+
+		var path = i.found[0]
+		i.found = i.found[1:]
+
+		repo, err := i.describe(path)
+		if err == git.ErrRepositoryNotExists {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		i.current = repo
+		return i, nil
+	}
+}
+
+func (i *reposIter) Column(ctx *sqlite.Context, c int) error {
+	var r = i.current
+	switch c {
+	case 0:
+		ctx.ResultText(r.path)
+	case 1:
+		resultText(ctx, r.branch)
+	case 2:
+		resultText(ctx, r.head)
+	case 3:
+		b, err := json.Marshal(r.remotes)
+		if err != nil {
+			return err
+		}
+		ctx.ResultText(string(b))
+	case 4:
+		resultText(ctx, r.lastCommitAt)
+	case 5:
+		ctx.ResultText(i.root)
+	}
+	return nil
+}
+
+// resultText sets the result to s, or NULL if it's empty
+func resultText(ctx *sqlite.Context, s string) {
+	if s == "" {
+		ctx.ResultNull()
+	} else {
+		ctx.ResultText(s)
+	}
+}
@@ -0,0 +1,67 @@
+package git_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+)
+
+func TestDiscoverRepos(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repos")
+	if err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// two repositories, one of them nested in a directory which isn't one, and a bare one
+	for _, path := range []string{"api", "libs/ui"} {
+		repo, err := git.PlainInit(filepath.Join(dir, path), false)
+		if err != nil {
+			t.Fatalf("failed to init repository: %v", err)
+		}
+		_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"https://example.com/" + path}})
+		if err != nil {
+			t.Fatalf("failed to create remote: %v", err)
+		}
+	}
+	if _, err = git.PlainInit(filepath.Join(dir, "mirror.git"), true); err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+	if err = os.MkdirAll(filepath.Join(dir, "notes"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	db := Connect(t, Memory)
+	rows, err := db.Query("SELECT path, json_extract(remotes, '$.origin') FROM repos(?) ORDER BY path", dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	var expected = [][2]string{
+		{filepath.Join(dir, "api"), "https://example.com/api"},
+		{filepath.Join(dir, "libs", "ui"), "https://example.com/libs/ui"},
+		{filepath.Join(dir, "mirror.git"), ""},
+	}
+	var i int
+	for ; rows.Next(); i++ {
+		var path string
+		var origin *string
+		if err = rows.Scan(&path, &origin); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+		if i >= len(expected) || path != expected[i][0] || (origin == nil) != (expected[i][1] == "") || (origin != nil && *origin != expected[i][1]) {
+			t.Fatalf("unexpected repository: %s (origin %v)", path, origin)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err.Error())
+	}
+	if i != len(expected) {
+		t.Fatalf("expected %d repositories, got: %d", len(expected), i)
+	}
+}
@@ -0,0 +1,182 @@
+package git
+
+import (
+	"io"
+	"strings"
+
+	"github.com/askgitdev/askgit/tables/services"
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+// RevParseFn implements the REV_PARSE(repository, revspec) sql function,
+// returning the hash of the commit a revision (such as HEAD~3 or v1.0) resolves to
+type RevParseFn struct {
+	Locator services.RepoLocator
+	Context services.Context
+}
+
+func (*RevParseFn) Deterministic() bool { return true }
+func (*RevParseFn) Args() int           { return 2 }
+func (fn *RevParseFn) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	repo, repoPath, err := openRepo(fn.Locator, fn.Context, values[0].Text())
+	if err != nil {
+		c.ResultError(err)
+		return
+	}
+
+	hash, err := resolveCommit(repo, PinnedRev(fn.Locator, repoPath, values[1].Text()))
+	if err != nil {
+		c.ResultError(errors.Wrapf(err, "failed to resolve %q", values[1].Text()))
+		return
+	}
+	c.ResultText(hash.String())
+}
+
+// ancestors returns the set of commits reachable from hash, including itself
+func ancestors(repo *git.Repository, hash plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	commits, err := repo.Log(&git.LogOptions{From: hash})
+	if err != nil {
+		return nil, err
+	}
+
+	var set = make(map[plumbing.Hash]bool)
+	err = commits.ForEach(func(commit *object.Commit) error {
+		set[commit.Hash] = true
+		return nil
+	})
+	return set, err
+}
+
+// revList returns the commits in a range, with the semantics of git rev-list:
+// "a..b" is the commits reachable from b but not from a, "a...b" the commits reachable from either but not both,
+// and a single revision all of its ancestors. An omitted end of a range defaults to HEAD.
+// Every revision is passed through pin first.
+func revList(repo *git.Repository, spec string, pin func(rev string) string) ([]plumbing.Hash, error) {
+	var sep = ".."
+	if strings.Contains(spec, "...") {
+		sep = "..."
+	}
+
+	var parts = strings.SplitN(spec, sep, 2)
+	var tips = make([]plumbing.Hash, len(parts))
+	for i, rev := range parts {
+		var err error
+		if tips[i], err = resolveCommit(repo, pin(rev)); err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve %q", rev)
+		}
+	}
+
+	var exclude = make(map[plumbing.Hash]bool)
+	if len(tips) == 2 {
+		left, err := ancestors(repo, tips[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if sep == "..." {
+			right, err := ancestors(repo, tips[1])
+			if err != nil {
+				return nil, err
+			}
+			for hash := range left {
+				if right[hash] {
+					exclude[hash] = true
+				}
+			}
+		} else {
+			exclude = left
+		}
+	}
+
+	// tips are walked from the right end of the range, as git rev-list lists the most recent commits first
+	var hashes []plumbing.Hash
+	for i := len(tips) - 1; i >= 0; i-- {
+		commits, err := repo.Log(&git.LogOptions{From: tips[i]})
+		if err != nil {
+			return nil, err
+		}
+
+		err = commits.ForEach(func(commit *object.Commit) error {
+			if !exclude[commit.Hash] {
+				exclude[commit.Hash] = true // only list commits reachable from both ends of "a...b" once
+				hashes = append(hashes, commit.Hash)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if sep == ".." {
+			break // the left end of "a..b" only excludes commits
+		}
+	}
+	return hashes, nil
+}
+
+var revListCols = []vtab.Column{
+	{Name: "hash", Type: sqlite.SQLITE_TEXT},
+
+	{Name: "repository", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "range", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// NewRevListModule returns the implementation of a table-valued-function listing the hashes of the commits in a range,
+// such as HEAD~20..HEAD or v1.0..v2.0, so they can be used in WHERE clauses
+func NewRevListModule(locator services.RepoLocator, ctx services.Context) sqlite.Module {
+	return vtab.NewTableFunc("rev_list", revListCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, spec string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 1:
+					repoPath = constraint.Value.Text()
+				case 2:
+					spec = constraint.Value.Text()
+				}
+			}
+		}
+
+		repo, repoPath, err := openRepo(locator, ctx, repoPath)
+		if err != nil {
+			return nil, err
+		}
+
+		hashes, err := revList(repo, spec, func(rev string) string { return PinnedRev(locator, repoPath, rev) })
+		if err != nil {
+			return nil, err
+		}
+		return &revListIter{repoPath: repoPath, spec: spec, hashes: hashes, index: -1}, nil
+	})
+}
+
+type revListIter struct {
+	repoPath, spec string
+	hashes         []plumbing.Hash
+	index          int
+}
+
+func (i *revListIter) Next() (vtab.Row, error) {
+	i.index++
+	if i.index >= len(i.hashes) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+func (i *revListIter) Column(ctx *sqlite.Context, c int) error {
+	switch c {
+	case 0:
+		ctx.ResultText(i.hashes[i.index].String())
+	case 1:
+		ctx.ResultText(i.repoPath)
+	case 2:
+		ctx.ResultText(i.spec)
+	}
+	return nil
+}
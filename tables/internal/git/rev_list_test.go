@@ -0,0 +1,38 @@
+package git_test
+
+import (
+	"testing"
+)
+
+func TestRevList(t *testing.T) {
+	db := Connect(t, Memory)
+	repo := "https://github.com/askgitdev/askgit"
+
+	var head, parent string
+	if err := db.QueryRow("SELECT rev_parse(?, 'HEAD'), rev_parse(?, 'HEAD~1')", repo, repo).Scan(&head, &parent); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if len(head) != 40 || head == parent {
+		t.Fatalf("unexpected revisions: HEAD=%q HEAD~1=%q", head, parent)
+	}
+
+	// HEAD~20..HEAD walks the 20 commits of the first-parent chain, and any history merged along the way
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM rev_list(?, 'HEAD~20..HEAD')", repo).Scan(&count); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if count < 20 {
+		t.Fatalf("expected at least 20 commits, got: %d", count)
+	}
+
+	var all, excluded int
+	if err := db.QueryRow("SELECT count(*) FROM commits(?)", repo).Scan(&all); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if err := db.QueryRow("SELECT count(*) FROM rev_list(?, 'HEAD~20')", repo).Scan(&excluded); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if count+excluded != all {
+		t.Fatalf("expected HEAD~20..HEAD and HEAD~20 to partition the history, got: %d + %d != %d", count, excluded, all)
+	}
+}
@@ -0,0 +1,108 @@
+package git
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/askgitdev/askgit/tables/services"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+// signedObjectOf returns the annotated tag or commit rev names, with its signature
+func signedObjectOf(repo *git.Repository, rev string) (*signedObject, error) {
+	// tags are looked up by hash first, as resolving a revision peels them to their commit
+	if plumbing.IsHash(rev) {
+		if tag, err := repo.TagObject(plumbing.NewHash(rev)); err == nil {
+			return tagSignature(tag)
+		}
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve %q", rev)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	return commitSignature(commit)
+}
+
+// SignatureTrustedFn implements the SIGNATURE_TRUSTED(hash [, repository [, allowlist]]) sql function,
+// returning whether the commit (or annotated tag) is signed with one of the keys of the allowlist,
+// a keyring file (see git_commit_signatures) that defaults to the one set with --trusted-keys.
+// As the keyring of git_commit_signatures, the allowlist argument is ignored in the sandbox.
+type SignatureTrustedFn struct {
+	Locator services.RepoLocator
+	Context services.Context
+
+	mu       sync.Mutex
+	keyrings map[string]*keyring // by path, read once
+}
+
+func (*SignatureTrustedFn) Deterministic() bool { return true }
+func (*SignatureTrustedFn) Args() int           { return -1 }
+func (fn *SignatureTrustedFn) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	if len(values) < 1 || len(values) > 3 {
+		c.ResultError(errors.New("signature_trusted takes a commit, and optionally a repository and an allowlist of keys"))
+		return
+	}
+
+	var repoPath, allowlist = "", fn.Context["trustedKeys"]
+	if len(values) > 1 {
+		repoPath = values[1].Text()
+	}
+	if sandboxed, _ := strconv.ParseBool(fn.Context["sandboxed"]); len(values) > 2 && !sandboxed {
+		allowlist = values[2].Text()
+	}
+	if allowlist == "" {
+		c.ResultError(errors.New("signature_trusted needs an allowlist of keys, set with --trusted-keys"))
+		return
+	}
+
+	keys, err := fn.keyring(allowlist)
+	if err != nil {
+		c.ResultError(err)
+		return
+	}
+
+	repo, repoPath, err := openRepo(fn.Locator, fn.Context, repoPath)
+	if err != nil {
+		c.ResultError(err)
+		return
+	}
+
+	obj, err := signedObjectOf(repo, PinnedRev(fn.Locator, repoPath, values[0].Text()))
+	if err != nil {
+		c.ResultError(err)
+		return
+	}
+
+	if keys.verify(obj.payload, obj.signature).status == statusGood {
+		c.ResultInt(1)
+	} else {
+		c.ResultInt(0)
+	}
+}
+
+// keyring returns the keyring at path, read the first time it's needed
+func (fn *SignatureTrustedFn) keyring(path string) (*keyring, error) {
+	fn.mu.Lock()
+	defer fn.mu.Unlock()
+
+	if k, ok := fn.keyrings[path]; ok {
+		return k, nil
+	}
+	k, err := loadKeyring(path)
+	if err != nil {
+		return nil, err
+	}
+	if fn.keyrings == nil {
+		fn.keyrings = make(map[string]*keyring)
+	}
+	fn.keyrings[path] = k
+	return k, nil
+}
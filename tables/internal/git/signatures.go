@@ -0,0 +1,454 @@
+package git
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/askgitdev/askgit/tables/services"
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+	"golang.org/x/crypto/ssh"
+)
+
+// verification statuses of signatures
+const (
+	statusUnsigned    = "unsigned"    // the object isn't signed
+	statusGood        = "good"        // the signature is valid, and made by a key of the keyring
+	statusBad         = "bad"         // the signature is malformed or doesn't match the object
+	statusUnknownKey  = "unknown_key" // the signing key isn't in the keyring (or there's no keyring)
+	statusUnsupported = "unsupported" // the signature can't be verified (x509 signatures)
+)
+
+const (
+	beginPGPSignature  = "-----BEGIN PGP SIGNATURE-----"
+	beginSSHSignature  = "-----BEGIN SSH SIGNATURE-----"
+	endSSHSignature    = "-----END SSH SIGNATURE-----"
+	beginX509Signature = "-----BEGIN SIGNED MESSAGE-----"
+	beginPGPPublicKey  = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+	endPGPPublicKey    = "-----END PGP PUBLIC KEY BLOCK-----"
+)
+
+// allowedSigner is an SSH key of the keyring, and the principal (usually an email address) it's allowed to sign as
+type allowedSigner struct {
+	principal string
+	key       ssh.PublicKey
+}
+
+// keyring holds the keys signatures are verified against
+type keyring struct {
+	pgp openpgp.EntityList
+	ssh map[string]*allowedSigner // by SHA256 fingerprint
+}
+
+// loadKeyring reads the keyring at path, a file of armored PGP public keys (such as the output of gpg --export --armor,
+// or https://github.com/<login>.gpg) and / or SSH public keys, one per line in the format of git's
+// gpg.ssh.allowedSignersFile (principal followed by the key) or of authorized_keys (such as https://github.com/<login>.keys)
+func loadKeyring(path string) (*keyring, error) {
+	var k = &keyring{ssh: make(map[string]*allowedSigner)}
+	if path == "" {
+		return k, nil
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read keyring")
+	}
+	var contents = string(buf)
+
+	// PGP key blocks are cut out first, everything left is read line by line as SSH keys
+	for {
+		start := strings.Index(contents, beginPGPPublicKey)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(contents[start:], endPGPPublicKey)
+		if end < 0 {
+			return nil, errors.Errorf("failed to read keyring %s: unterminated PGP public key block", path)
+		}
+		end += start + len(endPGPPublicKey)
+
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(contents[start:end]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read keyring %s", path)
+		}
+		k.pgp = append(k.pgp, entities...)
+		contents = contents[:start] + contents[end:]
+	}
+
+	for n, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		signer, err := parseAllowedSigner(line)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read keyring %s, line %d", path, n+1)
+		}
+		k.ssh[ssh.FingerprintSHA256(signer.key)] = signer
+	}
+
+	return k, nil
+}
+
+// parseAllowedSigner parses a line of an allowed signers or authorized keys file. The key is looked for after
+// the principals and options of the line, whichever way they're written. The principal of lines starting with
+// the key (authorized keys) is its comment.
+func parseAllowedSigner(line string) (*allowedSigner, error) {
+	var fields = strings.Fields(line)
+	var err error
+	for i := range fields {
+		var key ssh.PublicKey
+		var comment string
+		if key, comment, _, _, err = ssh.ParseAuthorizedKey([]byte(strings.Join(fields[i:], " "))); err == nil {
+			if fields[0] != key.Type() {
+				comment = fields[0]
+			}
+			return &allowedSigner{principal: comment, key: key}, nil
+		}
+	}
+	return nil, err
+}
+
+// verification is the outcome of the verification of the signature of an object.
+// Empty fields are unknown, and reported as NULL.
+type verification struct {
+	sigType, keyID, fingerprint, signer, status string
+}
+
+// verify verifies signature, the signature of the object encoded in payload
+func (k *keyring) verify(payload []byte, signature string) *verification {
+	switch {
+	case signature == "":
+		return &verification{status: statusUnsigned}
+	case strings.HasPrefix(signature, beginPGPSignature):
+		return k.verifyPGP(payload, signature)
+	case strings.HasPrefix(signature, beginSSHSignature):
+		return k.verifySSH(payload, signature)
+	case strings.HasPrefix(signature, beginX509Signature):
+		return &verification{sigType: "x509", status: statusUnsupported}
+	default:
+		return &verification{status: statusBad}
+	}
+}
+
+func (k *keyring) verifyPGP(payload []byte, signature string) *verification {
+	var v = &verification{sigType: "gpg", status: statusBad}
+
+	// the issuer is read from the signature itself, to be reported even when the key isn't in the keyring
+	block, err := armor.Decode(strings.NewReader(signature))
+	if err != nil {
+		return v
+	}
+	p, err := packet.NewReader(block.Body).Next()
+	if err != nil {
+		return v
+	}
+	sig, ok := p.(*packet.Signature)
+	if !ok {
+		return v
+	}
+	if sig.IssuerKeyId != nil {
+		v.keyID = fmt.Sprintf("%016X", *sig.IssuerKeyId)
+	}
+	if len(sig.IssuerFingerprint) > 0 {
+		v.fingerprint = fmt.Sprintf("%X", sig.IssuerFingerprint)
+	}
+
+	entity, err := openpgp.CheckArmoredDetachedSignature(k.pgp, bytes.NewReader(payload), strings.NewReader(signature), nil)
+	switch {
+	case err == pgperrors.ErrUnknownIssuer:
+		v.status = statusUnknownKey
+	case err != nil:
+		v.status = statusBad
+	default:
+		v.status = statusGood
+		if v.fingerprint == "" {
+			v.fingerprint = fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint)
+		}
+		if id := entity.PrimaryIdentity(); id != nil {
+			v.signer = id.Name
+		}
+	}
+	return v
+}
+
+// sshSignature is the blob of an SSH signature, following its "SSHSIG" magic preamble.
+// See https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.sshsig
+type sshSignature struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+const sshSigMagic = "SSHSIG"
+
+func (k *keyring) verifySSH(payload []byte, signature string) *verification {
+	var v = &verification{sigType: "ssh", status: statusBad}
+
+	var armored = strings.TrimSpace(signature)
+	armored = strings.TrimSuffix(strings.TrimPrefix(armored, beginSSHSignature), endSSHSignature)
+	blob, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(armored), ""))
+	if err != nil || !bytes.HasPrefix(blob, []byte(sshSigMagic)) {
+		return v
+	}
+
+	var sig sshSignature
+	if err := ssh.Unmarshal(blob[len(sshSigMagic):], &sig); err != nil {
+		return v
+	}
+	key, err := ssh.ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return v
+	}
+	v.fingerprint = ssh.FingerprintSHA256(key)
+
+	var h hash.Hash
+	switch sig.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return v
+	}
+	h.Write(payload)
+
+	var signed = append([]byte(sshSigMagic), ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{sig.Namespace, sig.Reserved, sig.HashAlgorithm, h.Sum(nil)})...)
+
+	var s ssh.Signature
+	if err := ssh.Unmarshal(sig.Signature, &s); err != nil {
+		return v
+	}
+	if sig.Namespace != "git" || key.Verify(signed, &s) != nil {
+		return v
+	}
+
+	// the signature is valid, but only trusted if made with a key of the keyring
+	if signer, ok := k.ssh[v.fingerprint]; ok {
+		v.status, v.signer = statusGood, signer.principal
+	} else {
+		v.status = statusUnknownKey
+	}
+	return v
+}
+
+// signedObject is a commit or an annotated tag, with its signature
+type signedObject struct {
+	hash       plumbing.Hash
+	objectType string
+	signature  string
+	payload    []byte // the encoded object, without its signature
+}
+
+// commitSignature returns commit as a signedObject
+func commitSignature(commit *object.Commit) (*signedObject, error) {
+	var encoded = &plumbing.MemoryObject{}
+	if err := commit.EncodeWithoutSignature(encoded); err != nil {
+		return nil, err
+	}
+	payload, err := readObject(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return &signedObject{commit.Hash, "commit", commit.PGPSignature, payload}, nil
+}
+
+// tagSignature returns tag as a signedObject
+func tagSignature(tag *object.Tag) (*signedObject, error) {
+	// only PGP signatures are split from the message of tags when decoding them
+	var t = *tag
+	if t.PGPSignature == "" {
+		if i := strings.Index(t.Message, beginSSHSignature); i >= 0 {
+			t.Message, t.PGPSignature = t.Message[:i], t.Message[i:]
+		}
+	}
+
+	var encoded = &plumbing.MemoryObject{}
+	if err := t.EncodeWithoutSignature(encoded); err != nil {
+		return nil, err
+	}
+	payload, err := readObject(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return &signedObject{tag.Hash, "tag", t.PGPSignature, payload}, nil
+}
+
+func readObject(o plumbing.EncodedObject) ([]byte, error) {
+	r, err := o.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+var signaturesCols = []vtab.Column{
+	{Name: "hash", Type: sqlite.SQLITE_TEXT},
+	{Name: "object_type", Type: sqlite.SQLITE_TEXT},
+	{Name: "signed", Type: sqlite.SQLITE_INTEGER},
+	{Name: "signature_type", Type: sqlite.SQLITE_TEXT},
+	{Name: "key_id", Type: sqlite.SQLITE_TEXT},
+	{Name: "fingerprint", Type: sqlite.SQLITE_TEXT},
+	{Name: "signer", Type: sqlite.SQLITE_TEXT},
+	{Name: "status", Type: sqlite.SQLITE_TEXT},
+
+	{Name: "repository", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "ref", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "keyring", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// NewCommitSignaturesModule returns the implementation of a table-valued-function listing the signatures of the commits
+// reachable from ref (HEAD by default) followed by those of the annotated tags of the repository, verified against
+// the keys of keyring (the file set with --signing-keyring by default). In the sandbox, the keyring argument is ignored,
+// so that clients can't have any file of the host read.
+func NewCommitSignaturesModule(locator services.RepoLocator, ctx services.Context) sqlite.Module {
+	var sandboxed, _ = strconv.ParseBool(ctx["sandboxed"])
+	return vtab.NewTableFunc("git_commit_signatures", signaturesCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref string
+		var keyringPath = ctx["signingKeyring"]
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 8:
+					repoPath = constraint.Value.Text()
+				case 9:
+					ref = constraint.Value.Text()
+				case 10:
+					if !sandboxed {
+						keyringPath = constraint.Value.Text()
+					}
+				}
+			}
+		}
+
+		keys, err := loadKeyring(keyringPath)
+		if err != nil {
+			return nil, err
+		}
+
+		repo, repoPath, err := openRepo(locator, ctx, repoPath)
+		if err != nil {
+			return nil, err
+		}
+
+		from, err := resolveCommit(repo, PinnedRev(locator, repoPath, ref))
+		if err != nil {
+			return nil, err
+		}
+
+		commits, err := repo.Log(&git.LogOptions{From: from})
+		if err != nil {
+			return nil, err
+		}
+		tags, err := repo.TagObjects()
+		if err != nil {
+			commits.Close()
+			return nil, err
+		}
+
+		return &signaturesIter{repoPath: repoPath, ref: ref, keyringPath: keyringPath, keys: keys, commits: commits, tags: tags}, nil
+	})
+}
+
+type signaturesIter struct {
+	repoPath, ref, keyringPath string
+	keys                       *keyring
+	commits                    object.CommitIter
+	tags                       *object.TagIter
+
+	object       *signedObject
+	verification *verification
+}
+
+func (i *signaturesIter) Next() (vtab.Row, error) {
+	var err error
+	if i.object, err = i.next(); err != nil {
+		i.commits.Close()
+		i.tags.Close()
+		return nil, err
+	}
+	i.verification = i.keys.verify(i.object.payload, i.object.signature)
+	return i, nil
+}
+
+// next returns the next commit, then the next annotated tag once all commits are listed
+func (i *signaturesIter) next() (*signedObject, error) {
+	commit, err := i.commits.Next()
+	if err == nil {
+		return commitSignature(commit)
+	}
+	if !eof(err) {
+		return nil, err
+	}
+
+	tag, err := i.tags.Next()
+	if err != nil {
+		return nil, err
+	}
+	return tagSignature(tag)
+}
+
+func (i *signaturesIter) Column(ctx *sqlite.Context, c int) error {
+	var v = i.verification
+	switch c {
+	case 0:
+		ctx.ResultText(i.object.hash.String())
+	case 1:
+		ctx.ResultText(i.object.objectType)
+	case 2:
+		if i.object.signature != "" {
+			ctx.ResultInt(1)
+		} else {
+			ctx.ResultInt(0)
+		}
+	case 3:
+		resultTextOrNull(ctx, v.sigType)
+	case 4:
+		resultTextOrNull(ctx, v.keyID)
+	case 5:
+		resultTextOrNull(ctx, v.fingerprint)
+	case 6:
+		resultTextOrNull(ctx, v.signer)
+	case 7:
+		ctx.ResultText(v.status)
+	case 8:
+		ctx.ResultText(i.repoPath)
+	case 9:
+		ctx.ResultText(i.ref)
+	case 10:
+		ctx.ResultText(i.keyringPath)
+	}
+	return nil
+}
+
+func resultTextOrNull(ctx *sqlite.Context, s string) {
+	if s == "" {
+		ctx.ResultNull()
+	} else {
+		ctx.ResultText(s)
+	}
+}
@@ -0,0 +1,216 @@
+package git
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/crypto/ssh"
+)
+
+// writeKeyring writes contents to a keyring file in dir
+func writeKeyring(t *testing.T, dir, contents string) string {
+	var path = filepath.Join(dir, "keyring")
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// sshSign signs payload the way git does with gpg.format=ssh
+func sshSign(t *testing.T, signer ssh.Signer, payload []byte) string {
+	var h = sha512.Sum512(payload)
+	var signed = append([]byte(sshSigMagic), ssh.Marshal(struct {
+		Namespace, Reserved, HashAlgorithm string
+		Hash                               []byte
+	}{"git", "", "sha512", h[:]})...)
+
+	sig, err := signer.Sign(rand.Reader, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var blob = append([]byte(sshSigMagic), ssh.Marshal(&sshSignature{1, signer.PublicKey().Marshal(), "git", "", "sha512", ssh.Marshal(sig)})...)
+	return beginSSHSignature + "\n" + base64.StdEncoding.EncodeToString(blob) + "\n" + endSSHSignature + "\n"
+}
+
+func TestVerifySSH(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var payload = []byte("tree 4b825dc642cb6eb9a060e54bf8d69288fbe4904\n\ninitial commit\n")
+	var signature = sshSign(t, signer, payload)
+
+	var line = "alice@example.com namespaces=\"git\" " + string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+	dir, err := ioutil.TempDir("", "askgit-keyring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	keys, err := loadKeyring(writeKeyring(t, dir, "# allowed signers\n"+line))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := keys.verify(payload, signature)
+	if v.sigType != "ssh" || v.status != statusGood || v.signer != "alice@example.com" {
+		t.Fatalf("expected a good signature by alice@example.com, got: %+v", v)
+	}
+	if v.fingerprint != ssh.FingerprintSHA256(signer.PublicKey()) {
+		t.Fatalf("unexpected fingerprint: %s", v.fingerprint)
+	}
+
+	if v := keys.verify(append(payload, '!'), signature); v.status != statusBad {
+		t.Fatalf("expected the signature of a modified object to be bad, got: %s", v.status)
+	}
+
+	empty, _ := loadKeyring("")
+	if v := empty.verify(payload, signature); v.status != statusUnknownKey {
+		t.Fatalf("expected the key to be unknown, got: %s", v.status)
+	}
+
+	if v := empty.verify(payload, ""); v.status != statusUnsigned || v.sigType != "" {
+		t.Fatalf("expected no signature, got: %+v", v)
+	}
+}
+
+func TestVerifyPGP(t *testing.T) {
+	entity, err := openpgp.NewEntity("Alice", "", "alice@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var payload = []byte("tree 4b825dc642cb6eb9a060e54bf8d69288fbe4904\n\ninitial commit\n")
+	var signature bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&signature, entity, bytes.NewReader(payload), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var public bytes.Buffer
+	w, err := armor.Encode(&public, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	dir, err := ioutil.TempDir("", "askgit-keyring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	keys, err := loadKeyring(writeKeyring(t, dir, public.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := keys.verify(payload, signature.String())
+	if v.sigType != "gpg" || v.status != statusGood || !strings.HasPrefix(v.signer, "Alice") {
+		t.Fatalf("expected a good signature by Alice, got: %+v", v)
+	}
+	if v.keyID != entity.PrimaryKey.KeyIdString() {
+		t.Fatalf("expected key id %s, got: %s", entity.PrimaryKey.KeyIdString(), v.keyID)
+	}
+
+	if v := keys.verify(append(payload, '!'), signature.String()); v.status != statusBad {
+		t.Fatalf("expected the signature of a modified object to be bad, got: %s", v.status)
+	}
+
+	empty, _ := loadKeyring("")
+	if v := empty.verify(payload, signature.String()); v.status != statusUnknownKey || v.keyID == "" {
+		t.Fatalf("expected the key to be unknown, got: %+v", v)
+	}
+}
+
+func TestSignatureTrusted(t *testing.T) {
+	trusted, err := openpgp.NewEntity("Alice", "", "alice@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rogue, err := openpgp.NewEntity("Mallory", "", "mallory@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "askgit-signed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	repo, err := git.PlainInit(filepath.Join(dir, "repo"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var author = &object.Signature{Name: "Alice", Email: "alice@example.com", When: time.Now()}
+	var commits = map[string]*openpgp.Entity{"trusted": trusted, "rogue": rogue, "unsigned": nil}
+	var hashes = make(map[string]plumbing.Hash)
+	for _, name := range []string{"trusted", "rogue", "unsigned"} {
+		if hashes[name], err = wt.Commit(name, &git.CommitOptions{Author: author, SignKey: commits[name]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tag, err := repo.CreateTag("v1.0", hashes["trusted"], &git.CreateTagOptions{Tagger: author, Message: "v1.0", SignKey: trusted})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var public bytes.Buffer
+	w, err := armor.Encode(&public, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := trusted.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	keys, err := loadKeyring(writeKeyring(t, dir, public.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for rev, expected := range map[string]string{
+		hashes["trusted"].String():  statusGood,
+		hashes["rogue"].String():    statusUnknownKey,
+		hashes["unsigned"].String(): statusUnsigned,
+		tag.Hash().String():         statusGood,
+		"HEAD~2":                    statusGood,
+	} {
+		obj, err := signedObjectOf(repo, rev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := keys.verify(obj.payload, obj.signature); v.status != expected {
+			t.Fatalf("expected %s to be %s, got: %s", rev, expected, v.status)
+		}
+	}
+}
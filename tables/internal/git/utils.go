@@ -1,12 +1,14 @@
 package git
 
 import (
+	"context"
 	"encoding/base64"
 	"io"
 	"os"
 	"strings"
 
 	"github.com/askgitdev/askgit/tables/services"
+	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 )
 
@@ -34,3 +36,38 @@ func GetDefaultRepoFromCtx(ctx services.Context) (repoPath string, err error) {
 	}
 	return
 }
+
+// openRepo opens the repository at repoPath with locator, or the default repository if repoPath is empty
+func openRepo(locator services.RepoLocator, ctx services.Context, repoPath string) (*git.Repository, string, error) {
+	if repoPath == "" {
+		var err error
+		if repoPath, err = GetDefaultRepoFromCtx(ctx); err != nil {
+			return nil, "", err
+		}
+	}
+
+	repo, err := locator.Open(context.Background(), repoPath)
+	return repo, repoPath, err
+}
+
+// PinnedRev returns the revision tables should read in the repository at repoPath, given the one they're asked for:
+// when rev designates HEAD (or is empty) and the locator pins repositories to a snapshot, the commit they're pinned to.
+// The repository must have been opened with the locator first.
+func PinnedRev(locator services.RepoLocator, repoPath, rev string) string {
+	// revisions relative to HEAD (such as HEAD~3) are made relative to the snapshot
+	var suffix string
+	switch {
+	case rev == "" || rev == "HEAD":
+	case strings.HasPrefix(rev, "HEAD~") || strings.HasPrefix(rev, "HEAD^"):
+		suffix = rev[len("HEAD"):]
+	default:
+		return rev
+	}
+
+	if snapshots, ok := locator.(services.SnapshotLocator); ok {
+		if hash, ok := snapshots.Snapshot(repoPath); ok {
+			return hash + suffix
+		}
+	}
+	return rev
+}
@@ -0,0 +1,35 @@
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+type snapshots map[string]string
+
+func (s snapshots) Open(context.Context, string) (*git.Repository, error) { return nil, nil }
+func (s snapshots) Snapshot(path string) (string, bool) {
+	hash, ok := s[path]
+	return hash, ok
+}
+
+func TestPinnedRev(t *testing.T) {
+	var locator = snapshots{"/src/askgit": "0123abcd"}
+
+	var tests = []struct{ path, rev, pinned string }{
+		{"/src/askgit", "", "0123abcd"},
+		{"/src/askgit", "HEAD", "0123abcd"},
+		{"/src/askgit", "HEAD~3", "0123abcd~3"},
+		{"/src/askgit", "HEAD^2", "0123abcd^2"},
+		{"/src/askgit", "main", "main"},
+		{"/src/askgit", "HEADLESS", "HEADLESS"},
+		{"/src/other", "HEAD", "HEAD"},
+	}
+	for _, test := range tests {
+		if pinned := PinnedRev(locator, test.path, test.rev); pinned != test.pinned {
+			t.Fatalf("expected %q in %s to be pinned to %q, got: %q", test.rev, test.path, test.pinned, pinned)
+		}
+	}
+}
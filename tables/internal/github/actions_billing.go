@@ -0,0 +1,173 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+type cacheUsage struct {
+	FullName   string `json:"full_name"`
+	SizeBytes  int64  `json:"active_caches_size_in_bytes"`
+	CacheCount int64  `json:"active_caches_count"`
+}
+
+// cacheUsageRows decodes the cache usage of a repository, or a page of that of the repositories of an organization,
+// into rows of cacheUsageCols, after the arguments of the table
+func cacheUsageRows(body []byte, args ...interface{}) ([][]interface{}, error) {
+	var page struct {
+		cacheUsage
+		Repositories []*cacheUsage `json:"repository_cache_usages"`
+	}
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, fmt.Errorf("failed to decode cache usage: %v", err)
+	}
+
+	var usages = page.Repositories
+	if page.FullName != "" {
+		usages = []*cacheUsage{&page.cacheUsage}
+	}
+
+	var rows = make([][]interface{}, 0, len(usages))
+	for _, u := range usages {
+		rows = append(rows, append(args[:len(args):len(args)], u.FullName, u.SizeBytes, u.CacheCount))
+	}
+	return rows, nil
+}
+
+var cacheUsageCols = []vtab.Column{
+	{Name: "owner", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "reponame", Type: sqlite.SQLITE_TEXT, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "repo", Type: sqlite.SQLITE_TEXT},
+	{Name: "active_caches_size_in_bytes", Type: sqlite.SQLITE_INTEGER},
+	{Name: "active_caches_count", Type: sqlite.SQLITE_INTEGER},
+}
+
+// NewCacheUsageModule returns the implementation of a table-valued-function listing the size and number of the active caches
+// of Actions of a repository, or of every repository of an organization using any if only an owner is given
+func NewCacheUsageModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_actions_cache_usage", cacheUsageCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var fullNameOrOwner, name string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && !constraint.Value.IsNil() {
+				switch constraint.ColIndex {
+				case 0:
+					fullNameOrOwner = constraint.Value.Text()
+				case 1:
+					name = constraint.Value.Text()
+				}
+			}
+		}
+
+		if iter, err := requireArgs(opts, "github_actions_cache_usage", "owner", fullNameOrOwner); iter != nil || err != nil {
+			return iter, err
+		}
+
+		var path = fmt.Sprintf("/orgs/%s/actions/cache/usage-by-repository?per_page=%d", url.PathEscape(fullNameOrOwner), maxPageSize)
+		var args = queryArgs("owner", fullNameOrOwner)
+		if name != "" || strings.Contains(fullNameOrOwner, "/") {
+			owner, repo, err := repoOwnerAndName(name, fullNameOrOwner)
+			if err != nil {
+				return nil, err
+			}
+			path = fmt.Sprintf("/repos/%s/%s/actions/cache/usage", url.PathEscape(owner), url.PathEscape(repo))
+			args = queryArgs("owner", owner, "reponame", repo)
+		}
+
+		return restPages(opts, "github_actions_cache_usage", args, path, func(body []byte) ([][]interface{}, error) {
+			return cacheUsageRows(body, fullNameOrOwner, name)
+		}), nil
+	})
+}
+
+type actionsBilling struct {
+	TotalMinutesUsed     float64            `json:"total_minutes_used"`
+	TotalPaidMinutesUsed float64            `json:"total_paid_minutes_used"`
+	IncludedMinutes      float64            `json:"included_minutes"`
+	MinutesUsed          map[string]float64 `json:"minutes_used_breakdown"`
+}
+
+type storageBilling struct {
+	DaysLeft        int64   `json:"days_left_in_billing_cycle"`
+	EstimatedPaidGB float64 `json:"estimated_paid_storage_for_month"`
+	EstimatedGB     float64 `json:"estimated_storage_for_month"`
+}
+
+// billingRows returns the rows of billingCols of the billing of an organization, a row for every operating system
+// (or size of runner) minutes were used on, after the arguments of the table
+func billingRows(actions *actionsBilling, storage *storageBilling, args ...interface{}) [][]interface{} {
+	var systems = make([]string, 0, len(actions.MinutesUsed))
+	for os := range actions.MinutesUsed {
+		systems = append(systems, os)
+	}
+	// sorted by their lowercased names, as they're listed, since the API mixes uppercase and lowercase ones
+	sort.Slice(systems, func(i, j int) bool { return strings.ToLower(systems[i]) < strings.ToLower(systems[j]) })
+
+	var rows = make([][]interface{}, 0, len(systems))
+	for _, os := range systems {
+		rows = append(rows, append(args[:len(args):len(args)], strings.ToLower(os), actions.MinutesUsed[os],
+			actions.TotalMinutesUsed, actions.TotalPaidMinutesUsed, actions.IncludedMinutes,
+			storage.EstimatedGB, storage.EstimatedPaidGB, storage.DaysLeft))
+	}
+	return rows
+}
+
+var billingCols = []vtab.Column{
+	{Name: "org", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "os", Type: sqlite.SQLITE_TEXT},
+	{Name: "minutes_used", Type: sqlite.SQLITE_FLOAT},
+	{Name: "total_minutes_used", Type: sqlite.SQLITE_FLOAT},
+	{Name: "total_paid_minutes_used", Type: sqlite.SQLITE_FLOAT},
+	{Name: "included_minutes", Type: sqlite.SQLITE_FLOAT},
+	{Name: "storage_gb", Type: sqlite.SQLITE_FLOAT},
+	{Name: "paid_storage_gb", Type: sqlite.SQLITE_FLOAT},
+	{Name: "days_left_in_billing_cycle", Type: sqlite.SQLITE_INTEGER},
+}
+
+// NewBillingModule returns the implementation of a table-valued-function listing the minutes of Actions an organization used
+// in the current billing cycle by operating system, along with its totals and the storage of its artifacts and packages
+func NewBillingModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_actions_billing", billingCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var org string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && !constraint.Value.IsNil() && constraint.ColIndex == 0 {
+				org = constraint.Value.Text()
+			}
+		}
+
+		if iter, err := requireArgs(opts, "github_actions_billing", "org", org); iter != nil || err != nil {
+			return iter, err
+		}
+
+		var args = queryArgs("org", org)
+		var get = func(ctx context.Context, path string, v interface{}) error {
+			body, _, err := restGet(ctx, opts, "github_actions_billing", args, restURL+path)
+			if err != nil {
+				return err
+			}
+			if err = json.Unmarshal(body, v); err != nil {
+				return &QueryError{Table: "github_actions_billing", Args: args, Err: fmt.Errorf("failed to decode billing: %v", err)}
+			}
+			return nil
+		}
+
+		return rest.NewIterator(func(ctx context.Context) ([][]interface{}, bool, error) {
+			var actions actionsBilling
+			if err := get(ctx, fmt.Sprintf("/orgs/%s/settings/billing/actions", url.PathEscape(org)), &actions); err != nil {
+				return nil, false, err
+			}
+			var storage storageBilling
+			if err := get(ctx, fmt.Sprintf("/orgs/%s/settings/billing/shared-storage", url.PathEscape(org)), &storage); err != nil {
+				return nil, false, err
+			}
+			return billingRows(&actions, &storage, org), false, nil
+		}), nil
+	})
+}
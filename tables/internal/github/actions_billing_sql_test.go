@@ -0,0 +1,65 @@
+package github_test
+
+import "testing"
+
+func TestCacheUsage(t *testing.T) {
+	// the usage of the repositories of the organization spans two pages
+	defer serve(rest(t, map[string]page{
+		"/orgs/octo-org/actions/cache/usage-by-repository?per_page=100": {
+			body: `{"total_count": 2, "repository_cache_usages": [{"full_name": "octo-org/Hello-World", "active_caches_size_in_bytes": 2322142, "active_caches_count": 3}]}`,
+			next: "/organizations/1/actions/cache/usage-by-repository?per_page=100&page=2",
+		},
+		"/organizations/1/actions/cache/usage-by-repository?per_page=100&page=2": {
+			body: `{"total_count": 2, "repository_cache_usages": [{"full_name": "octo-org/server", "active_caches_size_in_bytes": 1022142, "active_caches_count": 2}]}`,
+		},
+		"/repos/octo-org/Hello-World/actions/cache/usage": {
+			body: `{"full_name": "octo-org/Hello-World", "active_caches_size_in_bytes": 2322142, "active_caches_count": 3}`,
+		},
+	}))()
+
+	db := Connect(t, Memory)
+
+	// the usage of every repository of an organization, unless one is given
+	content, err := query(t, db, "SELECT * FROM github_actions_cache_usage('octo-org')")
+	expectContent(t, content, err, [][]string{
+		{"octo-org/Hello-World", "2322142", "3"},
+		{"octo-org/server", "1022142", "2"},
+	})
+
+	for _, q := range []string{
+		"SELECT * FROM github_actions_cache_usage('octo-org', 'Hello-World')",
+		"SELECT * FROM github_actions_cache_usage('octo-org/Hello-World')",
+	} {
+		content, err = query(t, db, q)
+		expectContent(t, content, err, [][]string{{"octo-org/Hello-World", "2322142", "3"}})
+	}
+
+	_, err = query(t, db, "SELECT * FROM github_actions_cache_usage")
+	expectError(t, err, "github_actions_cache_usage requires owner")
+}
+
+func TestBilling(t *testing.T) {
+	defer serve(rest(t, map[string]page{
+		"/orgs/octo-org/settings/billing/actions": {
+			body: `{"total_minutes_used": 305, "total_paid_minutes_used": 0, "included_minutes": 3000,
+				"minutes_used_breakdown": {"UBUNTU": 205, "MACOS": 10, "windows": 87.5, "ubuntu_4_core": 2.5}}`,
+		},
+		"/orgs/octo-org/settings/billing/shared-storage": {
+			body: `{"days_left_in_billing_cycle": 20, "estimated_paid_storage_for_month": 15, "estimated_storage_for_month": 40}`,
+		},
+	}))()
+
+	db := Connect(t, Memory)
+
+	// a row for every operating system, by their lowercased names
+	content, err := query(t, db, "SELECT org, * FROM github_actions_billing('octo-org')")
+	expectContent(t, content, err, [][]string{
+		{"octo-org", "macos", "10", "305", "0", "3000", "40", "15", "20"},
+		{"octo-org", "ubuntu", "205", "305", "0", "3000", "40", "15", "20"},
+		{"octo-org", "ubuntu_4_core", "2.5", "305", "0", "3000", "40", "15", "20"},
+		{"octo-org", "windows", "87.5", "305", "0", "3000", "40", "15", "20"},
+	})
+
+	_, err = query(t, db, "SELECT * FROM github_actions_billing")
+	expectError(t, err, "github_actions_billing requires org")
+}
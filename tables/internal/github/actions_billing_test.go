@@ -0,0 +1,57 @@
+package github
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCacheUsageRows(t *testing.T) {
+	var repo = `{"full_name": "octo-org/Hello-World", "active_caches_size_in_bytes": 2322142, "active_caches_count": 3}`
+	rows, err := cacheUsageRows([]byte(repo), "octo-org/Hello-World", "")
+	expectRows(t, rows, err, [][]interface{}{
+		{"octo-org/Hello-World", "", "octo-org/Hello-World", int64(2322142), int64(3)},
+	})
+
+	var org = `{
+		"total_count": 2,
+		"repository_cache_usages": [
+			{"full_name": "octo-org/Hello-World", "active_caches_size_in_bytes": 2322142, "active_caches_count": 3},
+			{"full_name": "octo-org/server", "active_caches_size_in_bytes": 1022142, "active_caches_count": 2}
+		]
+	}`
+	rows, err = cacheUsageRows([]byte(org), "octo-org", "")
+	expectRows(t, rows, err, [][]interface{}{
+		{"octo-org", "", "octo-org/Hello-World", int64(2322142), int64(3)},
+		{"octo-org", "", "octo-org/server", int64(1022142), int64(2)},
+	})
+
+	// organizations without any cache
+	rows, err = cacheUsageRows([]byte(`{"total_count": 0, "repository_cache_usages": []}`), "octo-org", "")
+	expectRows(t, rows, err, nil)
+}
+
+func TestBillingRows(t *testing.T) {
+	var actions actionsBilling
+	var body = `{
+		"total_minutes_used": 305,
+		"total_paid_minutes_used": 0,
+		"included_minutes": 3000,
+		"minutes_used_breakdown": {"UBUNTU": 205, "MACOS": 10, "WINDOWS": 90, "ubuntu_16_core": 0.5}
+	}`
+	if err := json.Unmarshal([]byte(body), &actions); err != nil {
+		t.Fatal(err)
+	}
+
+	var storage storageBilling
+	body = `{"days_left_in_billing_cycle": 20, "estimated_paid_storage_for_month": 15, "estimated_storage_for_month": 40}`
+	if err := json.Unmarshal([]byte(body), &storage); err != nil {
+		t.Fatal(err)
+	}
+
+	expectRows(t, billingRows(&actions, &storage, "octo-org"), nil, [][]interface{}{
+		{"octo-org", "macos", float64(10), float64(305), float64(0), float64(3000), float64(40), float64(15), int64(20)},
+		{"octo-org", "ubuntu", float64(205), float64(305), float64(0), float64(3000), float64(40), float64(15), int64(20)},
+		{"octo-org", "ubuntu_16_core", 0.5, float64(305), float64(0), float64(3000), float64(40), float64(15), int64(20)},
+		{"octo-org", "windows", float64(90), float64(305), float64(0), float64(3000), float64(40), float64(15), int64(20)},
+	})
+}
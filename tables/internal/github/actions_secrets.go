@@ -0,0 +1,126 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+// levels of the secrets and variables of Actions, by where they're defined
+const (
+	levelOrganization = "organization"
+	levelRepository   = "repository"
+	levelEnvironment  = "environment"
+)
+
+// actionsSetting is a secret or variable of Actions. The value of variables is never decoded,
+// so that they're listed like secrets are.
+type actionsSetting struct {
+	Name       string    `json:"name"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Visibility string    `json:"visibility"`
+}
+
+// actionsSettingsPage is a page of the secrets or variables of Actions, which are listed under their kind
+type actionsSettingsPage struct {
+	TotalCount int               `json:"total_count"`
+	Secrets    []*actionsSetting `json:"secrets"`
+	Variables  []*actionsSetting `json:"variables"`
+}
+
+// actionsSettingsRows decodes a page of the secrets or variables (the kind) of Actions into rows of actionsSettingsCols,
+// after the arguments of the table
+func actionsSettingsRows(body []byte, kind, level string, args ...interface{}) ([][]interface{}, error) {
+	var page actionsSettingsPage
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", kind, err)
+	}
+
+	var settings = page.Secrets
+	if kind == "variables" {
+		settings = page.Variables
+	}
+
+	var rows = make([][]interface{}, 0, len(settings))
+	for _, s := range settings {
+		rows = append(rows, append(args[:len(args):len(args)], level, s.Name, s.CreatedAt, s.UpdatedAt, stringOrNil(s.Visibility)))
+	}
+	return rows, nil
+}
+
+var actionsSettingsCols = []vtab.Column{
+	{Name: "owner", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "reponame", Type: sqlite.SQLITE_TEXT, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "environment", Type: sqlite.SQLITE_TEXT, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "level", Type: sqlite.SQLITE_TEXT},
+	{Name: "name", Type: sqlite.SQLITE_TEXT},
+	{Name: "created_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "updated_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "visibility", Type: sqlite.SQLITE_TEXT},
+}
+
+// newActionsSettingsModule returns the implementation of a table-valued-function listing the secrets or variables (the kind)
+// of Actions of an organization, a repository, or an environment of a repository
+func newActionsSettingsModule(opts *Options, table, kind string) sqlite.Module {
+	return vtab.NewTableFunc(table, actionsSettingsCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var fullNameOrOwner, name, environment string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && !constraint.Value.IsNil() {
+				switch constraint.ColIndex {
+				case 0:
+					fullNameOrOwner = constraint.Value.Text()
+				case 1:
+					name = constraint.Value.Text()
+				case 2:
+					environment = constraint.Value.Text()
+				}
+			}
+		}
+
+		if iter, err := requireArgs(opts, table, "owner", fullNameOrOwner); iter != nil || err != nil {
+			return iter, err
+		}
+
+		// settings are those of an organization, unless a repository is given
+		var level, base = levelOrganization, fmt.Sprintf("/orgs/%s/actions/%s", url.PathEscape(fullNameOrOwner), kind)
+		var args = queryArgs("owner", fullNameOrOwner)
+		if name != "" || strings.Contains(fullNameOrOwner, "/") {
+			owner, repo, err := repoOwnerAndName(name, fullNameOrOwner)
+			if err != nil {
+				return nil, err
+			}
+			level, base = levelRepository, fmt.Sprintf("/repos/%s/%s/actions/%s", url.PathEscape(owner), url.PathEscape(repo), kind)
+			args = queryArgs("owner", owner, "reponame", repo, "environment", environment)
+
+			if environment != "" {
+				level, base = levelEnvironment, fmt.Sprintf("/repos/%s/%s/environments/%s/%s", url.PathEscape(owner), url.PathEscape(repo), url.PathEscape(environment), kind)
+			}
+		} else if environment != "" {
+			return nil, errors.New("environments are those of a repository, which must be given too")
+		}
+
+		var path = fmt.Sprintf("%s?per_page=%d", base, maxPageSize)
+		return restPages(opts, table, args, path, func(body []byte) ([][]interface{}, error) {
+			return actionsSettingsRows(body, kind, level, fullNameOrOwner, name, environment)
+		}), nil
+	})
+}
+
+// NewActionsSecretsModule returns the implementation of a table-valued-function listing the names of the secrets
+// of Actions (never their values) of an organization, a repository or an environment, with when they were last updated
+func NewActionsSecretsModule(opts *Options) sqlite.Module {
+	return newActionsSettingsModule(opts, "github_actions_secrets", "secrets")
+}
+
+// NewActionsVariablesModule returns the implementation of a table-valued-function listing the names of the variables
+// of Actions (but not their values) of an organization, a repository or an environment, with when they were last updated
+func NewActionsVariablesModule(opts *Options) sqlite.Module {
+	return newActionsSettingsModule(opts, "github_actions_variables", "variables")
+}
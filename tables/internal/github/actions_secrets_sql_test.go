@@ -0,0 +1,59 @@
+package github_test
+
+import "testing"
+
+func TestActionsSecrets(t *testing.T) {
+	var secret = func(name, visibility string) string {
+		return `{"name": "` + name + `", "created_at": "2019-08-10T14:59:22Z", "updated_at": "2020-01-10T14:59:22Z"` + visibility + `}`
+	}
+
+	// the secrets of the organization span two pages
+	defer serve(rest(t, map[string]page{
+		"/orgs/octo-org/actions/secrets?per_page=100": {
+			body: `{"total_count": 2, "secrets": [` + secret("GIST_ID", `, "visibility": "private"`) + `]}`,
+			next: "/organizations/1/actions/secrets?per_page=100&page=2",
+		},
+		"/organizations/1/actions/secrets?per_page=100&page=2": {
+			body: `{"total_count": 2, "secrets": [` + secret("GH_TOKEN", `, "visibility": "all"`) + `]}`,
+		},
+		"/repos/octo-org/Hello-World/actions/secrets?per_page=100": {
+			body: `{"total_count": 1, "secrets": [` + secret("NPM_TOKEN", "") + `]}`,
+		},
+		"/repos/octo-org/Hello-World/environments/production/secrets?per_page=100": {
+			body: `{"total_count": 1, "secrets": [` + secret("DEPLOY_KEY", "") + `]}`,
+		},
+		"/repos/octo-org/Hello-World/actions/variables?per_page=100": {
+			body: `{"total_count": 1, "variables": [{"name": "USERNAME", "value": "octocat", "created_at": "2019-08-10T14:59:22Z", "updated_at": "2020-01-10T14:59:22Z"}]}`,
+		},
+	}))()
+
+	db := Connect(t, Memory)
+
+	// the secrets of an organization, unless a repository is given
+	content, err := query(t, db, "SELECT level, name, created_at, updated_at, visibility FROM github_actions_secrets('octo-org')")
+	expectContent(t, content, err, [][]string{
+		{"organization", "GIST_ID", "2019-08-10T14:59:22Z", "2020-01-10T14:59:22Z", "private"},
+		{"organization", "GH_TOKEN", "2019-08-10T14:59:22Z", "2020-01-10T14:59:22Z", "all"},
+	})
+
+	for _, q := range []string{
+		"SELECT level, name, visibility FROM github_actions_secrets('octo-org', 'Hello-World')",
+		"SELECT level, name, visibility FROM github_actions_secrets('octo-org/Hello-World')",
+	} {
+		content, err = query(t, db, q)
+		expectContent(t, content, err, [][]string{{"repository", "NPM_TOKEN", "NULL"}})
+	}
+
+	content, err = query(t, db, "SELECT environment, level, name FROM github_actions_secrets('octo-org', 'Hello-World', 'production')")
+	expectContent(t, content, err, [][]string{{"production", "environment", "DEPLOY_KEY"}})
+
+	// the values of variables are left out
+	content, err = query(t, db, "SELECT * FROM github_actions_variables('octo-org/Hello-World')")
+	expectContent(t, content, err, [][]string{{"repository", "USERNAME", "2019-08-10T14:59:22Z", "2020-01-10T14:59:22Z", "NULL"}})
+
+	_, err = query(t, db, "SELECT * FROM github_actions_secrets('octo-org', NULL, 'production')")
+	expectError(t, err, "environments are those of a repository")
+
+	_, err = query(t, db, "SELECT * FROM github_actions_variables")
+	expectError(t, err, "github_actions_variables requires owner")
+}
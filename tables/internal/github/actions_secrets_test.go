@@ -0,0 +1,56 @@
+package github
+
+import "testing"
+
+func TestActionsSettingsRows(t *testing.T) {
+	var secrets = `{
+		"total_count": 3,
+		"secrets": [
+			{
+				"name": "GIST_ID",
+				"created_at": "2019-08-10T14:59:22Z",
+				"updated_at": "2020-01-10T14:59:22Z",
+				"visibility": "private"
+			},
+			{
+				"name": "DEPLOY_TOKEN",
+				"created_at": "2019-08-10T14:59:22Z",
+				"updated_at": "2020-01-10T14:59:22Z",
+				"visibility": "selected",
+				"selected_repositories_url": "https://api.github.com/orgs/octo-org/actions/secrets/DEPLOY_TOKEN/repositories"
+			},
+			{
+				"name": "GH_TOKEN",
+				"created_at": "2019-08-10T14:59:22Z",
+				"updated_at": "2020-01-10T14:59:22Z",
+				"visibility": "all"
+			}
+		]
+	}`
+
+	rows, err := actionsSettingsRows([]byte(secrets), "secrets", levelOrganization, "octo-org", "", "")
+	var created, updated = timestamp(t, "2019-08-10T14:59:22Z"), timestamp(t, "2020-01-10T14:59:22Z")
+	expectRows(t, rows, err, [][]interface{}{
+		{"octo-org", "", "", levelOrganization, "GIST_ID", created, updated, "private"},
+		{"octo-org", "", "", levelOrganization, "DEPLOY_TOKEN", created, updated, "selected"},
+		{"octo-org", "", "", levelOrganization, "GH_TOKEN", created, updated, "all"},
+	})
+
+	// the values of variables are left out, and those of repositories have no visibility
+	var variables = `{
+		"total_count": 1,
+		"variables": [
+			{
+				"name": "USERNAME",
+				"value": "octocat",
+				"created_at": "2019-08-10T14:59:22Z",
+				"updated_at": "2020-01-10T14:59:22Z"
+			}
+		]
+	}`
+
+	rows, err = actionsSettingsRows([]byte(variables), "variables", levelRepository, "octocat/Hello-World", "", "")
+	expectRows(t, rows, err, [][]interface{}{
+		{"octocat/Hello-World", "", "", levelRepository, "USERNAME", created, updated, nil},
+	})
+}
@@ -0,0 +1,271 @@
+package github
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/shurcooL/githubv4"
+	"go.riyazali.net/sqlite"
+)
+
+// affiliations of collaborators, from the closest to the furthest
+const (
+	affiliationOutside  = "outside"  // isn't a member of the organization, and was given access to the repository directly
+	affiliationDirect   = "direct"   // was given access to the repository directly
+	affiliationIndirect = "indirect" // has access through the teams or base permission of the organization only
+)
+
+type collaboratorEdge struct {
+	Permission        string
+	PermissionSources []struct {
+		Source struct {
+			Typename     string `graphql:"__typename"`
+			Organization struct {
+				Login string
+			} `graphql:"... on Organization"`
+			Team struct {
+				Slug string
+			} `graphql:"... on Team"`
+		}
+	}
+	Node struct {
+		Login      string
+		Name       string
+		DatabaseId int
+		Id         string
+	}
+}
+
+// teams returns the slugs of the teams the collaborator has access through, sorted
+func (e *collaboratorEdge) teams() []string {
+	var teams []string
+	for _, s := range e.PermissionSources {
+		if s.Source.Typename == "Team" {
+			teams = append(teams, s.Source.Team.Slug)
+		}
+	}
+	sort.Strings(teams)
+	return teams
+}
+
+type fetchCollaboratorsResults struct {
+	Edges       []*collaboratorEdge
+	HasNextPage bool
+	EndCursor   *githubv4.String
+}
+
+// fetchCollaborators fetches the page of the collaborators of a repository with the given affiliation following cursor
+func fetchCollaborators(ctx context.Context, client *githubv4.Client, owner, name string, affiliation githubv4.CollaboratorAffiliation, perPage int, cursor *githubv4.String) (*fetchCollaboratorsResults, error) {
+	var query struct {
+		Repository struct {
+			Collaborators struct {
+				Edges    []*collaboratorEdge
+				PageInfo pageInfo
+			} `graphql:"collaborators(first: $perPage, after: $cursor, affiliation: $affiliation)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+	var variables = map[string]interface{}{
+		"owner":       githubv4.String(owner),
+		"name":        githubv4.String(name),
+		"perPage":     githubv4.Int(perPage),
+		"cursor":      cursor,
+		"affiliation": affiliation,
+	}
+	if err := client.Query(ctx, &query, variables); err != nil {
+		return nil, err
+	}
+
+	var collaborators = query.Repository.Collaborators
+	return &fetchCollaboratorsResults{collaborators.Edges, collaborators.PageInfo.HasNextPage, &collaborators.PageInfo.EndCursor}, nil
+}
+
+type iterCollaborators struct {
+	fullNameOrOwner, name string
+	owner, repo           string
+	opts                  *Options
+	client                *githubv4.Client
+	outside, direct       *affiliationList
+	current               int
+	results               *fetchCollaboratorsResults
+	pages                 *pager
+}
+
+// affiliationList lists the collaborators of a repository with an affiliation, which the API only tells apart
+// from the others by listing them separately. It's fetched a page at a time, only as far as needed.
+type affiliationList struct {
+	filter  githubv4.CollaboratorAffiliation
+	logins  map[string]bool
+	cursor  *githubv4.String
+	fetched int  // pages fetched
+	more    bool // whether there are pages left to fetch
+}
+
+func newAffiliationList(filter githubv4.CollaboratorAffiliation) *affiliationList {
+	return &affiliationList{filter: filter, logins: make(map[string]bool), more: true}
+}
+
+// lists reports whether list lists the collaborator called login, fetching its pages (within the max_pages hint) until it does,
+// and whether that's known, which it isn't if it doesn't list the collaborator within max_pages
+func (i *iterCollaborators) lists(list *affiliationList, login string) (listed, known bool, err error) {
+	for !list.logins[login] && list.more {
+		if i.pages.hints.maxPages != 0 && list.fetched >= i.pages.hints.maxPages {
+			return false, false, nil
+		}
+		if err := i.opts.RateLimiter.Wait(context.Background(), "github_collaborators", Interactive); err != nil {
+			return false, false, err
+		}
+		results, err := fetchCollaborators(context.Background(), i.client, i.owner, i.repo, list.filter, maxPageSize, list.cursor)
+		if err != nil {
+			return false, false, &QueryError{Table: "github_collaborators", Args: queryArgs("owner", i.owner, "reponame", i.repo), Err: err}
+		}
+		for _, edge := range results.Edges {
+			list.logins[edge.Node.Login] = true
+		}
+		list.cursor, list.more = results.EndCursor, results.HasNextPage
+		list.fetched++
+	}
+	return list.logins[login], true, nil
+}
+
+// affiliation returns the affiliation of the collaborator called login, or "" if it can't be told within the max_pages hint.
+// Outside collaborators are direct ones too, and so are looked for first.
+func (i *iterCollaborators) affiliation(login string) (string, error) {
+	for _, a := range []struct {
+		list *affiliationList
+		name string
+	}{{i.outside, affiliationOutside}, {i.direct, affiliationDirect}} {
+		listed, known, err := i.lists(a.list, login)
+		if err != nil || !known {
+			return "", err
+		}
+		if listed {
+			return a.name, nil
+		}
+	}
+	return affiliationIndirect, nil
+}
+
+func (i *iterCollaborators) Column(ctx *sqlite.Context, c int) error {
+	var edge = i.results.Edges[i.current]
+	switch c {
+	case 0:
+		ctx.ResultText(i.fullNameOrOwner)
+	case 1:
+		ctx.ResultText(i.name)
+	case 2:
+		ctx.ResultText(edge.Node.Login)
+	case 3:
+		resultTextOrNull(ctx, edge.Node.Name)
+	case 4:
+		ctx.ResultText(permissionName(edge.Permission))
+	case 5:
+		ctx.ResultInt(permissionLevels[permissionName(edge.Permission)])
+	case 6:
+		// the affiliations are only fetched if they're queried
+		affiliation, err := i.affiliation(edge.Node.Login)
+		if err != nil {
+			return err
+		}
+		resultTextOrNull(ctx, affiliation)
+	case 7:
+		resultTextOrNull(ctx, strings.Join(edge.teams(), ","))
+	case 8:
+		ctx.ResultInt(edge.Node.DatabaseId)
+	case 9:
+		ctx.ResultText(edge.Node.Id)
+	case 10, 11:
+		i.pages.hints.result(ctx, c-10)
+	}
+	return nil
+}
+
+// fetch fetches the page of collaborators following cursor
+func (i *iterCollaborators) fetch(ctx context.Context, cursor *githubv4.String) (interface{}, error) {
+	return fetchCollaborators(ctx, i.client, i.owner, i.repo, githubv4.CollaboratorAffiliationAll, i.pages.hints.pageSize, cursor)
+}
+
+func (i *iterCollaborators) Next() (vtab.Row, error) {
+	i.current += 1
+
+	if i.results == nil || i.current >= len(i.results.Edges) {
+		if i.results == nil || i.results.HasNextPage && i.pages.more() {
+			var cursor *githubv4.String
+			if i.results != nil {
+				cursor = i.results.EndCursor
+			}
+
+			page, err := i.pages.page(cursor)
+			if err != nil {
+				return nil, err
+			}
+
+			i.results = page.(*fetchCollaboratorsResults)
+			i.current = 0
+
+		} else {
+			return nil, io.EOF
+		}
+	}
+
+	// a page can be empty, for repositories without collaborators
+	if i.current >= len(i.results.Edges) {
+		return nil, io.EOF
+	}
+
+	i.pages.maybeFetchAhead(i.current, len(i.results.Edges), i.results.HasNextPage, i.results.EndCursor)
+
+	return i, nil
+}
+
+var collaboratorsCols = append([]vtab.Column{
+	{Name: "owner", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "reponame", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ}}},
+	{Name: "login", Type: sqlite.SQLITE_TEXT},
+	{Name: "name", Type: sqlite.SQLITE_TEXT},
+	{Name: "permission", Type: sqlite.SQLITE_TEXT},
+	{Name: "permission_level", Type: sqlite.SQLITE_INTEGER},
+	{Name: "affiliation", Type: sqlite.SQLITE_TEXT},
+	{Name: "teams", Type: sqlite.SQLITE_TEXT},
+	{Name: "database_id", Type: sqlite.SQLITE_INTEGER},
+	{Name: "node_id", Type: sqlite.SQLITE_TEXT},
+}, hintCols...)
+
+// NewCollaboratorsModule returns the implementation of a table-valued-function listing the collaborators of a repository,
+// with their effective permission, how they're affiliated with the repository, and the teams they have access through
+func NewCollaboratorsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_collaborators", collaboratorsCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var fullNameOrOwner, name string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					fullNameOrOwner = constraint.Value.Text()
+				case 1:
+					name = constraint.Value.Text()
+				}
+			}
+		}
+
+		if iter, err := requireArgs(opts, "github_collaborators", "owner", fullNameOrOwner); iter != nil || err != nil {
+			return iter, err
+		}
+
+		h, err := parseHints("github_collaborators", collaboratorsCols, constraints)
+		if err != nil {
+			return nil, err
+		}
+
+		owner, repo, err := repoOwnerAndName(name, fullNameOrOwner)
+		if err != nil {
+			return nil, err
+		}
+
+		var iter = &iterCollaborators{fullNameOrOwner: fullNameOrOwner, name: name, owner: owner, repo: repo, opts: opts, client: opts.Client(), current: -1,
+			outside: newAffiliationList(githubv4.CollaboratorAffiliationOutside), direct: newAffiliationList(githubv4.CollaboratorAffiliationDirect)}
+		iter.pages = newPager(opts, "github_collaborators", queryArgs("owner", owner, "reponame", repo), h, iter.fetch)
+		return iter, nil
+	})
+}
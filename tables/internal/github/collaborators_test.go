@@ -0,0 +1,81 @@
+package github_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestCollaborators(t *testing.T) {
+	var alice = `{"permission": "ADMIN", "permissionSources": [{"source": {"__typename": "Repository"}}], "node": {"login": "alice", "name": "Alice", "databaseId": 1, "id": "U_1"}}`
+	var bob = `{"permission": "WRITE", "permissionSources": [{"source": {"__typename": "Repository"}}], "node": {"login": "bob", "name": "", "databaseId": 2, "id": "U_2"}}`
+	var carol = `{"permission": "MAINTAIN", "permissionSources": [
+		{"source": {"__typename": "Team", "slug": "web"}},
+		{"source": {"__typename": "Organization", "login": "org"}},
+		{"source": {"__typename": "Team", "slug": "api"}}
+	], "node": {"login": "carol", "name": "Carol", "databaseId": 3, "id": "U_3"}}`
+
+	// the lists of outside and direct collaborators requested, by the page of them requested
+	var requests []string
+	defer serve(graphql(t, func(query string, variables map[string]interface{}) string {
+		var first = variables["cursor"] == nil
+		var edges, next = "", false
+		switch variables["affiliation"] {
+		case "DIRECT":
+			edges = alice + "," + bob
+		case "OUTSIDE":
+			// the outside collaborators span two pages, the second of them empty
+			if first {
+				edges, next = bob, true
+			}
+		case "ALL":
+			// every collaborator spans two pages
+			if first {
+				edges, next = alice+","+bob, true
+			} else {
+				edges = carol
+			}
+		}
+		if variables["affiliation"] != "ALL" {
+			requests = append(requests, fmt.Sprintf("%s first=%v", variables["affiliation"], first))
+		}
+		return fmt.Sprintf(`{"repository": {"collaborators": {"edges": [%s], "pageInfo": {"endCursor": "Y29sbGFib3JhdG9ycw==", "hasNextPage": %v}}}}`, edges, next)
+	}))()
+
+	db := Connect(t, Memory)
+
+	// the outside and direct collaborators are only listed if the affiliation is queried
+	content, err := query(t, db, "SELECT login, name, permission, permission_level, teams, database_id, node_id FROM github_collaborators('org/repo')")
+	expectContent(t, content, err, [][]string{
+		{"alice", "Alice", "admin", "5", "NULL", "1", "U_1"},
+		{"bob", "NULL", "push", "3", "NULL", "2", "U_2"},
+		{"carol", "Carol", "maintain", "4", "api,web", "3", "U_3"},
+	})
+	if len(requests) != 0 {
+		t.Fatalf("expected the affiliations not to be fetched, got: %v", requests)
+	}
+
+	content, err = query(t, db, "SELECT login, affiliation FROM github_collaborators('org', 'repo')")
+	expectContent(t, content, err, [][]string{
+		{"alice", "direct"},
+		{"bob", "outside"},
+		{"carol", "indirect"},
+	})
+	if want := []string{"OUTSIDE first=true", "OUTSIDE first=false", "DIRECT first=true"}; !reflect.DeepEqual(requests, want) {
+		t.Fatalf("expected the affiliations to be fetched once, by %v, got: %v", want, requests)
+	}
+
+	// a collaborator not found within max_pages pages of the outside collaborators can't be told apart
+	requests = nil
+	content, err = query(t, db, "SELECT login, affiliation FROM github_collaborators('org/repo') WHERE max_pages = 1")
+	expectContent(t, content, err, [][]string{
+		{"alice", "NULL"},
+		{"bob", "outside"},
+	})
+	if want := []string{"OUTSIDE first=true"}; !reflect.DeepEqual(requests, want) {
+		t.Fatalf("expected the affiliations to be fetched by %v, got: %v", want, requests)
+	}
+
+	_, err = query(t, db, "SELECT * FROM github_collaborators")
+	expectError(t, err, "github_collaborators requires owner")
+}
@@ -0,0 +1,97 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+type deployKey struct {
+	ID        int64      `json:"id"`
+	Title     string     `json:"title"`
+	Key       string     `json:"key"`
+	ReadOnly  bool       `json:"read_only"`
+	Verified  bool       `json:"verified"`
+	CreatedAt time.Time  `json:"created_at"`
+	LastUsed  *time.Time `json:"last_used"`
+	AddedBy   string     `json:"added_by"`
+}
+
+// timeOrNil returns t, or nil (to be NULL) if it isn't set
+func timeOrNil(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+// stringOrNil returns s, or nil (to be NULL) if it's empty
+func stringOrNil(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// deployKeysRows decodes a page of the deploy keys of a repository into rows of deployKeysCols, after the arguments of the table
+func deployKeysRows(body []byte, args ...interface{}) ([][]interface{}, error) {
+	var keys []*deployKey
+	if err := json.Unmarshal(body, &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode deploy keys: %v", err)
+	}
+
+	var rows = make([][]interface{}, 0, len(keys))
+	for _, k := range keys {
+		rows = append(rows, append(args[:len(args):len(args)], k.ID, k.Title, k.Key, k.ReadOnly, k.Verified, k.CreatedAt, timeOrNil(k.LastUsed), stringOrNil(k.AddedBy)))
+	}
+	return rows, nil
+}
+
+var deployKeysCols = []vtab.Column{
+	{Name: "owner", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "reponame", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: sqlite.SQLITE_INTEGER},
+	{Name: "title", Type: sqlite.SQLITE_TEXT},
+	{Name: "key", Type: sqlite.SQLITE_TEXT},
+	{Name: "read_only", Type: sqlite.SQLITE_INTEGER},
+	{Name: "verified", Type: sqlite.SQLITE_INTEGER},
+	{Name: "created_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "last_used", Type: sqlite.SQLITE_TEXT},
+	{Name: "added_by", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewDeployKeysModule returns the implementation of a table-valued-function listing the deploy keys of a repository,
+// which the GraphQL API doesn't tell the last use of, and so are listed through the REST API
+func NewDeployKeysModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_deploy_keys", deployKeysCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var fullNameOrOwner, name string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && !constraint.Value.IsNil() {
+				switch constraint.ColIndex {
+				case 0:
+					fullNameOrOwner = constraint.Value.Text()
+				case 1:
+					name = constraint.Value.Text()
+				}
+			}
+		}
+
+		if iter, err := requireArgs(opts, "github_deploy_keys", "owner", fullNameOrOwner); iter != nil || err != nil {
+			return iter, err
+		}
+
+		owner, repo, err := repoOwnerAndName(name, fullNameOrOwner)
+		if err != nil {
+			return nil, err
+		}
+
+		var path = fmt.Sprintf("/repos/%s/%s/keys?per_page=%d", url.PathEscape(owner), url.PathEscape(repo), maxPageSize)
+		return restPages(opts, "github_deploy_keys", queryArgs("owner", owner, "reponame", repo), path, func(body []byte) ([][]interface{}, error) {
+			return deployKeysRows(body, fullNameOrOwner, name)
+		}), nil
+	})
+}
@@ -0,0 +1,36 @@
+package github_test
+
+import "testing"
+
+func TestDeployKeys(t *testing.T) {
+	// the deploy keys span two pages
+	defer serve(rest(t, map[string]page{
+		"/repos/octocat/Hello-World/keys?per_page=100": {
+			body: `[{"id": 1, "key": "ssh-rsa AAA...", "title": "octocat@octomac", "verified": true, "created_at": "2014-12-10T15:53:42Z",
+				"read_only": true, "added_by": "octocat", "last_used": "2022-01-10T15:53:42Z"}]`,
+			next: "/repositories/1296269/keys?per_page=100&page=2",
+		},
+		"/repositories/1296269/keys?per_page=100&page=2": {
+			body: `[{"id": 2, "key": "ssh-ed25519 AAA...", "title": "deploy", "verified": false, "created_at": "2020-03-01T10:00:00Z",
+				"read_only": false, "added_by": null, "last_used": null}]`,
+		},
+	}))()
+
+	db := Connect(t, Memory)
+
+	content, err := query(t, db, "SELECT owner, reponame, id, title, key, read_only, verified, created_at, last_used, added_by FROM github_deploy_keys('octocat/Hello-World')")
+	expectContent(t, content, err, [][]string{
+		{"octocat/Hello-World", "", "1", "octocat@octomac", "ssh-rsa AAA...", "1", "1", "2014-12-10T15:53:42Z", "2022-01-10T15:53:42Z", "octocat"},
+		{"octocat/Hello-World", "", "2", "deploy", "ssh-ed25519 AAA...", "0", "0", "2020-03-01T10:00:00Z", "NULL", "NULL"},
+	})
+
+	// the owner and name of the repository given apart
+	content, err = query(t, db, "SELECT owner, reponame, id FROM github_deploy_keys('octocat', 'Hello-World') LIMIT 1")
+	expectContent(t, content, err, [][]string{{"octocat", "Hello-World", "1"}})
+
+	_, err = query(t, db, "SELECT * FROM github_deploy_keys")
+	expectError(t, err, "github_deploy_keys requires owner")
+
+	_, err = query(t, db, "SELECT * FROM github_deploy_keys('octocat')")
+	expectError(t, err, "must be of format owner/name")
+}
@@ -0,0 +1,40 @@
+package github
+
+import "testing"
+
+func TestDeployKeysRows(t *testing.T) {
+	var body = `[
+		{
+			"id": 1,
+			"key": "ssh-rsa AAA...",
+			"url": "https://api.github.com/repos/octocat/Hello-World/keys/1",
+			"title": "octocat@octomac",
+			"verified": true,
+			"created_at": "2014-12-10T15:53:42Z",
+			"read_only": true,
+			"added_by": "octocat",
+			"last_used": "2022-01-10T15:53:42Z"
+		},
+		{
+			"id": 2,
+			"key": "ssh-ed25519 AAA...",
+			"url": "https://api.github.com/repos/octocat/Hello-World/keys/2",
+			"title": "deploy",
+			"verified": false,
+			"created_at": "2020-03-01T10:00:00Z",
+			"read_only": false,
+			"added_by": null,
+			"last_used": null
+		}
+	]`
+
+	rows, err := deployKeysRows([]byte(body), "octocat/Hello-World", "")
+	expectRows(t, rows, err, [][]interface{}{
+		{"octocat/Hello-World", "", int64(1), "octocat@octomac", "ssh-rsa AAA...", true, true, timestamp(t, "2014-12-10T15:53:42Z"), timestamp(t, "2022-01-10T15:53:42Z"), "octocat"},
+		{"octocat/Hello-World", "", int64(2), "deploy", "ssh-ed25519 AAA...", false, false, timestamp(t, "2020-03-01T10:00:00Z"), nil, nil},
+	})
+
+	if _, err = deployKeysRows([]byte(`{"message": "Not Found"}`)); err == nil {
+		t.Fatal("expected an error decoding a response that isn't a list")
+	}
+}
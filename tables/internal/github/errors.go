@@ -0,0 +1,40 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QueryError is an error of the GitHub API, annotated with the table (or function) whose query failed,
+// its arguments and the cursor of the page being fetched, so that it can be told apart in queries joining several tables,
+// e.g. github_starred_repos(login=foo): Could not resolve to a User with the login of 'foo'.
+type QueryError struct {
+	Table  string
+	Args   []string // the arguments of the table, as name=value
+	Cursor string   // the cursor the page was fetched after, empty for the first page
+	Err    error
+}
+
+func (e *QueryError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s(%s)", e.Table, strings.Join(e.Args, ", "))
+	if e.Cursor != "" {
+		fmt.Fprintf(&b, " after cursor %q", e.Cursor)
+	}
+	fmt.Fprintf(&b, ": %v", e.Err)
+	return b.String()
+}
+
+func (e *QueryError) Unwrap() error { return e.Err }
+
+// queryArgs formats the arguments of a table, given as pairs of names and values, for a QueryError.
+// Arguments with no value are left out.
+func queryArgs(namesAndValues ...string) []string {
+	var args []string
+	for i := 0; i+1 < len(namesAndValues); i += 2 {
+		if namesAndValues[i+1] != "" {
+			args = append(args, namesAndValues[i]+"="+namesAndValues[i+1])
+		}
+	}
+	return args
+}
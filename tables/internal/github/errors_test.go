@@ -0,0 +1,26 @@
+package github_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/askgitdev/askgit/tables/internal/github"
+)
+
+func TestQueryError(t *testing.T) {
+	var cause = errors.New("Could not resolve to a User with the login of 'foo'.")
+
+	var err error = &github.QueryError{Table: "github_starred_repos", Args: []string{"login=foo"}, Err: cause}
+	if want := "github_starred_repos(login=foo): Could not resolve to a User with the login of 'foo'."; err.Error() != want {
+		t.Fatalf("expected %q, got %q", want, err.Error())
+	}
+
+	err = &github.QueryError{Table: "github_repo_issues", Args: []string{"owner=askgitdev", "reponame=askgit"}, Cursor: "Y3Vyc29y", Err: cause}
+	if want := `github_repo_issues(owner=askgitdev, reponame=askgit) after cursor "Y3Vyc29y": Could not resolve to a User with the login of 'foo'.`; err.Error() != want {
+		t.Fatalf("expected %q, got %q", want, err.Error())
+	}
+
+	if !errors.Is(err, cause) {
+		t.Fatal("expected the error to wrap its cause")
+	}
+}
@@ -3,12 +3,20 @@ package github_test
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path"
+	"reflect"
+	"strings"
 	"testing"
 
 	_ "github.com/askgitdev/askgit/pkg/sqlite"
 	"github.com/askgitdev/askgit/tables"
+	"github.com/askgitdev/askgit/tables/internal/tools"
 	"github.com/dnaeon/go-vcr/v2/cassette"
 	"github.com/dnaeon/go-vcr/v2/recorder"
 	_ "github.com/mattn/go-sqlite3"
@@ -51,8 +59,12 @@ func TestMain(m *testing.M) {
 		tables.WithExtraFunctions(),
 		tables.WithGitHub(),
 		tables.WithGitHubClientGetter(func() *githubv4.Client {
+			if server != nil {
+				return githubv4.NewClient(&http.Client{Transport: redirect{}})
+			}
 			return githubv4.NewClient(httpClient)
 		}),
+		tables.WithGitHubTransport(redirect{}),
 	))
 	os.Exit(m.Run())
 }
@@ -75,3 +87,104 @@ func Connect(t *testing.T, dataSourceName string) *sql.DB {
 
 	return db
 }
+
+// server is the fake API the requests made to GitHub are sent to, while a test serves one (see serve)
+var server *httptest.Server
+
+// redirect sends the requests made to GitHub to the fake API of the running test, if there's one
+type redirect struct{}
+
+func (redirect) RoundTrip(req *http.Request) (*http.Response, error) {
+	if server != nil {
+		target, _ := url.Parse(server.URL)
+		req = req.Clone(req.Context())
+		req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// serve serves the API with handler in place of GitHub, until the returned function is called
+func serve(handler http.Handler) func() {
+	server = httptest.NewServer(handler)
+	return func() {
+		server.Close()
+		server = nil
+	}
+}
+
+// graphql answers every query made to the GraphQL API with the data respond returns for it and its variables
+func graphql(t *testing.T, respond func(query string, variables map[string]interface{}) string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data": `+respond(req.Query, req.Variables)+`}`)
+	})
+}
+
+// page is a response of the REST API, linking to the path of the next page if any
+type page struct {
+	body string
+	next string
+}
+
+// rest answers the requests made to a path (with its query) of the REST API with the page in pages
+func rest(t *testing.T, pages map[string]page) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := pages[r.URL.RequestURI()]
+		if !ok {
+			t.Errorf("unexpected request of %s", r.URL.RequestURI())
+			http.NotFound(w, r)
+			return
+		}
+		if p.next != "" {
+			w.Header().Set("Link", `<https://api.github.com`+p.next+`>; rel="next"`)
+		}
+		_, _ = io.WriteString(w, p.body)
+	})
+}
+
+// query runs query, returning the contents of its rows (with NULLs as "NULL")
+func query(t *testing.T, db *sql.DB, query string) ([][]string, error) {
+	t.Helper()
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	_, content, err := tools.RowContent(rows)
+	return content, err
+}
+
+// expectContent fails the test if the contents of the rows returned by a query aren't the expected ones
+func expectContent(t *testing.T, content [][]string, err error, want [][]string) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if len(content) != len(want) {
+		t.Fatalf("expected %d rows, got: %d (%v)", len(want), len(content), content)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(content[i], want[i]) {
+			t.Fatalf("row %d: expected %q, got %q", i, want[i], content[i])
+		}
+	}
+}
+
+// expectError fails the test if a query didn't fail with an error containing message
+func expectError(t *testing.T, err error, message string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected an error containing %q", message)
+	}
+	if !strings.Contains(err.Error(), message) {
+		t.Fatalf("expected an error containing %q, got: %v", message, err)
+	}
+}
@@ -0,0 +1,60 @@
+package github
+
+import (
+	"fmt"
+
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+// maxPageSize is the most rows GitHub returns in a page, and the default page size
+const maxPageSize = 100
+
+// hints are arguments tuning how a table fetches its rows, they're the last hidden columns of every table:
+//
+//	SELECT * FROM github_repo_issues('askgitdev/askgit', NULL, 25, 10)
+//	SELECT * FROM github_starred_repos WHERE login = 'octocat' AND max_pages = 2
+type hints struct {
+	pageSize int // rows per page, maxPageSize if not set
+	maxPages int // pages fetched at most, all of them if 0
+}
+
+// hintCols are the columns of the hints, appended to the columns of every table
+var hintCols = []vtab.Column{
+	{Name: "page_size", Type: sqlite.SQLITE_INTEGER, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "max_pages", Type: sqlite.SQLITE_INTEGER, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}
+
+// parseHints reads the hints of the table with columns cols from the constraints of a query
+func parseHints(table string, cols []vtab.Column, constraints []*vtab.Constraint) (hints, error) {
+	var h = hints{pageSize: maxPageSize}
+	for _, constraint := range constraints {
+		if constraint.Op != sqlite.INDEX_CONSTRAINT_EQ || constraint.Value.IsNil() {
+			continue
+		}
+
+		switch cols[constraint.ColIndex].Name {
+		case "page_size":
+			h.pageSize = constraint.Value.Int()
+			if h.pageSize < 1 || h.pageSize > maxPageSize {
+				return h, fmt.Errorf("%s: page_size must be between 1 and %d, got %d", table, maxPageSize, h.pageSize)
+			}
+		case "max_pages":
+			h.maxPages = constraint.Value.Int()
+			if h.maxPages < 0 {
+				return h, fmt.Errorf("%s: max_pages can't be negative, got %d", table, h.maxPages)
+			}
+		}
+	}
+	return h, nil
+}
+
+// result sets the value of the i-th hint column as the result of ctx
+func (h hints) result(ctx *sqlite.Context, i int) {
+	switch i {
+	case 0:
+		ctx.ResultInt(h.pageSize)
+	case 1:
+		ctx.ResultInt(h.maxPages)
+	}
+}
@@ -0,0 +1,201 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// Priority is the priority of a request to the GitHub API
+type Priority int
+
+const (
+	// Background requests, such as the prefetching of pages, only go out when no interactive request is waiting,
+	// or every interactiveWeight interactive requests, so that they're not starved either
+	Background Priority = iota
+
+	// Interactive requests fetch the rows a query is waiting on
+	Interactive
+)
+
+// interactiveWeight is how many interactive requests are let out for every background one, when both are waiting
+const interactiveWeight = 4
+
+// maxQueuedBackground is how many background requests of a flow can wait at once. As the inner table of a join
+// is iterated anew for every row of the outer one, older prefetches are likely to be for iterators that were dropped,
+// so rather than letting them pile up (and hold up the rate limit), the oldest one is dropped to make room.
+const maxQueuedBackground = 2
+
+// ErrDropped is returned by WaitRequest for a background request dropped to make room for newer ones of its flow
+var ErrDropped = errors.New("github: background request dropped")
+
+// Limiter is a rate limiter shared by all the GitHub tables, letting requests out by priority.
+// Requests of the same priority are queued fairly, in turns, between flows (usually tables),
+// so that a table paging through thousands of results doesn't hold up the others.
+type Limiter struct {
+	limiter *rate.Limiter
+
+	mu          sync.Mutex
+	queues      [2]map[string][]*Request // waiting requests by priority, then flow
+	flows       [2][]string              // flows with waiting requests by priority, in turn order
+	interactive int                      // interactive requests let out in a row while background ones wait
+	running     bool                     // whether the dispatching goroutine is running
+}
+
+// Request is a request waiting to be let out by a Limiter
+type Request struct {
+	flow     string
+	priority Priority
+	granted  chan struct{}
+	err      error // why the request was let out without being granted, set before granted is closed
+}
+
+// NewLimiter returns a Limiter letting requests out at the pace of limiter
+func NewLimiter(limiter *rate.Limiter) *Limiter {
+	return &Limiter{limiter: limiter, queues: [2]map[string][]*Request{{}, {}}}
+}
+
+// Wait blocks until a request of the given flow and priority can be made, or ctx is done
+func (l *Limiter) Wait(ctx context.Context, flow string, priority Priority) error {
+	return l.WaitRequest(ctx, l.Enqueue(flow, priority))
+}
+
+// Enqueue queues a request, to be waited on with WaitRequest. Its priority can be raised in the meantime with Promote.
+func (l *Limiter) Enqueue(flow string, priority Priority) *Request {
+	var r = &Request{flow: flow, priority: priority, granted: make(chan struct{})}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if queue := l.queues[Background][flow]; priority == Background && len(queue) >= maxQueuedBackground {
+		var dropped = queue[0]
+		l.remove(dropped)
+		dropped.err = ErrDropped
+		close(dropped.granted)
+	}
+
+	l.push(r)
+	if !l.running {
+		l.running = true
+		go l.dispatch()
+	}
+	return r
+}
+
+// WaitRequest blocks until r is let out, or ctx is done
+func (l *Limiter) WaitRequest(ctx context.Context, r *Request) error {
+	select {
+	case <-r.granted:
+		return r.err
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// the request may have been let out in the meantime
+	select {
+	case <-r.granted:
+		return r.err
+	default:
+	}
+
+	l.remove(r)
+	return ctx.Err()
+}
+
+// Promote raises a waiting background request to interactive, for instance once the rows it prefetches are needed
+func (l *Limiter) Promote(r *Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r.priority == Interactive || !l.remove(r) {
+		return
+	}
+	r.priority = Interactive
+	l.push(r)
+}
+
+// push queues r, the lock must be held
+func (l *Limiter) push(r *Request) {
+	var queue = l.queues[r.priority][r.flow]
+	if len(queue) == 0 {
+		l.flows[r.priority] = append(l.flows[r.priority], r.flow)
+	}
+	l.queues[r.priority][r.flow] = append(queue, r)
+}
+
+// remove removes r from its queue, and reports whether it was queued. The lock must be held.
+func (l *Limiter) remove(r *Request) bool {
+	var queue = l.queues[r.priority][r.flow]
+	for i, q := range queue {
+		if q == r {
+			queue = append(queue[:i:i], queue[i+1:]...)
+			l.setQueue(r.priority, r.flow, queue)
+			return true
+		}
+	}
+	return false
+}
+
+// setQueue replaces the queue of a flow, dropping the flow from the turns once it's empty. The lock must be held.
+func (l *Limiter) setQueue(priority Priority, flow string, queue []*Request) {
+	if len(queue) > 0 {
+		l.queues[priority][flow] = queue
+		return
+	}
+
+	delete(l.queues[priority], flow)
+	for i, f := range l.flows[priority] {
+		if f == flow {
+			l.flows[priority] = append(l.flows[priority][:i:i], l.flows[priority][i+1:]...)
+			break
+		}
+	}
+}
+
+// next pops the request to let out next, or returns nil if none is waiting. The lock must be held.
+func (l *Limiter) next() *Request {
+	var priority = Interactive
+	switch {
+	case len(l.flows[Interactive]) == 0 && len(l.flows[Background]) == 0:
+		return nil
+	case len(l.flows[Interactive]) == 0, len(l.flows[Background]) > 0 && l.interactive >= interactiveWeight:
+		priority, l.interactive = Background, 0
+	case len(l.flows[Background]) > 0:
+		l.interactive++
+	}
+
+	// the flow whose turn it is goes to the back of the line
+	var flow = l.flows[priority][0]
+	var queue = l.queues[priority][flow]
+	var r = queue[0]
+	l.setQueue(priority, flow, queue[1:])
+	if len(queue) > 1 {
+		l.flows[priority] = append(l.flows[priority][1:], flow)
+	}
+	return r
+}
+
+// dispatch lets requests out, one per token of the rate limiter, as long as some are waiting
+func (l *Limiter) dispatch() {
+	for {
+		l.mu.Lock()
+		if len(l.flows[Interactive]) == 0 && len(l.flows[Background]) == 0 {
+			l.running = false
+			l.mu.Unlock()
+			return
+		}
+		l.mu.Unlock()
+
+		_ = l.limiter.Wait(context.Background())
+
+		l.mu.Lock()
+		if r := l.next(); r != nil {
+			close(r.granted)
+		}
+		l.mu.Unlock()
+	}
+}
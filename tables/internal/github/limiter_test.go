@@ -0,0 +1,127 @@
+package github_test
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/github"
+	"golang.org/x/time/rate"
+)
+
+type queued struct {
+	name    string
+	request *github.Request
+}
+
+// newTestLimiter returns a limiter letting a request out every 10ms, whose first token is already spent,
+// so that requests queued right away are all waiting when the first one is let out
+func newTestLimiter() *github.Limiter {
+	var rl = rate.NewLimiter(rate.Every(10*time.Millisecond), 1)
+	rl.Allow()
+	return github.NewLimiter(rl)
+}
+
+// grantOrder waits for every request, and returns their names in the order they were let out
+func grantOrder(t *testing.T, l *github.Limiter, requests []queued) []string {
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	for _, q := range requests {
+		wg.Add(1)
+		go func(q queued) {
+			defer wg.Done()
+			if err := l.WaitRequest(context.Background(), q.request); err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			order = append(order, q.name)
+			mu.Unlock()
+		}(q)
+	}
+	wg.Wait()
+	return order
+}
+
+func TestLimiterPriority(t *testing.T) {
+	var l = newTestLimiter()
+
+	var requests []queued
+	for i := 0; i < 2; i++ {
+		requests = append(requests, queued{"prefetch", l.Enqueue("prefetch", github.Background)})
+	}
+	for i := 0; i < 5; i++ {
+		requests = append(requests, queued{"query", l.Enqueue("query", github.Interactive)})
+	}
+
+	// background requests wait for interactive ones, but aren't starved by them
+	var want = []string{"query", "query", "query", "query", "prefetch", "query", "prefetch"}
+	if got := grantOrder(t, l, requests); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected requests to be let out in order %v, got %v", want, got)
+	}
+}
+
+func TestLimiterFairness(t *testing.T) {
+	var l = newTestLimiter()
+
+	var requests []queued
+	for i := 0; i < 3; i++ {
+		requests = append(requests, queued{"issues", l.Enqueue("issues", github.Interactive)})
+	}
+	requests = append(requests, queued{"stargazers", l.Enqueue("stargazers", github.Interactive)})
+
+	var want = []string{"issues", "stargazers", "issues", "issues"}
+	if got := grantOrder(t, l, requests); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected requests to be let out in order %v, got %v", want, got)
+	}
+}
+
+func TestLimiterPromote(t *testing.T) {
+	var l = newTestLimiter()
+
+	var requests = []queued{{"prefetch", l.Enqueue("prefetch", github.Background)}}
+	for i := 0; i < 5; i++ {
+		requests = append(requests, queued{"query", l.Enqueue("query", github.Interactive)})
+	}
+	l.Promote(requests[0].request)
+
+	var want = []string{"query", "prefetch", "query", "query", "query", "query"}
+	if got := grantOrder(t, l, requests); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected requests to be let out in order %v, got %v", want, got)
+	}
+}
+
+func TestLimiterCancel(t *testing.T) {
+	var rl = rate.NewLimiter(rate.Every(time.Hour), 1)
+	rl.Allow()
+	var l = github.NewLimiter(rl)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := l.Wait(ctx, "query", github.Interactive); err != context.DeadlineExceeded {
+		t.Fatalf("expected the wait to time out, got %v", err)
+	}
+}
+
+func TestLimiterDrop(t *testing.T) {
+	var l = newTestLimiter()
+
+	var requests []queued
+	for i := 0; i < 3; i++ {
+		requests = append(requests, queued{"prefetch", l.Enqueue("prefetch", github.Background)})
+	}
+
+	// the oldest background request made room for the last one
+	if err := l.WaitRequest(context.Background(), requests[0].request); err != github.ErrDropped {
+		t.Fatalf("expected the oldest prefetch to be dropped, got %v", err)
+	}
+
+	var want = []string{"prefetch", "prefetch"}
+	if got := grantOrder(t, l, requests[1:]); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected requests to be let out in order %v, got %v", want, got)
+	}
+}
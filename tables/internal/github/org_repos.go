@@ -5,10 +5,10 @@ import (
 	"io"
 	"time"
 
+	"github.com/askgitdev/askgit/tables/internal/tz"
 	"github.com/augmentable-dev/vtab"
 	"github.com/shurcooL/githubv4"
 	"go.riyazali.net/sqlite"
-	"golang.org/x/time/rate"
 )
 
 type fetchOrgReposOptions struct {
@@ -36,6 +36,7 @@ type orgRepo struct {
 	DiskUsage   int
 	ForkCount   int
 	HomepageUrl string
+	Id          string
 	IsArchived  bool
 	IsDisabled  bool
 	IsFork      bool
@@ -58,6 +59,7 @@ type orgRepo struct {
 		Nickname string
 	}
 	Name              string
+	NameWithOwner     string
 	OpenGraphImageUrl githubv4.URI
 	PrimaryLanguage   struct {
 		Name string
@@ -110,25 +112,37 @@ func fetchOrgRepos(ctx context.Context, input *fetchOrgReposOptions) (*fetchOrgR
 }
 
 type iterOrgRepos struct {
-	login       string
-	client      *githubv4.Client
-	current     int
-	results     *fetchOrgReposResults
-	rateLimiter *rate.Limiter
-	repoOrder   *githubv4.RepositoryOrder
+	login     string
+	client    *githubv4.Client
+	current   int
+	results   *fetchOrgReposResults
+	pages     *pager
+	repoOrder *githubv4.RepositoryOrder
+	opts      *Options
 }
 
 func (i *iterOrgRepos) Column(ctx *sqlite.Context, c int) error {
-	current := i.results.OrgRepos[i.current]
 	switch c {
 	case 0:
 		ctx.ResultText(i.login)
+	case 31, 32:
+		i.pages.hints.result(ctx, c-31)
+	default:
+		repoColumn(ctx, i.results.OrgRepos[i.current], c)
+	}
+	return nil
+}
+
+// repoColumn sets the result of ctx to column c (created_at to node_id, 1 to 30) of orgReposCols for the repository,
+// the columns shared by the tables of repositories
+func repoColumn(ctx *sqlite.Context, current *orgRepo, c int) {
+	switch c {
 	case 1:
 		t := current.CreatedAt
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t, time.RFC3339Nano))
 		}
 	case 2:
 		ctx.ResultInt(current.DatabaseId)
@@ -157,13 +171,13 @@ func (i *iterOrgRepos) Column(ctx *sqlite.Context, c int) error {
 	case 14:
 		ctx.ResultInt(current.Issues.TotalCount)
 	case 15:
-		ctx.ResultText(current.LatestRelease.Author.Login)
+		resultTextOrNull(ctx, current.LatestRelease.Author.Login)
 	case 16:
 		t := current.LatestRelease.CreatedAt
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t.Time, time.RFC3339Nano))
 		}
 	case 17:
 		ctx.ResultText(current.LatestRelease.Name)
@@ -172,7 +186,7 @@ func (i *iterOrgRepos) Column(ctx *sqlite.Context, c int) error {
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t.Time, time.RFC3339Nano))
 		}
 	case 19:
 		ctx.ResultText(current.LicenseInfo.Key)
@@ -191,7 +205,7 @@ func (i *iterOrgRepos) Column(ctx *sqlite.Context, c int) error {
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t, time.RFC3339Nano))
 		}
 	case 26:
 		ctx.ResultInt(current.Releases.TotalCount)
@@ -202,34 +216,49 @@ func (i *iterOrgRepos) Column(ctx *sqlite.Context, c int) error {
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t, time.RFC3339Nano))
 		}
 	case 29:
 		ctx.ResultInt(current.Watchers.TotalCount)
+	case 30:
+		ctx.ResultText(current.Id)
 	}
-	return nil
 }
 
-func (i *iterOrgRepos) Next() (vtab.Row, error) {
-	i.current += 1
-
-	if i.results == nil || i.current >= len(i.results.OrgRepos) {
-		if i.results == nil || i.results.HasNextPage {
-			err := i.rateLimiter.Wait(context.Background())
+// fetch fetches the page of repositories following cursor, from those of the user if the login is one
+func (i *iterOrgRepos) fetch(ctx context.Context, cursor *githubv4.String) (interface{}, error) {
+	return i.opts.fetchOwned(ctx, i.client, i.login, ownerOrganization, func(t ownerType) (interface{}, error) {
+		if t == ownerUser {
+			results, err := fetchUserRepos(ctx, &fetchUserReposOptions{i.client, i.login, i.pages.hints.pageSize, cursor, i.repoOrder})
 			if err != nil {
 				return nil, err
 			}
+			var repos = make([]*orgRepo, len(results.UserRepos))
+			for n, repo := range results.UserRepos {
+				repos[n] = (*orgRepo)(repo)
+			}
+			return &fetchOrgReposResults{repos, results.HasNextPage, results.EndCursor}, nil
+		}
+		return fetchOrgRepos(ctx, &fetchOrgReposOptions{i.client, i.login, i.pages.hints.pageSize, cursor, i.repoOrder})
+	})
+}
+
+func (i *iterOrgRepos) Next() (vtab.Row, error) {
+	i.current += 1
 
+	if i.results == nil || i.current >= len(i.results.OrgRepos) {
+		if i.results == nil || i.results.HasNextPage && i.pages.more() {
 			var cursor *githubv4.String
 			if i.results != nil {
 				cursor = i.results.EndCursor
 			}
-			results, err := fetchOrgRepos(context.Background(), &fetchOrgReposOptions{i.client, i.login, 100, cursor, i.repoOrder})
+
+			page, err := i.pages.page(cursor)
 			if err != nil {
 				return nil, err
 			}
 
-			i.results = results
+			i.results = page.(*fetchOrgReposResults)
 			i.current = 0
 
 		} else {
@@ -237,11 +266,13 @@ func (i *iterOrgRepos) Next() (vtab.Row, error) {
 		}
 	}
 
+	i.pages.maybeFetchAhead(i.current, len(i.results.OrgRepos), i.results.HasNextPage, i.results.EndCursor)
+
 	return i, nil
 }
 
-var orgReposCols = []vtab.Column{
-	{Name: "login", Type: sqlite.SQLITE_TEXT, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+var orgReposCols = append([]vtab.Column{
+	{Name: "login", Type: sqlite.SQLITE_TEXT, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
 	{Name: "created_at", Type: sqlite.SQLITE_TEXT, OrderBy: vtab.ASC | vtab.DESC},
 	{Name: "database_id", Type: sqlite.SQLITE_INTEGER},
 	{Name: "default_branch_ref_name", Type: sqlite.SQLITE_TEXT},
@@ -271,7 +302,8 @@ var orgReposCols = []vtab.Column{
 	{Name: "stargazer_count", Type: sqlite.SQLITE_TEXT, OrderBy: vtab.ASC | vtab.DESC},
 	{Name: "updated_at", Type: sqlite.SQLITE_TEXT, OrderBy: vtab.ASC | vtab.DESC},
 	{Name: "watcher_count", Type: sqlite.SQLITE_INTEGER},
-}
+	{Name: "node_id", Type: sqlite.SQLITE_TEXT},
+}, hintCols...)
 
 func NewOrgReposModule(opts *Options) sqlite.Module {
 	return vtab.NewTableFunc("github_org_repos", orgReposCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
@@ -285,6 +317,15 @@ func NewOrgReposModule(opts *Options) sqlite.Module {
 			}
 		}
 
+		if iter, err := requireArgs(opts, "github_org_repos", "login", login); iter != nil || err != nil {
+			return iter, err
+		}
+
+		h, err := parseHints("github_org_repos", orgReposCols, constraints)
+		if err != nil {
+			return nil, err
+		}
+
 		var repoOrder *githubv4.RepositoryOrder
 		// for now we can only support single field order bys
 		if len(orders) == 1 {
@@ -304,6 +345,8 @@ func NewOrgReposModule(opts *Options) sqlite.Module {
 			}
 		}
 
-		return &iterOrgRepos{login, opts.Client(), -1, nil, opts.RateLimiter, repoOrder}, nil
+		var iter = &iterOrgRepos{login, opts.Client(), -1, nil, nil, repoOrder, opts}
+		iter.pages = newPager(opts, "github_org_repos", queryArgs("login", login), h, iter.fetch)
+		return iter, nil
 	})
 }
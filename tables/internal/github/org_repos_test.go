@@ -23,8 +23,8 @@ func TestOrgRepos(t *testing.T) {
 		t.Fatalf("failed to retrieve row contents: %v", err.Error())
 	}
 
-	if colCount != 29 {
-		t.Fatalf("expected 29 columns, got: %d", colCount)
+	if colCount != 30 {
+		t.Fatalf("expected 30 columns, got: %d", colCount)
 	}
 
 	if len(content) != 10 {
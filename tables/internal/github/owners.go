@@ -0,0 +1,98 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// ownerType is the type of an owner of repositories, as the __typename of the RepositoryOwner interface
+type ownerType string
+
+const (
+	ownerUser         ownerType = "User"
+	ownerOrganization ownerType = "Organization"
+)
+
+// owners caches the types of the owners of repositories, by login, as they don't change (or hardly ever).
+// Its zero value is ready to use.
+type owners struct {
+	mu    sync.Mutex
+	types map[string]ownerType
+}
+
+// get returns the type of the owner with the login, if it's known
+func (o *owners) get(login string) (ownerType, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var t, ok = o.types[strings.ToLower(login)]
+	return t, ok
+}
+
+// set records the type of the owner with the login
+func (o *owners) set(login string, t ownerType) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.types == nil {
+		o.types = make(map[string]ownerType)
+	}
+	o.types[strings.ToLower(login)] = t
+}
+
+// isNotFound reports whether err is GitHub failing to resolve an object, like a user (or an organization)
+// with a login that's the other's, e.g. Could not resolve to a User with the login of 'askgitdev'.
+func isNotFound(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "Could not resolve to a")
+}
+
+// fetchOwned fetches a page of a table listing what belongs to a user or an organization, with the login.
+// The query for the type of owner the table expects is tried first, unless the login is known to be of the other type,
+// and if it can't be resolved, the type of the owner is looked up (through the repositoryOwner interface) and the
+// query for the other type is made instead, so that tables of users work with organizations and vice versa.
+// The type of the owner is cached, so that it's looked up at most once.
+func (opts *Options) fetchOwned(ctx context.Context, client *githubv4.Client, login string, expected ownerType, fetch func(ownerType) (interface{}, error)) (interface{}, error) {
+	if t, ok := opts.owners.get(login); ok {
+		return fetch(t)
+	}
+
+	page, err := fetch(expected)
+	if err == nil {
+		opts.owners.set(login, expected)
+		return page, nil
+	}
+	if !isNotFound(err) {
+		return nil, err
+	}
+
+	t, lookupErr := opts.lookupOwner(ctx, client, login)
+	if lookupErr != nil || t == expected {
+		return nil, err
+	}
+	opts.owners.set(login, t)
+	return fetch(t)
+}
+
+// lookupOwner returns the type of the owner with the login
+func (opts *Options) lookupOwner(ctx context.Context, client *githubv4.Client, login string) (ownerType, error) {
+	if opts.RateLimiter != nil {
+		if err := opts.RateLimiter.Wait(ctx, "github_owner_type", Interactive); err != nil {
+			return "", err
+		}
+	}
+
+	var query struct {
+		RepositoryOwner *struct {
+			Typename string `graphql:"__typename"`
+		} `graphql:"repositoryOwner(login: $login)"`
+	}
+	if err := client.Query(ctx, &query, map[string]interface{}{"login": githubv4.String(login)}); err != nil {
+		return "", err
+	}
+	if query.RepositoryOwner == nil {
+		return "", fmt.Errorf("no user or organization with the login of '%s'", login)
+	}
+	return ownerType(query.RepositoryOwner.Typename), nil
+}
@@ -0,0 +1,104 @@
+package github
+
+import (
+	"context"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// pageFunc fetches the page of results following cursor (the first page if cursor is nil)
+type pageFunc func(ctx context.Context, cursor *githubv4.String) (interface{}, error)
+
+// pager fetches the pages of results of a table through the shared limiter. With prefetching enabled,
+// the page following the one being consumed is fetched in the background, at a lower priority than the
+// pages queries are waiting on, and promoted once the query needs it.
+type pager struct {
+	limiter  *Limiter
+	flow     string
+	args     []string
+	hints    hints
+	prefetch bool
+	fetch    pageFunc
+
+	fetched int // pages fetched, or being fetched, for the query
+
+	ahead *prefetchedPage
+}
+
+// prefetchedPage is a page being fetched in the background
+type prefetchedPage struct {
+	cursor  string
+	request *Request
+	done    chan struct{}
+	page    interface{}
+	err     error
+}
+
+// newPager returns a pager fetching the pages of the table called flow with fetch, as tuned by h.
+// args describe the arguments of the table, in the errors returned (see queryArgs).
+func newPager(opts *Options, flow string, args []string, h hints, fetch pageFunc) *pager {
+	return &pager{limiter: opts.RateLimiter, flow: flow, args: args, hints: h, prefetch: opts.Prefetch, fetch: fetch}
+}
+
+// more reports whether more pages can be fetched, within the max_pages hint
+func (p *pager) more() bool {
+	return p.hints.maxPages == 0 || p.fetched < p.hints.maxPages
+}
+
+// page returns the page following cursor, from the prefetched page if it's the one (and it wasn't dropped)
+func (p *pager) page(cursor *githubv4.String) (interface{}, error) {
+	p.fetched++
+	if ahead := p.ahead; ahead != nil && cursor != nil && ahead.cursor == string(*cursor) {
+		p.ahead = nil
+		p.limiter.Promote(ahead.request)
+		<-ahead.done
+		if ahead.err != ErrDropped {
+			return ahead.page, ahead.err
+		}
+	}
+
+	if err := p.limiter.Wait(context.Background(), p.flow, Interactive); err != nil {
+		return nil, err
+	}
+	return p.fetchPage(context.Background(), cursor)
+}
+
+// maybeFetchAhead starts fetching the page following cursor once the iterator of a page of total results
+// has read half of them (current being the index of the result being read), if the page has a next one.
+// The next page isn't fetched sooner, so that iterators dropped early (like those of the inner table of a join)
+// don't fetch pages that are never read.
+func (p *pager) maybeFetchAhead(current, total int, hasNextPage bool, cursor *githubv4.String) {
+	if current == total/2 && hasNextPage {
+		p.fetchAhead(cursor)
+	}
+}
+
+// fetchAhead starts fetching the page following cursor in the background, if prefetching is enabled
+func (p *pager) fetchAhead(cursor *githubv4.String) {
+	if !p.prefetch || cursor == nil || !p.more() {
+		return
+	}
+
+	var ahead = &prefetchedPage{cursor: string(*cursor), request: p.limiter.Enqueue(p.flow, Background), done: make(chan struct{})}
+	p.ahead = ahead
+
+	go func() {
+		defer close(ahead.done)
+		if ahead.err = p.limiter.WaitRequest(context.Background(), ahead.request); ahead.err == nil {
+			ahead.page, ahead.err = p.fetchPage(context.Background(), cursor)
+		}
+	}()
+}
+
+// fetchPage fetches the page following cursor, annotating errors with the table, its arguments and the cursor
+func (p *pager) fetchPage(ctx context.Context, cursor *githubv4.String) (interface{}, error) {
+	page, err := p.fetch(ctx, cursor)
+	if err != nil {
+		var e = &QueryError{Table: p.flow, Args: p.args, Err: err}
+		if cursor != nil {
+			e.Cursor = string(*cursor)
+		}
+		return nil, e
+	}
+	return page, nil
+}
@@ -6,15 +6,18 @@ import (
 	"io"
 	"time"
 
+	"github.com/askgitdev/askgit/tables/internal/funcs"
+	"github.com/askgitdev/askgit/tables/internal/tz"
 	"github.com/augmentable-dev/vtab"
 	"github.com/shurcooL/githubv4"
 	"go.riyazali.net/sqlite"
-	"golang.org/x/time/rate"
 )
 
+// user is the author of an issue (or another item), which is null (and so zero) once their account is deleted
 type user struct {
-	Login string
-	URL   string
+	Login    string
+	URL      string
+	Typename string `graphql:"__typename"`
 }
 
 type issue struct {
@@ -32,6 +35,7 @@ type issue struct {
 	CreatedViaEmail     bool
 	DatabaseId          int
 	Editor              user
+	Id                  string
 	IncludesCreatedEdit bool
 	IsReadByViewer      bool
 	Labels              struct {
@@ -129,8 +133,9 @@ type iterIssues struct {
 	client          *githubv4.Client
 	current         int
 	results         *fetchIssuesResults
-	rateLimiter     *rate.Limiter
+	pages           *pager
 	issueOrder      *githubv4.IssueOrder
+	excludeBots     bool
 }
 
 func (i *iterIssues) Column(ctx *sqlite.Context, c int) error {
@@ -140,9 +145,9 @@ func (i *iterIssues) Column(ctx *sqlite.Context, c int) error {
 	case 1:
 		ctx.ResultText(i.name)
 	case 2:
-		ctx.ResultText(i.results.Edges[i.current].Node.Author.Login)
+		resultTextOrNull(ctx, i.results.Edges[i.current].Node.Author.Login)
 	case 3:
-		ctx.ResultText(i.results.Edges[i.current].Node.Author.URL)
+		resultTextOrNull(ctx, i.results.Edges[i.current].Node.Author.URL)
 	case 4:
 		ctx.ResultText(i.results.Edges[i.current].Node.Body)
 	case 5:
@@ -154,7 +159,7 @@ func (i *iterIssues) Column(ctx *sqlite.Context, c int) error {
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t.Time, time.RFC3339Nano))
 		}
 	case 8:
 		ctx.ResultInt(i.results.Edges[i.current].Node.Comments.TotalCount)
@@ -163,16 +168,16 @@ func (i *iterIssues) Column(ctx *sqlite.Context, c int) error {
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t.Time, time.RFC3339Nano))
 		}
 	case 10:
 		ctx.ResultInt(t1f0(i.results.Edges[i.current].Node.CreatedViaEmail))
 	case 11:
 		ctx.ResultInt(i.results.Edges[i.current].Node.DatabaseId)
 	case 12:
-		ctx.ResultText(i.results.Edges[i.current].Node.Editor.Login)
+		resultTextOrNull(ctx, i.results.Edges[i.current].Node.Editor.Login)
 	case 13:
-		ctx.ResultText(i.results.Edges[i.current].Node.Editor.URL)
+		resultTextOrNull(ctx, i.results.Edges[i.current].Node.Editor.URL)
 	case 14:
 		ctx.ResultInt(t1f0(i.results.Edges[i.current].Node.IncludesCreatedEdit))
 	case 15:
@@ -184,7 +189,7 @@ func (i *iterIssues) Column(ctx *sqlite.Context, c int) error {
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t.Time, time.RFC3339Nano))
 		}
 	case 18:
 		ctx.ResultInt(t1f0(i.results.Edges[i.current].Node.Locked))
@@ -201,7 +206,7 @@ func (i *iterIssues) Column(ctx *sqlite.Context, c int) error {
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t.Time, time.RFC3339Nano))
 		}
 	case 24:
 		ctx.ResultInt(i.results.Edges[i.current].Node.Reactions.TotalCount)
@@ -214,7 +219,7 @@ func (i *iterIssues) Column(ctx *sqlite.Context, c int) error {
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t.Time, time.RFC3339Nano))
 		}
 	case 28:
 		ctx.ResultText(i.results.Edges[i.current].Node.Url.String())
@@ -232,36 +237,58 @@ func (i *iterIssues) Column(ctx *sqlite.Context, c int) error {
 
 	case 34:
 		ctx.ResultText(fmt.Sprint(i.results.Edges[i.current].Node.ViewerSubscription))
+	case 35:
+		ctx.ResultText(i.results.Edges[i.current].Node.Id)
+	case 36:
+		ctx.ResultInt(t1f0(i.results.Edges[i.current].Node.Author.Typename == "Bot"))
+	case 37:
+		ctx.ResultInt(t1f0(i.results.Edges[i.current].Node.Author.Login == ""))
+	case 38, 39:
+		i.pages.hints.result(ctx, c-38)
 	}
 	return nil
 }
 
+// fetch fetches the page of issues following cursor
+func (i *iterIssues) fetch(ctx context.Context, cursor *githubv4.String) (interface{}, error) {
+	owner, name, err := repoOwnerAndName(i.name, i.fullNameOrOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	return fetchIssues(ctx, &fetchIssuesOptions{i.client, owner, name, i.pages.hints.pageSize, cursor, i.issueOrder})
+}
+
 func (i *iterIssues) Next() (vtab.Row, error) {
-	i.current += 1
+	for {
+		row, err := i.next()
+		if err != nil || !i.excludeBots || i.current >= len(i.results.Edges) {
+			return row, err
+		}
 
-	if i.results == nil || i.current >= len(i.results.Edges) {
-		if i.results == nil || i.results.HasNextPage {
-			err := i.rateLimiter.Wait(context.Background())
-			if err != nil {
-				return nil, err
-			}
+		// skip the issues opened by bots
+		if author := i.results.Edges[i.current].Node.Author; !funcs.IsBotLogin(author.Login, author.Typename) {
+			return row, nil
+		}
+	}
+}
 
-			owner, name, err := repoOwnerAndName(i.name, i.fullNameOrOwner)
-			if err != nil {
-				return nil, err
-			}
+func (i *iterIssues) next() (vtab.Row, error) {
+	i.current += 1
 
+	if i.results == nil || i.current >= len(i.results.Edges) {
+		if i.results == nil || i.results.HasNextPage && i.pages.more() {
 			var cursor *githubv4.String
 			if i.results != nil {
 				cursor = i.results.EndCursor
 			}
 
-			results, err := fetchIssues(context.Background(), &fetchIssuesOptions{i.client, owner, name, 100, cursor, i.issueOrder})
+			page, err := i.pages.page(cursor)
 			if err != nil {
 				return nil, err
 			}
 
-			i.results = results
+			i.results = page.(*fetchIssuesResults)
 			i.current = 0
 
 		} else {
@@ -269,11 +296,13 @@ func (i *iterIssues) Next() (vtab.Row, error) {
 		}
 	}
 
+	i.pages.maybeFetchAhead(i.current, len(i.results.Edges), i.results.HasNextPage, i.results.EndCursor)
+
 	return i, nil
 }
 
-var issuesCols = []vtab.Column{
-	{Name: "owner", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+var issuesCols = append([]vtab.Column{
+	{Name: "owner", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
 	{Name: "reponame", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ}}},
 	{Name: "author_login", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil},
 	{Name: "author_url", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil},
@@ -308,7 +337,10 @@ var issuesCols = []vtab.Column{
 	{Name: "viewer_can_update", Type: sqlite.SQLITE_INTEGER, NotNull: false, Hidden: false, Filters: nil},
 	{Name: "viewer_did_author", Type: sqlite.SQLITE_INTEGER, NotNull: false, Hidden: false, Filters: nil},
 	{Name: "viewer_subscription", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil},
-}
+	{Name: "node_id", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil},
+	{Name: "author_is_bot", Type: sqlite.SQLITE_INTEGER, NotNull: false, Hidden: false, Filters: nil},
+	{Name: "author_resigned", Type: sqlite.SQLITE_INTEGER, NotNull: false, Hidden: false, Filters: nil},
+}, hintCols...)
 
 func NewIssuesModule(opts *Options) sqlite.Module {
 	return vtab.NewTableFunc("github_repo_issues", issuesCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
@@ -324,6 +356,15 @@ func NewIssuesModule(opts *Options) sqlite.Module {
 			}
 		}
 
+		if iter, err := requireArgs(opts, "github_repo_issues", "owner", fullNameOrOwner); iter != nil || err != nil {
+			return iter, err
+		}
+
+		h, err := parseHints("github_repo_issues", issuesCols, constraints)
+		if err != nil {
+			return nil, err
+		}
+
 		var issueOrder *githubv4.IssueOrder
 		if len(orders) == 1 {
 			order := orders[0]
@@ -339,6 +380,8 @@ func NewIssuesModule(opts *Options) sqlite.Module {
 			issueOrder.Direction = orderByToGitHubOrder(order.Desc)
 		}
 
-		return &iterIssues{fullNameOrOwner, name, opts.Client(), -1, nil, opts.RateLimiter, issueOrder}, nil
+		var iter = &iterIssues{fullNameOrOwner, name, opts.Client(), -1, nil, nil, issueOrder, opts.ExcludeBots}
+		iter.pages = newPager(opts, "github_repo_issues", queryArgs("owner", fullNameOrOwner, "reponame", name), h, iter.fetch)
+		return iter, nil
 	})
 }
@@ -23,8 +23,8 @@ func TestRepoIssues(t *testing.T) {
 		t.Fatalf("failed to retrieve row contents: %v", err.Error())
 	}
 
-	if colCount != 33 {
-		t.Fatalf("expected 33 columns, got: %d", colCount)
+	if colCount != 36 {
+		t.Fatalf("expected 36 columns, got: %d", colCount)
 	}
 
 	if len(content) != 10 {
@@ -0,0 +1,187 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/shurcooL/githubv4"
+	"go.riyazali.net/sqlite"
+)
+
+// batchSize is the number of repositories fetched by every query of github_repos, each under an alias of its own
+const batchSize = 100
+
+// repoBatchQuery returns a pointer to a query for the repositories, as a struct generated with a field per repository
+// (R0 to Rn, aliased r0 to rn), along with its variables. Each field is a *orgRepo, nil if the repository isn't found.
+func repoBatchQuery(repos [][2]string) (interface{}, map[string]interface{}) {
+	var fields = make([]reflect.StructField, len(repos))
+	var variables = make(map[string]interface{}, 2*len(repos))
+	for i, repo := range repos {
+		fields[i] = reflect.StructField{
+			Name: fmt.Sprintf("R%d", i),
+			Type: reflect.TypeOf(&orgRepo{}),
+			Tag:  reflect.StructTag(fmt.Sprintf(`graphql:"r%d: repository(owner: $owner%d, name: $name%d)"`, i, i, i)),
+		}
+		variables[fmt.Sprintf("owner%d", i)] = githubv4.String(repo[0])
+		variables[fmt.Sprintf("name%d", i)] = githubv4.String(repo[1])
+	}
+	return reflect.New(reflect.StructOf(fields)).Interface(), variables
+}
+
+// fetchRepos fetches the repositories (as owner and name) in a single query. The repositories that can't be found
+// are nil, as GitHub reports an error for each but still returns the others: the query only fails if none is found.
+func fetchRepos(ctx context.Context, client *githubv4.Client, repos [][2]string) ([]*orgRepo, error) {
+	var query, variables = repoBatchQuery(repos)
+	var err = client.Query(ctx, query, variables)
+
+	var results = make([]*orgRepo, len(repos))
+	var found bool
+	var v = reflect.ValueOf(query).Elem()
+	for i := range results {
+		results[i] = v.Field(i).Interface().(*orgRepo)
+		found = found || results[i] != nil
+	}
+	if err != nil && !found {
+		return nil, err
+	}
+	return results, nil
+}
+
+// parseRepos parses a list of repositories, as a JSON array or separated by commas or whitespace, into owners and names
+func parseRepos(s string) ([][2]string, error) {
+	var names []string
+	if strings.HasPrefix(strings.TrimSpace(s), "[") {
+		if err := json.Unmarshal([]byte(s), &names); err != nil {
+			return nil, fmt.Errorf("invalid list of repositories: %v", err)
+		}
+	} else {
+		names = strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' })
+	}
+
+	var repos = make([][2]string, 0, len(names))
+	for _, name := range names {
+		owner, name, err := repoOwnerAndName("", strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, [2]string{owner, name})
+	}
+	return repos, nil
+}
+
+type iterRepos struct {
+	repos   string
+	client  *githubv4.Client
+	list    [][2]string
+	offset  int // the offset in list of the batch being read
+	current int
+	results []*orgRepo
+	pages   *pager
+}
+
+// fetch fetches the batch of repositories at the offset in cursor (the first if nil)
+func (i *iterRepos) fetch(ctx context.Context, cursor *githubv4.String) (interface{}, error) {
+	var offset int
+	if cursor != nil {
+		offset, _ = strconv.Atoi(string(*cursor))
+	}
+	var end = offset + batchSize
+	if end > len(i.list) {
+		end = len(i.list)
+	}
+	return fetchRepos(ctx, i.client, i.list[offset:end])
+}
+
+// cursor returns the cursor of the batch following the one being read, or nil if it's the last
+func (i *iterRepos) cursor() *githubv4.String {
+	if i.offset+batchSize >= len(i.list) {
+		return nil
+	}
+	var cursor = githubv4.String(strconv.Itoa(i.offset + batchSize))
+	return &cursor
+}
+
+func (i *iterRepos) Column(ctx *sqlite.Context, c int) error {
+	switch c {
+	case 0:
+		ctx.ResultText(i.repos)
+	case 31:
+		ctx.ResultText(i.results[i.current].NameWithOwner)
+	default:
+		repoColumn(ctx, i.results[i.current], c)
+	}
+	return nil
+}
+
+func (i *iterRepos) Next() (vtab.Row, error) {
+	for {
+		i.current += 1
+
+		if i.results == nil || i.current >= len(i.results) {
+			var cursor *githubv4.String
+			if i.results != nil {
+				if cursor = i.cursor(); cursor == nil {
+					return nil, io.EOF
+				}
+				i.offset += batchSize
+			}
+
+			page, err := i.pages.page(cursor)
+			if err != nil {
+				return nil, err
+			}
+
+			i.results = page.([]*orgRepo)
+			i.current = 0
+		}
+
+		if i.current == len(i.results)/2 {
+			i.pages.fetchAhead(i.cursor())
+		}
+
+		// repositories that weren't found are left out
+		if i.results[i.current] != nil {
+			return i, nil
+		}
+	}
+}
+
+var reposCols = append(append([]vtab.Column{
+	{Name: "repos", Type: sqlite.SQLITE_TEXT, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+}, orgReposCols[1:31]...), vtab.Column{Name: "name_with_owner", Type: sqlite.SQLITE_TEXT})
+
+// NewReposModule returns the github_repos table, of the repositories in a list (as owner/name), fetched in batches
+// of up to batchSize repositories per query, rather than a query per repository
+func NewReposModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_repos", reposCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repos string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && constraint.ColIndex == 0 {
+				repos = constraint.Value.Text()
+			}
+		}
+
+		if iter, err := requireArgs(opts, "github_repos", "repos", repos); iter != nil || err != nil {
+			return iter, err
+		}
+
+		list, err := parseRepos(repos)
+		if err != nil {
+			return nil, err
+		}
+		if len(list) == 0 {
+			return nil, errors.New("github_repos requires at least one repository, e.g. SELECT * FROM github_repos('askgitdev/askgit')")
+		}
+
+		var iter = &iterRepos{repos: repos, client: opts.Client(), list: list, current: -1}
+		iter.pages = newPager(opts, "github_repos", queryArgs("repos", repos), hints{}, iter.fetch)
+		return iter, nil
+	})
+}
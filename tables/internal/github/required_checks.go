@@ -0,0 +1,349 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/shurcooL/githubv4"
+	"go.riyazali.net/sqlite"
+)
+
+// defaultCheckedCommits is how many of the most recent commits of a branch are checked by default
+const defaultCheckedCommits = 20
+
+// gaps between the checks branch protection requires and those actually run
+const (
+	gapNeverRun     = "never_run"      // required, but not run on any of the commits (misspelled or renamed check, merges are blocked)
+	gapNotAlwaysRun = "not_always_run" // required, but missing from some of the commits (pushed past the protection)
+	gapNotRequired  = "not_required"   // run on every commit, but not required
+)
+
+type protectionRule struct {
+	Pattern                     string
+	RequiresStatusChecks        bool
+	RequiredStatusCheckContexts []string
+}
+
+// checkedCommit is a commit with the statuses and check runs reported on it
+type checkedCommit struct {
+	Oid    string
+	Status *struct {
+		Contexts []struct {
+			Context string
+			State   string
+		}
+	}
+	CheckSuites struct {
+		PageInfo struct {
+			HasNextPage bool
+		}
+		Nodes []struct {
+			CheckRuns struct {
+				PageInfo struct {
+					HasNextPage bool
+				}
+				Nodes []struct {
+					Name       string
+					Status     string
+					Conclusion string
+				}
+			} `graphql:"checkRuns(first: 50)"`
+		}
+	} `graphql:"checkSuites(first: 20)"`
+}
+
+// truncated reports whether the commit has more check suites, or one of its suites more check runs,
+// than are fetched, in which case the checks run on it may be missing from checks
+func (c *checkedCommit) truncated() bool {
+	if c.CheckSuites.PageInfo.HasNextPage {
+		return true
+	}
+	for _, suite := range c.CheckSuites.Nodes {
+		if suite.CheckRuns.PageInfo.HasNextPage {
+			return true
+		}
+	}
+	return false
+}
+
+// truncatedCommits counts the commits whose checks are truncated
+func truncatedCommits(commits []*checkedCommit) int {
+	var n int
+	for _, commit := range commits {
+		if commit.truncated() {
+			n++
+		}
+	}
+	return n
+}
+
+// checks returns the state of every status context and check run of the commit, by name
+func (c *checkedCommit) checks() map[string]string {
+	var checks = make(map[string]string)
+	if c.Status != nil {
+		for _, s := range c.Status.Contexts {
+			checks[s.Context] = s.State
+		}
+	}
+	for _, suite := range c.CheckSuites.Nodes {
+		for _, run := range suite.CheckRuns.Nodes {
+			// runs still in progress have no conclusion yet
+			if run.Conclusion != "" {
+				checks[run.Name] = run.Conclusion
+			} else {
+				checks[run.Name] = run.Status
+			}
+		}
+	}
+	return checks
+}
+
+type branchHistory struct {
+	Name   string
+	Target struct {
+		Commit struct {
+			History struct {
+				Nodes []*checkedCommit
+			} `graphql:"history(first: $commits)"`
+		} `graphql:"... on Commit"`
+	}
+}
+
+type fetchRequiredChecksResults struct {
+	Rules  []*protectionRule
+	Branch *branchHistory
+}
+
+// fetchRequiredChecks fetches the branch protection rules of a repository, and the recent commits of branch
+// (the default branch if empty)
+func fetchRequiredChecks(ctx context.Context, client *githubv4.Client, owner, name, branch string, commits int) (*fetchRequiredChecksResults, error) {
+	var variables = map[string]interface{}{
+		"owner":   githubv4.String(owner),
+		"name":    githubv4.String(name),
+		"commits": githubv4.Int(commits),
+	}
+
+	if branch == "" {
+		var query struct {
+			Repository struct {
+				BranchProtectionRules struct {
+					Nodes []*protectionRule
+				} `graphql:"branchProtectionRules(first: 100)"`
+				DefaultBranchRef *branchHistory
+			} `graphql:"repository(owner: $owner, name: $name)"`
+		}
+		if err := client.Query(ctx, &query, variables); err != nil {
+			return nil, err
+		}
+		return &fetchRequiredChecksResults{query.Repository.BranchProtectionRules.Nodes, query.Repository.DefaultBranchRef}, nil
+	}
+
+	var query struct {
+		Repository struct {
+			BranchProtectionRules struct {
+				Nodes []*protectionRule
+			} `graphql:"branchProtectionRules(first: 100)"`
+			Ref *branchHistory `graphql:"ref(qualifiedName: $branch)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+	variables["branch"] = githubv4.String(branch)
+	if err := client.Query(ctx, &query, variables); err != nil {
+		return nil, err
+	}
+	return &fetchRequiredChecksResults{query.Repository.BranchProtectionRules.Nodes, query.Repository.Ref}, nil
+}
+
+// checkGap compares a check required by branch protection, or run on the commits of the branch, to the other
+type checkGap struct {
+	context    string
+	pattern    string // of the protection rule requiring the check, empty if it isn't required
+	commitsRun int    // commits the check was run on
+	lastState  string // state of the check on the most recent commit it was run on
+	lastCommit string
+	gap        string
+}
+
+// checksGaps compares the checks required by the rules protecting branch with those run on commits, most recent first
+func checksGaps(branch string, rules []*protectionRule, commits []*checkedCommit) []*checkGap {
+	var gaps = make(map[string]*checkGap)
+	for _, rule := range rules {
+		if !rule.RequiresStatusChecks || !matchesBranch(rule.Pattern, branch) {
+			continue
+		}
+		for _, check := range rule.RequiredStatusCheckContexts {
+			if _, ok := gaps[check]; !ok {
+				gaps[check] = &checkGap{context: check, pattern: rule.Pattern}
+			}
+		}
+	}
+
+	for _, commit := range commits {
+		for check, state := range commit.checks() {
+			gap, ok := gaps[check]
+			if !ok {
+				gap = &checkGap{context: check}
+				gaps[check] = gap
+			}
+			if gap.commitsRun == 0 {
+				gap.lastState, gap.lastCommit = state, commit.Oid
+			}
+			gap.commitsRun++
+		}
+	}
+
+	var list = make([]*checkGap, 0, len(gaps))
+	for _, gap := range gaps {
+		switch required := gap.pattern != ""; {
+		case required && gap.commitsRun == 0:
+			gap.gap = gapNeverRun
+		case required && gap.commitsRun < len(commits):
+			gap.gap = gapNotAlwaysRun
+		case !required && gap.commitsRun == len(commits):
+			gap.gap = gapNotRequired
+		}
+		list = append(list, gap)
+	}
+
+	// required checks first
+	sort.Slice(list, func(i, j int) bool {
+		if (list[i].pattern != "") != (list[j].pattern != "") {
+			return list[i].pattern != ""
+		}
+		return list[i].context < list[j].context
+	})
+	return list
+}
+
+// matchesBranch reports whether the fnmatch-style pattern of a protection rule matches branch:
+// * and ? don't match /, while ** matches anything
+func matchesBranch(pattern, branch string) bool {
+	var expr = regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*\*`, "\x00")
+	expr = strings.ReplaceAll(expr, `\*`, "[^/]*")
+	expr = strings.ReplaceAll(expr, `\?`, "[^/]")
+	expr = strings.ReplaceAll(expr, "\x00", ".*")
+
+	re, err := regexp.Compile("^" + expr + "$")
+	return err == nil && re.MatchString(branch)
+}
+
+type iterRequiredChecks struct {
+	owner, name, branch string
+	commits             int
+	checked             int
+	truncated           int
+	gaps                []*checkGap
+	current             int
+}
+
+func (i *iterRequiredChecks) Column(ctx *sqlite.Context, c int) error {
+	var gap = i.gaps[i.current]
+	switch c {
+	case 0:
+		ctx.ResultText(i.owner)
+	case 1:
+		ctx.ResultText(i.name)
+	case 2:
+		ctx.ResultText(i.branch)
+	case 3:
+		ctx.ResultInt(i.commits)
+	case 4:
+		ctx.ResultText(gap.context)
+	case 5:
+		resultTextOrNull(ctx, gap.pattern)
+	case 6:
+		ctx.ResultInt(t1f0(gap.pattern != ""))
+	case 7:
+		ctx.ResultInt(i.checked)
+	case 8:
+		ctx.ResultInt(gap.commitsRun)
+	case 9:
+		resultTextOrNull(ctx, gap.lastState)
+	case 10:
+		resultTextOrNull(ctx, gap.lastCommit)
+	case 11:
+		resultTextOrNull(ctx, gap.gap)
+	case 12:
+		ctx.ResultInt(i.truncated)
+	}
+	return nil
+}
+
+func (i *iterRequiredChecks) Next() (vtab.Row, error) {
+	i.current++
+	if i.current >= len(i.gaps) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+var requiredChecksCols = []vtab.Column{
+	{Name: "owner", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "reponame", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "branch", Type: sqlite.SQLITE_TEXT, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "commits", Type: sqlite.SQLITE_INTEGER, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "context", Type: sqlite.SQLITE_TEXT},
+	{Name: "pattern", Type: sqlite.SQLITE_TEXT},
+	{Name: "required", Type: sqlite.SQLITE_INTEGER},
+	{Name: "commits_checked", Type: sqlite.SQLITE_INTEGER},
+	{Name: "commits_run", Type: sqlite.SQLITE_INTEGER},
+	{Name: "last_state", Type: sqlite.SQLITE_TEXT},
+	{Name: "last_commit", Type: sqlite.SQLITE_TEXT},
+	{Name: "gap", Type: sqlite.SQLITE_TEXT},
+	{Name: "commits_truncated", Type: sqlite.SQLITE_INTEGER},
+}
+
+// NewRequiredChecksModule returns the implementation of a table-valued-function comparing the status checks
+// the protection rules of a branch (the default one by default) require with the checks run on its recent commits
+func NewRequiredChecksModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_required_checks_gap", requiredChecksCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var fullNameOrOwner, name, branch string
+		var commits = defaultCheckedCommits
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && !constraint.Value.IsNil() {
+				switch constraint.ColIndex {
+				case 0:
+					fullNameOrOwner = constraint.Value.Text()
+				case 1:
+					name = constraint.Value.Text()
+				case 2:
+					branch = constraint.Value.Text()
+				case 3:
+					commits = constraint.Value.Int()
+				}
+			}
+		}
+
+		if iter, err := requireArgs(opts, "github_required_checks_gap", "owner", fullNameOrOwner); iter != nil || err != nil {
+			return iter, err
+		}
+		if commits < 1 || commits > maxPageSize {
+			return nil, fmt.Errorf("github_required_checks_gap: commits must be between 1 and %d, got %d", maxPageSize, commits)
+		}
+
+		owner, name, err := repoOwnerAndName(name, fullNameOrOwner)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := opts.RateLimiter.Wait(context.Background(), "github_required_checks_gap", Interactive); err != nil {
+			return nil, err
+		}
+		results, err := fetchRequiredChecks(context.Background(), opts.Client(), owner, name, branch, commits)
+		if err != nil {
+			return nil, &QueryError{Table: "github_required_checks_gap", Args: queryArgs("owner", owner, "reponame", name, "branch", branch), Err: err}
+		}
+		if results.Branch == nil {
+			return nil, &QueryError{Table: "github_required_checks_gap", Args: queryArgs("owner", owner, "reponame", name, "branch", branch), Err: errors.New("branch not found")}
+		}
+
+		var history = results.Branch.Target.Commit.History.Nodes
+		return &iterRequiredChecks{owner, name, results.Branch.Name, commits, len(history), truncatedCommits(history), checksGaps(results.Branch.Name, results.Rules, history), -1}, nil
+	})
+}
@@ -0,0 +1,114 @@
+package github
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// commitWith returns a commit with a status context per status, and a check run per run, as "name=STATE"
+func commitWith(t *testing.T, oid string, statuses []string, runs []string) *checkedCommit {
+	var contexts, checkRuns = []map[string]string{}, []map[string]string{}
+	for _, status := range statuses {
+		var parts = strings.SplitN(status, "=", 2)
+		contexts = append(contexts, map[string]string{"context": parts[0], "state": parts[1]})
+	}
+	for _, run := range runs {
+		var parts = strings.SplitN(run, "=", 2)
+		checkRuns = append(checkRuns, map[string]string{"name": parts[0], "status": "COMPLETED", "conclusion": parts[1]})
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"oid":         oid,
+		"status":      map[string]interface{}{"contexts": contexts},
+		"checkSuites": map[string]interface{}{"nodes": []interface{}{map[string]interface{}{"checkRuns": map[string]interface{}{"nodes": checkRuns}}}},
+	})
+	var commit checkedCommit
+	if err := json.Unmarshal(body, &commit); err != nil {
+		t.Fatal(err)
+	}
+	return &commit
+}
+
+func TestChecksGaps(t *testing.T) {
+	var rules = []*protectionRule{
+		{Pattern: "main", RequiresStatusChecks: true, RequiredStatusCheckContexts: []string{"build", "lint", "tset"}},
+		{Pattern: "release/*", RequiresStatusChecks: true, RequiredStatusCheckContexts: []string{"release"}},
+		{Pattern: "main", RequiresStatusChecks: false, RequiredStatusCheckContexts: []string{"ignored"}},
+	}
+	var commits = []*checkedCommit{
+		commitWith(t, "c3", []string{"ci/coverage=SUCCESS"}, []string{"build=FAILURE", "test=SUCCESS"}),
+		commitWith(t, "c2", nil, []string{"build=SUCCESS", "lint=SUCCESS", "test=SUCCESS"}),
+		commitWith(t, "c1", []string{"ci/coverage=SUCCESS"}, []string{"build=SUCCESS", "test=SUCCESS"}),
+	}
+
+	var want = map[string]checkGap{
+		"build":       {pattern: "main", commitsRun: 3, lastState: "FAILURE", lastCommit: "c3"},
+		"lint":        {pattern: "main", commitsRun: 1, lastState: "SUCCESS", lastCommit: "c2", gap: gapNotAlwaysRun},
+		"tset":        {pattern: "main", gap: gapNeverRun},
+		"test":        {commitsRun: 3, lastState: "SUCCESS", lastCommit: "c3", gap: gapNotRequired},
+		"ci/coverage": {commitsRun: 2, lastState: "SUCCESS", lastCommit: "c3"},
+	}
+
+	var gaps = checksGaps("main", rules, commits)
+	if len(gaps) != len(want) {
+		t.Fatalf("expected %d checks, got: %d", len(want), len(gaps))
+	}
+	for i, gap := range gaps {
+		var expected, ok = want[gap.context]
+		expected.context = gap.context
+		if !ok || *gap != expected {
+			t.Fatalf("unexpected check %s: %+v", gap.context, *gap)
+		}
+
+		// required checks first, by name
+		if i > 0 {
+			var prev = gaps[i-1]
+			if prev.pattern == "" && gap.pattern != "" || (prev.pattern != "") == (gap.pattern != "") && prev.context > gap.context {
+				t.Fatalf("unexpected order: %s before %s", prev.context, gap.context)
+			}
+		}
+	}
+}
+
+func TestCheckedCommitTruncated(t *testing.T) {
+	var commits = []*checkedCommit{
+		commitWith(t, "c1", nil, []string{"build=SUCCESS"}),
+		commitWith(t, "c2", nil, []string{"build=SUCCESS"}),
+		commitWith(t, "c3", nil, []string{"build=SUCCESS"}),
+	}
+	commits[1].CheckSuites.PageInfo.HasNextPage = true
+	commits[2].CheckSuites.Nodes[0].CheckRuns.PageInfo.HasNextPage = true
+
+	if commits[0].truncated() || !commits[1].truncated() || !commits[2].truncated() {
+		t.Fatal("expected the commits with more check suites or runs to be truncated")
+	}
+	if n := truncatedCommits(commits); n != 2 {
+		t.Fatalf("expected 2 truncated commits, got: %d", n)
+	}
+}
+
+func TestMatchesBranch(t *testing.T) {
+	var cases = []struct {
+		pattern, branch string
+		want            bool
+	}{
+		{"main", "main", true},
+		{"main", "main2", false},
+		{"release/*", "release/1.0", true},
+		{"release/*", "release/1.0/hotfix", false},
+		{"release/**", "release/1.0/hotfix", true},
+		{"**/stable", "a/b/stable", true},
+		{"*", "feature/x", false},
+		{"**", "feature/x", true},
+		{"v?", "v1", true},
+		{"v?", "v10", false},
+		{"v?", "v/", false},
+		{"release-1.0", "release-1x0", false},
+	}
+	for _, c := range cases {
+		if got := matchesBranch(c.pattern, c.branch); got != c.want {
+			t.Fatalf("matchesBranch(%q, %q): expected %v, got %v", c.pattern, c.branch, c.want, got)
+		}
+	}
+}
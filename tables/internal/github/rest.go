@@ -0,0 +1,51 @@
+package github
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+)
+
+// restURL is the URL of the REST API, which the tables of the data the GraphQL API doesn't expose call
+const restURL = "https://api.github.com"
+
+// restGet makes a GET request to url of the REST API, through the shared limiter, returning the body and headers of the response
+func restGet(ctx context.Context, opts *Options, table string, args []string, url string) ([]byte, http.Header, error) {
+	if err := opts.RateLimiter.Wait(ctx, table, Interactive); err != nil {
+		return nil, nil, err
+	}
+
+	var client = &rest.Client{HTTP: opts.HTTP(), Prepare: restHeaders}
+	body, header, err := client.FetchWithHeader(ctx, url)
+	if err != nil {
+		return nil, nil, &QueryError{Table: table, Args: args, Err: err}
+	}
+	return body, header, nil
+}
+
+// restPages returns an iterator over the rows of the pages of a list of the REST API, starting at path,
+// decoded into rows by decode (which can make requests of its own). The next pages are those linked from the previous ones.
+func restPages(opts *Options, table string, args []string, path string, decode func(body []byte) ([][]interface{}, error)) *rest.Iterator {
+	var next = restURL + path
+	return rest.NewIterator(func(ctx context.Context) ([][]interface{}, bool, error) {
+		body, header, err := restGet(ctx, opts, table, args, next)
+		if err != nil {
+			return nil, false, err
+		}
+		rows, err := decode(body)
+		if _, ok := err.(*QueryError); err != nil && !ok {
+			return nil, false, &QueryError{Table: table, Args: args, Err: err}
+		} else if err != nil {
+			return nil, false, err
+		}
+
+		next = rest.NextLink(header)
+		return rows, next != "", nil
+	})
+}
+
+// restHeaders sets the headers of the requests to the REST API
+func restHeaders(req *http.Request) {
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+}
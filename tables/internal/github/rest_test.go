@@ -0,0 +1,69 @@
+package github
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// restServer serves the REST API, answering the requests made to a path (with its query) with the body in responses,
+// and returns the options of the tables calling it, along with the function stopping it
+func restServer(t *testing.T, responses map[string]string) (*Options, func()) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.RequestURI()]
+		if !ok {
+			t.Errorf("unexpected request of %s", r.URL.RequestURI())
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.WriteString(w, body)
+	}))
+
+	// requests made to the REST API are sent to the server
+	target, _ := url.Parse(srv.URL)
+	var transport = roundTripper(func(req *http.Request) (*http.Response, error) {
+		req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+		return http.DefaultTransport.RoundTrip(req)
+	})
+
+	var opts = &Options{
+		HTTP:        func() *http.Client { return &http.Client{Transport: transport} },
+		RateLimiter: NewLimiter(rate.NewLimiter(rate.Inf, 1)),
+	}
+	return opts, srv.Close
+}
+
+type roundTripper func(req *http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// timestamp parses a timestamp of the REST API
+func timestamp(t *testing.T, value string) time.Time {
+	ts, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+// expectRows fails the test if the rows decoded from a response aren't the expected ones
+func expectRows(t *testing.T, rows [][]interface{}, err error, want [][]interface{}) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("expected %d rows, got: %d", len(want), len(rows))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(rows[i], want[i]) {
+			t.Fatalf("row %d: expected %#v, got %#v", i, want[i], rows[i])
+		}
+	}
+}
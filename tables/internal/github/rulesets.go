@@ -0,0 +1,126 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+type ruleset struct {
+	ID           int64           `json:"id"`
+	Name         string          `json:"name"`
+	Target       string          `json:"target"`
+	SourceType   string          `json:"source_type"`
+	Source       string          `json:"source"`
+	Enforcement  string          `json:"enforcement"`
+	Conditions   json.RawMessage `json:"conditions"`
+	Rules        json.RawMessage `json:"rules"`
+	BypassActors json.RawMessage `json:"bypass_actors"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// jsonOrNil returns raw as text, or nil (to be NULL) if it's missing or null
+func jsonOrNil(raw json.RawMessage) interface{} {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	return string(raw)
+}
+
+// row returns the row of rulesetsCols of the ruleset, after the arguments of the table
+func (r *ruleset) row(args ...interface{}) []interface{} {
+	return append(args[:len(args):len(args)], r.ID, r.Name, r.Target, r.SourceType, r.Source, r.Enforcement,
+		jsonOrNil(r.Conditions), jsonOrNil(r.Rules), jsonOrNil(r.BypassActors), r.CreatedAt, r.UpdatedAt)
+}
+
+// rulesetsRows decodes a page of the list of rulesets into rows of rulesetsCols, after the arguments of the table,
+// fetching every ruleset of the page from the detail URL (a format of its id) for its rules
+func rulesetsRows(opts *Options, args []string, detail string, body []byte, tableArgs ...interface{}) ([][]interface{}, error) {
+	var summaries []*ruleset
+	if err := json.Unmarshal(body, &summaries); err != nil {
+		return nil, fmt.Errorf("failed to decode rulesets: %v", err)
+	}
+
+	var rows = make([][]interface{}, 0, len(summaries))
+	for _, summary := range summaries {
+		b, _, err := restGet(context.Background(), opts, "github_repo_rulesets", args, fmt.Sprintf(detail, summary.ID))
+		if err != nil {
+			return nil, err
+		}
+		var r ruleset
+		if err = json.Unmarshal(b, &r); err != nil {
+			return nil, fmt.Errorf("failed to decode ruleset %d: %v", summary.ID, err)
+		}
+		rows = append(rows, r.row(tableArgs...))
+	}
+	return rows, nil
+}
+
+var rulesetsCols = []vtab.Column{
+	{Name: "owner", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "reponame", Type: sqlite.SQLITE_TEXT, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: sqlite.SQLITE_INTEGER},
+	{Name: "name", Type: sqlite.SQLITE_TEXT},
+	{Name: "target", Type: sqlite.SQLITE_TEXT},
+	{Name: "source_type", Type: sqlite.SQLITE_TEXT},
+	{Name: "source", Type: sqlite.SQLITE_TEXT},
+	{Name: "enforcement", Type: sqlite.SQLITE_TEXT},
+	{Name: "conditions", Type: sqlite.SQLITE_TEXT},
+	{Name: "rules", Type: sqlite.SQLITE_TEXT},
+	{Name: "bypass_actors", Type: sqlite.SQLITE_TEXT},
+	{Name: "created_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "updated_at", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewRulesetsModule returns the implementation of a table-valued-function listing the rulesets of a repository,
+// including those of its organization applying to it, or of an organization if only an owner is given.
+// The list of rulesets doesn't hold their rules, and so every ruleset is fetched in turn.
+func NewRulesetsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_repo_rulesets", rulesetsCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var fullNameOrOwner, name string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && !constraint.Value.IsNil() {
+				switch constraint.ColIndex {
+				case 0:
+					fullNameOrOwner = constraint.Value.Text()
+				case 1:
+					name = constraint.Value.Text()
+				}
+			}
+		}
+
+		if iter, err := requireArgs(opts, "github_repo_rulesets", "owner", fullNameOrOwner); iter != nil || err != nil {
+			return iter, err
+		}
+
+		// rulesets are those of an organization, unless a repository is given
+		var base = fmt.Sprintf("/orgs/%s/rulesets", url.PathEscape(fullNameOrOwner))
+		var parents bool // whether the rulesets of its organization applying to the repository are included
+		var args = queryArgs("owner", fullNameOrOwner)
+		if name != "" || strings.Contains(fullNameOrOwner, "/") {
+			owner, repo, err := repoOwnerAndName(name, fullNameOrOwner)
+			if err != nil {
+				return nil, err
+			}
+			base = fmt.Sprintf("/repos/%s/%s/rulesets", url.PathEscape(owner), url.PathEscape(repo))
+			parents = true
+			args = queryArgs("owner", owner, "reponame", repo)
+		}
+
+		var list, detail = fmt.Sprintf("%s?per_page=%d", base, maxPageSize), restURL + base + "/%d"
+		if parents {
+			list, detail = list+"&includes_parents=true", detail+"?includes_parents=true"
+		}
+
+		return restPages(opts, "github_repo_rulesets", args, list, func(body []byte) ([][]interface{}, error) {
+			return rulesetsRows(opts, args, detail, body, fullNameOrOwner, name)
+		}), nil
+	})
+}
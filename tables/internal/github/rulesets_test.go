@@ -0,0 +1,50 @@
+package github
+
+import "testing"
+
+func TestRulesetsRows(t *testing.T) {
+	var list = `[
+		{"id": 42, "name": "main protection", "target": "branch", "source_type": "Repository", "source": "octocat/Hello-World", "enforcement": "active"},
+		{"id": 7, "name": "org tags", "target": "tag", "source_type": "Organization", "source": "octocat", "enforcement": "evaluate"}
+	]`
+
+	opts, stop := restServer(t, map[string]string{
+		"/repos/octocat/Hello-World/rulesets/42?includes_parents=true": `{
+			"id": 42,
+			"name": "main protection",
+			"target": "branch",
+			"source_type": "Repository",
+			"source": "octocat/Hello-World",
+			"enforcement": "active",
+			"bypass_actors": [{"actor_id": 234, "actor_type": "Team", "bypass_mode": "always"}],
+			"conditions": {"ref_name": {"include": ["refs/heads/main"], "exclude": []}},
+			"rules": [{"type": "deletion"}],
+			"created_at": "2023-07-15T08:43:03Z",
+			"updated_at": "2023-08-23T16:29:47Z"
+		}`,
+		"/repos/octocat/Hello-World/rulesets/7?includes_parents=true": `{
+			"id": 7,
+			"name": "org tags",
+			"target": "tag",
+			"source_type": "Organization",
+			"source": "octocat",
+			"enforcement": "evaluate",
+			"bypass_actors": null,
+			"conditions": null,
+			"rules": [],
+			"created_at": "2023-01-01T00:00:00Z",
+			"updated_at": "2023-01-02T00:00:00Z"
+		}`,
+	})
+	defer stop()
+
+	var detail = restURL + "/repos/octocat/Hello-World/rulesets/%d?includes_parents=true"
+	rows, err := rulesetsRows(opts, queryArgs("owner", "octocat", "reponame", "Hello-World"), detail, []byte(list), "octocat/Hello-World", "")
+	expectRows(t, rows, err, [][]interface{}{
+		{"octocat/Hello-World", "", int64(42), "main protection", "branch", "Repository", "octocat/Hello-World", "active",
+			`{"ref_name": {"include": ["refs/heads/main"], "exclude": []}}`, `[{"type": "deletion"}]`, `[{"actor_id": 234, "actor_type": "Team", "bypass_mode": "always"}]`,
+			timestamp(t, "2023-07-15T08:43:03Z"), timestamp(t, "2023-08-23T16:29:47Z")},
+		{"octocat/Hello-World", "", int64(7), "org tags", "tag", "Organization", "octocat", "evaluate",
+			nil, `[]`, nil, timestamp(t, "2023-01-01T00:00:00Z"), timestamp(t, "2023-01-02T00:00:00Z")},
+	})
+}
@@ -0,0 +1,100 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+type runner struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	OS        string `json:"os"`
+	Status    string `json:"status"`
+	Busy      bool   `json:"busy"`
+	Ephemeral bool   `json:"ephemeral"`
+	Labels    []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+}
+
+// labels returns the names of the labels of the runner, in the order the API lists them
+func (r *runner) labels() []string {
+	var labels = make([]string, 0, len(r.Labels))
+	for _, l := range r.Labels {
+		labels = append(labels, l.Name)
+	}
+	return labels
+}
+
+// runnersRows decodes a page of self-hosted runners into rows of runnersCols, after the arguments of the table
+func runnersRows(body []byte, level string, args ...interface{}) ([][]interface{}, error) {
+	var page struct {
+		Runners []*runner `json:"runners"`
+	}
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, fmt.Errorf("failed to decode runners: %v", err)
+	}
+
+	var rows = make([][]interface{}, 0, len(page.Runners))
+	for _, r := range page.Runners {
+		rows = append(rows, append(args[:len(args):len(args)], level, r.ID, r.Name, r.OS, r.Status, r.Busy, r.labels(), r.Ephemeral))
+	}
+	return rows, nil
+}
+
+var runnersCols = []vtab.Column{
+	{Name: "owner", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "reponame", Type: sqlite.SQLITE_TEXT, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "level", Type: sqlite.SQLITE_TEXT},
+	{Name: "id", Type: sqlite.SQLITE_INTEGER},
+	{Name: "name", Type: sqlite.SQLITE_TEXT},
+	{Name: "os", Type: sqlite.SQLITE_TEXT},
+	{Name: "status", Type: sqlite.SQLITE_TEXT},
+	{Name: "busy", Type: sqlite.SQLITE_INTEGER},
+	{Name: "labels", Type: sqlite.SQLITE_TEXT},
+	{Name: "ephemeral", Type: sqlite.SQLITE_INTEGER},
+}
+
+// NewRunnersModule returns the implementation of a table-valued-function listing the self-hosted runners
+// of Actions of a repository, or of an organization if only an owner is given
+func NewRunnersModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_self_hosted_runners", runnersCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var fullNameOrOwner, name string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && !constraint.Value.IsNil() {
+				switch constraint.ColIndex {
+				case 0:
+					fullNameOrOwner = constraint.Value.Text()
+				case 1:
+					name = constraint.Value.Text()
+				}
+			}
+		}
+
+		if iter, err := requireArgs(opts, "github_self_hosted_runners", "owner", fullNameOrOwner); iter != nil || err != nil {
+			return iter, err
+		}
+
+		// runners are those of an organization, unless a repository is given
+		var level, base = levelOrganization, fmt.Sprintf("/orgs/%s/actions/runners", url.PathEscape(fullNameOrOwner))
+		var args = queryArgs("owner", fullNameOrOwner)
+		if name != "" || strings.Contains(fullNameOrOwner, "/") {
+			owner, repo, err := repoOwnerAndName(name, fullNameOrOwner)
+			if err != nil {
+				return nil, err
+			}
+			level, base = levelRepository, fmt.Sprintf("/repos/%s/%s/actions/runners", url.PathEscape(owner), url.PathEscape(repo))
+			args = queryArgs("owner", owner, "reponame", repo)
+		}
+
+		var path = fmt.Sprintf("%s?per_page=%d", base, maxPageSize)
+		return restPages(opts, "github_self_hosted_runners", args, path, func(body []byte) ([][]interface{}, error) {
+			return runnersRows(body, level, fullNameOrOwner, name)
+		}), nil
+	})
+}
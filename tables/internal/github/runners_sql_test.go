@@ -0,0 +1,41 @@
+package github_test
+
+import "testing"
+
+func TestRunners(t *testing.T) {
+	// the runners of the organization span two pages
+	defer serve(rest(t, map[string]page{
+		"/orgs/octo-org/actions/runners?per_page=100": {
+			body: `{"total_count": 2, "runners": [{"id": 23, "name": "MBP", "os": "macos", "status": "online", "busy": true, "ephemeral": false,
+				"labels": [{"id": 5, "name": "self-hosted", "type": "read-only"}, {"id": 7, "name": "X64", "type": "read-only"}]}]}`,
+			next: "/organizations/1/actions/runners?per_page=100&page=2",
+		},
+		"/organizations/1/actions/runners?per_page=100&page=2": {
+			body: `{"total_count": 2, "runners": [{"id": 24, "name": "iMac", "os": "macos", "status": "offline", "busy": false, "ephemeral": true, "labels": []}]}`,
+		},
+		"/repos/octo-org/Hello-World/actions/runners?per_page=100": {
+			body: `{"total_count": 1, "runners": [{"id": 25, "name": "linux", "os": "linux", "status": "online", "busy": false, "ephemeral": false,
+				"labels": [{"id": 5, "name": "self-hosted", "type": "read-only"}, {"id": 8, "name": "gpu", "type": "custom"}]}]}`,
+		},
+	}))()
+
+	db := Connect(t, Memory)
+
+	// the runners of an organization, unless a repository is given
+	content, err := query(t, db, "SELECT * FROM github_self_hosted_runners('octo-org')")
+	expectContent(t, content, err, [][]string{
+		{"organization", "23", "MBP", "macos", "online", "1", `["self-hosted","X64"]`, "0"},
+		{"organization", "24", "iMac", "macos", "offline", "0", "[]", "1"},
+	})
+
+	for _, q := range []string{
+		"SELECT level, name, labels FROM github_self_hosted_runners('octo-org', 'Hello-World')",
+		"SELECT level, name, labels FROM github_self_hosted_runners('octo-org/Hello-World')",
+	} {
+		content, err = query(t, db, q)
+		expectContent(t, content, err, [][]string{{"repository", "linux", `["self-hosted","gpu"]`}})
+	}
+
+	_, err = query(t, db, "SELECT * FROM github_self_hosted_runners")
+	expectError(t, err, "github_self_hosted_runners requires owner")
+}
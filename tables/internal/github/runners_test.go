@@ -0,0 +1,39 @@
+package github
+
+import "testing"
+
+func TestRunnersRows(t *testing.T) {
+	var body = `{
+		"total_count": 2,
+		"runners": [
+			{
+				"id": 23,
+				"name": "MBP",
+				"os": "macos",
+				"status": "online",
+				"busy": true,
+				"ephemeral": false,
+				"labels": [
+					{"id": 5, "name": "self-hosted", "type": "read-only"},
+					{"id": 7, "name": "X64", "type": "read-only"},
+					{"id": 20, "name": "gpu", "type": "custom"}
+				]
+			},
+			{
+				"id": 24,
+				"name": "iMac",
+				"os": "linux",
+				"status": "offline",
+				"busy": false,
+				"ephemeral": true,
+				"labels": []
+			}
+		]
+	}`
+
+	rows, err := runnersRows([]byte(body), levelOrganization, "octo-org", "")
+	expectRows(t, rows, err, [][]interface{}{
+		{"octo-org", "", levelOrganization, int64(23), "MBP", "macos", "online", true, []string{"self-hosted", "X64", "gpu"}, false},
+		{"octo-org", "", levelOrganization, int64(24), "iMac", "linux", "offline", false, []string{}, true},
+	})
+}
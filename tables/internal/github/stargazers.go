@@ -5,13 +5,15 @@ import (
 	"io"
 	"time"
 
+	"github.com/askgitdev/askgit/tables/internal/tz"
 	"github.com/augmentable-dev/vtab"
 	"github.com/shurcooL/githubv4"
 	"go.riyazali.net/sqlite"
-	"golang.org/x/time/rate"
 )
 
 type stargazer struct {
+	DatabaseId      int
+	Id              string
 	Login           string
 	Email           string
 	Name            string
@@ -89,7 +91,7 @@ type iterStargazers struct {
 	client          *githubv4.Client
 	current         int
 	results         *fetchStarsResults
-	rateLimiter     *rate.Limiter
+	pages           *pager
 	starOrder       *githubv4.StarOrder
 }
 
@@ -116,14 +118,14 @@ func (i *iterStargazers) Column(ctx *sqlite.Context, c int) error {
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t, time.RFC3339Nano))
 		}
 	case 9:
 		t := i.results.Edges[i.current].Node.UpdatedAt
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t, time.RFC3339Nano))
 		}
 	case 10:
 		ctx.ResultText(i.results.Edges[i.current].Node.TwitterUsername)
@@ -132,37 +134,43 @@ func (i *iterStargazers) Column(ctx *sqlite.Context, c int) error {
 	case 12:
 		ctx.ResultText(i.results.Edges[i.current].Node.Location)
 	case 13:
-		ctx.ResultText(i.results.Edges[i.current].StarredAt)
+		ctx.ResultText(tz.Text(i.results.Edges[i.current].StarredAt))
+	case 14:
+		ctx.ResultInt(i.results.Edges[i.current].Node.DatabaseId)
+	case 15:
+		ctx.ResultText(i.results.Edges[i.current].Node.Id)
+	case 16, 17:
+		i.pages.hints.result(ctx, c-16)
 	}
 	return nil
 }
 
+// fetch fetches the page of stargazers following cursor
+func (i *iterStargazers) fetch(ctx context.Context, cursor *githubv4.String) (interface{}, error) {
+	owner, name, err := repoOwnerAndName(i.name, i.fullNameOrOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	return fetchStars(ctx, &fetchStarsOptions{i.client, owner, name, i.pages.hints.pageSize, cursor, i.starOrder})
+}
+
 func (i *iterStargazers) Next() (vtab.Row, error) {
 	i.current += 1
 
 	if i.results == nil || i.current >= len(i.results.Edges) {
-		if i.results == nil || i.results.HasNextPage {
-			err := i.rateLimiter.Wait(context.Background())
-			if err != nil {
-				return nil, err
-			}
-
-			owner, name, err := repoOwnerAndName(i.name, i.fullNameOrOwner)
-			if err != nil {
-				return nil, err
-			}
-
+		if i.results == nil || i.results.HasNextPage && i.pages.more() {
 			var cursor *githubv4.String
 			if i.results != nil {
 				cursor = i.results.EndCursor
 			}
 
-			results, err := fetchStars(context.Background(), &fetchStarsOptions{i.client, owner, name, 100, cursor, i.starOrder})
+			page, err := i.pages.page(cursor)
 			if err != nil {
 				return nil, err
 			}
 
-			i.results = results
+			i.results = page.(*fetchStarsResults)
 			i.current = 0
 
 		} else {
@@ -170,11 +178,13 @@ func (i *iterStargazers) Next() (vtab.Row, error) {
 		}
 	}
 
+	i.pages.maybeFetchAhead(i.current, len(i.results.Edges), i.results.HasNextPage, i.results.EndCursor)
+
 	return i, nil
 }
 
-var stargazersCols = []vtab.Column{
-	{Name: "owner", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+var stargazersCols = append([]vtab.Column{
+	{Name: "owner", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
 	{Name: "reponame", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ}}},
 	{Name: "login", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil},
 	{Name: "email", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil},
@@ -188,7 +198,9 @@ var stargazersCols = []vtab.Column{
 	{Name: "website", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil},
 	{Name: "location", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil},
 	{Name: "starred_at", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.ASC | vtab.DESC},
-}
+	{Name: "database_id", Type: sqlite.SQLITE_INTEGER, NotNull: false, Hidden: false, Filters: nil},
+	{Name: "node_id", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil},
+}, hintCols...)
 
 func NewStargazersModule(opts *Options) sqlite.Module {
 	return vtab.NewTableFunc("github_stargazers", stargazersCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
@@ -204,6 +216,15 @@ func NewStargazersModule(opts *Options) sqlite.Module {
 			}
 		}
 
+		if iter, err := requireArgs(opts, "github_stargazers", "owner", fullNameOrOwner); iter != nil || err != nil {
+			return iter, err
+		}
+
+		h, err := parseHints("github_stargazers", stargazersCols, constraints)
+		if err != nil {
+			return nil, err
+		}
+
 		var starOrder *githubv4.StarOrder
 		// for now we can only support single field order bys
 		if len(orders) == 1 {
@@ -216,6 +237,8 @@ func NewStargazersModule(opts *Options) sqlite.Module {
 			starOrder.Direction = orderByToGitHubOrder(order.Desc)
 		}
 
-		return &iterStargazers{fullNameOrOwner, name, opts.Client(), -1, nil, opts.RateLimiter, starOrder}, nil
+		var iter = &iterStargazers{fullNameOrOwner, name, opts.Client(), -1, nil, nil, starOrder}
+		iter.pages = newPager(opts, "github_stargazers", queryArgs("owner", fullNameOrOwner, "reponame", name), h, iter.fetch)
+		return iter, nil
 	})
 }
@@ -16,7 +16,7 @@ type starCount struct {
 func (s *starCount) Args() int           { return -1 }
 func (s *starCount) Deterministic() bool { return false }
 func (s *starCount) Apply(ctx *sqlite.Context, values ...sqlite.Value) {
-	err := s.opts.RateLimiter.Wait(context.Background())
+	err := s.opts.RateLimiter.Wait(context.Background(), "github_stargazer_count", Interactive)
 	if err != nil {
 		ctx.ResultError(err)
 		return
@@ -52,7 +52,7 @@ func (s *starCount) Apply(ctx *sqlite.Context, values ...sqlite.Value) {
 	}
 	err = s.opts.Client().Query(context.Background(), &starsCountQuery, variables)
 	if err != nil {
-		ctx.ResultError(err)
+		ctx.ResultError(&QueryError{Table: "github_stargazer_count", Args: queryArgs("owner", owner, "name", name), Err: err})
 		return
 	}
 	ctx.ResultInt(starsCountQuery.Repository.StargazerCount)
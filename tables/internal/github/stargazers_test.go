@@ -23,8 +23,8 @@ func TestStargazers(t *testing.T) {
 		t.Fatalf("failed to retrieve row contents: %v", err.Error())
 	}
 
-	if colCount != 12 {
-		t.Fatalf("expected 12 columns, got: %d", colCount)
+	if colCount != 14 {
+		t.Fatalf("expected 14 columns, got: %d", colCount)
 	}
 
 	if len(content) != 500 {
@@ -2,13 +2,14 @@ package github
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"time"
 
+	"github.com/askgitdev/askgit/tables/internal/tz"
 	"github.com/augmentable-dev/vtab"
 	"github.com/shurcooL/githubv4"
 	"go.riyazali.net/sqlite"
-	"golang.org/x/time/rate"
 )
 
 type fetchStarredReposOptions struct {
@@ -31,6 +32,8 @@ type starredRepoEdge struct {
 }
 
 type starredRepoNode struct {
+	DatabaseId     int
+	Id             string
 	Name           string
 	Url            string
 	Description    string
@@ -77,12 +80,13 @@ func fetchStarredRepos(ctx context.Context, input *fetchStarredReposOptions) (*f
 }
 
 type iterStarredRepos struct {
-	login       string
-	client      *githubv4.Client
-	current     int
-	results     *fetchStarredReposResults
-	rateLimiter *rate.Limiter
-	starOrder   *githubv4.StarOrder
+	login     string
+	client    *githubv4.Client
+	current   int
+	results   *fetchStarredReposResults
+	pages     *pager
+	starOrder *githubv4.StarOrder
+	opts      *Options
 }
 
 func (i *iterStarredRepos) Column(ctx *sqlite.Context, c int) error {
@@ -101,52 +105,64 @@ func (i *iterStarredRepos) Column(ctx *sqlite.Context, c int) error {
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t, time.RFC3339Nano))
 		}
 	case 5:
 		t := current.Node.PushedAt
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t, time.RFC3339Nano))
 		}
 	case 6:
 		t := current.Node.UpdatedAt
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t, time.RFC3339Nano))
 		}
 	case 7:
 		ctx.ResultInt(current.Node.StargazerCount)
 	case 8:
 		ctx.ResultText(current.Node.NameWithOwner)
 	case 9:
-		ctx.ResultText(current.StarredAt)
+		ctx.ResultText(tz.Text(current.StarredAt))
+	case 10:
+		ctx.ResultInt(current.Node.DatabaseId)
+	case 11:
+		ctx.ResultText(current.Node.Id)
+	case 12, 13:
+		i.pages.hints.result(ctx, c-12)
 	}
 	return nil
 }
 
+// fetch fetches the page of starred repositories following cursor
+func (i *iterStarredRepos) fetch(ctx context.Context, cursor *githubv4.String) (interface{}, error) {
+	return i.opts.fetchOwned(ctx, i.client, i.login, ownerUser, func(t ownerType) (interface{}, error) {
+		if t == ownerOrganization {
+			return nil, fmt.Errorf("'%s' is an organization, only users star repositories", i.login)
+		}
+		return fetchStarredRepos(ctx, &fetchStarredReposOptions{i.client, i.login, i.pages.hints.pageSize, cursor, i.starOrder})
+	})
+}
+
 func (i *iterStarredRepos) Next() (vtab.Row, error) {
 	i.current += 1
 
 	if i.results == nil || i.current >= len(i.results.Edges) {
-		if i.results == nil || i.results.HasNextPage {
-			err := i.rateLimiter.Wait(context.Background())
-			if err != nil {
-				return nil, err
-			}
-
+		if i.results == nil || i.results.HasNextPage && i.pages.more() {
 			var cursor *githubv4.String
 			if i.results != nil {
 				cursor = i.results.EndCursor
 			}
-			results, err := fetchStarredRepos(context.Background(), &fetchStarredReposOptions{i.client, i.login, 100, cursor, i.starOrder})
+
+			page, err := i.pages.page(cursor)
 			if err != nil {
 				return nil, err
 			}
 
-			i.results = results
+			i.results = page.(*fetchStarredReposResults)
 			i.current = 0
 
 		} else {
@@ -154,11 +170,13 @@ func (i *iterStarredRepos) Next() (vtab.Row, error) {
 		}
 	}
 
+	i.pages.maybeFetchAhead(i.current, len(i.results.Edges), i.results.HasNextPage, i.results.EndCursor)
+
 	return i, nil
 }
 
-var starredReposCols = []vtab.Column{
-	{Name: "login", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+var starredReposCols = append([]vtab.Column{
+	{Name: "login", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
 	{Name: "name", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil},
 	{Name: "url", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil},
 	{Name: "description", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil},
@@ -168,20 +186,41 @@ var starredReposCols = []vtab.Column{
 	{Name: "stargazer_count", Type: sqlite.SQLITE_INTEGER, NotNull: true, Hidden: false, Filters: nil},
 	{Name: "name_with_owner", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil},
 	{Name: "starred_at", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.ASC | vtab.DESC},
-}
+	{Name: "database_id", Type: sqlite.SQLITE_INTEGER, NotNull: false, Hidden: false, Filters: nil},
+	{Name: "node_id", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: false, Filters: nil},
+}, hintCols...)
 
 func NewStarredReposModule(opts *Options) sqlite.Module {
 	return vtab.NewTableFunc("github_starred_repos", starredReposCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
 		var login string
+		var loginGiven bool
 		for _, constraint := range constraints {
 			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
 				switch constraint.ColIndex {
 				case 0:
-					login = constraint.Value.Text()
+					login, loginGiven = constraint.Value.Text(), true
 				}
 			}
 		}
 
+		// without a login, the table is of the authenticated user's (a NULL login is still missing)
+		var client = opts.Client()
+		if !loginGiven {
+			var err error
+			if login, err = opts.viewerLogin(context.Background(), client); err != nil {
+				return nil, &QueryError{Table: "github_starred_repos", Err: err}
+			}
+		}
+
+		if iter, err := requireArgs(opts, "github_starred_repos", "login", login); iter != nil || err != nil {
+			return iter, err
+		}
+
+		h, err := parseHints("github_starred_repos", starredReposCols, constraints)
+		if err != nil {
+			return nil, err
+		}
+
 		var starOrder *githubv4.StarOrder
 		// for now we can only support single field order bys
 		if len(orders) == 1 {
@@ -194,6 +233,8 @@ func NewStarredReposModule(opts *Options) sqlite.Module {
 			starOrder.Direction = orderByToGitHubOrder(order.Desc)
 		}
 
-		return &iterStarredRepos{login, opts.Client(), -1, nil, opts.RateLimiter, starOrder}, nil
+		var iter = &iterStarredRepos{login, client, -1, nil, nil, starOrder, opts}
+		iter.pages = newPager(opts, "github_starred_repos", queryArgs("login", login), h, iter.fetch)
+		return iter, nil
 	})
 }
@@ -1,6 +1,7 @@
 package github_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/askgitdev/askgit/tables/internal/tools"
@@ -23,11 +24,44 @@ func TestStarredRepos(t *testing.T) {
 		t.Fatalf("failed to retrieve row contents: %v", err.Error())
 	}
 
-	if colCount != 9 {
-		t.Fatalf("expected 9 columns, got: %d", colCount)
+	if colCount != 11 {
+		t.Fatalf("expected 11 columns, got: %d", colCount)
 	}
 
 	if len(content) != 10 {
 		t.Fatalf("expected 10 rows, got: %d", len(content))
 	}
 }
+
+func TestStarredReposMissingLogin(t *testing.T) {
+	db := Connect(t, Memory)
+
+	// a login left out defaults to the authenticated user's, but a NULL one is missing
+	rows, err := db.Query("SELECT * FROM github_starred_repos(NULL)")
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+		}
+		err = rows.Err()
+	}
+
+	if err == nil || !strings.Contains(err.Error(), "github_starred_repos requires login") {
+		t.Fatalf("expected a missing login error, got: %v", err)
+	}
+}
+
+func TestStarredReposInvalidPageSize(t *testing.T) {
+	db := Connect(t, Memory)
+
+	rows, err := db.Query("SELECT * FROM github_starred_repos('patrickdevivo', 500)")
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+		}
+		err = rows.Err()
+	}
+
+	if err == nil || !strings.Contains(err.Error(), "page_size must be between 1 and 100") {
+		t.Fatalf("expected an invalid page_size error, got: %v", err)
+	}
+}
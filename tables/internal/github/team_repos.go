@@ -0,0 +1,267 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/shurcooL/githubv4"
+	"go.riyazali.net/sqlite"
+)
+
+// permissionLevels ranks the permissions a team can have on a repository, named as by the REST API and the settings of GitHub
+var permissionLevels = map[string]int{"pull": 1, "triage": 2, "push": 3, "maintain": 4, "admin": 5}
+
+// permissionName returns the name of a permission of the GraphQL API (READ, TRIAGE, WRITE, MAINTAIN or ADMIN)
+// as by the REST API and the settings of GitHub (pull, triage, push, maintain or admin)
+func permissionName(permission string) string {
+	switch permission {
+	case "READ":
+		return "pull"
+	case "WRITE":
+		return "push"
+	}
+	return strings.ToLower(permission)
+}
+
+type team struct {
+	Slug       string
+	Name       string
+	Privacy    string
+	ParentTeam *struct {
+		Slug string
+	}
+}
+
+type teamRepoEdge struct {
+	Permission string
+	Node       struct {
+		NameWithOwner string
+		Name          string
+		IsPrivate     bool
+		IsArchived    bool
+	}
+}
+
+// pageInfo is the position of a page in a connection of the GraphQL API
+type pageInfo struct {
+	EndCursor   githubv4.String
+	HasNextPage bool
+}
+
+// fetchTeams fetches the page of the teams of org following cursor
+func fetchTeams(ctx context.Context, client *githubv4.Client, org string, cursor *githubv4.String) ([]*team, *pageInfo, error) {
+	var query struct {
+		Organization struct {
+			Teams struct {
+				Nodes    []*team
+				PageInfo pageInfo
+			} `graphql:"teams(first: $perPage, after: $cursor)"`
+		} `graphql:"organization(login: $login)"`
+	}
+	var variables = map[string]interface{}{
+		"login":   githubv4.String(org),
+		"perPage": githubv4.Int(maxPageSize),
+		"cursor":  cursor,
+	}
+	if err := client.Query(ctx, &query, variables); err != nil {
+		return nil, nil, err
+	}
+	return query.Organization.Teams.Nodes, &query.Organization.Teams.PageInfo, nil
+}
+
+// fetchTeam fetches the team of org called slug, or nil if there's none
+func fetchTeam(ctx context.Context, client *githubv4.Client, org, slug string) (*team, error) {
+	var query struct {
+		Organization struct {
+			Team *team `graphql:"team(slug: $slug)"`
+		} `graphql:"organization(login: $login)"`
+	}
+	var variables = map[string]interface{}{
+		"login": githubv4.String(org),
+		"slug":  githubv4.String(slug),
+	}
+	if err := client.Query(ctx, &query, variables); err != nil {
+		return nil, err
+	}
+	return query.Organization.Team, nil
+}
+
+// fetchTeamRepos fetches the page of the repositories the team of org called slug has access to following cursor
+func fetchTeamRepos(ctx context.Context, client *githubv4.Client, org, slug string, cursor *githubv4.String) ([]*teamRepoEdge, *pageInfo, error) {
+	var query struct {
+		Organization struct {
+			Team struct {
+				Repositories struct {
+					Edges    []*teamRepoEdge
+					PageInfo pageInfo
+				} `graphql:"repositories(first: $perPage, after: $cursor)"`
+			} `graphql:"team(slug: $slug)"`
+		} `graphql:"organization(login: $login)"`
+	}
+	var variables = map[string]interface{}{
+		"login":   githubv4.String(org),
+		"slug":    githubv4.String(slug),
+		"perPage": githubv4.Int(maxPageSize),
+		"cursor":  cursor,
+	}
+	if err := client.Query(ctx, &query, variables); err != nil {
+		return nil, nil, err
+	}
+	return query.Organization.Team.Repositories.Edges, &query.Organization.Team.Repositories.PageInfo, nil
+}
+
+// iterTeamRepos iterates over the repositories of every team of an organization (or of a single team),
+// fetching the teams a page at a time, and then the repositories of every team a page at a time
+type iterTeamRepos struct {
+	org, slug string
+	opts      *Options
+	client    *githubv4.Client
+
+	teams     []*team
+	teamsPage *pageInfo // nil once every team is listed
+	team      int
+
+	repos     []*teamRepoEdge
+	reposPage *pageInfo
+	current   int
+}
+
+// wait waits for the shared limiter to let a request of the table out
+func (i *iterTeamRepos) wait() error {
+	return i.opts.RateLimiter.Wait(context.Background(), "github_team_repos", Interactive)
+}
+
+func (i *iterTeamRepos) Column(ctx *sqlite.Context, c int) error {
+	var t, edge = i.teams[i.team], i.repos[i.current]
+	switch c {
+	case 0:
+		ctx.ResultText(i.org)
+	case 1:
+		resultTextOrNull(ctx, i.slug)
+	case 2:
+		ctx.ResultText(t.Slug)
+	case 3:
+		ctx.ResultText(t.Name)
+	case 4:
+		ctx.ResultText(strings.ToLower(t.Privacy))
+	case 5:
+		if t.ParentTeam != nil {
+			ctx.ResultText(t.ParentTeam.Slug)
+		} else {
+			ctx.ResultNull()
+		}
+	case 6:
+		ctx.ResultText(edge.Node.NameWithOwner)
+	case 7:
+		ctx.ResultText(edge.Node.Name)
+	case 8:
+		ctx.ResultInt(t1f0(edge.Node.IsPrivate))
+	case 9:
+		ctx.ResultInt(t1f0(edge.Node.IsArchived))
+	case 10:
+		ctx.ResultText(permissionName(edge.Permission))
+	case 11:
+		ctx.ResultInt(permissionLevels[permissionName(edge.Permission)])
+	}
+	return nil
+}
+
+func (i *iterTeamRepos) Next() (vtab.Row, error) {
+	i.current++
+	for i.current >= len(i.repos) {
+		var err error
+		switch {
+		// the next page of the repositories of the current team
+		case i.reposPage != nil && i.reposPage.HasNextPage:
+			if err = i.wait(); err != nil {
+				return nil, err
+			}
+			i.repos, i.reposPage, err = fetchTeamRepos(context.Background(), i.client, i.org, i.teams[i.team].Slug, &i.reposPage.EndCursor)
+
+		// the repositories of the next team
+		case i.team+1 < len(i.teams):
+			i.team++
+			if err = i.wait(); err != nil {
+				return nil, err
+			}
+			i.repos, i.reposPage, err = fetchTeamRepos(context.Background(), i.client, i.org, i.teams[i.team].Slug, nil)
+
+		// the next page of teams
+		case i.teamsPage != nil && i.teamsPage.HasNextPage:
+			if err = i.wait(); err != nil {
+				return nil, err
+			}
+			i.teams, i.teamsPage, err = fetchTeams(context.Background(), i.client, i.org, &i.teamsPage.EndCursor)
+			i.team, i.repos, i.reposPage = -1, nil, nil
+
+		default:
+			return nil, io.EOF
+		}
+
+		if err != nil {
+			return nil, &QueryError{Table: "github_team_repos", Args: queryArgs("org", i.org, "team", i.slug), Err: err}
+		}
+		i.current = 0
+	}
+	return i, nil
+}
+
+var teamReposCols = []vtab.Column{
+	{Name: "org", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "team", Type: sqlite.SQLITE_TEXT, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "team_slug", Type: sqlite.SQLITE_TEXT},
+	{Name: "team_name", Type: sqlite.SQLITE_TEXT},
+	{Name: "team_privacy", Type: sqlite.SQLITE_TEXT},
+	{Name: "parent_team", Type: sqlite.SQLITE_TEXT},
+	{Name: "repo", Type: sqlite.SQLITE_TEXT},
+	{Name: "repo_name", Type: sqlite.SQLITE_TEXT},
+	{Name: "is_private", Type: sqlite.SQLITE_INTEGER},
+	{Name: "is_archived", Type: sqlite.SQLITE_INTEGER},
+	{Name: "permission", Type: sqlite.SQLITE_TEXT},
+	{Name: "permission_level", Type: sqlite.SQLITE_INTEGER},
+}
+
+// NewTeamReposModule returns the implementation of a table-valued-function listing the repositories the teams
+// of an organization (or one of them) have access to, with their permission on every repository
+func NewTeamReposModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_team_repos", teamReposCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var org, slug string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && !constraint.Value.IsNil() {
+				switch constraint.ColIndex {
+				case 0:
+					org = constraint.Value.Text()
+				case 1:
+					slug = constraint.Value.Text()
+				}
+			}
+		}
+
+		if iter, err := requireArgs(opts, "github_team_repos", "org", org); iter != nil || err != nil {
+			return iter, err
+		}
+
+		var iter = &iterTeamRepos{org: org, slug: slug, opts: opts, client: opts.Client(), team: -1, current: -1}
+		if err := iter.wait(); err != nil {
+			return nil, err
+		}
+
+		var err error
+		if slug == "" {
+			iter.teams, iter.teamsPage, err = fetchTeams(context.Background(), iter.client, org, nil)
+		} else {
+			var t *team
+			if t, err = fetchTeam(context.Background(), iter.client, org, slug); err == nil && t == nil {
+				err = errors.New("team not found")
+			}
+			iter.teams = []*team{t}
+		}
+		if err != nil {
+			return nil, &QueryError{Table: "github_team_repos", Args: queryArgs("org", org, "team", slug), Err: err}
+		}
+		return iter, nil
+	})
+}
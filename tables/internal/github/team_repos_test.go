@@ -0,0 +1,56 @@
+package github_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTeamRepos(t *testing.T) {
+	defer serve(graphql(t, func(query string, variables map[string]interface{}) string {
+		switch {
+		case strings.Contains(query, "teams("):
+			return `{"organization": {"teams": {"nodes": [
+				{"slug": "core", "name": "Core", "privacy": "VISIBLE", "parentTeam": null},
+				{"slug": "docs", "name": "Docs", "privacy": "SECRET", "parentTeam": {"slug": "core"}}
+			], "pageInfo": {"endCursor": "dGVhbXM=", "hasNextPage": false}}}}`
+		case !strings.Contains(query, "repositories("):
+			return `{"organization": {"team": {"slug": "docs", "name": "Docs", "privacy": "SECRET", "parentTeam": {"slug": "core"}}}}`
+		}
+
+		// the repositories of core span two pages
+		var edges, next string
+		switch {
+		case variables["slug"] == "docs":
+			edges, next = `{"permission": "WRITE", "node": {"nameWithOwner": "org/site", "name": "site", "isPrivate": false, "isArchived": false}},
+				{"permission": "MAINTAIN", "node": {"nameWithOwner": "org/wiki", "name": "wiki", "isPrivate": false, "isArchived": false}}`, "false"
+		case variables["cursor"] == nil:
+			edges, next = `{"permission": "ADMIN", "node": {"nameWithOwner": "org/api", "name": "api", "isPrivate": true, "isArchived": false}}`, "true"
+		default:
+			edges, next = `{"permission": "READ", "node": {"nameWithOwner": "org/old", "name": "old", "isPrivate": false, "isArchived": true}},
+				{"permission": "TRIAGE", "node": {"nameWithOwner": "org/bugs", "name": "bugs", "isPrivate": false, "isArchived": false}}`, "false"
+		}
+		return fmt.Sprintf(`{"organization": {"team": {"repositories": {"edges": [%s], "pageInfo": {"endCursor": "cmVwb3M=", "hasNextPage": %s}}}}}`, edges, next)
+	}))()
+
+	db := Connect(t, Memory)
+
+	content, err := query(t, db, "SELECT team, team_slug, team_name, team_privacy, parent_team, repo, repo_name, is_private, is_archived, permission, permission_level FROM github_team_repos('org')")
+	expectContent(t, content, err, [][]string{
+		{"NULL", "core", "Core", "visible", "NULL", "org/api", "api", "1", "0", "admin", "5"},
+		{"NULL", "core", "Core", "visible", "NULL", "org/old", "old", "0", "1", "pull", "1"},
+		{"NULL", "core", "Core", "visible", "NULL", "org/bugs", "bugs", "0", "0", "triage", "2"},
+		{"NULL", "docs", "Docs", "secret", "core", "org/site", "site", "0", "0", "push", "3"},
+		{"NULL", "docs", "Docs", "secret", "core", "org/wiki", "wiki", "0", "0", "maintain", "4"},
+	})
+
+	// the repositories of a single team
+	content, err = query(t, db, "SELECT team, team_slug, repo, permission FROM github_team_repos('org', 'docs')")
+	expectContent(t, content, err, [][]string{
+		{"docs", "docs", "org/site", "push"},
+		{"docs", "docs", "org/wiki", "maintain"},
+	})
+
+	_, err = query(t, db, "SELECT * FROM github_team_repos")
+	expectError(t, err, "github_team_repos requires org")
+}
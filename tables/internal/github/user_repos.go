@@ -5,10 +5,10 @@ import (
 	"io"
 	"time"
 
+	"github.com/askgitdev/askgit/tables/internal/tz"
 	"github.com/augmentable-dev/vtab"
 	"github.com/shurcooL/githubv4"
 	"go.riyazali.net/sqlite"
-	"golang.org/x/time/rate"
 )
 
 type fetchUserReposOptions struct {
@@ -36,6 +36,7 @@ type userRepo struct {
 	DiskUsage   int
 	ForkCount   int
 	HomepageUrl string
+	Id          string
 	IsArchived  bool
 	IsDisabled  bool
 	IsFork      bool
@@ -58,6 +59,7 @@ type userRepo struct {
 		Nickname string
 	}
 	Name              string
+	NameWithOwner     string
 	OpenGraphImageUrl githubv4.URI
 	PrimaryLanguage   struct {
 		Name string
@@ -110,12 +112,13 @@ func fetchUserRepos(ctx context.Context, input *fetchUserReposOptions) (*fetchUs
 }
 
 type iterUserRepos struct {
-	login       string
-	client      *githubv4.Client
-	current     int
-	results     *fetchUserReposResults
-	rateLimiter *rate.Limiter
-	repoOrder   *githubv4.RepositoryOrder
+	login     string
+	client    *githubv4.Client
+	current   int
+	results   *fetchUserReposResults
+	pages     *pager
+	repoOrder *githubv4.RepositoryOrder
+	opts      *Options
 }
 
 func (i *iterUserRepos) Column(ctx *sqlite.Context, c int) error {
@@ -128,7 +131,7 @@ func (i *iterUserRepos) Column(ctx *sqlite.Context, c int) error {
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t, time.RFC3339Nano))
 		}
 	case 2:
 		ctx.ResultInt(current.DatabaseId)
@@ -157,13 +160,13 @@ func (i *iterUserRepos) Column(ctx *sqlite.Context, c int) error {
 	case 14:
 		ctx.ResultInt(current.Issues.TotalCount)
 	case 15:
-		ctx.ResultText(current.LatestRelease.Author.Login)
+		resultTextOrNull(ctx, current.LatestRelease.Author.Login)
 	case 16:
 		t := current.LatestRelease.CreatedAt
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t.Time, time.RFC3339Nano))
 		}
 	case 17:
 		ctx.ResultText(current.LatestRelease.Name)
@@ -172,7 +175,7 @@ func (i *iterUserRepos) Column(ctx *sqlite.Context, c int) error {
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t.Time, time.RFC3339Nano))
 		}
 	case 19:
 		ctx.ResultText(current.LicenseInfo.Key)
@@ -191,7 +194,7 @@ func (i *iterUserRepos) Column(ctx *sqlite.Context, c int) error {
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t, time.RFC3339Nano))
 		}
 	case 26:
 		ctx.ResultInt(current.Releases.TotalCount)
@@ -202,34 +205,52 @@ func (i *iterUserRepos) Column(ctx *sqlite.Context, c int) error {
 		if t.IsZero() {
 			ctx.ResultNull()
 		} else {
-			ctx.ResultText(t.Format(time.RFC3339Nano))
+			ctx.ResultText(tz.Format(t, time.RFC3339Nano))
 		}
 	case 29:
 		ctx.ResultInt(current.Watchers.TotalCount)
+	case 30:
+		ctx.ResultText(current.Id)
+	case 31, 32:
+		i.pages.hints.result(ctx, c-31)
 	}
 	return nil
 }
 
-func (i *iterUserRepos) Next() (vtab.Row, error) {
-	i.current += 1
-
-	if i.results == nil || i.current >= len(i.results.UserRepos) {
-		if i.results == nil || i.results.HasNextPage {
-			err := i.rateLimiter.Wait(context.Background())
+// fetch fetches the page of repositories following cursor, from those of the organization if the login is one
+func (i *iterUserRepos) fetch(ctx context.Context, cursor *githubv4.String) (interface{}, error) {
+	return i.opts.fetchOwned(ctx, i.client, i.login, ownerUser, func(t ownerType) (interface{}, error) {
+		if t == ownerOrganization {
+			results, err := fetchOrgRepos(ctx, &fetchOrgReposOptions{i.client, i.login, i.pages.hints.pageSize, cursor, i.repoOrder})
 			if err != nil {
 				return nil, err
 			}
+			var repos = make([]*userRepo, len(results.OrgRepos))
+			for n, repo := range results.OrgRepos {
+				repos[n] = (*userRepo)(repo)
+			}
+			return &fetchUserReposResults{repos, results.HasNextPage, results.EndCursor}, nil
+		}
+		return fetchUserRepos(ctx, &fetchUserReposOptions{i.client, i.login, i.pages.hints.pageSize, cursor, i.repoOrder})
+	})
+}
+
+func (i *iterUserRepos) Next() (vtab.Row, error) {
+	i.current += 1
 
+	if i.results == nil || i.current >= len(i.results.UserRepos) {
+		if i.results == nil || i.results.HasNextPage && i.pages.more() {
 			var cursor *githubv4.String
 			if i.results != nil {
 				cursor = i.results.EndCursor
 			}
-			results, err := fetchUserRepos(context.Background(), &fetchUserReposOptions{i.client, i.login, 100, cursor, i.repoOrder})
+
+			page, err := i.pages.page(cursor)
 			if err != nil {
 				return nil, err
 			}
 
-			i.results = results
+			i.results = page.(*fetchUserReposResults)
 			i.current = 0
 
 		} else {
@@ -237,11 +258,13 @@ func (i *iterUserRepos) Next() (vtab.Row, error) {
 		}
 	}
 
+	i.pages.maybeFetchAhead(i.current, len(i.results.UserRepos), i.results.HasNextPage, i.results.EndCursor)
+
 	return i, nil
 }
 
-var userReposCols = []vtab.Column{
-	{Name: "login", Type: sqlite.SQLITE_TEXT, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+var userReposCols = append([]vtab.Column{
+	{Name: "login", Type: sqlite.SQLITE_TEXT, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
 	{Name: "created_at", Type: sqlite.SQLITE_TEXT, OrderBy: vtab.ASC | vtab.DESC},
 	{Name: "database_id", Type: sqlite.SQLITE_INTEGER},
 	{Name: "default_branch_ref_name", Type: sqlite.SQLITE_TEXT},
@@ -271,20 +294,40 @@ var userReposCols = []vtab.Column{
 	{Name: "stargazer_count", Type: sqlite.SQLITE_TEXT, OrderBy: vtab.ASC | vtab.DESC},
 	{Name: "updated_at", Type: sqlite.SQLITE_TEXT, OrderBy: vtab.ASC | vtab.DESC},
 	{Name: "watcher_count", Type: sqlite.SQLITE_INTEGER},
-}
+	{Name: "node_id", Type: sqlite.SQLITE_TEXT},
+}, hintCols...)
 
 func NewUserReposModule(opts *Options) sqlite.Module {
 	return vtab.NewTableFunc("github_user_repos", userReposCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
 		var login string
+		var loginGiven bool
 		for _, constraint := range constraints {
 			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
 				switch constraint.ColIndex {
 				case 0:
-					login = constraint.Value.Text()
+					login, loginGiven = constraint.Value.Text(), true
 				}
 			}
 		}
 
+		// without a login, the table is of the authenticated user's (a NULL login is still missing)
+		var client = opts.Client()
+		if !loginGiven {
+			var err error
+			if login, err = opts.viewerLogin(context.Background(), client); err != nil {
+				return nil, &QueryError{Table: "github_user_repos", Err: err}
+			}
+		}
+
+		if iter, err := requireArgs(opts, "github_user_repos", "login", login); iter != nil || err != nil {
+			return iter, err
+		}
+
+		h, err := parseHints("github_user_repos", userReposCols, constraints)
+		if err != nil {
+			return nil, err
+		}
+
 		var repoOrder *githubv4.RepositoryOrder
 		// for now we can only support single field order bys
 		if len(orders) == 1 {
@@ -305,6 +348,8 @@ func NewUserReposModule(opts *Options) sqlite.Module {
 			repoOrder.Direction = orderByToGitHubOrder(order.Desc)
 		}
 
-		return &iterUserRepos{login, opts.Client(), -1, nil, opts.RateLimiter, repoOrder}, nil
+		var iter = &iterUserRepos{login, client, -1, nil, nil, repoOrder, opts}
+		iter.pages = newPager(opts, "github_user_repos", queryArgs("login", login), h, iter.fetch)
+		return iter, nil
 	})
 }
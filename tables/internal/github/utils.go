@@ -2,17 +2,36 @@ package github
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/askgitdev/askgit/tables/services"
+	"github.com/augmentable-dev/vtab"
 	"github.com/shurcooL/githubv4"
-	"golang.org/x/time/rate"
+	"go.riyazali.net/sqlite"
 )
 
 type Options struct {
 	Client      func() *githubv4.Client
-	RateLimiter *rate.Limiter
+	RateLimiter *Limiter
+
+	// HTTP returns the authenticated client of the REST API, for the tables of the data the GraphQL API doesn't expose
+	HTTP func() *http.Client
+
+	// Prefetch enables fetching the next page of results in the background, while the current one is consumed
+	Prefetch bool
+
+	// ExcludeBots leaves out the rows authored by bots (see funcs.IsBotLogin) from the tables of issues
+	ExcludeBots bool
+
+	// Lenient makes tables whose required arguments are missing return no rows, rather than failing the query
+	Lenient bool
+
+	owners owners // the types of the owners of repositories looked up (see fetchOwned)
+	viewer viewer // the login of the authenticated user, once looked up (see viewerLogin)
 }
 
 // GetGitHubTokenFromCtx looks up the githubToken key in the supplied context and returns it if set
@@ -20,6 +39,24 @@ func GetGitHubTokenFromCtx(ctx services.Context) string {
 	return ctx["githubToken"]
 }
 
+// GetGithubPrefetchFromCtx looks up the githubPrefetch key in the supplied context and reports whether it's set to true
+func GetGithubPrefetchFromCtx(ctx services.Context) bool {
+	prefetch, _ := strconv.ParseBool(ctx["githubPrefetch"])
+	return prefetch
+}
+
+// GetGithubLenientFromCtx looks up the githubArgs key in the supplied context and reports whether it's set to lenient,
+// otherwise missing arguments are reported strictly
+func GetGithubLenientFromCtx(ctx services.Context) bool {
+	return ctx["githubArgs"] == "lenient"
+}
+
+// GetGithubExcludeBotsFromCtx looks up the githubExcludeBots key in the supplied context and reports whether it's set to true
+func GetGithubExcludeBotsFromCtx(ctx services.Context) bool {
+	exclude, _ := strconv.ParseBool(ctx["githubExcludeBots"])
+	return exclude
+}
+
 // GetGithubReqPerSecondFromCtx looks up the githubReqPerSec key in the supplied context and returns it if set,
 // otherwise it returns a default of 1
 func GetGithubReqPerSecondFromCtx(ctx services.Context) int {
@@ -43,6 +80,16 @@ func t1f0(b bool) int {
 	return 0
 }
 
+// resultTextOrNull sets s as the result of ctx, or NULL if it's empty, so that the fields of users whose account
+// was deleted (which GitHub returns as null) are NULL in every table, rather than sometimes empty
+func resultTextOrNull(ctx *sqlite.Context, s string) {
+	if s == "" {
+		ctx.ResultNull()
+	} else {
+		ctx.ResultText(s)
+	}
+}
+
 // orderByToGitHubOrder is a helper that takes a boolean indicating whether DESC or ASC and returns
 // a corresponding OrderDirection from the githubv4 library
 func orderByToGitHubOrder(desc bool) githubv4.OrderDirection {
@@ -67,3 +114,24 @@ func repoOwnerAndName(name, fullNameOrOwner string) (string, string, error) {
 		return fullNameOrOwner, name, nil
 	}
 }
+
+// requireArgs checks that the required arguments of a table, given as pairs of names and values, are set.
+// If one isn't, it returns an error naming it, or in lenient mode an iterator over no rows, and nil otherwise.
+func requireArgs(opts *Options, table string, namesAndValues ...string) (vtab.Iterator, error) {
+	for i := 0; i+1 < len(namesAndValues); i += 2 {
+		if namesAndValues[i+1] != "" {
+			continue
+		}
+		if opts.Lenient {
+			return empty{}, nil
+		}
+		return nil, fmt.Errorf("%s requires %s = ..., e.g. SELECT * FROM %s('...')", table, namesAndValues[i], table)
+	}
+	return nil, nil
+}
+
+// empty is an iterator over no rows
+type empty struct{}
+
+func (empty) Column(*sqlite.Context, int) error { return nil }
+func (empty) Next() (vtab.Row, error)           { return nil, io.EOF }
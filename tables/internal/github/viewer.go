@@ -0,0 +1,43 @@
+package github
+
+import (
+	"context"
+	"sync"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// viewer caches the login of the authenticated user. Its zero value is ready to use.
+type viewer struct {
+	mu    sync.Mutex
+	login string
+}
+
+// viewerLogin returns the login of the authenticated user (the viewer), looked up once,
+// which user-scoped tables default to when their login is left out, as the gh CLI does
+func (opts *Options) viewerLogin(ctx context.Context, client *githubv4.Client) (string, error) {
+	opts.viewer.mu.Lock()
+	defer opts.viewer.mu.Unlock()
+	if opts.viewer.login != "" {
+		return opts.viewer.login, nil
+	}
+
+	if opts.RateLimiter != nil {
+		if err := opts.RateLimiter.Wait(ctx, "github_viewer", Interactive); err != nil {
+			return "", err
+		}
+	}
+
+	var query struct {
+		Viewer struct {
+			Login string
+		}
+	}
+	if err := client.Query(ctx, &query, nil); err != nil {
+		return "", err
+	}
+
+	opts.viewer.login = query.Viewer.Login
+	opts.owners.set(query.Viewer.Login, ownerUser)
+	return opts.viewer.login, nil
+}
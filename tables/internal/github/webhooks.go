@@ -0,0 +1,108 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+type webhook struct {
+	ID     int64    `json:"id"`
+	Name   string   `json:"name"`
+	Active bool     `json:"active"`
+	Events []string `json:"events"`
+	Config struct {
+		URL         string      `json:"url"`
+		ContentType string      `json:"content_type"`
+		InsecureSSL interface{} `json:"insecure_ssl"` // "0" or "1", though sometimes a number
+	} `json:"config"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	LastResponse struct {
+		Code    *int   `json:"code"`
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	} `json:"last_response"`
+}
+
+// webhooksRows decodes a page of the webhooks of a repository into rows of webhooksCols, after the arguments of the table.
+// Only the host of the URL of hooks is kept, as their path or query often hold a secret.
+func webhooksRows(body []byte, args ...interface{}) ([][]interface{}, error) {
+	var hooks []*webhook
+	if err := json.Unmarshal(body, &hooks); err != nil {
+		return nil, fmt.Errorf("failed to decode webhooks: %v", err)
+	}
+
+	var rows = make([][]interface{}, 0, len(hooks))
+	for _, h := range hooks {
+		var host, scheme interface{}
+		if u, err := url.Parse(h.Config.URL); err == nil && u.Host != "" {
+			host, scheme = u.Host, u.Scheme
+		}
+
+		var code interface{}
+		if h.LastResponse.Code != nil {
+			code = *h.LastResponse.Code
+		}
+
+		var insecure = fmt.Sprint(h.Config.InsecureSSL) == "1"
+		rows = append(rows, append(args[:len(args):len(args)],
+			h.ID, h.Name, host, scheme, h.Events, h.Active, stringOrNil(h.Config.ContentType), insecure,
+			h.CreatedAt, h.UpdatedAt, code, stringOrNil(h.LastResponse.Status), stringOrNil(h.LastResponse.Message)))
+	}
+	return rows, nil
+}
+
+var webhooksCols = []vtab.Column{
+	{Name: "owner", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "reponame", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: sqlite.SQLITE_INTEGER},
+	{Name: "name", Type: sqlite.SQLITE_TEXT},
+	{Name: "url_host", Type: sqlite.SQLITE_TEXT},
+	{Name: "url_scheme", Type: sqlite.SQLITE_TEXT},
+	{Name: "events", Type: sqlite.SQLITE_TEXT},
+	{Name: "active", Type: sqlite.SQLITE_INTEGER},
+	{Name: "content_type", Type: sqlite.SQLITE_TEXT},
+	{Name: "insecure_ssl", Type: sqlite.SQLITE_INTEGER},
+	{Name: "created_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "updated_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "last_response_code", Type: sqlite.SQLITE_INTEGER},
+	{Name: "last_response_status", Type: sqlite.SQLITE_TEXT},
+	{Name: "last_response_message", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewWebhooksModule returns the implementation of a table-valued-function listing the webhooks of a repository,
+// which only the REST API exposes
+func NewWebhooksModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_repo_webhooks", webhooksCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var fullNameOrOwner, name string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && !constraint.Value.IsNil() {
+				switch constraint.ColIndex {
+				case 0:
+					fullNameOrOwner = constraint.Value.Text()
+				case 1:
+					name = constraint.Value.Text()
+				}
+			}
+		}
+
+		if iter, err := requireArgs(opts, "github_repo_webhooks", "owner", fullNameOrOwner); iter != nil || err != nil {
+			return iter, err
+		}
+
+		owner, repo, err := repoOwnerAndName(name, fullNameOrOwner)
+		if err != nil {
+			return nil, err
+		}
+
+		var path = fmt.Sprintf("/repos/%s/%s/hooks?per_page=%d", url.PathEscape(owner), url.PathEscape(repo), maxPageSize)
+		return restPages(opts, "github_repo_webhooks", queryArgs("owner", owner, "reponame", repo), path, func(body []byte) ([][]interface{}, error) {
+			return webhooksRows(body, fullNameOrOwner, name)
+		}), nil
+	})
+}
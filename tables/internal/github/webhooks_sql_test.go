@@ -0,0 +1,34 @@
+package github_test
+
+import "testing"
+
+func TestWebhooks(t *testing.T) {
+	// the webhooks span two pages
+	defer serve(rest(t, map[string]page{
+		"/repos/octocat/Hello-World/hooks?per_page=100": {
+			body: `[{"id": 12345678, "name": "web", "active": true, "events": ["push", "pull_request"],
+				"config": {"content_type": "json", "insecure_ssl": "0", "url": "https://example.com/webhook/s3cr3t?token=abc"},
+				"updated_at": "2019-06-03T00:57:16Z", "created_at": "2019-06-03T00:57:16Z",
+				"last_response": {"code": 200, "status": "active", "message": "OK"}}]`,
+			next: "/repositories/1296269/hooks?per_page=100&page=2",
+		},
+		"/repositories/1296269/hooks?per_page=100&page=2": {
+			body: `[{"id": 12345679, "name": "web", "active": false, "events": ["*"],
+				"config": {"insecure_ssl": 1, "url": "http://ci.internal:8080/hook"},
+				"updated_at": "2020-01-01T00:00:00Z", "created_at": "2019-12-01T00:00:00Z",
+				"last_response": {"code": null, "status": "unused", "message": null}}]`,
+		},
+	}))()
+
+	db := Connect(t, Memory)
+
+	content, err := query(t, db, `SELECT id, name, url_host, url_scheme, events, active, content_type, insecure_ssl, created_at, updated_at,
+		last_response_code, last_response_status, last_response_message FROM github_repo_webhooks('octocat', 'Hello-World')`)
+	expectContent(t, content, err, [][]string{
+		{"12345678", "web", "example.com", "https", `["push","pull_request"]`, "1", "json", "0", "2019-06-03T00:57:16Z", "2019-06-03T00:57:16Z", "200", "active", "OK"},
+		{"12345679", "web", "ci.internal:8080", "http", `["*"]`, "0", "NULL", "1", "2019-12-01T00:00:00Z", "2020-01-01T00:00:00Z", "NULL", "unused", "NULL"},
+	})
+
+	_, err = query(t, db, "SELECT * FROM github_repo_webhooks")
+	expectError(t, err, "github_repo_webhooks requires owner")
+}
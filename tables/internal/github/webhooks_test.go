@@ -0,0 +1,42 @@
+package github
+
+import "testing"
+
+func TestWebhooksRows(t *testing.T) {
+	var body = `[
+		{
+			"type": "Repository",
+			"id": 12345678,
+			"name": "web",
+			"active": true,
+			"events": ["push", "pull_request"],
+			"config": {
+				"content_type": "json",
+				"insecure_ssl": "0",
+				"url": "https://example.com/webhook/s3cr3t?token=abc"
+			},
+			"updated_at": "2019-06-03T00:57:16Z",
+			"created_at": "2019-06-03T00:57:16Z",
+			"last_response": {"code": 200, "status": "active", "message": "OK"}
+		},
+		{
+			"type": "Repository",
+			"id": 12345679,
+			"name": "web",
+			"active": false,
+			"events": ["*"],
+			"config": {"insecure_ssl": 1, "url": "http://ci.internal:8080/hook"},
+			"updated_at": "2020-01-01T00:00:00Z",
+			"created_at": "2019-12-01T00:00:00Z",
+			"last_response": {"code": null, "status": "unused", "message": null}
+		}
+	]`
+
+	rows, err := webhooksRows([]byte(body), "octocat", "Hello-World")
+	expectRows(t, rows, err, [][]interface{}{
+		{"octocat", "Hello-World", int64(12345678), "web", "example.com", "https", []string{"push", "pull_request"}, true, "json", false,
+			timestamp(t, "2019-06-03T00:57:16Z"), timestamp(t, "2019-06-03T00:57:16Z"), 200, "active", "OK"},
+		{"octocat", "Hello-World", int64(12345679), "web", "ci.internal:8080", "http", []string{"*"}, false, nil, true,
+			timestamp(t, "2019-12-01T00:00:00Z"), timestamp(t, "2020-01-01T00:00:00Z"), nil, "unused", nil},
+	})
+}
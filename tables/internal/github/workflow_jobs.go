@@ -0,0 +1,200 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+type workflowStep struct {
+	Number      int        `json:"number"`
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Conclusion  string     `json:"conclusion"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+type workflowJob struct {
+	ID           int64           `json:"id"`
+	RunID        int64           `json:"run_id"`
+	RunAttempt   int64           `json:"run_attempt"`
+	Name         string          `json:"name"`
+	WorkflowName string          `json:"workflow_name"`
+	HeadBranch   string          `json:"head_branch"`
+	Status       string          `json:"status"`
+	Conclusion   string          `json:"conclusion"`
+	CreatedAt    *time.Time      `json:"created_at"`
+	StartedAt    *time.Time      `json:"started_at"`
+	CompletedAt  *time.Time      `json:"completed_at"`
+	RunnerName   string          `json:"runner_name"`
+	Labels       []string        `json:"labels"`
+	Steps        []*workflowStep `json:"steps"`
+}
+
+// secondsBetween returns the seconds from start to end, or nil (to be NULL) if either isn't set,
+// as for the jobs and steps that didn't start or complete yet
+func secondsBetween(start, end *time.Time) interface{} {
+	if start == nil || end == nil || start.IsZero() || end.IsZero() {
+		return nil
+	}
+	return end.Sub(*start).Seconds()
+}
+
+// parseID parses the ID given as the argument called name of table
+func parseID(table, name, id string) (int64, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s requires %s to be a numeric ID, not %q", table, name, id)
+	}
+	return n, nil
+}
+
+// workflowJobsRows decodes a page of the jobs of a workflow run into rows of workflowJobsCols, after the arguments of the table
+func workflowJobsRows(body []byte, args ...interface{}) ([][]interface{}, error) {
+	var page struct {
+		Jobs []*workflowJob `json:"jobs"`
+	}
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, fmt.Errorf("failed to decode jobs: %v", err)
+	}
+
+	var rows = make([][]interface{}, 0, len(page.Jobs))
+	for _, j := range page.Jobs {
+		rows = append(rows, append(args[:len(args):len(args)], j.ID, j.RunAttempt, j.Name, stringOrNil(j.WorkflowName), stringOrNil(j.HeadBranch),
+			j.Status, stringOrNil(j.Conclusion), timeOrNil(j.CreatedAt), timeOrNil(j.StartedAt), timeOrNil(j.CompletedAt),
+			secondsBetween(j.CreatedAt, j.StartedAt), secondsBetween(j.StartedAt, j.CompletedAt), stringOrNil(j.RunnerName), j.Labels))
+	}
+	return rows, nil
+}
+
+var workflowJobsCols = []vtab.Column{
+	{Name: "owner", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "reponame", Type: sqlite.SQLITE_TEXT, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "run_id", Type: sqlite.SQLITE_INTEGER, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: sqlite.SQLITE_INTEGER},
+	{Name: "run_attempt", Type: sqlite.SQLITE_INTEGER},
+	{Name: "name", Type: sqlite.SQLITE_TEXT},
+	{Name: "workflow_name", Type: sqlite.SQLITE_TEXT},
+	{Name: "head_branch", Type: sqlite.SQLITE_TEXT},
+	{Name: "status", Type: sqlite.SQLITE_TEXT},
+	{Name: "conclusion", Type: sqlite.SQLITE_TEXT},
+	{Name: "created_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "started_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "completed_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "queued_seconds", Type: sqlite.SQLITE_FLOAT},
+	{Name: "duration_seconds", Type: sqlite.SQLITE_FLOAT},
+	{Name: "runner_name", Type: sqlite.SQLITE_TEXT},
+	{Name: "labels", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewWorkflowJobsModule returns the implementation of a table-valued-function listing the jobs of every attempt of a workflow run,
+// with how long they were queued for before starting, and then ran for
+func NewWorkflowJobsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_workflow_jobs", workflowJobsCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var fullNameOrOwner, name, runID string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && !constraint.Value.IsNil() {
+				switch constraint.ColIndex {
+				case 0:
+					fullNameOrOwner = constraint.Value.Text()
+				case 1:
+					name = constraint.Value.Text()
+				case 2:
+					runID = constraint.Value.Text()
+				}
+			}
+		}
+
+		if iter, err := requireArgs(opts, "github_workflow_jobs", "owner", fullNameOrOwner, "run_id", runID); iter != nil || err != nil {
+			return iter, err
+		}
+
+		id, err := parseID("github_workflow_jobs", "run_id", runID)
+		if err != nil {
+			return nil, err
+		}
+
+		owner, repo, err := repoOwnerAndName(name, fullNameOrOwner)
+		if err != nil {
+			return nil, err
+		}
+
+		var path = fmt.Sprintf("/repos/%s/%s/actions/runs/%d/jobs?filter=all&per_page=%d", url.PathEscape(owner), url.PathEscape(repo), id, maxPageSize)
+		return restPages(opts, "github_workflow_jobs", queryArgs("owner", owner, "reponame", repo, "run_id", runID), path, func(body []byte) ([][]interface{}, error) {
+			return workflowJobsRows(body, fullNameOrOwner, name, id)
+		}), nil
+	})
+}
+
+// workflowStepsRows decodes a job into rows of workflowStepsCols of its steps, after the arguments of the table
+func workflowStepsRows(body []byte, args ...interface{}) ([][]interface{}, error) {
+	var job workflowJob
+	if err := json.Unmarshal(body, &job); err != nil {
+		return nil, fmt.Errorf("failed to decode job: %v", err)
+	}
+
+	var rows = make([][]interface{}, 0, len(job.Steps))
+	for _, s := range job.Steps {
+		rows = append(rows, append(args[:len(args):len(args)], s.Number, s.Name, s.Status, stringOrNil(s.Conclusion),
+			timeOrNil(s.StartedAt), timeOrNil(s.CompletedAt), secondsBetween(s.StartedAt, s.CompletedAt)))
+	}
+	return rows, nil
+}
+
+var workflowStepsCols = []vtab.Column{
+	{Name: "owner", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "reponame", Type: sqlite.SQLITE_TEXT, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "job_id", Type: sqlite.SQLITE_INTEGER, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "number", Type: sqlite.SQLITE_INTEGER},
+	{Name: "name", Type: sqlite.SQLITE_TEXT},
+	{Name: "status", Type: sqlite.SQLITE_TEXT},
+	{Name: "conclusion", Type: sqlite.SQLITE_TEXT},
+	{Name: "started_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "completed_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "duration_seconds", Type: sqlite.SQLITE_FLOAT},
+}
+
+// NewWorkflowStepsModule returns the implementation of a table-valued-function listing the steps of a job of a workflow run,
+// with how long they ran for
+func NewWorkflowStepsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("github_workflow_steps", workflowStepsCols, func(constraints []*vtab.Constraint, orders []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var fullNameOrOwner, name, jobID string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && !constraint.Value.IsNil() {
+				switch constraint.ColIndex {
+				case 0:
+					fullNameOrOwner = constraint.Value.Text()
+				case 1:
+					name = constraint.Value.Text()
+				case 2:
+					jobID = constraint.Value.Text()
+				}
+			}
+		}
+
+		if iter, err := requireArgs(opts, "github_workflow_steps", "owner", fullNameOrOwner, "job_id", jobID); iter != nil || err != nil {
+			return iter, err
+		}
+
+		id, err := parseID("github_workflow_steps", "job_id", jobID)
+		if err != nil {
+			return nil, err
+		}
+
+		owner, repo, err := repoOwnerAndName(name, fullNameOrOwner)
+		if err != nil {
+			return nil, err
+		}
+
+		var path = fmt.Sprintf("/repos/%s/%s/actions/jobs/%d", url.PathEscape(owner), url.PathEscape(repo), id)
+		return restPages(opts, "github_workflow_steps", queryArgs("owner", owner, "reponame", repo, "job_id", jobID), path, func(body []byte) ([][]interface{}, error) {
+			return workflowStepsRows(body, fullNameOrOwner, name, id)
+		}), nil
+	})
+}
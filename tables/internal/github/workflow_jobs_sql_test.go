@@ -0,0 +1,51 @@
+package github_test
+
+import "testing"
+
+func TestWorkflowJobs(t *testing.T) {
+	// the jobs of the run span two pages, one for each of its attempts
+	defer serve(rest(t, map[string]page{
+		"/repos/octo-org/Hello-World/actions/runs/29679449/jobs?filter=all&per_page=100": {
+			body: `{"total_count": 2, "jobs": [{"id": 399444496, "run_id": 29679449, "run_attempt": 1, "name": "build", "workflow_name": "CI", "head_branch": "main",
+				"status": "completed", "conclusion": "success", "created_at": "2020-01-20T17:42:40Z", "started_at": "2020-01-20T17:42:45Z",
+				"completed_at": "2020-01-20T17:44:39Z", "runner_name": "my runner", "labels": ["ubuntu-latest"]}]}`,
+			next: "/repositories/1296269/actions/runs/29679449/jobs?filter=all&per_page=100&page=2",
+		},
+		"/repositories/1296269/actions/runs/29679449/jobs?filter=all&per_page=100&page=2": {
+			body: `{"total_count": 2, "jobs": [{"id": 399444497, "run_id": 29679449, "run_attempt": 2, "name": "build", "workflow_name": "CI", "head_branch": "main",
+				"status": "queued", "conclusion": null, "created_at": "2020-01-20T18:00:00Z", "started_at": null,
+				"completed_at": null, "runner_name": null, "labels": []}]}`,
+		},
+		"/repos/octo-org/Hello-World/actions/jobs/399444496": {
+			body: `{"id": 399444496, "run_id": 29679449, "status": "completed", "steps": [
+				{"number": 1, "name": "Set up job", "status": "completed", "conclusion": "success", "started_at": "2020-01-20T17:42:45Z", "completed_at": "2020-01-20T17:42:47Z"},
+				{"number": 2, "name": "Run tests", "status": "in_progress", "conclusion": null, "started_at": "2020-01-20T17:42:47Z", "completed_at": null}
+			]}`,
+		},
+		"/repos/octo-org/Hello-World/actions/jobs/399444497": {
+			body: `{"id": 399444497, "run_id": 29679449, "status": "queued", "steps": []}`,
+		},
+	}))()
+
+	db := Connect(t, Memory)
+
+	content, err := query(t, db, "SELECT * FROM github_workflow_jobs('octo-org', 'Hello-World', 29679449)")
+	expectContent(t, content, err, [][]string{
+		{"399444496", "1", "build", "CI", "main", "completed", "success", "2020-01-20T17:42:40Z", "2020-01-20T17:42:45Z", "2020-01-20T17:44:39Z", "5", "114", "my runner", `["ubuntu-latest"]`},
+		{"399444497", "2", "build", "CI", "main", "queued", "NULL", "2020-01-20T18:00:00Z", "NULL", "NULL", "NULL", "NULL", "NULL", "[]"},
+	})
+
+	// the steps of every job of the run
+	content, err = query(t, db, `SELECT j.id, s.number, s.name, s.status, s.conclusion, s.started_at, s.completed_at, s.duration_seconds
+		FROM github_workflow_jobs('octo-org/Hello-World', NULL, 29679449) j CROSS JOIN github_workflow_steps('octo-org/Hello-World', NULL, j.id) s`)
+	expectContent(t, content, err, [][]string{
+		{"399444496", "1", "Set up job", "completed", "success", "2020-01-20T17:42:45Z", "2020-01-20T17:42:47Z", "2"},
+		{"399444496", "2", "Run tests", "in_progress", "NULL", "2020-01-20T17:42:47Z", "NULL", "NULL"},
+	})
+
+	_, err = query(t, db, "SELECT * FROM github_workflow_jobs('octo-org/Hello-World')")
+	expectError(t, err, "github_workflow_jobs requires run_id")
+
+	_, err = query(t, db, "SELECT * FROM github_workflow_steps('octo-org/Hello-World', NULL, 'latest')")
+	expectError(t, err, `github_workflow_steps requires job_id to be a numeric ID, not "latest"`)
+}
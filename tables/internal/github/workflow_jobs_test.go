@@ -0,0 +1,123 @@
+package github
+
+import (
+	"testing"
+	"time"
+)
+
+// the response of the API for a job, the first of the jobs of its run
+const workflowJobBody = `{
+	"id": 399444496,
+	"run_id": 29679449,
+	"run_url": "https://api.github.com/repos/octo-org/octo-repo/actions/runs/29679449",
+	"node_id": "MDEyOldvcmtmbG93IEpvYjM5OTQ0NDQ5Ng==",
+	"head_sha": "f83a356604ae3c5d03e1b46ef4d1ca77d64a90b0",
+	"run_attempt": 1,
+	"status": "completed",
+	"conclusion": "success",
+	"created_at": "2020-01-20T17:42:20Z",
+	"started_at": "2020-01-20T17:42:40Z",
+	"completed_at": "2020-01-20T17:44:39Z",
+	"name": "build",
+	"workflow_name": "CI",
+	"head_branch": "main",
+	"steps": [
+		{
+			"name": "Set up job",
+			"status": "completed",
+			"conclusion": "success",
+			"number": 1,
+			"started_at": "2020-01-20T09:42:40.000-08:00",
+			"completed_at": "2020-01-20T09:42:41.000-08:00"
+		},
+		{
+			"name": "Run tests",
+			"status": "in_progress",
+			"conclusion": null,
+			"number": 2,
+			"started_at": "2020-01-20T09:42:41.000-08:00",
+			"completed_at": null
+		}
+	],
+	"labels": ["self-hosted", "gpu"],
+	"runner_id": 1,
+	"runner_name": "my runner"
+}`
+
+func TestWorkflowJobsRows(t *testing.T) {
+	var body = `{
+		"total_count": 2,
+		"jobs": [` + workflowJobBody + `,
+			{
+				"id": 399444497,
+				"run_id": 29679449,
+				"run_attempt": 2,
+				"status": "queued",
+				"conclusion": null,
+				"created_at": "2020-01-21T10:00:00Z",
+				"started_at": null,
+				"completed_at": null,
+				"name": "deploy",
+				"workflow_name": "CI",
+				"head_branch": "main",
+				"steps": [],
+				"labels": ["ubuntu-latest"],
+				"runner_name": null
+			}
+		]
+	}`
+
+	rows, err := workflowJobsRows([]byte(body), "octo-org/octo-repo", "", int64(29679449))
+	expectRows(t, rows, err, [][]interface{}{
+		{"octo-org/octo-repo", "", int64(29679449), int64(399444496), int64(1), "build", "CI", "main", "completed", "success",
+			timestamp(t, "2020-01-20T17:42:20Z"), timestamp(t, "2020-01-20T17:42:40Z"), timestamp(t, "2020-01-20T17:44:39Z"),
+			float64(20), float64(119), "my runner", []string{"self-hosted", "gpu"}},
+		{"octo-org/octo-repo", "", int64(29679449), int64(399444497), int64(2), "deploy", "CI", "main", "queued", nil,
+			timestamp(t, "2020-01-21T10:00:00Z"), nil, nil, nil, nil, nil, []string{"ubuntu-latest"}},
+	})
+}
+
+func TestWorkflowStepsRows(t *testing.T) {
+	rows, err := workflowStepsRows([]byte(workflowJobBody), "octo-org/octo-repo", "", int64(399444496))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 steps, got: %d", len(rows))
+	}
+
+	// timestamps are compared as instants, as their offsets differ
+	var first, second = rows[0], rows[1]
+	if first[3] != 1 || first[4] != "Set up job" || first[5] != "completed" || first[6] != "success" || first[9] != float64(1) {
+		t.Fatalf("unexpected step: %#v", first)
+	}
+	if started, ok := first[7].(time.Time); !ok || !started.Equal(timestamp(t, "2020-01-20T17:42:40Z")) {
+		t.Fatalf("unexpected start of the step: %v", first[7])
+	}
+	if second[4] != "Run tests" || second[6] != nil || second[8] != nil || second[9] != nil {
+		t.Fatalf("expected the step in progress not to have a conclusion nor a duration: %#v", second)
+	}
+}
+
+func TestSecondsBetween(t *testing.T) {
+	var start, end, zero = timestamp(t, "2020-01-20T17:42:20Z"), timestamp(t, "2020-01-20T17:44:20.5Z"), time.Time{}
+	if got := secondsBetween(&start, &end); got != 120.5 {
+		t.Fatalf("expected 120.5 seconds, got: %v", got)
+	}
+	for _, c := range [][2]*time.Time{{nil, &end}, {&start, nil}, {&zero, &end}, {&start, &zero}} {
+		if got := secondsBetween(c[0], c[1]); got != nil {
+			t.Fatalf("expected no duration between %v and %v, got: %v", c[0], c[1], got)
+		}
+	}
+}
+
+func TestParseID(t *testing.T) {
+	if id, err := parseID("github_workflow_jobs", "run_id", "29679449"); err != nil || id != 29679449 {
+		t.Fatalf("expected 29679449, got: %d (%v)", id, err)
+	}
+	for _, id := range []string{"", "0", "-1", "abc"} {
+		if _, err := parseID("github_workflow_jobs", "run_id", id); err == nil {
+			t.Fatalf("expected %q to be rejected", id)
+		}
+	}
+}
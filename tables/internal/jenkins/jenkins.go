@@ -0,0 +1,199 @@
+// Package jenkins implements a table over the builds of Jenkins jobs, read from the Jenkins JSON API
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+// Options configures the Jenkins tables
+type Options struct {
+	// Client is used to make requests to the Jenkins API
+	Client *rest.Client
+
+	// Context holds the jenkinsUser and jenkinsToken credentials, if any
+	Context services.Context
+}
+
+// perPage is the number of builds of a job fetched per request
+const perPage = 100
+
+// tree selects the fields of a job, and a range of its builds, returned by the API
+const tree = "name,fullName,url,jobs[url],allBuilds[number,result,building,duration,timestamp,url," +
+	"actions[_class,causes[shortDescription,userId,userName],parameters[name,value]]]{%d,%d}"
+
+type cause struct {
+	ShortDescription string `json:"shortDescription"`
+	UserID           string `json:"userId"`
+	UserName         string `json:"userName"`
+}
+
+type build struct {
+	Number    int    `json:"number"`
+	Result    string `json:"result"`
+	Building  bool   `json:"building"`
+	Duration  int64  `json:"duration"`
+	Timestamp int64  `json:"timestamp"`
+	URL       string `json:"url"`
+	Actions   []*struct {
+		Class      string   `json:"_class"`
+		Causes     []*cause `json:"causes"`
+		Parameters []*struct {
+			Name  string      `json:"name"`
+			Value interface{} `json:"value"`
+		} `json:"parameters"`
+	} `json:"actions"`
+}
+
+type job struct {
+	Name     string `json:"name"`
+	FullName string `json:"fullName"`
+	URL      string `json:"url"`
+	Jobs     []*struct {
+		URL string `json:"url"`
+	} `json:"jobs"`
+	AllBuilds []*build `json:"allBuilds"`
+}
+
+type fetchJobOptions struct {
+	Client *rest.Client
+	URL    string
+	Start  int
+}
+
+func fetchJob(ctx context.Context, input *fetchJobOptions) (*job, error) {
+	var endpoint = strings.TrimSuffix(input.URL, "/") + "/api/json?tree=" + url.QueryEscape(fmt.Sprintf(tree, input.Start, input.Start+perPage))
+
+	var j job
+	if err := input.Client.GetJSON(ctx, endpoint, &j); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// triggeredBy returns the first cause of a build, and the id of the user who started it, if any
+func (b *build) triggeredBy() (string, string) {
+	for _, action := range b.Actions {
+		for _, c := range action.Causes {
+			if c.UserName != "" {
+				return c.UserName, c.UserID
+			}
+			return c.ShortDescription, c.UserID
+		}
+	}
+	return "", ""
+}
+
+// parameters returns the parameters the build was started with, or nil if there aren't any
+func (b *build) parameters() map[string]interface{} {
+	var params map[string]interface{}
+	for _, action := range b.Actions {
+		for _, p := range action.Parameters {
+			if params == nil {
+				params = make(map[string]interface{})
+			}
+			params[p.Name] = p.Value
+		}
+	}
+	return params
+}
+
+var buildsCols = []vtab.Column{
+	{Name: "job_url", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "job", Type: sqlite.SQLITE_TEXT},
+	{Name: "number", Type: sqlite.SQLITE_INTEGER},
+	{Name: "result", Type: sqlite.SQLITE_TEXT},
+	{Name: "building", Type: sqlite.SQLITE_INTEGER},
+	{Name: "duration_ms", Type: sqlite.SQLITE_INTEGER},
+	{Name: "started_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "triggered_by", Type: sqlite.SQLITE_TEXT},
+	{Name: "triggered_by_user", Type: sqlite.SQLITE_TEXT},
+	{Name: "parameters", Type: sqlite.SQLITE_TEXT},
+	{Name: "url", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewBuildsModule returns the implementation of a table-valued-function listing the builds of a Jenkins job.
+// When the URL is that of a folder (or multibranch pipeline), the builds of every job under it are listed.
+func NewBuildsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("jenkins_builds", buildsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var jobURL string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && constraint.ColIndex == 0 {
+				jobURL = constraint.Value.Text()
+			}
+		}
+
+		return rest.NewIterator(buildPages(opts, jobURL)), nil
+	})
+}
+
+// buildPages returns a function walking the jobs under jobURL depth-first, returning a page of builds at a time
+func buildPages(opts *Options, jobURL string) rest.PageFunc {
+	var client = opts.Client
+	if user, token := opts.Context["jenkinsUser"], opts.Context["jenkinsToken"]; user != "" {
+		client = opts.Client.With(func(req *http.Request) { req.SetBasicAuth(user, token) })
+	}
+
+	type page struct {
+		url   string
+		start int
+	}
+	var queue = []page{{url: jobURL}}
+
+	return func(ctx context.Context) ([][]interface{}, bool, error) {
+		var next = queue[0]
+		queue = queue[1:]
+
+		j, err := fetchJob(ctx, &fetchJobOptions{Client: client, URL: next.url, Start: next.start})
+		if err != nil {
+			return nil, false, err
+		}
+
+		// the child jobs of folders are visited once, along with the first page of builds of the folder (which has none)
+		if next.start == 0 {
+			var children []page
+			for _, child := range j.Jobs {
+				children = append(children, page{url: child.URL})
+			}
+			queue = append(children, queue...)
+		}
+		if len(j.AllBuilds) == perPage {
+			queue = append([]page{{url: next.url, start: next.start + perPage}}, queue...)
+		}
+
+		var rows [][]interface{}
+		for _, b := range j.AllBuilds {
+			var triggeredBy, user = b.triggeredBy()
+
+			var params interface{}
+			if p := b.parameters(); p != nil {
+				encoded, err := json.Marshal(p)
+				if err != nil {
+					return nil, false, err
+				}
+				params = string(encoded)
+			}
+
+			var result interface{} = b.Result
+			if b.Result == "" {
+				result = nil // builds still running don't have a result yet
+			}
+
+			rows = append(rows, []interface{}{
+				jobURL, j.FullName, b.Number, result, b.Building, b.Duration, time.Unix(0, b.Timestamp*int64(time.Millisecond)).UTC(),
+				triggeredBy, user, params, b.URL,
+			})
+		}
+		return rows, len(queue) > 0, nil
+	}
+}
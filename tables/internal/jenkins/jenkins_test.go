@@ -0,0 +1,68 @@
+package jenkins
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+)
+
+func TestBuildPages(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, token, _ := r.BasicAuth(); user != "ci" || token != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !strings.Contains(r.URL.Query().Get("tree"), "allBuilds[") {
+			t.Errorf("unexpected tree: %s", r.URL.Query().Get("tree"))
+		}
+
+		switch r.URL.Path {
+		case "/job/folder/api/json":
+			fmt.Fprintf(w, `{"fullName": "folder", "jobs": [{"url": "%s/job/folder/job/app/"}]}`, srv.URL)
+		case "/job/folder/job/app/api/json":
+			_, _ = w.Write([]byte(`{"fullName": "folder/app", "allBuilds": [
+				{"number": 2, "building": true, "timestamp": 1614592800000},
+				{"number": 1, "result": "SUCCESS", "duration": 60000, "timestamp": 1614589200000, "actions": [
+					{"_class": "hudson.model.CauseAction", "causes": [{"shortDescription": "Started by user Ada", "userId": "ada", "userName": "Ada"}]},
+					{"_class": "hudson.model.ParametersAction", "parameters": [{"name": "DEPLOY", "value": true}]}
+				]}
+			]}`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	var opts = &Options{Client: &rest.Client{}, Context: services.Context{"jenkinsUser": "ci", "jenkinsToken": "secret"}}
+	var next = buildPages(opts, srv.URL+"/job/folder/")
+
+	var rows [][]interface{}
+	for more := true; more; {
+		var page [][]interface{}
+		var err error
+		if page, more, err = next(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, page...)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 builds, got: %d", len(rows))
+	}
+
+	var build = rows[1]
+	if build[1] != "folder/app" || build[3] != "SUCCESS" || build[7] != "Ada" || build[8] != "ada" || build[9] != `{"DEPLOY":true}` {
+		t.Fatalf("unexpected build: %v", build)
+	}
+	if rows[0][3] != nil {
+		t.Fatalf("expected a running build to have no result, got: %v", rows[0][3])
+	}
+}
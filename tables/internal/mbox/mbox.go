@@ -0,0 +1,198 @@
+// Package mbox implements a table over the patches of an mbox file, as sent to the mailing lists of projects
+// (such as the Linux kernel) reviewing changes by email, so they can be queried as if they were commits
+package mbox
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// patch is an email carrying a patch, formatted by git format-patch
+type patch struct {
+	MessageID   string
+	InReplyTo   string
+	AuthorName  string
+	AuthorEmail string
+	Date        time.Time
+	Subject     string
+	Version     int
+	Number      int
+	Total       int
+	Message     string
+	Files       int
+	Additions   int
+	Deletions   int
+	Diff        string
+}
+
+// subjectPrefix matches the prefix git format-patch adds to subjects, such as "[PATCH v2 3/7]" or "[RFC PATCH net-next]"
+var subjectPrefix = regexp.MustCompile(`^\s*(?:\[[^\]]*\]\s*)+`)
+var version = regexp.MustCompile(`(?i)\bv(\d+)\b`)
+var series = regexp.MustCompile(`\b(\d+)/(\d+)\b`)
+
+// splitMessages splits an mbox into its messages, undoing the quoting of lines starting with "From " (mboxrd)
+func splitMessages(r io.Reader) ([][]byte, error) {
+	var messages []*bytes.Buffer
+
+	var scanner = bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var line = scanner.Text()
+		if strings.HasPrefix(line, "From ") {
+			messages = append(messages, new(bytes.Buffer))
+			continue
+		}
+		if len(messages) == 0 {
+			continue // anything before the first separator isn't a message
+		}
+
+		if trimmed := strings.TrimLeft(line, ">"); len(trimmed) < len(line) && strings.HasPrefix(trimmed, "From ") {
+			line = line[1:]
+		}
+		var cur = messages[len(messages)-1]
+		cur.WriteString(line)
+		cur.WriteByte('\n')
+	}
+
+	var raw = make([][]byte, 0, len(messages))
+	for _, m := range messages {
+		if m.Len() > 0 {
+			raw = append(raw, m.Bytes())
+		}
+	}
+	return raw, scanner.Err()
+}
+
+// decodeBody returns the body of msg, decoding any quoted-printable or base64 transfer encoding
+func decodeBody(msg *mail.Message) (string, error) {
+	var r = msg.Body
+	switch strings.ToLower(strings.TrimSpace(msg.Header.Get("Content-Transfer-Encoding"))) {
+	case "quoted-printable":
+		r = quotedprintable.NewReader(r)
+	case "base64":
+		r = base64.NewDecoder(base64.StdEncoding, r)
+	}
+	b, err := ioutil.ReadAll(r)
+	return string(b), err
+}
+
+// parseSubject splits a subject into the actual title of the patch, and its version and position in its series
+func parseSubject(subject string) (title string, v, n, total int) {
+	var prefix = subjectPrefix.FindString(subject)
+	title = strings.TrimSpace(subject[len(prefix):])
+
+	v = 1
+	if m := version.FindStringSubmatch(prefix); m != nil {
+		v, _ = strconv.Atoi(m[1])
+	}
+	if m := series.FindStringSubmatch(prefix); m != nil {
+		n, _ = strconv.Atoi(m[1])
+		total, _ = strconv.Atoi(m[2])
+	} else {
+		n, total = 1, 1
+	}
+	return title, v, n, total
+}
+
+// parsePatch parses a message, returning nil if it doesn't carry a patch (such as cover letters and replies)
+func parsePatch(raw []byte) (*patch, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := decodeBody(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffStart = strings.Index(body, "\ndiff --git ")
+	if diffStart < 0 {
+		return nil, nil
+	}
+	var p = &patch{
+		MessageID: strings.Trim(msg.Header.Get("Message-Id"), "<> "),
+		InReplyTo: strings.Trim(msg.Header.Get("In-Reply-To"), "<> "),
+		Diff:      body[diffStart+1:],
+	}
+
+	var dec = new(mime.WordDecoder)
+	var subject = msg.Header.Get("Subject")
+	if decoded, err := dec.DecodeHeader(subject); err == nil {
+		subject = decoded
+	}
+	p.Subject, p.Version, p.Number, p.Total = parseSubject(subject)
+
+	if from, err := mail.ParseAddress(msg.Header.Get("From")); err == nil {
+		p.AuthorName, p.AuthorEmail = from.Name, from.Address
+	}
+	p.Date, _ = msg.Header.Date()
+
+	// the commit message ends at the "---" line separating it from the diffstat
+	var message = "\n" + body[:diffStart+1]
+	if i := strings.Index(message, "\n---\n"); i >= 0 {
+		message = message[:i]
+	}
+	p.Message = strings.TrimSpace(message)
+
+	p.Files, p.Additions, p.Deletions = diffStat(p.Diff)
+	return p, nil
+}
+
+// diffStat counts the files changed, and lines added and removed by a diff
+func diffStat(diff string) (files, additions, deletions int) {
+	var inHunk bool
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files++
+			inHunk = false
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case line == "-- " || line == "--":
+			inHunk = false // the signature git format-patch appends
+		case inHunk && strings.HasPrefix(line, "+"):
+			additions++
+		case inHunk && strings.HasPrefix(line, "-"):
+			deletions++
+		}
+	}
+	return files, additions, deletions
+}
+
+// readPatches returns the patches in the mbox file at path
+func readPatches(path string) ([]*patch, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	messages, err := splitMessages(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var patches []*patch
+	for _, raw := range messages {
+		p, err := parsePatch(raw)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			patches = append(patches, p)
+		}
+	}
+	return patches, nil
+}
@@ -0,0 +1,122 @@
+package mbox
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testSeries = `From 4f1b2c Mon Sep 17 00:00:00 2001
+From: Jane Doe <jane@example.com>
+Date: Tue, 3 Aug 2021 10:00:00 +0200
+Subject: [PATCH v2 0/2] Teach widgets to frob
+Message-Id: <cover.jane@example.com>
+
+This series teaches widgets to frob.
+
+From 5a6b7c Mon Sep 17 00:00:00 2001
+From: =?UTF-8?q?J=C3=B6rg=20M=C3=BCller?= <jorg@example.com>
+Date: Tue, 3 Aug 2021 10:00:01 +0200
+Subject: [PATCH v2 1/2] widget: add frob()
+Message-Id: <1.jorg@example.com>
+In-Reply-To: <cover.jane@example.com>
+
+Frobbing was missing.
+>From now on, widgets frob.
+
+Signed-off-by: Jörg Müller <jorg@example.com>
+---
+ widget.c | 3 ++-
+ 1 file changed, 2 insertions(+), 1 deletion(-)
+
+diff --git a/widget.c b/widget.c
+index 1111111..2222222 100644
+--- a/widget.c
++++ b/widget.c
+@@ -1,3 +1,4 @@
+ int widget(void)
+-{ return 0; }
++{ return 1; }
++int frob(void) { return 2; }
+-- 
+2.32.0
+
+From 8d9e0f Mon Sep 17 00:00:00 2001
+From: Jane Doe <jane@example.com>
+Date: Tue, 3 Aug 2021 10:00:02 +0200
+Subject: [PATCH v2 2/2] docs: describe frobbing
+Message-Id: <2.jane@example.com>
+In-Reply-To: <cover.jane@example.com>
+
+---
+diff --git a/README b/README
+new file mode 100644
+--- /dev/null
++++ b/README
+@@ -0,0 +1 @@
++Widgets frob.
+diff --git a/NEWS b/NEWS
+--- a/NEWS
++++ b/NEWS
+@@ -1 +1 @@
+-nothing
++frobbing
+`
+
+func TestReadPatches(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "series.mbox")
+	if err = ioutil.WriteFile(path, []byte(testSeries), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	patches, err := readPatches(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patches) != 2 {
+		t.Fatalf("expected the 2 patches of the series, got: %d", len(patches))
+	}
+
+	var p = patches[0]
+	if p.Subject != "widget: add frob()" || p.Version != 2 || p.Number != 1 || p.Total != 2 {
+		t.Fatalf("unexpected subject: %q v%d %d/%d", p.Subject, p.Version, p.Number, p.Total)
+	}
+	if p.AuthorName != "Jörg Müller" || p.AuthorEmail != "jorg@example.com" || p.InReplyTo != "cover.jane@example.com" {
+		t.Fatalf("unexpected author: %q <%s>", p.AuthorName, p.AuthorEmail)
+	}
+	if p.Message != "Frobbing was missing.\nFrom now on, widgets frob.\n\nSigned-off-by: Jörg Müller <jorg@example.com>" {
+		t.Fatalf("unexpected message: %q", p.Message)
+	}
+	if p.Files != 1 || p.Additions != 2 || p.Deletions != 1 {
+		t.Fatalf("unexpected diffstat: %d files, +%d -%d", p.Files, p.Additions, p.Deletions)
+	}
+
+	p = patches[1]
+	if p.Message != "" || p.Files != 2 || p.Additions != 2 || p.Deletions != 1 {
+		t.Fatalf("unexpected patch: %q, %d files, +%d -%d", p.Message, p.Files, p.Additions, p.Deletions)
+	}
+}
+
+func TestParseSubject(t *testing.T) {
+	var tests = []struct {
+		subject, title    string
+		version, n, total int
+	}{
+		{"[PATCH] fix it", "fix it", 1, 1, 1},
+		{"[RFC PATCH net-next v3 04/12] net: fix it", "net: fix it", 3, 4, 12},
+		{"Re: [PATCH] fix it", "Re: [PATCH] fix it", 1, 1, 1},
+	}
+	for _, test := range tests {
+		title, v, n, total := parseSubject(test.subject)
+		if title != test.title || v != test.version || n != test.n || total != test.total {
+			t.Fatalf("unexpected parse of %q: %q v%d %d/%d", test.subject, title, v, n, total)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package mbox
+
+import (
+	"context"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+var patchesCols = []vtab.Column{
+	{Name: "path", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "message_id", Type: sqlite.SQLITE_TEXT},
+	{Name: "in_reply_to", Type: sqlite.SQLITE_TEXT},
+	{Name: "author_name", Type: sqlite.SQLITE_TEXT},
+	{Name: "author_email", Type: sqlite.SQLITE_TEXT},
+	{Name: "author_when", Type: sqlite.SQLITE_TEXT},
+	{Name: "subject", Type: sqlite.SQLITE_TEXT},
+	{Name: "version", Type: sqlite.SQLITE_INTEGER},
+	{Name: "patch_number", Type: sqlite.SQLITE_INTEGER},
+	{Name: "patch_total", Type: sqlite.SQLITE_INTEGER},
+	{Name: "message", Type: sqlite.SQLITE_TEXT},
+	{Name: "files_changed", Type: sqlite.SQLITE_INTEGER},
+	{Name: "additions", Type: sqlite.SQLITE_INTEGER},
+	{Name: "deletions", Type: sqlite.SQLITE_INTEGER},
+	{Name: "diff", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewPatchesModule returns the implementation of a table-valued-function listing the patches of a local mbox file
+func NewPatchesModule() sqlite.Module {
+	return vtab.NewTableFunc("mbox_patches", patchesCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var path string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && constraint.ColIndex == 0 {
+				path = constraint.Value.Text()
+			}
+		}
+
+		return rest.NewIterator(func(context.Context) ([][]interface{}, bool, error) {
+			patches, err := readPatches(path)
+			if err != nil {
+				return nil, false, err
+			}
+
+			var rows = make([][]interface{}, 0, len(patches))
+			for _, p := range patches {
+				rows = append(rows, []interface{}{
+					path, p.MessageID, nullable(p.InReplyTo), p.AuthorName, p.AuthorEmail, p.Date, p.Subject,
+					p.Version, p.Number, p.Total, p.Message, p.Files, p.Additions, p.Deletions, p.Diff,
+				})
+			}
+			return rows, false, nil
+		}), nil
+	})
+}
+
+// nullable returns nil for empty strings, so they're NULL in SQL
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
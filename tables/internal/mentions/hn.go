@@ -0,0 +1,120 @@
+package mentions
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+// hnURL is the base URL of the Algolia powered Hacker News search API
+const hnURL = "https://hn.algolia.com/api/v1"
+
+type hit struct {
+	ObjectID    string   `json:"objectID"`
+	Title       string   `json:"title"`
+	StoryTitle  string   `json:"story_title"`
+	URL         string   `json:"url"`
+	StoryURL    string   `json:"story_url"`
+	Author      string   `json:"author"`
+	Points      int      `json:"points"`
+	NumComments int      `json:"num_comments"`
+	CreatedAtI  int64    `json:"created_at_i"`
+	Tags        []string `json:"_tags"`
+}
+
+// kind returns whether the hit is a story or a comment
+func (h *hit) kind() string {
+	for _, tag := range h.Tags {
+		switch tag {
+		case "story", "comment", "poll", "job":
+			return tag
+		}
+	}
+	return ""
+}
+
+type fetchHitsOptions struct {
+	Client *rest.Client
+	URL    string
+	Query  string
+	Page   int
+}
+
+type fetchHitsResults struct {
+	Hits  []*hit `json:"hits"`
+	Page  int    `json:"page"`
+	Pages int    `json:"nbPages"`
+}
+
+func fetchHits(ctx context.Context, input *fetchHitsOptions) (*fetchHitsResults, error) {
+	var params = url.Values{
+		"query":       {input.Query},
+		"tags":        {"(story,comment)"},
+		"hitsPerPage": {fmt.Sprint(perPage)},
+		"page":        {fmt.Sprint(input.Page)},
+	}
+
+	var results fetchHitsResults
+	if err := input.Client.GetJSON(ctx, input.URL+"/search_by_date?"+params.Encode(), &results); err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+var hnCols = []vtab.Column{
+	{Name: "query", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "id", Type: sqlite.SQLITE_TEXT},
+	{Name: "type", Type: sqlite.SQLITE_TEXT},
+	{Name: "title", Type: sqlite.SQLITE_TEXT},
+	{Name: "url", Type: sqlite.SQLITE_TEXT},
+	{Name: "author", Type: sqlite.SQLITE_TEXT},
+	{Name: "points", Type: sqlite.SQLITE_INTEGER},
+	{Name: "comment_count", Type: sqlite.SQLITE_INTEGER},
+	{Name: "created_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "hn_url", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewHNSearchModule returns the implementation of a table-valued-function listing the Hacker News stories
+// and comments matching a search query, newest first
+func NewHNSearchModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("hn_search", hnCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var query string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && constraint.ColIndex == 0 {
+				query = constraint.Value.Text()
+			}
+		}
+
+		return rest.NewIterator(hnPages(opts, hnURL, query)), nil
+	})
+}
+
+func hnPages(opts *Options, baseURL, query string) rest.PageFunc {
+	var page int
+	return func(ctx context.Context) ([][]interface{}, bool, error) {
+		results, err := fetchHits(ctx, &fetchHitsOptions{Client: opts.Client, URL: baseURL, Query: query, Page: page})
+		if err != nil {
+			return nil, false, err
+		}
+		page++
+
+		var rows [][]interface{}
+		for _, h := range results.Hits {
+			// comments carry the title and link of the story they were posted on
+			var title, link = h.Title, h.URL
+			if title == "" {
+				title, link = h.StoryTitle, h.StoryURL
+			}
+
+			rows = append(rows, []interface{}{
+				query, h.ObjectID, h.kind(), title, link, h.Author, h.Points, h.NumComments, unix(h.CreatedAtI),
+				"https://news.ycombinator.com/item?id=" + h.ObjectID,
+			})
+		}
+		return rows, results.Page+1 < results.Pages, nil
+	}
+}
@@ -0,0 +1,26 @@
+// Package mentions implements tables over the posts mentioning a project on Hacker News and Reddit,
+// to measure the reach of announcements alongside star growth
+package mentions
+
+import (
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+)
+
+// Options configures the mentions tables
+type Options struct {
+	// Client is used to make requests to the Hacker News and Reddit APIs
+	Client *rest.Client
+}
+
+// perPage is the number of posts fetched per request
+const perPage = 100
+
+// unix converts a unix timestamp to a time, leaving unset timestamps zero
+func unix(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).UTC()
+}
@@ -0,0 +1,76 @@
+package mentions
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+)
+
+func collect(t *testing.T, next rest.PageFunc) [][]interface{} {
+	var rows [][]interface{}
+	for more := true; more; {
+		var page [][]interface{}
+		var err error
+		if page, more, err = next(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, page...)
+	}
+	return rows
+}
+
+func TestHNPages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search_by_date" || r.URL.Query().Get("query") != "askgit" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+
+		switch r.URL.Query().Get("page") {
+		case "0":
+			_, _ = w.Write([]byte(`{"hits": [{"objectID": "2", "story_title": "Show HN: askgit", "story_url": "https://askgit.com",
+				"_tags": ["comment", "author_ada"]}], "page": 0, "nbPages": 2}`))
+		case "1":
+			_, _ = w.Write([]byte(`{"hits": [{"objectID": "1", "title": "Show HN: askgit", "points": 120, "_tags": ["story"]}], "page": 1, "nbPages": 2}`))
+		}
+	}))
+	defer srv.Close()
+
+	var rows = collect(t, hnPages(&Options{Client: &rest.Client{}}, srv.URL, "askgit"))
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 hits, got: %d", len(rows))
+	}
+	if rows[0][2] != "comment" || rows[0][3] != "Show HN: askgit" || rows[1][2] != "story" || rows[1][6] != 120 {
+		t.Fatalf("unexpected hits: %v", rows)
+	}
+}
+
+func TestRedditPages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/r/golang/search.json" || r.URL.Query().Get("restrict_sr") != "1" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		if !strings.HasPrefix(r.Header.Get("User-Agent"), "askgit") {
+			t.Errorf("unexpected user agent: %s", r.Header.Get("User-Agent"))
+		}
+
+		switch r.URL.Query().Get("after") {
+		case "":
+			_, _ = w.Write([]byte(`{"data": {"children": [{"data": {"id": "b", "subreddit": "golang", "score": 42, "created_utc": 1614592800.0}}], "after": "t3_b"}}`))
+		case "t3_b":
+			_, _ = w.Write([]byte(`{"data": {"children": [{"data": {"id": "a", "subreddit": "golang"}}], "after": null}}`))
+		}
+	}))
+	defer srv.Close()
+
+	var rows = collect(t, redditPages(&Options{Client: &rest.Client{}}, srv.URL, "golang", "askgit"))
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 posts, got: %d", len(rows))
+	}
+	if rows[0][7] != 42 {
+		t.Fatalf("unexpected score: %v", rows[0][7])
+	}
+}
@@ -0,0 +1,122 @@
+package mentions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+// redditURL is the base URL of the Reddit JSON API
+const redditURL = "https://www.reddit.com"
+
+// userAgent identifies requests to Reddit, which throttles generic user agents
+const userAgent = "askgit (+https://github.com/askgitdev/askgit)"
+
+type post struct {
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	URL         string  `json:"url"`
+	Permalink   string  `json:"permalink"`
+	Author      string  `json:"author"`
+	Subreddit   string  `json:"subreddit"`
+	Score       int     `json:"score"`
+	NumComments int     `json:"num_comments"`
+	CreatedUTC  float64 `json:"created_utc"`
+}
+
+type fetchPostsOptions struct {
+	Client    *rest.Client
+	URL       string
+	Subreddit string
+	Query     string
+	After     string
+}
+
+type fetchPostsResults struct {
+	Data struct {
+		Children []*struct {
+			Data *post `json:"data"`
+		} `json:"children"`
+		After string `json:"after"`
+	} `json:"data"`
+}
+
+func fetchPosts(ctx context.Context, input *fetchPostsOptions) (*fetchPostsResults, error) {
+	var endpoint = input.URL + "/search.json"
+	var params = url.Values{"q": {input.Query}, "sort": {"new"}, "limit": {fmt.Sprint(perPage)}, "raw_json": {"1"}}
+	if input.Subreddit != "" && input.Subreddit != "all" {
+		endpoint = fmt.Sprintf("%s/r/%s/search.json", input.URL, url.PathEscape(input.Subreddit))
+		params.Set("restrict_sr", "1")
+	}
+	if input.After != "" {
+		params.Set("after", input.After)
+	}
+
+	var results fetchPostsResults
+	if err := input.Client.GetJSON(ctx, endpoint+"?"+params.Encode(), &results); err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+var redditCols = []vtab.Column{
+	{Name: "subreddit", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "query", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "id", Type: sqlite.SQLITE_TEXT},
+	{Name: "posted_in", Type: sqlite.SQLITE_TEXT},
+	{Name: "title", Type: sqlite.SQLITE_TEXT},
+	{Name: "url", Type: sqlite.SQLITE_TEXT},
+	{Name: "author", Type: sqlite.SQLITE_TEXT},
+	{Name: "score", Type: sqlite.SQLITE_INTEGER},
+	{Name: "comment_count", Type: sqlite.SQLITE_INTEGER},
+	{Name: "created_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "reddit_url", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewRedditSearchModule returns the implementation of a table-valued-function listing the Reddit posts
+// of a subreddit (or of all of Reddit, with 'all') matching a search query, newest first
+func NewRedditSearchModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("reddit_search", redditCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var subreddit, query string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					subreddit = constraint.Value.Text()
+				case 1:
+					query = constraint.Value.Text()
+				}
+			}
+		}
+
+		return rest.NewIterator(redditPages(opts, redditURL, subreddit, query)), nil
+	})
+}
+
+func redditPages(opts *Options, baseURL, subreddit, query string) rest.PageFunc {
+	var client = opts.Client.With(func(req *http.Request) { req.Header.Set("User-Agent", userAgent) })
+
+	var after string
+	return func(ctx context.Context) ([][]interface{}, bool, error) {
+		results, err := fetchPosts(ctx, &fetchPostsOptions{Client: client, URL: baseURL, Subreddit: subreddit, Query: query, After: after})
+		if err != nil {
+			return nil, false, err
+		}
+		after = results.Data.After
+
+		var rows [][]interface{}
+		for _, child := range results.Data.Children {
+			var p = child.Data
+			rows = append(rows, []interface{}{
+				subreddit, query, p.ID, p.Subreddit, p.Title, p.URL, p.Author, p.Score, p.NumComments, unix(int64(p.CreatedUTC)),
+				redditURL + p.Permalink,
+			})
+		}
+		return rows, after != "", nil
+	}
+}
@@ -0,0 +1,147 @@
+// Package opsgenie implements a table over the incidents of an Opsgenie account
+package opsgenie
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+// Options configures the Opsgenie tables
+type Options struct {
+	// Client is used to make requests to the Opsgenie REST API
+	Client *rest.Client
+
+	// Context holds the opsgenieKey API key, and the opsgenieURL of the API for accounts outside of the US region
+	Context services.Context
+}
+
+// apiURL is the default base URL of the Opsgenie REST API
+const apiURL = "https://api.opsgenie.com"
+
+// perPage is the number of incidents fetched per request
+const perPage = 100
+
+type incident struct {
+	ID               string     `json:"id"`
+	TinyID           string     `json:"tinyId"`
+	Message          string     `json:"message"`
+	Status           string     `json:"status"`
+	Priority         string     `json:"priority"`
+	OwnerTeam        string     `json:"ownerTeam"`
+	CreatedAt        *time.Time `json:"createdAt"`
+	ImpactEndDate    *time.Time `json:"impactEndDate"`
+	ImpactedServices []string   `json:"impactedServices"`
+	Responders       []*struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+		Name string `json:"name,omitempty"`
+	} `json:"responders"`
+	Tags  []string `json:"tags"`
+	Links struct {
+		Web string `json:"web"`
+	} `json:"links"`
+}
+
+type fetchIncidentsOptions struct {
+	Client *rest.Client
+	URL    string
+	Query  string
+	Offset int
+}
+
+type fetchIncidentsResults struct {
+	Incidents []*incident `json:"data"`
+	Paging    struct {
+		Next string `json:"next"`
+	} `json:"paging"`
+}
+
+func fetchIncidents(ctx context.Context, input *fetchIncidentsOptions) (*fetchIncidentsResults, error) {
+	var params = url.Values{"limit": {fmt.Sprint(perPage)}, "offset": {fmt.Sprint(input.Offset)}, "sort": {"createdAt"}, "order": {"asc"}}
+	if input.Query != "" {
+		params.Set("query", input.Query)
+	}
+
+	var results fetchIncidentsResults
+	if err := input.Client.GetJSON(ctx, strings.TrimSuffix(input.URL, "/")+"/v1/incidents?"+params.Encode(), &results); err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+var incidentsCols = []vtab.Column{
+	{Name: "query", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: sqlite.SQLITE_TEXT},
+	{Name: "number", Type: sqlite.SQLITE_TEXT},
+	{Name: "title", Type: sqlite.SQLITE_TEXT},
+	{Name: "services", Type: sqlite.SQLITE_TEXT},
+	{Name: "status", Type: sqlite.SQLITE_TEXT},
+	{Name: "priority", Type: sqlite.SQLITE_TEXT},
+	{Name: "owner_team", Type: sqlite.SQLITE_TEXT},
+	{Name: "created_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "resolved_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "responders", Type: sqlite.SQLITE_TEXT},
+	{Name: "tags", Type: sqlite.SQLITE_TEXT},
+	{Name: "url", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewIncidentsModule returns the implementation of a table-valued-function listing the incidents of an Opsgenie account,
+// optionally filtered with an Opsgenie search query, e.g. opsgenie_incidents('status:resolved')
+func NewIncidentsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("opsgenie_incidents", incidentsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var query string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ && constraint.ColIndex == 0 {
+				query = constraint.Value.Text()
+			}
+		}
+
+		var baseURL = opts.Context["opsgenieURL"]
+		if baseURL == "" {
+			baseURL = apiURL
+		}
+
+		return rest.NewIterator(incidentPages(opts, baseURL, query)), nil
+	})
+}
+
+func incidentPages(opts *Options, baseURL, query string) rest.PageFunc {
+	var key = opts.Context["opsgenieKey"]
+	var client = opts.Client.With(func(req *http.Request) { req.Header.Set("Authorization", "GenieKey "+key) })
+
+	var offset int
+	return func(ctx context.Context) ([][]interface{}, bool, error) {
+		results, err := fetchIncidents(ctx, &fetchIncidentsOptions{Client: client, URL: baseURL, Query: query, Offset: offset})
+		if err != nil {
+			return nil, false, err
+		}
+		offset += len(results.Incidents)
+
+		var rows [][]interface{}
+		for _, i := range results.Incidents {
+			var createdAt, resolvedAt time.Time
+			if i.CreatedAt != nil {
+				createdAt = *i.CreatedAt
+			}
+			// the end of the impact is when the incident was resolved, once it's no longer open
+			if i.ImpactEndDate != nil && (i.Status == "resolved" || i.Status == "closed") {
+				resolvedAt = *i.ImpactEndDate
+			}
+
+			rows = append(rows, []interface{}{
+				query, i.ID, i.TinyID, i.Message, i.ImpactedServices, i.Status, i.Priority, i.OwnerTeam,
+				createdAt, resolvedAt, i.Responders, i.Tags, i.Links.Web,
+			})
+		}
+		return rows, results.Paging.Next != "", nil
+	}
+}
@@ -0,0 +1,58 @@
+package opsgenie
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+)
+
+func TestIncidentPages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "GenieKey secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/v1/incidents" || r.URL.Query().Get("query") != "priority:P1" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+
+		switch r.URL.Query().Get("offset") {
+		case "0":
+			_, _ = w.Write([]byte(`{"data": [{"id": "a", "tinyId": "1", "status": "resolved", "createdAt": "2021-03-01T10:00:00Z",
+				"impactEndDate": "2021-03-01T10:45:00Z", "responders": [{"type": "team", "id": "t1"}]}],
+				"paging": {"next": "https://api.opsgenie.com/v1/incidents?offset=1"}}`))
+		case "1":
+			_, _ = w.Write([]byte(`{"data": [{"id": "b", "tinyId": "2", "status": "open", "impactEndDate": "2021-03-02T10:00:00Z"}], "paging": {}}`))
+		default:
+			t.Errorf("unexpected offset: %s", r.URL.Query().Get("offset"))
+		}
+	}))
+	defer srv.Close()
+
+	var next = incidentPages(&Options{Client: &rest.Client{}, Context: services.Context{"opsgenieKey": "secret"}}, srv.URL, "priority:P1")
+
+	var incidents [][]interface{}
+	for more := true; more; {
+		var page [][]interface{}
+		var err error
+		if page, more, err = next(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		incidents = append(incidents, page...)
+	}
+
+	if len(incidents) != 2 {
+		t.Fatalf("expected 2 incidents, got: %d", len(incidents))
+	}
+	if resolved := incidents[0][9].(time.Time); resolved.Sub(incidents[0][8].(time.Time)) != 45*time.Minute {
+		t.Fatalf("unexpected resolution time: %v", resolved)
+	}
+	if resolved := incidents[1][9].(time.Time); !resolved.IsZero() {
+		t.Fatalf("expected an open incident not to be resolved, got: %v", resolved)
+	}
+}
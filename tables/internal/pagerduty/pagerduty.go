@@ -0,0 +1,187 @@
+// Package pagerduty implements a table over the incidents of a PagerDuty account
+package pagerduty
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+// Options configures the PagerDuty tables
+type Options struct {
+	// Client is used to make requests to the PagerDuty REST API
+	Client *rest.Client
+
+	// Context holds the pagerdutyToken API key
+	Context services.Context
+}
+
+// apiURL is the base URL of the PagerDuty REST API
+const apiURL = "https://api.pagerduty.com"
+
+// perPage is the number of incidents fetched per request
+const perPage = 100
+
+type reference struct {
+	ID      string `json:"id"`
+	Summary string `json:"summary"`
+}
+
+type incident struct {
+	ID                 string     `json:"id"`
+	IncidentNumber     int        `json:"incident_number"`
+	Title              string     `json:"title"`
+	Status             string     `json:"status"`
+	Urgency            string     `json:"urgency"`
+	CreatedAt          *time.Time `json:"created_at"`
+	LastStatusChangeAt *time.Time `json:"last_status_change_at"`
+	HTMLURL            string     `json:"html_url"`
+	Service            reference  `json:"service"`
+	Priority           *reference `json:"priority"`
+	Assignments        []*struct {
+		Assignee reference `json:"assignee"`
+	} `json:"assignments"`
+	Acknowledgements []*struct {
+		Acknowledger reference `json:"acknowledger"`
+	} `json:"acknowledgements"`
+}
+
+// resolvedAt returns when the incident was resolved, which for resolved incidents is the time of their last status change
+func (i *incident) resolvedAt() time.Time {
+	if i.Status != "resolved" || i.LastStatusChangeAt == nil {
+		return time.Time{}
+	}
+	return *i.LastStatusChangeAt
+}
+
+// responders returns the names of the people the incident was assigned to or acknowledged by
+func (i *incident) responders() []string {
+	var names []string
+	var seen = make(map[string]bool)
+	var add = func(r reference) {
+		if r.Summary != "" && !seen[r.ID] {
+			seen[r.ID] = true
+			names = append(names, r.Summary)
+		}
+	}
+
+	for _, a := range i.Assignments {
+		add(a.Assignee)
+	}
+	for _, a := range i.Acknowledgements {
+		add(a.Acknowledger)
+	}
+	return names
+}
+
+type fetchIncidentsOptions struct {
+	Client *rest.Client
+	URL    string
+	Since  string
+	Until  string
+	Offset int
+}
+
+type fetchIncidentsResults struct {
+	Incidents   []*incident `json:"incidents"`
+	HasNextPage bool        `json:"more"`
+}
+
+func fetchIncidents(ctx context.Context, input *fetchIncidentsOptions) (*fetchIncidentsResults, error) {
+	var params = url.Values{"limit": {fmt.Sprint(perPage)}, "offset": {fmt.Sprint(input.Offset)}, "sort_by": {"created_at:asc"}}
+	// without a range, the API only returns the incidents of the last 30 days
+	if input.Since == "" && input.Until == "" {
+		params.Set("date_range", "all")
+	}
+	if input.Since != "" {
+		params.Set("since", input.Since)
+	}
+	if input.Until != "" {
+		params.Set("until", input.Until)
+	}
+
+	var results fetchIncidentsResults
+	if err := input.Client.GetJSON(ctx, input.URL+"/incidents?"+params.Encode(), &results); err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+var incidentsCols = []vtab.Column{
+	{Name: "since", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "until", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: sqlite.SQLITE_TEXT},
+	{Name: "number", Type: sqlite.SQLITE_INTEGER},
+	{Name: "title", Type: sqlite.SQLITE_TEXT},
+	{Name: "service", Type: sqlite.SQLITE_TEXT},
+	{Name: "status", Type: sqlite.SQLITE_TEXT},
+	{Name: "urgency", Type: sqlite.SQLITE_TEXT},
+	{Name: "priority", Type: sqlite.SQLITE_TEXT},
+	{Name: "created_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "resolved_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "responders", Type: sqlite.SQLITE_TEXT},
+	{Name: "url", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewIncidentsModule returns the implementation of a table-valued-function listing the incidents of a PagerDuty account,
+// optionally between two dates, e.g. pagerduty_incidents('2021-01-01', '2021-04-01')
+func NewIncidentsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("pagerduty_incidents", incidentsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var since, until string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					since = constraint.Value.Text()
+				case 1:
+					until = constraint.Value.Text()
+				}
+			}
+		}
+
+		return rest.NewIterator(incidentPages(opts, apiURL, since, until)), nil
+	})
+}
+
+func incidentPages(opts *Options, baseURL, since, until string) rest.PageFunc {
+	var token = opts.Context["pagerdutyToken"]
+	var client = opts.Client.With(func(req *http.Request) {
+		req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+		req.Header.Set("Authorization", "Token token="+token)
+	})
+
+	var offset int
+	return func(ctx context.Context) ([][]interface{}, bool, error) {
+		results, err := fetchIncidents(ctx, &fetchIncidentsOptions{Client: client, URL: baseURL, Since: since, Until: until, Offset: offset})
+		if err != nil {
+			return nil, false, err
+		}
+		offset += len(results.Incidents)
+
+		var rows [][]interface{}
+		for _, i := range results.Incidents {
+			var priority interface{}
+			if i.Priority != nil {
+				priority = i.Priority.Summary
+			}
+
+			var createdAt time.Time
+			if i.CreatedAt != nil {
+				createdAt = *i.CreatedAt
+			}
+
+			rows = append(rows, []interface{}{
+				since, until, i.ID, i.IncidentNumber, i.Title, i.Service.Summary, i.Status, i.Urgency, priority,
+				createdAt, i.resolvedAt(), i.responders(), i.HTMLURL,
+			})
+		}
+		return rows, results.HasNextPage, nil
+	}
+}
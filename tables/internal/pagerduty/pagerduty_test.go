@@ -0,0 +1,60 @@
+package pagerduty
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+)
+
+func TestIncidentPages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Token token=secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("date_range") != "all" {
+			t.Errorf("expected all incidents to be requested, got: %s", r.URL.RawQuery)
+		}
+
+		switch r.URL.Query().Get("offset") {
+		case "0":
+			_, _ = w.Write([]byte(`{"incidents": [{"id": "P1", "incident_number": 1, "status": "resolved", "urgency": "high",
+				"created_at": "2021-03-01T10:00:00Z", "last_status_change_at": "2021-03-01T11:30:00Z", "service": {"summary": "api"},
+				"assignments": [{"assignee": {"id": "U1", "summary": "Ada"}}],
+				"acknowledgements": [{"acknowledger": {"id": "U1", "summary": "Ada"}}, {"acknowledger": {"id": "U2", "summary": "Bob"}}]}],
+				"more": true}`))
+		case "1":
+			_, _ = w.Write([]byte(`{"incidents": [{"id": "P2", "incident_number": 2, "status": "triggered", "last_status_change_at": "2021-03-02T10:00:00Z"}], "more": false}`))
+		default:
+			t.Errorf("unexpected offset: %s", r.URL.Query().Get("offset"))
+		}
+	}))
+	defer srv.Close()
+
+	var next = incidentPages(&Options{Client: &rest.Client{}, Context: services.Context{"pagerdutyToken": "secret"}}, srv.URL, "", "")
+
+	var incidents [][]interface{}
+	for more := true; more; {
+		var page [][]interface{}
+		var err error
+		if page, more, err = next(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		incidents = append(incidents, page...)
+	}
+
+	if len(incidents) != 2 {
+		t.Fatalf("expected 2 incidents, got: %d", len(incidents))
+	}
+
+	if responders := incidents[0][11].([]string); len(responders) != 2 {
+		t.Fatalf("expected responders to be deduplicated, got: %v", responders)
+	}
+	if resolved := incidents[1][10].(interface{ IsZero() bool }); !resolved.IsZero() {
+		t.Fatalf("expected an open incident not to be resolved, got: %v", resolved)
+	}
+}
@@ -0,0 +1,194 @@
+// Package phabricator implements tables over the Differential revisions of a Phabricator instance,
+// read either from its Conduit API or from a JSON export, for organizations migrating their review history
+package phabricator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+)
+
+// Options configures the Phabricator tables
+type Options struct {
+	// Client is used to make requests to the Conduit API
+	Client *rest.Client
+
+	// Context holds the phabricatorToken Conduit API token
+	Context services.Context
+}
+
+// perPage is the number of revisions fetched per request, the maximum allowed by Conduit
+const perPage = 100
+
+type reviewer struct {
+	ReviewerPHID string `json:"reviewerPHID"`
+	Status       string `json:"status"`
+	IsBlocking   bool   `json:"isBlocking"`
+	ActorPHID    string `json:"actorPHID"`
+}
+
+type revision struct {
+	ID     int    `json:"id"`
+	PHID   string `json:"phid"`
+	Fields struct {
+		Title          string `json:"title"`
+		URI            string `json:"uri"`
+		AuthorPHID     string `json:"authorPHID"`
+		RepositoryPHID string `json:"repositoryPHID"`
+		DiffPHID       string `json:"diffPHID"`
+		Summary        string `json:"summary"`
+		TestPlan       string `json:"testPlan"`
+		Status         struct {
+			Value  string `json:"value"`
+			Name   string `json:"name"`
+			Closed bool   `json:"closed"`
+		} `json:"status"`
+		IsDraft      bool  `json:"isDraft"`
+		DateCreated  int64 `json:"dateCreated"`
+		DateModified int64 `json:"dateModified"`
+	} `json:"fields"`
+	Attachments struct {
+		Reviewers struct {
+			Reviewers []*reviewer `json:"reviewers"`
+		} `json:"reviewers"`
+	} `json:"attachments"`
+}
+
+// searchResponse is the response of differential.revision.search, which is also the format of exports
+type searchResponse struct {
+	Result struct {
+		Data   []*revision `json:"data"`
+		Cursor struct {
+			After *string `json:"after"`
+		} `json:"cursor"`
+	} `json:"result"`
+	ErrorCode *string `json:"error_code"`
+	ErrorInfo *string `json:"error_info"`
+}
+
+type fetchRevisionsOptions struct {
+	Client   *rest.Client
+	URL      string
+	Token    string
+	QueryKey string
+	After    string
+	PerPage  int
+}
+
+type fetchRevisionsResults struct {
+	Revisions   []*revision
+	HasNextPage bool
+	EndCursor   string
+}
+
+func fetchRevisions(ctx context.Context, input *fetchRevisionsOptions) (*fetchRevisionsResults, error) {
+	var form = url.Values{}
+	form.Set("api.token", input.Token)
+	form.Set("queryKey", input.QueryKey)
+	form.Set("attachments[reviewers]", "1")
+	form.Set("limit", fmt.Sprint(input.PerPage))
+	if input.After != "" {
+		form.Set("after", input.After)
+	}
+
+	body, err := input.Client.PostForm(ctx, strings.TrimSuffix(input.URL, "/")+"/api/differential.revision.search", form)
+	if err != nil {
+		return nil, err
+	}
+
+	var res searchResponse
+	if err = json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("failed to decode Conduit response: %v", err)
+	}
+
+	// Conduit reports errors with a 200 status
+	if res.ErrorCode != nil {
+		var info string
+		if res.ErrorInfo != nil {
+			info = *res.ErrorInfo
+		}
+		return nil, fmt.Errorf("conduit error %s: %s", *res.ErrorCode, info)
+	}
+
+	var results = &fetchRevisionsResults{Revisions: res.Result.Data}
+	if after := res.Result.Cursor.After; after != nil && *after != "" {
+		results.HasNextPage, results.EndCursor = true, *after
+	}
+	return results, nil
+}
+
+// readExport reads the revisions of a JSON export, either a saved differential.revision.search response
+// or an array of revision objects
+func readExport(path string) ([]*revision, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if trimmed := strings.TrimSpace(string(b)); strings.HasPrefix(trimmed, "[") {
+		var revisions []*revision
+		if err = json.Unmarshal(b, &revisions); err != nil {
+			return nil, fmt.Errorf("failed to decode export %s: %v", path, err)
+		}
+		return revisions, nil
+	}
+
+	var res searchResponse
+	if err = json.Unmarshal(b, &res); err != nil {
+		return nil, fmt.Errorf("failed to decode export %s: %v", path, err)
+	}
+	return res.Result.Data, nil
+}
+
+// revisionPages returns a function listing the revisions of source one page at a time, turning them into rows with toRows.
+// source is either the base URL of a Phabricator instance or the path to an export.
+func revisionPages(opts *Options, source, queryKey string, toRows func(*revision) [][]interface{}) rest.PageFunc {
+	var toPage = func(revisions []*revision) [][]interface{} {
+		var rows [][]interface{}
+		for _, r := range revisions {
+			rows = append(rows, toRows(r)...)
+		}
+		return rows
+	}
+
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return func(context.Context) ([][]interface{}, bool, error) {
+			revisions, err := readExport(source)
+			if err != nil {
+				return nil, false, err
+			}
+			return toPage(revisions), false, nil
+		}
+	}
+
+	if queryKey == "" {
+		queryKey = "all"
+	}
+
+	var after string
+	return func(ctx context.Context) ([][]interface{}, bool, error) {
+		results, err := fetchRevisions(ctx, &fetchRevisionsOptions{
+			Client: opts.Client, URL: source, Token: opts.Context["phabricatorToken"], QueryKey: queryKey, After: after, PerPage: perPage,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		after = results.EndCursor
+		return toPage(results.Revisions), results.HasNextPage, nil
+	}
+}
+
+// epoch converts a unix timestamp to a time, leaving unset timestamps zero
+func epoch(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).UTC()
+}
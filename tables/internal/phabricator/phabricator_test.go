@@ -0,0 +1,100 @@
+package phabricator
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+)
+
+const response = `{"result": {"data": [{"id": %d, "phid": "PHID-DREV-%d", "fields": {"title": "Fix it", "dateCreated": 1600000000,
+  "status": {"value": "published", "name": "Closed", "closed": true}},
+  "attachments": {"reviewers": {"reviewers": [{"reviewerPHID": "PHID-USER-1", "status": "accepted"}]}}}],
+  "cursor": {"after": %s}}, "error_code": null, "error_info": null}`
+
+func collect(t *testing.T, next rest.PageFunc) {
+	for more := true; more; {
+		var err error
+		if _, more, err = next(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestConduit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/differential.revision.search" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.FormValue("api.token") != "api-secret" {
+			t.Errorf("unexpected token: %s", r.FormValue("api.token"))
+		}
+
+		switch r.FormValue("after") {
+		case "":
+			_, _ = w.Write([]byte(fmt.Sprintf(response, 2, 2, `"1"`)))
+		case "1":
+			_, _ = w.Write([]byte(fmt.Sprintf(response, 1, 1, `null`)))
+		default:
+			t.Errorf("unexpected cursor: %s", r.FormValue("after"))
+		}
+	}))
+	defer srv.Close()
+
+	var opts = &Options{Client: &rest.Client{}, Context: services.Context{"phabricatorToken": "api-secret"}}
+
+	var ids []int
+	collect(t, revisionPages(opts, srv.URL, "", func(r *revision) [][]interface{} {
+		ids = append(ids, r.ID)
+		if !r.Fields.Status.Closed || len(r.Attachments.Reviewers.Reviewers) != 1 {
+			t.Errorf("unexpected revision: %+v", r)
+		}
+		return nil
+	}))
+
+	if len(ids) != 2 || ids[0] != 2 || ids[1] != 1 {
+		t.Fatalf("unexpected revisions: %v", ids)
+	}
+}
+
+func TestConduitError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"result": null, "error_code": "ERR-INVALID-AUTH", "error_info": "API token is invalid."}`))
+	}))
+	defer srv.Close()
+
+	var next = revisionPages(&Options{Client: &rest.Client{}}, srv.URL, "", nil)
+	if _, _, err := next(context.Background()); err == nil {
+		t.Fatal("expected the Conduit error to be reported")
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phabricator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "revisions.json")
+	if err = ioutil.WriteFile(path, []byte(`[{"id": 7, "fields": {"title": "Exported"}}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var titles []string
+	collect(t, revisionPages(&Options{}, path, "", func(r *revision) [][]interface{} {
+		titles = append(titles, r.Fields.Title)
+		return nil
+	}))
+
+	if len(titles) != 1 || titles[0] != "Exported" {
+		t.Fatalf("unexpected revisions: %v", titles)
+	}
+}
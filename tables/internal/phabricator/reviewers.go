@@ -0,0 +1,46 @@
+package phabricator
+
+import (
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+var reviewersCols = []vtab.Column{
+	{Name: "source", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "query_key", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "revision_id", Type: sqlite.SQLITE_INTEGER},
+	{Name: "revision_phid", Type: sqlite.SQLITE_TEXT},
+	{Name: "reviewer_phid", Type: sqlite.SQLITE_TEXT},
+	{Name: "status", Type: sqlite.SQLITE_TEXT},
+	{Name: "is_blocking", Type: sqlite.SQLITE_INTEGER},
+	{Name: "actor_phid", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewReviewersModule returns the implementation of a table-valued-function listing the reviewers
+// of Differential revisions, along with the status of their review (accepted, rejected...)
+func NewReviewersModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("phabricator_reviewers", reviewersCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var source, queryKey string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					source = constraint.Value.Text()
+				case 1:
+					queryKey = constraint.Value.Text()
+				}
+			}
+		}
+
+		return rest.NewIterator(revisionPages(opts, source, queryKey, func(r *revision) [][]interface{} {
+			var rows [][]interface{}
+			for _, reviewer := range r.Attachments.Reviewers.Reviewers {
+				rows = append(rows, []interface{}{
+					source, queryKey, r.ID, r.PHID, reviewer.ReviewerPHID, reviewer.Status, reviewer.IsBlocking, reviewer.ActorPHID,
+				})
+			}
+			return rows
+		})), nil
+	})
+}
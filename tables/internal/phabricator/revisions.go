@@ -0,0 +1,54 @@
+package phabricator
+
+import (
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+var revisionsCols = []vtab.Column{
+	{Name: "source", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "query_key", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: sqlite.SQLITE_INTEGER},
+	{Name: "phid", Type: sqlite.SQLITE_TEXT},
+	{Name: "title", Type: sqlite.SQLITE_TEXT},
+	{Name: "uri", Type: sqlite.SQLITE_TEXT},
+	{Name: "author_phid", Type: sqlite.SQLITE_TEXT},
+	{Name: "repository_phid", Type: sqlite.SQLITE_TEXT},
+	{Name: "diff_phid", Type: sqlite.SQLITE_TEXT},
+	{Name: "status", Type: sqlite.SQLITE_TEXT},
+	{Name: "status_name", Type: sqlite.SQLITE_TEXT},
+	{Name: "closed", Type: sqlite.SQLITE_INTEGER},
+	{Name: "draft", Type: sqlite.SQLITE_INTEGER},
+	{Name: "summary", Type: sqlite.SQLITE_TEXT},
+	{Name: "test_plan", Type: sqlite.SQLITE_TEXT},
+	{Name: "created", Type: sqlite.SQLITE_TEXT},
+	{Name: "modified", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewRevisionsModule returns the implementation of a table-valued-function listing Differential revisions,
+// e.g. phabricator_revisions('https://phabricator.example.com') or phabricator_revisions('revisions.json')
+func NewRevisionsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("phabricator_revisions", revisionsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var source, queryKey string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					source = constraint.Value.Text()
+				case 1:
+					queryKey = constraint.Value.Text()
+				}
+			}
+		}
+
+		return rest.NewIterator(revisionPages(opts, source, queryKey, func(r *revision) [][]interface{} {
+			var f = r.Fields
+			return [][]interface{}{{
+				source, queryKey, r.ID, r.PHID, f.Title, f.URI, f.AuthorPHID, f.RepositoryPHID, f.DiffPHID,
+				f.Status.Value, f.Status.Name, f.Status.Closed, f.IsDraft, f.Summary, f.TestPlan,
+				epoch(f.DateCreated), epoch(f.DateModified),
+			}}
+		})), nil
+	})
+}
@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ETags stores the validators (ETag or Last-Modified) of the responses to GET requests, along with the responses,
+// so that the requests made again are conditional: a 304 Not Modified is answered with the stored response.
+// GitHub doesn't count conditional requests answered with a 304 against the rate limit, which makes polling cheap,
+// and other APIs at least don't send the response again. Its zero value is ready to use, and safe for concurrent use.
+type ETags struct {
+	mu      sync.Mutex
+	entries map[string]*validated
+}
+
+// validated is a response stored along with its validators
+type validated struct {
+	etag, lastModified string
+	body               []byte
+	header             http.Header
+}
+
+// responses larger than maxValidatedBody (downloads, mostly) aren't stored, and only the last maxValidated responses are
+const (
+	maxValidatedBody = 1 << 20
+	maxValidated     = 1000
+)
+
+// validatedKey returns the key of the response to req: its URL and headers, as credentials are usually sent in headers,
+// and the response to a user isn't another's
+func validatedKey(req *http.Request) string {
+	var names = make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		if name != "If-None-Match" && name != "If-Modified-Since" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var h = sha256.New()
+	h.Write([]byte(req.URL.String()))
+	for _, name := range names {
+		h.Write([]byte("\n" + name + ": " + strings.Join(req.Header[name], ", ")))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// prepare makes req conditional on the validators of the response stored for it, if any
+func (e *ETags) prepare(req *http.Request, key string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var v, ok = e.entries[key]
+	switch {
+	case !ok:
+	case v.etag != "":
+		req.Header.Set("If-None-Match", v.etag)
+	case v.lastModified != "":
+		req.Header.Set("If-Modified-Since", v.lastModified)
+	}
+}
+
+// stored returns the response stored for the key, if any
+func (e *ETags) stored(key string) ([]byte, http.Header, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var v, ok = e.entries[key]
+	if !ok {
+		return nil, nil, false
+	}
+	return v.body, v.header, true
+}
+
+// store stores the response for the key, if it has validators
+func (e *ETags) store(key string, header http.Header, body []byte) {
+	var v = &validated{etag: header.Get("ETag"), lastModified: header.Get("Last-Modified"), body: body, header: header}
+	if (v.etag == "" && v.lastModified == "") || len(body) > maxValidatedBody {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.entries == nil {
+		e.entries = make(map[string]*validated)
+	}
+	// past the limit, an arbitrary response makes room for the new one
+	if _, ok := e.entries[key]; !ok && len(e.entries) >= maxValidated {
+		for k := range e.entries {
+			delete(e.entries, k)
+			break
+		}
+	}
+	e.entries[key] = v
+}
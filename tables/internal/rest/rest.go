@@ -0,0 +1,251 @@
+// Package rest provides the plumbing shared by the tables backed by JSON over HTTP APIs:
+// a small client, and an iterator paging through results one batch of rows at a time.
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/tz"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+// Client makes requests to a JSON API
+type Client struct {
+	// HTTP is the client used to make requests, http.DefaultClient if nil
+	HTTP *http.Client
+
+	// Prepare, if set, is called on every request before it's sent, to add credentials for instance
+	Prepare func(req *http.Request)
+
+	// ETags, if set, makes GET requests made again conditional, answering a 304 with the stored response (see ETags)
+	ETags *ETags
+}
+
+// With returns a copy of the client also calling prepare on every request, after any existing Prepare function
+func (c *Client) With(prepare func(req *http.Request)) *Client {
+	var parent = c.Prepare
+	return &Client{HTTP: c.HTTP, ETags: c.ETags, Prepare: func(req *http.Request) {
+		if parent != nil {
+			parent(req)
+		}
+		prepare(req)
+	}}
+}
+
+// Fetch makes a GET request to url and returns the response body.
+// Responses with a status other than 200 are reported as errors.
+func (c *Client) Fetch(ctx context.Context, url string) ([]byte, error) {
+	body, _, err := c.FetchWithHeader(ctx, url)
+	return body, err
+}
+
+// FetchWithHeader is like Fetch, but also returns the headers of the response, for APIs paginating through them
+func (c *Client) FetchWithHeader(ctx context.Context, url string) ([]byte, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.Do(req)
+}
+
+// PostForm makes a POST request of the url-encoded form to endpoint and returns the response body
+func (c *Client) PostForm(ctx context.Context, endpoint string, form url.Values) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	body, _, err := c.Do(req)
+	return body, err
+}
+
+// Do sends req, asking for JSON unless another Accept header is set, and returns the response body and headers.
+// Responses with a status other than 200 are reported as errors, but for a 304 to a request made conditional by ETags.
+func (c *Client) Do(req *http.Request) ([]byte, http.Header, error) {
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+	if c.Prepare != nil {
+		c.Prepare(req)
+	}
+
+	var key string
+	if c.ETags != nil && req.Method == http.MethodGet {
+		key = validatedKey(req)
+		c.ETags.prepare(req, key)
+	}
+
+	var client = c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.StatusCode == http.StatusNotModified && key != "" {
+		if body, header, ok := c.ETags.stored(key); ok {
+			return body, header, nil
+		}
+	}
+
+	if res.StatusCode != http.StatusOK {
+		var msg = strings.TrimSpace(string(body))
+		if len(msg) > 200 {
+			msg = msg[:200] + "..."
+		}
+		// the query string is left out of the error, as some APIs take credentials there
+		return nil, nil, fmt.Errorf("%s %s://%s%s: unexpected status %s: %s", req.Method, req.URL.Scheme, req.URL.Host, req.URL.Path, res.Status, msg)
+	}
+
+	if key != "" {
+		c.ETags.store(key, res.Header, body)
+	}
+	return body, res.Header, nil
+}
+
+// GetJSON makes a GET request to url and decodes the JSON response into v
+func (c *Client) GetJSON(ctx context.Context, url string, v interface{}) error {
+	body, err := c.Fetch(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
+
+// NextLink returns the URL of the next page of results from the Link header (RFC 8288) of a response, if any.
+// Links marked with results="false", as Sentry does on the last page, are ignored.
+func NextLink(header http.Header) string {
+	for _, value := range header["Link"] {
+		for _, link := range strings.Split(value, ",") {
+			var parts = strings.Split(link, ";")
+			var target = strings.Trim(strings.TrimSpace(parts[0]), "<>")
+
+			var next, results = false, true
+			for _, param := range parts[1:] {
+				switch strings.ReplaceAll(strings.TrimSpace(param), " ", "") {
+				case `rel="next"`, "rel=next":
+					next = true
+				case `results="false"`:
+					results = false
+				}
+			}
+
+			if next && results {
+				return target
+			}
+		}
+	}
+	return ""
+}
+
+// PageFunc returns the next batch of rows of a result set, and whether there are more to come.
+// Each row holds the value of every column of the table, in order.
+type PageFunc func(ctx context.Context) (rows [][]interface{}, more bool, err error)
+
+// Iterator is a vtab.Iterator emitting the rows returned by successive calls to a PageFunc
+type Iterator struct {
+	next    PageFunc
+	rows    [][]interface{}
+	current int
+	more    bool
+}
+
+// NewIterator returns an iterator over the rows returned by next
+func NewIterator(next PageFunc) *Iterator {
+	return &Iterator{next: next, current: -1, more: true}
+}
+
+// Rows returns an iterator over a fixed set of rows
+func Rows(rows [][]interface{}) *Iterator {
+	return NewIterator(func(context.Context) ([][]interface{}, bool, error) { return rows, false, nil })
+}
+
+// Next implements vtab.Iterator
+func (i *Iterator) Next() (vtab.Row, error) {
+	i.current++
+
+	// fetch pages until one has rows, as some APIs return empty pages
+	for i.current >= len(i.rows) {
+		if !i.more {
+			return nil, io.EOF
+		}
+
+		rows, more, err := i.next(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		i.rows, i.more, i.current = rows, more, 0
+	}
+
+	return i, nil
+}
+
+// Column implements vtab.Row
+func (i *Iterator) Column(ctx *sqlite.Context, c int) error {
+	var row = i.rows[i.current]
+	if c >= len(row) {
+		ctx.ResultNull()
+		return nil
+	}
+
+	switch v := row[c].(type) {
+	case nil:
+		ctx.ResultNull()
+	case string:
+		ctx.ResultText(v)
+	case int:
+		ctx.ResultInt(v)
+	case int64:
+		ctx.ResultInt64(v)
+	case float64:
+		ctx.ResultFloat(v)
+	case bool:
+		if v {
+			ctx.ResultInt(1)
+		} else {
+			ctx.ResultInt(0)
+		}
+	case time.Time:
+		if v.IsZero() {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(tz.Format(v, time.RFC3339Nano))
+		}
+	case []byte:
+		ctx.ResultBlob(v)
+	default:
+		// anything else (maps, slices) is returned as JSON
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if string(b) == "null" {
+			ctx.ResultNull() // nil slices and maps
+		} else {
+			ctx.ResultText(string(b))
+		}
+	}
+	return nil
+}
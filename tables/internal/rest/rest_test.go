@@ -0,0 +1,108 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name": "askgit"}`))
+	}))
+	defer srv.Close()
+
+	var v struct{ Name string }
+	if err := (&Client{}).GetJSON(context.Background(), srv.URL, &v); err == nil {
+		t.Fatal("expected an error for an unauthorized request")
+	}
+
+	var client = &Client{Prepare: func(req *http.Request) { req.Header.Set("Authorization", "token secret") }}
+	if err := client.GetJSON(context.Background(), srv.URL, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "askgit" {
+		t.Fatalf("expected askgit, got: %s", v.Name)
+	}
+}
+
+func TestETags(t *testing.T) {
+	var requests, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{"name": "askgit"}`))
+	}))
+	defer srv.Close()
+
+	var client = &Client{ETags: &ETags{}, Prepare: func(req *http.Request) { req.Header.Set("Authorization", "token secret") }}
+	for i := 0; i < 3; i++ {
+		var v struct{ Name string }
+		if err := client.GetJSON(context.Background(), srv.URL, &v); err != nil {
+			t.Fatal(err)
+		}
+		if v.Name != "askgit" {
+			t.Fatalf("expected askgit, got: %s", v.Name)
+		}
+	}
+	if requests != 3 || notModified != 2 {
+		t.Fatalf("expected 2 of 3 requests to be answered with a 304, got %d of %d", notModified, requests)
+	}
+
+	// the response to another user isn't reused
+	var other = &Client{ETags: client.ETags, Prepare: func(req *http.Request) { req.Header.Set("Authorization", "token other") }}
+	if _, err := other.Fetch(context.Background(), srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if notModified != 2 {
+		t.Fatal("expected the request of another user not to be conditional")
+	}
+}
+
+func TestIterator(t *testing.T) {
+	// an empty page in the middle of the results shouldn't end the iteration
+	var pages = [][][]interface{}{{{"a"}}, {}, {{"b"}, {"c"}}}
+	var iter = NewIterator(func(context.Context) ([][]interface{}, bool, error) {
+		page := pages[0]
+		pages = pages[1:]
+		return page, len(pages) > 0, nil
+	})
+
+	var count int
+	for {
+		if _, err := iter.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		count++
+	}
+
+	if count != 3 {
+		t.Fatalf("expected 3 rows, got: %d", count)
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	var header = http.Header{}
+	header.Add("Link", `<https://api.example.com/items?page=1>; rel="prev", <https://api.example.com/items?page=3>; rel="next"`)
+	if next := NextLink(header); next != "https://api.example.com/items?page=3" {
+		t.Fatalf("unexpected next link: %q", next)
+	}
+
+	header.Set("Link", `<https://sentry.io/api/0/items/?cursor=0:100:0>; rel="next"; results="false"; cursor="0:100:0"`)
+	if next := NextLink(header); next != "" {
+		t.Fatalf("expected no next link, got: %q", next)
+	}
+}
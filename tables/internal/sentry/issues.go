@@ -0,0 +1,85 @@
+package sentry
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+type issue struct {
+	ID        string     `json:"id"`
+	ShortID   string     `json:"shortId"`
+	Title     string     `json:"title"`
+	Culprit   string     `json:"culprit"`
+	Level     string     `json:"level"`
+	Status    string     `json:"status"`
+	Count     count      `json:"count"`
+	UserCount int64      `json:"userCount"`
+	FirstSeen *time.Time `json:"firstSeen"`
+	LastSeen  *time.Time `json:"lastSeen"`
+	Permalink string     `json:"permalink"`
+}
+
+var issuesCols = []vtab.Column{
+	{Name: "organization", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "project", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "query", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: sqlite.SQLITE_TEXT},
+	{Name: "short_id", Type: sqlite.SQLITE_TEXT},
+	{Name: "title", Type: sqlite.SQLITE_TEXT},
+	{Name: "culprit", Type: sqlite.SQLITE_TEXT},
+	{Name: "level", Type: sqlite.SQLITE_TEXT},
+	{Name: "status", Type: sqlite.SQLITE_TEXT},
+	{Name: "event_count", Type: sqlite.SQLITE_INTEGER},
+	{Name: "user_count", Type: sqlite.SQLITE_INTEGER},
+	{Name: "first_seen", Type: sqlite.SQLITE_TEXT},
+	{Name: "last_seen", Type: sqlite.SQLITE_TEXT},
+	{Name: "url", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewIssuesModule returns the implementation of a table-valued-function listing the issues of a Sentry project,
+// optionally filtered with a Sentry search query (by default, only unresolved issues are listed)
+func NewIssuesModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("sentry_issues", issuesCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var organization, project, query string
+		var hasQuery bool
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					organization = constraint.Value.Text()
+				case 1:
+					project = constraint.Value.Text()
+				case 2:
+					query, hasQuery = constraint.Value.Text(), true
+				}
+			}
+		}
+
+		var endpoint = projectEndpoint(opts, organization, project, "issues")
+		if hasQuery {
+			// an empty query lists issues in any state
+			endpoint += "?" + url.Values{"query": {query}}.Encode()
+		}
+
+		return rest.NewIterator(pages(client(opts), endpoint, func(body []byte) ([][]interface{}, error) {
+			var issues []*issue
+			if err := json.Unmarshal(body, &issues); err != nil {
+				return nil, err
+			}
+
+			var rows [][]interface{}
+			for _, i := range issues {
+				rows = append(rows, []interface{}{
+					organization, project, query, i.ID, i.ShortID, i.Title, i.Culprit, i.Level, i.Status, int64(i.Count), i.UserCount,
+					timeOrZero(i.FirstSeen), timeOrZero(i.LastSeen), i.Permalink,
+				})
+			}
+			return rows, nil
+		})), nil
+	})
+}
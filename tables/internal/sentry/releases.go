@@ -0,0 +1,87 @@
+package sentry
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+type release struct {
+	Version      string     `json:"version"`
+	ShortVersion string     `json:"shortVersion"`
+	Ref          string     `json:"ref"`
+	URL          string     `json:"url"`
+	DateCreated  *time.Time `json:"dateCreated"`
+	DateReleased *time.Time `json:"dateReleased"`
+	NewGroups    int        `json:"newGroups"`
+	CommitCount  int        `json:"commitCount"`
+	LastCommit   *struct {
+		ID string `json:"id"`
+	} `json:"lastCommit"`
+	LastDeploy *struct {
+		Environment  string     `json:"environment"`
+		DateFinished *time.Time `json:"dateFinished"`
+	} `json:"lastDeploy"`
+}
+
+var releasesCols = []vtab.Column{
+	{Name: "organization", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "project", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "version", Type: sqlite.SQLITE_TEXT},
+	{Name: "short_version", Type: sqlite.SQLITE_TEXT},
+	{Name: "ref", Type: sqlite.SQLITE_TEXT},
+	{Name: "last_commit", Type: sqlite.SQLITE_TEXT},
+	{Name: "commit_count", Type: sqlite.SQLITE_INTEGER},
+	{Name: "new_issues", Type: sqlite.SQLITE_INTEGER},
+	{Name: "created_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "released_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "last_deploy_environment", Type: sqlite.SQLITE_TEXT},
+	{Name: "last_deployed_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "url", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewReleasesModule returns the implementation of a table-valued-function listing the releases of a Sentry project,
+// along with the commit they were cut from, when the release is associated with commits
+func NewReleasesModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("sentry_releases", releasesCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var organization, project string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					organization = constraint.Value.Text()
+				case 1:
+					project = constraint.Value.Text()
+				}
+			}
+		}
+
+		return rest.NewIterator(pages(client(opts), projectEndpoint(opts, organization, project, "releases"), func(body []byte) ([][]interface{}, error) {
+			var releases []*release
+			if err := json.Unmarshal(body, &releases); err != nil {
+				return nil, err
+			}
+
+			var rows [][]interface{}
+			for _, r := range releases {
+				var lastCommit, environment interface{}
+				var deployedAt time.Time
+				if r.LastCommit != nil {
+					lastCommit = r.LastCommit.ID
+				}
+				if r.LastDeploy != nil {
+					environment, deployedAt = r.LastDeploy.Environment, timeOrZero(r.LastDeploy.DateFinished)
+				}
+
+				rows = append(rows, []interface{}{
+					organization, project, r.Version, r.ShortVersion, r.Ref, lastCommit, r.CommitCount, r.NewGroups,
+					timeOrZero(r.DateCreated), timeOrZero(r.DateReleased), environment, deployedAt, r.URL,
+				})
+			}
+			return rows, nil
+		})), nil
+	})
+}
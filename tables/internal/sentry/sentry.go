@@ -0,0 +1,100 @@
+// Package sentry implements tables over the issues and releases of Sentry projects
+package sentry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+)
+
+// Options configures the Sentry tables
+type Options struct {
+	// Client is used to make requests to the Sentry API
+	Client *rest.Client
+
+	// Context holds the sentryToken auth token, and the sentryURL of self-hosted instances
+	Context services.Context
+}
+
+// apiURL is the default base URL of the Sentry API
+const apiURL = "https://sentry.io"
+
+// baseURL returns the URL of the Sentry instance the tables query
+func baseURL(opts *Options) string {
+	if u := opts.Context["sentryURL"]; u != "" {
+		return strings.TrimSuffix(u, "/")
+	}
+	return apiURL
+}
+
+// client returns the API client, authenticating with the auth token if one is set
+func client(opts *Options) *rest.Client {
+	var token = opts.Context["sentryToken"]
+	if token == "" {
+		return opts.Client
+	}
+	return opts.Client.With(func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) })
+}
+
+// pages returns a function fetching the results at endpoint, then following the Link headers of the responses.
+// Every page is decoded with decode, which turns the items into rows.
+func pages(client *rest.Client, endpoint string, decode func(body []byte) ([][]interface{}, error)) rest.PageFunc {
+	return func(ctx context.Context) ([][]interface{}, bool, error) {
+		body, header, err := client.FetchWithHeader(ctx, endpoint)
+		if err != nil {
+			return nil, false, err
+		}
+
+		rows, err := decode(body)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to decode Sentry response: %v", err)
+		}
+
+		endpoint = rest.NextLink(header)
+		return rows, endpoint != "", nil
+	}
+}
+
+// projectEndpoint returns the URL of a resource of a project
+func projectEndpoint(opts *Options, organization, project, resource string) string {
+	return fmt.Sprintf("%s/api/0/projects/%s/%s/%s/", baseURL(opts), url.PathEscape(organization), url.PathEscape(project), resource)
+}
+
+// count is a number Sentry returns as a string, as it may overflow JavaScript numbers
+type count int64
+
+func (c *count) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		// accept plain numbers as well
+		var n int64
+		if err := json.Unmarshal(b, &n); err != nil {
+			return err
+		}
+		*c = count(n)
+		return nil
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*c = count(n)
+	return nil
+}
+
+// timeOrZero dereferences t, for the timestamps that may be missing
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
@@ -0,0 +1,57 @@
+package sentry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+)
+
+func TestPages(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?cursor=0:100:0>; rel="next"; results="true"; cursor="0:100:0"`, srv.URL, r.URL.Path))
+			_, _ = w.Write([]byte(`[{"id": "1", "title": "TypeError", "count": "1234", "userCount": 56, "firstSeen": "2021-03-01T10:00:00Z"}]`))
+		default:
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?cursor=0:200:0>; rel="next"; results="false"; cursor="0:200:0"`, srv.URL, r.URL.Path))
+			_, _ = w.Write([]byte(`[{"id": "2", "title": "KeyError", "count": "1"}]`))
+		}
+	}))
+	defer srv.Close()
+
+	var opts = &Options{Client: &rest.Client{}, Context: services.Context{"sentryToken": "secret", "sentryURL": srv.URL}}
+
+	var issues []*issue
+	var next = pages(client(opts), projectEndpoint(opts, "acme", "web", "issues"), func(body []byte) ([][]interface{}, error) {
+		var page []*issue
+		err := json.Unmarshal(body, &page)
+		issues = append(issues, page...)
+		return nil, err
+	})
+
+	for more := true; more; {
+		var err error
+		if _, more, err = next(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got: %d", len(issues))
+	}
+	if issues[0].Count != 1234 || issues[0].FirstSeen == nil {
+		t.Fatalf("unexpected issue: %+v", issues[0])
+	}
+}
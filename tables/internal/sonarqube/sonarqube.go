@@ -0,0 +1,189 @@
+// Package sonarqube implements a table over the history of the quality measures of SonarQube projects
+package sonarqube
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+// Options configures the SonarQube tables
+type Options struct {
+	// Client is used to make requests to the SonarQube Web API
+	Client *rest.Client
+
+	// Context holds the sonarToken user token
+	Context services.Context
+}
+
+// perPage is the number of analyses fetched per request, the maximum allowed by the API
+const perPage = 1000
+
+// timeLayout is the format of the dates of analyses
+const timeLayout = "2006-01-02T15:04:05-0700"
+
+// metrics are the keys of the measures exposed as columns, in order
+var metrics = []string{"bugs", "vulnerabilities", "code_smells", "coverage", "duplicated_lines_density", "ncloc", "sqale_index"}
+
+// percentages are the metrics with fractional values, the others being counts
+var percentages = map[string]bool{"coverage": true, "duplicated_lines_density": true}
+
+type fetchHistoryOptions struct {
+	Client    *rest.Client
+	URL       string
+	Component string
+	Page      int
+}
+
+type fetchHistoryResults struct {
+	Paging struct {
+		PageIndex int `json:"pageIndex"`
+		PageSize  int `json:"pageSize"`
+		Total     int `json:"total"`
+	} `json:"paging"`
+	Measures []*struct {
+		Metric  string `json:"metric"`
+		History []*struct {
+			Date  string `json:"date"`
+			Value string `json:"value"`
+		} `json:"history"`
+	} `json:"measures"`
+}
+
+func (r *fetchHistoryResults) hasNextPage() bool {
+	return r.Paging.PageIndex*r.Paging.PageSize < r.Paging.Total
+}
+
+func fetchHistory(ctx context.Context, input *fetchHistoryOptions) (*fetchHistoryResults, error) {
+	var params = url.Values{
+		"component": {input.Component},
+		"metrics":   {strings.Join(metrics, ",")},
+		"p":         {fmt.Sprint(input.Page)},
+		"ps":        {fmt.Sprint(perPage)},
+	}
+
+	var results fetchHistoryResults
+	if err := input.Client.GetJSON(ctx, strings.TrimSuffix(input.URL, "/")+"/api/measures/search_history?"+params.Encode(), &results); err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+// pivot turns the history of every metric into one row per analysis, with a column per metric
+func pivot(results *fetchHistoryResults) ([][]interface{}, error) {
+	var column = make(map[string]int, len(metrics))
+	for i, metric := range metrics {
+		column[metric] = i + 1
+	}
+
+	var analyses = make(map[string][]interface{})
+	for _, measure := range results.Measures {
+		c, ok := column[measure.Metric]
+		if !ok {
+			continue
+		}
+
+		for _, h := range measure.History {
+			row, ok := analyses[h.Date]
+			if !ok {
+				date, err := time.Parse(timeLayout, h.Date)
+				if err != nil {
+					return nil, fmt.Errorf("invalid analysis date %q: %v", h.Date, err)
+				}
+				row = make([]interface{}, len(metrics)+1)
+				row[0] = date.UTC()
+				analyses[h.Date] = row
+			}
+
+			// analyses predating a metric have no value for it
+			if h.Value == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(h.Value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s measure %q: %v", measure.Metric, h.Value, err)
+			}
+			if percentages[measure.Metric] {
+				row[c] = value
+			} else {
+				row[c] = int64(value)
+			}
+		}
+	}
+
+	var rows = make([][]interface{}, 0, len(analyses))
+	for _, row := range analyses {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0].(time.Time).Before(rows[j][0].(time.Time)) })
+	return rows, nil
+}
+
+var measuresCols = []vtab.Column{
+	{Name: "url", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "project", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "analysis_date", Type: sqlite.SQLITE_TEXT},
+	{Name: "bugs", Type: sqlite.SQLITE_INTEGER},
+	{Name: "vulnerabilities", Type: sqlite.SQLITE_INTEGER},
+	{Name: "code_smells", Type: sqlite.SQLITE_INTEGER},
+	{Name: "coverage", Type: sqlite.SQLITE_FLOAT},
+	{Name: "duplication", Type: sqlite.SQLITE_FLOAT},
+	{Name: "lines_of_code", Type: sqlite.SQLITE_INTEGER},
+	{Name: "technical_debt_minutes", Type: sqlite.SQLITE_INTEGER},
+}
+
+// NewMeasuresModule returns the implementation of a table-valued-function listing the quality measures
+// of a SonarQube project at each of its analyses, e.g. sonarqube_measures('https://sonar.example.com', 'my-project')
+func NewMeasuresModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("sonarqube_measures", measuresCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var baseURL, project string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					baseURL = constraint.Value.Text()
+				case 1:
+					project = constraint.Value.Text()
+				}
+			}
+		}
+
+		return rest.NewIterator(measurePages(opts, baseURL, project)), nil
+	})
+}
+
+func measurePages(opts *Options, baseURL, project string) rest.PageFunc {
+	var client = opts.Client
+	// user tokens are sent as the username of basic auth, which every version of SonarQube supports
+	if token := opts.Context["sonarToken"]; token != "" {
+		client = client.With(func(req *http.Request) { req.SetBasicAuth(token, "") })
+	}
+
+	var page = 1
+	return func(ctx context.Context) ([][]interface{}, bool, error) {
+		results, err := fetchHistory(ctx, &fetchHistoryOptions{Client: client, URL: baseURL, Component: project, Page: page})
+		if err != nil {
+			return nil, false, err
+		}
+		page++
+
+		rows, err := pivot(results)
+		if err != nil {
+			return nil, false, err
+		}
+		for i, row := range rows {
+			rows[i] = append([]interface{}{baseURL, project}, row...)
+		}
+		return rows, results.hasNextPage(), nil
+	}
+}
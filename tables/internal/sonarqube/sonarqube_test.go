@@ -0,0 +1,51 @@
+package sonarqube
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+)
+
+func TestMeasurePages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token, _, _ := r.BasicAuth(); token != "squ_secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/api/measures/search_history" || r.URL.Query().Get("component") != "web" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+
+		_, _ = w.Write([]byte(`{"paging": {"pageIndex": 1, "pageSize": 1000, "total": 2}, "measures": [
+			{"metric": "bugs", "history": [{"date": "2021-03-02T10:00:00+0100", "value": "3"}, {"date": "2021-03-01T10:00:00+0100", "value": "5"}]},
+			{"metric": "coverage", "history": [{"date": "2021-03-02T10:00:00+0100", "value": "81.5"}, {"date": "2021-03-01T10:00:00+0100"}]}
+		]}`))
+	}))
+	defer srv.Close()
+
+	var next = measurePages(&Options{Client: &rest.Client{}, Context: services.Context{"sonarToken": "squ_secret"}}, srv.URL, "web")
+	rows, more, err := next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if more {
+		t.Fatal("expected a single page")
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 analyses, got: %d", len(rows))
+	}
+
+	// analyses are sorted chronologically, with a column per metric
+	if date := rows[0][2].(time.Time); date.Format(time.RFC3339) != "2021-03-01T09:00:00Z" {
+		t.Fatalf("unexpected analysis date: %v", date)
+	}
+	if rows[0][3] != int64(5) || rows[0][6] != nil || rows[1][3] != int64(3) || rows[1][6] != 81.5 {
+		t.Fatalf("unexpected measures: %v", rows)
+	}
+}
@@ -0,0 +1,166 @@
+// Package stackexchange implements a table over the questions of Stack Exchange sites, such as Stack Overflow
+package stackexchange
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/url"
+	"time"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+// Options configures the Stack Exchange tables
+type Options struct {
+	// Client is used to make requests to the Stack Exchange API
+	Client *rest.Client
+
+	// Context holds the stackExchangeKey app key, which raises the request quota
+	Context services.Context
+}
+
+// apiURL is the base URL of the Stack Exchange API
+const apiURL = "https://api.stackexchange.com/2.3"
+
+// perPage is the number of questions fetched per request, the maximum allowed by the API
+const perPage = 100
+
+type question struct {
+	QuestionID       int      `json:"question_id"`
+	Title            string   `json:"title"`
+	Link             string   `json:"link"`
+	Score            int      `json:"score"`
+	AnswerCount      int      `json:"answer_count"`
+	ViewCount        int      `json:"view_count"`
+	IsAnswered       bool     `json:"is_answered"`
+	AcceptedAnswerID int      `json:"accepted_answer_id"`
+	CreationDate     int64    `json:"creation_date"`
+	LastActivityDate int64    `json:"last_activity_date"`
+	Tags             []string `json:"tags"`
+	Owner            struct {
+		DisplayName string `json:"display_name"`
+	} `json:"owner"`
+}
+
+type fetchQuestionsOptions struct {
+	Client *rest.Client
+	URL    string
+	Tag    string
+	Site   string
+	Key    string
+	Page   int
+}
+
+type fetchQuestionsResults struct {
+	Questions   []*question `json:"items"`
+	HasNextPage bool        `json:"has_more"`
+	// Backoff is the number of seconds to wait before making another request, when the API asks for it
+	Backoff int `json:"backoff"`
+}
+
+func fetchQuestions(ctx context.Context, input *fetchQuestionsOptions) (*fetchQuestionsResults, error) {
+	var params = url.Values{
+		"tagged":   {input.Tag},
+		"site":     {input.Site},
+		"sort":     {"creation"},
+		"order":    {"desc"},
+		"page":     {fmt.Sprint(input.Page)},
+		"pagesize": {fmt.Sprint(perPage)},
+	}
+	if input.Key != "" {
+		params.Set("key", input.Key)
+	}
+
+	var results fetchQuestionsResults
+	if err := input.Client.GetJSON(ctx, input.URL+"/questions?"+params.Encode(), &results); err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+// unix converts a unix timestamp to a time, leaving unset timestamps zero
+func unix(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).UTC()
+}
+
+var questionsCols = []vtab.Column{
+	{Name: "tag", Type: sqlite.SQLITE_TEXT, NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, Required: true, OmitCheck: true}}},
+	{Name: "site", Type: sqlite.SQLITE_TEXT, NotNull: false, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}},
+	{Name: "id", Type: sqlite.SQLITE_INTEGER},
+	{Name: "title", Type: sqlite.SQLITE_TEXT},
+	{Name: "author", Type: sqlite.SQLITE_TEXT},
+	{Name: "score", Type: sqlite.SQLITE_INTEGER},
+	{Name: "answer_count", Type: sqlite.SQLITE_INTEGER},
+	{Name: "view_count", Type: sqlite.SQLITE_INTEGER},
+	{Name: "is_answered", Type: sqlite.SQLITE_INTEGER},
+	{Name: "has_accepted_answer", Type: sqlite.SQLITE_INTEGER},
+	{Name: "tags", Type: sqlite.SQLITE_TEXT},
+	{Name: "created_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "last_activity_at", Type: sqlite.SQLITE_TEXT},
+	{Name: "url", Type: sqlite.SQLITE_TEXT},
+}
+
+// NewQuestionsModule returns the implementation of a table-valued-function listing the questions with a tag
+// on a Stack Exchange site (Stack Overflow by default), newest first
+func NewQuestionsModule(opts *Options) sqlite.Module {
+	return vtab.NewTableFunc("stackexchange_questions", questionsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var tag, site string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 0:
+					tag = constraint.Value.Text()
+				case 1:
+					site = constraint.Value.Text()
+				}
+			}
+		}
+
+		return rest.NewIterator(questionPages(opts, apiURL, tag, site)), nil
+	})
+}
+
+func questionPages(opts *Options, baseURL, tag, site string) rest.PageFunc {
+	if site == "" {
+		site = "stackoverflow"
+	}
+
+	var page = 1
+	var backoff time.Duration
+	return func(ctx context.Context) ([][]interface{}, bool, error) {
+		// honour the backoff requested by the previous response, or the API rejects further requests
+		if backoff > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return nil, false, ctx.Err()
+			}
+		}
+
+		results, err := fetchQuestions(ctx, &fetchQuestionsOptions{
+			Client: opts.Client, URL: baseURL, Tag: tag, Site: site, Key: opts.Context["stackExchangeKey"], Page: page,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		page++
+		backoff = time.Duration(results.Backoff) * time.Second
+
+		var rows [][]interface{}
+		for _, q := range results.Questions {
+			rows = append(rows, []interface{}{
+				tag, site, q.QuestionID, html.UnescapeString(q.Title), html.UnescapeString(q.Owner.DisplayName),
+				q.Score, q.AnswerCount, q.ViewCount, q.IsAnswered, q.AcceptedAnswerID != 0, q.Tags,
+				unix(q.CreationDate), unix(q.LastActivityDate), q.Link,
+			})
+		}
+		return rows, results.HasNextPage, nil
+	}
+}
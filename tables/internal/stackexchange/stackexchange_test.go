@@ -0,0 +1,53 @@
+package stackexchange
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/services"
+)
+
+func TestQuestionPages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var q = r.URL.Query()
+		if q.Get("tagged") != "askgit" || q.Get("site") != "stackoverflow" || q.Get("key") != "app-key" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+
+		switch q.Get("page") {
+		case "1":
+			_, _ = w.Write([]byte(`{"items": [{"question_id": 2, "title": "Why &quot;askgit&quot;?", "is_answered": true,
+				"accepted_answer_id": 3, "creation_date": 1614592800, "tags": ["askgit", "sql"]}], "has_more": true}`))
+		case "2":
+			_, _ = w.Write([]byte(`{"items": [{"question_id": 1, "title": "Joins"}], "has_more": false}`))
+		default:
+			t.Errorf("unexpected page: %s", q.Get("page"))
+		}
+	}))
+	defer srv.Close()
+
+	var next = questionPages(&Options{Client: &rest.Client{}, Context: services.Context{"stackExchangeKey": "app-key"}}, srv.URL, "askgit", "")
+
+	var questions [][]interface{}
+	for more := true; more; {
+		var page [][]interface{}
+		var err error
+		if page, more, err = next(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		questions = append(questions, page...)
+	}
+
+	if len(questions) != 2 {
+		t.Fatalf("expected 2 questions, got: %d", len(questions))
+	}
+	if title := questions[0][3]; title != `Why "askgit"?` {
+		t.Fatalf("expected the title to be unescaped, got: %v", title)
+	}
+	if questions[0][9] != true || questions[1][9] != false {
+		t.Fatalf("unexpected accepted answers: %v, %v", questions[0][9], questions[1][9])
+	}
+}
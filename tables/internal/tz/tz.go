@@ -0,0 +1,62 @@
+// Package tz converts the timestamps returned by the tables to the timezone chosen with tables.WithTimezone,
+// so that git timestamps (in the offset of their author or committer) and API timestamps (in UTC) can be aggregated together.
+package tz
+
+import (
+	"sync"
+	"time"
+)
+
+var mu sync.RWMutex
+var location *time.Location
+
+// SetLocation sets the timezone timestamps are converted to, nil to return them as they are.
+// It applies to every connection, as tables are registered once per process.
+func SetLocation(loc *time.Location) {
+	mu.Lock()
+	defer mu.Unlock()
+	location = loc
+}
+
+// Format formats t with layout, in the timezone set with SetLocation if any
+func Format(t time.Time, layout string) string {
+	mu.RLock()
+	var loc = location
+	mu.RUnlock()
+
+	if loc != nil {
+		t = t.In(loc)
+	}
+	return t.Format(layout)
+}
+
+// Text converts the RFC 3339 timestamp ts, as returned by an API, to the timezone set with SetLocation if any.
+// Anything that isn't an RFC 3339 timestamp is returned as is.
+func Text(ts string) string {
+	mu.RLock()
+	var loc = location
+	mu.RUnlock()
+
+	if loc == nil {
+		return ts
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return ts
+	}
+	return t.In(loc).Format(time.RFC3339Nano)
+}
+
+// Convert converts the RFC 3339 timestamp ts to the timezone called zone (an IANA name such as Europe/Paris, or UTC)
+func Convert(ts, zone string) (string, error) {
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return "", err
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return "", err
+	}
+	return t.In(loc).Format(time.RFC3339Nano), nil
+}
@@ -0,0 +1,54 @@
+package tz
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	var ts = time.Date(2021, 3, 1, 23, 30, 0, 0, time.FixedZone("", -5*60*60))
+
+	if got := Format(ts, time.RFC3339); got != "2021-03-01T23:30:00-05:00" {
+		t.Fatalf("expected the timestamp in its own offset, got %s", got)
+	}
+
+	SetLocation(time.UTC)
+	defer SetLocation(nil)
+
+	if got := Format(ts, time.RFC3339); got != "2021-03-02T04:30:00Z" {
+		t.Fatalf("expected the timestamp in UTC, got %s", got)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	got, err := Convert("2021-03-02T04:30:00Z", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "2021-03-02T04:30:00Z" {
+		t.Fatalf("expected 2021-03-02T04:30:00Z, got %s", got)
+	}
+
+	if _, err = Convert("2021-03-02T04:30:00Z", "Nowhere/Somewhere"); err == nil {
+		t.Fatal("expected an unknown timezone error")
+	}
+	if _, err = Convert("yesterday", "UTC"); err == nil {
+		t.Fatal("expected an invalid timestamp error")
+	}
+}
+
+func TestText(t *testing.T) {
+	if got := Text("2021-03-02T04:30:00Z"); got != "2021-03-02T04:30:00Z" {
+		t.Fatalf("expected the timestamp as is, got %s", got)
+	}
+
+	SetLocation(time.FixedZone("", 2*60*60))
+	defer SetLocation(nil)
+
+	if got := Text("2021-03-02T04:30:00Z"); got != "2021-03-02T06:30:00+02:00" {
+		t.Fatalf("expected the timestamp in the location set, got %s", got)
+	}
+	if got := Text("not a timestamp"); got != "not a timestamp" {
+		t.Fatalf("expected text that isn't a timestamp as is, got %s", got)
+	}
+}
@@ -0,0 +1,27 @@
+package tables
+
+import "github.com/askgitdev/askgit/pkg/lint"
+
+// LintTables describes the tables backed by APIs to the query linter (see lint.Check):
+// the arguments they require, and whether they page through their results
+var LintTables = map[string]lint.Table{
+	"github_stargazers":          {Args: []string{"owner"}, Paged: true, Example: "github_stargazers('askgitdev/askgit')"},
+	"github_starred_repos":       {Paged: true},
+	"github_user_repos":          {Paged: true},
+	"github_org_repos":           {Args: []string{"login"}, Paged: true, Example: "github_org_repos('askgitdev')"},
+	"github_repos":               {Args: []string{"repos"}, Example: "github_repos('askgitdev/askgit, askgitdev/site')"},
+	"github_repo_issues":         {Args: []string{"owner"}, Paged: true, Example: "github_repo_issues('askgitdev/askgit')"},
+	"github_required_checks_gap": {Args: []string{"owner"}, Example: "github_required_checks_gap('askgitdev/askgit')"},
+	"github_team_repos":          {Args: []string{"org"}, Paged: true, Example: "github_team_repos('askgitdev')"},
+	"github_collaborators":       {Args: []string{"owner"}, Paged: true, Example: "github_collaborators('askgitdev/askgit')"},
+	"github_deploy_keys":         {Args: []string{"owner"}, Example: "github_deploy_keys('askgitdev/askgit')"},
+	"github_repo_webhooks":       {Args: []string{"owner"}, Example: "github_repo_webhooks('askgitdev/askgit')"},
+	"github_repo_rulesets":       {Args: []string{"owner"}, Example: "github_repo_rulesets('askgitdev/askgit')"},
+	"github_actions_secrets":     {Args: []string{"owner"}, Example: "github_actions_secrets('askgitdev/askgit')"},
+	"github_actions_variables":   {Args: []string{"owner"}, Example: "github_actions_variables('askgitdev/askgit')"},
+	"github_self_hosted_runners": {Args: []string{"owner"}, Example: "github_self_hosted_runners('askgitdev')"},
+	"github_actions_cache_usage": {Args: []string{"owner"}, Example: "github_actions_cache_usage('askgitdev')"},
+	"github_actions_billing":     {Args: []string{"org"}, Example: "github_actions_billing('askgitdev')"},
+	"github_workflow_jobs":       {Args: []string{"owner", "run_id"}, Example: "github_workflow_jobs('askgitdev', 'askgit', 1234567890)"},
+	"github_workflow_steps":      {Args: []string{"owner", "job_id"}, Example: "github_workflow_steps('askgitdev', 'askgit', 1234567890)"},
+}
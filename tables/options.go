@@ -2,10 +2,13 @@ package tables
 
 import (
 	"context"
+	"net/http"
+	"time"
 
 	"github.com/askgitdev/askgit/tables/services"
 	"github.com/go-git/go-git/v5"
 	"github.com/shurcooL/githubv4"
+	"golang.org/x/oauth2"
 )
 
 // Options is the container for various different options
@@ -24,6 +27,22 @@ type Options struct {
 	// GitHubClientGetter overrides the default GitHub v4 client
 	GitHubClientGetter func() *githubv4.Client
 
+	// GitHubTokenSource, if set, provides the token of the default GitHub client
+	// in place of the githubToken context value
+	GitHubTokenSource oauth2.TokenSource
+
+	// GitHubTransport, if set, is used as the base transport of the default GitHub client
+	GitHubTransport http.RoundTripper
+
+	// Transport, if set, is used by the clients of the tables backed by REST APIs other than GitHub's (e.g. Gerrit)
+	Transport http.RoundTripper
+
+	// Timezone, if set, is the timezone the timestamps returned by every table are converted to
+	Timezone *time.Location
+
+	// Providers are third-party providers whose tables and functions are registered along with the built-in ones
+	Providers []Provider
+
 	// Context is a key-value store to pass along values to the underlying extensions
 	Context services.Context
 }
@@ -47,6 +66,28 @@ func WithGitHubClientGetter(getter func() *githubv4.Client) OptionFn {
 	return func(o *Options) { o.GitHubClientGetter = getter }
 }
 
+// WithGitHubTokenSource configures the source of the token used by the default GitHub client
+func WithGitHubTokenSource(ts oauth2.TokenSource) OptionFn {
+	return func(o *Options) { o.GitHubTokenSource = ts }
+}
+
+// WithGitHubTransport configures the base http.RoundTripper used by the default GitHub client,
+// for instance to observe or tweak the requests made to the API
+func WithGitHubTransport(rt http.RoundTripper) OptionFn {
+	return func(o *Options) { o.GitHubTransport = rt }
+}
+
+// WithTransport configures the http.RoundTripper used by the clients of the REST API backed tables
+func WithTransport(rt http.RoundTripper) OptionFn {
+	return func(o *Options) { o.Transport = rt }
+}
+
+// WithTimezone converts the timestamps returned by every table (git timestamps, in the offset of their author or committer,
+// as well as API timestamps, usually in UTC) to loc
+func WithTimezone(loc *time.Location) OptionFn {
+	return func(o *Options) { o.Timezone = loc }
+}
+
 // RepoLocatorFn is an adapter type that adapts any function with compatible
 // signature to a RepoLocator instance.
 type RepoLocatorFn func(ctx context.Context, path string) (*git.Repository, error)
@@ -0,0 +1,20 @@
+package tables
+
+import "go.riyazali.net/sqlite"
+
+// Provider is implemented by third-party packages contributing their own tables and functions,
+// (an internal Gerrit provider for instance) so they can be added to askgit without forking it.
+// Providers are either compiled in, with WithProviders, or loaded from Go plugins (see pkg/plugins).
+type Provider interface {
+	// Name identifies the provider in error messages
+	Name() string
+
+	// Register registers the provider's modules and functions with ext.
+	// opt holds the options the extension was configured with, such as its context values.
+	Register(ext *sqlite.ExtensionApi, opt *Options) error
+}
+
+// WithProviders configures the extension to also register the tables and functions of the given providers
+func WithProviders(providers ...Provider) OptionFn {
+	return func(o *Options) { o.Providers = append(o.Providers, providers...) }
+}
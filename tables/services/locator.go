@@ -15,3 +15,18 @@ type RepoLocator interface {
 	// to the initialized git repository instance, or throw an error.
 	Open(ctx context.Context, path string) (*git.Repository, error)
 }
+
+// RepoPrefetcher is implemented by locators able to open repositories in the background,
+// ahead of the tables asking for them, so that several repositories are processed concurrently.
+type RepoPrefetcher interface {
+	// Prefetch starts opening the repository at path, if it isn't already.
+	// It doesn't wait for the repository to be opened, a later call to Open does.
+	Prefetch(ctx context.Context, path string)
+}
+
+// SnapshotLocator is implemented by locators pinning every repository to the commit its HEAD (or another ref)
+// resolved to when first opened, so that all the tables of a multi-statement run read the same snapshot.
+type SnapshotLocator interface {
+	// Snapshot returns the hash of the commit the repository at path is pinned to, if it's been opened and pinned
+	Snapshot(path string) (hash string, ok bool)
+}
@@ -4,13 +4,31 @@
 package tables
 
 import (
-	"context"
+	"net/http"
 	"time"
 
+	"github.com/askgitdev/askgit/pkg/audit"
+	"github.com/askgitdev/askgit/tables/internal/azuredevops"
+	"github.com/askgitdev/askgit/tables/internal/coverage"
+	"github.com/askgitdev/askgit/tables/internal/dataset"
+	"github.com/askgitdev/askgit/tables/internal/feed"
+	"github.com/askgitdev/askgit/tables/internal/filesystem"
 	"github.com/askgitdev/askgit/tables/internal/funcs"
+	"github.com/askgitdev/askgit/tables/internal/gerrit"
 	"github.com/askgitdev/askgit/tables/internal/git"
 	"github.com/askgitdev/askgit/tables/internal/git/native"
 	"github.com/askgitdev/askgit/tables/internal/github"
+	"github.com/askgitdev/askgit/tables/internal/jenkins"
+	"github.com/askgitdev/askgit/tables/internal/mbox"
+	"github.com/askgitdev/askgit/tables/internal/mentions"
+	"github.com/askgitdev/askgit/tables/internal/opsgenie"
+	"github.com/askgitdev/askgit/tables/internal/pagerduty"
+	"github.com/askgitdev/askgit/tables/internal/phabricator"
+	"github.com/askgitdev/askgit/tables/internal/rest"
+	"github.com/askgitdev/askgit/tables/internal/sentry"
+	"github.com/askgitdev/askgit/tables/internal/sonarqube"
+	"github.com/askgitdev/askgit/tables/internal/stackexchange"
+	"github.com/askgitdev/askgit/tables/internal/tz"
 	"github.com/pkg/errors"
 	"github.com/shurcooL/githubv4"
 	"go.riyazali.net/sqlite"
@@ -24,8 +42,22 @@ func RegisterFn(fns ...OptionFn) func(ext *sqlite.ExtensionApi) (_ sqlite.ErrorC
 		fn(opt)
 	}
 
+	tz.SetLocation(opt.Timezone)
+
+	// the GitHub rate limiter is shared by every connection, so that the requests of all tables are queued together
+	var githubLimiter = github.NewLimiter(rate.NewLimiter(rate.Every(1*time.Second), github.GetGithubReqPerSecondFromCtx(opt.Context)))
+
+	// so are the responses REST requests are made conditional on
+	var etags = &rest.ETags{}
+
 	// return an extension function that register modules with sqlite when this package is loaded
 	return func(ext *sqlite.ExtensionApi) (_ sqlite.ErrorCode, err error) {
+		// the requests of the tables of this connection are attributed to the query it's bound to, see audit.Conn
+		var scope = &audit.Scope{}
+		if err = ext.CreateFunction(audit.BindFunction, &bindQueryFn{scope: scope}); err != nil {
+			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q function", audit.BindFunction)
+		}
+
 		// register virtual table modules
 		var modules = map[string]sqlite.Module{
 			"commits": &git.LogModule{Locator: opt.Locator, Context: opt.Context},
@@ -33,6 +65,16 @@ func RegisterFn(fns ...OptionFn) func(ext *sqlite.ExtensionApi) (_ sqlite.ErrorC
 			"stats":   native.NewStatsModule(opt.Locator, opt.Context),
 			"files":   native.NewFilesModule(opt.Locator, opt.Context),
 			"blame":   native.NewBlameModule(opt.Locator, opt.Context),
+
+			"git_commit_parents":    git.NewCommitParentsModule(opt.Locator, opt.Context),
+			"rev_list":              git.NewRevListModule(opt.Locator, opt.Context),
+			"git_lfs_objects":       git.NewLFSObjectsModule(opt.Locator, opt.Context),
+			"git_commit_signatures": git.NewCommitSignaturesModule(opt.Locator, opt.Context),
+			"repos":                 git.NewReposModule(opt.Locator),
+
+			"coverage_report": coverage.NewReportModule(),
+			"fs_walk":         filesystem.NewWalkModule(),
+			"mbox_patches":    mbox.NewPatchesModule(),
 		}
 
 		for name, mod := range modules {
@@ -42,7 +84,11 @@ func RegisterFn(fns ...OptionFn) func(ext *sqlite.ExtensionApi) (_ sqlite.ErrorC
 		}
 
 		var fns = map[string]sqlite.Function{
-			"commit_from_tag": &git.CommitFromTagFn{},
+			"commit_from_tag":   &git.CommitFromTagFn{},
+			"is_merge":          &git.IsMergeFn{Locator: opt.Locator, Context: opt.Context},
+			"merge_base":        &git.MergeBaseFn{Locator: opt.Locator, Context: opt.Context},
+			"rev_parse":         &git.RevParseFn{Locator: opt.Locator, Context: opt.Context},
+			"signature_trusted": &git.SignatureTrustedFn{Locator: opt.Locator, Context: opt.Context},
 		}
 
 		for name, fn := range fns {
@@ -68,6 +114,8 @@ func RegisterFn(fns ...OptionFn) func(ext *sqlite.ExtensionApi) (_ sqlite.ErrorC
 				"enry_is_image":         &funcs.EnryIsImage{},
 				"enry_is_test":          &funcs.EnryIsTest{},
 				"enry_is_vendor":        &funcs.EnryIsVendor{},
+				"is_bot_login":          &funcs.IsBotLoginFn{},
+				"to_tz":                 &funcs.ToTz{},
 			}
 
 			// alias yaml_to_json => yml_to_json
@@ -82,15 +130,25 @@ func RegisterFn(fns ...OptionFn) func(ext *sqlite.ExtensionApi) (_ sqlite.ErrorC
 
 		// conditionally register the GitHub functionality
 		if opt.GitHub {
+			var githubHTTP = func() *http.Client {
+				var ts = opt.GitHubTokenSource
+				if ts == nil {
+					ts = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: github.GetGitHubTokenFromCtx(opt.Context)})
+				}
+				// the token source is consulted on every request (rather than being wrapped in a oauth2.ReuseTokenSource)
+				// so that sources caching and rotating tokens themselves are honoured
+				return &http.Client{Transport: &oauth2.Transport{Source: ts, Base: scope.Transport(opt.GitHubTransport)}}
+			}
+
 			githubOpts := &github.Options{
-				RateLimiter: rate.NewLimiter(rate.Every(1*time.Second), github.GetGithubReqPerSecondFromCtx(opt.Context)),
+				RateLimiter: githubLimiter,
+				Prefetch:    github.GetGithubPrefetchFromCtx(opt.Context),
+				Lenient:     github.GetGithubLenientFromCtx(opt.Context),
+				ExcludeBots: github.GetGithubExcludeBotsFromCtx(opt.Context),
 				Client: func() *githubv4.Client {
-					httpClient := oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(
-						&oauth2.Token{AccessToken: github.GetGitHubTokenFromCtx(opt.Context)},
-					))
-					client := githubv4.NewClient(httpClient)
-					return client
+					return githubv4.NewClient(githubHTTP())
 				},
+				HTTP: githubHTTP,
 			}
 
 			if opt.GitHubClientGetter != nil {
@@ -98,11 +156,25 @@ func RegisterFn(fns ...OptionFn) func(ext *sqlite.ExtensionApi) (_ sqlite.ErrorC
 			}
 
 			var modules = map[string]sqlite.Module{
-				"github_stargazers":    github.NewStargazersModule(githubOpts),
-				"github_starred_repos": github.NewStarredReposModule(githubOpts),
-				"github_user_repos":    github.NewUserReposModule(githubOpts),
-				"github_org_repos":     github.NewOrgReposModule(githubOpts),
-				"github_repo_issues":   github.NewIssuesModule(githubOpts),
+				"github_stargazers":          github.NewStargazersModule(githubOpts),
+				"github_starred_repos":       github.NewStarredReposModule(githubOpts),
+				"github_user_repos":          github.NewUserReposModule(githubOpts),
+				"github_org_repos":           github.NewOrgReposModule(githubOpts),
+				"github_repos":               github.NewReposModule(githubOpts),
+				"github_repo_issues":         github.NewIssuesModule(githubOpts),
+				"github_required_checks_gap": github.NewRequiredChecksModule(githubOpts),
+				"github_team_repos":          github.NewTeamReposModule(githubOpts),
+				"github_collaborators":       github.NewCollaboratorsModule(githubOpts),
+				"github_deploy_keys":         github.NewDeployKeysModule(githubOpts),
+				"github_repo_webhooks":       github.NewWebhooksModule(githubOpts),
+				"github_repo_rulesets":       github.NewRulesetsModule(githubOpts),
+				"github_actions_secrets":     github.NewActionsSecretsModule(githubOpts),
+				"github_actions_variables":   github.NewActionsVariablesModule(githubOpts),
+				"github_self_hosted_runners": github.NewRunnersModule(githubOpts),
+				"github_actions_cache_usage": github.NewCacheUsageModule(githubOpts),
+				"github_actions_billing":     github.NewBillingModule(githubOpts),
+				"github_workflow_jobs":       github.NewWorkflowJobsModule(githubOpts),
+				"github_workflow_steps":      github.NewWorkflowStepsModule(githubOpts),
 			}
 
 			// register GitHub tables
@@ -124,6 +196,71 @@ func RegisterFn(fns ...OptionFn) func(ext *sqlite.ExtensionApi) (_ sqlite.ErrorC
 			}
 		}
 
+		// the tables of other code review and hosting services take the address of the server (or an export) as an argument,
+		// and don't make any request until queried, so they're always registered. Their requests made again, by the queries
+		// of a server or of a sync polling an API for instance, are conditional on the responses received before.
+		var client = &rest.Client{HTTP: &http.Client{Transport: scope.Transport(opt.Transport)}, ETags: etags}
+
+		var gerritOpts = &gerrit.Options{Client: client, Context: opt.Context}
+		var phabricatorOpts = &phabricator.Options{Client: client, Context: opt.Context}
+		var azureOpts = &azuredevops.Options{Client: client, Context: opt.Context}
+		var sentryOpts = &sentry.Options{Client: client, Context: opt.Context}
+		var restModules = map[string]sqlite.Module{
+			"gerrit_changes":        gerrit.NewChangesModule(gerritOpts),
+			"gerrit_patchsets":      gerrit.NewPatchsetsModule(gerritOpts),
+			"gerrit_labels":         gerrit.NewLabelsModule(gerritOpts),
+			"phabricator_revisions": phabricator.NewRevisionsModule(phabricatorOpts),
+			"phabricator_reviewers": phabricator.NewReviewersModule(phabricatorOpts),
+			"azure_repos":           azuredevops.NewReposModule(azureOpts),
+			"azure_pull_requests":   azuredevops.NewPullRequestsModule(azureOpts),
+			"azure_pipeline_runs":   azuredevops.NewPipelineRunsModule(azureOpts),
+			"jenkins_builds":        jenkins.NewBuildsModule(&jenkins.Options{Client: client, Context: opt.Context}),
+			"pagerduty_incidents":   pagerduty.NewIncidentsModule(&pagerduty.Options{Client: client, Context: opt.Context}),
+			"opsgenie_incidents":    opsgenie.NewIncidentsModule(&opsgenie.Options{Client: client, Context: opt.Context}),
+			"sentry_issues":         sentry.NewIssuesModule(sentryOpts),
+			"sentry_releases":       sentry.NewReleasesModule(sentryOpts),
+			"codecov_coverage":      coverage.NewCodecovModule(&coverage.Options{Client: client, Context: opt.Context}),
+			"sonarqube_measures":    sonarqube.NewMeasuresModule(&sonarqube.Options{Client: client, Context: opt.Context}),
+
+			"stackexchange_questions": stackexchange.NewQuestionsModule(&stackexchange.Options{Client: client, Context: opt.Context}),
+			"hn_search":               mentions.NewHNSearchModule(&mentions.Options{Client: client}),
+			"reddit_search":           mentions.NewRedditSearchModule(&mentions.Options{Client: client}),
+			"feed":                    feed.NewFeedModule(&feed.Options{Client: client}),
+			"read_csv":                dataset.NewCSVModule(&dataset.Options{Client: client}),
+			"read_json":               dataset.NewJSONModule(&dataset.Options{Client: client}),
+			"http_json":               dataset.NewHTTPModule(&dataset.Options{Client: client}),
+			"zip_entries":             filesystem.NewZipModule(&filesystem.Options{Client: client}),
+			"tar_entries":             filesystem.NewTarModule(&filesystem.Options{Client: client}),
+		}
+
+		for name, mod := range restModules {
+			if err = ext.CreateModule(name, mod); err != nil {
+				return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q module", name)
+			}
+		}
+
+		// register third-party providers after the built-in tables and functions
+		for _, provider := range opt.Providers {
+			if err = provider.Register(ext, opt); err != nil {
+				return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register provider %q", provider.Name())
+			}
+		}
+
 		return sqlite.SQLITE_OK, nil
 	}
 }
+
+// bindQueryFn implements the askgit_bind_query scalar sql function, which binds the scope of a connection
+// to the query registered under the id it's passed, or unbinds it if passed NULL
+type bindQueryFn struct{ scope *audit.Scope }
+
+func (f *bindQueryFn) Args() int           { return 1 }
+func (f *bindQueryFn) Deterministic() bool { return false }
+func (f *bindQueryFn) Apply(context *sqlite.Context, value ...sqlite.Value) {
+	if value[0].IsNil() {
+		f.scope.Bind(nil)
+	} else {
+		f.scope.Bind(audit.Lookup(value[0].Int64()))
+	}
+	context.ResultNull()
+}
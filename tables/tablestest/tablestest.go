@@ -0,0 +1,95 @@
+// Package tablestest provides helpers to write integration style tests for tables backed by an API,
+// that run offline in CI. API responses are recorded once into a fixture (a go-vcr cassette) and replayed
+// afterwards, and query results can be compared against golden files.
+//
+//	func TestIssues(t *testing.T) {
+//		rt, stop := tablestest.Transport(t, "fixtures", httpClient.Transport)
+//		defer stop()
+//		httpClient.Transport = rt
+//
+//		rows, err := db.Query("SELECT number, title FROM github_repo_issues('askgitdev/askgit')")
+//		...
+//		tablestest.Golden(t, "fixtures", rows)
+//	}
+package tablestest
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/askgitdev/askgit/pkg/query"
+	"github.com/askgitdev/askgit/pkg/replay"
+)
+
+// UpdateEnv is the environment variable that, when set, makes Golden rewrite golden files rather than compare against them
+const UpdateEnv = "ASKGIT_UPDATE_GOLDEN"
+
+// Transport returns a transport replaying the fixture of the running test, dir/<test name>.yaml.
+// If the fixture doesn't exist, requests are made through base and recorded into it instead.
+// The returned function saves the fixture, and must be called once the test is done with the transport.
+func Transport(t *testing.T, dir string, base http.RoundTripper) (http.RoundTripper, func()) {
+	t.Helper()
+
+	var path = filepath.Join(dir, t.Name()+".yaml")
+	var mode = replay.Replay
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		mode = replay.Record
+	}
+
+	r, err := replay.Open(path, mode, base)
+	if err != nil {
+		t.Fatalf("failed to open fixture %s: %v", path, err)
+	}
+
+	return r, func() {
+		if err := r.Stop(); err != nil {
+			t.Fatalf("failed to save fixture %s: %v", path, err)
+		}
+	}
+}
+
+// Golden compares the contents of rows to the golden file of the running test, dir/<test name>.golden.json.
+// If the environment variable named by UpdateEnv is set, the golden file is (re-)written instead.
+func Golden(t *testing.T, dir string, rows *sql.Rows) {
+	t.Helper()
+
+	res, err := query.Collect(rows, -1)
+	if err != nil {
+		t.Fatalf("failed to read rows: %v", err)
+	}
+
+	got, err := json.MarshalIndent(struct {
+		Columns []string        `json:"columns"`
+		Rows    [][]interface{} `json:"rows"`
+	}{res.Columns, res.Rows}, "", "  ")
+	if err != nil {
+		t.Fatalf("failed to encode rows: %v", err)
+	}
+	got = append(got, '\n')
+
+	var path = filepath.Join(dir, t.Name()+".golden.json")
+	if os.Getenv(UpdateEnv) != "" {
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			err = ioutil.WriteFile(path, got, 0644)
+		}
+		if err != nil {
+			t.Fatalf("failed to write golden file %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file %s (set %s=1 to create it): %v", path, UpdateEnv, err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Fatalf("rows don't match golden file %s (set %s=1 to update it)\ngot:\n%s\nwant:\n%s", path, UpdateEnv, got, want)
+	}
+}
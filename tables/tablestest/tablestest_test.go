@@ -0,0 +1,38 @@
+package tablestest
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGolden(t *testing.T) {
+	dir, err := ioutil.TempDir("", "askgit-golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, mock, _ := sqlmock.New()
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery("select").WillReturnRows(sqlmock.NewRows([]string{"login", "stars"}).AddRow("patrickdevivo", 42))
+	}
+
+	defer os.Unsetenv(UpdateEnv)
+
+	// the first run writes the golden file, the second compares against it
+	for _, update := range []string{"1", ""} {
+		if err = os.Setenv(UpdateEnv, update); err != nil {
+			t.Fatal(err)
+		}
+
+		rows, err := db.Query("select")
+		if err != nil {
+			t.Fatal(err)
+		}
+		Golden(t, dir, rows)
+		rows.Close()
+	}
+}
@@ -0,0 +1,10 @@
+// +build js,wasm
+
+// askgit can't be built for WebAssembly (GOOS=js GOARCH=wasm): queries run on SQLite through cgo
+// (mattn/go-sqlite3 and the go.riyazali.net/sqlite extension API), and the stats, files and blame tables
+// use libgit2 through git2go, none of which is available without cgo. A browser playground would need
+// SQLite compiled to WebAssembly, with the tables registered through its extension API instead.
+package main
+
+// fail the build with an error naming the reason, rather than only with those of the cgo packages
+var _ = askgit_cannot_be_built_for_js_wasm_as_it_requires_cgo